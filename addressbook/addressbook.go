@@ -0,0 +1,299 @@
+// Package addressbook resolves @name aliases of addresses per chain. The
+// aliases are defined under the addressbook key of the config file, by chain
+// ID or network preset name:
+//
+//	addressbook:
+//	  137:
+//	    treasury: "0x..."
+//	  amoy:
+//	    treasury: "0x..."
+//
+// The same alias can point at a different address on every chain, so the
+// chain of the RPC endpoint decides which one is used.
+package addressbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// Prefix marks an alias where an address is expected.
+const Prefix = "@"
+
+// configKey is the key of the address book in the config file.
+const configKey = "addressbook"
+
+// Help describes the aliases and names for the flags that accept them.
+const Help = "Can be an @alias of the address book or a name like vitalik.eth"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Book holds the addresses by chain ID and alias name. Names are lower case,
+// since they're matched case-insensitively.
+type Book map[uint64]map[string]common.Address
+
+// Entry is an alias of an address on a chain.
+type Entry struct {
+	ChainID uint64         `json:"chainId"`
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+}
+
+// IsAlias reports whether the value is an alias rather than an address.
+func IsAlias(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Valid reports whether the value is an address or has the syntax of an
+// alias or a name. Whether they exist is only known once the chain is.
+func Valid(s string) bool {
+	if IsAlias(s) {
+		return ValidName(strings.TrimPrefix(s, Prefix))
+	}
+	return common.IsHexAddress(s) || util.IsName(s)
+}
+
+// ValidName reports whether the name can be used as an alias.
+func ValidName(name string) bool {
+	return namePattern.MatchString(strings.ToLower(name))
+}
+
+// Path returns the config file that the address book is read from and
+// written to, which is the one of --config or $HOME/.polygon-cli.yaml.
+func Path() (string, error) {
+	if p := viper.ConfigFileUsed(); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".polygon-cli.yaml"), nil
+}
+
+// Load reads the address book of the config file. A missing config file is an
+// empty address book.
+func Load(path string) (Book, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(Book), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		AddressBook map[string]map[string]string `yaml:"addressbook"`
+	}
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse the address book of %s: %w", path, err)
+	}
+
+	book := make(Book)
+	for chain, names := range config.AddressBook {
+		chainID, err := parseChain(chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain of the address book of %s: %w", path, err)
+		}
+		for name, address := range names {
+			if !ValidName(name) {
+				return nil, fmt.Errorf("the alias %s of chain %s isn't a valid name", name, chain)
+			}
+			if !common.IsHexAddress(address) {
+				return nil, fmt.Errorf("the address %s of %s%s isn't valid", address, Prefix, name)
+			}
+			book.Set(chainID, name, common.HexToAddress(address))
+		}
+	}
+	return book, nil
+}
+
+// parseChain returns the chain ID of a chain ID or a network preset name.
+func parseChain(chain string) (uint64, error) {
+	if chainID, err := strconv.ParseUint(chain, 0, 64); err == nil {
+		return chainID, nil
+	}
+	n, err := util.LookupNetwork(chain)
+	if err != nil {
+		return 0, err
+	}
+	return n.ChainID, nil
+}
+
+// Save writes the address book to the config file, keyed by chain ID. The
+// rest of the config file is kept as it is.
+func Save(path string, book Book) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err = yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("unable to parse %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("the config file %s isn't a mapping", path)
+	}
+
+	var value yaml.Node
+	if err = value.Encode(book.entries()); err != nil {
+		return err
+	}
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == configKey {
+			root.Content[i+1] = &value
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: configKey}, &value)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0600)
+}
+
+// entries returns the address book in the layout of the config file.
+func (b Book) entries() map[uint64]map[string]string {
+	out := make(map[uint64]map[string]string, len(b))
+	for chainID, names := range b {
+		if len(names) == 0 {
+			continue
+		}
+		out[chainID] = make(map[string]string, len(names))
+		for name, address := range names {
+			out[chainID][name] = address.Hex()
+		}
+	}
+	return out
+}
+
+// Set adds or replaces the alias of the chain.
+func (b Book) Set(chainID uint64, name string, address common.Address) {
+	name = strings.ToLower(strings.TrimPrefix(name, Prefix))
+	if b[chainID] == nil {
+		b[chainID] = make(map[string]common.Address)
+	}
+	b[chainID][name] = address
+}
+
+// Remove removes the alias of the chain, and reports whether it existed.
+func (b Book) Remove(chainID uint64, name string) bool {
+	name = strings.ToLower(strings.TrimPrefix(name, Prefix))
+	if _, ok := b[chainID][name]; !ok {
+		return false
+	}
+	delete(b[chainID], name)
+	if len(b[chainID]) == 0 {
+		delete(b, chainID)
+	}
+	return true
+}
+
+// Lookup returns the address of the alias on the chain. The alias can be
+// given with or without the prefix.
+func (b Book) Lookup(chainID uint64, name string) (common.Address, bool) {
+	address, ok := b[chainID][strings.ToLower(strings.TrimPrefix(name, Prefix))]
+	return address, ok
+}
+
+// Entries returns the aliases of the chain, or of all the chains if chainID is
+// 0, sorted by chain and name.
+func (b Book) Entries(chainID uint64) []Entry {
+	var entries []Entry
+	for id, names := range b {
+		if chainID != 0 && id != chainID {
+			continue
+		}
+		for name, address := range names {
+			entries = append(entries, Entry{ChainID: id, Name: name, Address: address})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ChainID != entries[j].ChainID {
+			return entries[i].ChainID < entries[j].ChainID
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Resolver resolves the addresses, aliases, and names given to a command
+// against the chain of its RPC endpoint. The address book and the chain ID are
+// only read once an alias is resolved, so commands that are only given
+// addresses don't need a config file.
+type Resolver struct {
+	rpc     *ethrpc.Client
+	book    Book
+	chainID uint64
+	names   *util.Names
+}
+
+// NewResolver returns a resolver for the chain of the RPC endpoint.
+func NewResolver(rpc *ethrpc.Client) *Resolver {
+	return &Resolver{rpc: rpc}
+}
+
+// Resolve returns the address, or the address of the alias or the name on the
+// chain.
+func (r *Resolver) Resolve(ctx context.Context, s string) (common.Address, error) {
+	switch {
+	case IsAlias(s):
+	case util.IsName(s):
+		if r.names == nil {
+			r.names = util.NewNames(r.rpc)
+		}
+		return r.names.Address(ctx, s)
+	case common.IsHexAddress(s):
+		return common.HexToAddress(s), nil
+	default:
+		return common.Address{}, fmt.Errorf("%s isn't an address", s)
+	}
+
+	if r.book == nil {
+		path, err := Path()
+		if err != nil {
+			return common.Address{}, err
+		}
+		if r.book, err = Load(path); err != nil {
+			return common.Address{}, err
+		}
+	}
+	if r.chainID == 0 {
+		var chainID hexutil.Uint64
+		if err := r.rpc.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+			return common.Address{}, fmt.Errorf("unable to get the chain ID to resolve %s: %w", s, err)
+		}
+		r.chainID = uint64(chainID)
+	}
+	address, ok := r.book.Lookup(r.chainID, s)
+	if !ok {
+		return common.Address{}, fmt.Errorf("the alias %s isn't in the address book of chain %d", s, r.chainID)
+	}
+	return address, nil
+}
@@ -0,0 +1,248 @@
+package addressbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type addressBookParams struct {
+	ChainID uint64
+	RPCURL  string
+	JSON    bool
+}
+
+var (
+	//go:embed usage.md
+	usage            string
+	inputAddressBook addressBookParams
+)
+
+// AddressBookCmd manages the aliases of addresses per chain.
+var AddressBookCmd = &cobra.Command{
+	Use:     "addressbook",
+	Aliases: []string{"ab"},
+	Short:   "Manage named addresses per chain that can be used as @aliases wherever an address is accepted.",
+	Long:    usage,
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set alias address",
+	Short: "Add or replace an alias of an address on a chain.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		if !addressbook.ValidName(trimAlias(args[0])) {
+			return fmt.Errorf("the alias %s isn't valid, it can only have letters, digits, dots, dashes, and underscores", args[0])
+		}
+		if !ethcommon.IsHexAddress(args[1]) {
+			return fmt.Errorf("the address %s isn't valid", args[1])
+		}
+		return requireChainID()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, book, err := load()
+		if err != nil {
+			return err
+		}
+		address := ethcommon.HexToAddress(args[1])
+		book.Set(inputAddressBook.ChainID, args[0], address)
+		if err = addressbook.Save(path, book); err != nil {
+			return err
+		}
+		log.Info().Str("alias", addressbook.Prefix+trimAlias(args[0])).Str("address", address.Hex()).Uint64("chainID", inputAddressBook.ChainID).Str("config", path).Msg("Saved the alias")
+		return nil
+	},
+}
+
+var removeCmd = &cobra.Command{
+	Use:     "remove alias",
+	Aliases: []string{"rm"},
+	Short:   "Remove an alias from a chain.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return requireChainID()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, book, err := load()
+		if err != nil {
+			return err
+		}
+		if !book.Remove(inputAddressBook.ChainID, args[0]) {
+			return fmt.Errorf("the alias %s isn't in the address book of chain %d", args[0], inputAddressBook.ChainID)
+		}
+		if err = addressbook.Save(path, book); err != nil {
+			return err
+		}
+		log.Info().Str("alias", addressbook.Prefix+trimAlias(args[0])).Uint64("chainID", inputAddressBook.ChainID).Str("config", path).Msg("Removed the alias")
+		return nil
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the aliases of a chain, or of all the chains.",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, book, err := load()
+		if err != nil {
+			return err
+		}
+		entries := book.Entries(inputAddressBook.ChainID)
+		if inputAddressBook.JSON {
+			if entries == nil {
+				entries = []addressbook.Entry{}
+			}
+			out, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Chain", "Alias", "Address"})
+		for _, e := range entries {
+			t.AppendRow(table.Row{chainName(e.ChainID), addressbook.Prefix + e.Name, e.Address.Hex()})
+		}
+		t.Render()
+		return nil
+	},
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve alias|name",
+	Short: "Print the address of an alias or a name on the chain of --chain-id, or of the RPC endpoint.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Names like vitalik.eth can only be resolved on chain, and aliases
+		// are given with or without the prefix.
+		alias := args[0]
+		if !util.IsName(alias) {
+			alias = addressbook.Prefix + trimAlias(alias)
+		}
+		if inputAddressBook.ChainID != 0 && !util.IsName(alias) {
+			_, book, err := load()
+			if err != nil {
+				return err
+			}
+			address, ok := book.Lookup(inputAddressBook.ChainID, alias)
+			if !ok {
+				return fmt.Errorf("the alias %s isn't in the address book of chain %d", alias, inputAddressBook.ChainID)
+			}
+			fmt.Println(address.Hex())
+			return nil
+		}
+
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputAddressBook.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+		address, err := addressbook.NewResolver(rpc).Resolve(ctx, alias)
+		if err != nil {
+			return err
+		}
+		fmt.Println(address.Hex())
+		return nil
+	},
+}
+
+var reverseCmd = &cobra.Command{
+	Use:   "reverse address",
+	Short: "Print the primary name of an address with the name service of the RPC endpoint.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if !ethcommon.IsHexAddress(args[0]) {
+			return fmt.Errorf("the address %s isn't valid", args[0])
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputAddressBook.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+		address := ethcommon.HexToAddress(args[0])
+		name, err := util.NewNames(rpc).Name(ctx, address)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s doesn't have a primary name", address.Hex())
+		}
+		fmt.Println(name)
+		return nil
+	},
+}
+
+func init() {
+	setCmd.Flags().Uint64Var(&inputAddressBook.ChainID, "chain-id", 0, "The chain ID of the alias")
+	removeCmd.Flags().Uint64Var(&inputAddressBook.ChainID, "chain-id", 0, "The chain ID of the alias")
+	listCmd.Flags().Uint64Var(&inputAddressBook.ChainID, "chain-id", 0, "Only list the aliases of this chain ID")
+	listCmd.Flags().BoolVar(&inputAddressBook.JSON, "json", false, "Print the aliases as JSON")
+	resolveCmd.Flags().Uint64Var(&inputAddressBook.ChainID, "chain-id", 0, "The chain ID to resolve the alias on, instead of the chain of the RPC endpoint. Names are always resolved on the RPC endpoint")
+	resolveCmd.Flags().StringVar(&inputAddressBook.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url whose chain the alias or name is resolved on")
+	reverseCmd.Flags().StringVar(&inputAddressBook.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url whose name service is used")
+
+	AddressBookCmd.AddCommand(setCmd, removeCmd, listCmd, resolveCmd, reverseCmd)
+}
+
+func requireChainID() error {
+	if inputAddressBook.ChainID == 0 {
+		return fmt.Errorf("the chain ID is needed, set it with --chain-id or --network")
+	}
+	return nil
+}
+
+func load() (string, addressbook.Book, error) {
+	path, err := addressbook.Path()
+	if err != nil {
+		return "", nil, err
+	}
+	book, err := addressbook.Load(path)
+	return path, book, err
+}
+
+// trimAlias accepts aliases with and without the prefix, since the shell
+// doesn't treat @ specially.
+func trimAlias(alias string) string {
+	if addressbook.IsAlias(alias) {
+		return alias[len(addressbook.Prefix):]
+	}
+	return alias
+}
+
+// chainName returns the chain ID along with the name of its network preset,
+// if there's one.
+func chainName(chainID uint64) string {
+	for _, name := range util.NetworkNames() {
+		if n, _ := util.LookupNetwork(name); n.ChainID == chainID {
+			return fmt.Sprintf("%d (%s)", chainID, name)
+		}
+	}
+	return strconv.FormatUint(chainID, 10)
+}
@@ -0,0 +1,342 @@
+package approvals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/contracts"
+	"github.com/maticnetwork/polygon-cli/signer"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	approvalsParams struct {
+		RPCURL     string
+		Address    string
+		FromBlock  uint64
+		ToBlock    uint64
+		BlockRange uint64
+		Tokens     []string
+		JSON       bool
+		Revoke     bool
+		PrivateKey string
+		Send       bool
+		signer     signer.Signer
+	}
+
+	// allowance is a live approval of a spender to spend the tokens of the
+	// owner, with the last Approval event that was emitted for it.
+	allowance struct {
+		Token     ethcommon.Address `json:"token"`
+		Symbol    string            `json:"symbol,omitempty"`
+		Spender   ethcommon.Address `json:"spender"`
+		Amount    *big.Int          `json:"amount"`
+		Unlimited bool              `json:"unlimited"`
+		Block     uint64            `json:"block"`
+		TxHash    ethcommon.Hash    `json:"txHash"`
+	}
+
+	// revokeTx is an unsigned transaction that sets an allowance to zero.
+	revokeTx struct {
+		From    ethcommon.Address `json:"from"`
+		To      ethcommon.Address `json:"to"`
+		Data    hexutil.Bytes     `json:"data"`
+		Spender ethcommon.Address `json:"spender"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage          string
+	inputApprovals approvalsParams
+
+	// approvalTopic is the topic of Approval(address,address,uint256). ERC-721
+	// tokens emit an event with the same signature, but with the token ID as
+	// a third indexed topic, so those logs are skipped.
+	approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+
+	// unlimitedThreshold is the amount above which an allowance is considered
+	// unlimited. Most tokens don't decrease allowances of max uint256, and
+	// others decrease them from a huge amount, so anything above 2^128 counts.
+	unlimitedThreshold = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+// ApprovalsCmd scans the ERC-20 approvals of an address and revokes them.
+var ApprovalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "List the live ERC-20 allowances of an address and revoke them.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputApprovals.Address == "" && inputApprovals.PrivateKey == "" {
+			return errors.New("either an address or a private key is required")
+		}
+		if inputApprovals.Address != "" && !ethcommon.IsHexAddress(inputApprovals.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputApprovals.Address)
+		}
+		for _, t := range inputApprovals.Tokens {
+			if !ethcommon.IsHexAddress(t) {
+				return fmt.Errorf("the token %s isn't a valid address", t)
+			}
+		}
+		if inputApprovals.BlockRange == 0 {
+			return errors.New("the block range must be at least one")
+		}
+		if inputApprovals.Send && inputApprovals.PrivateKey == "" {
+			return errors.New("a private key is required to send revoke transactions")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		owner := ethcommon.HexToAddress(inputApprovals.Address)
+		if inputApprovals.PrivateKey != "" {
+			s, err := signer.New(ctx, inputApprovals.PrivateKey)
+			if err != nil {
+				return fmt.Errorf("unable to load the signer: %w", err)
+			}
+			inputApprovals.signer = s
+			from := s.Address()
+			if inputApprovals.Address != "" && from != owner {
+				return fmt.Errorf("the private key is for %s rather than %s", from.Hex(), owner.Hex())
+			}
+			owner = from
+		}
+
+		rpc, err := util.DialRPC(ctx, inputApprovals.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+		ec := ethclient.NewClient(rpc)
+
+		allowances, err := scanAllowances(ctx, ec, owner)
+		if err != nil {
+			return err
+		}
+
+		if inputApprovals.Send {
+			return sendRevokes(ctx, ec, allowances)
+		}
+		if inputApprovals.Revoke {
+			return printRevokes(owner, allowances)
+		}
+		if inputApprovals.JSON {
+			out, err := json.MarshalIndent(allowances, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		printTable(allowances)
+		return nil
+	},
+}
+
+func init() {
+	flagSet := ApprovalsCmd.Flags()
+	flagSet.StringVar(&inputApprovals.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputApprovals.Address, "address", "", "The address of the token owner")
+	flagSet.Uint64Var(&inputApprovals.FromBlock, "from-block", 0, "The first block to scan for Approval events")
+	flagSet.Uint64Var(&inputApprovals.ToBlock, "to-block", 0, "The last block to scan for Approval events (default latest)")
+	flagSet.Uint64Var(&inputApprovals.BlockRange, "block-range", 10000, "The number of blocks scanned per eth_getLogs request")
+	flagSet.StringSliceVar(&inputApprovals.Tokens, "tokens", nil, "Only scan the approvals of these tokens")
+	flagSet.BoolVar(&inputApprovals.JSON, "json", false, "Output the allowances as JSON")
+	flagSet.BoolVar(&inputApprovals.Revoke, "revoke", false, "Output unsigned transactions that revoke the allowances as JSON")
+	flagSet.StringVar(&inputApprovals.PrivateKey, "private-key", "", "The hex encoded private key of the owner used to send revoke transactions. "+util.SecretHelp+". "+signer.Help)
+	flagSet.BoolVar(&inputApprovals.Send, "send", false, "Sign and send the revoke transactions with the private key")
+}
+
+// scanAllowances finds the spenders the owner approved and returns the ones
+// that still have an allowance, sorted by token and spender.
+func scanAllowances(ctx context.Context, ec *ethclient.Client, owner ethcommon.Address) ([]allowance, error) {
+	to := inputApprovals.ToBlock
+	if to == 0 {
+		latest, err := ec.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		to = latest
+	}
+
+	query := ethereum.FilterQuery{
+		Topics: [][]ethcommon.Hash{{approvalTopic}, {ethcommon.BytesToHash(owner.Bytes())}},
+	}
+	for _, t := range inputApprovals.Tokens {
+		query.Addresses = append(query.Addresses, ethcommon.HexToAddress(t))
+	}
+
+	// The last event of every token and spender pair.
+	type pair struct{ token, spender ethcommon.Address }
+	events := make(map[pair]types.Log)
+	for from := inputApprovals.FromBlock; from <= to; from += inputApprovals.BlockRange {
+		end := from + inputApprovals.BlockRange - 1
+		if end > to {
+			end = to
+		}
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(end)
+
+		logs, err := ec.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the logs of blocks %d to %d: %w", from, end, err)
+		}
+		log.Debug().Uint64("from", from).Uint64("to", end).Int("logs", len(logs)).Msg("Scanned blocks")
+
+		for _, l := range logs {
+			if len(l.Topics) != 3 {
+				continue
+			}
+			events[pair{l.Address, ethcommon.BytesToAddress(l.Topics[2].Bytes())}] = l
+		}
+	}
+	log.Info().Int("pairs", len(events)).Msg("Found approved spenders")
+
+	var (
+		allowances = make([]allowance, 0)
+		symbols    = make(map[ethcommon.Address]string)
+		opts       = &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(to)}
+	)
+	for p, l := range events {
+		token, err := contracts.NewERC20Caller(p.token, ec)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := token.Allowance(opts, owner, p.spender)
+		if err != nil {
+			log.Warn().Err(err).Str("token", p.token.Hex()).Msg("Unable to get the allowance")
+			continue
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		symbol, ok := symbols[p.token]
+		if !ok {
+			// Not every token implements the optional metadata.
+			symbol, _ = token.Symbol(opts)
+			symbols[p.token] = symbol
+		}
+		allowances = append(allowances, allowance{
+			Token:     p.token,
+			Symbol:    symbol,
+			Spender:   p.spender,
+			Amount:    amount,
+			Unlimited: amount.Cmp(unlimitedThreshold) >= 0,
+			Block:     l.BlockNumber,
+			TxHash:    l.TxHash,
+		})
+	}
+
+	sort.Slice(allowances, func(i, j int) bool {
+		if allowances[i].Token != allowances[j].Token {
+			return allowances[i].Token.Hex() < allowances[j].Token.Hex()
+		}
+		return allowances[i].Spender.Hex() < allowances[j].Spender.Hex()
+	})
+	return allowances, nil
+}
+
+func printTable(allowances []allowance) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Token", "Symbol", "Spender", "Allowance", "Block"})
+	for _, a := range allowances {
+		amount := a.Amount.String()
+		if a.Unlimited {
+			amount = "unlimited"
+		}
+		t.AppendRow(table.Row{a.Token.Hex(), a.Symbol, a.Spender.Hex(), amount, a.Block})
+	}
+	t.Render()
+}
+
+func revokeData(spender ethcommon.Address) ([]byte, error) {
+	erc20, err := contracts.ERC20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return erc20.Pack("approve", spender, big.NewInt(0))
+}
+
+func printRevokes(owner ethcommon.Address, allowances []allowance) error {
+	txs := make([]revokeTx, 0, len(allowances))
+	for _, a := range allowances {
+		data, err := revokeData(a.Spender)
+		if err != nil {
+			return err
+		}
+		txs = append(txs, revokeTx{From: owner, To: a.Token, Data: data, Spender: a.Spender})
+	}
+
+	out, err := json.MarshalIndent(txs, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// sendRevokes sends a transaction per allowance that sets it to zero. The
+// transactions are sent one after the other and their receipts are awaited.
+func sendRevokes(ctx context.Context, ec *ethclient.Client, allowances []allowance) error {
+	if len(allowances) == 0 {
+		log.Info().Msg("There are no allowances to revoke")
+		return nil
+	}
+
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	opts, err := signer.NewTransactor(inputApprovals.signer, chainID)
+	if err != nil {
+		return err
+	}
+	opts.Context = ctx
+
+	for _, a := range allowances {
+		token, err := contracts.NewERC20Transactor(a.Token, ec)
+		if err != nil {
+			return err
+		}
+		tx, err := token.Approve(opts, a.Spender, big.NewInt(0))
+		if err != nil {
+			return fmt.Errorf("unable to revoke the allowance of %s for %s: %w", a.Spender.Hex(), a.Token.Hex(), err)
+		}
+
+		receipt, err := bind.WaitMined(ctx, ec, tx)
+		if err != nil {
+			return err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("the revoke transaction %s failed", tx.Hash().Hex())
+		}
+		log.Info().
+			Str("token", a.Token.Hex()).
+			Str("spender", a.Spender.Hex()).
+			Str("tx", tx.Hash().Hex()).
+			Msg("Revoked allowance")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+//go:embed usage.md
+var usage string
+
+// output is the file the encode and decode commands write to.
+var output string
+
+// BlobCmd represents the blob command
+var BlobCmd = &cobra.Command{
+	Use:   "blob",
+	Short: "Encode data into EIP-4844 blobs and compute their KZG commitments and proofs.",
+	Long:  usage,
+}
+
+func init() {
+	BlobCmd.AddCommand(encodeCmd)
+	BlobCmd.AddCommand(decodeCmd)
+	BlobCmd.AddCommand(commitCmd)
+	BlobCmd.AddCommand(verifyCmd)
+
+	for _, cmd := range []*cobra.Command{encodeCmd, decodeCmd} {
+		cmd.Flags().StringVarP(&output, "output", "o", "", "The file to write to rather than stdout")
+	}
+}
+
+// readInput returns the contents of the file in the arguments, or of stdin if
+// there's no argument.
+func readInput(args []string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if len(args) > 0 {
+		data, err = os.ReadFile(args[0])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the input: %w", err)
+	}
+	return data, nil
+}
+
+// readBlobs returns the blobs in the file or stdin, which are either hex
+// encoded with one blob per line, like encode writes them, or raw binary
+// blobs one after the other.
+func readBlobs(args []string) ([][]byte, error) {
+	data, err := readInput(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 && len(data)%util.BlobSize == 0 && !bytes.HasPrefix(data, []byte("0x")) {
+		var blobs [][]byte
+		for i := 0; i < len(data); i += util.BlobSize {
+			blobs = append(blobs, data[i:i+util.BlobSize])
+		}
+		return blobs, nil
+	}
+
+	var blobs [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 2*util.BlobSize+16), 2*util.BlobSize+16)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		blob, err := hexutil.Decode(text)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the blob on line %d: %w", line, err)
+		}
+		if len(blob) != util.BlobSize {
+			return nil, fmt.Errorf("the blob on line %d is %d bytes rather than %d", line, len(blob), util.BlobSize)
+		}
+		blobs = append(blobs, blob)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read the blobs: %w", err)
+	}
+	if len(blobs) == 0 {
+		return nil, fmt.Errorf("there are no blobs in the input")
+	}
+	return blobs, nil
+}
+
+// writeOutput writes the data to the output file, or stdout if there's none.
+func writeOutput(data []byte) error {
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(output, data, 0o644)
+}
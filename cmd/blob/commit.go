@@ -0,0 +1,63 @@
+package blob
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// committedBlob is what a blob transaction carries for a blob, along with
+// the blob itself.
+type committedBlob struct {
+	Index         int            `json:"index"`
+	Commitment    hexutil.Bytes  `json:"commitment"`
+	Proof         hexutil.Bytes  `json:"proof"`
+	VersionedHash ethcommon.Hash `json:"versionedHash"`
+}
+
+var commitCmd = &cobra.Command{
+	Use:   "commit [blobs file]",
+	Short: "Compute the KZG commitments, proofs, and versioned hashes of blobs.",
+	Long: `Compute the KZG commitment and proof of every blob, and the versioned hash of
+the commitment, which are what a blob transaction carries. The blobs are read
+from the file, or stdin if there's no file, either hex encoded with one blob
+per line or as raw binary blobs. The trusted setup of the Ethereum KZG
+ceremony is used.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blobs, err := readBlobs(args)
+		if err != nil {
+			return err
+		}
+
+		out := make([]committedBlob, 0, len(blobs))
+		for i, blob := range blobs {
+			commitment, err := util.BlobToCommitment(blob)
+			if err != nil {
+				return fmt.Errorf("unable to commit to blob %d: %w", i, err)
+			}
+			proof, err := util.ComputeBlobProof(blob, commitment)
+			if err != nil {
+				return fmt.Errorf("unable to compute the proof of blob %d: %w", i, err)
+			}
+			out = append(out, committedBlob{
+				Index:         i,
+				Commitment:    commitment,
+				Proof:         proof,
+				VersionedHash: util.KZGToVersionedHash(commitment),
+			})
+		}
+
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
@@ -0,0 +1,27 @@
+package blob
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+var decodeCmd = &cobra.Command{
+	Use:   "decode [blobs file]",
+	Short: "Decode blobs back into the data that was encoded.",
+	Long: `Decode the blobs that encode wrote back into the original data. The blobs are
+read from the file, or stdin if there's no file, either hex encoded with one
+blob per line or as raw binary blobs.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blobs, err := readBlobs(args)
+		if err != nil {
+			return err
+		}
+		data, err := util.DecodeBlobs(blobs)
+		if err != nil {
+			return err
+		}
+		return writeOutput(data)
+	},
+}
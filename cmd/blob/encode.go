@@ -0,0 +1,37 @@
+package blob
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+var encodeCmd = &cobra.Command{
+	Use:   "encode [file]",
+	Short: "Encode a file into blobs.",
+	Long: `Encode the file, or stdin if there's no file, into as many blobs as it takes and
+write them hex encoded, one blob per line. Every 32 byte field element holds 31
+bytes of the data after a zero byte, so that it's always less than the BLS
+modulus, and the data is followed by a 0x80 byte before the zero padding, so
+that decode can tell where it ends. A blob holds up to 126975 bytes.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+		blobs := util.EncodeBlobs(data)
+		log.Info().Int("bytes", len(data)).Int("blobs", len(blobs)).Msg("Encoded blobs")
+
+		var out bytes.Buffer
+		for _, blob := range blobs {
+			out.WriteString(hexutil.Encode(blob))
+			out.WriteByte('\n')
+		}
+		return writeOutput(out.Bytes())
+	},
+}
@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	verifyParams struct {
+		Commitments     []string
+		Proofs          []string
+		VersionedHashes []string
+	}
+
+	verifiedBlob struct {
+		Index         int            `json:"index"`
+		Valid         bool           `json:"valid"`
+		VersionedHash ethcommon.Hash `json:"versionedHash"`
+		Error         string         `json:"error,omitempty"`
+	}
+)
+
+var inputVerify verifyParams
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [blobs file]",
+	Short: "Verify the KZG proofs of blobs against their commitments.",
+	Long: `Verify the KZG proof of every blob against its commitment, and with
+--versioned-hash that the commitment hashes to the versioned hash. Pass one
+--commitment and --proof per blob, in the order of the blobs, which are read
+like commit reads them. The command fails if any of them isn't valid.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blobs, err := readBlobs(args)
+		if err != nil {
+			return err
+		}
+		if len(inputVerify.Commitments) != len(blobs) || len(inputVerify.Proofs) != len(blobs) {
+			return fmt.Errorf("expected a commitment and proof for each of the %d blobs", len(blobs))
+		}
+		if len(inputVerify.VersionedHashes) > 0 && len(inputVerify.VersionedHashes) != len(blobs) {
+			return fmt.Errorf("expected a versioned hash for each of the %d blobs", len(blobs))
+		}
+
+		out := make([]verifiedBlob, 0, len(blobs))
+		invalid := 0
+		for i, blob := range blobs {
+			result := verifiedBlob{Index: i}
+			if err := verifyBlob(blob, i, &result); err != nil {
+				result.Error = err.Error()
+				invalid++
+			} else {
+				result.Valid = true
+			}
+			out = append(out, result)
+		}
+
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		if invalid > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d of the %d blobs aren't valid", invalid, len(blobs))
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := verifyCmd.Flags()
+	flagSet.StringSliceVar(&inputVerify.Commitments, "commitment", nil, "The hex encoded KZG commitment of each blob")
+	flagSet.StringSliceVar(&inputVerify.Proofs, "proof", nil, "The hex encoded KZG proof of each blob")
+	flagSet.StringSliceVar(&inputVerify.VersionedHashes, "versioned-hash", nil, "The versioned hash each commitment has to hash to")
+}
+
+func verifyBlob(blob []byte, i int, result *verifiedBlob) error {
+	commitment, err := hexutil.Decode(inputVerify.Commitments[i])
+	if err != nil {
+		return fmt.Errorf("unable to decode the commitment: %w", err)
+	}
+	proof, err := hexutil.Decode(inputVerify.Proofs[i])
+	if err != nil {
+		return fmt.Errorf("unable to decode the proof: %w", err)
+	}
+	result.VersionedHash = util.KZGToVersionedHash(commitment)
+	if len(inputVerify.VersionedHashes) > 0 {
+		if expected := ethcommon.HexToHash(inputVerify.VersionedHashes[i]); expected != result.VersionedHash {
+			return fmt.Errorf("the commitment hashes to %s rather than %s", result.VersionedHash.Hex(), expected.Hex())
+		}
+	}
+	return util.VerifyBlobProof(blob, commitment, proof)
+}
@@ -0,0 +1,380 @@
+package blockstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	blockStatsParams struct {
+		URL       string
+		From      uint64
+		To        uint64
+		BatchSize uint64
+		Top       int
+		Output    string
+
+		JaipurBlock uint64
+	}
+
+	// distribution summarizes a value over the blocks of the range.
+	distribution struct {
+		Min  float64 `json:"min"`
+		Mean float64 `json:"mean"`
+		P50  float64 `json:"p50"`
+		P90  float64 `json:"p90"`
+		P99  float64 `json:"p99"`
+		Max  float64 `json:"max"`
+	}
+
+	producerStats struct {
+		Address      ethcommon.Address `json:"address"`
+		Blocks       uint64            `json:"blocks"`
+		EmptyBlocks  uint64            `json:"emptyBlocks"`
+		Transactions uint64            `json:"transactions"`
+		GasUsed      uint64            `json:"gasUsed"`
+	}
+
+	intervalCount struct {
+		Seconds uint64 `json:"seconds"`
+		Blocks  uint64 `json:"blocks"`
+	}
+
+	stats struct {
+		From         uint64           `json:"from"`
+		To           uint64           `json:"to"`
+		Blocks       uint64           `json:"blocks"`
+		EmptyBlocks  uint64           `json:"emptyBlocks"`
+		Transactions uint64           `json:"transactions"`
+		GasUsed      uint64           `json:"gasUsed"`
+		Duration     uint64           `json:"duration"`
+		TPS          float64          `json:"tps"`
+		Interval     distribution     `json:"interval"`
+		Intervals    []intervalCount  `json:"intervals"`
+		Utilization  distribution     `json:"utilization"`
+		TxCount      distribution     `json:"txCount"`
+		Producers    []*producerStats `json:"producers"`
+
+		intervals      []float64
+		utilizations   []float64
+		txCounts       []float64
+		intervalCounts map[uint64]uint64
+		producers      map[ethcommon.Address]*producerStats
+		firstTime      uint64
+		lastTime       uint64
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage           string
+	inputBlockStats blockStatsParams
+)
+
+// BlockStatsCmd represents the blockstats command
+var BlockStatsCmd = &cobra.Command{
+	Use:   "blockstats url",
+	Short: "Summarize the block production of a range of blocks.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputBlockStats.URL = args[0]
+
+		if inputBlockStats.To < inputBlockStats.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputBlockStats.BatchSize == 0 {
+			return fmt.Errorf("the batch size must be greater than zero")
+		}
+		if inputBlockStats.Top <= 0 {
+			return fmt.Errorf("the number of rows must be greater than zero")
+		}
+		if !slices.Contains([]string{"text", "json"}, inputBlockStats.Output) {
+			return fmt.Errorf("output must be one of [text, json]")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputBlockStats.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		s := &stats{
+			From:           inputBlockStats.From,
+			To:             inputBlockStats.To,
+			intervalCounts: make(map[uint64]uint64),
+			producers:      make(map[ethcommon.Address]*producerStats),
+		}
+		for start := inputBlockStats.From; start <= inputBlockStats.To; start += inputBlockStats.BatchSize {
+			end := start + inputBlockStats.BatchSize - 1
+			if end > inputBlockStats.To {
+				end = inputBlockStats.To
+			}
+
+			log.Info().Uint64("start", start).Uint64("end", end).Msg("Getting range")
+			blocks, err := getBlocks(ctx, rpc, start, end)
+			if err != nil {
+				return err
+			}
+			for _, raw := range blocks {
+				if err = s.addBlock(raw); err != nil {
+					return err
+				}
+			}
+		}
+		s.summarize()
+
+		if inputBlockStats.Output == "json" {
+			out, err := json.MarshalIndent(s, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		s.print(inputBlockStats.Top)
+		return nil
+	},
+}
+
+func init() {
+	BlockStatsCmd.PersistentFlags().Uint64Var(&inputBlockStats.From, "from", 0, "the first block of the range")
+	BlockStatsCmd.PersistentFlags().Uint64Var(&inputBlockStats.To, "to", 0, "the last block of the range")
+	BlockStatsCmd.PersistentFlags().Uint64VarP(&inputBlockStats.BatchSize, "batch-size", "b", 100, "the number of blocks to fetch per batch request")
+	BlockStatsCmd.PersistentFlags().IntVar(&inputBlockStats.Top, "top", 20, "the number of producers to show")
+	BlockStatsCmd.PersistentFlags().StringVarP(&inputBlockStats.Output, "output", "o", "text", "the output format [text, json]")
+	BlockStatsCmd.PersistentFlags().Uint64Var(&inputBlockStats.JaipurBlock, "jaipur-block", 0, "the bor Jaipur block, from which the seals cover the base fee")
+}
+
+// getBlocks fetches the blocks of the range without their transaction bodies.
+func getBlocks(ctx context.Context, rpc *ethrpc.Client, from, to uint64) ([]json.RawMessage, error) {
+	blocks := make([]json.RawMessage, to-from+1)
+	elems := make([]ethrpc.BatchElem, 0, len(blocks))
+	for i := range blocks {
+		elems = append(elems, ethrpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+			Result: &blocks[i],
+		})
+	}
+	if err := rpc.BatchCallContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	for i, e := range elems {
+		if e.Error != nil {
+			return nil, fmt.Errorf("unable to get block %d: %w", from+uint64(i), e.Error)
+		}
+		if len(blocks[i]) == 0 || string(blocks[i]) == "null" {
+			return nil, fmt.Errorf("block %d not found", from+uint64(i))
+		}
+	}
+	return blocks, nil
+}
+
+// producer returns the address that produced the block. Clique and bor leave
+// the coinbase empty and seal the block in the extra data instead. The bor seal
+// hash is the clique one with the base fee left out before the Jaipur block,
+// so it covers both.
+func producer(header *types.Header) ethcommon.Address {
+	if header.Coinbase != (ethcommon.Address{}) {
+		return header.Coinbase
+	}
+	signer, err := util.BorSealSigner(header, inputBlockStats.JaipurBlock)
+	if err != nil {
+		return header.Coinbase
+	}
+	return signer
+}
+
+func (s *stats) addBlock(raw json.RawMessage) error {
+	header, _, err := util.ParseHeader(raw)
+	if err != nil {
+		return err
+	}
+	var body struct {
+		Transactions []json.RawMessage `json:"transactions"`
+	}
+	if err = json.Unmarshal(raw, &body); err != nil {
+		return fmt.Errorf("unable to decode the transactions of block %d: %w", header.Number.Uint64(), err)
+	}
+	txs := uint64(len(body.Transactions))
+
+	s.Blocks++
+	s.Transactions += txs
+	s.GasUsed += header.GasUsed
+	s.txCounts = append(s.txCounts, float64(txs))
+	if header.GasLimit > 0 {
+		s.utilizations = append(s.utilizations, float64(header.GasUsed)/float64(header.GasLimit)*100)
+	}
+	if txs == 0 {
+		s.EmptyBlocks++
+	}
+
+	if s.Blocks == 1 {
+		s.firstTime = header.Time
+	} else {
+		// Timestamps only have to increase, but be defensive about RPCs that
+		// return blocks of different forks.
+		var interval uint64
+		if header.Time > s.lastTime {
+			interval = header.Time - s.lastTime
+		}
+		s.intervals = append(s.intervals, float64(interval))
+		s.intervalCounts[interval]++
+	}
+	s.lastTime = header.Time
+
+	address := producer(header)
+	p, ok := s.producers[address]
+	if !ok {
+		p = &producerStats{Address: address}
+		s.producers[address] = p
+	}
+	p.Blocks++
+	p.Transactions += txs
+	p.GasUsed += header.GasUsed
+	if txs == 0 {
+		p.EmptyBlocks++
+	}
+	log.Debug().Uint64("number", header.Number.Uint64()).Uint64("transactions", txs).Str("producer", address.Hex()).Msg("Added block")
+	return nil
+}
+
+// summarize computes the distributions and orders the producers by the number
+// of blocks they produced.
+func (s *stats) summarize() {
+	s.Interval = newDistribution(s.intervals)
+	s.Utilization = newDistribution(s.utilizations)
+	s.TxCount = newDistribution(s.txCounts)
+
+	if s.lastTime > s.firstTime {
+		s.Duration = s.lastTime - s.firstTime
+	}
+	if s.Duration > 0 {
+		s.TPS = float64(s.Transactions) / float64(s.Duration)
+	}
+
+	s.Intervals = make([]intervalCount, 0, len(s.intervalCounts))
+	for seconds, blocks := range s.intervalCounts {
+		s.Intervals = append(s.Intervals, intervalCount{Seconds: seconds, Blocks: blocks})
+	}
+	sort.Slice(s.Intervals, func(i, j int) bool {
+		return s.Intervals[i].Seconds < s.Intervals[j].Seconds
+	})
+
+	s.Producers = make([]*producerStats, 0, len(s.producers))
+	for _, p := range s.producers {
+		s.Producers = append(s.Producers, p)
+	}
+	sort.Slice(s.Producers, func(i, j int) bool {
+		if s.Producers[i].Blocks != s.Producers[j].Blocks {
+			return s.Producers[i].Blocks > s.Producers[j].Blocks
+		}
+		return s.Producers[i].Address.Hex() < s.Producers[j].Address.Hex()
+	})
+}
+
+func newDistribution(values []float64) distribution {
+	if len(values) == 0 {
+		return distribution{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, v := range sorted {
+		total += v
+	}
+	percentile := func(p float64) float64 {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return distribution{
+		Min:  sorted[0],
+		Mean: total / float64(len(sorted)),
+		P50:  percentile(0.5),
+		P90:  percentile(0.9),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+func share(n, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(n)/float64(total)*100)
+}
+
+func (s *stats) print(top int) {
+	fmt.Printf("Blocks %d to %d: %d blocks, %d empty (%s), %d transactions, %d gas used, %.2f tps over %ds\n",
+		s.From, s.To, s.Blocks, s.EmptyBlocks, share(s.EmptyBlocks, s.Blocks), s.Transactions, s.GasUsed, s.TPS, s.Duration)
+
+	dt := table.NewWriter()
+	dt.SetOutputMirror(os.Stdout)
+	dt.SetTitle("Distributions")
+	dt.AppendHeader(table.Row{"Metric", "Min", "Mean", "P50", "P90", "P99", "Max"})
+	for _, d := range []struct {
+		name string
+		d    distribution
+	}{
+		{"Block interval (s)", s.Interval},
+		{"Gas utilization (%)", s.Utilization},
+		{"Transactions", s.TxCount},
+	} {
+		dt.AppendRow(table.Row{d.name,
+			fmt.Sprintf("%.2f", d.d.Min),
+			fmt.Sprintf("%.2f", d.d.Mean),
+			fmt.Sprintf("%.2f", d.d.P50),
+			fmt.Sprintf("%.2f", d.d.P90),
+			fmt.Sprintf("%.2f", d.d.P99),
+			fmt.Sprintf("%.2f", d.d.Max),
+		})
+	}
+	dt.Render()
+
+	it := table.NewWriter()
+	it.SetOutputMirror(os.Stdout)
+	it.SetTitle("Block Intervals")
+	it.AppendHeader(table.Row{"Seconds", "Blocks", "Share"})
+	for _, i := range s.Intervals {
+		it.AppendRow(table.Row{i.Seconds, i.Blocks, share(i.Blocks, uint64(len(s.intervals)))})
+	}
+	it.Render()
+
+	pt := table.NewWriter()
+	pt.SetOutputMirror(os.Stdout)
+	pt.SetTitle("Producers")
+	pt.AppendHeader(table.Row{"Address", "Blocks", "Share", "Empty Blocks", "Transactions", "Gas Used"})
+	for i, p := range s.Producers {
+		if i == top {
+			break
+		}
+		pt.AppendRow(table.Row{p.Address.Hex(), p.Blocks, share(p.Blocks, s.Blocks), p.EmptyBlocks, p.Transactions, p.GasUsed})
+	}
+	pt.Render()
+}
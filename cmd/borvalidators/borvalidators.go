@@ -0,0 +1,286 @@
+package borvalidators
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	borValidatorsParams struct {
+		URL       string
+		From      uint64
+		To        uint64
+		BatchSize uint64
+		Sprint    uint64
+		JSON      bool
+
+		JaipurBlock uint64
+	}
+
+	// sprintValidator is a validator of a sprint and the blocks it produced.
+	sprintValidator struct {
+		Address ethcommon.Address `json:"address"`
+		Power   *hexutil.Big      `json:"power"`
+		Primary uint64            `json:"primary"`
+		Backup  uint64            `json:"backup"`
+	}
+
+	// sprint is a run of blocks produced by the validator set in the extra
+	// data of the sprint end header before it.
+	sprint struct {
+		SetBlock   uint64             `json:"setBlock"`
+		From       uint64             `json:"from"`
+		To         uint64             `json:"to"`
+		Changed    bool               `json:"changed"`
+		Proposer   *ethcommon.Address `json:"proposer,omitempty"`
+		Validators []*sprintValidator `json:"validators"`
+		Issues     []string           `json:"issues,omitempty"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage              string
+	inputBorValidators borValidatorsParams
+)
+
+// BorValidatorsCmd represents the bor-validators command
+var BorValidatorsCmd = &cobra.Command{
+	Use:   "bor-validators url",
+	Short: "Show the validator sets in bor sprint end headers and which validators produced the blocks of each sprint.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputBorValidators.URL = args[0]
+
+		if inputBorValidators.To < inputBorValidators.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputBorValidators.BatchSize == 0 {
+			return fmt.Errorf("the batch size must be greater than zero")
+		}
+		if inputBorValidators.Sprint == 0 {
+			return fmt.Errorf("the sprint length must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputBorValidators.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		// Start at the sprint end header before the range, which has the
+		// validator set of the first sprint.
+		start := inputBorValidators.From
+		if start >= inputBorValidators.Sprint {
+			start = start - start%inputBorValidators.Sprint - 1
+		}
+
+		var (
+			sprints []*sprint
+			current *sprint
+			unknown uint64
+		)
+		for from := start; from <= inputBorValidators.To; from += inputBorValidators.BatchSize {
+			to := from + inputBorValidators.BatchSize - 1
+			if to > inputBorValidators.To {
+				to = inputBorValidators.To
+			}
+
+			log.Info().Uint64("start", from).Uint64("end", to).Msg("Getting range")
+			blocks, err := util.GetBlockRange(ctx, from, to, rpc)
+			if err != nil {
+				return err
+			}
+
+			for _, raw := range blocks {
+				header, _, err := util.ParseHeader(*raw)
+				if err != nil {
+					return err
+				}
+				number := header.Number.Uint64()
+				if number >= inputBorValidators.From {
+					if current == nil {
+						unknown++
+					} else {
+						current.add(header, inputBorValidators.JaipurBlock)
+					}
+				}
+
+				// Only sprint end headers have validator bytes, which are the
+				// validator set of the next sprint.
+				if err = util.CheckBorValidatorBytes(header, inputBorValidators.Sprint); err != nil {
+					if current != nil {
+						current.issue("%s", err)
+					} else {
+						log.Error().Err(err).Uint64("block", number).Msg("Invalid validator bytes")
+					}
+					continue
+				}
+				if (number+1)%inputBorValidators.Sprint != 0 {
+					continue
+				}
+				extra, err := util.ParseBorExtra(header.Extra)
+				if err != nil {
+					return err
+				}
+				next := newSprint(number, extra.Validators, current)
+				if current != nil && current.To >= current.From {
+					sprints = append(sprints, current)
+				}
+				current = next
+			}
+		}
+		if current != nil && current.To >= current.From {
+			sprints = append(sprints, current)
+		}
+
+		if unknown > 0 {
+			log.Warn().Uint64("blocks", unknown).Msg("The validator set of some blocks is unknown, check the sprint length")
+		}
+
+		var issues int
+		for _, s := range sprints {
+			for _, issue := range s.Issues {
+				log.Error().Uint64("sprint", s.From).Msg(issue)
+			}
+			issues += len(s.Issues)
+		}
+
+		if inputBorValidators.JSON {
+			out, err := json.MarshalIndent(sprints, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		} else {
+			printSprints(sprints)
+		}
+
+		if issues > 0 {
+			return fmt.Errorf("%d issues with the block producers", issues)
+		}
+		return nil
+	},
+}
+
+func init() {
+	BorValidatorsCmd.PersistentFlags().Uint64Var(&inputBorValidators.From, "from", 0, "the first block of the range")
+	BorValidatorsCmd.PersistentFlags().Uint64Var(&inputBorValidators.To, "to", 0, "the last block of the range")
+	BorValidatorsCmd.PersistentFlags().Uint64VarP(&inputBorValidators.BatchSize, "batch-size", "b", 100, "the number of headers to fetch per batch request")
+	BorValidatorsCmd.PersistentFlags().Uint64Var(&inputBorValidators.Sprint, "sprint", 16, "the sprint length, to find the validator set of the first blocks")
+	BorValidatorsCmd.PersistentFlags().BoolVar(&inputBorValidators.JSON, "json", false, "output the sprints as JSON")
+	BorValidatorsCmd.PersistentFlags().Uint64Var(&inputBorValidators.JaipurBlock, "jaipur-block", 0, "the Jaipur block, from which the seals cover the base fee")
+}
+
+// newSprint starts the sprint after the sprint end header, and whether its
+// validator set differs from the previous one, which happens at span
+// boundaries.
+func newSprint(number uint64, validators []util.BorValidator, previous *sprint) *sprint {
+	s := &sprint{SetBlock: number, From: number + 1, To: number}
+	for _, v := range validators {
+		s.Validators = append(s.Validators, &sprintValidator{Address: v.Address, Power: v.Power})
+	}
+	if previous != nil {
+		s.Changed = len(previous.Validators) != len(s.Validators)
+		for i := 0; !s.Changed && i < len(s.Validators); i++ {
+			p, v := previous.Validators[i], s.Validators[i]
+			s.Changed = p.Address != v.Address || p.Power.ToInt().Cmp(v.Power.ToInt()) != 0
+		}
+	}
+	return s
+}
+
+// add attributes the block to its producer. Bor sets the difficulty to the
+// number of validators for the primary producer of the sprint, and to one
+// less for every step a backup producer is after it in the validator set,
+// which is ordered by address. This makes it possible to find the primary
+// producer even when it didn't produce the block. The seal only covers the
+// base fee from the Jaipur block.
+func (s *sprint) add(header *types.Header, jaipurBlock uint64) {
+	number := header.Number.Uint64()
+	s.To = number
+
+	signer, err := util.BorSealSigner(header, jaipurBlock)
+	if err != nil {
+		s.issue("unable to recover the signer of block %d: %s", number, err)
+		return
+	}
+	index := -1
+	for i, v := range s.Validators {
+		if v.Address == signer {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		s.issue("block %d was signed by %s, which isn't in the validator set", number, signer.Hex())
+		return
+	}
+
+	n := uint64(len(s.Validators))
+	difficulty := header.Difficulty.Uint64()
+	if !header.Difficulty.IsUint64() || difficulty == 0 || difficulty > n {
+		s.issue("block %d has a difficulty of %s with %d validators", number, header.Difficulty, n)
+		return
+	}
+	succession := n - difficulty
+	if succession == 0 {
+		s.Validators[index].Primary++
+	} else {
+		s.Validators[index].Backup++
+	}
+
+	proposer := s.Validators[(uint64(index)+n-succession)%n].Address
+	if s.Proposer == nil {
+		s.Proposer = &proposer
+	} else if *s.Proposer != proposer {
+		s.issue("the primary producer changed from %s to %s in block %d", s.Proposer.Hex(), proposer.Hex(), number)
+	}
+}
+
+func (s *sprint) issue(format string, args ...interface{}) {
+	s.Issues = append(s.Issues, fmt.Sprintf(format, args...))
+}
+
+func printSprints(sprints []*sprint) {
+	for _, s := range sprints {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		title := fmt.Sprintf("Blocks %d to %d, validator set from block %d", s.From, s.To, s.SetBlock)
+		if s.Changed {
+			title += " (changed)"
+		}
+		t.SetTitle(title)
+		t.AppendHeader(table.Row{"Validator", "Power", "Primary", "Backup", ""})
+		for _, v := range s.Validators {
+			var note string
+			if s.Proposer != nil && *s.Proposer == v.Address {
+				note = "proposer"
+			}
+			t.AppendRow(table.Row{v.Address.Hex(), v.Power.ToInt(), v.Primary, v.Backup, note})
+		}
+		t.Render()
+	}
+}
@@ -0,0 +1,94 @@
+package borvalidators
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+func TestSprintAdd(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var validators []util.BorValidator
+	for _, k := range []string{
+		"42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+	} {
+		key, err := crypto.HexToECDSA(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		validators = append(validators, util.BorValidator{Address: crypto.PubkeyToAddress(key.PublicKey), Power: (*hexutil.Big)(big.NewInt(1))})
+	}
+	const jaipurBlock = 100
+
+	tests := []struct {
+		name       string
+		number     int64
+		key        int
+		difficulty int64
+		primary    []uint64
+		backup     []uint64
+		issues     int
+	}{
+		{
+			name:       "primary before jaipur",
+			number:     98,
+			key:        0,
+			difficulty: 2,
+			primary:    []uint64{1, 0},
+			backup:     []uint64{0, 0},
+		},
+		{
+			name:       "backup after jaipur",
+			number:     102,
+			key:        1,
+			difficulty: 1,
+			primary:    []uint64{0, 0},
+			backup:     []uint64{0, 1},
+		},
+		{
+			name:       "difficulty above the validator count",
+			number:     102,
+			key:        0,
+			difficulty: 3,
+			primary:    []uint64{0, 0},
+			backup:     []uint64{0, 0},
+			issues:     1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := &types.Header{
+				Difficulty: big.NewInt(tc.difficulty),
+				Number:     big.NewInt(tc.number),
+				GasLimit:   30000000,
+				Extra:      make([]byte, 32+crypto.SignatureLength),
+				BaseFee:    big.NewInt(30000000000),
+			}
+			sig, err := crypto.Sign(util.BorSealHash(header, jaipurBlock).Bytes(), keys[tc.key])
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(header.Extra[32:], sig)
+
+			s := newSprint(uint64(tc.number)-1, validators, nil)
+			s.add(header, jaipurBlock)
+			if len(s.Issues) != tc.issues {
+				t.Fatalf("expected %d issues, got %v", tc.issues, s.Issues)
+			}
+			for i, v := range s.Validators {
+				if v.Primary != tc.primary[i] || v.Backup != tc.backup[i] {
+					t.Errorf("expected validator %d to have %d primary and %d backup blocks, got %d and %d", i, tc.primary[i], tc.backup[i], v.Primary, v.Backup)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package call
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	_ "embed"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	callParams struct {
+		RPCURL             string
+		From               string
+		To                 string
+		Data               string
+		Value              string
+		Gas                uint64
+		Block              string
+		ABIFile            string
+		Method             string
+		StateOverridesFile string
+		BlockOverridesFile string
+		Interval           time.Duration
+	}
+
+	// callArgs are the transaction arguments of eth_call.
+	callArgs struct {
+		From  *ethcommon.Address `json:"from,omitempty"`
+		To    ethcommon.Address  `json:"to"`
+		Data  hexutil.Bytes      `json:"data,omitempty"`
+		Value *hexutil.Big       `json:"value,omitempty"`
+		Gas   *hexutil.Uint64    `json:"gas,omitempty"`
+	}
+
+	// overrideAccount is the state override of an account. Only one of State,
+	// which replaces the whole storage, and StateDiff can be set.
+	overrideAccount struct {
+		Nonce     *hexutil.Uint64                   `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes                    `json:"code,omitempty"`
+		Balance   *hexutil.Big                      `json:"balance,omitempty"`
+		State     map[ethcommon.Hash]ethcommon.Hash `json:"state,omitempty"`
+		StateDiff map[ethcommon.Hash]ethcommon.Hash `json:"stateDiff,omitempty"`
+	}
+
+	blockOverrides struct {
+		Number     *hexutil.Big       `json:"number,omitempty"`
+		Difficulty *hexutil.Big       `json:"difficulty,omitempty"`
+		Time       *hexutil.Uint64    `json:"time,omitempty"`
+		GasLimit   *hexutil.Uint64    `json:"gasLimit,omitempty"`
+		Coinbase   *ethcommon.Address `json:"coinbase,omitempty"`
+		Random     *ethcommon.Hash    `json:"random,omitempty"`
+		BaseFee    *hexutil.Big       `json:"baseFee,omitempty"`
+	}
+
+	decodedOutput struct {
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		Value any    `json:"value"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage     string
+	inputCall callParams
+)
+
+// CallCmd makes an eth_call with optional state and block overrides.
+var CallCmd = &cobra.Command{
+	Use:   "call [args...]",
+	Short: "Make an eth_call with state and block overrides and decode the result.",
+	Long:  usage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		method, err := readMethod()
+		if err != nil {
+			return err
+		}
+
+		rpc, err := util.DialRPC(ctx, inputCall.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		tx, err := buildCallArgs(ctx, addressbook.NewResolver(rpc), method, args)
+		if err != nil {
+			return err
+		}
+
+		params := []any{tx, inputCall.Block}
+		var stateOverrides map[ethcommon.Address]overrideAccount
+		if inputCall.StateOverridesFile != "" {
+			if err = readJSONFile(inputCall.StateOverridesFile, &stateOverrides); err != nil {
+				return err
+			}
+			for address, account := range stateOverrides {
+				if account.State != nil && account.StateDiff != nil {
+					return fmt.Errorf("the state override of %s can't have both state and stateDiff", address.Hex())
+				}
+			}
+		}
+		if inputCall.BlockOverridesFile != "" {
+			var overrides blockOverrides
+			if err = readJSONFile(inputCall.BlockOverridesFile, &overrides); err != nil {
+				return err
+			}
+			// The block overrides are the fourth parameter, so the state
+			// overrides have to be passed even if there aren't any.
+			if stateOverrides == nil {
+				stateOverrides = make(map[ethcommon.Address]overrideAccount)
+			}
+			params = append(params, stateOverrides, overrides)
+		} else if stateOverrides != nil {
+			params = append(params, stateOverrides)
+		}
+
+		if inputCall.Interval <= 0 {
+			return call(ctx, rpc, method, params)
+		}
+
+		ticker := time.NewTicker(inputCall.Interval)
+		defer ticker.Stop()
+		for {
+			if err = call(ctx, rpc, method, params); err != nil {
+				log.Error().Err(err).Msg("Call failed")
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if inputCall.To != "" && !addressbook.Valid(inputCall.To) {
+			return fmt.Errorf("the to address %s is not valid", inputCall.To)
+		}
+		if inputCall.From != "" && !addressbook.Valid(inputCall.From) {
+			return fmt.Errorf("the from address %s is not valid", inputCall.From)
+		}
+		if inputCall.Method != "" && inputCall.ABIFile == "" {
+			return fmt.Errorf("the method can only be used with an abi")
+		}
+		if inputCall.Data != "" && len(args) > 0 {
+			return fmt.Errorf("arguments can't be used with data")
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := CallCmd.Flags()
+	flagSet.StringVar(&inputCall.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputCall.From, "from", "", "The address the call is made from. "+addressbook.Help)
+	flagSet.StringVar(&inputCall.To, "to", "", "The address of the contract to call. "+addressbook.Help)
+	flagSet.StringVar(&inputCall.Data, "data", "", "The hex encoded call data. Otherwise it's encoded from the method and the arguments")
+	flagSet.StringVar(&inputCall.Value, "value", "", "The amount of wei sent with the call")
+	flagSet.Uint64Var(&inputCall.Gas, "gas", 0, "The gas limit of the call")
+	flagSet.StringVar(&inputCall.Block, "block", "latest", "The block number, hash, or tag the call is made at")
+	flagSet.StringVar(&inputCall.ABIFile, "abi", "", "The ABI file of the contract used to encode the call and decode the result")
+	flagSet.StringVar(&inputCall.Method, "method", "", "The ABI method to call")
+	flagSet.StringVar(&inputCall.StateOverridesFile, "state-overrides", "", "A JSON file with the balance, nonce, code, and storage to override per address")
+	flagSet.StringVar(&inputCall.BlockOverridesFile, "block-overrides", "", "A JSON file with the block number, time, gas limit, coinbase, random, and base fee to override")
+	flagSet.DurationVar(&inputCall.Interval, "interval", 0, "Repeat the call at this interval until interrupted")
+	_ = CallCmd.MarkFlagRequired("to")
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// readMethod returns the ABI method to call, or nil if there isn't an ABI.
+func readMethod() (*gethabi.Method, error) {
+	if inputCall.ABIFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(inputCall.ABIFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contractABI, err := gethabi.JSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse abi: %w", err)
+	}
+
+	if inputCall.Method == "" {
+		if inputCall.Data == "" {
+			return nil, fmt.Errorf("either the method or the data is needed")
+		}
+		data, err := hexutil.Decode(inputCall.Data)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 4 {
+			return nil, fmt.Errorf("the data is too short to contain a selector")
+		}
+		return contractABI.MethodById(data[:4])
+	}
+
+	method, ok := contractABI.Methods[inputCall.Method]
+	if !ok {
+		return nil, fmt.Errorf("the method %s isn't in the abi", inputCall.Method)
+	}
+	return &method, nil
+}
+
+func buildCallArgs(ctx context.Context, resolver *addressbook.Resolver, method *gethabi.Method, args []string) (*callArgs, error) {
+	to, err := resolver.Resolve(ctx, inputCall.To)
+	if err != nil {
+		return nil, err
+	}
+	tx := &callArgs{To: to}
+	if inputCall.From != "" {
+		from, err := resolver.Resolve(ctx, inputCall.From)
+		if err != nil {
+			return nil, err
+		}
+		tx.From = &from
+	}
+	if inputCall.Value != "" {
+		value, ok := new(big.Int).SetString(inputCall.Value, 0)
+		if !ok {
+			return nil, fmt.Errorf("the value %s is not a number", inputCall.Value)
+		}
+		tx.Value = (*hexutil.Big)(value)
+	}
+	if inputCall.Gas > 0 {
+		tx.Gas = (*hexutil.Uint64)(&inputCall.Gas)
+	}
+
+	switch {
+	case inputCall.Data != "":
+		data, err := hexutil.Decode(inputCall.Data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode data: %w", err)
+		}
+		tx.Data = data
+	case method != nil:
+		if len(args) != len(method.Inputs) {
+			return nil, fmt.Errorf("%s expects %d arguments but got %d", method.Sig, len(method.Inputs), len(args))
+		}
+		values := make([]any, len(args))
+		for i, arg := range args {
+			if method.Inputs[i].Type.T == gethabi.AddressTy && addressbook.IsAlias(arg) {
+				address, err := resolver.Resolve(ctx, arg)
+				if err != nil {
+					return nil, fmt.Errorf("invalid argument %d: %w", i, err)
+				}
+				arg = address.Hex()
+			}
+			v, err := parseArg(method.Inputs[i].Type, arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid argument %d: %w", i, err)
+			}
+			values[i] = v
+		}
+		input, err := method.Inputs.Pack(values...)
+		if err != nil {
+			return nil, err
+		}
+		tx.Data = append(append([]byte{}, method.ID...), input...)
+	}
+	return tx, nil
+}
+
+// parseArg converts a command line argument to the Go type the ABI encoder
+// expects for the type. Arrays and tuples aren't supported.
+func parseArg(t gethabi.Type, arg string) (any, error) {
+	switch t.T {
+	case gethabi.AddressTy:
+		if !ethcommon.IsHexAddress(arg) {
+			return nil, fmt.Errorf("%s is not an address", arg)
+		}
+		return ethcommon.HexToAddress(arg), nil
+	case gethabi.BoolTy:
+		return strconv.ParseBool(arg)
+	case gethabi.StringTy:
+		return arg, nil
+	case gethabi.BytesTy:
+		return hexutil.Decode(arg)
+	case gethabi.FixedBytesTy:
+		b, err := hexutil.Decode(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("expected %d bytes but got %d", t.Size, len(b))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+	case gethabi.IntTy, gethabi.UintTy:
+		n, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", arg)
+		}
+		if t.Size > 64 {
+			return n, nil
+		}
+		v := reflect.New(t.GetType()).Elem()
+		if t.T == gethabi.UintTy {
+			if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+				return nil, fmt.Errorf("%s doesn't fit in %s", arg, t)
+			}
+			v.SetUint(n.Uint64())
+		} else {
+			if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+				return nil, fmt.Errorf("%s doesn't fit in %s", arg, t)
+			}
+			v.SetInt(n.Int64())
+		}
+		return v.Interface(), nil
+	default:
+		return nil, fmt.Errorf("the type %s is not supported", t)
+	}
+}
+
+func call(ctx context.Context, rpc *ethrpc.Client, method *gethabi.Method, params []any) error {
+	var result hexutil.Bytes
+	err := rpc.CallContext(ctx, &result, "eth_call", params...)
+	if err != nil {
+		var dataErr ethrpc.DataError
+		if errors.As(err, &dataErr) {
+			if data, ok := dataErr.ErrorData().(string); ok {
+				if revert, decodeErr := hexutil.Decode(data); decodeErr == nil {
+					if reason, unpackErr := gethabi.UnpackRevert(revert); unpackErr == nil {
+						return fmt.Errorf("%w: %s", err, reason)
+					}
+				}
+			}
+		}
+		return err
+	}
+
+	if method == nil || len(method.Outputs) == 0 {
+		fmt.Println(result.String())
+		return nil
+	}
+
+	values, err := method.Outputs.UnpackValues(result)
+	if err != nil {
+		return fmt.Errorf("unable to decode result %s: %w", result.String(), err)
+	}
+	outputs := make([]decodedOutput, len(values))
+	for i, v := range values {
+		name := method.Outputs[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		outputs[i] = decodedOutput{Name: name, Type: method.Outputs[i].Type.String(), Value: v}
+	}
+	out, err := json.Marshal(outputs)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+var (
+	// choicesPattern matches the lists of values in flag usages, like
+	// "[json, proto]" or "(newest|oldest)".
+	choicesPattern = regexp.MustCompile(`\[([^\]]+)\]|\(([^)]+)\)`)
+	choicePattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+	// derivationPaths are the common derivation paths, with the wallets that
+	// use them.
+	derivationPaths = []string{
+		"m/44'/60'/0'\tthe default of polycli, whose children are m/44'/60'/0'/0/i",
+		"m/44'/60'/0'/0\tBIP-44 Ethereum accounts of MetaMask and Trezor",
+		"m/44'/60'/0'/0/0\tthe first BIP-44 Ethereum account",
+		"m/44'/60'\tLedger Live, whose accounts are m/44'/60'/i'/0/0",
+		"m/44'/966'/0'/0\tBIP-44 Polygon accounts",
+	}
+
+	dirFlags = map[string]bool{"data-dir": true, "datadir": true, "export-path": true}
+)
+
+// registerCompletions registers dynamic completions for the flags and
+// arguments of the command and its subcommands, so that the growing number
+// of flags can be discovered from the shell:
+//
+//   - flags that list their values in their usage complete those values
+//   - --network completes the network presets, and --bootnodes the bootnodes
+//     of the --network preset
+//   - derivation --path flags complete the common derivation paths
+//   - directory flags complete directories
+//   - the nodes file arguments complete JSON files
+//
+// Completions registered by the commands themselves are kept.
+func registerCompletions(cmd *cobra.Command) {
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if dirFlags[f.Name] {
+			_ = cmd.LocalFlags().SetAnnotation(f.Name, cobra.BashCompSubdirsInDir, []string{})
+			return
+		}
+		if complete := flagCompletion(f); complete != nil {
+			// Registering fails if the command registered its own.
+			_ = cmd.RegisterFlagCompletionFunc(f.Name, complete)
+		}
+	})
+
+	if cmd.ValidArgsFunction == nil && strings.Contains(cmd.Use, "nodes file") {
+		cmd.ValidArgsFunction = func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+		}
+	}
+
+	for _, c := range cmd.Commands() {
+		registerCompletions(c)
+	}
+}
+
+// completionFunc is the function cobra calls to complete a flag.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+func flagCompletion(f *pflag.Flag) completionFunc {
+	list := strings.HasSuffix(f.Value.Type(), "Slice") || strings.HasSuffix(f.Value.Type(), "Array")
+	switch {
+	case f.Name == "network":
+		return completeNetworks
+	case f.Name == "bootnodes":
+		return completeBootnodes
+	case f.Name == "path" && strings.Contains(f.Usage, "derivation path"):
+		return completeValues(derivationPaths, false)
+	case f.Name == "metrics-sink-format":
+		return completeValues([]string{metrics.SinkFormatInflux, metrics.SinkFormatRemoteWrite}, false)
+	}
+	if choices := usageChoices(f.Usage); choices != nil {
+		return completeValues(choices, list)
+	}
+	return nil
+}
+
+// usageChoices returns the first list of values in the usage, or nil if
+// there's none.
+func usageChoices(usage string) []string {
+	for _, m := range choicesPattern.FindAllStringSubmatch(usage, -1) {
+		values, sep := m[1], ","
+		if values == "" {
+			values, sep = m[2], "|"
+		}
+		if !strings.Contains(values, sep) {
+			continue
+		}
+		var choices []string
+		for _, c := range strings.Split(values, sep) {
+			if c = strings.TrimSpace(c); choicePattern.MatchString(c) {
+				choices = append(choices, c)
+			}
+		}
+		if len(choices) > 1 {
+			return choices
+		}
+	}
+	return nil
+}
+
+// completeValues completes the values, which can have tab separated
+// descriptions. Comma separated lists complete the value after the last
+// comma.
+func completeValues(values []string, list bool) completionFunc {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if !list {
+			return values, cobra.ShellCompDirectiveNoFileComp
+		}
+		prefix := ""
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix = toComplete[:i+1]
+		}
+		completions := make([]string, 0, len(values))
+		for _, v := range values {
+			completions = append(completions, prefix+v)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+func completeNetworks(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, name := range util.NetworkNames() {
+		n, err := util.LookupNetwork(name)
+		if err != nil {
+			continue
+		}
+		completions = append(completions, fmt.Sprintf("%s\tchain ID %d", name, n.ChainID))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBootnodes completes the bootnodes of the --network preset, or of
+// every preset if it isn't set.
+func completeBootnodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := util.NetworkNames()
+	if network != "" {
+		names = []string{network}
+	}
+	var bootnodes []string
+	for _, name := range names {
+		if n, err := util.LookupNetwork(name); err == nil {
+			bootnodes = append(bootnodes, n.Bootnodes...)
+		}
+	}
+	return completeValues(bootnodes, true)(cmd, args, toComplete)
+}
@@ -0,0 +1,297 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	unitHex       = "hex"
+	unitDec       = "dec"
+	unitBlock     = "block"
+	unitTimestamp = "timestamp"
+)
+
+type (
+	convertParams struct {
+		From   string
+		To     string
+		RPCURL string
+		Sample uint64
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage        string
+	inputConvert convertParams
+
+	// denominations maps the ether units to their number of decimals.
+	denominations = map[string]int64{
+		"wei":    0,
+		"kwei":   3,
+		"mwei":   6,
+		"gwei":   9,
+		"szabo":  12,
+		"finney": 15,
+		"ether":  18,
+	}
+)
+
+// ConvertCmd represents the convert command
+var ConvertCmd = &cobra.Command{
+	Use:   "convert value",
+	Short: "Convert between ether units, hex and decimal, and timestamps and blocks.",
+	Long:  usage,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value := strings.TrimSpace(args[0])
+		from := strings.ToLower(inputConvert.From)
+		to := strings.ToLower(inputConvert.To)
+
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			from = unitTimestamp
+		}
+
+		var (
+			out string
+			err error
+		)
+		switch {
+		case to == unitBlock || to == unitTimestamp || from == unitBlock || from == unitTimestamp:
+			out, err = convertTime(cmd.Context(), value, from, to)
+		case to == unitHex || to == unitDec:
+			out, err = convertBase(value, to)
+		default:
+			out, err = convertDenomination(value, from, to)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	},
+}
+
+func init() {
+	ConvertCmd.PersistentFlags().StringVar(&inputConvert.From, "from", "wei", "the unit of the input when it has no suffix [wei, kwei, mwei, gwei, szabo, finney, ether, block, timestamp]")
+	ConvertCmd.PersistentFlags().StringVar(&inputConvert.To, "to", "wei", "the unit to convert to [wei, kwei, mwei, gwei, szabo, finney, ether, hex, dec, block, timestamp]")
+	ConvertCmd.PersistentFlags().StringVar(&inputConvert.RPCURL, "rpc-url", "", "the rpc url used to convert between timestamps and blocks")
+	ConvertCmd.PersistentFlags().Uint64Var(&inputConvert.Sample, "sample", 1000, "the number of blocks used to estimate the block time of future blocks")
+}
+
+// parseQuantity parses a decimal or hex integer. Unlike JSON-RPC quantities,
+// hex integers can have leading zeros, like the words of calldata.
+func parseQuantity(value string) (*big.Int, error) {
+	base, digits := 10, value
+	if strings.HasPrefix(value, "0x") {
+		base, digits = 16, value[2:]
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok || (base == 16 && strings.ContainsAny(digits, "+-")) {
+		return nil, fmt.Errorf("unable to parse %s as an integer", value)
+	}
+	return n, nil
+}
+
+func convertBase(value, to string) (string, error) {
+	n, err := parseQuantity(value)
+	if err != nil {
+		return "", err
+	}
+	if to == unitHex {
+		return hexutil.EncodeBig(n), nil
+	}
+	return n.String(), nil
+}
+
+// splitUnit separates the numeric value from a unit suffix like "1.5ether".
+func splitUnit(value, defaultUnit string) (string, string) {
+	lower := strings.ToLower(value)
+	for unit := range denominations {
+		if strings.HasSuffix(lower, unit) && !strings.HasSuffix(lower, "g"+unit) && !strings.HasSuffix(lower, "k"+unit) && !strings.HasSuffix(lower, "m"+unit) {
+			return strings.TrimSpace(value[:len(value)-len(unit)]), unit
+		}
+	}
+	return value, defaultUnit
+}
+
+func convertDenomination(value, from, to string) (string, error) {
+	number, unit := splitUnit(value, from)
+	fromDecimals, ok := denominations[unit]
+	if !ok {
+		return "", fmt.Errorf("unknown unit %s", unit)
+	}
+	toDecimals, ok := denominations[to]
+	if !ok {
+		return "", fmt.Errorf("unknown unit %s", to)
+	}
+
+	var amount *big.Rat
+	if strings.HasPrefix(number, "0x") {
+		n, err := parseQuantity(number)
+		if err != nil {
+			return "", err
+		}
+		amount = new(big.Rat).SetInt(n)
+	} else if amount, ok = new(big.Rat).SetString(number); !ok {
+		return "", fmt.Errorf("unable to parse %s as a number", number)
+	}
+
+	// Amounts are whole numbers of wei, so a fraction of a wei would be
+	// rounded away in any unit.
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(fromDecimals), nil))
+	amount.Mul(amount, scale)
+	if !amount.IsInt() {
+		return "", fmt.Errorf("%s is not a whole number of wei", value)
+	}
+	scale.SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(toDecimals), nil))
+	amount.Quo(amount, scale)
+	return formatRat(amount, int(toDecimals)), nil
+}
+
+// formatRat formats the number with up to the given decimals and removes the
+// trailing zeros.
+func formatRat(r *big.Rat, decimals int) string {
+	s := r.FloatString(decimals)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+func convertTime(ctx context.Context, value, from, to string) (string, error) {
+	if inputConvert.RPCURL == "" {
+		return "", fmt.Errorf("an rpc url is required to convert between timestamps and blocks")
+	}
+	rpc, err := util.DialRPC(ctx, inputConvert.RPCURL)
+	if err != nil {
+		return "", err
+	}
+	defer rpc.Close()
+	client := ethclient.NewClient(rpc)
+
+	switch {
+	case from == unitTimestamp && to == unitBlock:
+		timestamp, err := parseTimestamp(value)
+		if err != nil {
+			return "", err
+		}
+		block, err := blockAtTimestamp(ctx, client, timestamp)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(block), nil
+	case from == unitBlock && to == unitTimestamp:
+		n, err := parseQuantity(value)
+		if err != nil {
+			return "", err
+		}
+		timestamp, err := timestampOfBlock(ctx, client, n.Uint64())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d (%s)", timestamp, time.Unix(int64(timestamp), 0).UTC().Format(time.RFC3339)), nil
+	}
+	return "", fmt.Errorf("unable to convert from %s to %s", from, to)
+}
+
+func parseTimestamp(value string) (uint64, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return uint64(t.Unix()), nil
+	}
+	n, err := parseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("timestamps should be unix seconds or RFC 3339: %w", err)
+	}
+	return n.Uint64(), nil
+}
+
+// averageBlockTime returns the average block time in seconds over the last
+// sample blocks.
+func averageBlockTime(ctx context.Context, client *ethclient.Client, head *types.Header) (float64, error) {
+	sample := inputConvert.Sample
+	if sample == 0 || sample > head.Number.Uint64() {
+		sample = head.Number.Uint64()
+	}
+	if sample == 0 {
+		return 0, fmt.Errorf("not enough blocks to estimate the block time")
+	}
+	past, err := client.HeaderByNumber(ctx, new(big.Int).Sub(head.Number, new(big.Int).SetUint64(sample)))
+	if err != nil {
+		return 0, err
+	}
+	if head.Time <= past.Time {
+		return 0, fmt.Errorf("unable to estimate the block time from blocks with the same timestamp")
+	}
+	return float64(head.Time-past.Time) / float64(sample), nil
+}
+
+// blockAtTimestamp returns the last block with a timestamp lower or equal to
+// the given timestamp. Timestamps in the future are estimated.
+func blockAtTimestamp(ctx context.Context, client *ethclient.Client, timestamp uint64) (uint64, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp >= head.Time {
+		blockTime, err := averageBlockTime(ctx, client, head)
+		if err != nil {
+			return 0, err
+		}
+		estimate := head.Number.Uint64() + uint64(float64(timestamp-head.Time)/blockTime)
+		log.Info().Float64("blockTime", blockTime).Msg("Timestamp is in the future, the block is estimated")
+		return estimate, nil
+	}
+
+	low, high := uint64(0), head.Number.Uint64()
+	for low < high {
+		mid := (low + high + 1) / 2
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if header.Time <= timestamp {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// timestampOfBlock returns the timestamp of the block. Blocks in the future
+// are estimated.
+func timestampOfBlock(ctx context.Context, client *ethclient.Client, number uint64) (uint64, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if number <= head.Number.Uint64() {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return 0, err
+		}
+		return header.Time, nil
+	}
+
+	blockTime, err := averageBlockTime(ctx, client, head)
+	if err != nil {
+		return 0, err
+	}
+	log.Info().Float64("blockTime", blockTime).Msg("Block is in the future, the timestamp is estimated")
+	return head.Time + uint64(float64(number-head.Number.Uint64())*blockTime), nil
+}
@@ -0,0 +1,88 @@
+package convert
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "0", want: "0"},
+		{value: "26", want: "26"},
+		{value: "0x1a", want: "26"},
+		{value: "0x001a", want: "26"},
+		{value: "0x0000000000000000000000000000000000000000000000000de0b6b3a7640000", want: "1000000000000000000"},
+		{value: "0x0", want: "0"},
+		{value: "0x00", want: "0"},
+		{value: "0xFF", want: "255"},
+		{value: "115792089237316195423570985008687907853269984665640564039457584007913129639935", want: "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{value: "0x", wantErr: true},
+		{value: "0x1g", wantErr: true},
+		{value: "0x-1", wantErr: true},
+		{value: "0x+1", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			n, err := parseQuantity(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.String() != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, n)
+			}
+		})
+	}
+}
+
+func TestConvertDenomination(t *testing.T) {
+	tests := []struct {
+		value   string
+		from    string
+		to      string
+		want    string
+		wantErr bool
+	}{
+		{value: "1.5ether", from: "wei", to: "wei", want: "1500000000000000000"},
+		{value: "21000gwei", from: "wei", to: "ether", want: "0.000021"},
+		{value: "1000000000", from: "wei", to: "gwei", want: "1"},
+		{value: "1", from: "wei", to: "ether", want: "0.000000000000000001"},
+		{value: "0x0de0b6b3a7640000", from: "wei", to: "ether", want: "1"},
+		{value: "0.000000001", from: "gwei", to: "wei", want: "1"},
+		{value: "1.000000000000000001", from: "ether", to: "gwei", want: "1000000000.000000001"},
+		{value: "1.5kwei", from: "wei", to: "wei", want: "1500"},
+		{value: "0.5", from: "wei", to: "gwei", wantErr: true},
+		{value: "0.0000000001gwei", from: "wei", to: "wei", wantErr: true},
+		{value: "1.0000000000000000001ether", from: "wei", to: "gwei", wantErr: true},
+		{value: "1.0000000000000000001", from: "ether", to: "ether", wantErr: true},
+		{value: "1", from: "wei", to: "lovelace", wantErr: true},
+		{value: "one", from: "wei", to: "wei", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value+"/"+tc.to, func(t *testing.T) {
+			got, err := convertDenomination(tc.value, tc.from, tc.to)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
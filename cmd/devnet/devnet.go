@@ -0,0 +1,21 @@
+package devnet
+
+import (
+	_ "embed"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// DevnetCmd represents the devnet command
+var DevnetCmd = &cobra.Command{
+	Use:   "devnet",
+	Short: "Launch local multi-node devnets.",
+	Long:  usage,
+}
+
+func init() {
+	DevnetCmd.AddCommand(upCmd)
+}
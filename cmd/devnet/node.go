@@ -0,0 +1,127 @@
+package devnet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// node is a single devnet node running either as a Docker container or as a
+// local subprocess.
+type node struct {
+	Name    string `json:"name"`
+	RPCURL  string `json:"rpcUrl"`
+	Enode   string `json:"enode,omitempty"`
+	DataDir string `json:"dataDir"`
+
+	args []string
+	cmd  *exec.Cmd
+}
+
+// run executes a command to completion with the configured runtime. This is
+// used for one off commands such as initializing the data directory.
+func run(ctx context.Context, name string, args []string) error {
+	cmd := newCommand(ctx, name, args, true)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, string(out))
+	}
+	return nil
+}
+
+// start launches the node in the background. The node output is written to a
+// log file in its data directory.
+func (n *node) start(ctx context.Context) error {
+	logFile, err := os.Create(filepath.Join(n.DataDir, "node.log"))
+	if err != nil {
+		return err
+	}
+
+	n.cmd = newCommand(ctx, n.Name, n.args, false)
+	n.cmd.Stdout = logFile
+	n.cmd.Stderr = logFile
+	if err = n.cmd.Start(); err != nil {
+		return fmt.Errorf("unable to start %s: %w", n.Name, err)
+	}
+	log.Info().Str("name", n.Name).Str("log", logFile.Name()).Msg("Started node")
+
+	go func() {
+		err := n.cmd.Wait()
+		logFile.Close()
+		if ctx.Err() == nil {
+			log.Error().Err(err).Str("name", n.Name).Msg("Node exited unexpectedly")
+		}
+	}()
+	return nil
+}
+
+// stop terminates the node. Containers are stopped explicitly because killing
+// the docker client doesn't stop the container.
+func (n *node) stop() {
+	if inputUp.Runtime == runtimeDocker {
+		if out, err := exec.Command("docker", "stop", n.Name).CombinedOutput(); err != nil {
+			log.Error().Err(err).Str("name", n.Name).Str("output", string(out)).Msg("Unable to stop container")
+		}
+	}
+	if n.cmd != nil && n.cmd.Process != nil {
+		_ = n.cmd.Process.Kill()
+	}
+	log.Info().Str("name", n.Name).Msg("Stopped node")
+}
+
+// waitForRPC polls the node until it answers eth_chainId.
+func (n *node) waitForRPC(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s rpc at %s didn't come up: %w", n.Name, n.RPCURL, ctx.Err())
+		case <-ticker.C:
+		}
+
+		client, err := util.DialRPC(ctx, n.RPCURL)
+		if err != nil {
+			continue
+		}
+		var chainID hexutil.Big
+		err = client.CallContext(ctx, &chainID, "eth_chainId")
+		client.Close()
+		if err == nil {
+			return nil
+		}
+	}
+}
+
+// newCommand builds the command for the configured runtime. Containers use
+// the host network and mount the data directory at the same path, so the
+// arguments are identical for both runtimes.
+func newCommand(ctx context.Context, name string, args []string, oneOff bool) *exec.Cmd {
+	if inputUp.Runtime == runtimeProcess {
+		return exec.CommandContext(ctx, inputUp.Binary, args...)
+	}
+
+	dockerArgs := []string{"run", "--rm", "--network", "host", "-v", fmt.Sprintf("%s:%s", inputUp.DataDir, inputUp.DataDir)}
+	if !oneOff {
+		dockerArgs = append(dockerArgs, "--name", name)
+	}
+	if inputUp.Client == clientAnvil {
+		// The foundry image doesn't use anvil as its entrypoint.
+		dockerArgs = append(dockerArgs, "--entrypoint", "anvil")
+	}
+	dockerArgs = append(dockerArgs, inputUp.Image)
+	dockerArgs = append(dockerArgs, args...)
+	return exec.CommandContext(ctx, "docker", dockerArgs...)
+}
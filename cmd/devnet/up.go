@@ -0,0 +1,422 @@
+package devnet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/maticnetwork/polygon-cli/cmd/nodekey"
+	"github.com/maticnetwork/polygon-cli/hdwallet"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	clientGeth  = "geth"
+	clientBor   = "bor"
+	clientAnvil = "anvil"
+
+	runtimeDocker  = "docker"
+	runtimeProcess = "process"
+
+	// signerPassword protects the keystore of the clique signer. The devnet
+	// keys are derived from a well known mnemonic, so this is only here
+	// because geth requires one.
+	signerPassword = "password"
+)
+
+type (
+	upParams struct {
+		Client    string
+		Runtime   string
+		Image     string
+		Binary    string
+		DataDir   string
+		Nodes     uint
+		ChainID   uint64
+		Period    uint64
+		Mnemonic  string
+		Password  string
+		Accounts  uint
+		Balance   uint64
+		RPCPort   int
+		P2PPort   int
+		GasLimit  uint64
+		RPCWait   time.Duration
+		KeepState bool
+	}
+
+	account struct {
+		Address    ethcommon.Address `json:"address"`
+		PrivateKey string            `json:"privateKey"`
+		key        *ecdsa.PrivateKey
+	}
+)
+
+var (
+	inputUp upParams
+
+	defaultImages = map[string]string{
+		clientGeth:  "ethereum/client-go:v1.13.15",
+		clientBor:   "0xpolygon/bor:latest",
+		clientAnvil: "ghcr.io/foundry-rs/foundry:latest",
+	}
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Start a local devnet and keep it running until interrupted.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains([]string{clientGeth, clientBor, clientAnvil}, inputUp.Client) {
+			return fmt.Errorf("client must be one of [%s, %s, %s]", clientGeth, clientBor, clientAnvil)
+		}
+		if !slices.Contains([]string{runtimeDocker, runtimeProcess}, inputUp.Runtime) {
+			return fmt.Errorf("runtime must be one of [%s, %s]", runtimeDocker, runtimeProcess)
+		}
+		if inputUp.Nodes == 0 {
+			return fmt.Errorf("at least one node is required")
+		}
+		if inputUp.Client != clientGeth && inputUp.Nodes > 1 {
+			return fmt.Errorf("only the %s client supports multiple nodes", clientGeth)
+		}
+		if inputUp.Accounts == 0 {
+			return fmt.Errorf("at least one account is required")
+		}
+		if inputUp.Client == clientAnvil && inputUp.Password != "" {
+			return fmt.Errorf("the %s client doesn't support a mnemonic password", clientAnvil)
+		}
+		if inputUp.Image == "" {
+			inputUp.Image = defaultImages[inputUp.Client]
+		}
+		if inputUp.Binary == "" {
+			inputUp.Binary = inputUp.Client
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if inputUp.DataDir == "" {
+			dir, err := os.MkdirTemp("", "polycli-devnet-")
+			if err != nil {
+				return err
+			}
+			inputUp.DataDir = dir
+		}
+		dataDir, err := filepath.Abs(inputUp.DataDir)
+		if err != nil {
+			return err
+		}
+		inputUp.DataDir = dataDir
+		if err = os.MkdirAll(dataDir, 0755); err != nil {
+			return err
+		}
+
+		// The nodes are stopped and the data directory is removed however the
+		// command ends, including when the setup fails.
+		var nodes []*node
+		defer func() {
+			for _, n := range nodes {
+				n.stop()
+			}
+			if !inputUp.KeepState {
+				if err := os.RemoveAll(inputUp.DataDir); err != nil {
+					log.Error().Err(err).Msg("Unable to remove data directory")
+				}
+			}
+		}()
+
+		if inputUp.Mnemonic, err = util.ResolveSecret(inputUp.Mnemonic); err != nil {
+			return err
+		}
+		if inputUp.Password, err = util.ResolveSecret(inputUp.Password); err != nil {
+			return err
+		}
+		accounts, err := deriveAccounts(inputUp.Mnemonic, inputUp.Password, inputUp.Accounts)
+		if err != nil {
+			return err
+		}
+
+		switch inputUp.Client {
+		case clientGeth:
+			nodes, err = setupGeth(ctx, accounts)
+		case clientBor:
+			nodes, err = setupBor()
+		case clientAnvil:
+			nodes = setupAnvil()
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, n := range nodes {
+			if err = os.MkdirAll(n.DataDir, 0755); err != nil {
+				return err
+			}
+			if err = n.start(ctx); err != nil {
+				return err
+			}
+		}
+		for _, n := range nodes {
+			if err = n.waitForRPC(ctx, inputUp.RPCWait); err != nil {
+				return err
+			}
+		}
+
+		if inputUp.Client == clientBor {
+			if err = fundAccounts(ctx, nodes[0].RPCURL, accounts); err != nil {
+				return err
+			}
+		}
+
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"chainId":  inputUp.ChainID,
+			"nodes":    nodes,
+			"accounts": accounts,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		log.Info().Str("dataDir", inputUp.DataDir).Msg("Devnet is up, press ctrl+c to stop it")
+		<-ctx.Done()
+		return nil
+	},
+}
+
+func init() {
+	flags := upCmd.PersistentFlags()
+	flags.StringVar(&inputUp.Client, "client", clientGeth, "the client to run [geth, bor, anvil]")
+	flags.StringVar(&inputUp.Runtime, "runtime", runtimeDocker, "how to run the nodes [docker, process]")
+	flags.StringVar(&inputUp.Image, "image", "", "the docker image to use (defaults to an image of the client)")
+	flags.StringVar(&inputUp.Binary, "binary", "", "the client binary to use with the process runtime (defaults to the client name)")
+	flags.StringVar(&inputUp.DataDir, "datadir", "", "where to store the node data (defaults to a temporary directory)")
+	flags.BoolVar(&inputUp.KeepState, "keep", false, "keep the data directory once the devnet is stopped")
+	flags.UintVar(&inputUp.Nodes, "nodes", 1, "the number of nodes to run")
+	flags.Uint64Var(&inputUp.ChainID, "chain-id", 1337, "the chain id")
+	flags.Uint64Var(&inputUp.Period, "period", 2, "the block time in seconds")
+	flags.StringVar(&inputUp.Mnemonic, "mnemonic", "code code code code code code code code code code code quality", "the mnemonic used to derive the funded accounts. "+util.SecretHelp)
+	flags.StringVar(&inputUp.Password, "password", "", "the BIP-39 passphrase used along with the mnemonic. "+util.SecretHelp)
+	flags.UintVar(&inputUp.Accounts, "accounts", 10, "the number of accounts to fund")
+	flags.Uint64Var(&inputUp.Balance, "balance", 1000000, "the balance in ether of each funded account")
+	flags.Uint64Var(&inputUp.GasLimit, "gas-limit", 30000000, "the block gas limit")
+	flags.IntVar(&inputUp.RPCPort, "rpc-port", 8545, "the rpc port of the first node, the other nodes use the following ports")
+	flags.IntVar(&inputUp.P2PPort, "p2p-port", 30303, "the p2p port of the first node, the other nodes use the following ports")
+	flags.DurationVar(&inputUp.RPCWait, "rpc-wait", time.Minute, "how long to wait for the nodes to be ready")
+}
+
+// deriveAccounts derives the accounts from the mnemonic using the default
+// ethereum derivation path.
+func deriveAccounts(mnemonic, password string, count uint) ([]account, error) {
+	pw, err := hdwallet.NewPolyWallet(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]account, 0, count)
+	for i := uint(0); i < count; i++ {
+		k, err := pw.GetKeyForPath(fmt.Sprintf("m/44'/60'/0'/0/%d", i))
+		if err != nil {
+			return nil, err
+		}
+		key, err := crypto.ToECDSA(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account{
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: hexutil.Encode(k.Key),
+			key:        key,
+		})
+	}
+	return accounts, nil
+}
+
+func etherToWei(ether uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(ether), big.NewInt(params.Ether))
+}
+
+// setupGeth writes the shared clique genesis, the node keys, and the signer
+// keystore, then initializes the data directory of every node. Discovery is
+// disabled, so the other nodes have the signer as a static peer to sync from.
+func setupGeth(ctx context.Context, accounts []account) ([]*node, error) {
+	signer := accounts[0]
+	genesis := core.DeveloperGenesisBlock(inputUp.Period, inputUp.GasLimit, signer.Address)
+	genesis.Config.ChainID = new(big.Int).SetUint64(inputUp.ChainID)
+	for _, a := range accounts {
+		genesis.Alloc[a.Address] = core.GenesisAccount{Balance: etherToWei(inputUp.Balance)}
+	}
+
+	genesisFile := filepath.Join(inputUp.DataDir, "genesis.json")
+	data, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(genesisFile, data, 0644); err != nil {
+		return nil, err
+	}
+
+	var signerNode string
+	nodes := make([]*node, 0, inputUp.Nodes)
+	for i := 0; i < int(inputUp.Nodes); i++ {
+		n := &node{
+			Name:    fmt.Sprintf("polycli-devnet-%d", i),
+			RPCURL:  fmt.Sprintf("http://127.0.0.1:%d", inputUp.RPCPort+i),
+			DataDir: filepath.Join(inputUp.DataDir, fmt.Sprintf("node-%d", i)),
+		}
+		if err = os.MkdirAll(n.DataDir, 0755); err != nil {
+			return nil, err
+		}
+
+		nodeKey, err := nodekey.NewDevp2pKey("")
+		if err != nil {
+			return nil, err
+		}
+		nodeKeyFile := filepath.Join(n.DataDir, "nodekey")
+		if err = crypto.SaveECDSA(nodeKeyFile, nodeKey); err != nil {
+			return nil, err
+		}
+		p2pPort := inputUp.P2PPort + i
+		n.Enode = enode.NewV4(&nodeKey.PublicKey, []byte{127, 0, 0, 1}, p2pPort, p2pPort).URLv4()
+
+		n.args = []string{
+			"--datadir", n.DataDir,
+			"--networkid", strconv.FormatUint(inputUp.ChainID, 10),
+			"--nodekey", nodeKeyFile,
+			"--port", strconv.Itoa(p2pPort),
+			"--authrpc.port", strconv.Itoa(8551 + i),
+			"--http", "--http.addr", "0.0.0.0", "--http.port", strconv.Itoa(inputUp.RPCPort + i),
+			"--http.api", "eth,net,web3,txpool,debug",
+			"--http.corsdomain", "*",
+			"--syncmode", "full",
+			"--nodiscover",
+			"--ipcdisable",
+		}
+
+		if i == 0 {
+			if err = importSigner(n.DataDir, signer); err != nil {
+				return nil, err
+			}
+			n.args = append(n.args,
+				"--mine", "--miner.etherbase", signer.Address.Hex(),
+				"--unlock", signer.Address.Hex(),
+				"--password", filepath.Join(n.DataDir, "password"),
+				"--allow-insecure-unlock",
+			)
+			signerNode = n.Enode
+		} else {
+			configFile := filepath.Join(n.DataDir, "config.toml")
+			config := fmt.Sprintf("[Node.P2P]\nStaticNodes = [%q]\n", signerNode)
+			if err = os.WriteFile(configFile, []byte(config), 0644); err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, "--config", configFile)
+		}
+
+		if err = run(ctx, n.Name, []string{"init", "--datadir", n.DataDir, genesisFile}); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// importSigner writes the keystore and password file of the clique signer.
+func importSigner(dataDir string, signer account) error {
+	ks := keystore.NewKeyStore(filepath.Join(dataDir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	if _, err := ks.ImportECDSA(signer.key, signerPassword); err != nil {
+		return fmt.Errorf("unable to import signer key: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dataDir, "password"), []byte(signerPassword), 0600)
+}
+
+// setupBor runs bor in developer mode, which doesn't require heimdall.
+func setupBor() ([]*node, error) {
+	n := &node{
+		Name:    "polycli-devnet-0",
+		RPCURL:  fmt.Sprintf("http://127.0.0.1:%d", inputUp.RPCPort),
+		DataDir: filepath.Join(inputUp.DataDir, "node-0"),
+	}
+	n.args = []string{
+		"server",
+		"--datadir", n.DataDir,
+		"--dev", "--dev.period", strconv.FormatUint(inputUp.Period, 10),
+		"--bor.withoutheimdall",
+		"--port", strconv.Itoa(inputUp.P2PPort),
+		"--http", "--http.addr", "0.0.0.0", "--http.port", strconv.Itoa(inputUp.RPCPort),
+		"--http.api", "eth,net,web3,txpool,debug,bor",
+		"--nodiscover",
+	}
+	return []*node{n}, nil
+}
+
+// setupAnvil runs anvil which funds the mnemonic accounts by itself.
+func setupAnvil() []*node {
+	n := &node{
+		Name:    "polycli-devnet-0",
+		RPCURL:  fmt.Sprintf("http://127.0.0.1:%d", inputUp.RPCPort),
+		DataDir: filepath.Join(inputUp.DataDir, "node-0"),
+	}
+	n.args = []string{
+		"--host", "0.0.0.0",
+		"--port", strconv.Itoa(inputUp.RPCPort),
+		"--chain-id", strconv.FormatUint(inputUp.ChainID, 10),
+		"--mnemonic", inputUp.Mnemonic,
+		"--accounts", strconv.FormatUint(uint64(inputUp.Accounts), 10),
+		"--balance", strconv.FormatUint(inputUp.Balance, 10),
+		"--gas-limit", strconv.FormatUint(inputUp.GasLimit, 10),
+		"--block-time", strconv.FormatUint(inputUp.Period, 10),
+	}
+	return []*node{n}
+}
+
+// fundAccounts transfers the balance to each account from the node's unlocked
+// developer account.
+func fundAccounts(ctx context.Context, rpcURL string, accounts []account) error {
+	client, err := util.DialRPC(ctx, rpcURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var devAccounts []ethcommon.Address
+	if err = client.CallContext(ctx, &devAccounts, "eth_accounts"); err != nil {
+		return fmt.Errorf("unable to fetch the developer account: %w", err)
+	}
+	if len(devAccounts) == 0 {
+		return fmt.Errorf("the node has no unlocked account to fund from")
+	}
+
+	for _, a := range accounts {
+		var txHash ethcommon.Hash
+		tx := map[string]interface{}{
+			"from":  devAccounts[0],
+			"to":    a.Address,
+			"value": (*hexutil.Big)(etherToWei(inputUp.Balance)),
+		}
+		if err = client.CallContext(ctx, &txHash, "eth_sendTransaction", tx); err != nil {
+			return fmt.Errorf("unable to fund %s: %w", a.Address.Hex(), err)
+		}
+		log.Info().Str("address", a.Address.Hex()).Str("txHash", txHash.Hex()).Msg("Funded account")
+	}
+	return nil
+}
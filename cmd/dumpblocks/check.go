@@ -0,0 +1,395 @@
+package dumpblocks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	checkParams struct {
+		MaxProblems    int
+		AllowGaps      bool
+		AllowUnordered bool
+	}
+
+	// checkRecord has the fields of the blocks and receipts in a dump that
+	// are checked. Blocks have a parent hash and transactions, and receipts
+	// have the hash of their block.
+	checkRecord struct {
+		Number           *hexutil.Big      `json:"number"`
+		Hash             *ethcommon.Hash   `json:"hash"`
+		ParentHash       *ethcommon.Hash   `json:"parentHash"`
+		TransactionsRoot *ethcommon.Hash   `json:"transactionsRoot"`
+		Transactions     []json.RawMessage `json:"transactions"`
+		BlockHash        *ethcommon.Hash   `json:"blockHash"`
+		TransactionHash  *ethcommon.Hash   `json:"transactionHash"`
+	}
+
+	checkTx struct {
+		Hash        ethcommon.Hash     `json:"hash"`
+		From        ethcommon.Address  `json:"from"`
+		To          *ethcommon.Address `json:"to"`
+		BlockHash   *ethcommon.Hash    `json:"blockHash"`
+		BlockNumber *hexutil.Big       `json:"blockNumber"`
+	}
+
+	checkedBlock struct {
+		hash       ethcommon.Hash
+		parentHash ethcommon.Hash
+		txs        int
+	}
+
+	blockGap struct {
+		From uint64 `json:"from"`
+		To   uint64 `json:"to"`
+	}
+
+	checkReport struct {
+		Format           string     `json:"format"`
+		Records          int        `json:"records"`
+		Blocks           int        `json:"blocks"`
+		Receipts         int        `json:"receipts"`
+		FirstBlock       uint64     `json:"firstBlock"`
+		LastBlock        uint64     `json:"lastBlock"`
+		SchemaErrors     int        `json:"schemaErrors"`
+		Duplicates       int        `json:"duplicates"`
+		Conflicts        int        `json:"conflicts"`
+		OutOfOrder       int        `json:"outOfOrder"`
+		BrokenLinks      int        `json:"brokenLinks"`
+		TxRootMismatches int        `json:"txRootMismatches"`
+		TxRootUnchecked  int        `json:"txRootUnchecked"`
+		TxMismatches     int        `json:"txMismatches"`
+		ReceiptErrors    int        `json:"receiptErrors"`
+		MissingBlocks    uint64     `json:"missingBlocks"`
+		Gaps             []blockGap `json:"gaps,omitempty"`
+		Problems         []string   `json:"problems,omitempty"`
+		Valid            bool       `json:"valid"`
+	}
+)
+
+var inputCheck checkParams
+
+var checkCmd = &cobra.Command{
+	Use:   "check [file]",
+	Short: "Check that a dump is complete and consistent before forging it.",
+	Long: `Check the blocks and receipts of a dump written by dumpblocks end to end, so that
+broken dumps are caught before they're forged or replayed. JSON and protobuf
+dumps are detected, they can be gzipped, and stdin is read if no file is given.
+
+Every record is validated against the schema: the eth_getBlockByNumber and
+eth_getTransactionReceipt schemas for JSON dumps and the embedded .proto schema
+for protobuf dumps. Then the blocks are checked to be in ascending order without
+duplicates, to link to the hash of their parent when it's in the dump, and to
+have the transactions root of their transactions, and the receipts to belong to
+dumped blocks, with one receipt for every transaction. Finally, the gaps in the
+block numbers are listed.
+
+The command fails if there are any problems. Dumps written with more than one
+thread aren't in order, and filtered dumps have gaps, which --allow-unordered
+and --allow-gaps accept.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := os.Stdin
+		if len(args) > 0 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("unable to open dump: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		r, err := openDump(in)
+		if err != nil {
+			return err
+		}
+
+		report, err := checkDump(bufio.NewReader(r))
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		if !report.Valid {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the dump has %d problems", report.problemCount())
+		}
+		return nil
+	},
+}
+
+func init() {
+	checkCmd.Flags().IntVar(&inputCheck.MaxProblems, "max-problems", 50, "the maximum number of problems to list")
+	checkCmd.Flags().BoolVar(&inputCheck.AllowGaps, "allow-gaps", false, "don't fail when there are gaps in the block numbers")
+	checkCmd.Flags().BoolVar(&inputCheck.AllowUnordered, "allow-unordered", false, "don't fail when the blocks aren't in ascending order")
+	DumpblocksCmd.AddCommand(checkCmd)
+}
+
+func (r *checkReport) problem(format string, args ...interface{}) {
+	if len(r.Problems) < inputCheck.MaxProblems {
+		r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// orderProblem lists the problem unless unordered dumps are allowed, which
+// may have duplicates as well.
+func (r *checkReport) orderProblem(format string, args ...interface{}) {
+	if !inputCheck.AllowUnordered {
+		r.problem(format, args...)
+	}
+}
+
+func (r *checkReport) problemCount() int {
+	n := r.SchemaErrors + r.Conflicts + r.BrokenLinks + r.TxRootMismatches + r.TxMismatches + r.ReceiptErrors
+	if !inputCheck.AllowUnordered {
+		n += r.Duplicates + r.OutOfOrder
+	}
+	if !inputCheck.AllowGaps {
+		n += len(r.Gaps)
+	}
+	return n
+}
+
+// checkDump reads the records of the dump and checks them.
+func checkDump(r *bufio.Reader) (*checkReport, error) {
+	report := &checkReport{Format: "proto"}
+	if first, err := r.Peek(1); err == nil && (first[0] == '{' || first[0] == ' ' || first[0] == '\n') {
+		report.Format = "json"
+	}
+
+	blockSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(rpctypes.RPCSchemaEthBlock))
+	if err != nil {
+		return nil, err
+	}
+	receiptSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(rpctypes.RPCSchemaEthReceipt))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[uint64]checkedBlock)
+	receipts := make(map[ethcommon.Hash]int)
+	var last *uint64
+	for {
+		var (
+			data     []byte
+			problems []string
+		)
+		if report.Format == "json" {
+			data, err = r.ReadBytes('\n')
+			if errors.Is(err, io.EOF) && len(bytes.TrimSpace(data)) > 0 {
+				err = nil
+			}
+			if err == nil && len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+		} else {
+			var record []byte
+			if record, err = readRecord(r); err == nil {
+				msg, _, recordProblems := decodeRecord(record, recordTypeAuto)
+				problems = recordProblems
+				data, err = protojson.Marshal(msg)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read record %d: %w", report.Records, err)
+		}
+		index := report.Records
+		report.Records++
+
+		var record checkRecord
+		if err = json.Unmarshal(data, &record); err != nil {
+			report.SchemaErrors++
+			report.problem("record %d: %v", index, err)
+			continue
+		}
+		isBlock := record.ParentHash != nil
+		if report.Format == "json" {
+			schema := receiptSchema
+			if isBlock {
+				schema = blockSchema
+			}
+			result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+			if err != nil {
+				return nil, fmt.Errorf("unable to validate record %d: %w", index, err)
+			}
+			for _, desc := range result.Errors() {
+				problems = append(problems, desc.String())
+			}
+		}
+		if len(problems) > 0 {
+			report.SchemaErrors++
+			report.problem("record %d doesn't match the schema: %v", index, problems)
+		}
+
+		if !isBlock {
+			if record.BlockHash == nil {
+				report.ReceiptErrors++
+				report.problem("record %d is neither a block nor a receipt", index)
+				continue
+			}
+			report.Receipts++
+			receipts[*record.BlockHash]++
+			continue
+		}
+
+		if record.Number == nil || record.Hash == nil {
+			report.SchemaErrors++
+			report.problem("block record %d has no number or hash", index)
+			continue
+		}
+		number := record.Number.ToInt().Uint64()
+		report.Blocks++
+		if last != nil && number <= *last {
+			report.OutOfOrder++
+			report.orderProblem("block %d comes after block %d", number, *last)
+		}
+		last = &number
+
+		if seen, ok := blocks[number]; ok {
+			if seen.hash == *record.Hash {
+				report.Duplicates++
+				report.orderProblem("block %d is in the dump more than once", number)
+			} else {
+				report.Conflicts++
+				report.problem("block %d is in the dump as both %s and %s", number, seen.hash.Hex(), record.Hash.Hex())
+			}
+			continue
+		}
+		blocks[number] = checkedBlock{hash: *record.Hash, parentHash: *record.ParentHash, txs: len(record.Transactions)}
+		checkTransactions(report, number, &record)
+	}
+
+	checkLinks(report, blocks, receipts)
+	report.Valid = report.problemCount() == 0
+	return report, nil
+}
+
+// checkTransactions checks that the full transactions of the block belong to
+// it and recomputes the transactions root. Blocks with transactions of a type
+// that can't be encoded, or only with their hashes, are left unchecked. Bor
+// state sync transactions aren't part of the root.
+func checkTransactions(report *checkReport, number uint64, record *checkRecord) {
+	encoded := make([][]byte, 0, len(record.Transactions))
+	checkRoot := record.TransactionsRoot != nil
+	for i, raw := range record.Transactions {
+		var meta checkTx
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			checkRoot = false
+			continue
+		}
+		if meta.BlockHash != nil && *meta.BlockHash != *record.Hash ||
+			meta.BlockNumber != nil && meta.BlockNumber.ToInt().Uint64() != number {
+			report.TxMismatches++
+			report.problem("transaction %d of block %d is for another block", i, number)
+		}
+		if meta.From == (ethcommon.Address{}) && meta.To != nil && *meta.To == (ethcommon.Address{}) {
+			continue
+		}
+
+		tx := new(types.Transaction)
+		if err := json.Unmarshal(raw, tx); err != nil {
+			checkRoot = false
+			continue
+		}
+		if tx.Hash() != meta.Hash {
+			report.TxMismatches++
+			report.problem("transaction %d of block %d hashes to %s rather than %s", i, number, tx.Hash().Hex(), meta.Hash.Hex())
+		}
+		b, err := tx.MarshalBinary()
+		if err != nil {
+			checkRoot = false
+			continue
+		}
+		encoded = append(encoded, b)
+	}
+
+	if !checkRoot {
+		report.TxRootUnchecked++
+		return
+	}
+	if root := util.DeriveListRoot(encoded); root != *record.TransactionsRoot {
+		report.TxRootMismatches++
+		report.problem("block %d has a transactions root of %s, but its transactions have %s", number, record.TransactionsRoot.Hex(), root.Hex())
+	}
+}
+
+// checkLinks checks the parent hashes of the blocks whose parents are in the
+// dump, finds the gaps, and matches the receipts with the blocks.
+func checkLinks(report *checkReport, blocks map[uint64]checkedBlock, receipts map[ethcommon.Hash]int) {
+	numbers := make([]uint64, 0, len(blocks))
+	hashes := make(map[ethcommon.Hash]checkedBlock, len(blocks))
+	for number, block := range blocks {
+		numbers = append(numbers, number)
+		hashes[block.hash] = block
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	for i, number := range numbers {
+		if i == 0 {
+			report.FirstBlock = number
+			continue
+		}
+		prev := numbers[i-1]
+		if prev != number-1 {
+			report.Gaps = append(report.Gaps, blockGap{From: prev + 1, To: number - 1})
+			report.MissingBlocks += number - prev - 1
+			continue
+		}
+		if parent := blocks[prev].hash; blocks[number].parentHash != parent {
+			report.BrokenLinks++
+			report.problem("block %d has a parent hash of %s, but block %d is %s", number, blocks[number].parentHash.Hex(), prev, parent.Hex())
+		}
+	}
+	if len(numbers) > 0 {
+		report.LastBlock = numbers[len(numbers)-1]
+	}
+	if !inputCheck.AllowGaps {
+		for _, gap := range report.Gaps {
+			report.problem("blocks %d to %d are missing", gap.From, gap.To)
+		}
+	}
+
+	if report.Receipts == 0 {
+		return
+	}
+	for hash, count := range receipts {
+		block, ok := hashes[hash]
+		if !ok {
+			report.ReceiptErrors++
+			report.problem("%d receipts are for block %s, which isn't in the dump", count, hash.Hex())
+			continue
+		}
+		if count != block.txs {
+			report.ReceiptErrors++
+			report.problem("block %s has %d transactions but %d receipts", hash.Hex(), block.txs, count)
+		}
+	}
+	for _, number := range numbers {
+		if block := blocks[number]; block.txs > 0 && receipts[block.hash] == 0 {
+			report.ReceiptErrors++
+			report.problem("block %d has %d transactions but no receipts", number, block.txs)
+		}
+	}
+}
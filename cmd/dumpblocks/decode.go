@@ -0,0 +1,233 @@
+package dumpblocks
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	dumpschema "github.com/maticnetwork/polygon-cli/proto"
+	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	recordTypeAuto        = "auto"
+	recordTypeBlock       = "block"
+	recordTypeTransaction = "transaction"
+
+	// maxRecordSize guards against reading a corrupt length prefix.
+	maxRecordSize = 256 * 1024 * 1024
+)
+
+type decodeParams struct {
+	RecordType   string
+	ShouldSchema bool
+}
+
+var inputDecode decodeParams
+
+var decodeCmd = &cobra.Command{
+	Use:   "decode [file]",
+	Short: "Decode a protobuf dump into JSON and validate it against the schema.",
+	Long: `Decode the records of a protobuf dump written with --mode proto and print them as JSON. The file can be gzipped, and stdin is read if no file is given.
+
+Every record is validated against the schema that's embedded in polycli. Records with fields that aren't part of the schema, or that were written with a newer schema version, are reported and make the command fail.
+
+Blocks and receipts are written to the same file, so the type of each record is detected. Use --type to skip the detection. The embedded .proto files can be printed with --schema.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains([]string{recordTypeAuto, recordTypeBlock, recordTypeTransaction}, inputDecode.RecordType) {
+			return fmt.Errorf("type must be one of [%s, %s, %s]", recordTypeAuto, recordTypeBlock, recordTypeTransaction)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if inputDecode.ShouldSchema {
+			return printSchemas()
+		}
+
+		in := os.Stdin
+		if len(args) > 0 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("unable to open dump: %w", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		r, err := openDump(in)
+		if err != nil {
+			return err
+		}
+
+		var records, invalid int
+		marshaler := protojson.MarshalOptions{Multiline: true, Indent: "  "}
+		for {
+			data, err := readRecord(r)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("unable to read record %d: %w", records, err)
+			}
+
+			msg, recordType, problems := decodeRecord(data, inputDecode.RecordType)
+			if len(problems) > 0 {
+				invalid++
+				log.Error().Int("record", records).Str("type", recordType).Strs("problems", problems).Msg("Invalid record")
+			}
+			out, err := marshaler.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			records++
+		}
+
+		log.Info().Int("records", records).Int("invalid", invalid).Uint32("schemaVersion", dumpschema.SchemaVersion).Msg("Decoded dump")
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d records don't match schema version %d", invalid, records, dumpschema.SchemaVersion)
+		}
+		return nil
+	},
+}
+
+func init() {
+	decodeCmd.Flags().StringVarP(&inputDecode.RecordType, "type", "t", recordTypeAuto, "the type of the records [auto, block, transaction]")
+	decodeCmd.Flags().BoolVar(&inputDecode.ShouldSchema, "schema", false, "print the embedded .proto files instead of decoding")
+	DumpblocksCmd.AddCommand(decodeCmd)
+}
+
+func printSchemas() error {
+	return fs.WalkDir(dumpschema.Schemas, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := dumpschema.Schemas.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("// %s\n%s\n", path, string(data))
+		return nil
+	})
+}
+
+// openDump returns a reader of the dump, decompressing it if it's gzipped.
+func openDump(in io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(in)
+	magic, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// readRecord reads a record that's prefixed with its length, as written by
+// dumpOutput.writeProto.
+func readRecord(r io.Reader) ([]byte, error) {
+	prefix := make([]byte, 4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated length prefix")
+		}
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(prefix)
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("record size %d is too large, the dump is probably corrupt", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated record: %w", err)
+	}
+	return data, nil
+}
+
+// decodeRecord decodes the record and returns the problems found when
+// validating it against the schema.
+func decodeRecord(data []byte, recordType string) (proto.Message, string, []string) {
+	if recordType == recordTypeAuto {
+		recordType = recordTypeTransaction
+		block := &pb.Block{}
+		// The hash of a block has the same field number as the from address
+		// of a transaction, so the length tells them apart.
+		if err := proto.Unmarshal(data, block); err == nil && len(block.Hash) == 66 && block.Number != "" {
+			recordType = recordTypeBlock
+		}
+	}
+
+	var (
+		msg     proto.Message
+		version uint32
+	)
+	if recordType == recordTypeBlock {
+		msg = &pb.Block{}
+	} else {
+		msg = &pb.Transaction{}
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return msg, recordType, []string{err.Error()}
+	}
+	switch m := msg.(type) {
+	case *pb.Block:
+		version = m.SchemaVersion
+	case *pb.Transaction:
+		version = m.SchemaVersion
+	}
+
+	var problems []string
+	if version > dumpschema.SchemaVersion {
+		problems = append(problems, fmt.Sprintf("written with schema version %d, which is newer than %d", version, dumpschema.SchemaVersion))
+	}
+	for _, field := range unknownFields(msg.ProtoReflect(), "") {
+		problems = append(problems, fmt.Sprintf("unknown field %s", field))
+	}
+	return msg, recordType, problems
+}
+
+// unknownFields returns the paths of the messages with fields that aren't part
+// of the schema, along with the unknown field numbers.
+func unknownFields(m protoreflect.Message, path string) []string {
+	var fields []string
+	for b := m.GetUnknown(); len(b) > 0; {
+		num, _, n := protowire.ConsumeField(b)
+		if n < 0 {
+			fields = append(fields, fmt.Sprintf("%s(malformed)", path))
+			break
+		}
+		fields = append(fields, fmt.Sprintf("%s%d", path, num))
+		b = b[n:]
+	}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+		name := path + string(fd.Name())
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				fields = append(fields, unknownFields(list.Get(i).Message(), fmt.Sprintf("%s[%d].", name, i))...)
+			}
+			return true
+		}
+		fields = append(fields, unknownFields(v.Message(), name+".")...)
+		return true
+	})
+	return fields
+}
@@ -17,11 +17,10 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package dumpblocks
 
 import (
-	"encoding/binary"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +29,7 @@ import (
 	_ "embed"
 
 	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	dumpschema "github.com/maticnetwork/polygon-cli/proto"
 	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
 	"github.com/maticnetwork/polygon-cli/util"
@@ -52,6 +52,13 @@ type (
 		Filename           string
 		Mode               string
 		FilterStr          string
+		ShouldDumpUncles   bool
+		BeaconURL          string
+		BlobArchiveURL     string
+		ShouldDumpBlobData bool
+		Since              string
+		Until              string
+		MaxMemory          string
 		filter             Filter
 	}
 	Filter struct {
@@ -68,22 +75,48 @@ var (
 
 // dumpblocksCmd represents the dumpblocks command
 var DumpblocksCmd = &cobra.Command{
-	Use:   "dumpblocks url start end",
+	Use:   "dumpblocks url [start end]",
 	Short: "Export a range of blocks from a JSON-RPC endpoint.",
 	Long:  usage,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		ec, err := ethrpc.DialContext(ctx, args[0])
+		ec, err := util.DialRPC(ctx, args[0])
 		if err != nil {
 			return err
 		}
 
+		if inputDumpblocks.Since != "" || inputDumpblocks.Until != "" {
+			if err = resolveTimeRange(ctx, ec); err != nil {
+				return err
+			}
+		}
+
+		var beacon *beaconClient
+		if inputDumpblocks.BeaconURL != "" || inputDumpblocks.BlobArchiveURL != "" {
+			beacon, err = newBeaconClient(ctx, inputDumpblocks.BeaconURL, inputDumpblocks.BlobArchiveURL)
+			if err != nil {
+				return err
+			}
+		}
+
+		limiter, err := util.NewMemoryLimiter(inputDumpblocks.MaxMemory)
+		if err != nil {
+			return err
+		}
+		if output, err = openOutput(inputDumpblocks.Filename); err != nil {
+			return err
+		}
+		defer output.Close()
+
 		var wg sync.WaitGroup
 		log.Info().Uint("thread", inputDumpblocks.Threads).Msg("Thread count")
 		var pool = make(chan bool, inputDumpblocks.Threads)
 		start := inputDumpblocks.Start
 		end := inputDumpblocks.End
 
+		progress := util.NewProgress("dumpblocks", "blocks", end-start)
+		defer progress.Done()
+
 		for start < end {
 			rangeStart := start
 			rangeEnd := rangeStart + inputDumpblocks.BatchSize
@@ -92,13 +125,18 @@ var DumpblocksCmd = &cobra.Command{
 				rangeEnd = end
 			}
 
+			// Hold back the next range while the heap is close to the
+			// memory hint, until the ranges in flight are written.
+			if err = limiter.Wait(ctx); err != nil {
+				return err
+			}
 			pool <- true
 			wg.Add(1)
 			log.Info().Uint64("start", rangeStart).Uint64("end", rangeEnd).Msg("Getting range")
 			go func() {
 				defer wg.Done()
+				failCount := 0
 				for {
-					failCount := 0
 					blocks, err := util.GetBlockRange(ctx, rangeStart, rangeEnd, ec)
 					if err != nil {
 						failCount = failCount + 1
@@ -112,34 +150,46 @@ var DumpblocksCmd = &cobra.Command{
 
 					blocks = filterBlocks(blocks)
 
-					if inputDumpblocks.ShouldDumpBlocks {
-						err = writeResponses(blocks, "block")
-						if err != nil {
-							log.Error().Err(err).Msg("Error writing blocks")
+					if inputDumpblocks.ShouldDumpBlocks && inputDumpblocks.ShouldDumpUncles {
+						if err = addUncles(ctx, blocks, ec); err != nil {
+							failCount = failCount + 1
+							if failCount > 5 {
+								log.Error().Err(err).Uint64("rangeStart", rangeStart).Uint64("rangeEnd", rangeEnd).Msg("Unable to fetch uncles")
+								break
+							}
+							time.Sleep(5 * time.Second)
+							continue
 						}
 					}
 
-					if inputDumpblocks.ShouldDumpReceipts {
-						failCount = 0
-						receipts, err := util.GetReceipts(ctx, blocks, ec, inputDumpblocks.BatchSize)
-						if err != nil {
+					if inputDumpblocks.ShouldDumpBlocks && beacon != nil {
+						if err = addBlobSidecars(ctx, blocks, beacon, inputDumpblocks.ShouldDumpBlobData); err != nil {
 							failCount = failCount + 1
 							if failCount > 5 {
-								log.Error().Uint64("rangeStart", rangeStart).Uint64("rangeEnd", rangeEnd).Msg("Unable to fetch receipts")
+								log.Error().Err(err).Uint64("rangeStart", rangeStart).Uint64("rangeEnd", rangeEnd).Msg("Unable to fetch blob sidecars")
 								break
 							}
 							time.Sleep(5 * time.Second)
 							continue
 						}
+					}
 
-						err = writeResponses(receipts, "transaction")
+					if inputDumpblocks.ShouldDumpBlocks {
+						err = writeResponses(blocks, "block")
 						if err != nil {
-							log.Error().Err(err).Msg("Error writing receipts")
+							log.Error().Err(err).Msg("Error writing blocks")
+						}
+					}
+
+					if inputDumpblocks.ShouldDumpReceipts {
+						if err = dumpReceipts(ctx, blocks, ec); err != nil {
+							log.Error().Err(err).Uint64("rangeStart", rangeStart).Uint64("rangeEnd", rangeEnd).Msg("Unable to fetch receipts")
 						}
 					}
 
 					break
 				}
+				progress.Add(rangeEnd - rangeStart)
 				<-pool
 			}()
 			start = rangeEnd
@@ -152,7 +202,17 @@ var DumpblocksCmd = &cobra.Command{
 		return nil
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 3 {
+		byTime := inputDumpblocks.Since != "" || inputDumpblocks.Until != ""
+		if byTime {
+			if len(args) != 1 {
+				return fmt.Errorf("the start and end blocks can't be used with --since or --until")
+			}
+			for _, t := range []string{inputDumpblocks.Since, inputDumpblocks.Until} {
+				if _, err := parseTime(t); t != "" && err != nil {
+					return err
+				}
+			}
+		} else if len(args) < 3 {
 			return fmt.Errorf("command needs at least three arguments. A URL a start block and an end block")
 		}
 
@@ -160,6 +220,11 @@ var DumpblocksCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		inputDumpblocks.URL = args[0]
+		if byTime {
+			return validateDumpblocksFlags()
+		}
+
 		start, err := strconv.ParseInt(args[1], 10, 64)
 		if err != nil {
 			return err
@@ -175,31 +240,34 @@ var DumpblocksCmd = &cobra.Command{
 			start, end = end, start
 		}
 
-		inputDumpblocks.URL = args[0]
 		inputDumpblocks.Start = uint64(start)
 		inputDumpblocks.End = uint64(end)
 
-		if inputDumpblocks.Threads == 0 {
-			inputDumpblocks.Threads = 1
-		}
-		if !slices.Contains([]string{"json", "proto"}, inputDumpblocks.Mode) {
-			return fmt.Errorf("output format must one of [json, proto]")
-		}
+		return validateDumpblocksFlags()
+	},
+}
 
-		if err := json.Unmarshal([]byte(inputDumpblocks.FilterStr), &inputDumpblocks.filter); err != nil {
-			return fmt.Errorf("could not unmarshal filter string")
-		}
+func validateDumpblocksFlags() error {
+	if inputDumpblocks.Threads == 0 {
+		inputDumpblocks.Threads = 1
+	}
+	if !slices.Contains([]string{"json", "proto"}, inputDumpblocks.Mode) {
+		return fmt.Errorf("output format must one of [json, proto]")
+	}
 
-		// Make sure the filters are all lowercase.
-		for i := 0; i < len(inputDumpblocks.filter.To); i++ {
-			inputDumpblocks.filter.To[i] = strings.ToLower(inputDumpblocks.filter.To[i])
-		}
-		for i := 0; i < len(inputDumpblocks.filter.From); i++ {
-			inputDumpblocks.filter.From[i] = strings.ToLower(inputDumpblocks.filter.From[i])
-		}
+	if err := json.Unmarshal([]byte(inputDumpblocks.FilterStr), &inputDumpblocks.filter); err != nil {
+		return fmt.Errorf("could not unmarshal filter string")
+	}
 
-		return nil
-	},
+	// Make sure the filters are all lowercase.
+	for i := 0; i < len(inputDumpblocks.filter.To); i++ {
+		inputDumpblocks.filter.To[i] = strings.ToLower(inputDumpblocks.filter.To[i])
+	}
+	for i := 0; i < len(inputDumpblocks.filter.From); i++ {
+		inputDumpblocks.filter.From[i] = strings.ToLower(inputDumpblocks.filter.From[i])
+	}
+
+	return nil
 }
 
 func init() {
@@ -210,6 +278,13 @@ func init() {
 	DumpblocksCmd.PersistentFlags().StringVarP(&inputDumpblocks.Mode, "mode", "m", "json", "the output format [json, proto]")
 	DumpblocksCmd.PersistentFlags().Uint64VarP(&inputDumpblocks.BatchSize, "batch-size", "b", 150, "the batch size. Realistically, this probably shouldn't be bigger than 999. Most providers seem to cap at 1000.")
 	DumpblocksCmd.PersistentFlags().StringVarP(&inputDumpblocks.FilterStr, "filter", "F", "{}", "filter output based on tx to and from, not setting a filter means all are allowed")
+	DumpblocksCmd.PersistentFlags().BoolVar(&inputDumpblocks.ShouldDumpUncles, "dump-uncles", false, "if the uncle headers will be dumped with their blocks")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.BeaconURL, "beacon-url", "", "the beacon node api used to dump the blob sidecars of blocks with blob transactions")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.BlobArchiveURL, "blob-archive-url", "", "a blob archive serving the beacon blob sidecars api, used for blobs the beacon node has pruned")
+	DumpblocksCmd.PersistentFlags().BoolVar(&inputDumpblocks.ShouldDumpBlobData, "blob-data", true, "if the blobs will be dumped along with their commitments and proofs")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.Since, "since", "", "dump the blocks from this date or time instead of a start block, e.g. 2024-01-01 or 2024-01-01T12:00:00Z")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.MaxMemory, "max-memory", "", util.MaxMemoryHelp)
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.Until, "until", "", "dump the blocks before this date or time instead of an end block, e.g. 2024-02-01")
 }
 
 // writeResponses writes the data to either stdout or a file if one is provided.
@@ -218,10 +293,11 @@ func init() {
 func writeResponses(msg []*json.RawMessage, msgType string) error {
 	switch inputDumpblocks.Mode {
 	case "json":
-		if err := writeJSON(msg); err != nil {
+		if err := output.writeJSON(msg); err != nil {
 			log.Error().Err(err).Msgf("Failed to write %s json", msgType)
 		}
 	case "proto":
+		records := make([][]byte, 0, len(msg))
 		for _, b := range msg {
 			var protoMsg proto.Message
 			switch msgType {
@@ -237,71 +313,75 @@ func writeResponses(msg []*json.RawMessage, msgType string) error {
 				continue
 			}
 
+			switch m := protoMsg.(type) {
+			case *pb.Block:
+				m.SchemaVersion = dumpschema.SchemaVersion
+			case *pb.Transaction:
+				m.SchemaVersion = dumpschema.SchemaVersion
+			}
+
 			out, err := proto.Marshal(protoMsg)
 			if err != nil {
 				log.Error().Err(err).Msgf("Failed to marshal %s proto", msgType)
 				continue
 			}
+			records = append(records, out)
+		}
 
-			if err = writeProto(out); err != nil {
-				log.Error().Err(err).Msgf("Failed to write %s proto", msgType)
-				continue
-			}
+		if err := output.writeProto(records); err != nil {
+			log.Error().Err(err).Msgf("Failed to write %s proto", msgType)
 		}
 	}
 
 	return nil
 }
 
-// writeJSON writes the json raw messages to stdout by default and to a file if
-// provided.
-func writeJSON(msg []*json.RawMessage) error {
-	f := os.Stdout
-	if inputDumpblocks.Filename != "" {
-		var err error
-		f, err = os.OpenFile(inputDumpblocks.Filename, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
-		if err != nil {
-			return err
+// dumpReceipts fetches and writes the receipts of the blocks in chunks of
+// about --batch-size transactions, so that only a chunk of receipts is held in
+// memory at a time rather than the receipts of the whole range.
+func dumpReceipts(ctx context.Context, blocks []*json.RawMessage, ec *ethrpc.Client) error {
+	var (
+		chunk []*json.RawMessage
+		txs   uint64
+	)
+	flush := func() error {
+		if txs == 0 {
+			chunk = nil
+			return nil
 		}
+		var (
+			receipts []*json.RawMessage
+			err      error
+		)
+		for failCount := 0; ; failCount++ {
+			if receipts, err = util.GetReceipts(ctx, chunk, ec, inputDumpblocks.BatchSize); err == nil {
+				break
+			}
+			if failCount >= 5 {
+				return err
+			}
+			time.Sleep(5 * time.Second)
+		}
+		chunk, txs = nil, 0
+		return writeResponses(receipts, "transaction")
 	}
 
-	for _, b := range msg {
-		fmt.Fprintln(f, string(*b))
-	}
-
-	return nil
-}
-
-// writeProto writes the buffer data to stdout by default and to a file if
-// provided.
-//
-// It will write first the length of the buffer and then the buffer.
-func writeProto(out []byte) error {
-	f := os.Stdout
-	// Open the file for writing if the filename is provided.
-	if inputDumpblocks.Filename != "" {
-		var err error
-		f, err = os.OpenFile(inputDumpblocks.Filename, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
-		if err != nil {
+	for _, b := range blocks {
+		var block struct {
+			Transactions []struct{} `json:"transactions"`
+		}
+		if err := json.Unmarshal(*b, &block); err != nil {
 			return err
 		}
+		chunk = append(chunk, b)
+		txs += uint64(len(block.Transactions))
+		if txs >= inputDumpblocks.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
 	}
-
-	// Because protobuf isn't a self delimiting format, we write the length of the
-	// bytes to the file as a header. This allows us to correctly read back in the
-	// file.
-	buf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(buf, uint32(len(out)))
-
-	if _, err := f.Write(buf); err != nil {
-		return err
-	}
-
-	if _, err := f.Write(out); err != nil {
-		return err
-	}
-
-	return nil
+	return flush()
 }
 
 // filterBlocks will filter blocks that having transactions with a matching to or
@@ -0,0 +1,250 @@
+package dumpblocks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// blobCommitmentVersionKZG is the version byte of the versioned hashes of KZG
+// commitments.
+const blobCommitmentVersionKZG = 0x01
+
+type (
+	// extrasBlock has the fields of a block needed to fetch its uncles and
+	// blob sidecars.
+	extrasBlock struct {
+		Number       hexutil.Uint64 `json:"number"`
+		Timestamp    hexutil.Uint64 `json:"timestamp"`
+		Uncles       []string       `json:"uncles"`
+		Transactions []struct {
+			BlobVersionedHashes []string `json:"blobVersionedHashes"`
+		} `json:"transactions"`
+	}
+
+	// blobSidecar is the part of a beacon blob sidecar that is dumped along
+	// with the block.
+	blobSidecar struct {
+		Index         string `json:"index"`
+		Blob          string `json:"blob,omitempty"`
+		KZGCommitment string `json:"kzgCommitment"`
+		KZGProof      string `json:"kzgProof"`
+		VersionedHash string `json:"versionedHash"`
+	}
+
+	beaconBlobSidecar struct {
+		Index         string `json:"index"`
+		Blob          string `json:"blob"`
+		KZGCommitment string `json:"kzg_commitment"`
+		KZGProof      string `json:"kzg_proof"`
+	}
+
+	// beaconClient fetches blob sidecars from a beacon node, falling back to a
+	// blob archive that serves the same API for blobs that have been pruned.
+	beaconClient struct {
+		urls           []string
+		client         *http.Client
+		genesisTime    uint64
+		secondsPerSlot uint64
+	}
+)
+
+// addToBlock adds the field to the JSON object of the block.
+func addToBlock(raw *json.RawMessage, field string, value interface{}) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(*raw, &fields); err != nil {
+		return err
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fields[field] = v
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	*raw = out
+	return nil
+}
+
+// addUncles adds the headers of the uncles of the blocks as uncleHeaders.
+func addUncles(ctx context.Context, blocks []*json.RawMessage, c *ethrpc.Client) error {
+	for _, raw := range blocks {
+		var block extrasBlock
+		if err := json.Unmarshal(*raw, &block); err != nil {
+			return err
+		}
+		if len(block.Uncles) == 0 {
+			continue
+		}
+
+		uncles := make([]*json.RawMessage, len(block.Uncles))
+		elems := make([]ethrpc.BatchElem, len(block.Uncles))
+		for i := range block.Uncles {
+			uncles[i] = new(json.RawMessage)
+			elems[i] = ethrpc.BatchElem{
+				Method: "eth_getUncleByBlockNumberAndIndex",
+				Args:   []interface{}{block.Number, hexutil.Uint64(i)},
+				Result: uncles[i],
+			}
+		}
+		if err := c.BatchCallContext(ctx, elems); err != nil {
+			return err
+		}
+		for _, elem := range elems {
+			if elem.Error != nil {
+				return fmt.Errorf("unable to get uncle of block %d: %w", block.Number, elem.Error)
+			}
+		}
+
+		if err := addToBlock(raw, "uncleHeaders", uncles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newBeaconClient(ctx context.Context, beaconURL, archiveURL string) (*beaconClient, error) {
+	bc := &beaconClient{client: &http.Client{Timeout: 30 * time.Second}}
+	for _, u := range []string{beaconURL, archiveURL} {
+		if u != "" {
+			bc.urls = append(bc.urls, strings.TrimSuffix(u, "/"))
+		}
+	}
+
+	var genesis struct {
+		Data struct {
+			GenesisTime string `json:"genesis_time"`
+		} `json:"data"`
+	}
+	if err := bc.get(ctx, "/eth/v1/beacon/genesis", &genesis); err != nil {
+		return nil, fmt.Errorf("unable to get beacon genesis: %w", err)
+	}
+	var spec struct {
+		Data struct {
+			SecondsPerSlot string `json:"SECONDS_PER_SLOT"`
+		} `json:"data"`
+	}
+	if err := bc.get(ctx, "/eth/v1/config/spec", &spec); err != nil {
+		return nil, fmt.Errorf("unable to get beacon spec: %w", err)
+	}
+
+	var err error
+	if bc.genesisTime, err = strconv.ParseUint(genesis.Data.GenesisTime, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid beacon genesis time: %w", err)
+	}
+	if bc.secondsPerSlot, err = strconv.ParseUint(spec.Data.SecondsPerSlot, 10, 64); err != nil || bc.secondsPerSlot == 0 {
+		return nil, fmt.Errorf("invalid beacon seconds per slot: %s", spec.Data.SecondsPerSlot)
+	}
+	return bc, nil
+}
+
+// get requests the path from each of the urls in order until one has it.
+func (bc *beaconClient) get(ctx context.Context, path string, out interface{}) error {
+	var lastErr error
+	for _, u := range bc.urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+path, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := bc.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s returned %s: %s", u+path, resp.Status, string(body))
+			continue
+		}
+		return json.Unmarshal(body, out)
+	}
+	return lastErr
+}
+
+// addBlobSidecars adds the sidecars of the blobs of the blocks as
+// blobSidecars. The sidecars are matched with the blob transactions by their
+// versioned hashes, and the blob itself is left out unless withBlobs is set.
+func addBlobSidecars(ctx context.Context, blocks []*json.RawMessage, bc *beaconClient, withBlobs bool) error {
+	for _, raw := range blocks {
+		var block extrasBlock
+		if err := json.Unmarshal(*raw, &block); err != nil {
+			return err
+		}
+		var hashes []string
+		for _, tx := range block.Transactions {
+			hashes = append(hashes, tx.BlobVersionedHashes...)
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+
+		if uint64(block.Timestamp) < bc.genesisTime {
+			return fmt.Errorf("block %d is before the beacon genesis", block.Number)
+		}
+		slot := (uint64(block.Timestamp) - bc.genesisTime) / bc.secondsPerSlot
+		var resp struct {
+			Data []beaconBlobSidecar `json:"data"`
+		}
+		if err := bc.get(ctx, fmt.Sprintf("/eth/v1/beacon/blob_sidecars/%d", slot), &resp); err != nil {
+			return fmt.Errorf("unable to get blob sidecars of block %d: %w", block.Number, err)
+		}
+
+		byHash := make(map[string]blobSidecar, len(resp.Data))
+		for _, s := range resp.Data {
+			commitment, err := hexutil.Decode(s.KZGCommitment)
+			if err != nil {
+				return fmt.Errorf("invalid kzg commitment in block %d: %w", block.Number, err)
+			}
+			sidecar := blobSidecar{
+				Index:         s.Index,
+				KZGCommitment: s.KZGCommitment,
+				KZGProof:      s.KZGProof,
+				VersionedHash: kzgToVersionedHash(commitment),
+			}
+			if withBlobs {
+				sidecar.Blob = s.Blob
+			}
+			byHash[sidecar.VersionedHash] = sidecar
+		}
+
+		sidecars := make([]blobSidecar, 0, len(hashes))
+		for _, h := range hashes {
+			sidecar, ok := byHash[strings.ToLower(h)]
+			if !ok {
+				return fmt.Errorf("missing the blob sidecar of %s in block %d", h, block.Number)
+			}
+			sidecars = append(sidecars, sidecar)
+		}
+
+		if err := addToBlock(raw, "blobSidecars", sidecars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kzgToVersionedHash returns the versioned hash of a KZG commitment as defined
+// by EIP-4844.
+func kzgToVersionedHash(commitment []byte) string {
+	h := sha256.Sum256(commitment)
+	h[0] = blobCommitmentVersionKZG
+	return hexutil.Encode(h[:])
+}
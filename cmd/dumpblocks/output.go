@@ -0,0 +1,89 @@
+package dumpblocks
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// outputBufferSize is the size of the buffer the records are written through.
+const outputBufferSize = 1024 * 1024
+
+// dumpOutput is where the records of every range are written. The ranges are
+// fetched concurrently, so the writes are serialized, which keeps the length
+// prefixed protobuf records whole, and they're buffered and flushed after
+// every batch, so that records are streamed out rather than held in memory.
+type dumpOutput struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+}
+
+var output *dumpOutput
+
+// openOutput opens the file to append the records to, or stdout if there's no
+// file.
+func openOutput(filename string) (*dumpOutput, error) {
+	f := os.Stdout
+	if filename != "" {
+		var err error
+		f, err = os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open output file: %w", err)
+		}
+	}
+	return &dumpOutput{f: f, w: bufio.NewWriterSize(f, outputBufferSize)}, nil
+}
+
+// writeJSON writes the json raw messages, one per line, and flushes them.
+func (o *dumpOutput) writeJSON(msg []*json.RawMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, b := range msg {
+		if _, err := o.w.Write(*b); err != nil {
+			return err
+		}
+		if err := o.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return o.w.Flush()
+}
+
+// writeProto writes the encoded records and flushes them.
+//
+// It will write first the length of the buffer and then the buffer.
+func (o *dumpOutput) writeProto(records [][]byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, out := range records {
+		// Because protobuf isn't a self delimiting format, we write the length of the
+		// bytes to the file as a header. This allows us to correctly read back in the
+		// file.
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(len(out)))
+
+		if _, err := o.w.Write(buf); err != nil {
+			return err
+		}
+		if _, err := o.w.Write(out); err != nil {
+			return err
+		}
+	}
+	return o.w.Flush()
+}
+
+func (o *dumpOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.w.Flush(); err != nil {
+		return err
+	}
+	if o.f == os.Stdout {
+		return nil
+	}
+	return o.f.Close()
+}
@@ -0,0 +1,100 @@
+package dumpblocks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// timeLayouts are the formats accepted by --since and --until.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// parseTime parses a date or a time, which is in UTC unless it has an offset.
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s, expected a date like 2024-01-01 or a time like 2024-01-01T15:04:05Z", value)
+}
+
+func getBlockTime(ctx context.Context, c *ethrpc.Client, number uint64) (uint64, error) {
+	var header struct {
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+	}
+	if err := c.CallContext(ctx, &header, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+		return 0, fmt.Errorf("unable to get block %d: %w", number, err)
+	}
+	return uint64(header.Timestamp), nil
+}
+
+// findBlockAtTime binary searches for the first block with a timestamp at or
+// after t. If every block is before t, latest + 1 is returned.
+func findBlockAtTime(ctx context.Context, c *ethrpc.Client, t time.Time, latest uint64) (uint64, error) {
+	target := uint64(t.Unix())
+	if t.Unix() < 0 {
+		target = 0
+	}
+
+	low, high := uint64(0), latest+1
+	for low < high {
+		mid := low + (high-low)/2
+		ts, err := getBlockTime(ctx, c, mid)
+		if err != nil {
+			return 0, err
+		}
+		if ts < target {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+// resolveTimeRange sets the start and end blocks from --since and --until. The
+// range covers the blocks with a timestamp at or after since and before until.
+func resolveTimeRange(ctx context.Context, c *ethrpc.Client) error {
+	var latest hexutil.Uint64
+	if err := c.CallContext(ctx, &latest, "eth_blockNumber"); err != nil {
+		return fmt.Errorf("unable to get the latest block number: %w", err)
+	}
+
+	start, end := uint64(0), uint64(latest)
+	if inputDumpblocks.Since != "" {
+		since, err := parseTime(inputDumpblocks.Since)
+		if err != nil {
+			return err
+		}
+		if start, err = findBlockAtTime(ctx, c, since, uint64(latest)); err != nil {
+			return err
+		}
+	}
+	if inputDumpblocks.Until != "" {
+		until, err := parseTime(inputDumpblocks.Until)
+		if err != nil {
+			return err
+		}
+		first, err := findBlockAtTime(ctx, c, until, uint64(latest))
+		if err != nil {
+			return err
+		}
+		if first == 0 {
+			return fmt.Errorf("there are no blocks before %s", inputDumpblocks.Until)
+		}
+		end = first - 1
+	}
+	if start > end {
+		return fmt.Errorf("there are no blocks in the time range")
+	}
+
+	log.Info().Uint64("start", start).Uint64("end", end).Msg("Resolved time range to blocks")
+	inputDumpblocks.Start = start
+	inputDumpblocks.End = end
+	return nil
+}
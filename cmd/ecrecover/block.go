@@ -0,0 +1,235 @@
+package ecrecover
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	blockParams struct {
+		From      uint64
+		To        uint64
+		BatchSize uint64
+		JSON      bool
+
+		Clique      bool
+		JaipurBlock uint64
+	}
+
+	recoveredBlock struct {
+		Number uint64            `json:"number"`
+		Hash   ethcommon.Hash    `json:"hash"`
+		Signer ethcommon.Address `json:"signer"`
+	}
+
+	// signerStats are the blocks of a range that a signer sealed.
+	signerStats struct {
+		Signer ethcommon.Address `json:"signer"`
+		Blocks uint64            `json:"blocks"`
+		Share  float64           `json:"share"`
+		First  uint64            `json:"first"`
+		Last   uint64            `json:"last"`
+	}
+
+	rangeStats struct {
+		From    uint64            `json:"from"`
+		To      uint64            `json:"to"`
+		Signers []*signerStats    `json:"signers"`
+		Blocks  []*recoveredBlock `json:"blocks"`
+	}
+)
+
+var inputBlock blockParams
+
+var blockCmd = &cobra.Command{
+	Use:   "block [number | hash | tag | raw header]",
+	Short: "Recover the signers of bor and clique blocks from their seals.",
+	Long: `Recover the signer of a bor or clique block from the seal at the end of its
+extra data. The block is fetched from --rpc-url by its number, hash, or a tag
+like latest, or decoded from the hex encoded RLP header if that's passed
+instead. The input is read from stdin if there's no argument.
+
+The seals are bor seals, which only cover the base fee from --jaipur-block, unless
+--clique is set.
+
+With --from and --to, the signers of every block in the range are recovered and
+counted, which shows how the blocks are spread over the signers.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		isRange := cmd.Flags().Changed("from") || cmd.Flags().Changed("to")
+		if isRange && len(args) > 0 {
+			return fmt.Errorf("a block can't be passed along with a range")
+		}
+		if isRange && inputBlock.To < inputBlock.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputBlock.BatchSize == 0 {
+			return fmt.Errorf("the batch size must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("from") || cmd.Flags().Changed("to") {
+			return recoverRange(cmd)
+		}
+
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+
+		var header *types.Header
+		if data, err := hexutil.Decode(input); err == nil && len(data) > ethcommon.HashLength {
+			header = new(types.Header)
+			if err = rlp.DecodeBytes(data, header); err != nil {
+				return fmt.Errorf("unable to decode the header: %w", err)
+			}
+		} else {
+			if header, err = fetchHeader(cmd, input); err != nil {
+				return err
+			}
+		}
+
+		signer, err := sealSigner(header)
+		if err != nil {
+			return fmt.Errorf("unable to recover the signer: %w", err)
+		}
+		return printJSON(recoveredBlock{Number: header.Number.Uint64(), Hash: header.Hash(), Signer: signer})
+	},
+}
+
+func init() {
+	flagSet := blockCmd.Flags()
+	flagSet.Uint64Var(&inputBlock.From, "from", 0, "The first block of the range to recover the signers of")
+	flagSet.Uint64Var(&inputBlock.To, "to", 0, "The last block of the range to recover the signers of")
+	flagSet.Uint64VarP(&inputBlock.BatchSize, "batch-size", "b", 100, "The number of blocks to fetch per batch request")
+	flagSet.BoolVar(&inputBlock.JSON, "json", false, "Output the signers of the range and of every block as JSON")
+	flagSet.BoolVar(&inputBlock.Clique, "clique", false, "Recover the signers from clique seals rather than bor seals")
+	flagSet.Uint64Var(&inputBlock.JaipurBlock, "jaipur-block", 0, "The bor Jaipur block, from which the seals cover the base fee")
+}
+
+// sealSigner recovers the signer of the header from its bor seal, or its
+// clique seal with --clique.
+func sealSigner(header *types.Header) (ethcommon.Address, error) {
+	if inputBlock.Clique {
+		return util.SealSigner(header)
+	}
+	return util.BorSealSigner(header, inputBlock.JaipurBlock)
+}
+
+// fetchHeader fetches the header of the block by its number, hash, or tag.
+func fetchHeader(cmd *cobra.Command, block string) (*types.Header, error) {
+	if err := requireRPC(); err != nil {
+		return nil, err
+	}
+	rpc, err := util.DialRPC(cmd.Context(), rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rpc.Close()
+
+	method := "eth_getBlockByNumber"
+	if strings.HasPrefix(block, "0x") && len(block) == 2+2*ethcommon.HashLength {
+		method = "eth_getBlockByHash"
+	} else if n, err := strconv.ParseUint(block, 10, 64); err == nil {
+		block = hexutil.EncodeUint64(n)
+	}
+
+	var raw json.RawMessage
+	if err = rpc.CallContext(cmd.Context(), &raw, method, block, false); err != nil {
+		return nil, fmt.Errorf("unable to fetch block %s: %w", block, err)
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+	header, _, err := util.ParseHeader(raw)
+	return header, err
+}
+
+func recoverRange(cmd *cobra.Command) error {
+	if err := requireRPC(); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	rpc, err := util.DialRPC(ctx, rpcURL)
+	if err != nil {
+		return err
+	}
+	defer rpc.Close()
+
+	stats := rangeStats{From: inputBlock.From, To: inputBlock.To}
+	bySigner := make(map[ethcommon.Address]*signerStats)
+	for from := inputBlock.From; from <= inputBlock.To; from += inputBlock.BatchSize {
+		to := from + inputBlock.BatchSize - 1
+		if to > inputBlock.To || to < from {
+			to = inputBlock.To
+		}
+
+		log.Info().Uint64("start", from).Uint64("end", to).Msg("Getting range")
+		blocks, err := util.GetBlockRange(ctx, from, to, rpc)
+		if err != nil {
+			return err
+		}
+		for _, raw := range blocks {
+			header, hash, err := util.ParseHeader(*raw)
+			if err != nil {
+				return err
+			}
+			number := header.Number.Uint64()
+			signer, err := sealSigner(header)
+			if err != nil {
+				log.Warn().Err(err).Uint64("block", number).Msg("Unable to recover the signer")
+				continue
+			}
+			stats.Blocks = append(stats.Blocks, &recoveredBlock{Number: number, Hash: hash, Signer: signer})
+
+			s, ok := bySigner[signer]
+			if !ok {
+				s = &signerStats{Signer: signer, First: number}
+				bySigner[signer] = s
+				stats.Signers = append(stats.Signers, s)
+			}
+			s.Blocks++
+			s.Last = number
+		}
+		if to == inputBlock.To {
+			break
+		}
+	}
+
+	for _, s := range stats.Signers {
+		s.Share = float64(s.Blocks) / float64(len(stats.Blocks))
+	}
+	sort.SliceStable(stats.Signers, func(i, j int) bool {
+		return stats.Signers[i].Blocks > stats.Signers[j].Blocks
+	})
+
+	if inputBlock.JSON {
+		return printJSON(stats)
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(fmt.Sprintf("Signers of blocks %d to %d", stats.From, stats.To))
+	t.AppendHeader(table.Row{"Signer", "Blocks", "Share", "First", "Last"})
+	for _, s := range stats.Signers {
+		t.AppendRow(table.Row{s.Signer.Hex(), s.Blocks, fmt.Sprintf("%0.2f%%", s.Share*100), s.First, s.Last})
+	}
+	t.AppendFooter(table.Row{"Total", len(stats.Blocks), "", "", ""})
+	t.Render()
+	return nil
+}
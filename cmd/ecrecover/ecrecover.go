@@ -0,0 +1,63 @@
+package ecrecover
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	//go:embed usage.md
+	usage string
+
+	rpcURL string
+)
+
+// EcrecoverCmd represents the ecrecover command
+var EcrecoverCmd = &cobra.Command{
+	Use:   "ecrecover",
+	Short: "Recover the signers of blocks, transactions, and messages.",
+	Long:  usage,
+}
+
+func init() {
+	EcrecoverCmd.AddCommand(blockCmd)
+	EcrecoverCmd.AddCommand(txCmd)
+	EcrecoverCmd.AddCommand(messageCmd)
+	EcrecoverCmd.PersistentFlags().StringVar(&rpcURL, "rpc-url", "", "The RPC endpoint url to fetch blocks and transactions from")
+}
+
+// getInputData returns the first argument if there is one, otherwise it
+// reads stdin.
+func getInputData(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimSpace(args[0]), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("unable to read stdin: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func requireRPC() error {
+	if rpcURL == "" {
+		return fmt.Errorf("--rpc-url is required to fetch from a node, or pass the raw hex instead")
+	}
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,63 @@
+package ecrecover
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	messageParams struct {
+		Signature string
+		File      string
+		Hex       bool
+		Raw       bool
+	}
+
+	recoveredMessage struct {
+		Signer ethcommon.Address `json:"signer"`
+		Digest hexutil.Bytes     `json:"digest"`
+	}
+)
+
+var inputMessage messageParams
+
+var messageCmd = &cobra.Command{
+	Use:   "message [message]",
+	Short: "Recover the signer of a message signature.",
+	Long: `Recover the address that signed a message. The message is prefixed like
+personal_sign does unless --raw is set, the same way polycli sign message signs
+it. It's read from --file, the arguments, or stdin.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		signature, err := hexutil.Decode(inputMessage.Signature)
+		if err != nil {
+			return fmt.Errorf("unable to decode signature: %w", err)
+		}
+		message, err := util.ReadMessage(inputMessage.File, inputMessage.Hex || inputMessage.Raw, args)
+		if err != nil {
+			return err
+		}
+		digest, err := util.MessageDigest(message, inputMessage.Raw)
+		if err != nil {
+			return err
+		}
+		signer, err := util.RecoverSigner(digest, signature)
+		if err != nil {
+			return err
+		}
+		return printJSON(recoveredMessage{Signer: signer, Digest: digest})
+	},
+}
+
+func init() {
+	flagSet := messageCmd.Flags()
+	flagSet.StringVar(&inputMessage.Signature, "signature", "", "The hex encoded signature")
+	flagSet.StringVar(&inputMessage.File, "file", "", "Read the message from a file rather than the arguments or stdin")
+	flagSet.BoolVar(&inputMessage.Hex, "hex", false, "Decode the message as hex before recovering")
+	flagSet.BoolVar(&inputMessage.Raw, "raw", false, "Recover the signer of the hex encoded 32 byte digest as is, without the personal_sign prefix")
+	_ = messageCmd.MarkFlagRequired("signature")
+}
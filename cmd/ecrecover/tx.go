@@ -0,0 +1,62 @@
+package ecrecover
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type recoveredTx struct {
+	Hash ethcommon.Hash    `json:"hash"`
+	From ethcommon.Address `json:"from"`
+}
+
+var txCmd = &cobra.Command{
+	Use:   "tx [hash | raw transaction]",
+	Short: "Recover the sender of a transaction by its hash or raw hex.",
+	Long: `Recover the sender of a transaction of any type from its signature. A 32 byte
+hash is looked up with eth_getRawTransactionByHash at --rpc-url, and anything
+else is decoded as the hex encoded raw transaction. The input is read from
+stdin if there's no argument.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+		data, err := hexutil.Decode(input)
+		if err != nil {
+			return fmt.Errorf("unable to decode the input as hex: %w", err)
+		}
+
+		if len(data) == ethcommon.HashLength {
+			if err = requireRPC(); err != nil {
+				return err
+			}
+			rpc, err := util.DialRPC(cmd.Context(), rpcURL)
+			if err != nil {
+				return err
+			}
+			defer rpc.Close()
+
+			var raw hexutil.Bytes
+			if err = rpc.CallContext(cmd.Context(), &raw, "eth_getRawTransactionByHash", input); err != nil {
+				return fmt.Errorf("unable to fetch the transaction: %w", err)
+			}
+			if len(raw) == 0 {
+				return fmt.Errorf("transaction %s not found", input)
+			}
+			data = raw
+		}
+
+		hash, from, err := util.TxSender(data)
+		if err != nil {
+			return fmt.Errorf("unable to recover the sender: %w", err)
+		}
+		return printJSON(recoveredTx{Hash: hash, From: from})
+	},
+}
@@ -10,14 +10,26 @@ import (
 
 	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/proto"
 )
 
-const maxBufferCapacity = 5 * 1024 * 1024
+const (
+	// The scanner buffers of the json readers start small and grow up to the
+	// longest line they accept, so that memory is only spent on large blocks
+	// when there are some.
+	initialBufferCapacity = 64 * 1024
+	maxBufferCapacity     = 256 * 1024 * 1024
+)
 
 type (
+	// BlockReader reads the blocks of a file in order. Reading a block is
+	// split into reading its raw bytes and decoding them, so that the
+	// pipeline can decode blocks in parallel.
 	BlockReader interface {
 		ReadBlock() (rpctypes.PolyBlock, error)
+		ReadRawBlock() ([]byte, error)
+		DecodeBlock(raw []byte) (rpctypes.PolyBlock, error)
 	}
 	JSONBlockReader struct {
 		scanner *bufio.Scanner
@@ -38,7 +50,7 @@ func OpenBlockReader(file string, mode string) (BlockReader, error) {
 
 	switch mode {
 	case "json":
-		buf := make([]byte, maxBufferCapacity)
+		buf := make([]byte, initialBufferCapacity)
 		scanner := bufio.NewScanner(blockFile)
 		scanner.Buffer(buf, maxBufferCapacity)
 
@@ -62,8 +74,22 @@ func (blockReader *JSONBlockReader) ReadBlock() (rpctypes.PolyBlock, error) {
 	if !blockReader.scanner.Scan() {
 		return nil, BlockReadEOF
 	}
+	return blockReader.DecodeBlock(blockReader.scanner.Bytes())
+}
+
+// ReadRawBlock returns a copy of the next line, since the scanner reuses its
+// buffer.
+func (blockReader *JSONBlockReader) ReadRawBlock() ([]byte, error) {
+	if !blockReader.scanner.Scan() {
+		if err := blockReader.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, BlockReadEOF
+	}
+	return slices.Clone(blockReader.scanner.Bytes()), nil
+}
 
-	rawBlockBytes := blockReader.scanner.Bytes()
+func (blockReader *JSONBlockReader) DecodeBlock(rawBlockBytes []byte) (rpctypes.PolyBlock, error) {
 	var raw rpctypes.RawBlockResponse
 	err := json.Unmarshal(rawBlockBytes, &raw)
 	if err != nil {
@@ -73,6 +99,14 @@ func (blockReader *JSONBlockReader) ReadBlock() (rpctypes.PolyBlock, error) {
 }
 
 func (blockReader *ProtoBlockReader) ReadBlock() (rpctypes.PolyBlock, error) {
+	item, err := blockReader.ReadRawBlock()
+	if err != nil {
+		return nil, err
+	}
+	return blockReader.DecodeBlock(item)
+}
+
+func (blockReader *ProtoBlockReader) ReadRawBlock() ([]byte, error) {
 	// reading the length of the encoded item before reading each item
 	buf := make([]byte, 4)
 	if _, err := blockReader.file.ReadAt(buf, blockReader.offset); err != nil {
@@ -86,14 +120,16 @@ func (blockReader *ProtoBlockReader) ReadBlock() (rpctypes.PolyBlock, error) {
 	if _, err := blockReader.file.ReadAt(item, blockReader.offset); err != nil {
 		return nil, err
 	}
+	blockReader.offset += int64(itemSize)
+	return item, nil
+}
 
+func (blockReader *ProtoBlockReader) DecodeBlock(item []byte) (rpctypes.PolyBlock, error) {
 	block := &pb.Block{}
 	if err := proto.Unmarshal(item, block); err != nil {
 		return nil, err
 	}
 
-	blockReader.offset += int64(itemSize)
-
 	txs := []rpctypes.RawTransactionResponse{}
 	for _, tx := range block.Transactions {
 		to := ""
@@ -102,21 +138,23 @@ func (blockReader *ProtoBlockReader) ReadBlock() (rpctypes.PolyBlock, error) {
 		}
 
 		txs = append(txs, rpctypes.RawTransactionResponse{
-			BlockHash:        rpctypes.RawData32Response(tx.BlockHash),
-			BlockNumber:      rpctypes.RawQuantityResponse(tx.BlockNumber),
-			From:             rpctypes.RawData20Response(tx.From),
-			Gas:              rpctypes.RawQuantityResponse(tx.Gas),
-			GasPrice:         rpctypes.RawQuantityResponse(tx.GasPrice),
-			Hash:             rpctypes.RawData32Response(tx.Hash),
-			Input:            rpctypes.RawDataResponse(tx.Input),
-			Nonce:            rpctypes.RawQuantityResponse(tx.Nonce),
-			To:               rpctypes.RawData20Response(to),
-			TransactionIndex: rpctypes.RawQuantityResponse(tx.TransactionIndex),
-			Value:            rpctypes.RawQuantityResponse(tx.Value),
-			V:                rpctypes.RawQuantityResponse(tx.V),
-			R:                rpctypes.RawQuantityResponse(tx.R),
-			S:                rpctypes.RawQuantityResponse(tx.S),
-			Type:             rpctypes.RawQuantityResponse(tx.Type),
+			BlockHash:            rpctypes.RawData32Response(tx.BlockHash),
+			BlockNumber:          rpctypes.RawQuantityResponse(tx.BlockNumber),
+			From:                 rpctypes.RawData20Response(tx.From),
+			Gas:                  rpctypes.RawQuantityResponse(tx.Gas),
+			GasPrice:             rpctypes.RawQuantityResponse(tx.GasPrice),
+			MaxFeePerGas:         rpctypes.RawQuantityResponse(tx.MaxFeePerGas),
+			MaxPriorityFeePerGas: rpctypes.RawQuantityResponse(tx.MaxPriorityFeePerGas),
+			Hash:                 rpctypes.RawData32Response(tx.Hash),
+			Input:                rpctypes.RawDataResponse(tx.Input),
+			Nonce:                rpctypes.RawQuantityResponse(tx.Nonce),
+			To:                   rpctypes.RawData20Response(to),
+			TransactionIndex:     rpctypes.RawQuantityResponse(tx.TransactionIndex),
+			Value:                rpctypes.RawQuantityResponse(tx.Value),
+			V:                    rpctypes.RawQuantityResponse(tx.V),
+			R:                    rpctypes.RawQuantityResponse(tx.R),
+			S:                    rpctypes.RawQuantityResponse(tx.S),
+			Type:                 rpctypes.RawQuantityResponse(tx.Type),
 		})
 	}
 
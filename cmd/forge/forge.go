@@ -17,6 +17,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package forge
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -48,6 +49,7 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/util"
 	"golang.org/x/exp/slices"
 
 	"github.com/rs/zerolog/log"
@@ -71,8 +73,15 @@ type (
 		ShouldRewriteTxNonces bool
 		HasConsecutiveBlocks  bool
 		ShouldProcessBlocks   bool
+		ReportFile            string
+		Workers               int
+		QueueSize             int
+		CommitInterval        uint64
+		TrieCache             int
+		MaxMemory             string
 
 		GenesisData []byte
+		memory      *util.MemoryLimiter
 	}
 )
 
@@ -90,6 +99,10 @@ var ForgeCmd = &cobra.Command{
 	Long:  usage,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if inputForge.Client == "geth" {
+			return reexecuteFromFile()
+		}
+
 		fmt.Println("forge called")
 		blockchain, err := NewEdgeBlockchain()
 		if err != nil {
@@ -111,8 +124,8 @@ var ForgeCmd = &cobra.Command{
 		return err
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
-		if inputForge.Client != "edge" {
-			return fmt.Errorf("the client %s is not supported. Only Edge is supported", inputForge.Client)
+		if !slices.Contains([]string{"edge", "geth"}, inputForge.Client) {
+			return fmt.Errorf("the client %s is not supported. Only edge and geth are supported", inputForge.Client)
 		}
 		if !slices.Contains([]string{"json", "proto"}, inputForge.Mode) {
 			return fmt.Errorf("output format must one of [json, proto]")
@@ -127,12 +140,21 @@ var ForgeCmd = &cobra.Command{
 		}
 		inputForge.GenesisData = genesisData
 
+		if inputForge.memory, err = util.NewMemoryLimiter(inputForge.MaxMemory); err != nil {
+			return err
+		}
+		// Unless it's set, keep a quarter of the memory hint for the state
+		// between commits, which leaves the rest for the queued blocks.
+		if limit := inputForge.memory.Limit(); limit > 0 && !cmd.Flags().Changed("trie-cache") {
+			inputForge.TrieCache = int(limit / 4 / 1024 / 1024)
+		}
+
 		return nil
 	},
 }
 
 func init() {
-	ForgeCmd.PersistentFlags().StringVarP(&inputForge.Client, "client", "c", "edge", "Specify which blockchain client should be use to forge the data")
+	ForgeCmd.PersistentFlags().StringVarP(&inputForge.Client, "client", "c", "edge", "Specify which blockchain client should be use to forge the data [edge, geth]. geth re-executes the blocks and reports the state roots instead of forging them")
 	ForgeCmd.PersistentFlags().StringVarP(&inputForge.DataDir, "data-dir", "d", "./forged-data", "Specify a folder to be used to store the chain data")
 	ForgeCmd.PersistentFlags().StringVarP(&inputForge.GenesisFile, "genesis", "g", "genesis.json", "Specify a file to be used for genesis configuration")
 	ForgeCmd.PersistentFlags().StringVarP(&inputForge.Verifier, "verifier", "V", "dummy", "Specify a consensus engine to use for forging")
@@ -147,6 +169,12 @@ func init() {
 	ForgeCmd.PersistentFlags().BoolVar(&inputForge.ShouldRewriteTxNonces, "rewrite-tx-nonces", false, "whether to rewrite transaction nonces, set true if forging nonconsecutive blocks")
 	ForgeCmd.PersistentFlags().BoolVar(&inputForge.HasConsecutiveBlocks, "consecutive-blocks", true, "whether the blocks file has consecutive blocks")
 	ForgeCmd.PersistentFlags().BoolVarP(&inputForge.ShouldProcessBlocks, "process-blocks", "p", true, "whether the transactions in blocks should be processed applied to the state")
+	ForgeCmd.PersistentFlags().IntVar(&inputForge.Workers, "workers", 0, "The number of goroutines decoding and converting blocks ahead of execution (0 for one per CPU)")
+	ForgeCmd.PersistentFlags().IntVar(&inputForge.QueueSize, "queue-size", 256, "The maximum number of blocks queued between the stages of the import pipeline")
+	ForgeCmd.PersistentFlags().Uint64Var(&inputForge.CommitInterval, "commit-interval", 1024, "The number of blocks whose state is written to the database at once when re-executing with the geth client")
+	ForgeCmd.PersistentFlags().IntVar(&inputForge.TrieCache, "trie-cache", 256, "The megabytes of state kept in memory between commits when re-executing with the geth client")
+	ForgeCmd.PersistentFlags().StringVar(&inputForge.MaxMemory, "max-memory", "", util.MaxMemoryHelp)
+	ForgeCmd.PersistentFlags().StringVar(&inputForge.ReportFile, "report", "", "A file to write the per block state root report to when re-executing with the geth client, defaults to stdout")
 
 	if err := cobra.MarkFlagRequired(ForgeCmd.PersistentFlags(), "blocks"); err != nil {
 		log.Error().Err(err).Msg("Unable to mark blocks flag as required")
@@ -281,7 +309,6 @@ func NewEdgeBlockchain() (*edgeBlockchainHandle, error) {
 
 func readAllBlocksToChain(bh *edgeBlockchainHandle, blockReader BlockReader, receiptReader ReceiptReader) error {
 	bc := bh.Blockchain
-	blocksToRead := inputForge.Count
 	genesisBlock, _ := bc.GetBlockByHash(bc.Genesis(), true)
 
 	inputForge.BaseBlockReward = strings.ReplaceAll(strings.TrimSpace(inputForge.BaseBlockReward), "_", "")
@@ -294,37 +321,53 @@ func readAllBlocksToChain(bh *edgeBlockchainHandle, blockReader BlockReader, rec
 
 	parentBlock := genesisBlock
 
-	// Usually, the first block is the genesis block so it will skip it based on the flag.
-	var i uint64 = 0
-	if !inputForge.ShouldReadFirstBlock {
-		_, err := blockReader.ReadBlock()
-		if err != nil {
-			return fmt.Errorf("could not read off the genesis block from input: %w", err)
+	// Converting the blocks doesn't depend on the chain, so it's done by the
+	// pipeline workers ahead of execution.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	items := startPipeline(ctx, blockReader, func(item *pipelineItem) error {
+		// convert the generic rpc block into a block for edge. I suppose we'll need to think about other blockchain
+		// forging at some point, but for now edge & supernets seem to be the real use case
+		edgeBlock := PolyBlockToEdge(item.block)
+
+		// The transactions nonces need to be rewritten or else there will be an error.
+		if inputForge.ShouldRewriteTxNonces {
+			for nonce, tx := range edgeBlock.Transactions {
+				tx.Nonce = uint64(nonce)
+				log.Debug().Int64("old nonce", int64(tx.Nonce)).Int64("new nonce", int64(nonce)).Str("tx hash", tx.Hash.String()).Msg("Rewrote tx nonce")
+			}
 		}
-		i++
-	}
 
-	// in practice, I ran into some issues where the dumps that I created had duplicate blocks, This map is used to
-	// detect and skip any kind of duplicates
-	blockHashSet := make(map[ethcommon.Hash]struct{}, 0)
+		// The Transactions Root should be the same (i think?), but we'll set it
+		edgeBlock.Header.TxRoot = edgebuildroot.CalculateTransactionsRoot(edgeBlock.Transactions)
+		item.prepared = edgeBlock
+		return nil
+	})
+
+	// in practice, I ran into some issues where the dumps that I created had duplicate blocks, This window of the
+	// recent hashes is used to detect and skip any kind of duplicates
+	recent := newRecentHashes(duplicateWindow)
 
 	// insertion into the chain will fail if blocks are numbered non-sequentially. This is used to throw an error if we
 	// encounter blocks out of order. In the future, we should have a flag if we want to use original numbering or if
 	// we want to create new numbering
 	var lastNumber uint64 = 0
 	var receipt *rpctypes.RawTxReceipt
-	for ; i < blocksToRead; i++ {
+	progress := newPipelineProgress()
+	defer progress.Done()
+	for next := range items {
 		// read a polyblock which is a generic interface that can be marshalled into different formats
-		block, err := blockReader.ReadBlock()
+		item, err := next.wait()
 		if err != nil {
-			return fmt.Errorf("could not read block %d due to error: %w", i, err)
+			return fmt.Errorf("could not read block %d due to error: %w", item.index, err)
 		}
+		i, block := item.index, item.block
+		progress.Add(1)
 
-		if _, hasKey := blockHashSet[block.Hash()]; hasKey {
+		if !recent.add(block.Hash()) {
 			log.Trace().Str("blockhash", block.Hash().String()).Msg("Skipping duplicate block")
 			continue
 		}
-		blockHashSet[block.Hash()] = struct{}{}
 
 		// There are instances where we can import nonconsecutive blocks, skip this
 		// error on those instances.
@@ -333,24 +376,11 @@ func readAllBlocksToChain(bh *edgeBlockchainHandle, blockReader BlockReader, rec
 		}
 		lastNumber = block.Number().Uint64()
 
-		// convert the generic rpc block into a block for edge. I suppose we'll need to think about other blockchain
-		// forging at some point, but for now edge & supernets seem to be the real use case
-		edgeBlock := PolyBlockToEdge(block)
-
-		// The transactions nonces need to be rewritten or else there will be an error.
-		if inputForge.ShouldRewriteTxNonces {
-			for nonce, tx := range edgeBlock.Transactions {
-				tx.Nonce = uint64(nonce)
-				log.Debug().Int64("old nonce", int64(tx.Nonce)).Int64("new nonce", int64(nonce)).Str("tx hash", tx.Hash.String()).Msg("Rewrote tx nonce")
-			}
-		}
+		edgeBlock := item.prepared.(*edgetypes.Block)
 
 		// The parent hash value will not make sense, so we'll overwrite this when the value from our local parent block.
 		edgeBlock.Header.ParentHash = parentBlock.Header.ComputeHash().Hash
 
-		// The Transactions Root should be the same (i think?), but we'll set it
-		edgeBlock.Header.TxRoot = edgebuildroot.CalculateTransactionsRoot(edgeBlock.Transactions)
-
 		blockCreator, err := bh.Blockchain.GetConsensus().GetBlockCreator(edgeBlock.Header)
 		if err != nil {
 			return err
@@ -523,3 +553,41 @@ func IsEmptyAddress(addr []byte) bool {
 func GenerateRandomBlock(number uint64) *edgetypes.Block {
 	return nil
 }
+
+// duplicateWindow is the number of recent block hashes that duplicates are
+// detected among.
+const duplicateWindow = 4096
+
+// recentHashes is a set of the last hashes added to it. Duplicate blocks in
+// dumps are next to each other, usually at the boundaries of the ranges, so
+// remembering the recent blocks detects them without the set growing with
+// every block of the import.
+type recentHashes struct {
+	set  map[ethcommon.Hash]struct{}
+	ring []ethcommon.Hash
+	next int
+}
+
+func newRecentHashes(size int) *recentHashes {
+	return &recentHashes{
+		set:  make(map[ethcommon.Hash]struct{}, size),
+		ring: make([]ethcommon.Hash, 0, size),
+	}
+}
+
+// add adds the hash and evicts the oldest one if the window is full. It
+// returns false if the hash is already in the window.
+func (r *recentHashes) add(hash ethcommon.Hash) bool {
+	if _, ok := r.set[hash]; ok {
+		return false
+	}
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, hash)
+	} else {
+		delete(r.set, r.ring[r.next])
+		r.ring[r.next] = hash
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.set[hash] = struct{}{}
+	return true
+}
@@ -0,0 +1,125 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// pipelineItem is a block moving through the import pipeline. The reader
+// reads its raw bytes, a worker decodes and prepares it and closes done, and
+// the executor consumes the items in the order they were read.
+type pipelineItem struct {
+	index    uint64
+	raw      []byte
+	block    rpctypes.PolyBlock
+	prepared interface{}
+	err      error
+	done     chan struct{}
+}
+
+// wait blocks until the item has been decoded and prepared.
+func (item *pipelineItem) wait() (*pipelineItem, error) {
+	<-item.done
+	return item, item.err
+}
+
+// startPipeline reads the raw blocks in order and decodes them with
+// --workers goroutines, which also run prepare on every block, so that
+// reading, decoding, and converting blocks overlaps with executing them. Both
+// queues hold up to --queue-size blocks, so a slow executor holds the reader
+// back rather than blocks piling up in memory, and the reader also waits
+// while the heap is close to --max-memory.
+//
+// The returned channel yields the items in the order they were read and is
+// closed after the last block, which is the one before the count, the end of
+// the file, or a read error. The first block is skipped unless
+// --read-first-block is set, since it's usually the genesis.
+func startPipeline(ctx context.Context, blockReader BlockReader, prepare func(*pipelineItem) error) <-chan *pipelineItem {
+	queueSize := inputForge.QueueSize
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	work := make(chan *pipelineItem, queueSize)
+	ordered := make(chan *pipelineItem, queueSize)
+
+	workers := inputForge.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for item := range work {
+				item.block, item.err = blockReader.DecodeBlock(item.raw)
+				if item.err == nil {
+					item.err = prepare(item)
+				}
+				item.raw = nil
+				close(item.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(ordered)
+		defer close(work)
+
+		var i uint64
+		if !inputForge.ShouldReadFirstBlock {
+			if _, err := blockReader.ReadRawBlock(); err != nil {
+				item := &pipelineItem{err: fmt.Errorf("could not read off the genesis block from input: %w", err), done: make(chan struct{})}
+				close(item.done)
+				ordered <- item
+				return
+			}
+			i++
+		}
+
+		for ; i < inputForge.Count; i++ {
+			err := inputForge.memory.Wait(ctx)
+			var raw []byte
+			if err == nil {
+				raw, err = blockReader.ReadRawBlock()
+			}
+			item := &pipelineItem{index: i, raw: raw, err: err, done: make(chan struct{})}
+			if err != nil {
+				close(item.done)
+			}
+			select {
+			case ordered <- item:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+			select {
+			case work <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		// Drain the items of an abandoned pipeline, so that the reader
+		// isn't left blocked.
+		<-ctx.Done()
+		for range ordered {
+		}
+	}()
+	return ordered
+}
+
+// newPipelineProgress reports the progress of the import towards --count
+// blocks, which is the most the pipeline reads.
+func newPipelineProgress() *util.Progress {
+	total := inputForge.Count
+	if !inputForge.ShouldReadFirstBlock && total > 0 {
+		total--
+	}
+	return util.NewProgress("forge", "blocks", total)
+}
@@ -28,7 +28,7 @@ func OpenReceiptReader(file string, mode string) (ReceiptReader, error) {
 
 	switch mode {
 	case "json":
-		buf := make([]byte, maxBufferCapacity)
+		buf := make([]byte, initialBufferCapacity)
 		scanner := bufio.NewScanner(receiptsFile)
 		scanner.Buffer(buf, maxBufferCapacity)
 
@@ -0,0 +1,389 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/rs/zerolog/log"
+)
+
+// blockHashWindow is the number of ancestors reachable with BLOCKHASH.
+const blockHashWindow = 256
+
+type (
+	// stateRootReport is the result of re-executing a single block.
+	stateRootReport struct {
+		Number            uint64         `json:"number"`
+		Hash              ethcommon.Hash `json:"hash"`
+		ExpectedStateRoot ethcommon.Hash `json:"expectedStateRoot"`
+		StateRoot         ethcommon.Hash `json:"stateRoot"`
+		ExpectedGasUsed   uint64         `json:"expectedGasUsed"`
+		GasUsed           uint64         `json:"gasUsed"`
+		Transactions      int            `json:"transactions"`
+		SkippedStateSyncs int            `json:"skippedStateSyncs,omitempty"`
+		Match             bool           `json:"match"`
+		Error             string         `json:"error,omitempty"`
+	}
+
+	// reexecChain provides the recently executed headers to the EVM so that
+	// BLOCKHASH can be served.
+	reexecChain struct {
+		engine  consensus.Engine
+		headers map[ethcommon.Hash]*types.Header
+		hashes  map[uint64]ethcommon.Hash
+	}
+
+	gethBlockchainHandle struct {
+		DB      ethdb.Database
+		StateDB state.Database
+		Config  *params.ChainConfig
+		Genesis *types.Block
+	}
+)
+
+func (c *reexecChain) Engine() consensus.Engine {
+	return c.engine
+}
+
+func (c *reexecChain) GetHeader(hash ethcommon.Hash, number uint64) *types.Header {
+	h, ok := c.headers[hash]
+	if !ok || h.Number.Uint64() != number {
+		return nil
+	}
+	return h
+}
+
+func (c *reexecChain) add(hash ethcommon.Hash, h *types.Header) {
+	number := h.Number.Uint64()
+	c.headers[hash] = h
+	c.hashes[number] = hash
+	if number < blockHashWindow {
+		return
+	}
+	if old, ok := c.hashes[number-blockHashWindow]; ok {
+		delete(c.headers, old)
+		delete(c.hashes, number-blockHashWindow)
+	}
+}
+
+// NewGethBlockchain writes the genesis state to a database in the data
+// directory, which is used as the starting state for re-executing blocks.
+func NewGethBlockchain() (*gethBlockchainHandle, error) {
+	var genesis core.Genesis
+	if err := json.Unmarshal(inputForge.GenesisData, &genesis); err != nil {
+		return nil, fmt.Errorf("unable to parse genesis data: %w", err)
+	}
+	if genesis.Config == nil {
+		return nil, fmt.Errorf("the genesis file doesn't have a chain config")
+	}
+
+	db, err := rawdb.NewLevelDBDatabase(filepath.Join(inputForge.DataDir, "geth"), 512, 256, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open leveldb database: %w", err)
+	}
+	block, err := genesis.Commit(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to write genesis: %w", err)
+	}
+	log.Info().Str("hash", block.Hash().String()).Str("root", block.Root().String()).Msg("Wrote genesis state")
+
+	return &gethBlockchainHandle{
+		DB:      db,
+		StateDB: state.NewDatabase(db),
+		Config:  genesis.Config,
+		Genesis: block,
+	}, nil
+}
+
+// reexecuteBlocks applies the transactions of every block to the state with the
+// go-ethereum EVM and reports the state root computed after each block next to
+// the one in the block header. Nothing but the state is written, so this is
+// meant for verifying an export rather than building a chain from it.
+func reexecuteBlocks(bh *gethBlockchainHandle, blockReader BlockReader, report io.Writer) error {
+	defer bh.DB.Close()
+
+	root := bh.Genesis.Root()
+	chain := &reexecChain{
+		engine:  ethash.NewFaker(),
+		headers: make(map[ethcommon.Hash]*types.Header),
+		hashes:  make(map[uint64]ethcommon.Hash),
+	}
+	chain.add(bh.Genesis.Hash(), bh.Genesis.Header())
+
+	// The blocks are decoded and converted to messages by the pipeline
+	// workers, and the state is written to the database in batches, which is
+	// where most of the time went when every block was committed.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	items := startPipeline(ctx, blockReader, func(item *pipelineItem) error {
+		item.prepared = prepareReexecBlock(item.block)
+		return nil
+	})
+
+	triedb := bh.StateDB.TrieDB()
+	trieCache := ethcommon.StorageSize(inputForge.TrieCache) * 1024 * 1024
+	encoder := json.NewEncoder(report)
+	var executed, mismatched, uncommitted uint64
+	progress := newPipelineProgress()
+	defer progress.Done()
+	for next := range items {
+		item, err := next.wait()
+		if errors.Is(err, BlockReadEOF) || errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("could not read block %d due to error: %w", item.index, err)
+		}
+		block := item.block
+
+		r, newRoot, err := reexecuteBlock(bh, chain, root, item.prepared.(*reexecBlock))
+		if err != nil {
+			return fmt.Errorf("unable to re-execute block %s: %w", block.Number().String(), err)
+		}
+
+		// The new state is kept in memory and referenced until it's
+		// committed, and the previous state is released, so that the
+		// intermediate states are garbage collected.
+		triedb.Reference(newRoot, ethcommon.Hash{})
+		triedb.Dereference(root)
+		root = newRoot
+		executed++
+		progress.Add(1)
+		uncommitted++
+
+		if nodes, _ := triedb.Size(); uncommitted >= inputForge.CommitInterval {
+			if err = commitState(triedb, root); err != nil {
+				return err
+			}
+			uncommitted = 0
+		} else if nodes > trieCache {
+			if err = triedb.Cap(trieCache); err != nil {
+				return fmt.Errorf("unable to write state: %w", err)
+			}
+		}
+
+		if !r.Match {
+			mismatched++
+			log.Error().Uint64("number", r.Number).Str("expected", r.ExpectedStateRoot.String()).Str("computed", r.StateRoot.String()).Str("error", r.Error).Msg("State root mismatch")
+		} else {
+			log.Debug().Uint64("number", r.Number).Str("root", r.StateRoot.String()).Msg("State root matches")
+		}
+		if err = encoder.Encode(r); err != nil {
+			return fmt.Errorf("unable to write report: %w", err)
+		}
+	}
+	if uncommitted > 0 {
+		if err := commitState(triedb, root); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Uint64("executed", executed).Uint64("mismatched", mismatched).Msg("Done re-executing blocks")
+	if mismatched > 0 {
+		return fmt.Errorf("the state roots of %d blocks didn't match", mismatched)
+	}
+	return nil
+}
+
+// reexecBlock is a block with its header and messages, which the pipeline
+// workers prepare ahead of execution. The messages of bor state sync
+// transactions are nil.
+type reexecBlock struct {
+	block    rpctypes.PolyBlock
+	header   *types.Header
+	messages []*types.Message
+}
+
+func prepareReexecBlock(block rpctypes.PolyBlock) *reexecBlock {
+	header := PolyBlockToGethHeader(block)
+	txs := block.Transactions()
+	messages := make([]*types.Message, len(txs))
+	for i, tx := range txs {
+		// Bor applies state syncs with a system call outside of the EVM
+		// transactions, so they can't be replayed from the block.
+		if IsEmptyAddress(tx.From().Bytes()) && IsEmptyAddress(tx.To().Bytes()) {
+			continue
+		}
+		msg := polyTransactionToMessage(tx, header.BaseFee)
+		messages[i] = &msg
+	}
+	return &reexecBlock{block: block, header: header, messages: messages}
+}
+
+// commitState writes the state to the database.
+func commitState(triedb *trie.Database, root ethcommon.Hash) error {
+	if err := triedb.Commit(root, false, nil); err != nil {
+		return fmt.Errorf("unable to write state: %w", err)
+	}
+	log.Debug().Str("root", root.String()).Msg("Committed state")
+	return nil
+}
+
+// reexecuteBlock applies the block on top of the parent state root and
+// commits the resulting state to the trie database, which is written to disk
+// in batches by the caller.
+func reexecuteBlock(bh *gethBlockchainHandle, chain *reexecChain, parentRoot ethcommon.Hash, prepared *reexecBlock) (*stateRootReport, ethcommon.Hash, error) {
+	block, header := prepared.block, prepared.header
+	r := &stateRootReport{
+		Number:            block.Number().Uint64(),
+		Hash:              block.Hash(),
+		ExpectedStateRoot: block.Root(),
+		ExpectedGasUsed:   block.GasUsed(),
+		Transactions:      len(block.Transactions()),
+	}
+
+	statedb, err := state.New(parentRoot, bh.StateDB, nil)
+	if err != nil {
+		return nil, parentRoot, fmt.Errorf("unable to open parent state %s: %w", parentRoot.String(), err)
+	}
+
+	author := header.Coinbase
+	blockContext := core.NewEVMBlockContext(header, chain, &author)
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, bh.Config, vm.Config{})
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+
+	for txIndex, tx := range block.Transactions() {
+		msg := prepared.messages[txIndex]
+		if msg == nil {
+			r.SkippedStateSyncs++
+			continue
+		}
+
+		statedb.Prepare(tx.Hash(), txIndex)
+		evm.Reset(core.NewEVMTxContext(*msg), statedb)
+		result, applyErr := core.ApplyMessage(evm, *msg, gp)
+		if applyErr != nil {
+			r.Error = fmt.Sprintf("transaction %s could not be applied: %s", tx.Hash().String(), applyErr.Error())
+			break
+		}
+		r.GasUsed += result.UsedGas
+		statedb.Finalise(bh.Config.IsEIP158(header.Number))
+	}
+
+	if bh.Config.Ethash != nil {
+		reward := ethash.FrontierBlockReward
+		if bh.Config.IsConstantinople(header.Number) {
+			reward = ethash.ConstantinopleBlockReward
+		} else if bh.Config.IsByzantium(header.Number) {
+			reward = ethash.ByzantiumBlockReward
+		}
+		statedb.AddBalance(header.Coinbase, reward)
+	}
+
+	root, err := statedb.Commit(bh.Config.IsEIP158(header.Number))
+	if err != nil {
+		return nil, parentRoot, fmt.Errorf("unable to commit state: %w", err)
+	}
+
+	r.StateRoot = root
+	r.Match = r.Error == "" && root == r.ExpectedStateRoot && r.GasUsed == r.ExpectedGasUsed
+	if r.Error == "" && r.GasUsed != r.ExpectedGasUsed {
+		r.Error = fmt.Sprintf("gas used %d doesn't match header %d", r.GasUsed, r.ExpectedGasUsed)
+	}
+	chain.add(block.Hash(), header)
+	return r, root, nil
+}
+
+// PolyBlockToGethHeader converts the generic PolyBlock into a go-ethereum
+// header. The original parent hash is kept so that BLOCKHASH returns the
+// hashes of the exported chain.
+func PolyBlockToGethHeader(polyBlock rpctypes.PolyBlock) *types.Header {
+	h := &types.Header{
+		ParentHash:  polyBlock.ParentHash(),
+		UncleHash:   polyBlock.UncleHash(),
+		Coinbase:    polyBlock.Miner(),
+		Root:        polyBlock.Root(),
+		TxHash:      polyBlock.TxHash(),
+		ReceiptHash: polyBlock.ReceiptsRoot(),
+		Bloom:       types.BytesToBloom(polyBlock.LogsBloom()),
+		Difficulty:  polyBlock.Difficulty(),
+		Number:      polyBlock.Number(),
+		GasLimit:    polyBlock.GasLimit(),
+		GasUsed:     polyBlock.GasUsed(),
+		Time:        polyBlock.Time(),
+		Extra:       polyBlock.Extra(),
+		Nonce:       types.EncodeNonce(polyBlock.Nonce()),
+	}
+	if baseFee := polyBlock.BaseFee(); baseFee != nil && baseFee.Sign() > 0 {
+		h.BaseFee = baseFee
+	}
+	return h
+}
+
+// polyTransactionToMessage builds the message for a transaction using the sender
+// from the export, so signatures don't need to be recovered. Access lists
+// aren't part of the export, so access list transactions may use more gas than
+// they originally did.
+func polyTransactionToMessage(tx rpctypes.PolyTransaction, baseFee *big.Int) types.Message {
+	var to *ethcommon.Address
+	if addr := tx.To(); !IsEmptyAddress(addr.Bytes()) {
+		to = &addr
+	}
+
+	gasPrice := tx.GasPrice()
+	gasFeeCap, gasTipCap := gasPrice, gasPrice
+	if tx.Type() == types.DynamicFeeTxType {
+		gasFeeCap = new(big.Int).SetUint64(tx.MaxFeePerGas())
+		gasTipCap = new(big.Int).SetUint64(tx.MaxPriorityFeePerGas())
+		if baseFee != nil {
+			gasPrice = new(big.Int).Add(gasTipCap, baseFee)
+			if gasPrice.Cmp(gasFeeCap) > 0 {
+				gasPrice = gasFeeCap
+			}
+		}
+	}
+
+	return types.NewMessage(tx.From(), to, tx.Nonce(), tx.Value(), tx.Gas(), gasPrice, gasFeeCap, gasTipCap, tx.Data(), nil, false)
+}
+
+// openReport returns the writer for the state root report, which is stdout
+// when no file is given.
+func openReport(file string) (io.WriteCloser, error) {
+	if file == "" {
+		return os.Stdout, nil
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create report file: %w", err)
+	}
+	return f, nil
+}
+
+func reexecuteFromFile() error {
+	blockchain, err := NewGethBlockchain()
+	if err != nil {
+		return err
+	}
+
+	blockReader, err := OpenBlockReader(inputForge.BlocksFile, inputForge.Mode)
+	if err != nil {
+		return err
+	}
+
+	report, err := openReport(inputForge.ReportFile)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	return reexecuteBlocks(blockchain, blockReader, report)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 var (
@@ -30,12 +32,13 @@ var ForkCmd = &cobra.Command{
 	Long:  "",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Info().Str("rpc", rpcURL).Str("blockHash", blockHash.String()).Msg("Starting Analysis")
-		c, err := ethclient.Dial(rpcURL)
+		rpc, err := util.DialRPC(cmd.Context(), rpcURL)
 		if err != nil {
 			log.Error().Err(err).Str("rpc", rpcURL).Msg("Could not rpc dial connection")
 			return err
 		}
-		return walkTheBlocks(blockHash, c)
+		defer rpc.Close()
+		return walkTheBlocks(blockHash, ethclient.NewClient(rpc))
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 2 {
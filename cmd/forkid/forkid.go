@@ -0,0 +1,276 @@
+package forkid
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	forkidParams struct {
+		GenesisFile string
+		Head        uint64
+		Remote      string
+		RPCURL      string
+	}
+
+	// chain implements forkid.Blockchain so the fork ID filter can be
+	// constructed without a database.
+	chain struct {
+		config  *params.ChainConfig
+		genesis *types.Block
+		head    uint64
+	}
+
+	// ethEntry is the "eth" ENR entry which contains the fork ID.
+	ethEntry struct {
+		ForkID forkid.ID
+		Rest   []rlp.RawValue `rlp:"tail"`
+	}
+
+	forkidResult struct {
+		GenesisHash string `json:"genesisHash"`
+		Head        uint64 `json:"head"`
+		Hash        string `json:"hash"`
+		Next        uint64 `json:"next"`
+		Forks       []fork `json:"forks"`
+	}
+
+	fork struct {
+		Name   string `json:"name"`
+		Block  uint64 `json:"block"`
+		Passed bool   `json:"passed"`
+	}
+)
+
+func (c *chain) Config() *params.ChainConfig { return c.config }
+func (c *chain) Genesis() *types.Block       { return c.genesis }
+func (c *chain) CurrentHeader() *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}
+}
+
+func (e ethEntry) ENRKey() string { return "eth" }
+
+var (
+	//go:embed usage.md
+	usage         string
+	inputForkID   forkidParams
+	isHeadDefined bool
+)
+
+// ForkIDCmd represents the forkid command
+var ForkIDCmd = &cobra.Command{
+	Use:   "forkid",
+	Short: "Compute and validate EIP-2124 fork IDs from a genesis file.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if inputForkID.GenesisFile == "" {
+			return fmt.Errorf("a genesis file is required")
+		}
+		isHeadDefined = cmd.Flags().Changed("head")
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		c, err := loadChain(inputForkID.GenesisFile)
+		if err != nil {
+			return err
+		}
+		c.head = inputForkID.Head
+
+		var rpc *ethrpc.Client
+		if inputForkID.RPCURL != "" {
+			rpc, err = util.DialRPC(ctx, inputForkID.RPCURL)
+			if err != nil {
+				return err
+			}
+			defer rpc.Close()
+
+			if !isHeadDefined {
+				var head hexutil.Uint64
+				if err = rpc.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+					return fmt.Errorf("unable to fetch the head block: %w", err)
+				}
+				c.head = uint64(head)
+			}
+
+			var genesis struct {
+				Hash ethcommon.Hash `json:"hash"`
+			}
+			if err = rpc.CallContext(ctx, &genesis, "eth_getBlockByNumber", "0x0", false); err != nil {
+				log.Warn().Err(err).Msg("Unable to fetch the remote genesis block")
+			} else if genesis.Hash != c.genesis.Hash() {
+				log.Warn().Str("local", c.genesis.Hash().Hex()).Str("remote", genesis.Hash.Hex()).Msg("Genesis hash mismatch")
+			}
+		}
+
+		id := forkid.NewIDWithChain(c)
+		printResult(c, id)
+
+		var remote *forkid.ID
+		if inputForkID.Remote != "" {
+			if remote, err = parseForkID(inputForkID.Remote); err != nil {
+				return err
+			}
+		} else if rpc != nil {
+			var info struct {
+				ENR string `json:"enr"`
+			}
+			if err = rpc.CallContext(ctx, &info, "admin_nodeInfo"); err != nil {
+				return fmt.Errorf("unable to fetch node info, provide the remote fork id with --remote instead: %w", err)
+			}
+			if remote, err = forkIDFromENR(info.ENR); err != nil {
+				return err
+			}
+		}
+		if remote == nil {
+			return nil
+		}
+
+		log.Info().Str("hash", hexutil.Encode(remote.Hash[:])).Uint64("next", remote.Next).Msg("Validating remote fork id")
+		if err = forkid.NewFilter(c)(*remote); err != nil {
+			return fmt.Errorf("remote fork id is incompatible: %w", err)
+		}
+		log.Info().Msg("Remote fork id is compatible")
+
+		return nil
+	},
+}
+
+func init() {
+	ForkIDCmd.PersistentFlags().StringVarP(&inputForkID.GenesisFile, "genesis", "g", "", "the genesis file containing the chain config")
+	ForkIDCmd.PersistentFlags().Uint64VarP(&inputForkID.Head, "head", "b", 0, "the head block number to compute the fork id for")
+	ForkIDCmd.PersistentFlags().StringVarP(&inputForkID.Remote, "remote", "r", "", "a remote fork id to validate in the format hash:next (e.g. 0xfc64ec04:1150000)")
+	ForkIDCmd.PersistentFlags().StringVar(&inputForkID.RPCURL, "rpc-url", "", "the rpc url of a node to fetch the head and fork id from")
+}
+
+func loadChain(file string) (*chain, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := new(core.Genesis)
+	if err = json.Unmarshal(data, genesis); err != nil {
+		return nil, fmt.Errorf("unable to parse genesis file: %w", err)
+	}
+	if genesis.Config == nil {
+		return nil, fmt.Errorf("the genesis file has no chain config")
+	}
+
+	return &chain{config: genesis.Config, genesis: genesis.ToBlock()}, nil
+}
+
+// parseForkID parses a fork id in the format hash:next.
+func parseForkID(s string) (*forkid.ID, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("fork id should be in the format hash:next")
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(parts[0], "0x"))
+	if err != nil || len(hash) != 4 {
+		return nil, fmt.Errorf("fork hash should be 4 hex encoded bytes")
+	}
+	next, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse next fork block: %w", err)
+	}
+
+	id := &forkid.ID{Next: next}
+	copy(id.Hash[:], hash)
+	return id, nil
+}
+
+// forkIDFromENR extracts the fork id from the "eth" entry of an ENR.
+func forkIDFromENR(record string) (*forkid.ID, error) {
+	node, err := enode.Parse(enode.ValidSchemes, record)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse enr: %w", err)
+	}
+
+	var entry ethEntry
+	if err = node.Load(&entry); err != nil {
+		return nil, fmt.Errorf("enr does not contain an eth entry: %w", err)
+	}
+	return &entry.ForkID, nil
+}
+
+// gatherForks returns the names and block numbers of the block based forks in
+// the chain config. Forks sharing a block are only counted once in the fork id
+// but are all listed here.
+func gatherForks(config *params.ChainConfig) []fork {
+	forks := []struct {
+		name  string
+		block *big.Int
+	}{
+		{"homestead", config.HomesteadBlock},
+		{"daoFork", config.DAOForkBlock},
+		{"eip150", config.EIP150Block},
+		{"eip155", config.EIP155Block},
+		{"eip158", config.EIP158Block},
+		{"byzantium", config.ByzantiumBlock},
+		{"constantinople", config.ConstantinopleBlock},
+		{"petersburg", config.PetersburgBlock},
+		{"istanbul", config.IstanbulBlock},
+		{"muirGlacier", config.MuirGlacierBlock},
+		{"berlin", config.BerlinBlock},
+		{"london", config.LondonBlock},
+		{"arrowGlacier", config.ArrowGlacierBlock},
+		{"grayGlacier", config.GrayGlacierBlock},
+		{"mergeNetsplit", config.MergeNetsplitBlock},
+		{"shanghai", config.ShanghaiBlock},
+		{"cancun", config.CancunBlock},
+	}
+
+	var result []fork
+	for _, f := range forks {
+		// Forks at genesis are not part of the fork id.
+		if f.block == nil || f.block.Sign() == 0 {
+			continue
+		}
+		result = append(result, fork{Name: f.name, Block: f.block.Uint64()})
+	}
+	return result
+}
+
+func printResult(c *chain, id forkid.ID) {
+	forks := gatherForks(c.config)
+	for i := range forks {
+		forks[i].Passed = forks[i].Block <= c.head
+	}
+
+	out, err := json.MarshalIndent(forkidResult{
+		GenesisHash: c.genesis.Hash().Hex(),
+		Head:        c.head,
+		Hash:        hexutil.Encode(id.Hash[:]),
+		Next:        id.Next,
+		Forks:       forks,
+	}, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to marshal fork id")
+		return
+	}
+	fmt.Println(string(out))
+}
@@ -0,0 +1,168 @@
+package forkid
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// mainnetChain loads the Ethereum mainnet genesis like a genesis file.
+func mainnetChain(t *testing.T) *chain {
+	data, err := json.Marshal(core.DefaultGenesisBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "genesis.json")
+	if err = os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadChain(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.genesis.Hash() != params.MainnetGenesisHash {
+		t.Fatalf("expected the genesis hash %s, got %s", params.MainnetGenesisHash.Hex(), c.genesis.Hash().Hex())
+	}
+	return c
+}
+
+func TestForkID(t *testing.T) {
+	c := mainnetChain(t)
+
+	// The Ethereum mainnet vectors of EIP-2124.
+	tests := []struct {
+		head uint64
+		want string
+	}{
+		{head: 0, want: "0xfc64ec04:1150000"},
+		{head: 1149999, want: "0xfc64ec04:1150000"},
+		{head: 1150000, want: "0x97c2c34c:1920000"},
+		{head: 1920000, want: "0x91d1f948:2463000"},
+		{head: 2463000, want: "0x7a64da13:2675000"},
+		{head: 2675000, want: "0x3edd5b10:4370000"},
+		{head: 4370000, want: "0xa00bc324:7280000"},
+		{head: 7280000, want: "0x668db0af:9069000"},
+		{head: 9069000, want: "0x879d6e30:9200000"},
+		{head: 9200000, want: "0xe029e991:12244000"},
+		{head: 12244000, want: "0x0eb440f6:12965000"},
+		{head: 12965000, want: "0xb715077d:13773000"},
+		{head: 13773000, want: "0x20c327fc:15050000"},
+		{head: 15050000, want: "0xf0afd0e3:0"},
+		{head: 20000000, want: "0xf0afd0e3:0"},
+	}
+
+	for _, tc := range tests {
+		c.head = tc.head
+		want, err := parseForkID(tc.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id := forkid.NewIDWithChain(c); id != *want {
+			t.Errorf("expected the fork id %s at block %d, got %#x:%d", tc.want, tc.head, id.Hash, id.Next)
+		}
+	}
+}
+
+func TestForkIDFilter(t *testing.T) {
+	c := mainnetChain(t)
+
+	// A few of the mainnet validation vectors of EIP-2124.
+	tests := []struct {
+		head   uint64
+		remote forkid.ID
+		want   error
+	}{
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0x66, 0x8d, 0xb0, 0xaf}, Next: 0}},
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0x66, 0x8d, 0xb0, 0xaf}, Next: math.MaxUint64}},
+		{head: 7279999, remote: forkid.ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 7280000}},
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 7280000}},
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 0}, want: forkid.ErrRemoteStale},
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0x5c, 0xdd, 0xc0, 0xe1}, Next: 0}, want: forkid.ErrLocalIncompatibleOrStale},
+		{head: 7987396, remote: forkid.ID{Hash: [4]byte{0xaf, 0xec, 0x6b, 0x27}, Next: 0}, want: forkid.ErrLocalIncompatibleOrStale},
+	}
+
+	for _, tc := range tests {
+		c.head = tc.head
+		if err := forkid.NewFilter(c)(tc.remote); !errors.Is(err, tc.want) {
+			t.Errorf("expected %v for the remote %#x:%d at block %d, got %v", tc.want, tc.remote.Hash, tc.remote.Next, tc.head, err)
+		}
+	}
+}
+
+func TestParseForkID(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    forkid.ID
+		wantErr bool
+	}{
+		{value: "0xfc64ec04:1150000", want: forkid.ID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}},
+		{value: "f0afd0e3:0", want: forkid.ID{Hash: [4]byte{0xf0, 0xaf, 0xd0, 0xe3}}},
+		{value: "0xfc64ec04", wantErr: true},
+		{value: "0xfc64ec:1150000", wantErr: true},
+		{value: "0xfc64ec0400:1150000", wantErr: true},
+		{value: "0xfc64ec04:-1", wantErr: true},
+		{value: "0xfc64ec04:1:2", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		id, err := parseForkID(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("expected an error for %s", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unable to parse %s: %v", tc.value, err)
+			continue
+		}
+		if *id != tc.want {
+			t.Errorf("expected %#x:%d for %s, got %#x:%d", tc.want.Hash, tc.want.Next, tc.value, id.Hash, id.Next)
+		}
+	}
+}
+
+func TestForkIDFromENR(t *testing.T) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := forkid.ID{Hash: [4]byte{0xf0, 0xaf, 0xd0, 0xe3}}
+
+	var withEth, withoutEth enr.Record
+	withEth.Set(ethEntry{ForkID: want})
+	for _, r := range []*enr.Record{&withEth, &withoutEth} {
+		if err = enode.SignV4(r, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	node, err := enode.New(enode.ValidSchemes, &withEth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := forkIDFromENR(node.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *id != want {
+		t.Errorf("expected the fork id %#x:%d, got %#x:%d", want.Hash, want.Next, id.Hash, id.Next)
+	}
+
+	node, err = enode.New(enode.ValidSchemes, &withoutEth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = forkIDFromENR(node.String()); err == nil {
+		t.Error("expected an error for an enr without an eth entry")
+	}
+}
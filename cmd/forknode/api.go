@@ -0,0 +1,680 @@
+package forknode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// defaultTip is the priority fee of the transactions sent with
+// eth_sendTransaction that don't set one.
+var defaultTip = big.NewInt(params.GWei)
+
+type (
+	// ethAPI serves the eth namespace. The state of the latest block is
+	// local, the state of the forked chain up to the fork block is read from
+	// it, and the state of earlier local blocks isn't kept.
+	ethAPI struct{ c *localChain }
+	netAPI struct{ c *localChain }
+	// cheatAPI serves the methods that change the state directly, under
+	// the anvil and hardhat namespaces.
+	cheatAPI struct{ c *localChain }
+	evmAPI   struct{ c *localChain }
+	web3API  struct{}
+
+	// callArgs is the transaction object of eth_call, eth_estimateGas, and
+	// eth_sendTransaction.
+	callArgs struct {
+		From                 *ethcommon.Address `json:"from,omitempty"`
+		To                   *ethcommon.Address `json:"to,omitempty"`
+		Gas                  *hexutil.Uint64    `json:"gas,omitempty"`
+		GasPrice             *hexutil.Big       `json:"gasPrice,omitempty"`
+		MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas,omitempty"`
+		MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
+		Value                *hexutil.Big       `json:"value,omitempty"`
+		Nonce                *hexutil.Uint64    `json:"nonce,omitempty"`
+		Data                 *hexutil.Bytes     `json:"data,omitempty"`
+		Input                *hexutil.Bytes     `json:"input,omitempty"`
+	}
+
+	// revertError is the error of a call that reverted, with the revert data
+	// in the error data like other nodes.
+	revertError struct {
+		error
+		data string
+	}
+)
+
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+func (e *revertError) ErrorData() interface{} {
+	return e.data
+}
+
+func newRevertError(result *core.ExecutionResult) error {
+	if !errors.Is(result.Err, vm.ErrExecutionReverted) {
+		return result.Err
+	}
+	err := errors.New("execution reverted")
+	if reason, unpackErr := gethabi.UnpackRevert(result.Revert()); unpackErr == nil {
+		err = fmt.Errorf("execution reverted: %s", reason)
+	}
+	return &revertError{error: err, data: hexutil.Encode(result.Revert())}
+}
+
+func (a *callArgs) data() []byte {
+	if a.Input != nil {
+		return *a.Input
+	}
+	if a.Data != nil {
+		return *a.Data
+	}
+	return nil
+}
+
+// callMessage returns the message of an eth_call, which doesn't pay for gas
+// unless it sets a gas price.
+func (a *callArgs) callMessage(gasCap uint64) types.Message {
+	var from ethcommon.Address
+	if a.From != nil {
+		from = *a.From
+	}
+	gas := gasCap
+	if a.Gas != nil && uint64(*a.Gas) < gasCap {
+		gas = uint64(*a.Gas)
+	}
+	gasPrice, feeCap, tipCap := new(big.Int), new(big.Int), new(big.Int)
+	if a.GasPrice != nil {
+		gasPrice, feeCap, tipCap = a.GasPrice.ToInt(), a.GasPrice.ToInt(), a.GasPrice.ToInt()
+	}
+	value := new(big.Int)
+	if a.Value != nil {
+		value = a.Value.ToInt()
+	}
+	return types.NewMessage(from, a.To, 0, value, gas, gasPrice, feeCap, tipCap, a.data(), nil, true)
+}
+
+// local returns whether the block is the latest block, or otherwise the
+// block argument to read the forked chain at.
+func (c *localChain) local(b *ethrpc.BlockNumberOrHash) (bool, interface{}, error) {
+	if b == nil {
+		return true, nil, nil
+	}
+	head, headHash := c.head()
+	if hash, ok := b.Hash(); ok {
+		if hash == headHash {
+			return true, nil, nil
+		}
+		if c.blockByHash(hash) != nil {
+			return false, nil, errors.New("the state of earlier local blocks isn't kept")
+		}
+		return false, map[string]interface{}{"blockHash": hash}, nil
+	}
+	n, _ := b.Number()
+	if n < 0 {
+		return true, nil, nil
+	}
+	switch {
+	case uint64(n) <= c.fork.Number.Uint64():
+		return false, hexutil.EncodeUint64(uint64(n)), nil
+	case uint64(n) == head.Number.Uint64():
+		return true, nil, nil
+	case uint64(n) > head.Number.Uint64():
+		return false, nil, fmt.Errorf("the block %d doesn't exist", n)
+	}
+	return false, nil, errors.New("the state of earlier local blocks isn't kept")
+}
+
+// proxy forwards the request to the forked chain.
+func (c *localChain) proxy(ctx context.Context, method string, args ...interface{}) (json.RawMessage, error) {
+	var out json.RawMessage
+	err := c.remote.rpc.CallContext(ctx, &out, method, args...)
+	return out, err
+}
+
+// estimateGas finds the lowest gas limit that the call succeeds with by a
+// binary search.
+func (c *localChain) estimateGas(args callArgs) (uint64, error) {
+	head, _ := c.head()
+	hi := head.GasLimit
+	if args.Gas != nil && uint64(*args.Gas) >= params.TxGas {
+		hi = uint64(*args.Gas)
+	}
+	run := func(gas uint64) (*core.ExecutionResult, error) {
+		a := args
+		g := hexutil.Uint64(gas)
+		a.Gas = &g
+		return c.call(a.callMessage(gas))
+	}
+
+	result, err := run(hi)
+	if err != nil {
+		return 0, err
+	}
+	if result.Failed() {
+		return 0, newRevertError(result)
+	}
+	lo := params.TxGas - 1
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		result, err := run(mid)
+		if err != nil && !errors.Is(err, core.ErrIntrinsicGas) {
+			return 0, err
+		}
+		if err != nil || result.Failed() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+func (api *ethAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(api.c.config.ChainID)
+}
+
+func (api *ethAPI) BlockNumber() hexutil.Uint64 {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	head, _ := api.c.head()
+	return hexutil.Uint64(head.Number.Uint64())
+}
+
+// Accounts returns the impersonated accounts.
+func (api *ethAPI) Accounts() []ethcommon.Address {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	accounts := []ethcommon.Address{}
+	for addr := range api.c.impersonated {
+		accounts = append(accounts, addr)
+	}
+	return accounts
+}
+
+func (api *ethAPI) GasPrice() *hexutil.Big {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	price := new(big.Int).Set(defaultTip)
+	if baseFee := api.c.nextHeader().BaseFee; baseFee != nil {
+		price.Add(price, baseFee)
+	}
+	return (*hexutil.Big)(price)
+}
+
+func (api *ethAPI) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(defaultTip)
+}
+
+func (api *ethAPI) GetBalance(ctx context.Context, addr ethcommon.Address, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_getBalance", addr, arg)
+	}
+	balance := api.c.state.GetBalance(addr)
+	return (*hexutil.Big)(new(big.Int).Set(balance)), api.c.state.finalise()
+}
+
+func (api *ethAPI) GetTransactionCount(ctx context.Context, addr ethcommon.Address, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_getTransactionCount", addr, arg)
+	}
+	nonce := api.c.state.GetNonce(addr)
+	return hexutil.Uint64(nonce), api.c.state.finalise()
+}
+
+func (api *ethAPI) GetCode(ctx context.Context, addr ethcommon.Address, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_getCode", addr, arg)
+	}
+	code := api.c.state.GetCode(addr)
+	return hexutil.Bytes(code), api.c.state.finalise()
+}
+
+func (api *ethAPI) GetStorageAt(ctx context.Context, addr ethcommon.Address, slot string, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	key, err := util.ParseSlot(slot)
+	if err != nil {
+		return nil, err
+	}
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_getStorageAt", addr, key, arg)
+	}
+	value := api.c.state.GetState(addr, key)
+	return value, api.c.state.finalise()
+}
+
+func (api *ethAPI) Call(ctx context.Context, args callArgs, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_call", args, arg)
+	}
+	head, _ := api.c.head()
+	result, err := api.c.call(args.callMessage(head.GasLimit))
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed() {
+		return nil, newRevertError(result)
+	}
+	return hexutil.Bytes(result.Return()), nil
+}
+
+func (api *ethAPI) EstimateGas(ctx context.Context, args callArgs, block *ethrpc.BlockNumberOrHash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	local, arg, err := api.c.local(block)
+	if err != nil || !local {
+		return orProxy(ctx, api.c, err, "eth_estimateGas", args, arg)
+	}
+	gas, err := api.c.estimateGas(args)
+	return hexutil.Uint64(gas), err
+}
+
+func (api *ethAPI) SendRawTransaction(input hexutil.Bytes) (ethcommon.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if tx.Protected() && tx.ChainId().Cmp(api.c.config.ChainID) != 0 {
+		return ethcommon.Hash{}, fmt.Errorf("the chain ID %s of the transaction isn't %s", tx.ChainId(), api.c.config.ChainID)
+	}
+
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	msg, err := tx.AsMessage(types.LatestSignerForChainID(api.c.config.ChainID), api.c.nextHeader().BaseFee)
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	if _, err = api.c.mine(tx, msg.From(), msg); err != nil {
+		return ethcommon.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SendTransaction sends an unsigned transaction from an impersonated
+// account. The nonce, the gas limit, and the fees are filled in if they
+// aren't set.
+func (api *ethAPI) SendTransaction(args callArgs) (ethcommon.Hash, error) {
+	if args.From == nil {
+		return ethcommon.Hash{}, errors.New("the transaction has no sender")
+	}
+	from := *args.From
+
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	if err := api.c.canSendFrom(from); err != nil {
+		return ethcommon.Hash{}, err
+	}
+
+	nonce := api.c.state.GetNonce(from)
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
+	gas := uint64(0)
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	} else {
+		estimate, err := api.c.estimateGas(args)
+		if err != nil {
+			return ethcommon.Hash{}, err
+		}
+		gas = estimate
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+
+	var tx *types.Transaction
+	var msg types.Message
+	baseFee := api.c.nextHeader().BaseFee
+	if args.GasPrice != nil || baseFee == nil {
+		gasPrice := new(big.Int).Set(defaultTip)
+		if args.GasPrice != nil {
+			gasPrice = args.GasPrice.ToInt()
+		} else if baseFee != nil {
+			gasPrice.Add(gasPrice, baseFee)
+		}
+		tx = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: args.To, Value: value, Data: args.data()})
+		msg = types.NewMessage(from, args.To, nonce, value, gas, gasPrice, gasPrice, gasPrice, args.data(), nil, false)
+	} else {
+		tip := new(big.Int).Set(defaultTip)
+		if args.MaxPriorityFeePerGas != nil {
+			tip = args.MaxPriorityFeePerGas.ToInt()
+		}
+		feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+		if args.MaxFeePerGas != nil {
+			feeCap = args.MaxFeePerGas.ToInt()
+		}
+		if tip.Cmp(feeCap) > 0 {
+			tip = feeCap
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{ChainID: api.c.config.ChainID, Nonce: nonce, GasTipCap: tip, GasFeeCap: feeCap, Gas: gas, To: args.To, Value: value, Data: args.data()})
+		gasPrice := new(big.Int).Add(tip, baseFee)
+		if gasPrice.Cmp(feeCap) > 0 {
+			gasPrice = feeCap
+		}
+		msg = types.NewMessage(from, args.To, nonce, value, gas, gasPrice, feeCap, tip, args.data(), nil, false)
+	}
+	if _, err := api.c.mine(tx, from, msg); err != nil {
+		return ethcommon.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+func (api *ethAPI) GetTransactionByHash(ctx context.Context, hash ethcommon.Hash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	l, ok := api.c.txs[hash]
+	if !ok {
+		return api.c.proxy(ctx, "eth_getTransactionByHash", hash)
+	}
+	return marshalTx(api.c.blocks[l.block], 0, l.from)
+}
+
+func (api *ethAPI) GetTransactionReceipt(ctx context.Context, hash ethcommon.Hash) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	l, ok := api.c.txs[hash]
+	if !ok {
+		return api.c.proxy(ctx, "eth_getTransactionReceipt", hash)
+	}
+	return marshalReceipt(api.c.blocks[l.block], api.c.receipts[l.block][0], l.from), nil
+}
+
+func (api *ethAPI) GetBlockByNumber(ctx context.Context, number ethrpc.BlockNumber, full bool) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	var block *types.Block
+	if number < 0 {
+		if len(api.c.blocks) == 0 {
+			return api.c.proxy(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(api.c.fork.Number.Uint64()), full)
+		}
+		block = api.c.blocks[len(api.c.blocks)-1]
+	} else if uint64(number) <= api.c.fork.Number.Uint64() {
+		return api.c.proxy(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(uint64(number)), full)
+	} else if block = api.c.block(uint64(number)); block == nil {
+		return nil, nil
+	}
+	return marshalBlock(api.c, block, full)
+}
+
+func (api *ethAPI) GetBlockByHash(ctx context.Context, hash ethcommon.Hash, full bool) (interface{}, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	block := api.c.blockByHash(hash)
+	if block == nil {
+		return api.c.proxy(ctx, "eth_getBlockByHash", hash, full)
+	}
+	return marshalBlock(api.c, block, full)
+}
+
+func (api *netAPI) Version() string {
+	return api.c.config.ChainID.String()
+}
+
+func (api *web3API) ClientVersion() string {
+	return "polycli/fork-node"
+}
+
+func (api *cheatAPI) SetBalance(addr ethcommon.Address, balance hexutil.Big) error {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.state.SetBalance(addr, balance.ToInt())
+	return api.c.state.finalise()
+}
+
+func (api *cheatAPI) SetNonce(addr ethcommon.Address, nonce hexutil.Uint64) error {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.state.SetNonce(addr, uint64(nonce))
+	return api.c.state.finalise()
+}
+
+func (api *cheatAPI) SetCode(addr ethcommon.Address, code hexutil.Bytes) error {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.state.SetCode(addr, code)
+	return api.c.state.finalise()
+}
+
+func (api *cheatAPI) SetStorageAt(addr ethcommon.Address, slot string, value ethcommon.Hash) (bool, error) {
+	key, err := util.ParseSlot(slot)
+	if err != nil {
+		return false, err
+	}
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.state.SetState(addr, key, value)
+	return true, api.c.state.finalise()
+}
+
+func (api *cheatAPI) ImpersonateAccount(addr ethcommon.Address) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.impersonated[addr] = true
+}
+
+func (api *cheatAPI) StopImpersonatingAccount(addr ethcommon.Address) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	delete(api.c.impersonated, addr)
+}
+
+// Mine mines empty blocks, one by default, with the interval in seconds
+// between their timestamps.
+func (api *cheatAPI) Mine(blocks, interval *json.RawMessage) error {
+	n, err := parseQuantity(blocks, 1)
+	if err != nil {
+		return err
+	}
+	step, err := parseQuantity(interval, 0)
+	if err != nil {
+		return err
+	}
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	for i := uint64(0); i < n; i++ {
+		if i > 0 {
+			api.c.timeOffset += int64(step)
+		}
+		if _, err := api.c.mine(nil, ethcommon.Address{}, types.Message{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mine mines an empty block, with the timestamp if it's set.
+func (api *evmAPI) Mine(timestamp *json.RawMessage) (string, error) {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	if timestamp != nil {
+		ts, err := parseQuantity(timestamp, 0)
+		if err != nil {
+			return "", err
+		}
+		api.c.setNextTimestamp(ts)
+	}
+	if _, err := api.c.mine(nil, ethcommon.Address{}, types.Message{}); err != nil {
+		return "", err
+	}
+	return "0x0", nil
+}
+
+func (api *evmAPI) Snapshot() hexutil.Uint64 {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	return hexutil.Uint64(api.c.snapshot())
+}
+
+func (api *evmAPI) Revert(id hexutil.Uint64) bool {
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	return api.c.revert(uint64(id)) == nil
+}
+
+// IncreaseTime moves the time of the next blocks forward and returns the
+// total offset in seconds.
+func (api *evmAPI) IncreaseTime(seconds json.RawMessage) (string, error) {
+	n, err := parseQuantity(&seconds, 0)
+	if err != nil {
+		return "", err
+	}
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.timeOffset += int64(n)
+	return strconv.FormatInt(api.c.timeOffset, 10), nil
+}
+
+func (api *evmAPI) SetNextBlockTimestamp(timestamp json.RawMessage) error {
+	ts, err := parseQuantity(&timestamp, 0)
+	if err != nil {
+		return err
+	}
+	api.c.mu.Lock()
+	defer api.c.mu.Unlock()
+	api.c.setNextTimestamp(ts)
+	return nil
+}
+
+// parseQuantity parses a hex quantity or a JSON number, since tools send
+// both.
+func parseQuantity(raw *json.RawMessage, def uint64) (uint64, error) {
+	if raw == nil || string(*raw) == "null" {
+		return def, nil
+	}
+	var hex hexutil.Uint64
+	if err := json.Unmarshal(*raw, &hex); err == nil {
+		return uint64(hex), nil
+	}
+	var n uint64
+	if err := json.Unmarshal(*raw, &n); err != nil {
+		return 0, fmt.Errorf("%s isn't a quantity", string(*raw))
+	}
+	return n, nil
+}
+
+// orProxy returns the error, or the result of the request to the forked
+// chain if there's none.
+func orProxy(ctx context.Context, c *localChain, err error, method string, args ...interface{}) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return c.proxy(ctx, method, args...)
+}
+
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil || tx.Type() == types.LegacyTxType {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(tx.GasTipCap(), baseFee)
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+	return price
+}
+
+func marshalTx(block *types.Block, index int, from ethcommon.Address) (map[string]interface{}, error) {
+	tx := block.Transactions()[index]
+	b, err := tx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	fields["from"] = from
+	fields["blockHash"] = block.Hash()
+	fields["blockNumber"] = (*hexutil.Big)(block.Number())
+	fields["transactionIndex"] = hexutil.Uint64(index)
+	fields["gasPrice"] = (*hexutil.Big)(effectiveGasPrice(tx, block.BaseFee()))
+	return fields, nil
+}
+
+func marshalReceipt(block *types.Block, r *types.Receipt, from ethcommon.Address) map[string]interface{} {
+	tx := block.Transactions()[r.TransactionIndex]
+	logs := r.Logs
+	if logs == nil {
+		logs = []*types.Log{}
+	}
+	fields := map[string]interface{}{
+		"blockHash":         block.Hash(),
+		"blockNumber":       (*hexutil.Big)(block.Number()),
+		"transactionHash":   r.TxHash,
+		"transactionIndex":  hexutil.Uint64(r.TransactionIndex),
+		"from":              from,
+		"to":                tx.To(),
+		"gasUsed":           hexutil.Uint64(r.GasUsed),
+		"cumulativeGasUsed": hexutil.Uint64(r.CumulativeGasUsed),
+		"effectiveGasPrice": (*hexutil.Big)(effectiveGasPrice(tx, block.BaseFee())),
+		"contractAddress":   nil,
+		"logs":              logs,
+		"logsBloom":         r.Bloom,
+		"type":              hexutil.Uint(r.Type),
+		"status":            hexutil.Uint(r.Status),
+	}
+	if r.ContractAddress != (ethcommon.Address{}) {
+		fields["contractAddress"] = r.ContractAddress
+	}
+	return fields
+}
+
+func marshalBlock(c *localChain, block *types.Block, full bool) (map[string]interface{}, error) {
+	b, err := block.Header().MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	fields["size"] = hexutil.Uint64(block.Size())
+	fields["uncles"] = []ethcommon.Hash{}
+	fields["totalDifficulty"] = (*hexutil.Big)(new(big.Int))
+
+	txs := []interface{}{}
+	for i, tx := range block.Transactions() {
+		if !full {
+			txs = append(txs, tx.Hash())
+			continue
+		}
+		t, err := marshalTx(block, i, c.txs[tx.Hash()].from)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	fields["transactions"] = txs
+	return fields, nil
+}
@@ -0,0 +1,288 @@
+package forknode
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	// localChain is the chain of the blocks mined on top of the fork block.
+	// Every transaction is mined in a block of its own as soon as it's sent.
+	localChain struct {
+		mu       sync.Mutex
+		config   *params.ChainConfig
+		remote   *remoteState
+		fork     *types.Header
+		forkHash ethcommon.Hash
+		state    *lazyState
+
+		blocks   []*types.Block
+		receipts [][]*types.Receipt
+		txs      map[ethcommon.Hash]txLookup
+		// remoteHeaders are the ancestors of the fork block that BLOCKHASH
+		// asked for.
+		remoteHeaders map[uint64]*types.Header
+
+		impersonated    map[ethcommon.Address]bool
+		autoImpersonate bool
+		timeOffset      int64
+		snapshots       []*chainSnapshot
+	}
+
+	txLookup struct {
+		block int
+		from  ethcommon.Address
+	}
+
+	chainSnapshot struct {
+		state      *lazyState
+		blocks     int
+		timeOffset int64
+	}
+)
+
+// newLocalChain returns a chain on top of the fork block. The header of the
+// fork block only has the fields that the next blocks are derived from, so
+// its hash is the one of the forked chain.
+func newLocalChain(config *params.ChainConfig, remote *remoteState, fork *types.Header, forkHash ethcommon.Hash) (*localChain, error) {
+	db, err := state.New(ethcommon.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &localChain{
+		config:        config,
+		remote:        remote,
+		fork:          fork,
+		forkHash:      forkHash,
+		state:         newLazyState(db, remote),
+		txs:           make(map[ethcommon.Hash]txLookup),
+		remoteHeaders: make(map[uint64]*types.Header),
+		impersonated:  make(map[ethcommon.Address]bool),
+	}, nil
+}
+
+// Engine isn't used, since the author of the blocks is passed to the EVM.
+func (c *localChain) Engine() consensus.Engine {
+	return nil
+}
+
+// GetHeader returns the headers of the local blocks, or of the forked chain
+// for BLOCKHASH. Only the parent hash of the ancestors of the fork block is
+// used, so that's all they have.
+func (c *localChain) GetHeader(hash ethcommon.Hash, number uint64) *types.Header {
+	if n := c.fork.Number.Uint64(); number > n {
+		if i := number - n - 1; i < uint64(len(c.blocks)) && c.blocks[i].Hash() == hash {
+			return c.blocks[i].Header()
+		}
+		return nil
+	}
+	if number == c.fork.Number.Uint64() {
+		return c.fork
+	}
+	if h, ok := c.remoteHeaders[number]; ok {
+		return h
+	}
+	var h struct {
+		ParentHash ethcommon.Hash `json:"parentHash"`
+	}
+	if err := c.remote.rpc.CallContext(c.remote.ctx, &h, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false); err != nil {
+		log.Warn().Err(err).Uint64("number", number).Msg("Unable to get the header of the forked chain for BLOCKHASH")
+		return nil
+	}
+	header := &types.Header{ParentHash: h.ParentHash, Number: new(big.Int).SetUint64(number)}
+	c.remoteHeaders[number] = header
+	return header
+}
+
+// head returns the header and the hash of the latest block.
+func (c *localChain) head() (*types.Header, ethcommon.Hash) {
+	if len(c.blocks) == 0 {
+		return c.fork, c.forkHash
+	}
+	b := c.blocks[len(c.blocks)-1]
+	return b.Header(), b.Hash()
+}
+
+// nextHeader returns the header of the next block, which calls and
+// transactions are executed in.
+func (c *localChain) nextHeader() *types.Header {
+	parent, parentHash := c.head()
+	now := uint64(time.Now().Unix() + c.timeOffset)
+	if now <= parent.Time {
+		now = parent.Time + 1
+	}
+	h := &types.Header{
+		ParentHash: parentHash,
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+		Time:       now,
+		Difficulty: new(big.Int),
+		UncleHash:  types.EmptyUncleHash,
+	}
+	_, _ = rand.Read(h.MixDigest[:])
+	if c.config.IsLondon(h.Number) {
+		h.BaseFee = misc.CalcBaseFee(c.config, parent)
+	}
+	return h
+}
+
+func (c *localChain) newEVM(header *types.Header, db vm.StateDB, noBaseFee bool) *vm.EVM {
+	coinbase := header.Coinbase
+	blockContext := core.NewEVMBlockContext(header, c, &coinbase)
+	return vm.NewEVM(blockContext, vm.TxContext{}, db, c.config, vm.Config{NoBaseFee: noBaseFee})
+}
+
+// call executes the message on top of the latest block and reverts its
+// changes.
+func (c *localChain) call(msg types.Message) (*core.ExecutionResult, error) {
+	header := c.nextHeader()
+	id := c.state.Snapshot()
+	defer c.state.RevertToSnapshot(id)
+
+	evm := c.newEVM(header, c.state, true)
+	evm.Reset(core.NewEVMTxContext(msg), c.state)
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if c.state.err != nil {
+		err, c.state.err = c.state.err, nil
+	}
+	return result, err
+}
+
+// mine mines a block with the transaction, or an empty block if it's nil.
+// A transaction that can't be applied, like one with a nonce that's too low,
+// isn't mined and its error is returned.
+func (c *localChain) mine(tx *types.Transaction, from ethcommon.Address, msg types.Message) (*types.Block, error) {
+	header := c.nextHeader()
+	var txs []*types.Transaction
+	var receipts []*types.Receipt
+	if tx != nil {
+		id := c.state.Snapshot()
+		c.state.Prepare(tx.Hash(), 0)
+		evm := c.newEVM(header, c.state, false)
+		evm.Reset(core.NewEVMTxContext(msg), c.state)
+		result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit))
+		if err == nil && c.state.err != nil {
+			err = c.state.err
+		}
+		if err != nil {
+			c.state.RevertToSnapshot(id)
+			c.state.err = nil
+			return nil, err
+		}
+		c.state.Finalise(true)
+		header.GasUsed = result.UsedGas
+
+		r := &types.Receipt{
+			Type:              tx.Type(),
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: result.UsedGas,
+			TxHash:            tx.Hash(),
+			GasUsed:           result.UsedGas,
+		}
+		if result.Failed() {
+			r.Status = types.ReceiptStatusFailed
+		}
+		if msg.To() == nil {
+			r.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
+		}
+		r.Logs = c.state.GetLogs(tx.Hash(), ethcommon.Hash{})
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+		txs, receipts = []*types.Transaction{tx}, []*types.Receipt{r}
+	}
+	header.Root = c.state.IntermediateRoot(true)
+
+	block := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	for _, r := range receipts {
+		r.BlockHash = block.Hash()
+		r.BlockNumber = block.Number()
+		for i, l := range r.Logs {
+			l.BlockHash = block.Hash()
+			l.BlockNumber = block.NumberU64()
+			l.Index = uint(i)
+		}
+	}
+	if tx != nil {
+		c.txs[tx.Hash()] = txLookup{block: len(c.blocks), from: from}
+	}
+	c.blocks = append(c.blocks, block)
+	c.receipts = append(c.receipts, receipts)
+	log.Info().Uint64("number", block.NumberU64()).Str("hash", block.Hash().Hex()).Int("txs", len(txs)).Uint64("gasUsed", header.GasUsed).Msg("Mined block")
+	return block, nil
+}
+
+// block returns the local block with the number.
+func (c *localChain) block(number uint64) *types.Block {
+	n := c.fork.Number.Uint64()
+	if number <= n || number-n-1 >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[number-n-1]
+}
+
+func (c *localChain) blockByHash(hash ethcommon.Hash) *types.Block {
+	for _, b := range c.blocks {
+		if b.Hash() == hash {
+			return b
+		}
+	}
+	return nil
+}
+
+// snapshot saves the chain and returns the ID to revert to it with.
+func (c *localChain) snapshot() uint64 {
+	c.snapshots = append(c.snapshots, &chainSnapshot{state: c.state.copy(), blocks: len(c.blocks), timeOffset: c.timeOffset})
+	return uint64(len(c.snapshots))
+}
+
+// revert reverts the chain to the snapshot, which removes the snapshot and
+// the ones taken after it.
+func (c *localChain) revert(id uint64) error {
+	if id == 0 || id > uint64(len(c.snapshots)) {
+		return errors.New("the snapshot doesn't exist")
+	}
+	s := c.snapshots[id-1]
+	c.snapshots = c.snapshots[:id-1]
+	for _, b := range c.blocks[s.blocks:] {
+		for _, tx := range b.Transactions() {
+			delete(c.txs, tx.Hash())
+		}
+	}
+	c.blocks = c.blocks[:s.blocks]
+	c.receipts = c.receipts[:s.blocks]
+	c.state = s.state
+	c.timeOffset = s.timeOffset
+	return nil
+}
+
+// setNextTimestamp sets the time offset so that the next block has the
+// timestamp, if it's later than the latest block.
+func (c *localChain) setNextTimestamp(ts uint64) {
+	c.timeOffset = int64(ts) - time.Now().Unix()
+}
+
+// canSendFrom returns an error if unsigned transactions can't be sent from
+// the account.
+func (c *localChain) canSendFrom(addr ethcommon.Address) error {
+	if c.autoImpersonate || c.impersonated[addr] {
+		return nil
+	}
+	return fmt.Errorf("the account %s isn't impersonated, call anvil_impersonateAccount first", addr.Hex())
+}
@@ -0,0 +1,190 @@
+package forknode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	forkNodeParams struct {
+		RPCURL          string
+		ForkBlock       string
+		Listen          string
+		AutoImpersonate bool
+	}
+
+	// forkBlock is the part of the fork block that the local blocks are
+	// derived from.
+	forkBlock struct {
+		Hash       ethcommon.Hash `json:"hash"`
+		ParentHash ethcommon.Hash `json:"parentHash"`
+		Number     hexutil.Big    `json:"number"`
+		Timestamp  hexutil.Uint64 `json:"timestamp"`
+		GasLimit   hexutil.Uint64 `json:"gasLimit"`
+		BaseFee    *hexutil.Big   `json:"baseFeePerGas"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage         string
+	inputForkNode forkNodeParams
+)
+
+// ForkNodeCmd serves a local JSON-RPC endpoint on top of a lazily forked
+// chain.
+var ForkNodeCmd = &cobra.Command{
+	Use:     "fork-node",
+	Aliases: []string{"anvil-like"},
+	Short:   "Fork a chain lazily over RPC and serve a local JSON-RPC endpoint with cheat methods for testing.",
+	Long:    usage,
+	Args:    cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputForkNode.ForkBlock != "latest" {
+			if _, ok := new(big.Int).SetString(inputForkNode.ForkBlock, 0); !ok {
+				return fmt.Errorf("the fork block %s isn't a number", inputForkNode.ForkBlock)
+			}
+		}
+		if _, _, err := net.SplitHostPort(inputForkNode.Listen); err != nil {
+			return fmt.Errorf("the listen address %s isn't valid: %w", inputForkNode.Listen, err)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputForkNode.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		var chainID hexutil.Big
+		if err = rpc.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+			return fmt.Errorf("unable to get the chain ID: %w", err)
+		}
+		blockArg := "latest"
+		if inputForkNode.ForkBlock != "latest" {
+			n, _ := new(big.Int).SetString(inputForkNode.ForkBlock, 0)
+			blockArg = hexutil.EncodeBig(n)
+		}
+		var fb *forkBlock
+		if err = rpc.CallContext(ctx, &fb, "eth_getBlockByNumber", blockArg, false); err != nil {
+			return fmt.Errorf("unable to get the fork block: %w", err)
+		}
+		if fb == nil {
+			return fmt.Errorf("the fork block %s doesn't exist", inputForkNode.ForkBlock)
+		}
+
+		fork := &types.Header{
+			ParentHash: fb.ParentHash,
+			Number:     fb.Number.ToInt(),
+			Time:       uint64(fb.Timestamp),
+			GasLimit:   uint64(fb.GasLimit),
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+		}
+		if fb.BaseFee != nil {
+			fork.BaseFee = fb.BaseFee.ToInt()
+		}
+		remote := newRemoteState(ctx, rpc, fork.Number.Uint64())
+		chain, err := newLocalChain(chainConfig(chainID.ToInt()), remote, fork, fb.Hash)
+		if err != nil {
+			return err
+		}
+		chain.autoImpersonate = inputForkNode.AutoImpersonate
+
+		server, err := newServer(chain)
+		if err != nil {
+			return err
+		}
+		defer server.Stop()
+
+		listener, err := net.Listen("tcp", inputForkNode.Listen)
+		if err != nil {
+			return err
+		}
+		httpServer := &http.Server{Handler: handler(server), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		log.Info().
+			Str("url", "http://"+listener.Addr().String()).
+			Uint64("forkBlock", fork.Number.Uint64()).
+			Str("forkHash", fb.Hash.Hex()).
+			Str("chainID", chainID.ToInt().String()).
+			Msg("Serving the forked chain")
+		if err = httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := ForkNodeCmd.Flags()
+	flagSet.StringVar(&inputForkNode.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url of the chain to fork")
+	flagSet.StringVar(&inputForkNode.ForkBlock, "fork-block", "latest", "The block number to fork the chain at")
+	flagSet.StringVar(&inputForkNode.Listen, "listen", "127.0.0.1:8546", "The address to serve the JSON-RPC endpoint on, over HTTP and websockets")
+	flagSet.BoolVar(&inputForkNode.AutoImpersonate, "auto-impersonate", false, "Whether to accept eth_sendTransaction from any account without anvil_impersonateAccount")
+}
+
+// chainConfig enables all the forks that the EVM supports from the start,
+// since the local blocks are built on a chain that already has them.
+func chainConfig(chainID *big.Int) *params.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.ChainID = chainID
+	config.Ethash = nil
+	return &config
+}
+
+func newServer(chain *localChain) (*ethrpc.Server, error) {
+	server := ethrpc.NewServer()
+	services := map[string]interface{}{
+		"eth":     &ethAPI{c: chain},
+		"net":     &netAPI{c: chain},
+		"web3":    &web3API{},
+		"anvil":   &cheatAPI{c: chain},
+		"hardhat": &cheatAPI{c: chain},
+		"evm":     &evmAPI{c: chain},
+	}
+	for name, service := range services {
+		if err := server.RegisterName(name, service); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// handler serves websocket upgrades and HTTP requests on the same address.
+func handler(server *ethrpc.Server) http.Handler {
+	ws := server.WebsocketHandler([]string{"*"})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		server.ServeHTTP(w, r)
+	})
+}
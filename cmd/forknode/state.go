@@ -0,0 +1,317 @@
+package forknode
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// finalised is the revision of the loads that can't be reverted anymore.
+const finalised = -1
+
+type (
+	// remoteState reads the accounts and storage of the forked chain at the
+	// fork block, and caches them since they can't change.
+	remoteState struct {
+		ctx   context.Context
+		rpc   *ethrpc.Client
+		block string
+
+		mu       sync.Mutex
+		accounts map[ethcommon.Address]*remoteAccount
+		storage  map[ethcommon.Address]map[ethcommon.Hash]ethcommon.Hash
+	}
+
+	remoteAccount struct {
+		Balance *big.Int
+		Nonce   uint64
+		Code    []byte
+	}
+
+	// lazyState is a vm.StateDB over an in-memory state that loads the
+	// accounts and storage slots of the forked chain the first time they're
+	// accessed. Loads are changes like any other, so a load is recorded with
+	// the snapshot it was made after and repeated if that snapshot is
+	// reverted, until the state is finalised.
+	lazyState struct {
+		*state.StateDB
+		remote *remoteState
+
+		accounts map[ethcommon.Address]int
+		slots    map[ethcommon.Address]map[ethcommon.Hash]int
+		revision int
+		// err is the first error reading the forked chain. The EVM can't
+		// handle errors of the state, so it's checked after execution.
+		err error
+	}
+)
+
+func newRemoteState(ctx context.Context, rpc *ethrpc.Client, block uint64) *remoteState {
+	return &remoteState{
+		ctx:      ctx,
+		rpc:      rpc,
+		block:    hexutil.EncodeUint64(block),
+		accounts: make(map[ethcommon.Address]*remoteAccount),
+		storage:  make(map[ethcommon.Address]map[ethcommon.Hash]ethcommon.Hash),
+	}
+}
+
+// account reads the balance, nonce, and code of the account in a batch.
+func (r *remoteState) account(addr ethcommon.Address) (*remoteAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if a, ok := r.accounts[addr]; ok {
+		return a, nil
+	}
+
+	var balance hexutil.Big
+	var nonce hexutil.Uint64
+	var code hexutil.Bytes
+	elems := []ethrpc.BatchElem{
+		{Method: "eth_getBalance", Args: []interface{}{addr, r.block}, Result: &balance},
+		{Method: "eth_getTransactionCount", Args: []interface{}{addr, r.block}, Result: &nonce},
+		{Method: "eth_getCode", Args: []interface{}{addr, r.block}, Result: &code},
+	}
+	if err := r.rpc.BatchCallContext(r.ctx, elems); err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		if e.Error != nil {
+			return nil, fmt.Errorf("unable to read the account %s: %w", addr.Hex(), e.Error)
+		}
+	}
+	a := &remoteAccount{Balance: balance.ToInt(), Nonce: uint64(nonce), Code: code}
+	r.accounts[addr] = a
+	log.Trace().Str("address", addr.Hex()).Msg("Loaded account from the forked chain")
+	return a, nil
+}
+
+func (r *remoteState) slot(addr ethcommon.Address, key ethcommon.Hash) (ethcommon.Hash, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.storage[addr][key]; ok {
+		return v, nil
+	}
+
+	var value hexutil.Bytes
+	if err := r.rpc.CallContext(r.ctx, &value, "eth_getStorageAt", addr, key, r.block); err != nil {
+		return ethcommon.Hash{}, fmt.Errorf("unable to read the slot %s of %s: %w", key.Hex(), addr.Hex(), err)
+	}
+	if r.storage[addr] == nil {
+		r.storage[addr] = make(map[ethcommon.Hash]ethcommon.Hash)
+	}
+	v := ethcommon.BytesToHash(value)
+	r.storage[addr][key] = v
+	return v, nil
+}
+
+func newLazyState(db *state.StateDB, remote *remoteState) *lazyState {
+	return &lazyState{
+		StateDB:  db,
+		remote:   remote,
+		accounts: make(map[ethcommon.Address]int),
+		slots:    make(map[ethcommon.Address]map[ethcommon.Hash]int),
+	}
+}
+
+// copy returns an independent copy of the state, which has to be finalised.
+func (s *lazyState) copy() *lazyState {
+	c := newLazyState(s.StateDB.Copy(), s.remote)
+	for addr := range s.accounts {
+		c.accounts[addr] = finalised
+	}
+	for addr, slots := range s.slots {
+		c.slots[addr] = make(map[ethcommon.Hash]int, len(slots))
+		for key := range slots {
+			c.slots[addr][key] = finalised
+		}
+	}
+	return c
+}
+
+func (s *lazyState) loadAccount(addr ethcommon.Address) {
+	if _, ok := s.accounts[addr]; ok {
+		return
+	}
+	a, err := s.remote.account(addr)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return
+	}
+	// Empty accounts aren't written, so that they don't exist locally
+	// either.
+	if a.Balance.Sign() != 0 || a.Nonce != 0 || len(a.Code) != 0 {
+		s.StateDB.SetBalance(addr, a.Balance)
+		s.StateDB.SetNonce(addr, a.Nonce)
+		s.StateDB.SetCode(addr, a.Code)
+	}
+	s.accounts[addr] = s.revision
+}
+
+func (s *lazyState) loadSlot(addr ethcommon.Address, key ethcommon.Hash) {
+	s.loadAccount(addr)
+	if _, ok := s.slots[addr][key]; ok {
+		return
+	}
+	v, err := s.remote.slot(addr, key)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return
+	}
+	if v != (ethcommon.Hash{}) {
+		s.StateDB.SetState(addr, key, v)
+	}
+	if s.slots[addr] == nil {
+		s.slots[addr] = make(map[ethcommon.Hash]int)
+	}
+	s.slots[addr][key] = s.revision
+}
+
+func (s *lazyState) Snapshot() int {
+	id := s.StateDB.Snapshot()
+	s.revision = id + 1
+	return id
+}
+
+// RevertToSnapshot reverts the state, and forgets the loads that were made
+// after the snapshot, since they were reverted too.
+func (s *lazyState) RevertToSnapshot(id int) {
+	s.StateDB.RevertToSnapshot(id)
+	for addr, rev := range s.accounts {
+		if rev > id {
+			delete(s.accounts, addr)
+		}
+	}
+	for _, slots := range s.slots {
+		for key, rev := range slots {
+			if rev > id {
+				delete(slots, key)
+			}
+		}
+	}
+}
+
+// Finalise finalises the state, after which the loads can't be reverted.
+func (s *lazyState) Finalise(deleteEmptyObjects bool) {
+	s.StateDB.Finalise(deleteEmptyObjects)
+	for addr := range s.accounts {
+		s.accounts[addr] = finalised
+	}
+	for _, slots := range s.slots {
+		for key := range slots {
+			slots[key] = finalised
+		}
+	}
+}
+
+// finalise finalises the changes that were made outside of transactions,
+// and returns the error of the loads they made, if any.
+func (s *lazyState) finalise() error {
+	s.Finalise(false)
+	err := s.err
+	s.err = nil
+	return err
+}
+
+func (s *lazyState) CreateAccount(addr ethcommon.Address) {
+	s.loadAccount(addr)
+	s.StateDB.CreateAccount(addr)
+}
+
+func (s *lazyState) SubBalance(addr ethcommon.Address, amount *big.Int) {
+	s.loadAccount(addr)
+	s.StateDB.SubBalance(addr, amount)
+}
+
+func (s *lazyState) AddBalance(addr ethcommon.Address, amount *big.Int) {
+	s.loadAccount(addr)
+	s.StateDB.AddBalance(addr, amount)
+}
+
+func (s *lazyState) SetBalance(addr ethcommon.Address, amount *big.Int) {
+	s.loadAccount(addr)
+	s.StateDB.SetBalance(addr, amount)
+}
+
+func (s *lazyState) GetBalance(addr ethcommon.Address) *big.Int {
+	s.loadAccount(addr)
+	return s.StateDB.GetBalance(addr)
+}
+
+func (s *lazyState) GetNonce(addr ethcommon.Address) uint64 {
+	s.loadAccount(addr)
+	return s.StateDB.GetNonce(addr)
+}
+
+func (s *lazyState) SetNonce(addr ethcommon.Address, nonce uint64) {
+	s.loadAccount(addr)
+	s.StateDB.SetNonce(addr, nonce)
+}
+
+func (s *lazyState) GetCodeHash(addr ethcommon.Address) ethcommon.Hash {
+	s.loadAccount(addr)
+	return s.StateDB.GetCodeHash(addr)
+}
+
+func (s *lazyState) GetCode(addr ethcommon.Address) []byte {
+	s.loadAccount(addr)
+	return s.StateDB.GetCode(addr)
+}
+
+func (s *lazyState) SetCode(addr ethcommon.Address, code []byte) {
+	s.loadAccount(addr)
+	s.StateDB.SetCode(addr, code)
+}
+
+func (s *lazyState) GetCodeSize(addr ethcommon.Address) int {
+	s.loadAccount(addr)
+	return s.StateDB.GetCodeSize(addr)
+}
+
+// GetCommittedState returns the value of the slot before the transaction.
+// The in-memory state doesn't have the value of a slot that was loaded
+// during the transaction, so it's the value of the forked chain.
+func (s *lazyState) GetCommittedState(addr ethcommon.Address, key ethcommon.Hash) ethcommon.Hash {
+	s.loadSlot(addr, key)
+	if rev, ok := s.slots[addr][key]; ok && rev != finalised {
+		v, _ := s.remote.slot(addr, key)
+		return v
+	}
+	return s.StateDB.GetCommittedState(addr, key)
+}
+
+func (s *lazyState) GetState(addr ethcommon.Address, key ethcommon.Hash) ethcommon.Hash {
+	s.loadSlot(addr, key)
+	return s.StateDB.GetState(addr, key)
+}
+
+func (s *lazyState) SetState(addr ethcommon.Address, key, value ethcommon.Hash) {
+	s.loadSlot(addr, key)
+	s.StateDB.SetState(addr, key, value)
+}
+
+func (s *lazyState) Suicide(addr ethcommon.Address) bool {
+	s.loadAccount(addr)
+	return s.StateDB.Suicide(addr)
+}
+
+func (s *lazyState) Exist(addr ethcommon.Address) bool {
+	s.loadAccount(addr)
+	return s.StateDB.Exist(addr)
+}
+
+func (s *lazyState) Empty(addr ethcommon.Address) bool {
+	s.loadAccount(addr)
+	return s.StateDB.Empty(addr)
+}
@@ -0,0 +1,294 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/maticnetwork/polygon-cli/hdwallet"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	consensusClique = "clique"
+	consensusBor    = "bor"
+	consensusEthash = "ethash"
+)
+
+type (
+	generateParams struct {
+		ChainID               uint64
+		Consensus             string
+		Mnemonic              string
+		Password              string
+		Path                  string
+		Accounts              uint
+		Balance               string
+		GasLimit              uint64
+		AllocFile             string
+		Forks                 []string
+		OutputFile            string
+		CliquePeriod          uint64
+		BorPeriod             uint64
+		BorSprint             uint64
+		BorProducerDelay      uint64
+		BorBackupMultiplier   uint64
+		ValidatorContract     string
+		StateReceiverContract string
+	}
+)
+
+var (
+	inputGenerate generateParams
+	balance       *big.Int
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a geth or bor genesis file.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains([]string{consensusClique, consensusBor, consensusEthash}, inputGenerate.Consensus) {
+			return fmt.Errorf("consensus must be one of [%s, %s, %s]", consensusClique, consensusBor, consensusEthash)
+		}
+		var ok bool
+		if balance, ok = new(big.Int).SetString(inputGenerate.Balance, 10); !ok {
+			return fmt.Errorf("unable to parse balance %s", inputGenerate.Balance)
+		}
+		if inputGenerate.Consensus == consensusClique && inputGenerate.Accounts == 0 {
+			return fmt.Errorf("clique requires at least one account to be used as the signer")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		if inputGenerate.Mnemonic, err = util.ResolveSecret(inputGenerate.Mnemonic); err != nil {
+			return err
+		}
+		if inputGenerate.Password, err = util.ResolveSecret(inputGenerate.Password); err != nil {
+			return err
+		}
+
+		config, err := newChainConfig()
+		if err != nil {
+			return err
+		}
+
+		genesis := &core.Genesis{
+			Config:     config,
+			GasLimit:   inputGenerate.GasLimit,
+			Difficulty: big.NewInt(1),
+			Alloc:      make(core.GenesisAlloc),
+		}
+
+		accounts, err := deriveAccounts()
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			genesis.Alloc[account] = core.GenesisAccount{Balance: balance}
+		}
+
+		if inputGenerate.AllocFile != "" {
+			if err = mergeAlloc(genesis.Alloc, inputGenerate.AllocFile); err != nil {
+				return err
+			}
+		}
+
+		switch inputGenerate.Consensus {
+		case consensusClique:
+			config.Clique = &params.CliqueConfig{Period: inputGenerate.CliquePeriod, Epoch: 30000}
+			// The first account is used as the only signer.
+			extra := make([]byte, 32, 32+ethcommon.AddressLength+crypto.SignatureLength)
+			extra = append(extra, accounts[0].Bytes()...)
+			genesis.ExtraData = append(extra, make([]byte, crypto.SignatureLength)...)
+		case consensusEthash:
+			config.Ethash = new(params.EthashConfig)
+			genesis.Difficulty = big.NewInt(131072)
+		}
+
+		out, err := marshalGenesis(genesis)
+		if err != nil {
+			return err
+		}
+
+		if inputGenerate.OutputFile == "" {
+			fmt.Println(string(out))
+			return nil
+		}
+		if err = os.WriteFile(inputGenerate.OutputFile, out, 0644); err != nil {
+			return err
+		}
+		log.Info().Str("file", inputGenerate.OutputFile).Int("accounts", len(accounts)).Msg("Genesis written")
+		return nil
+	},
+}
+
+func init() {
+	flags := generateCmd.PersistentFlags()
+	flags.Uint64Var(&inputGenerate.ChainID, "chain-id", 1337, "the chain id")
+	flags.StringVar(&inputGenerate.Consensus, "consensus", consensusClique, "the consensus engine [clique, bor, ethash]")
+	flags.StringVar(&inputGenerate.Mnemonic, "mnemonic", "code code code code code code code code code code code quality", "the mnemonic used to derive the prefunded accounts. "+util.SecretHelp)
+	flags.StringVar(&inputGenerate.Password, "password", "", "the password used along with the mnemonic. "+util.SecretHelp)
+	flags.StringVar(&inputGenerate.Path, "path", "m/44'/60'/0'", "the derivation path of the accounts")
+	flags.UintVar(&inputGenerate.Accounts, "accounts", 10, "the number of prefunded accounts to derive")
+	flags.StringVar(&inputGenerate.Balance, "balance", "1000000000000000000000000", "the balance in wei of each prefunded account")
+	flags.Uint64Var(&inputGenerate.GasLimit, "gas-limit", 30000000, "the genesis block gas limit")
+	flags.StringVar(&inputGenerate.AllocFile, "alloc", "", "a json file with additional alloc entries (e.g. genesis contracts)")
+	flags.StringSliceVar(&inputGenerate.Forks, "fork", []string{}, "override a fork block in the format name=block (e.g. london=100). The forks up to london default to 0, arrowglacier, grayglacier, and shanghai are disabled unless set")
+	flags.StringVarP(&inputGenerate.OutputFile, "output", "o", "", "where to write the genesis (default stdout)")
+	flags.Uint64Var(&inputGenerate.CliquePeriod, "clique-period", 5, "the clique block period in seconds")
+	flags.Uint64Var(&inputGenerate.BorPeriod, "bor-period", 2, "the bor block period in seconds")
+	flags.Uint64Var(&inputGenerate.BorSprint, "bor-sprint", 64, "the bor sprint length")
+	flags.Uint64Var(&inputGenerate.BorProducerDelay, "bor-producer-delay", 6, "the bor producer delay in seconds")
+	flags.Uint64Var(&inputGenerate.BorBackupMultiplier, "bor-backup-multiplier", 2, "the bor backup multiplier")
+	flags.StringVar(&inputGenerate.ValidatorContract, "validator-contract", "0x0000000000000000000000000000000000001000", "the bor validator set contract address")
+	flags.StringVar(&inputGenerate.StateReceiverContract, "state-receiver-contract", "0x0000000000000000000000000000000000001001", "the bor state receiver contract address")
+}
+
+// newChainConfig returns a chain config with the forks up to london enabled at
+// genesis, and the later ones disabled, except for the ones overridden with the
+// --fork flag.
+func newChainConfig() (*params.ChainConfig, error) {
+	config := &params.ChainConfig{
+		ChainID:             new(big.Int).SetUint64(inputGenerate.ChainID),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		MuirGlacierBlock:    big.NewInt(0),
+		BerlinBlock:         big.NewInt(0),
+		LondonBlock:         big.NewInt(0),
+	}
+
+	forks := map[string]**big.Int{
+		"homestead":      &config.HomesteadBlock,
+		"eip150":         &config.EIP150Block,
+		"eip155":         &config.EIP155Block,
+		"eip158":         &config.EIP158Block,
+		"byzantium":      &config.ByzantiumBlock,
+		"constantinople": &config.ConstantinopleBlock,
+		"petersburg":     &config.PetersburgBlock,
+		"istanbul":       &config.IstanbulBlock,
+		"muirglacier":    &config.MuirGlacierBlock,
+		"berlin":         &config.BerlinBlock,
+		"london":         &config.LondonBlock,
+		"arrowglacier":   &config.ArrowGlacierBlock,
+		"grayglacier":    &config.GrayGlacierBlock,
+		"shanghai":       &config.ShanghaiBlock,
+	}
+	for _, f := range inputGenerate.Forks {
+		parts := strings.Split(f, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("fork %s should be in the format name=block", f)
+		}
+		block, ok := forks[strings.ToLower(parts[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown fork %s", parts[0])
+		}
+		number, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse block of fork %s: %w", parts[0], err)
+		}
+		*block = new(big.Int).SetUint64(number)
+	}
+
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func deriveAccounts() ([]ethcommon.Address, error) {
+	if inputGenerate.Accounts == 0 {
+		return nil, nil
+	}
+
+	pw, err := hdwallet.NewPolyWallet(inputGenerate.Mnemonic, inputGenerate.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err = pw.SetPath(inputGenerate.Path); err != nil {
+		return nil, err
+	}
+	export, err := pw.ExportHDAddresses(int(inputGenerate.Accounts))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]ethcommon.Address, 0, len(export.Addresses))
+	for _, a := range export.Addresses {
+		accounts = append(accounts, ethcommon.HexToAddress(a.ETHAddress))
+	}
+	return accounts, nil
+}
+
+func mergeAlloc(alloc core.GenesisAlloc, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	extra := make(core.GenesisAlloc)
+	if err = extra.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("unable to parse alloc file: %w", err)
+	}
+	for addr, account := range extra {
+		if _, ok := alloc[addr]; ok {
+			log.Warn().Str("address", addr.Hex()).Msg("Alloc file overrides a prefunded account")
+		}
+		alloc[addr] = account
+	}
+	return nil
+}
+
+// marshalGenesis encodes the genesis and adds the bor section to the chain
+// config when needed.
+func marshalGenesis(genesis *core.Genesis) ([]byte, error) {
+	if inputGenerate.Consensus != consensusBor {
+		return json.MarshalIndent(genesis, "", "  ")
+	}
+
+	out, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers are decoded as json.Number so large values aren't truncated.
+	var g map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	if err = decoder.Decode(&g); err != nil {
+		return nil, err
+	}
+	config, ok := g["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to add bor config")
+	}
+	zero := uint64(0)
+	config["bor"] = borConfig{
+		JaipurBlock:           &zero,
+		DelhiBlock:            &zero,
+		IndoreBlock:           &zero,
+		Period:                map[string]uint64{"0": inputGenerate.BorPeriod},
+		ProducerDelay:         map[string]uint64{"0": inputGenerate.BorProducerDelay},
+		Sprint:                map[string]uint64{"0": inputGenerate.BorSprint},
+		BackupMultiplier:      map[string]uint64{"0": inputGenerate.BorBackupMultiplier},
+		ValidatorContract:     inputGenerate.ValidatorContract,
+		StateReceiverContract: inputGenerate.StateReceiverContract,
+	}
+	return json.MarshalIndent(g, "", "  ")
+}
@@ -0,0 +1,74 @@
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/spf13/cobra"
+)
+
+type (
+	// borConfig is the bor specific section of the chain config. Most values
+	// are keyed by the block number from which they apply.
+	borConfig struct {
+		JaipurBlock           *uint64                      `json:"jaipurBlock,omitempty"`
+		DelhiBlock            *uint64                      `json:"delhiBlock,omitempty"`
+		IndoreBlock           *uint64                      `json:"indoreBlock,omitempty"`
+		Period                map[string]uint64            `json:"period"`
+		ProducerDelay         map[string]uint64            `json:"producerDelay"`
+		Sprint                map[string]uint64            `json:"sprint"`
+		BackupMultiplier      map[string]uint64            `json:"backupMultiplier"`
+		ValidatorContract     string                       `json:"validatorContract"`
+		StateReceiverContract string                       `json:"stateReceiverContract"`
+		BurntContract         map[string]ethcommon.Address `json:"burntContract,omitempty"`
+	}
+)
+
+//go:embed usage.md
+var usage string
+
+// GenesisCmd represents the genesis command
+var GenesisCmd = &cobra.Command{
+	Use:   "genesis",
+	Short: "Generate, validate, and hash genesis files.",
+	Long:  usage,
+}
+
+func init() {
+	GenesisCmd.AddCommand(generateCmd)
+	GenesisCmd.AddCommand(validateCmd)
+	GenesisCmd.AddCommand(hashCmd)
+}
+
+// loadGenesis reads a genesis file. The bor section of the chain config is
+// returned separately because it isn't part of the geth chain config.
+func loadGenesis(file string) (*core.Genesis, *borConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	genesis := new(core.Genesis)
+	if err = json.Unmarshal(data, genesis); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse genesis file: %w", err)
+	}
+	if genesis.Config == nil {
+		return nil, nil, fmt.Errorf("the genesis file has no chain config")
+	}
+
+	var raw struct {
+		Config struct {
+			Bor *borConfig `json:"bor"`
+		} `json:"config"`
+	}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse bor config: %w", err)
+	}
+
+	return genesis, raw.Config.Bor, nil
+}
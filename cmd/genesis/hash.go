@@ -0,0 +1,33 @@
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var hashCmd = &cobra.Command{
+	Use:   "hash genesis.json",
+	Short: "Compute the genesis block hash and state root.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		genesis, _, err := loadGenesis(args[0])
+		if err != nil {
+			return err
+		}
+
+		block := genesis.ToBlock()
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"chainId":   genesis.Config.ChainID,
+			"hash":      block.Hash(),
+			"stateRoot": block.Root(),
+			"accounts":  len(genesis.Alloc),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
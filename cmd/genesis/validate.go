@@ -0,0 +1,108 @@
+package genesis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate genesis.json",
+	Short: "Check a genesis file for common mistakes.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		genesis, bor, err := loadGenesis(args[0])
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		config := genesis.Config
+		if config.ChainID == nil || config.ChainID.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("chainId must be set to a positive value"))
+		}
+		if err = config.CheckConfigForkOrder(); err != nil {
+			errs = append(errs, err)
+		}
+		if genesis.GasLimit == 0 {
+			errs = append(errs, fmt.Errorf("gasLimit must be greater than zero"))
+		}
+		if genesis.Difficulty == nil {
+			errs = append(errs, fmt.Errorf("difficulty must be set"))
+		}
+		if len(genesis.Alloc) == 0 {
+			log.Warn().Msg("No accounts are prefunded")
+		}
+
+		if config.Clique != nil {
+			// The clique extra data is 32 bytes of vanity, the signer
+			// addresses, and a 65 byte seal.
+			signers := len(genesis.ExtraData) - 32 - crypto.SignatureLength
+			if signers <= 0 || signers%20 != 0 {
+				errs = append(errs, fmt.Errorf("clique extraData must contain 32 bytes of vanity, at least one signer, and 65 bytes of seal"))
+			}
+			if config.Clique.Period == 0 {
+				log.Warn().Msg("Clique period is zero, blocks will only be sealed when there are pending transactions")
+			}
+		}
+
+		if bor != nil {
+			errs = append(errs, validateBor(bor, genesis.Alloc)...)
+		}
+
+		if len(errs) > 0 {
+			for _, err := range errs {
+				log.Error().Err(err).Msg("Invalid genesis")
+			}
+			return errors.New("genesis validation failed")
+		}
+
+		log.Info().Str("file", args[0]).Msg("Genesis is valid")
+		return nil
+	},
+}
+
+// validateBor checks the bor section of the chain config. The validator set
+// and state receiver contracts are deployed at genesis, so they must be part
+// of the alloc.
+func validateBor(bor *borConfig, alloc core.GenesisAlloc) []error {
+	var errs []error
+	for name, values := range map[string]map[string]uint64{
+		"period":           bor.Period,
+		"producerDelay":    bor.ProducerDelay,
+		"sprint":           bor.Sprint,
+		"backupMultiplier": bor.BackupMultiplier,
+	} {
+		if _, ok := values["0"]; !ok {
+			errs = append(errs, fmt.Errorf("bor %s must have a value for block 0", name))
+		}
+		for block := range values {
+			if _, err := strconv.ParseUint(block, 10, 64); err != nil {
+				errs = append(errs, fmt.Errorf("bor %s has an invalid block number %s", name, block))
+			}
+		}
+	}
+	if sprint, ok := bor.Sprint["0"]; ok && sprint == 0 {
+		errs = append(errs, fmt.Errorf("bor sprint must be greater than zero"))
+	}
+
+	for name, contract := range map[string]string{
+		"validatorContract":     bor.ValidatorContract,
+		"stateReceiverContract": bor.StateReceiverContract,
+	} {
+		if !ethcommon.IsHexAddress(contract) {
+			errs = append(errs, fmt.Errorf("bor %s is not a valid address", name))
+			continue
+		}
+		if account, ok := alloc[ethcommon.HexToAddress(contract)]; !ok || len(account.Code) == 0 {
+			errs = append(errs, fmt.Errorf("bor %s %s has no code in the alloc", name, contract))
+		}
+	}
+	return errs
+}
@@ -24,6 +24,9 @@ import (
 	"os"
 	"strings"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
 
 	_ "embed"
@@ -64,21 +67,51 @@ var (
 		"blake2b_512",
 		"keccak256",
 		"keccak512",
+		"keccak",
 	}
-	inputFileName *string
+	// supportedUtilities are helpers built on top of keccak256 that don't
+	// output a plain digest.
+	supportedUtilities = []string{
+		"selector",
+		"create",
+		"create2",
+	}
+	inputFileName     *string
+	inputIsHex        *bool
+	inputDeployer     *string
+	inputNonce        *uint64
+	inputSalt         *string
+	inputInitCode     *string
+	inputInitCodeHash *string
 )
 
 // hashCmd represents the hash command
 var HashCmd = &cobra.Command{
-	Use:   fmt.Sprintf("hash [%s]", strings.Join(supportedHashFunctions, "|")),
+	Use:   fmt.Sprintf("hash [%s|%s]", strings.Join(supportedHashFunctions, "|"), strings.Join(supportedUtilities, "|")),
 	Short: "Provide common crypto hashing functions.",
 	Long:  usage,
 	Run: func(cmd *cobra.Command, args []string) {
+		switch args[0] {
+		case "create", "create2":
+			address, err := getContractAddress(args[0])
+			if err != nil {
+				cmd.PrintErrf("There was an error computing the contract address: %s", err.Error())
+				return
+			}
+			cmd.Println(address.Hex())
+			return
+		}
+
 		data, err := getInputData(cmd, args)
 		if err != nil {
 			cmd.PrintErrf("There was a nerror reading input for hashing: %s", err.Error())
 			return
 		}
+		if args[0] == "selector" {
+			cmd.Println(hexutil.Encode(getSelector(string(data))))
+			return
+		}
+
 		h, err := getHash(args[0])
 		if err != nil {
 			cmd.PrintErrf("There was an error creating the hash function: %s", err.Error())
@@ -98,14 +131,25 @@ var HashCmd = &cobra.Command{
 				return nil
 			}
 		}
+		for _, v := range supportedUtilities {
+			if v == args[0] {
+				return nil
+			}
+		}
 
-		return fmt.Errorf("the name %s is not recognized. Please use one of the following: %s", args[0], strings.Join(supportedHashFunctions, ","))
+		return fmt.Errorf("the name %s is not recognized. Please use one of the following: %s,%s", args[0], strings.Join(supportedHashFunctions, ","), strings.Join(supportedUtilities, ","))
 	},
 }
 
 func init() {
 	flagSet := HashCmd.PersistentFlags()
 	inputFileName = flagSet.String("file", "", "Provide a filename to read and hash")
+	inputIsHex = flagSet.Bool("hex", false, "Decode the input as hex before hashing")
+	inputDeployer = flagSet.String("deployer", "", "The deployer address used to compute create and create2 addresses")
+	inputNonce = flagSet.Uint64("nonce", 0, "The deployer nonce used to compute create addresses")
+	inputSalt = flagSet.String("salt", "0x", "The 32 byte salt used to compute create2 addresses")
+	inputInitCode = flagSet.String("init-code", "", "The hex encoded init code used to compute create2 addresses")
+	inputInitCodeHash = flagSet.String("init-code-hash", "", "The keccak256 hash of the init code used to compute create2 addresses")
 }
 
 func getHash(name string) (hash.Hash, error) {
@@ -146,7 +190,7 @@ func getHash(name string) (hash.Hash, error) {
 		return blake2b.New384(nil)
 	case "blake2b_512":
 		return blake2b.New512(nil)
-	case "keccak256":
+	case "keccak256", "keccak":
 		return sha3.NewLegacyKeccak256(), nil
 	case "keccak512":
 		return sha3.NewLegacyKeccak512(), nil
@@ -155,7 +199,59 @@ func getHash(name string) (hash.Hash, error) {
 	return h, fmt.Errorf("unable to create a hash function for %s", name)
 }
 
+// getSelector returns the 4 byte function selector of a signature. Spaces are
+// removed so signatures like "transfer(address, uint256)" work too.
+func getSelector(signature string) []byte {
+	signature = strings.Join(strings.Fields(signature), "")
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// getContractAddress computes the address of a contract deployed with either
+// CREATE or CREATE2.
+func getContractAddress(mode string) (ethcommon.Address, error) {
+	if !ethcommon.IsHexAddress(*inputDeployer) {
+		return ethcommon.Address{}, fmt.Errorf("a valid deployer address is required")
+	}
+	deployer := ethcommon.HexToAddress(*inputDeployer)
+
+	if mode == "create" {
+		return crypto.CreateAddress(deployer, *inputNonce), nil
+	}
+
+	salt, err := hexutil.Decode(*inputSalt)
+	if err != nil || len(salt) > 32 {
+		return ethcommon.Address{}, fmt.Errorf("the salt should be at most 32 hex encoded bytes")
+	}
+
+	var initCodeHash []byte
+	switch {
+	case *inputInitCodeHash != "":
+		initCodeHash, err = hexutil.Decode(*inputInitCodeHash)
+		if err != nil || len(initCodeHash) != 32 {
+			return ethcommon.Address{}, fmt.Errorf("the init code hash should be 32 hex encoded bytes")
+		}
+	case *inputInitCode != "":
+		initCode, err := hexutil.Decode(*inputInitCode)
+		if err != nil {
+			return ethcommon.Address{}, fmt.Errorf("unable to decode init code: %w", err)
+		}
+		initCodeHash = crypto.Keccak256(initCode)
+	default:
+		return ethcommon.Address{}, fmt.Errorf("either the init code or the init code hash is required")
+	}
+
+	return crypto.CreateAddress2(deployer, ethcommon.BytesToHash(salt), initCodeHash), nil
+}
+
 func getInputData(cmd *cobra.Command, args []string) ([]byte, error) {
+	data, err := getRawInputData(cmd, args)
+	if err != nil || !*inputIsHex {
+		return data, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+}
+
+func getRawInputData(cmd *cobra.Command, args []string) ([]byte, error) {
 	// first check and see if we have an input file
 	if inputFileName != nil && *inputFileName != "" {
 		// If we get here, we're going to assume the user
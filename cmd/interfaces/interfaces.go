@@ -0,0 +1,258 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// supportsInterfaceGas is the gas that ERC-165 allows supportsInterface to
+// use, so that callers can bound the cost of the detection.
+const supportsInterfaceGas = 30000
+
+type (
+	interfacesParams struct {
+		RPCURL     string
+		Address    string
+		Block      string
+		Interfaces []string
+		JSON       bool
+	}
+
+	// knownInterface is an interface ID and the standard it identifies.
+	knownInterface struct {
+		Name string
+		ID   [4]byte
+	}
+
+	interfaceResult struct {
+		Name      string `json:"name"`
+		ID        string `json:"id"`
+		Supported bool   `json:"supported"`
+	}
+
+	interfacesReport struct {
+		Address ethcommon.Address `json:"address"`
+		// ERC165 is whether the contract implements ERC-165. The other
+		// interfaces are only probed if it does, since the answers of
+		// contracts that don't can't be trusted.
+		ERC165     bool              `json:"erc165"`
+		Interfaces []interfaceResult `json:"interfaces,omitempty"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage           string
+	inputInterfaces interfacesParams
+
+	// supportsInterfaceSelector is the selector of supportsInterface(bytes4).
+	supportsInterfaceSelector = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+
+	knownInterfaces = []knownInterface{
+		{"ERC-721", [4]byte{0x80, 0xac, 0x58, 0xcd}},
+		{"ERC-721 Metadata", [4]byte{0x5b, 0x5e, 0x13, 0x9f}},
+		{"ERC-721 Enumerable", [4]byte{0x78, 0x0e, 0x9d, 0x63}},
+		{"ERC-721 Receiver", [4]byte{0x15, 0x0b, 0x7a, 0x02}},
+		{"ERC-1155", [4]byte{0xd9, 0xb6, 0x7a, 0x26}},
+		{"ERC-1155 Metadata URI", [4]byte{0x0e, 0x89, 0x34, 0x1c}},
+		{"ERC-1155 Receiver", [4]byte{0x4e, 0x23, 0x12, 0xe0}},
+		{"ERC-2981 Royalties", [4]byte{0x2a, 0x55, 0x20, 0x5a}},
+		{"ERC-4906 Metadata Update", [4]byte{0x49, 0x06, 0x49, 0x06}},
+		{"ERC-5192 Soulbound", [4]byte{0xb4, 0x5a, 0x3c, 0x0e}},
+		{"AccessControl", [4]byte{0x79, 0x65, 0xdb, 0x0b}},
+		{"AccessControlEnumerable", [4]byte{0x5a, 0x05, 0x18, 0x0f}},
+	}
+)
+
+// InterfacesCmd detects the ERC-165 interfaces that a contract implements.
+var InterfacesCmd = &cobra.Command{
+	Use:   "interfaces",
+	Short: "Detect which ERC-165 interfaces, like ERC-721, ERC-1155, and ERC-2981, a contract implements.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !addressbook.Valid(inputInterfaces.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputInterfaces.Address)
+		}
+		for _, id := range inputInterfaces.Interfaces {
+			if _, err := parseInterfaceID(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputInterfaces.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+		ec := ethclient.NewClient(rpc)
+
+		opts := &bind.CallOpts{Context: ctx}
+		if inputInterfaces.Block != "latest" {
+			n, ok := new(big.Int).SetString(inputInterfaces.Block, 0)
+			if !ok {
+				return fmt.Errorf("the block %s isn't a number", inputInterfaces.Block)
+			}
+			opts.BlockNumber = n
+		}
+
+		address, err := addressbook.NewResolver(rpc).Resolve(ctx, inputInterfaces.Address)
+		if err != nil {
+			return err
+		}
+		code, err := ec.CodeAt(ctx, address, opts.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if len(code) == 0 {
+			return fmt.Errorf("%s has no code", address.Hex())
+		}
+
+		probes := knownInterfaces
+		for _, s := range inputInterfaces.Interfaces {
+			id, _ := parseInterfaceID(s)
+			probes = append(probes, knownInterface{Name: "Custom", ID: id})
+		}
+
+		report, err := probeInterfaces(ec, opts, address, probes)
+		if err != nil {
+			return err
+		}
+
+		if inputInterfaces.JSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		printInterfaces(report)
+		return nil
+	},
+}
+
+func init() {
+	flagSet := InterfacesCmd.Flags()
+	flagSet.StringVar(&inputInterfaces.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputInterfaces.Address, "address", "", "The address of the contract to probe. "+addressbook.Help)
+	flagSet.StringVar(&inputInterfaces.Block, "block", "latest", "The block number to probe the contract at")
+	flagSet.StringSliceVar(&inputInterfaces.Interfaces, "interface", nil, "Additional 4 byte interface IDs to probe, like 0x80ac58cd")
+	flagSet.BoolVar(&inputInterfaces.JSON, "json", false, "Output the interfaces as JSON")
+	_ = InterfacesCmd.MarkFlagRequired("address")
+}
+
+func parseInterfaceID(s string) ([4]byte, error) {
+	var id [4]byte
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("the interface ID %s isn't 4 bytes of hex", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// supportsInterface calls supportsInterface(id) on the contract with the gas
+// that ERC-165 allows. Reverts and malformed answers count as unsupported,
+// while errors of the endpoint are returned.
+func supportsInterface(ec *ethclient.Client, opts *bind.CallOpts, address ethcommon.Address, id [4]byte) (bool, error) {
+	data := make([]byte, 36)
+	copy(data, supportsInterfaceSelector[:])
+	copy(data[4:], id[:])
+	out, err := ec.CallContract(opts.Context, ethereum.CallMsg{To: &address, Gas: supportsInterfaceGas, Data: data}, opts.BlockNumber)
+	if err != nil {
+		var rpcErr ethrpc.Error
+		if errors.As(err, &rpcErr) {
+			log.Debug().Err(err).Str("interface", hexutil.Encode(id[:])).Msg("supportsInterface reverted")
+			return false, nil
+		}
+		return false, err
+	}
+	if len(out) != 32 {
+		return false, nil
+	}
+	return new(big.Int).SetBytes(out).Cmp(big.NewInt(1)) == 0, nil
+}
+
+// probeInterfaces follows the detection of ERC-165: the contract has to
+// support the ERC-165 interface ID and not support 0xffffffff before the
+// other interfaces are asked for.
+func probeInterfaces(ec *ethclient.Client, opts *bind.CallOpts, address ethcommon.Address, probes []knownInterface) (*interfacesReport, error) {
+	report := &interfacesReport{Address: address}
+	supported, err := supportsInterface(ec, opts, address, supportsInterfaceSelector)
+	if err != nil || !supported {
+		return report, err
+	}
+	invalid, err := supportsInterface(ec, opts, address, [4]byte{0xff, 0xff, 0xff, 0xff})
+	if err != nil || invalid {
+		if invalid {
+			log.Warn().Msg("The contract claims to support the invalid interface 0xffffffff, so it doesn't implement ERC-165 correctly")
+		}
+		return report, err
+	}
+	report.ERC165 = true
+
+	for _, p := range probes {
+		supported, err := supportsInterface(ec, opts, address, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		report.Interfaces = append(report.Interfaces, interfaceResult{Name: p.Name, ID: hexutil.Encode(p.ID[:]), Supported: supported})
+	}
+	return report, nil
+}
+
+func printInterfaces(report *interfacesReport) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle("Interfaces of " + report.Address.Hex())
+	t.AppendHeader(table.Row{"Interface", "ID", "Supported"})
+	t.AppendRow(table.Row{"ERC-165", hexutil.Encode(supportsInterfaceSelector[:]), yesNo(report.ERC165)})
+	var names []string
+	for _, r := range report.Interfaces {
+		t.AppendRow(table.Row{r.Name, r.ID, yesNo(r.Supported)})
+		if r.Supported {
+			names = append(names, r.Name)
+		}
+	}
+	t.Render()
+
+	switch {
+	case !report.ERC165:
+		fmt.Println("The contract doesn't implement ERC-165, so its interfaces can't be detected.")
+	case len(names) == 0:
+		fmt.Println("The contract implements ERC-165, but none of the probed interfaces.")
+	default:
+		fmt.Printf("The contract implements %s.\n", strings.Join(names, ", "))
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
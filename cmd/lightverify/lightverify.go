@@ -0,0 +1,345 @@
+package lightverify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	lightVerifyParams struct {
+		RPCURL    string
+		Signers   []string
+		From      uint64
+		Interval  time.Duration
+		BatchSize uint64
+		Window    int
+		Addresses []string
+		Slots     []string
+
+		Sprint      uint64
+		JaipurBlock uint64
+	}
+
+	// verifiedHeader is a header whose hash, parent link, and seal were
+	// verified, with the validator set that signs the blocks after it.
+	verifiedHeader struct {
+		Number     uint64
+		Hash       ethcommon.Hash
+		StateRoot  ethcommon.Hash
+		Validators map[ethcommon.Address]struct{}
+	}
+
+	// borValidator is a validator returned by bor_getCurrentValidators.
+	borValidator struct {
+		Signer ethcommon.Address `json:"signer"`
+		Power  int64             `json:"power"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage            string
+	inputLightVerify lightVerifyParams
+
+	// errReorg is returned when a header doesn't link to the last verified
+	// header, so the verified chain has to be rolled back.
+	errReorg = errors.New("the header doesn't link to the last verified header")
+)
+
+// LightVerifyCmd follows the head of an untrusted RPC and verifies headers and
+// proofs against the validator set.
+var LightVerifyCmd = &cobra.Command{
+	Use:   "lightverify",
+	Short: "Follow the head of an untrusted RPC, verify the bor seals of the headers, and verify account proofs against them.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		for _, s := range inputLightVerify.Signers {
+			if !ethcommon.IsHexAddress(s) {
+				return fmt.Errorf("%s is not a valid signer address", s)
+			}
+		}
+		for _, a := range inputLightVerify.Addresses {
+			if !ethcommon.IsHexAddress(a) {
+				return fmt.Errorf("the address %s isn't valid", a)
+			}
+		}
+		for _, s := range inputLightVerify.Slots {
+			if _, err := util.ParseSlot(s); err != nil {
+				return err
+			}
+		}
+		if len(inputLightVerify.Slots) > 0 && len(inputLightVerify.Addresses) == 0 {
+			return errors.New("slots can only be proven along with an address")
+		}
+		if inputLightVerify.BatchSize == 0 {
+			return errors.New("the batch size must be greater than zero")
+		}
+		if inputLightVerify.Window < 1 {
+			return errors.New("the window must be at least one header")
+		}
+		if inputLightVerify.Sprint == 0 {
+			return errors.New("the sprint length must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputLightVerify.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		validators, err := initialValidators(ctx, rpc)
+		if err != nil {
+			return err
+		}
+
+		next := inputLightVerify.From
+		if next == 0 {
+			var head hexutil.Uint64
+			if err = rpc.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+				return err
+			}
+			next = uint64(head)
+		}
+		log.Info().Uint64("from", next).Int("validators", len(validators)).Msg("Starting light verification")
+
+		cmd.SilenceUsage = true
+		return follow(ctx, rpc, next, validators)
+	},
+}
+
+func init() {
+	flagSet := LightVerifyCmd.Flags()
+	flagSet.StringVar(&inputLightVerify.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringSliceVar(&inputLightVerify.Signers, "signers", nil, "The trusted validator set at the first block (default the validators returned by the RPC)")
+	flagSet.Uint64Var(&inputLightVerify.From, "from", 0, "The first block to verify (default the head)")
+	flagSet.DurationVar(&inputLightVerify.Interval, "interval", 2*time.Second, "How often to poll the RPC for new headers")
+	flagSet.Uint64VarP(&inputLightVerify.BatchSize, "batch-size", "b", 100, "The number of headers to fetch per batch request")
+	flagSet.IntVar(&inputLightVerify.Window, "window", 128, "The number of verified headers kept to follow reorgs")
+	flagSet.StringSliceVar(&inputLightVerify.Addresses, "address", nil, "The accounts to prove at every new verified head. Can be repeated")
+	flagSet.StringSliceVar(&inputLightVerify.Slots, "slot", nil, "The storage slots to prove for every account, as hex or decimal. Can be repeated")
+	flagSet.Uint64Var(&inputLightVerify.Sprint, "sprint", 16, "The sprint length, to check that only sprint end headers have validator bytes")
+	flagSet.Uint64Var(&inputLightVerify.JaipurBlock, "jaipur-block", 0, "The Jaipur block, from which the seals cover the base fee")
+}
+
+// initialValidators returns the validator set that the first header is
+// verified against. Unless the set is given, the RPC is trusted for it.
+func initialValidators(ctx context.Context, rpc *ethrpc.Client) (map[ethcommon.Address]struct{}, error) {
+	validators := make(map[ethcommon.Address]struct{})
+	if len(inputLightVerify.Signers) > 0 {
+		for _, s := range inputLightVerify.Signers {
+			validators[ethcommon.HexToAddress(s)] = struct{}{}
+		}
+		return validators, nil
+	}
+
+	var current []borValidator
+	if err := rpc.CallContext(ctx, &current, "bor_getCurrentValidators"); err != nil {
+		return nil, fmt.Errorf("unable to get the current validators, use --signers to provide them: %w", err)
+	}
+	if len(current) == 0 {
+		return nil, errors.New("the RPC returned an empty validator set, use --signers to provide it")
+	}
+	for _, v := range current {
+		validators[v.Signer] = struct{}{}
+	}
+	log.Warn().Int("validators", len(validators)).Msg("Trusting the validator set returned by the RPC, use --signers to provide a trusted one")
+	return validators, nil
+}
+
+// follow verifies the headers from the block on as they are produced, and the
+// account proofs at every new verified head, until the context is done.
+func follow(ctx context.Context, rpc *ethrpc.Client, next uint64, validators map[ethcommon.Address]struct{}) error {
+	var chain []verifiedHeader
+	for {
+		var head hexutil.Uint64
+		if err := rpc.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			log.Error().Err(err).Msg("Unable to get the head")
+		}
+
+		advanced := false
+	batches:
+		for next <= uint64(head) {
+			end := next + inputLightVerify.BatchSize - 1
+			if end > uint64(head) {
+				end = uint64(head)
+			}
+			blocks, err := util.GetBlockRange(ctx, next, end, rpc)
+			if err != nil {
+				log.Error().Err(err).Uint64("start", next).Uint64("end", end).Msg("Unable to get headers")
+				break
+			}
+
+			for _, raw := range blocks {
+				// The RPC might not have the block yet if it's behind a load
+				// balancer.
+				if raw == nil || string(*raw) == "null" {
+					break
+				}
+				current := validators
+				if len(chain) > 0 {
+					current = chain[len(chain)-1].Validators
+				}
+				h, err := verifyHeader(*raw, chain, current, &inputLightVerify)
+				if errors.Is(err, errReorg) {
+					if chain, err = rollback(chain); err != nil {
+						return err
+					}
+					next = chain[len(chain)-1].Number + 1
+					continue batches
+				}
+				if err != nil {
+					return err
+				}
+
+				chain = append(chain, *h)
+				if len(chain) > inputLightVerify.Window {
+					chain = chain[len(chain)-inputLightVerify.Window:]
+				}
+				next = h.Number + 1
+				advanced = true
+			}
+			if len(blocks) == 0 || next <= end {
+				break
+			}
+			last := chain[len(chain)-1]
+			log.Info().Uint64("number", last.Number).Str("hash", last.Hash.Hex()).Msg("Verified headers")
+		}
+
+		if advanced && len(inputLightVerify.Addresses) > 0 {
+			verifyProofs(ctx, rpc, chain[len(chain)-1])
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(inputLightVerify.Interval):
+		}
+	}
+}
+
+// verifyHeader verifies the hash of the header, that it links to the last
+// verified header, that it's sealed by one of the validators, and that only
+// sprint end headers have validator bytes.
+func verifyHeader(raw json.RawMessage, chain []verifiedHeader, validators map[ethcommon.Address]struct{}, params *lightVerifyParams) (*verifiedHeader, error) {
+	header, claimedHash, err := util.ParseHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	number := header.Number.Uint64()
+
+	if header.Hash() != claimedHash {
+		return nil, fmt.Errorf("header %d has hash %s but hashes to %s", number, claimedHash.Hex(), header.Hash().Hex())
+	}
+	if len(chain) > 0 && header.ParentHash != chain[len(chain)-1].Hash {
+		log.Warn().
+			Uint64("number", number).
+			Str("parentHash", header.ParentHash.Hex()).
+			Str("verifiedHash", chain[len(chain)-1].Hash.Hex()).
+			Msg("Header doesn't link to the last verified header")
+		return nil, errReorg
+	}
+
+	signer, err := util.BorSealSigner(header, params.JaipurBlock)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover the signer of header %d: %w", number, err)
+	}
+	if _, ok := validators[signer]; !ok {
+		return nil, fmt.Errorf("header %d is sealed by %s, which isn't a validator", number, signer.Hex())
+	}
+	if err = util.CheckBorValidatorBytes(header, params.Sprint); err != nil {
+		return nil, err
+	}
+	log.Debug().Uint64("number", number).Str("hash", claimedHash.Hex()).Str("signer", signer.Hex()).Msg("Verified header")
+
+	// The validator set only changes at the end of a sprint, and only a
+	// verified header can change it.
+	if next, ok := util.BorValidators(header); ok {
+		if !sameValidators(validators, next) {
+			log.Info().Uint64("number", number).Int("validators", len(next)).Msg("Validator set changed")
+		}
+		validators = next
+	}
+	return &verifiedHeader{Number: number, Hash: claimedHash, StateRoot: header.Root, Validators: validators}, nil
+}
+
+// rollback drops the last verified header after a reorg, so that the new
+// header at its height gets verified. Reorgs deeper than the window can't be
+// followed because there's no verified header left to link to.
+func rollback(chain []verifiedHeader) ([]verifiedHeader, error) {
+	dropped := chain[len(chain)-1]
+	chain = chain[:len(chain)-1]
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("the reorg at block %d is deeper than the window of %d headers", dropped.Number, inputLightVerify.Window)
+	}
+	log.Warn().Uint64("number", dropped.Number).Str("hash", dropped.Hash.Hex()).Msg("Rolled back verified header")
+	return chain, nil
+}
+
+// verifyProofs proves the accounts at the verified header. The proofs are
+// requested by hash so that they can't be for a different block at the same
+// height.
+func verifyProofs(ctx context.Context, rpc *ethrpc.Client, h verifiedHeader) {
+	slots := make([]ethcommon.Hash, len(inputLightVerify.Slots))
+	for i, s := range inputLightVerify.Slots {
+		slots[i], _ = util.ParseSlot(s)
+	}
+	block := map[string]interface{}{"blockHash": h.Hash}
+
+	for _, a := range inputLightVerify.Addresses {
+		address := ethcommon.HexToAddress(a)
+		var p util.AccountProof
+		if err := rpc.CallContext(ctx, &p, "eth_getProof", address, slots, block); err != nil {
+			log.Error().Err(err).Uint64("number", h.Number).Str("address", address.Hex()).Msg("Unable to get the proof")
+			continue
+		}
+
+		account, err := util.VerifyAccountProof(h.StateRoot, address, slots, &p)
+		if err != nil {
+			log.Error().Err(err).Uint64("number", h.Number).Str("address", address.Hex()).Msg("The proof failed verification")
+			continue
+		}
+		storage := make(map[string]string, len(account.Storage))
+		for _, s := range account.Storage {
+			storage[s.Slot.Hex()] = s.Value.Hex()
+		}
+		log.Info().
+			Uint64("number", h.Number).
+			Str("address", address.Hex()).
+			Bool("exists", account.Exists).
+			Uint64("nonce", account.Nonce).
+			Str("balance", account.Balance.ToInt().String()).
+			Str("codeHash", account.CodeHash.Hex()).
+			Interface("storage", storage).
+			Msg("Verified account")
+	}
+}
+
+func sameValidators(a, b map[ethcommon.Address]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for v := range a {
+		if _, ok := b[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package lightverify
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+func TestVerifyHeader(t *testing.T) {
+	key, err := crypto.HexToECDSA("42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	other := ethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	validatorBytes := append(other.Bytes(), ethcommon.LeftPadBytes([]byte{1}, 20)...)
+
+	params := &lightVerifyParams{Sprint: 16, JaipurBlock: 100}
+
+	type test struct {
+		name           string
+		number         int64
+		validatorBytes []byte
+		cliqueSeal     bool
+		validators     []ethcommon.Address
+		valid          bool
+		next           []ethcommon.Address
+	}
+	tests := []test{
+		{
+			name:       "sealed after jaipur",
+			number:     100,
+			validators: []ethcommon.Address{signer},
+			valid:      true,
+			next:       []ethcommon.Address{signer},
+		},
+		{
+			name:       "sealed before jaipur",
+			number:     98,
+			validators: []ethcommon.Address{signer},
+			valid:      true,
+			next:       []ethcommon.Address{signer},
+		},
+		{
+			name:       "clique seal before jaipur",
+			number:     98,
+			cliqueSeal: true,
+			validators: []ethcommon.Address{signer},
+		},
+		{
+			name:       "not a validator",
+			number:     100,
+			validators: []ethcommon.Address{other},
+		},
+		{
+			name:           "sprint end changes the validators",
+			number:         111,
+			validatorBytes: validatorBytes,
+			validators:     []ethcommon.Address{signer},
+			valid:          true,
+			next:           []ethcommon.Address{other},
+		},
+		{
+			name:       "sprint end without validator bytes",
+			number:     111,
+			validators: []ethcommon.Address{signer},
+		},
+		{
+			name:           "validator bytes in a sprint",
+			number:         110,
+			validatorBytes: validatorBytes,
+			validators:     []ethcommon.Address{signer},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			extra := append(make([]byte, 32), tc.validatorBytes...)
+			header := &types.Header{
+				Difficulty: big.NewInt(16),
+				Number:     big.NewInt(tc.number),
+				GasLimit:   30000000,
+				Time:       1700000000,
+				Extra:      append(extra, make([]byte, crypto.SignatureLength)...),
+				BaseFee:    big.NewInt(30000000000),
+			}
+			hash := util.BorSealHash(header, params.JaipurBlock)
+			if tc.cliqueSeal {
+				hash = clique.SealHash(header)
+			}
+			sig, err := crypto.Sign(hash.Bytes(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sig)
+			raw, err := json.Marshal(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validators := make(map[ethcommon.Address]struct{})
+			for _, v := range tc.validators {
+				validators[v] = struct{}{}
+			}
+			h, err := verifyHeader(raw, nil, validators, params)
+			if !tc.valid {
+				if err == nil {
+					t.Fatal("expected the header to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the header to be verified, got %v", err)
+			}
+			if h.Hash != header.Hash() {
+				t.Errorf("expected the hash %s, got %s", header.Hash().Hex(), h.Hash.Hex())
+			}
+			if len(h.Validators) != len(tc.next) {
+				t.Fatalf("expected %d validators after the header, got %d", len(tc.next), len(h.Validators))
+			}
+			for _, v := range tc.next {
+				if _, ok := h.Validators[v]; !ok {
+					t.Errorf("expected %s to be a validator after the header", v.Hex())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package loadtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+)
+
+// backpressure pauses the load test while too many of its transactions are
+// pending in the pool of the node. Once the pool is flooded, the inclusion
+// latency only measures how long the transactions waited behind each other.
+type backpressure struct {
+	maxPending uint64
+
+	mu        sync.Mutex
+	open      chan struct{} // closed while requests can be sent
+	since     time.Time
+	throttled time.Duration
+	pauses    int
+}
+
+func newBackpressure(maxPending uint64) *backpressure {
+	open := make(chan struct{})
+	close(open)
+	return &backpressure{maxPending: maxPending, open: open}
+}
+
+// wait blocks while the load test is throttled.
+func (b *backpressure) wait(ctx context.Context) error {
+	b.mu.Lock()
+	open := b.open
+	b.mu.Unlock()
+	select {
+	case <-open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run polls the backlog of the account, which is the difference between its
+// pending nonce and its mined nonce, until the context is done.
+func (b *backpressure) run(ctx context.Context, c *ethclient.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	address := *inputLoadTestParams.FromETHAddress
+	for {
+		select {
+		case <-ticker.C:
+			pending, err := c.PendingNonceAt(ctx, address)
+			if err != nil {
+				log.Error().Err(err).Msg("Unable to get the pending nonce")
+				continue
+			}
+			mined, err := c.NonceAt(ctx, address, nil)
+			if err != nil {
+				log.Error().Err(err).Msg("Unable to get the nonce")
+				continue
+			}
+			var backlog uint64
+			if pending > mined {
+				backlog = pending - mined
+			}
+			if backlog > b.maxPending {
+				b.pause(backlog)
+			} else {
+				b.resume(backlog)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *backpressure) pause(backlog uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.since.IsZero() {
+		return
+	}
+	b.open = make(chan struct{})
+	b.since = time.Now()
+	b.pauses++
+	log.Warn().Uint64("pending", backlog).Uint64("maxPending", b.maxPending).Msg("Too many pending transactions, throttling the load test")
+}
+
+func (b *backpressure) resume(backlog uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.since.IsZero() {
+		return
+	}
+	close(b.open)
+	paused := time.Since(b.since)
+	b.throttled += paused
+	b.since = time.Time{}
+	log.Info().Uint64("pending", backlog).Dur("paused", paused).Msg("Resumed the load test")
+}
+
+// summary logs how long the load test was throttled.
+func (b *backpressure) summary() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	throttled := b.throttled
+	if !b.since.IsZero() {
+		throttled += time.Since(b.since)
+	}
+	log.Info().Int("pauses", b.pauses).Dur("throttled", throttled).Msg("Backpressure summary")
+}
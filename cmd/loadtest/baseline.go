@@ -0,0 +1,246 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+const (
+	phaseBefore = "before"
+	phaseDuring = "during"
+	phaseAfter  = "after"
+
+	// gasPriceSampleInterval is how often eth_gasPrice is sampled.
+	gasPriceSampleInterval = time.Second
+)
+
+type (
+	// phaseStats are the network conditions measured before, during, or after
+	// the load. Gas prices are in gwei and times in seconds.
+	phaseStats struct {
+		Phase            string  `json:"phase"`
+		Blocks           uint64  `json:"blocks"`
+		BlockTime        float64 `json:"blockTime"`
+		GasPrice         float64 `json:"gasPrice"`
+		BaseFee          float64 `json:"baseFee"`
+		Included         int     `json:"included"`
+		InclusionLatency float64 `json:"inclusionLatency"`
+	}
+
+	// gasPriceSampler polls the suggested gas price until its context is done.
+	gasPriceSampler struct {
+		samples []float64
+		mu      sync.Mutex
+	}
+)
+
+func toGwei(wei *big.Int) float64 {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gwei
+}
+
+func (s *gasPriceSampler) run(ctx context.Context, c *ethclient.Client) {
+	ticker := time.NewTicker(gasPriceSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		if price, err := c.SuggestGasPrice(ctx); err == nil {
+			s.mu.Lock()
+			s.samples = append(s.samples, toGwei(price))
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *gasPriceSampler) mean() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.samples) == 0 {
+		return 0
+	}
+	var total float64
+	for _, p := range s.samples {
+		total += p
+	}
+	return total / float64(len(s.samples))
+}
+
+// runBaselinePhase measures the network without the load for the duration.
+// Transfers are sent one at a time to measure the inclusion latency, so they
+// don't add load themselves, and their nonces are read from the node.
+func runBaselinePhase(ctx context.Context, s *txSender, phase string, duration time.Duration) (phaseStats, error) {
+	ltp := inputLoadTestParams
+	c := s.client
+	log.Info().Str("phase", phase).Dur("duration", duration).Msg("Measuring baseline")
+
+	startBlock, err := c.BlockNumber(ctx)
+	if err != nil {
+		return phaseStats{}, err
+	}
+	nonce, err := c.PendingNonceAt(ctx, *ltp.FromETHAddress)
+	if err != nil {
+		return phaseStats{}, err
+	}
+
+	phaseCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	var sampler gasPriceSampler
+	go sampler.run(phaseCtx, c)
+
+	sent := make(map[uint64]time.Time)
+	for phaseCtx.Err() == nil {
+		_, t2, err := loadtestTransaction(phaseCtx, s, nonce)
+		if err != nil {
+			if phaseCtx.Err() == nil {
+				log.Warn().Err(err).Str("phase", phase).Msg("Unable to send baseline transaction")
+			}
+			break
+		}
+		sent[nonce] = t2
+		nonce += 1
+
+		for phaseCtx.Err() == nil {
+			included, err := c.NonceAt(phaseCtx, *ltp.FromETHAddress, nil)
+			if err == nil && included >= nonce {
+				break
+			}
+			select {
+			case <-phaseCtx.Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+	<-phaseCtx.Done()
+	if ctx.Err() != nil {
+		return phaseStats{}, ctx.Err()
+	}
+
+	endBlock, err := c.BlockNumber(ctx)
+	if err != nil {
+		return phaseStats{}, err
+	}
+	return measurePhase(ctx, c, phase, startBlock, endBlock, sampler.mean(), sent)
+}
+
+// measurePhase computes the block time, base fee, and the inclusion latency of
+// the sent transactions, keyed by nonce, over the blocks after startBlock up
+// to endBlock.
+func measurePhase(ctx context.Context, c *ethclient.Client, phase string, startBlock, endBlock uint64, gasPrice float64, sent map[uint64]time.Time) (phaseStats, error) {
+	ltp := inputLoadTestParams
+	stats := phaseStats{Phase: phase, GasPrice: gasPrice}
+	if endBlock <= startBlock {
+		return stats, nil
+	}
+
+	parent, err := c.HeaderByNumber(ctx, new(big.Int).SetUint64(startBlock))
+	if err != nil {
+		return stats, err
+	}
+	first := parent.Time
+
+	signer := ethtypes.LatestSignerForChainID(new(big.Int).SetUint64(*ltp.ChainID))
+	var baseFees, latencies float64
+	for n := startBlock + 1; n <= endBlock; n++ {
+		block, err := c.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return stats, err
+		}
+		stats.Blocks += 1
+		if block.BaseFee() != nil {
+			baseFees += toGwei(block.BaseFee())
+		}
+
+		blockTime := time.Unix(int64(block.Time()), 0)
+		for _, tx := range block.Transactions() {
+			sentTime, ok := sent[tx.Nonce()]
+			if !ok {
+				continue
+			}
+			if from, err := ethtypes.Sender(signer, tx); err != nil || from != *ltp.FromETHAddress {
+				continue
+			}
+			latency := blockTime.Sub(sentTime)
+			// Block times only have a precision of a second.
+			if latency < 0 {
+				latency = 0
+			}
+			stats.Included += 1
+			latencies += latency.Seconds()
+		}
+
+		if n == endBlock {
+			stats.BlockTime = float64(block.Time()-first) / float64(stats.Blocks)
+		}
+	}
+
+	stats.BaseFee = baseFees / float64(stats.Blocks)
+	if stats.Included > 0 {
+		stats.InclusionLatency = latencies / float64(stats.Included)
+	}
+	return stats, nil
+}
+
+// sentDuringLoad returns the send times of the load test transactions.
+func sentDuringLoad() map[uint64]time.Time {
+	loadTestResutsMutex.RLock()
+	defer loadTestResutsMutex.RUnlock()
+
+	sent := make(map[uint64]time.Time, len(loadTestResults))
+	for _, s := range loadTestResults {
+		if !s.IsError {
+			sent[s.Nonce] = s.RequestTime.Add(s.WaitTime)
+		}
+	}
+	return sent
+}
+
+// printPhaseComparison prints the phases along with the change relative to the
+// first phase, which is the baseline.
+func printPhaseComparison(phases []phaseStats) {
+	if len(phases) == 0 {
+		return
+	}
+
+	p := message.NewPrinter(language.English)
+	if *inputLoadTestParams.SummaryOutputMode == "json" {
+		val, _ := json.MarshalIndent(phases, "", "    ")
+		p.Println(string(val))
+		return
+	}
+
+	base := phases[0]
+	change := func(v, b float64) string {
+		if b == 0 {
+			return "-"
+		}
+		return p.Sprintf("%+.1f%%", (v-b)/b*100)
+	}
+	p.Printf("Load impact compared to the %s baseline:\n", base.Phase)
+	for _, s := range phases {
+		p.Printf("%s\tBlocks: %v\tBlock Time: %vs (%s)\tGas Price: %v gwei (%s)\tBase Fee: %v gwei (%s)\tInclusion Latency: %vs (%s, %v tx)\n",
+			s.Phase,
+			number.Decimal(s.Blocks),
+			number.Decimal(s.BlockTime), change(s.BlockTime, base.BlockTime),
+			number.Decimal(s.GasPrice), change(s.GasPrice, base.GasPrice),
+			number.Decimal(s.BaseFee), change(s.BaseFee, base.BaseFee),
+			number.Decimal(s.InclusionLatency), change(s.InclusionLatency, base.InclusionLatency),
+			number.Decimal(s.Included))
+	}
+}
@@ -0,0 +1,101 @@
+package loadtest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/signerpool"
+)
+
+// With --send-batch-size, the requests of a routine are signed one by one but
+// sent together, as a single JSON-RPC batch of eth_sendRawTransaction calls.
+// At high rates the HTTP round trip of every request is the bottleneck of the
+// client rather than the node, and batching spreads it over the batch.
+//
+// With --replace-fee-bump, a transaction that the node rejects because a
+// pending transaction already has its nonce is signed again with higher fees
+// and sent as a replacement. This happens when a send timed out but still
+// reached the pool, so its nonce was reserved again for a new transaction, or
+// when transactions of a previous run are stuck in the pool.
+//
+// Both are options of the sender of a routine, which the requests send their
+// signed transactions with.
+
+// txSender sends the transactions of the requests of a routine.
+type txSender struct {
+	client  *ethclient.Client
+	account *signerpool.Account
+	opts    signerpool.SendOptions
+}
+
+// send sends the transaction, or queues it if the routine batches its
+// requests. The contract bindings sign their transactions with NoSend, so that
+// they're sent here as well.
+func (s *txSender) send(ctx context.Context, tx *ethtypes.Transaction) error {
+	return s.account.Send(ctx, s.client, tx, s.opts)
+}
+
+// queuedRequest is a request whose transaction is queued in a batch.
+type queuedRequest struct {
+	request int64
+	nonce   uint64
+}
+
+// batchStats collects the acceptance latency of the batches, which is the time
+// between sending a batch and getting the responses of all of its
+// transactions.
+type batchStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	sent      int
+	accepted  int
+}
+
+func (s *batchStats) add(latency time.Duration, errs []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, latency)
+	s.sent += len(errs)
+	for _, err := range errs {
+		if err == nil {
+			s.accepted++
+		}
+	}
+}
+
+// summary logs the number of batches and the distribution of their latency.
+func (s *batchStats) summary() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.latencies) == 0 {
+		log.Info().Msg("No batches were sent")
+		return
+	}
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	at := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+
+	log.Info().
+		Int("batches", len(sorted)).
+		Int("transactions", s.sent).
+		Int("accepted", s.accepted).
+		Dur("mean", total/time.Duration(len(sorted))).
+		Dur("p50", at(0.5)).
+		Dur("p90", at(0.9)).
+		Dur("p99", at(0.99)).
+		Dur("max", sorted[len(sorted)-1]).
+		Dur("perTransaction", total/time.Duration(s.sent)).
+		Msg("Batch acceptance latency")
+}
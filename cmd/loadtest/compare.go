@@ -0,0 +1,327 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	// savedRun is a load test saved with --results.
+	savedRun struct {
+		URL         string           `json:"url"`
+		Mode        string           `json:"mode"`
+		Concurrency int64            `json:"concurrency"`
+		RateLimit   float64          `json:"rateLimit"`
+		Saved       time.Time        `json:"saved"`
+		Samples     []loadTestSample `json:"samples"`
+		Blocks      []loadTestBlock  `json:"blocks"`
+	}
+
+	// comparison is a metric of two runs. Lower is better unless Higher is
+	// set. Metrics without a p-value are only described, since their
+	// distribution is tested on another metric.
+	comparison struct {
+		Metric     string   `json:"metric"`
+		A          float64  `json:"a"`
+		B          float64  `json:"b"`
+		Change     float64  `json:"change"`
+		PValue     *float64 `json:"pValue,omitempty"`
+		Regression bool     `json:"regression"`
+		Higher     bool     `json:"-"`
+	}
+)
+
+var (
+	compareAlpha     float64
+	compareThreshold float64
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare runA.json runB.json",
+	Short: "Compare the results of two load tests saved with --results and report significant regressions of the second run.",
+	Args:  cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if compareAlpha <= 0 || compareAlpha >= 1 {
+			return errors.New("the significance level must be between 0 and 1")
+		}
+		if compareThreshold < 0 {
+			return errors.New("the threshold can't be negative")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := readSavedRun(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := readSavedRun(args[1])
+		if err != nil {
+			return err
+		}
+		if a.Mode != b.Mode {
+			log.Warn().Str("a", a.Mode).Str("b", b.Mode).Msg("The runs used different modes")
+		}
+
+		comparisons := compareRuns(a, b, compareAlpha, compareThreshold)
+		var regressions int
+		for _, c := range comparisons {
+			if c.Regression {
+				regressions++
+			}
+		}
+
+		if *inputLoadTestParams.SummaryOutputMode == "json" {
+			val, err := json.MarshalIndent(comparisons, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(val))
+		} else {
+			printComparisons(args[0], args[1], comparisons)
+		}
+
+		if regressions > 0 {
+			cmd.SilenceUsage = true
+			return util.ThresholdError("%d significant regressions", regressions)
+		}
+		return nil
+	},
+}
+
+func init() {
+	LoadtestCmd.AddCommand(compareCmd)
+	compareCmd.Flags().Float64Var(&compareAlpha, "alpha", 0.05, "The significance level below which a difference isn't attributed to chance")
+	compareCmd.Flags().Float64Var(&compareThreshold, "threshold", 5, "The change in percent that a significant difference needs to be reported as a regression")
+}
+
+// writeResults saves the samples and the blocks of the load test.
+func writeResults(file string, blocks []loadTestBlock) error {
+	run := savedRun{
+		URL:         inputLoadTestParams.URL.Redacted(),
+		Mode:        *inputLoadTestParams.Mode,
+		Concurrency: *inputLoadTestParams.Concurrency,
+		RateLimit:   *inputLoadTestParams.RateLimit,
+		Saved:       time.Now().UTC(),
+		Samples:     sortedSamples(),
+		Blocks:      blocks,
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	log.Info().Str("file", file).Int("samples", len(run.Samples)).Int("blocks", len(run.Blocks)).Msg("Saved the load test results")
+	return nil
+}
+
+func readSavedRun(file string) (*savedRun, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	run := new(savedRun)
+	if err = json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", file, err)
+	}
+	if len(run.Samples) == 0 {
+		return nil, fmt.Errorf("%s has no samples", file)
+	}
+	return run, nil
+}
+
+// compareRuns compares the latency of the successful requests and the
+// throughput of the blocks with Mann-Whitney U tests, and the error rates with
+// a two-proportion z-test. A difference is a regression if it's significant at
+// alpha and worse by more than threshold percent.
+//
+// The Mann-Whitney U test compares whole distributions, which tells whether
+// the values of one run tend to be higher than the other, rather than whether
+// a single statistic changed. So each distribution is tested once, on its
+// median, which is the statistic that the test is sensitive to, and its other
+// statistics are only described.
+func compareRuns(a, b *savedRun, alpha, threshold float64) []comparison {
+	var comparisons []comparison
+	describe := func(metric string, va, vb float64, higher bool) *comparison {
+		c := comparison{Metric: metric, A: va, B: vb, Higher: higher}
+		if va != 0 {
+			c.Change = (vb - va) / va * 100
+		}
+		comparisons = append(comparisons, c)
+		return &comparisons[len(comparisons)-1]
+	}
+	test := func(metric string, va, vb, p float64, higher bool) {
+		c := describe(metric, va, vb, higher)
+		c.PValue = &p
+		worse := vb > va
+		if higher {
+			worse = vb < va
+		}
+		// A metric that was zero can't change relatively, so any significant
+		// increase counts.
+		exceeds := math.Abs(c.Change) > threshold || va == 0
+		c.Regression = worse && exceeds && p < alpha
+	}
+
+	la, lb := runLatencies(a), runLatencies(b)
+	if len(la) > 0 && len(lb) > 0 {
+		describe("Latency mean (ms)", mean(la), mean(lb), false)
+		test("Latency P50 (ms)", percentile(la, 0.5), percentile(lb, 0.5), mannWhitneyU(la, lb), false)
+		describe("Latency P90 (ms)", percentile(la, 0.9), percentile(lb, 0.9), false)
+		describe("Latency P99 (ms)", percentile(la, 0.99), percentile(lb, 0.99), false)
+	}
+
+	ta, tb := blockTPS(a.Blocks), blockTPS(b.Blocks)
+	if len(ta) > 0 && len(tb) > 0 {
+		describe("Block TPS mean", mean(ta), mean(tb), true)
+		test("Block TPS P50", percentile(ta, 0.5), percentile(tb, 0.5), mannWhitneyU(ta, tb), true)
+	}
+
+	ea, eb := runErrors(a), runErrors(b)
+	na, nb := float64(len(a.Samples)), float64(len(b.Samples))
+	test("Error rate (%)", ea/na*100, eb/nb*100, twoProportionZ(ea, na, eb, nb), false)
+	return comparisons
+}
+
+// runLatencies returns the sorted latencies of the successful requests in
+// milliseconds.
+func runLatencies(run *savedRun) []float64 {
+	var latencies []float64
+	for _, s := range run.Samples {
+		if !s.IsError {
+			latencies = append(latencies, float64(s.WaitTime.Microseconds())/1000)
+		}
+	}
+	sort.Float64s(latencies)
+	return latencies
+}
+
+func runErrors(run *savedRun) float64 {
+	var count float64
+	for _, s := range run.Samples {
+		if s.IsError {
+			count++
+		}
+	}
+	return count
+}
+
+// blockTPS returns the sorted transactions per second of every block.
+func blockTPS(blocks []loadTestBlock) []float64 {
+	var tps []float64
+	for i := 1; i < len(blocks); i++ {
+		elapsed := float64(blocks[i].Timestamp - blocks[i-1].Timestamp)
+		if elapsed > 0 {
+			tps = append(tps, float64(len(blocks[i].Transactions))/elapsed)
+		}
+	}
+	sort.Float64s(tps)
+	return tps
+}
+
+func mean(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test with
+// the normal approximation, which doesn't assume that the values are normally
+// distributed like latencies usually aren't.
+func mannWhitneyU(a, b []float64) float64 {
+	type value struct {
+		v     float64
+		fromA bool
+	}
+	values := make([]value, 0, len(a)+len(b))
+	for _, v := range a {
+		values = append(values, value{v, true})
+	}
+	for _, v := range b {
+		values = append(values, value{v, false})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].v < values[j].v })
+
+	// Tied values get the average of their ranks.
+	var rankSumA, ties float64
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].v == values[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := rankSumA - n1*(n1+1)/2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (u - n1*n2/2) / sigma
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// twoProportionZ returns the two-sided p-value of the difference between the
+// proportions x1/n1 and x2/n2.
+func twoProportionZ(x1, n1, x2, n2 float64) float64 {
+	pooled := (x1 + x2) / (n1 + n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/n1 + 1/n2))
+	if se == 0 {
+		return 1
+	}
+	z := (x1/n1 - x2/n2) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+func printComparisons(a, b string, comparisons []comparison) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.AppendHeader(table.Row{"Metric", a, b, "Change", "P-value", "Verdict"})
+	for _, c := range comparisons {
+		verdict, pValue := "-", "-"
+		if c.PValue != nil {
+			pValue = fmt.Sprintf("%.4f", *c.PValue)
+			switch {
+			case c.Regression:
+				verdict = "REGRESSION"
+			case *c.PValue < compareAlpha && (c.B > c.A) == c.Higher && c.A != c.B:
+				verdict = "improvement"
+			case *c.PValue >= compareAlpha:
+				verdict = "not significant"
+			}
+		}
+		tw.AppendRow(table.Row{
+			c.Metric,
+			fmt.Sprintf("%.2f", c.A),
+			fmt.Sprintf("%.2f", c.B),
+			fmt.Sprintf("%+.1f%%", c.Change),
+			pValue,
+			verdict,
+		})
+	}
+	tw.Render()
+}
@@ -0,0 +1,66 @@
+package loadtest
+
+import (
+	"testing"
+	"time"
+)
+
+func testRun(latencies []time.Duration) *savedRun {
+	run := new(savedRun)
+	for _, l := range latencies {
+		run.Samples = append(run.Samples, loadTestSample{WaitTime: l})
+	}
+	return run
+}
+
+func TestCompareRuns(t *testing.T) {
+	var a, slower, same []time.Duration
+	for i := 0; i < 200; i++ {
+		l := time.Duration(10+i%20) * time.Millisecond
+		a = append(a, l)
+		slower = append(slower, 2*l)
+		same = append(same, l)
+	}
+
+	tests := []struct {
+		name        string
+		b           []time.Duration
+		regressions int
+	}{
+		{
+			name:        "slower",
+			b:           slower,
+			regressions: 1,
+		},
+		{
+			name: "same",
+			b:    same,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			comparisons := compareRuns(testRun(a), testRun(tc.b), 0.05, 5)
+
+			var regressions, tested int
+			for _, c := range comparisons {
+				if c.Regression {
+					regressions++
+				}
+				if c.PValue != nil {
+					tested++
+				} else if c.Regression {
+					t.Errorf("the described metric %s can't be a regression", c.Metric)
+				}
+			}
+			// The latency distribution and the error rate are tested once
+			// each, even though every latency statistic changed.
+			if tested != 2 {
+				t.Errorf("expected 2 tested metrics, got %d", tested)
+			}
+			if regressions != tc.regressions {
+				t.Errorf("expected %d regressions, got %d", tc.regressions, regressions)
+			}
+		})
+	}
+}
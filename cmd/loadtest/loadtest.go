@@ -28,8 +28,6 @@ import (
 	"math/big"
 	"math/rand"
 	"net/url"
-	"os"
-	"os/signal"
 	"regexp"
 	"sort"
 	"strconv"
@@ -39,6 +37,8 @@ import (
 
 	"github.com/maticnetwork/polygon-cli/metrics"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/signer"
+	"github.com/maticnetwork/polygon-cli/signerpool"
 	"github.com/maticnetwork/polygon-cli/util"
 	"golang.org/x/exp/constraints"
 	"golang.org/x/text/language"
@@ -78,6 +78,20 @@ const (
 	loadTestModeERC721               = "7"
 	loadTestModePrecompiledContracts = "p"
 	loadTestModePrecompiledContract  = "a"
+	loadTestModeGasBurner            = "b"
+	loadTestModeSetCode              = "e"
+
+	// gasBurnerGasLimit is used for gas burner calls when no gas limit is
+	// given, since estimating it would only cover a single loop.
+	gasBurnerGasLimit = 1000000
+
+	// batchSendTimeout bounds the send of a batch of transactions, including
+	// the replacements of its underpriced transactions.
+	batchSendTimeout = 30 * time.Second
+
+	// finalBlockTimeout bounds the wait for the transactions to be mined once
+	// the load test is done, which polls for at most 50 times 5 seconds.
+	finalBlockTimeout = 5 * time.Minute
 
 	codeQualitySeed       = "code code code code code code code code code code code quality"
 	codeQualityPrivateKey = "42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa"
@@ -101,6 +115,8 @@ var (
 		loadTestModeERC721,
 		loadTestModePrecompiledContracts,
 		loadTestModePrecompiledContract,
+		loadTestModeGasBurner,
+		loadTestModeSetCode,
 		// r should be last to exclude it from random mode selection
 		loadTestModeRandom,
 	}
@@ -160,6 +176,9 @@ var LoadtestCmd = &cobra.Command{
 	Short: "Run a generic load test against an Eth/EVM style JSON-RPC endpoint.",
 	Long:  usage,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flags are valid once the load test runs, so its errors, like a
+		// partial failure, aren't followed by the usage.
+		cmd.SilenceUsage = true
 		err := runLoadTest(cmd.Context())
 		if err != nil {
 			return err
@@ -170,6 +189,12 @@ var LoadtestCmd = &cobra.Command{
 		zerolog.DurationFieldUnit = time.Second
 		zerolog.DurationFieldInteger = true
 
+		if *inputLoadTestParams.Interactive {
+			var err error
+			if args, err = util.RunWizard(cmd, args, loadTestWizardSteps()); err != nil {
+				return err
+			}
+		}
 		if len(args) != 1 {
 			return fmt.Errorf("expected exactly one argument")
 		}
@@ -182,13 +207,53 @@ var LoadtestCmd = &cobra.Command{
 			return fmt.Errorf("the scheme %s is not supported", url.Scheme)
 		}
 		inputLoadTestParams.URL = url
+		if !signer.IsRemote(*inputLoadTestParams.PrivateKey) {
+			if *inputLoadTestParams.PrivateKey, err = util.ResolveSecret(*inputLoadTestParams.PrivateKey); err != nil {
+				return err
+			}
+		}
+		if *inputLoadTestParams.ProfilePath != "" {
+			if cmd.Flags().Changed("mode") {
+				return fmt.Errorf("the mode can't be set when using a profile")
+			}
+			if *inputLoadTestParams.IsAvail {
+				return fmt.Errorf("profiles aren't supported for avail")
+			}
+			profile, err := readLoadTestProfile(*inputLoadTestParams.ProfilePath)
+			if err != nil {
+				return err
+			}
+			inputLoadTestParams.Profile = profile
+			*inputLoadTestParams.Mode = profile.modes()
+		}
 		r := regexp.MustCompile(fmt.Sprintf("^[%s]+$", strings.Join(validLoadTestModes, "")))
 		if !r.MatchString(*inputLoadTestParams.Mode) {
 			return fmt.Errorf("the mode %s is not recognized", *inputLoadTestParams.Mode)
 		}
+		if _, ok := contracts.GasBurnerOpcodes[*inputLoadTestParams.BurnOpcode]; !ok {
+			return fmt.Errorf("the burn opcode %s is not recognized, expected one of %s", *inputLoadTestParams.BurnOpcode, strings.Join(contracts.GasBurnerOpcodeNames(), ", "))
+		}
+		if *inputLoadTestParams.LegacyTransactionMode && strings.Contains(*inputLoadTestParams.Mode, loadTestModeSetCode) {
+			return fmt.Errorf("set code transactions can't be sent in legacy mode")
+		}
 		if *inputLoadTestParams.AdaptiveBackoffFactor <= 0.0 {
 			return fmt.Errorf("the backoff factor needs to be non-zero positive")
 		}
+		if *inputLoadTestParams.SendBatchSize == 0 {
+			return fmt.Errorf("the send batch size needs to be at least one")
+		}
+		if *inputLoadTestParams.SendBatchSize > 1 && *inputLoadTestParams.IsAvail {
+			return fmt.Errorf("transactions can't be sent in batches to avail")
+		}
+		if *inputLoadTestParams.ReplaceFeeBump > 0 && *inputLoadTestParams.ReplaceFeeBump < signerpool.MinBumpPercent {
+			return fmt.Errorf("the replacement fee bump needs to be at least %d percent", signerpool.MinBumpPercent)
+		}
+		if *inputLoadTestParams.MaxPending > 0 && *inputLoadTestParams.BackpressureInterval == 0 {
+			return fmt.Errorf("the backpressure interval needs to be at least one second")
+		}
+		if *inputLoadTestParams.MaxFailureRate < 0 || *inputLoadTestParams.MaxFailureRate > 100 {
+			return fmt.Errorf("the max failure rate needs to be a percent between 0 and 100")
+		}
 		return nil
 	},
 }
@@ -229,32 +294,47 @@ type (
 		AdaptiveCycleDuration               *uint64
 		AdaptiveBackoffFactor               *float64
 		Mode                                *string
+		ProfilePath                         *string
 		Function                            *uint64
 		Iterations                          *uint64
+		BurnOpcode                          *string
 		ByteCount                           *uint64
 		Seed                                *int64
 		IsAvail                             *bool
 		LtAddress                           *string
 		DelAddress                          *string
+		GasBurnerAddress                    *string
 		ContractCallNumberOfBlocksToWaitFor *uint64
 		ContractCallBlockInterval           *uint64
 		ForceContractDeploy                 *bool
+		BaselineDuration                    *uint64
+		Prewarm                             *uint64
+		SendBatchSize                       *uint64
+		ReplaceFeeBump                      *uint64
+		MaxPending                          *uint64
+		BackpressureInterval                *uint64
+		MaxFailureRate                      *float64
+		Report                              *string
+		Results                             *string
 		ForceGasLimit                       *uint64
 		ForceGasPrice                       *uint64
 		ForcePriorityGasPrice               *uint64
 		ShouldProduceSummary                *bool
 		SummaryOutputMode                   *string
 		LegacyTransactionMode               *bool
+		Interactive                         *bool
 
 		// Computed
 		CurrentGas       *big.Int
 		CurrentGasTipCap *big.Int
 		CurrentNonce     *uint64
 		ECDSAPrivateKey  *ecdsa.PrivateKey
+		Signer           signer.Signer
 		FromETHAddress   *ethcommon.Address
 		ToETHAddress     *ethcommon.Address
 		SendAmount       *big.Int
 		BaseFee          *big.Int
+		Profile          *loadTestProfile
 
 		ToAvailAddress   *gstypes.MultiAddress
 		FromAvailAddress *gssignature.KeyringPair
@@ -276,7 +356,7 @@ func init() {
 	// https://logging.apache.org/log4j/2.x/manual/customloglevels.html
 
 	// extended parameters
-	ltp.PrivateKey = LoadtestCmd.PersistentFlags().String("private-key", codeQualityPrivateKey, "The hex encoded private key that we'll use to sending transactions")
+	ltp.PrivateKey = LoadtestCmd.PersistentFlags().String("private-key", codeQualityPrivateKey, "The hex encoded private key that we'll use to sending transactions. "+util.SecretHelp+". "+signer.Help)
 	ltp.ChainID = LoadtestCmd.PersistentFlags().Uint64("chain-id", 0, "The chain id for the transactions that we're going to send")
 	ltp.ToAddress = LoadtestCmd.PersistentFlags().String("to-address", "0xDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF", "The address that we're going to send to")
 	ltp.ToRandom = LoadtestCmd.PersistentFlags().Bool("to-random", false, "When doing a transfer test, should we send to random addresses rather than DEADBEEFx5")
@@ -296,33 +376,65 @@ p - call random precompiled contracts
 a - call a specific precompiled contract address
 s - store mode
 l - long running mode
+b - burn gas with a single opcode
+e - delegate new accounts with EIP-7702 set code transactions
 r - random modes
 2 - ERC20 Transfers
 7 - ERC721 Mints`)
+	ltp.ProfilePath = LoadtestCmd.PersistentFlags().String("profile", "", "A YAML file with a weighted mix of modes to run instead of --mode")
 	ltp.Function = LoadtestCmd.PersistentFlags().Uint64P("function", "f", 1, "A specific function to be called if running with `--mode f` or a specific precompiled contract when running with `--mode a`")
 	ltp.Iterations = LoadtestCmd.PersistentFlags().Uint64P("iterations", "i", 100, "If we're making contract calls, this controls how many times the contract will execute the instruction in a loop. If we are making ERC721 Mints, this indicated the minting batch size")
+	ltp.BurnOpcode = LoadtestCmd.PersistentFlags().String("burn-opcode", "sstore", "The opcode the gas burner loops if running with `--mode b`: "+strings.Join(contracts.GasBurnerOpcodeNames(), ", "))
 	ltp.ByteCount = LoadtestCmd.PersistentFlags().Uint64P("byte-count", "b", 1024, "If we're in store mode, this controls how many bytes we'll try to store in our contract")
 	ltp.Seed = LoadtestCmd.PersistentFlags().Int64("seed", 123456, "A seed for generating random values and addresses")
 	ltp.IsAvail = LoadtestCmd.PersistentFlags().Bool("data-avail", false, "Is this a test of avail rather than an EVM / Geth Chain")
 	ltp.LtAddress = LoadtestCmd.PersistentFlags().String("lt-address", "", "A pre-deployed load test contract address")
 	ltp.DelAddress = LoadtestCmd.PersistentFlags().String("del-address", "", "A pre-deployed delegator contract address")
+	ltp.GasBurnerAddress = LoadtestCmd.PersistentFlags().String("gas-burner-address", "", "A pre-deployed gas burner contract address")
 	ltp.ContractCallNumberOfBlocksToWaitFor = LoadtestCmd.PersistentFlags().Uint64("contract-call-nb-blocks-to-wait-for", 30, "The number of blocks to wait for before giving up on a contract call")
 	ltp.ContractCallBlockInterval = LoadtestCmd.PersistentFlags().Uint64("contract-call-block-interval", 1, "The number of blocks to wait between contract calls")
 	ltp.ForceContractDeploy = LoadtestCmd.PersistentFlags().Bool("force-contract-deploy", false, "Some loadtest modes don't require a contract deployment. Set this flag to true to force contract deployments. This will still respect the --del-address and --il-address flags.")
+	ltp.BaselineDuration = LoadtestCmd.PersistentFlags().Uint64("baseline-duration-seconds", 0, "Measure the block time, gas price, and inclusion latency for this many seconds before and after the load to compare them with the conditions during the load. 0 disables the baseline")
+	ltp.Prewarm = LoadtestCmd.PersistentFlags().Uint64("prewarm", 0, "Send this many throwaway requests of every mode before the measurement starts, so the accounts and storage slots the load test uses are warm. 0 disables pre-warming")
+	ltp.MaxPending = LoadtestCmd.PersistentFlags().Uint64("max-pending", 0, "Pause sending requests while more than this many of our transactions are pending in the pool. 0 disables the backpressure")
+	ltp.BackpressureInterval = LoadtestCmd.PersistentFlags().Uint64("backpressure-interval-seconds", 1, "How often in seconds to check the number of pending transactions when --max-pending is set")
+	ltp.MaxFailureRate = LoadtestCmd.PersistentFlags().Float64("max-failure-rate", 100, "Exit with the partial failure exit code if more than this percent of the requests failed. 0 exits with it on any failed request, and 100 never does")
 	ltp.ForceGasLimit = LoadtestCmd.PersistentFlags().Uint64("gas-limit", 0, "In environments where the gas limit can't be computed on the fly, we can specify it manually")
 	ltp.ForceGasPrice = LoadtestCmd.PersistentFlags().Uint64("gas-price", 0, "In environments where the gas price can't be estimated, we can specify it manually")
 	ltp.ForcePriorityGasPrice = LoadtestCmd.PersistentFlags().Uint64("priority-gas-price", 0, "Specify Gas Tip Price in the case of EIP-1559")
 	ltp.ShouldProduceSummary = LoadtestCmd.PersistentFlags().Bool("summarize", false, "Should we produce an execution summary after the load test has finished. If you're running a large loadtest, this can take a long time")
 	ltp.BatchSize = LoadtestCmd.PersistentFlags().Uint64("batch-size", 999, "Number of batches to perform at a time for receipt fetching. Default is 999 requests at a time.")
+	ltp.SendBatchSize = LoadtestCmd.PersistentFlags().Uint64("send-batch-size", 1, "Number of signed transactions of a routine to send together in a single JSON-RPC batch of eth_sendRawTransaction calls. 1 sends every transaction on its own")
+	ltp.ReplaceFeeBump = LoadtestCmd.PersistentFlags().Uint64("replace-fee-bump", 0, "When a transaction is rejected as an underpriced replacement of a pending transaction with the same nonce, sign it again with the fees raised by this percent and send it again, up to 3 times. Has to be at least 10. 0 disables the replacements")
 	ltp.SummaryOutputMode = LoadtestCmd.PersistentFlags().String("output-mode", "text", "Format mode for summary output (json | text)")
+	ltp.Report = LoadtestCmd.PersistentFlags().String("report", "", "Write a self-contained HTML report with charts of the load test to this file")
+	ltp.Results = LoadtestCmd.PersistentFlags().String("results", "", "Save the samples and the blocks of the load test to this JSON file to compare runs with `polycli loadtest compare`")
 	ltp.LegacyTransactionMode = LoadtestCmd.PersistentFlags().Bool("legacy", false, "Send a legacy transaction instead of an EIP1559 transaction.")
+	ltp.Interactive = LoadtestCmd.Flags().Bool("interactive", false, util.InteractiveHelp)
 	inputLoadTestParams = *ltp
 
+	_ = LoadtestCmd.RegisterFlagCompletionFunc("mode", completeLoadTestModes)
+	_ = LoadtestCmd.RegisterFlagCompletionFunc("burn-opcode", cobra.FixedCompletions(contracts.GasBurnerOpcodeNames(), cobra.ShellCompDirectiveNoFileComp))
+
 	// TODO batch size
 	// TODO Compression
 	// TODO array of RPC endpoints to round robin?
 }
 
+// completeLoadTestModes completes the modes that can be added to the modes
+// typed so far, with their descriptions from the usage of --mode.
+func completeLoadTestModes(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, line := range strings.Split(cmd.Flag("mode").Usage, "\n") {
+		mode, description, ok := strings.Cut(line, " - ")
+		if !ok || strings.Contains(toComplete, mode) {
+			continue
+		}
+		completions = append(completions, toComplete+mode+"\t"+description)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
 func initializeLoadTestParams(ctx context.Context, c *ethclient.Client) error {
 	log.Info().Msg("Connecting with RPC endpoint to initialize load test parameters")
 	gas, err := c.SuggestGasPrice(ctx)
@@ -342,9 +454,17 @@ func initializeLoadTestParams(ctx context.Context, c *ethclient.Client) error {
 		inputLoadTestParams.CurrentGasTipCap = gasTipCap
 	}
 
-	privateKey, err := ethcrypto.HexToECDSA(*inputLoadTestParams.PrivateKey)
+	txSigner, err := signer.New(ctx, *inputLoadTestParams.PrivateKey)
 	if err != nil {
-		log.Error().Err(err).Msg("Couldn't process the hex private key")
+		log.Error().Err(err).Msg("Couldn't load the signer")
+		return err
+	}
+	// The private key is also used to sign the input of the ecrecover
+	// precompile, which doesn't need the key of the account.
+	var privateKey *ecdsa.PrivateKey
+	if local, ok := txSigner.(*signer.Local); ok {
+		privateKey = local.PrivateKey()
+	} else if privateKey, err = ethcrypto.GenerateKey(); err != nil {
 		return err
 	}
 
@@ -356,7 +476,7 @@ func initializeLoadTestParams(ctx context.Context, c *ethclient.Client) error {
 	}
 	log.Trace().Uint64("blocknumber", blockNumber).Msg("Current Block Number")
 
-	ethAddress := ethcrypto.PubkeyToAddress(privateKey.PublicKey)
+	ethAddress := txSigner.Address()
 
 	nonce, err := c.NonceAt(ctx, ethAddress, bigBlockNumber)
 	if err != nil {
@@ -404,6 +524,7 @@ func initializeLoadTestParams(ctx context.Context, c *ethclient.Client) error {
 	inputLoadTestParams.CurrentGas = gas
 	inputLoadTestParams.CurrentNonce = &nonce
 	inputLoadTestParams.ECDSAPrivateKey = privateKey
+	inputLoadTestParams.Signer = txSigner
 	inputLoadTestParams.FromETHAddress = &ethAddress
 	if *inputLoadTestParams.ChainID == 0 {
 		*inputLoadTestParams.ChainID = chainID.Uint64()
@@ -448,7 +569,7 @@ func runLoadTest(ctx context.Context) error {
 		overallTimer = new(time.Timer)
 	}
 
-	rpc, err := ethrpc.DialContext(ctx, inputLoadTestParams.URL.String())
+	rpc, err := util.DialRPC(ctx, inputLoadTestParams.URL.String())
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to dial rpc")
 		return err
@@ -456,10 +577,10 @@ func runLoadTest(ctx context.Context) error {
 	rpc.SetHeader("Accept-Encoding", "identity")
 	ec := ethclient.NewClient(rpc)
 
-	var loopFunc func() error
+	var loopFunc func(ctx context.Context) error
 	if *inputLoadTestParams.IsAvail {
 		log.Info().Msg("Running in Avail mode")
-		loopFunc = func() error {
+		loopFunc = func(ctx context.Context) error {
 			var api *gsrpc.SubstrateAPI
 			api, err = gsrpc.NewSubstrateAPI(inputLoadTestParams.URL.String())
 			if err != nil {
@@ -473,7 +594,7 @@ func runLoadTest(ctx context.Context) error {
 		}
 
 	} else {
-		loopFunc = func() error {
+		loopFunc = func(ctx context.Context) error {
 			err = initializeLoadTestParams(ctx, ec)
 			if err != nil {
 				return err
@@ -483,41 +604,69 @@ func runLoadTest(ctx context.Context) error {
 		}
 	}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt)
+	// The loop context is cancelled when the time limit is reached or the
+	// command is interrupted. The loop then stops sending new requests and
+	// waits for the pending transactions before summarizing the results.
+	loopCtx, cancelLoop := context.WithCancel(ctx)
+	defer cancelLoop()
 
 	loadTestResults = make([]loadTestSample, 0)
 	errCh := make(chan error)
 	go func() {
-		errCh <- loopFunc()
+		errCh <- loopFunc(loopCtx)
 	}()
 
 	select {
 	case <-overallTimer.C:
 		log.Info().Msg("Time's up")
-	case <-sigCh:
-		log.Info().Msg("Interrupted.. Stopping load test")
+		cancelLoop()
+		err = <-errCh
+	case <-ctx.Done():
+		log.Info().Msg("Interrupted.. Stopping load test, interrupt again to exit immediately")
+		cancelLoop()
+		err = <-errCh
 	case err = <-errCh:
-		if err != nil {
-			log.Fatal().Err(err).Msg("Received critical error while running load test")
-		}
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Error().Err(err).Msg("Received critical error while running load test")
+		return err
 	}
 
 	printResults(loadTestResults)
+	failed := countFailedRequests(loadTestResults, *inputLoadTestParams.MaxFailureRate)
 	if *inputLoadTestParams.IsAvail {
 		log.Trace().Msg("Finished testing avail")
-		return nil
+		return failed
 	}
 
+	printConnStats(util.RPCTransportStats())
+
 	// TODO this doesn't make sense for avail
-	ptc, err := ec.PendingTransactionCount(ctx)
+	// The command context might be cancelled at this point.
+	ptc, err := ec.PendingTransactionCount(context.Background())
 	if err != nil {
 		log.Debug().Err(err).Msg("Unable to get the number of pending transactions before closing")
 	} else if ptc > 0 {
 		log.Info().Uint("pending", ptc).Msg("There are still outstanding transactions. There might be issues restarting with the same sending key until those transactions clear")
 	}
 	log.Info().Msg("Finished")
-	return nil
+	return failed
+}
+
+// countFailedRequests returns a partial failure error if more than maxRate
+// percent of the requests of the load test failed, so that scripts can tell a
+// load test with too many errors apart from one that couldn't run.
+func countFailedRequests(lts []loadTestSample, maxRate float64) error {
+	var failed int
+	for _, s := range lts {
+		if s.IsError {
+			failed++
+		}
+	}
+	if failed == 0 || float64(failed)*100 <= maxRate*float64(len(lts)) {
+		return nil
+	}
+	return util.PartialError("%d of the %d requests failed, more than %g%%", failed, len(lts), maxRate)
 }
 
 func printResults(lts []loadTestSample) {
@@ -549,6 +698,24 @@ func printResults(lts []loadTestSample) {
 	log.Info().Uint64("numErrors", numErrors).Msg("Num errors")
 }
 
+// printConnStats logs how the HTTP requests of the load test were spread over
+// connections. Many new connections or TLS handshakes mean that the client,
+// rather than the node, limited the load test, which the --rpc-* flags tune.
+func printConnStats(stats util.RPCConnStats) {
+	if stats.Requests == 0 {
+		return
+	}
+	log.Info().
+		Uint64("requests", stats.Requests).
+		Uint64("failed", stats.Failed).
+		Uint64("http2Requests", stats.HTTP2Requests).
+		Uint64("newConns", stats.NewConns).
+		Uint64("reusedConns", stats.ReusedConns).
+		Uint64("tlsHandshakes", stats.TLSHandshakes).
+		Uint64("tlsResumed", stats.TLSResumed).
+		Msg("Connection stats")
+}
+
 func convHexToUint64(hexString string) (uint64, error) {
 	hexString = strings.TrimPrefix(hexString, "0x")
 	if len(hexString)%2 != 0 {
@@ -628,7 +795,6 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 	requests := *ltp.Requests
 	currentNonce := *ltp.CurrentNonce
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 	mode := *ltp.Mode
 	steadyStateTxPoolSize := *ltp.SteadyStateTxPoolSize
 	adaptiveRateLimitIncrement := *ltp.AdaptiveRateLimitIncrement
@@ -642,8 +808,12 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 	if *ltp.AdaptiveRateLimit && rl != nil {
 		go updateRateLimit(rateLimitCtx, rl, rpc, steadyStateTxPoolSize, adaptiveRateLimitIncrement, time.Duration(*ltp.AdaptiveCycleDuration)*time.Second, *ltp.AdaptiveBackoffFactor)
 	}
+	var bp *backpressure
+	if *ltp.MaxPending > 0 {
+		bp = newBackpressure(*ltp.MaxPending)
+	}
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	tops = configureTransactOpts(tops)
 	tops.GasLimit = 10000000
 
@@ -658,7 +828,7 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 	var ltContract *contracts.LoadTester
 	numberOfBlocksToWaitFor := *inputLoadTestParams.ContractCallNumberOfBlocksToWaitFor
 	blockInterval := *inputLoadTestParams.ContractCallBlockInterval
-	if strings.ContainsAny(mode, "rcfislpas") || *inputLoadTestParams.ForceContractDeploy {
+	if strings.ContainsAny(mode, "rcfislpase") || *inputLoadTestParams.ForceContractDeploy {
 		if *inputLoadTestParams.LtAddress == "" {
 			ltAddr, _, _, err = contracts.DeployLoadTester(tops, c)
 			if err != nil {
@@ -689,7 +859,7 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 
 	var erc20Addr ethcommon.Address
 	var erc20Contract *contracts.ERC20
-	if mode == loadTestModeERC20 || mode == loadTestModeRandom {
+	if strings.ContainsAny(mode, "2r") {
 		erc20Addr, _, _, err = contracts.DeployERC20(tops, c)
 		if err != nil {
 			log.Error().Err(err).Msg("Unable to deploy ERC20 contract")
@@ -739,7 +909,7 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 
 	var erc721Addr ethcommon.Address
 	var erc721Contract *contracts.ERC721
-	if mode == loadTestModeERC721 || mode == loadTestModeRandom {
+	if strings.ContainsAny(mode, "7r") {
 		erc721Addr, _, _, err = contracts.DeployERC721(tops, c)
 		if err != nil {
 			log.Error().Err(err).Msg("Unable to deploy ERC721 contract")
@@ -806,15 +976,132 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 		currentNonce = currentNonce + 1
 	}
 
-	var currentNonceMutex sync.Mutex
+	// deploy and instantiate the gas burner contract
+	var gasBurnerContract *bind.BoundContract
+	if strings.ContainsAny(mode, "rb") || *inputLoadTestParams.ForceContractDeploy {
+		var gasBurnerAddr ethcommon.Address
+		if *inputLoadTestParams.GasBurnerAddress == "" {
+			tops.Nonce = new(big.Int).SetUint64(currentNonce)
+			gasBurnerAddr, _, _, err = contracts.DeployGasBurner(tops, c)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to create the gas burner contract. Do you have the right chain id? Do you have enough funds?")
+				return err
+			}
+			currentNonce = currentNonce + 1
+		} else {
+			gasBurnerAddr = ethcommon.HexToAddress(*inputLoadTestParams.GasBurnerAddress)
+		}
+		log.Trace().Interface("contractaddress", gasBurnerAddr).Msg("Gas burner contract address")
+
+		gasBurnerContract = contracts.NewGasBurner(gasBurnerAddr, c)
+		err = blockUntilSuccessful(ctx, c, func() error {
+			code, err := c.CodeAt(ctx, gasBurnerAddr, nil)
+			if err == nil && len(code) == 0 {
+				err = fmt.Errorf("the gas burner contract isn't deployed yet")
+			}
+			return err
+		}, numberOfBlocksToWaitFor, blockInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	// send sends a request of the mode with the nonce through the sender.
+	send := func(ctx context.Context, s *txSender, localMode string, nonce uint64) (time.Time, time.Time, error) {
+		switch localMode {
+		case loadTestModeTransaction:
+			return loadtestTransaction(ctx, s, nonce)
+		case loadTestModeDeploy:
+			return loadtestDeploy(ctx, s, nonce)
+		case loadTestModeCall:
+			return loadtestCall(ctx, s, nonce, ltContract)
+		case loadTestModeFunction:
+			return loadtestFunction(ctx, s, nonce, ltContract)
+		case loadTestModeInc:
+			return loadtestInc(ctx, s, nonce, ltContract)
+		case loadTestModeStore:
+			return loadtestStore(ctx, s, nonce, ltContract)
+		case loadTestModeLong:
+			return loadtestLong(ctx, s, nonce, delegatorContract, ltAddr)
+		case loadTestModeERC20:
+			return loadtestERC20(ctx, s, nonce, erc20Contract, ltAddr)
+		case loadTestModeERC721:
+			return loadtestERC721(ctx, s, nonce, erc721Contract, ltAddr)
+		case loadTestModePrecompiledContract:
+			return loadtestCallPrecompiledContracts(ctx, s, nonce, ltContract, true)
+		case loadTestModePrecompiledContracts:
+			return loadtestCallPrecompiledContracts(ctx, s, nonce, ltContract, false)
+		case loadTestModeGasBurner:
+			return loadtestGasBurner(ctx, s, nonce, gasBurnerContract)
+		case loadTestModeSetCode:
+			return loadtestSetCode(ctx, rpc, s, nonce, ltAddr)
+		default:
+			log.Error().Str("mode", mode).Msg("We've arrived at a load test mode that we don't recognize")
+			return time.Time{}, time.Time{}, nil
+		}
+	}
+
+	// The nonces are handed out by the account from here on, which reuses the
+	// nonces of the requests that couldn't be sent.
+	account := signerpool.NewAccount(ltp.Signer, currentNonce)
+	// The requests of every routine are sent with a sender of their own, and
+	// the others with this one.
+	sendOpts := signerpool.SendOptions{ChainID: chainID, FeeBump: *ltp.ReplaceFeeBump}
+	sender := &txSender{client: c, account: account, opts: sendOpts}
+
+	if *ltp.Prewarm > 0 {
+		if err = prewarm(ctx, sender, mode, send); err != nil {
+			return err
+		}
+	}
+
+	var phases []phaseStats
+	baselineDuration := time.Duration(*ltp.BaselineDuration) * time.Second
+	if baselineDuration > 0 {
+		var before phaseStats
+		before, err = runBaselinePhase(ctx, sender, phaseBefore, baselineDuration)
+		if err != nil {
+			return err
+		}
+		phases = append(phases, before)
+		if err = account.Sync(ctx, c); err != nil {
+			return err
+		}
+	}
+
 	var i int64
 	startBlockNumber, err := c.BlockNumber(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get current block number")
 		return err
 	}
-	startNonce := currentNonce
-	log.Debug().Uint64("currentNonce", currentNonce).Msg("Starting main loadtest loop")
+	startNonce := account.Next()
+	if ltp.Profile != nil {
+		log.Info().Str("profile", ltp.Profile.String()).Msg("Running load test profile")
+	}
+	log.Debug().Uint64("currentNonce", startNonce).Msg("Starting main loadtest loop")
+	samplerCtx, stopSampler := context.WithCancel(ctx)
+	var duringGasPrice gasPriceSampler
+	if baselineDuration > 0 {
+		go duringGasPrice.run(samplerCtx, c)
+	}
+	if bp != nil {
+		go bp.run(rateLimitCtx, c, time.Duration(*ltp.BackpressureInterval)*time.Second)
+	}
+	progress := util.NewProgress("loadtest", "requests", uint64(routines*requests))
+	// failed heals the nonces after a request with the nonce couldn't be sent.
+	failed := func(nonce uint64, err error) {
+		log.Error().Err(err).Uint64("nonce", nonce).Msg("Recorded an error while sending transactions")
+		// A nonce that's already used can't be sent again, so the account
+		// catches up with the node instead.
+		if !signerpool.NonceUsed(err) {
+			account.Release(nonce)
+		} else if err = account.Sync(ctx, c); err != nil {
+			log.Error().Err(err).Msg("Unable to sync the nonce")
+		}
+	}
+	sendBatchSize := int(*ltp.SendBatchSize)
+	var batches batchStats
 	var wg sync.WaitGroup
 	for i = 0; i < routines; i = i + 1 {
 		log.Trace().Int64("routine", i).Msg("Starting Thread")
@@ -823,83 +1110,162 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 			var j int64
 			var startReq time.Time
 			var endReq time.Time
-			var retryForNonce bool = false
 			var myNonceValue uint64
+			profileRand := rand.New(rand.NewSource(*ltp.Seed + i))
+
+			// The requests of the routine are queued in the batch, and sent
+			// once it's full or the routine stops.
+			s := &txSender{client: c, account: account, opts: sendOpts}
+			var queued []queuedRequest
+			if sendBatchSize > 1 {
+				s.opts.Batch = new(signerpool.Batch)
+			}
+			flush := func() {
+				if len(queued) == 0 {
+					return
+				}
+				// The last batch is still sent when the load test is
+				// stopped, since its nonces are already reserved, so it
+				// gets a context of its own once the routine's is done.
+				parent := ctx
+				if ctx.Err() != nil {
+					parent = context.Background()
+				}
+				batchCtx, cancelBatch := context.WithTimeout(parent, batchSendTimeout)
+				defer cancelBatch()
+				start := time.Now()
+				raw, errs, batchErr := s.opts.Batch.Send(batchCtx, rpc)
+				end := time.Now()
+				if batchErr != nil {
+					log.Error().Err(batchErr).Int("size", len(queued)).Msg("Unable to send the batch")
+				}
+				batches.add(end.Sub(start), errs)
+				for k, q := range queued {
+					errs[k] = account.ReplaceRaw(batchCtx, c, raw[k], errs[k], s.opts)
+					recordSample(i, q.request, errs[k], start, end, q.nonce)
+					if errs[k] != nil {
+						failed(q.nonce, errs[k])
+					}
+				}
+				progress.Add(uint64(len(queued)))
+				queued = queued[:0]
+			}
 
 			for j = 0; j < requests; j = j + 1 {
+				if ctx.Err() != nil {
+					log.Trace().Int64("routine", i).Msg("Stopping routine")
+					break
+				}
 				if rl != nil {
 					err = rl.Wait(ctx)
+					if ctx.Err() != nil {
+						break
+					}
 					if err != nil {
 						log.Error().Err(err).Msg("Encountered a rate limiting error")
 					}
 				}
-
-				if retryForNonce {
-					retryForNonce = false
-				} else {
-					currentNonceMutex.Lock()
-					myNonceValue = currentNonce
-					currentNonce = currentNonce + 1
-					currentNonceMutex.Unlock()
+				if bp != nil {
+					if bp.wait(ctx) != nil {
+						break
+					}
 				}
 
+				myNonceValue = account.Reserve()
+
 				localMode := mode
 				// if there are multiple modes, iterate through them, 'r' mode is supported here
-				if len(mode) > 1 {
+				if ltp.Profile != nil {
+					localMode = ltp.Profile.pick(profileRand)
+				} else if len(mode) > 1 {
 					localMode = string(mode[int(i+j)%(len(mode))])
 				}
 				// if we're doing random, we'll just pick one based on the current index
 				if localMode == loadTestModeRandom {
 					localMode = validLoadTestModes[int(i+j)%(len(validLoadTestModes)-1)]
 				}
-				switch localMode {
-				case loadTestModeTransaction:
-					startReq, endReq, err = loadtestTransaction(ctx, c, myNonceValue)
-				case loadTestModeDeploy:
-					startReq, endReq, err = loadtestDeploy(ctx, c, myNonceValue)
-				case loadTestModeCall:
-					startReq, endReq, err = loadtestCall(ctx, c, myNonceValue, ltContract)
-				case loadTestModeFunction:
-					startReq, endReq, err = loadtestFunction(ctx, c, myNonceValue, ltContract)
-				case loadTestModeInc:
-					startReq, endReq, err = loadtestInc(ctx, c, myNonceValue, ltContract)
-				case loadTestModeStore:
-					startReq, endReq, err = loadtestStore(ctx, c, myNonceValue, ltContract)
-				case loadTestModeLong:
-					startReq, endReq, err = loadtestLong(ctx, c, myNonceValue, delegatorContract, ltAddr)
-				case loadTestModeERC20:
-					startReq, endReq, err = loadtestERC20(ctx, c, myNonceValue, erc20Contract, ltAddr)
-				case loadTestModeERC721:
-					startReq, endReq, err = loadtestERC721(ctx, c, myNonceValue, erc721Contract, ltAddr)
-				case loadTestModePrecompiledContract:
-					startReq, endReq, err = loadtestCallPrecompiledContracts(ctx, c, myNonceValue, ltContract, true)
-				case loadTestModePrecompiledContracts:
-					startReq, endReq, err = loadtestCallPrecompiledContracts(ctx, c, myNonceValue, ltContract, false)
-				default:
-					log.Error().Str("mode", mode).Msg("We've arrived at a load test mode that we don't recognize")
+				pending := 0
+				if s.opts.Batch != nil {
+					pending = s.opts.Batch.Len()
 				}
-				recordSample(i, j, err, startReq, endReq, myNonceValue)
-				if err != nil {
-					log.Error().Err(err).Uint64("nonce", myNonceValue).Msg("Recorded an error while sending transactions")
-					retryForNonce = true
+				startReq, endReq, err = send(ctx, s, localMode, myNonceValue)
+				if err == nil && s.opts.Batch != nil && s.opts.Batch.Len() > pending {
+					queued = append(queued, queuedRequest{request: j, nonce: myNonceValue})
+					if len(queued) >= sendBatchSize {
+						flush()
+					}
+				} else {
+					recordSample(i, j, err, startReq, endReq, myNonceValue)
+					progress.Add(1)
+					if err != nil {
+						failed(myNonceValue, err)
+					}
 				}
 
 				log.Trace().Uint64("nonce", myNonceValue).Int64("routine", i).Str("mode", localMode).Int64("request", j).Msg("Request")
 			}
+			flush()
 			wg.Done()
 		}(i)
 	}
 	log.Trace().Msg("Finished starting go routines. Waiting..")
 	wg.Wait()
+	progress.Done()
 	cancel()
+	stopSampler()
+	if bp != nil {
+		bp.summary()
+	}
+	if sendBatchSize > 1 {
+		batches.summary()
+	}
+	currentNonce = account.Next()
 	log.Debug().Uint64("currentNonce", currentNonce).Msg("Finished main loadtest loop")
+
+	// The loop context is cancelled if the load test was stopped early, but the
+	// transactions that were already sent should still be summarized.
+	ctx = context.Background()
 	log.Debug().Msg("Waiting for transactions to actually be mined")
-	finalBlockNumber, err := waitForFinalBlock(ctx, c, rpc, startBlockNumber, startNonce, currentNonce)
+	waitCtx, cancelWait := context.WithTimeout(ctx, finalBlockTimeout)
+	finalBlockNumber, err := waitForFinalBlock(waitCtx, c, rpc, startBlockNumber, startNonce, currentNonce)
+	cancelWait()
 	if err != nil {
 		log.Error().Err(err).Msg("there was an issue waiting for all transactions to be mined")
 	}
 
 	lightSummary(ctx, c, rpc, startBlockNumber, startNonce, finalBlockNumber, currentNonce, rl)
+	if (*ltp.Report != "" || *ltp.Results != "") && finalBlockNumber > 0 {
+		blocks, err := getLoadTestBlocks(ctx, rpc, startBlockNumber, finalBlockNumber)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to get the blocks of the load test")
+		} else {
+			if *ltp.Report != "" {
+				if err = writeReport(*ltp.Report, blocks); err != nil {
+					log.Error().Err(err).Msg("Unable to write the load test report")
+				}
+			}
+			if *ltp.Results != "" {
+				if err = writeResults(*ltp.Results, blocks); err != nil {
+					log.Error().Err(err).Msg("Unable to save the load test results")
+				}
+			}
+		}
+	}
+	if baselineDuration > 0 && finalBlockNumber > 0 {
+		during, err := measurePhase(ctx, c, phaseDuring, startBlockNumber, finalBlockNumber, duringGasPrice.mean(), sentDuringLoad())
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to measure the network during the load")
+		} else {
+			phases = append(phases, during)
+		}
+		after, err := runBaselinePhase(ctx, sender, phaseAfter, baselineDuration)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to measure the network after the load")
+		} else {
+			phases = append(phases, after)
+		}
+		printPhaseComparison(phases)
+	}
 	if *ltp.ShouldProduceSummary {
 		err = summarizeTransactions(ctx, c, rpc, startBlockNumber, startNonce, finalBlockNumber, currentNonce)
 		if err != nil {
@@ -910,18 +1276,18 @@ func mainLoop(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client) erro
 }
 
 func lightSummary(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Client, startBlockNumber, startNonce, endBlockNumber, endNonce uint64, rl *rate.Limiter) {
-	startBlock, err := c.BlockByNumber(ctx, new(big.Int).SetUint64(startBlockNumber))
+	startBlock, err := c.HeaderByNumber(ctx, new(big.Int).SetUint64(startBlockNumber))
 	if err != nil {
 		log.Error().Err(err).Msg("unable to get start block for light summary")
 		return
 	}
-	endBlock, err := c.BlockByNumber(ctx, new(big.Int).SetUint64(endBlockNumber))
+	endBlock, err := c.HeaderByNumber(ctx, new(big.Int).SetUint64(endBlockNumber))
 	if err != nil {
 		log.Error().Err(err).Msg("unable to get end block for light summary")
 		return
 	}
-	endTime := time.Unix(int64(endBlock.Time()), 0)
-	startTime := time.Unix(int64(startBlock.Time()), 0)
+	endTime := time.Unix(int64(endBlock.Time), 0)
+	startTime := time.Unix(int64(startBlock.Time), 0)
 
 	testDuration := endTime.Sub(startTime)
 	tps := float64(len(loadTestResults)) / testDuration.Seconds()
@@ -990,7 +1356,7 @@ func blockUntilSuccessful(ctx context.Context, c *ethclient.Client, f func() err
 	}
 }
 
-func loadtestTransaction(ctx context.Context, c *ethclient.Client, nonce uint64) (t1 time.Time, t2 time.Time, err error) {
+func loadtestTransaction(ctx context.Context, s *txSender, nonce uint64) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	to := ltp.ToETHAddress
@@ -1000,9 +1366,8 @@ func loadtestTransaction(ctx context.Context, c *ethclient.Client, nonce uint64)
 
 	amount := ltp.SendAmount
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
@@ -1036,81 +1401,92 @@ func loadtestTransaction(ctx context.Context, c *ethclient.Client, nonce uint64)
 	}
 
 	t1 = time.Now()
-	err = c.SendTransaction(ctx, stx)
+	err = s.send(ctx, stx)
 	t2 = time.Now()
 	return
 }
 
-func loadtestDeploy(ctx context.Context, c *ethclient.Client, nonce uint64) (t1 time.Time, t2 time.Time, err error) {
+func loadtestDeploy(ctx context.Context, s *txSender, nonce uint64) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, _, _, err = contracts.DeployLoadTester(tops, c)
+	var tx *ethtypes.Transaction
+	if _, tx, _, err = contracts.DeployLoadTester(tops, s.client); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestFunction(ctx context.Context, c *ethclient.Client, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
+func loadtestFunction(ctx context.Context, s *txSender, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 	iterations := ltp.Iterations
 	f := ltp.Function
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, err = contracts.CallLoadTestFunctionByOpCode(*f, ltContract, tops, *iterations)
+	var tx *ethtypes.Transaction
+	if tx, err = contracts.CallLoadTestFunctionByOpCode(*f, ltContract, tops, *iterations); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestCall(ctx context.Context, c *ethclient.Client, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
+func loadtestCall(ctx context.Context, s *txSender, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 	iterations := ltp.Iterations
 	f := contracts.GetRandomOPCode()
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, err = contracts.CallLoadTestFunctionByOpCode(f, ltContract, tops, *iterations)
+	var tx *ethtypes.Transaction
+	if tx, err = contracts.CallLoadTestFunctionByOpCode(f, ltContract, tops, *iterations); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestCallPrecompiledContracts(ctx context.Context, c *ethclient.Client, nonce uint64, ltContract *contracts.LoadTester, useSelectedAddress bool) (t1 time.Time, t2 time.Time, err error) {
+func loadtestCallPrecompiledContracts(ctx context.Context, s *txSender, nonce uint64, ltContract *contracts.LoadTester, useSelectedAddress bool) (t1 time.Time, t2 time.Time, err error) {
 	var f int
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 	iterations := ltp.Iterations
 	if useSelectedAddress {
 		f = int(*ltp.Function)
@@ -1118,87 +1494,131 @@ func loadtestCallPrecompiledContracts(ctx context.Context, c *ethclient.Client,
 		f = contracts.GetRandomPrecompiledContractAddress()
 	}
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, err = contracts.CallPrecompiledContracts(f, ltContract, tops, *iterations, privateKey)
+	var tx *ethtypes.Transaction
+	if tx, err = contracts.CallPrecompiledContracts(f, ltContract, tops, *iterations, ltp.ECDSAPrivateKey); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestInc(ctx context.Context, c *ethclient.Client, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
+func loadtestGasBurner(ctx context.Context, s *txSender, nonce uint64, gasBurnerContract *bind.BoundContract) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
+	if tops.GasLimit == 0 {
+		tops.GasLimit = gasBurnerGasLimit
+	}
 
 	t1 = time.Now()
-	_, err = ltContract.Inc(tops)
+	var tx *ethtypes.Transaction
+	if tx, err = contracts.BurnGas(gasBurnerContract, tops, *ltp.BurnOpcode); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestStore(ctx context.Context, c *ethclient.Client, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
+func loadtestInc(ctx context.Context, s *txSender, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
+	tops = configureTransactOpts(tops)
+
+	t1 = time.Now()
+	var tx *ethtypes.Transaction
+	if tx, err = ltContract.Inc(tops); err == nil {
+		err = s.send(ctx, tx)
+	}
+	t2 = time.Now()
+	return
+}
+
+func loadtestStore(ctx context.Context, s *txSender, nonce uint64, ltContract *contracts.LoadTester) (t1 time.Time, t2 time.Time, err error) {
+	ltp := inputLoadTestParams
+
+	chainID := new(big.Int).SetUint64(*ltp.ChainID)
+
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable create transaction signer")
+		return
+	}
+	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	inputData := make([]byte, *ltp.ByteCount)
 	_, _ = hexwordRead(inputData)
 	t1 = time.Now()
-	_, err = ltContract.Store(tops, inputData)
+	var tx *ethtypes.Transaction
+	if tx, err = ltContract.Store(tops, inputData); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestLong(ctx context.Context, c *ethclient.Client, nonce uint64, delegatorContract *contracts.Delegator, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
+func loadtestLong(ctx context.Context, s *txSender, nonce uint64, delegatorContract *contracts.Delegator, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	// TODO the delegated call should be a parameter
 	t1 = time.Now()
 	// loopBlockHashUntilLimit (verify here https://abi.hashex.org/)
-	_, err = delegatorContract.LoopDelegateCall(tops, ltAddress, []byte{0xa2, 0x71, 0xb7, 0x21})
+	var tx *ethtypes.Transaction
+	if tx, err = delegatorContract.LoopDelegateCall(tops, ltAddress, []byte{0xa2, 0x71, 0xb7, 0x21}); err == nil {
+		err = s.send(ctx, tx)
+	}
 	// loopUntilLimit
 	// _, err = delegatorContract.LoopDelegateCall(tops, ltAddress, []byte{0x65, 0x9b, 0xbb, 0x4f})
 	t2 = time.Now()
 	return
 }
 
-func loadtestERC20(ctx context.Context, c *ethclient.Client, nonce uint64, erc20Contract *contracts.ERC20, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
+func loadtestERC20(ctx context.Context, s *txSender, nonce uint64, erc20Contract *contracts.ERC20, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 
 	to := ltp.ToETHAddress
@@ -1208,23 +1628,27 @@ func loadtestERC20(ctx context.Context, c *ethclient.Client, nonce uint64, erc20
 	amount := ltp.SendAmount
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, err = erc20Contract.Transfer(tops, *to, amount)
+	var tx *ethtypes.Transaction
+	if tx, err = erc20Contract.Transfer(tops, *to, amount); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
 
-func loadtestERC721(ctx context.Context, c *ethclient.Client, nonce uint64, erc721Contract *contracts.ERC721, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
+func loadtestERC721(ctx context.Context, s *txSender, nonce uint64, erc721Contract *contracts.ERC721, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
 	ltp := inputLoadTestParams
 	iterations := ltp.Iterations
 
@@ -1234,18 +1658,22 @@ func loadtestERC721(ctx context.Context, c *ethclient.Client, nonce uint64, erc7
 	}
 
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 
-	tops, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Unable create transaction signer")
 		return
 	}
 	tops.Nonce = new(big.Int).SetUint64(nonce)
+	tops.Context = ctx
+	tops.NoSend = true
 	tops = configureTransactOpts(tops)
 
 	t1 = time.Now()
-	_, err = erc721Contract.MintBatch(tops, *to, new(big.Int).SetUint64(*iterations))
+	var tx *ethtypes.Transaction
+	if tx, err = erc721Contract.MintBatch(tops, *to, new(big.Int).SetUint64(*iterations)); err == nil {
+		err = s.send(ctx, tx)
+	}
 	t2 = time.Now()
 	return
 }
@@ -1302,11 +1730,9 @@ func availLoop(ctx context.Context, c *gsrpc.SubstrateAPI) error {
 	requests := *ltp.Requests
 	currentNonce := uint64(0) // *ltp.CurrentNonce
 	chainID := new(big.Int).SetUint64(*ltp.ChainID)
-	privateKey := ltp.ECDSAPrivateKey
 	mode := *ltp.Mode
 
 	_ = chainID
-	_ = privateKey
 
 	meta, err := c.RPC.State.GetMetadataLatest()
 	if err != nil {
@@ -1360,6 +1786,9 @@ func availLoop(ctx context.Context, c *gsrpc.SubstrateAPI) error {
 
 				if rl != nil {
 					err = rl.Wait(ctx)
+					if ctx.Err() != nil {
+						break
+					}
 					if err != nil {
 						log.Error().Err(err).Msg("Encountered a rate limiting error")
 					}
@@ -1597,7 +2026,11 @@ func waitForFinalBlock(ctx context.Context, c *ethclient.Client, rpc *ethrpc.Cli
 		}
 		if currentNonce < endNonce && maxWaitCount > 0 {
 			log.Trace().Uint64("endNonce", endNonce).Uint64("currentNonce", currentNonce).Msg("Not all transactions have been mined. Waiting")
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
 			maxWaitCount = maxWaitCount - 1
 			continue
 		}
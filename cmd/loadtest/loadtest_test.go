@@ -0,0 +1,44 @@
+package loadtest
+
+import (
+	"testing"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+func TestCountFailedRequests(t *testing.T) {
+	samples := func(failed, total int) []loadTestSample {
+		lts := make([]loadTestSample, total)
+		for i := 0; i < failed; i++ {
+			lts[i].IsError = true
+		}
+		return lts
+	}
+
+	tests := []struct {
+		name    string
+		lts     []loadTestSample
+		maxRate float64
+		partial bool
+	}{
+		{name: "no failures", lts: samples(0, 10), maxRate: 0},
+		{name: "any failure", lts: samples(1, 10), maxRate: 0, partial: true},
+		{name: "below the rate", lts: samples(1, 10), maxRate: 20},
+		{name: "at the rate", lts: samples(2, 10), maxRate: 20},
+		{name: "above the rate", lts: samples(3, 10), maxRate: 20, partial: true},
+		{name: "default", lts: samples(10, 10), maxRate: 100},
+		{name: "no requests", maxRate: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := countFailedRequests(tc.lts, tc.maxRate)
+			if tc.partial && util.ExitCode(err) != util.ExitPartial {
+				t.Errorf("expected the partial failure exit code, got %v", err)
+			}
+			if !tc.partial && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
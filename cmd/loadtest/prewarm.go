@@ -0,0 +1,64 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// prewarm sends throwaway requests of every mode of the load test and waits
+// for them to be mined, so the accounts, contracts, and storage slots that the
+// load test uses are in the caches of the node before the measurement starts.
+// The requests aren't recorded, and their nonces are reserved from the account
+// of the sender.
+func prewarm(ctx context.Context, s *txSender, mode string, send func(context.Context, *txSender, string, uint64) (time.Time, time.Time, error)) error {
+	c, account := s.client, s.account
+	modes := mode
+	if strings.Contains(modes, loadTestModeRandom) {
+		modes = strings.Join(validLoadTestModes[:len(validLoadTestModes)-1], "")
+	}
+
+	count := *inputLoadTestParams.Prewarm
+	start := time.Now()
+	log.Info().Str("modes", modes).Uint64("requests", count).Msg("Pre-warming the state of the load test")
+	progress := util.NewProgress("prewarm", "requests", count*uint64(len(modes)))
+	defer progress.Done()
+	for _, m := range modes {
+		for i := uint64(0); i < count; i++ {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			nonce := account.Reserve()
+			if _, _, err := send(ctx, s, string(m), nonce); err != nil {
+				// The nonce is reused if the request wasn't sent, like the
+				// load test does.
+				log.Warn().Err(err).Str("mode", string(m)).Uint64("nonce", nonce).Msg("Unable to send a pre-warming request")
+				account.Release(nonce)
+				continue
+			}
+			progress.Add(1)
+		}
+	}
+
+	nonce := account.Next()
+	err := blockUntilSuccessful(ctx, c, func() error {
+		mined, err := c.NonceAt(ctx, *inputLoadTestParams.FromETHAddress, nil)
+		if err != nil {
+			return err
+		}
+		if mined < nonce {
+			return fmt.Errorf("%d pre-warming transactions aren't mined yet", nonce-mined)
+		}
+		return nil
+	}, *inputLoadTestParams.ContractCallNumberOfBlocksToWaitFor, *inputLoadTestParams.ContractCallBlockInterval)
+	if err != nil {
+		return fmt.Errorf("the pre-warming transactions weren't mined: %w", err)
+	}
+	log.Info().Uint64("nonce", nonce).Dur("duration", time.Since(start)).Msg("Pre-warmed the state of the load test")
+	return nil
+}
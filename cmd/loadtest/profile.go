@@ -0,0 +1,97 @@
+package loadtest
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// loadTestProfile is a weighted mix of modes. Every request picks one of
+	// the modes at random according to the weights, so concurrent routines
+	// approximate the traffic composition of a real network. For example:
+	//
+	//	modes:
+	//	  - mode: t
+	//	    weight: 60
+	//	  - mode: "2"
+	//	    weight: 20
+	//	  - mode: d
+	//	    weight: 10
+	//	  - mode: s
+	//	    weight: 10
+	loadTestProfile struct {
+		Modes []profileMode `yaml:"modes"`
+
+		total float64
+	}
+	profileMode struct {
+		Mode   string  `yaml:"mode"`
+		Weight float64 `yaml:"weight"`
+	}
+)
+
+func readLoadTestProfile(path string) (*loadTestProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p loadTestProfile
+	if err = yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("unable to parse profile %s: %w", path, err)
+	}
+	if len(p.Modes) == 0 {
+		return nil, fmt.Errorf("profile %s doesn't have any modes", path)
+	}
+
+	for _, m := range p.Modes {
+		if len(m.Mode) != 1 || !strings.Contains(strings.Join(validLoadTestModes, ""), m.Mode) {
+			return nil, fmt.Errorf("the profile mode %q is not recognized", m.Mode)
+		}
+		if m.Mode == loadTestModeRandom {
+			return nil, fmt.Errorf("the random mode can't be used in a profile")
+		}
+		if m.Weight <= 0 {
+			return nil, fmt.Errorf("the weight of profile mode %s must be positive", m.Mode)
+		}
+		p.total += m.Weight
+	}
+
+	return &p, nil
+}
+
+// modes returns all the modes of the profile, so the contracts they need are
+// deployed.
+func (p *loadTestProfile) modes() string {
+	var modes string
+	for _, m := range p.Modes {
+		if !strings.Contains(modes, m.Mode) {
+			modes += m.Mode
+		}
+	}
+	return modes
+}
+
+// pick returns a mode at random according to the weights.
+func (p *loadTestProfile) pick(r *rand.Rand) string {
+	x := r.Float64() * p.total
+	for _, m := range p.Modes {
+		if x < m.Weight {
+			return m.Mode
+		}
+		x -= m.Weight
+	}
+	return p.Modes[len(p.Modes)-1].Mode
+}
+
+func (p *loadTestProfile) String() string {
+	mix := make([]string, 0, len(p.Modes))
+	for _, m := range p.Modes {
+		mix = append(mix, fmt.Sprintf("%s=%.1f%%", m.Mode, m.Weight/p.total*100))
+	}
+	return strings.Join(mix, " ")
+}
@@ -0,0 +1,348 @@
+package loadtest
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	chartWidth  = 900
+	chartHeight = 260
+	chartMargin = 50
+)
+
+var (
+	//go:embed report.html
+	reportTemplate string
+
+	chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728"}
+)
+
+type (
+	// loadTestBlock is the part of a block that the report and the saved
+	// results need.
+	loadTestBlock struct {
+		Number        hexutil.Uint64   `json:"number"`
+		Timestamp     hexutil.Uint64   `json:"timestamp"`
+		GasUsed       hexutil.Uint64   `json:"gasUsed"`
+		GasLimit      hexutil.Uint64   `json:"gasLimit"`
+		BaseFeePerGas *hexutil.Big     `json:"baseFeePerGas"`
+		Transactions  []ethcommon.Hash `json:"transactions"`
+	}
+
+	chartPoint struct {
+		X float64
+		Y float64
+	}
+
+	chartSeries struct {
+		Name   string
+		Points []chartPoint
+	}
+
+	reportStat struct {
+		Name  string
+		Value string
+	}
+
+	reportData struct {
+		Title     string
+		Generated string
+		Stats     []reportStat
+		Charts    []template.HTML
+	}
+)
+
+// writeReport renders a self-contained HTML report of the samples and the
+// blocks of the load test, so the results can be shared without polycli.
+func writeReport(file string, blocks []loadTestBlock) error {
+	samples := sortedSamples()
+
+	data := reportData{
+		Title:     fmt.Sprintf("Load test of %s", inputLoadTestParams.URL.Redacted()),
+		Generated: time.Now().UTC().Format(time.RFC1123),
+		Stats:     reportStats(samples, blocks),
+	}
+	sent, included := tpsSeries(samples, blocks)
+	data.Charts = append(data.Charts,
+		lineChart("Transactions per second", "Seconds", "TPS", sent, included),
+		lineChart("Request latency", "Seconds", "Milliseconds", latencySeries(samples)...),
+		lineChart("Gas price", "Block", "Gwei", baseFeeSeries(blocks)),
+		lineChart("Block fullness", "Block", "Percent of the gas limit", fullnessSeries(blocks)),
+	)
+
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = tmpl.Execute(f, data); err != nil {
+		return err
+	}
+	log.Info().Str("file", file).Msg("Wrote the load test report")
+	return nil
+}
+
+// sortedSamples returns a copy of the samples sorted by request time.
+func sortedSamples() []loadTestSample {
+	loadTestResutsMutex.RLock()
+	samples := append([]loadTestSample(nil), loadTestResults...)
+	loadTestResutsMutex.RUnlock()
+	sort.Slice(samples, func(i, j int) bool { return samples[i].RequestTime.Before(samples[j].RequestTime) })
+	return samples
+}
+
+func getLoadTestBlocks(ctx context.Context, rpc *ethrpc.Client, start, end uint64) ([]loadTestBlock, error) {
+	batchSize := *inputLoadTestParams.BatchSize
+	if batchSize == 0 {
+		batchSize = 1
+	}
+	var blocks []loadTestBlock
+	for from := start; from <= end; from += batchSize {
+		to := from + batchSize - 1
+		if to > end {
+			to = end
+		}
+		batch := make([]loadTestBlock, to-from+1)
+		elems := make([]ethrpc.BatchElem, len(batch))
+		for i := range elems {
+			elems[i] = ethrpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+				Result: &batch[i],
+			}
+		}
+		if err := rpc.BatchCallContext(ctx, elems); err != nil {
+			return nil, fmt.Errorf("unable to get blocks %d to %d: %w", from, to, err)
+		}
+		for i, e := range elems {
+			if e.Error != nil {
+				return nil, fmt.Errorf("unable to get block %d: %w", from+uint64(i), e.Error)
+			}
+		}
+		blocks = append(blocks, batch...)
+	}
+	return blocks, nil
+}
+
+func reportStats(samples []loadTestSample, blocks []loadTestBlock) []reportStat {
+	var (
+		errors    int
+		latencies []float64
+	)
+	for _, s := range samples {
+		if s.IsError {
+			errors++
+		}
+		latencies = append(latencies, float64(s.WaitTime.Microseconds())/1000)
+	}
+	var txs int
+	var fullness float64
+	for _, b := range blocks {
+		txs += len(b.Transactions)
+		if b.GasLimit > 0 {
+			fullness += float64(b.GasUsed) / float64(b.GasLimit) * 100
+		}
+	}
+
+	stats := []reportStat{
+		{"Mode", *inputLoadTestParams.Mode},
+		{"Requests", fmt.Sprint(len(samples))},
+		{"Errors", fmt.Sprint(errors)},
+	}
+	if len(blocks) > 1 {
+		first, last := blocks[0], blocks[len(blocks)-1]
+		duration := float64(last.Timestamp - first.Timestamp)
+		stats = append(stats,
+			reportStat{"Blocks", fmt.Sprintf("%d to %d", first.Number, last.Number)},
+			reportStat{"Duration", (time.Duration(duration) * time.Second).String()},
+			reportStat{"Transactions included", fmt.Sprint(txs)},
+		)
+		if duration > 0 {
+			stats = append(stats, reportStat{"Mean TPS", fmt.Sprintf("%.2f", float64(txs)/duration)})
+		}
+		stats = append(stats, reportStat{"Mean block fullness", fmt.Sprintf("%.2f%%", fullness/float64(len(blocks)))})
+	}
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		stats = append(stats,
+			reportStat{"Latency P50", fmt.Sprintf("%.2fms", percentile(latencies, 0.5))},
+			reportStat{"Latency P90", fmt.Sprintf("%.2fms", percentile(latencies, 0.9))},
+			reportStat{"Latency P99", fmt.Sprintf("%.2fms", percentile(latencies, 0.99))},
+			reportStat{"Latency max", fmt.Sprintf("%.2fms", latencies[len(latencies)-1])},
+		)
+	}
+	return stats
+}
+
+// percentile expects the values to be sorted.
+func percentile(sorted []float64, p float64) float64 {
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// tpsSeries returns the requests sent per second and the transactions included
+// per second, both in seconds since the first block.
+func tpsSeries(samples []loadTestSample, blocks []loadTestBlock) (sent, included chartSeries) {
+	sent.Name = "Sent"
+	included.Name = "Included"
+	if len(blocks) == 0 {
+		return
+	}
+	start := int64(blocks[0].Timestamp)
+
+	perSecond := make(map[int64]int)
+	for _, s := range samples {
+		perSecond[s.RequestTime.Unix()-start]++
+	}
+	seconds := make([]int64, 0, len(perSecond))
+	for s := range perSecond {
+		seconds = append(seconds, s)
+	}
+	sort.Slice(seconds, func(i, j int) bool { return seconds[i] < seconds[j] })
+	for _, s := range seconds {
+		sent.Points = append(sent.Points, chartPoint{float64(s), float64(perSecond[s])})
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		elapsed := float64(blocks[i].Timestamp - blocks[i-1].Timestamp)
+		if elapsed == 0 {
+			continue
+		}
+		included.Points = append(included.Points, chartPoint{float64(int64(blocks[i].Timestamp) - start), float64(len(blocks[i].Transactions)) / elapsed})
+	}
+	return
+}
+
+// latencySeries returns the percentiles of the request latency of every
+// second since the first request.
+func latencySeries(samples []loadTestSample) []chartSeries {
+	series := []chartSeries{{Name: "P50"}, {Name: "P90"}, {Name: "P99"}}
+	if len(samples) == 0 {
+		return series
+	}
+	start := samples[0].RequestTime.Unix()
+
+	var (
+		second    int64
+		latencies []float64
+	)
+	flush := func() {
+		if len(latencies) == 0 {
+			return
+		}
+		sort.Float64s(latencies)
+		for i, p := range []float64{0.5, 0.9, 0.99} {
+			series[i].Points = append(series[i].Points, chartPoint{float64(second), percentile(latencies, p)})
+		}
+		latencies = latencies[:0]
+	}
+	for _, s := range samples {
+		if elapsed := s.RequestTime.Unix() - start; elapsed != second {
+			flush()
+			second = elapsed
+		}
+		latencies = append(latencies, float64(s.WaitTime.Microseconds())/1000)
+	}
+	flush()
+	return series
+}
+
+func baseFeeSeries(blocks []loadTestBlock) chartSeries {
+	series := chartSeries{Name: "Base fee"}
+	for _, b := range blocks {
+		if b.BaseFeePerGas != nil {
+			series.Points = append(series.Points, chartPoint{float64(b.Number), toGwei((*big.Int)(b.BaseFeePerGas))})
+		}
+	}
+	return series
+}
+
+func fullnessSeries(blocks []loadTestBlock) chartSeries {
+	series := chartSeries{Name: "Gas used"}
+	for _, b := range blocks {
+		if b.GasLimit > 0 {
+			series.Points = append(series.Points, chartPoint{float64(b.Number), float64(b.GasUsed) / float64(b.GasLimit) * 100})
+		}
+	}
+	return series
+}
+
+// lineChart renders the series as an inline SVG chart, so the report doesn't
+// depend on scripts or stylesheets that would have to be downloaded.
+func lineChart(title, xLabel, yLabel string, series ...chartSeries) template.HTML {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	maxY := 0.0
+	for _, s := range series {
+		for _, p := range s.Points {
+			minX = math.Min(minX, p.X)
+			maxX = math.Max(maxX, p.X)
+			maxY = math.Max(maxY, p.Y)
+		}
+	}
+
+	var sb strings.Builder
+	esc := template.HTMLEscapeString
+	fmt.Fprintf(&sb, `<figure><figcaption>%s</figcaption>`, esc(title))
+	if math.IsInf(minX, 1) {
+		sb.WriteString(`<p class="empty">No data</p></figure>`)
+		return template.HTML(sb.String())
+	}
+	if maxX == minX {
+		maxX = minX + 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	x := func(v float64) float64 { return chartMargin + (v-minX)/(maxX-minX)*plotWidth }
+	y := func(v float64) float64 { return chartMargin + plotHeight - v/maxY*plotHeight }
+
+	fmt.Fprintf(&sb, `<svg viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, chartWidth, chartHeight)
+	for i := 0; i <= 4; i++ {
+		v := maxY * float64(i) / 4
+		fmt.Fprintf(&sb, `<line class="grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, chartMargin, y(v), chartWidth-chartMargin, y(v))
+		fmt.Fprintf(&sb, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, chartMargin-5, y(v)+4, formatTick(v))
+		u := minX + (maxX-minX)*float64(i)/4
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x(u), chartHeight-chartMargin+15, formatTick(u))
+	}
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, chartWidth/2, chartHeight-10, esc(xLabel))
+	fmt.Fprintf(&sb, `<text x="12" y="%d" text-anchor="middle" transform="rotate(-90 12 %d)">%s</text>`, chartHeight/2, chartHeight/2, esc(yLabel))
+
+	for i, s := range series {
+		color := chartColors[i%len(chartColors)]
+		points := make([]string, len(s.Points))
+		for j, p := range s.Points {
+			points[j] = fmt.Sprintf("%.1f,%.1f", x(p.X), y(p.Y))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, chartMargin+10+i*110, 15, color)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`, chartMargin+25+i*110, 24, esc(s.Name))
+	}
+	sb.WriteString(`</svg></figure>`)
+	return template.HTML(sb.String())
+}
+
+func formatTick(v float64) string {
+	if v == math.Trunc(v) || v >= 100 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
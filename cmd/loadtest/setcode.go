@@ -0,0 +1,95 @@
+package loadtest
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/contracts"
+	"github.com/maticnetwork/polygon-cli/signer"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// setCodeGasLimit is used for set code transactions when no gas limit is
+// given. Nodes without EIP-7702 support can't estimate it, and it covers the
+// authorization of a new account along with the delegated call.
+const setCodeGasLimit = 150000
+
+// loadtestSetCode delegates a fresh account to the load test contract with an
+// EIP-7702 authorization and calls inc on it in the same transaction, so the
+// delegated code runs in the context of the account.
+func loadtestSetCode(ctx context.Context, rpc *ethrpc.Client, s *txSender, nonce uint64, ltAddress ethcommon.Address) (t1 time.Time, t2 time.Time, err error) {
+	ltp := inputLoadTestParams
+
+	chainID := new(big.Int).SetUint64(*ltp.ChainID)
+
+	delegatedKey, err := ethcrypto.GenerateKey()
+	if err != nil {
+		return
+	}
+	auth, err := util.SignSetCodeAuthorization(delegatedKey, chainID, ltAddress, 0)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to sign set code authorization")
+		return
+	}
+
+	ltABI, err := contracts.LoadTesterMetaData.GetAbi()
+	if err != nil {
+		return
+	}
+	data, err := ltABI.Pack("inc")
+	if err != nil {
+		return
+	}
+
+	tops, err := signer.NewTransactor(ltp.Signer, chainID)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable create transaction signer")
+		return
+	}
+	tops = configureTransactOpts(tops)
+	if tops.GasLimit == 0 {
+		tops.GasLimit = setCodeGasLimit
+	}
+
+	tx := &util.SetCodeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tops.GasTipCap,
+		GasFeeCap: new(big.Int).Add(tops.GasTipCap, ltp.BaseFee),
+		Gas:       tops.GasLimit,
+		To:        ethcrypto.PubkeyToAddress(delegatedKey.PublicKey),
+		Data:      data,
+		AuthList:  []util.SetCodeAuthorization{auth},
+	}
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return
+	}
+	sig, err := ltp.Signer.SignHash(ctx, hash.Bytes())
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to sign transaction")
+		return
+	}
+	tx.SetSignature(sig)
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	t1 = time.Now()
+	// The transaction type isn't known to the client, so it's sent raw.
+	if b := s.opts.Batch; b != nil {
+		b.Add(raw)
+	} else {
+		err = rpc.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(raw))
+	}
+	t2 = time.Now()
+	return
+}
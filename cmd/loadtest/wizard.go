@@ -0,0 +1,58 @@
+package loadtest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// loadTestWizardSteps are the parameters that --interactive asks for, which
+// are the ones most load tests set.
+func loadTestWizardSteps() []util.WizardStep {
+	return []util.WizardStep{{
+		Prompt:   "RPC URL to load test",
+		Required: true,
+		Validate: func(s string) error {
+			u, err := url.Parse(s)
+			if err != nil {
+				return err
+			}
+			if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "ws" && u.Scheme != "wss" {
+				return fmt.Errorf("the scheme %q is not supported, use http, https, ws, or wss", u.Scheme)
+			}
+			return nil
+		},
+	}, {
+		Flag:   "mode",
+		Prompt: "Modes, one letter each (t transfers, d deploys, c calls, s stores, 2 ERC20, 7 ERC721, r random, ...)",
+		Validate: func(s string) error {
+			for _, m := range s {
+				if !strings.Contains(strings.Join(validLoadTestModes, ""), string(m)) {
+					return fmt.Errorf("the mode %q is not recognized", m)
+				}
+			}
+			return nil
+		},
+	}, {
+		Flag:   "requests",
+		Prompt: "Requests per goroutine",
+	}, {
+		Flag:   "concurrency",
+		Prompt: "Goroutines sending requests",
+	}, {
+		Flag:   "rate-limit",
+		Prompt: "Requests per second, below zero for no limit",
+	}, {
+		Flag:   "time-limit",
+		Prompt: "Time limit in seconds, -1 for none",
+	}, {
+		Flag:   "chain-id",
+		Prompt: "Chain ID, 0 to use the chain ID of the RPC",
+	}, {
+		Flag:   "private-key",
+		Prompt: "Private key of the sender, or a reference like env:NAME",
+		Secret: true,
+	}}
+}
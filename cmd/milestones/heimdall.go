@@ -0,0 +1,154 @@
+package milestones
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	// jsonUint is a number that heimdall v1 returns as a JSON number and v2
+	// returns as a string.
+	jsonUint uint64
+
+	// jsonHash is a hash that heimdall v1 returns as hex and v2 returns as
+	// base64.
+	jsonHash ethcommon.Hash
+
+	milestone struct {
+		Proposer    ethcommon.Address `json:"proposer"`
+		StartBlock  jsonUint          `json:"start_block"`
+		EndBlock    jsonUint          `json:"end_block"`
+		Hash        jsonHash          `json:"hash"`
+		BorChainID  string            `json:"bor_chain_id"`
+		MilestoneID string            `json:"milestone_id"`
+		Timestamp   jsonUint          `json:"timestamp"`
+	}
+
+	heimdallClient struct {
+		url    string
+		client *http.Client
+		// v2 is set if the node serves the heimdall v2 API, which moved the
+		// milestones from /milestone to /milestones.
+		v2 bool
+	}
+)
+
+func (u *jsonUint) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", string(data), err)
+	}
+	*u = jsonUint(n)
+	return nil
+}
+
+func (h *jsonHash) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if strings.HasPrefix(s, "0x") {
+		b, err = hexutil.Decode(s)
+	} else {
+		b, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid hash %s: %w", s, err)
+	}
+	if len(b) != ethcommon.HashLength {
+		return fmt.Errorf("the hash %s should be %d bytes, got %d", s, ethcommon.HashLength, len(b))
+	}
+	*h = jsonHash(ethcommon.BytesToHash(b))
+	return nil
+}
+
+// newHeimdallClient returns a client of the heimdall REST API and detects
+// whether it's heimdall v1 or v2.
+func newHeimdallClient(ctx context.Context, url string) (*heimdallClient, error) {
+	hc := &heimdallClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	var v2 struct {
+		Milestone *milestone `json:"milestone"`
+	}
+	err := hc.get(ctx, "/milestones/latest", &v2)
+	if err == nil && v2.Milestone != nil {
+		hc.v2 = true
+		log.Debug().Str("url", hc.url).Msg("Using the heimdall v2 API")
+		return hc, nil
+	}
+	log.Debug().AnErr("v2", err).Msg("Falling back to the heimdall v1 API")
+
+	if _, err = hc.latest(ctx); err != nil {
+		return nil, fmt.Errorf("unable to get the latest milestone: %w", err)
+	}
+	return hc, nil
+}
+
+// latest returns the latest milestone.
+func (hc *heimdallClient) latest(ctx context.Context) (*milestone, error) {
+	if hc.v2 {
+		var resp struct {
+			Milestone *milestone `json:"milestone"`
+		}
+		if err := hc.get(ctx, "/milestones/latest", &resp); err != nil {
+			return nil, err
+		}
+		if resp.Milestone == nil {
+			return nil, fmt.Errorf("the response has no milestone")
+		}
+		return resp.Milestone, nil
+	}
+
+	var resp struct {
+		Result *milestone `json:"result"`
+	}
+	if err := hc.get(ctx, "/milestone/latest", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Result == nil {
+		return nil, fmt.Errorf("the response has no milestone")
+	}
+	return resp.Result, nil
+}
+
+func (hc *heimdallClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := hc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", hc.url+path, resp.Status, string(bytes.TrimSpace(body)))
+	}
+	return json.Unmarshal(body, out)
+}
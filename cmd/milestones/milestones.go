@@ -0,0 +1,324 @@
+package milestones
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	milestonesParams struct {
+		RPCURL         string
+		HeimdallURL    string
+		Interval       time.Duration
+		Window         time.Duration
+		ReportInterval time.Duration
+		MaxLag         time.Duration
+		MaxLagBlocks   uint64
+	}
+
+	rpcHeader struct {
+		Number    hexutil.Uint64 `json:"number"`
+		Hash      ethcommon.Hash `json:"hash"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+	}
+
+	// sample is the time to finality of the blocks of a milestone. The oldest
+	// block of a milestone waited the longest to be finalized and the newest
+	// block the shortest.
+	sample struct {
+		At     time.Time
+		Newest time.Duration
+		Oldest time.Duration
+	}
+
+	// distribution summarizes the samples in the window.
+	distribution struct {
+		Min  time.Duration
+		Mean time.Duration
+		P50  time.Duration
+		P90  time.Duration
+		P99  time.Duration
+		Max  time.Duration
+	}
+
+	tracker struct {
+		last     *milestone
+		lastTime uint64
+		samples  []sample
+		lagging  bool
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage           string
+	inputMilestones milestonesParams
+)
+
+// MilestonesCmd tracks the time it takes heimdall milestones to finalize bor
+// blocks.
+var MilestonesCmd = &cobra.Command{
+	Use:   "milestones",
+	Short: "Track the time to finality of heimdall milestones and alert when finality lags behind the bor head.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputMilestones.Interval <= 0 {
+			return errors.New("the interval must be greater than zero")
+		}
+		if inputMilestones.Window <= 0 {
+			return errors.New("the window must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputMilestones.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		hc, err := newHeimdallClient(ctx, inputMilestones.HeimdallURL)
+		if err != nil {
+			return err
+		}
+
+		t := new(tracker)
+		lastReport := time.Now()
+		for {
+			if err = t.poll(ctx, rpc, hc); err != nil {
+				log.Error().Err(err).Msg("Unable to poll the milestone")
+			}
+			if inputMilestones.ReportInterval > 0 && time.Since(lastReport) >= inputMilestones.ReportInterval {
+				t.logReport()
+				lastReport = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				t.print()
+				return nil
+			case <-time.After(inputMilestones.Interval):
+			}
+		}
+	},
+}
+
+func init() {
+	flagSet := MilestonesCmd.Flags()
+	flagSet.StringVar(&inputMilestones.RPCURL, "rpc-url", "http://localhost:8545", "The bor RPC endpoint url")
+	flagSet.StringVar(&inputMilestones.HeimdallURL, "heimdall-url", "http://localhost:1317", "The heimdall REST API url")
+	flagSet.DurationVar(&inputMilestones.Interval, "interval", 2*time.Second, "How often to poll for new milestones")
+	flagSet.DurationVar(&inputMilestones.Window, "window", time.Hour, "The window of milestones that the time to finality is reported over")
+	flagSet.DurationVar(&inputMilestones.ReportInterval, "report-interval", time.Minute, "How often to log the time to finality (0 to only report on exit)")
+	flagSet.DurationVar(&inputMilestones.MaxLag, "max-lag", time.Minute, "Alert when the head is this far ahead of the last finalized block (0 to disable)")
+	flagSet.Uint64Var(&inputMilestones.MaxLagBlocks, "max-lag-blocks", 0, "Alert when the head is this many blocks ahead of the last finalized block (0 to disable)")
+}
+
+func getHeader(ctx context.Context, rpc *ethrpc.Client, block string) (*rpcHeader, error) {
+	var header *rpcHeader
+	if err := rpc.CallContext(ctx, &header, "eth_getBlockByNumber", block, false); err != nil {
+		return nil, fmt.Errorf("unable to get block %s: %w", block, err)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+	return header, nil
+}
+
+// poll records the time to finality of a new milestone and checks how far the
+// head is ahead of the last finalized block.
+func (t *tracker) poll(ctx context.Context, rpc *ethrpc.Client, hc *heimdallClient) error {
+	m, err := hc.latest(ctx)
+	if err != nil {
+		return err
+	}
+	observed := time.Now()
+
+	if t.last == nil || m.EndBlock > t.last.EndBlock {
+		if err = t.addMilestone(ctx, rpc, m, observed); err != nil {
+			return err
+		}
+	}
+
+	head, err := getHeader(ctx, rpc, "latest")
+	if err != nil {
+		return err
+	}
+	var (
+		lagBlocks uint64
+		lag       time.Duration
+	)
+	if uint64(head.Number) > uint64(t.last.EndBlock) {
+		lagBlocks = uint64(head.Number) - uint64(t.last.EndBlock)
+	}
+	if uint64(head.Timestamp) > t.lastTime {
+		lag = time.Duration(uint64(head.Timestamp)-t.lastTime) * time.Second
+	}
+	log.Debug().Uint64("head", uint64(head.Number)).Uint64("finalized", uint64(t.last.EndBlock)).Uint64("lagBlocks", lagBlocks).Dur("lag", lag).Msg("Polled")
+
+	exceeded := (inputMilestones.MaxLag > 0 && lag > inputMilestones.MaxLag) ||
+		(inputMilestones.MaxLagBlocks > 0 && lagBlocks > inputMilestones.MaxLagBlocks)
+	switch {
+	case exceeded && !t.lagging:
+		log.Warn().
+			Uint64("head", uint64(head.Number)).
+			Uint64("finalized", uint64(t.last.EndBlock)).
+			Uint64("lagBlocks", lagBlocks).
+			Str("lag", lag.String()).
+			Msg("Finality lag exceeded the threshold")
+	case !exceeded && t.lagging:
+		log.Info().
+			Uint64("head", uint64(head.Number)).
+			Uint64("finalized", uint64(t.last.EndBlock)).
+			Uint64("lagBlocks", lagBlocks).
+			Str("lag", lag.String()).
+			Msg("Finality lag recovered")
+	}
+	t.lagging = exceeded
+	return nil
+}
+
+// addMilestone records the time to finality of the oldest and newest block of
+// the milestone. Milestones are timestamped by heimdall, so the samples don't
+// depend on the poll interval. The milestone hash is checked against bor.
+func (t *tracker) addMilestone(ctx context.Context, rpc *ethrpc.Client, m *milestone, observed time.Time) error {
+	end, err := getHeader(ctx, rpc, hexutil.EncodeUint64(uint64(m.EndBlock)))
+	if err != nil {
+		return err
+	}
+	start, err := getHeader(ctx, rpc, hexutil.EncodeUint64(uint64(m.StartBlock)))
+	if err != nil {
+		return err
+	}
+	if end.Hash != ethcommon.Hash(m.Hash) {
+		log.Error().
+			Str("id", m.MilestoneID).
+			Uint64("block", uint64(m.EndBlock)).
+			Str("milestoneHash", ethcommon.Hash(m.Hash).Hex()).
+			Str("borHash", end.Hash.Hex()).
+			Msg("The milestone hash doesn't match the bor block")
+	}
+
+	first := t.last == nil
+	t.last = m
+	t.lastTime = uint64(end.Timestamp)
+
+	// Without a heimdall timestamp, the milestone that was already there when
+	// tracking started can't be timed.
+	finalized := observed
+	if m.Timestamp > 0 {
+		finalized = time.Unix(int64(m.Timestamp), 0)
+	} else if first {
+		return nil
+	}
+
+	s := sample{
+		At:     observed,
+		Newest: finalized.Sub(time.Unix(int64(end.Timestamp), 0)).Round(time.Millisecond),
+		Oldest: finalized.Sub(time.Unix(int64(start.Timestamp), 0)).Round(time.Millisecond),
+	}
+	t.samples = append(t.samples, s)
+	log.Info().
+		Str("id", m.MilestoneID).
+		Uint64("start", uint64(m.StartBlock)).
+		Uint64("end", uint64(m.EndBlock)).
+		Str("proposer", m.Proposer.Hex()).
+		Str("newest", s.Newest.String()).
+		Str("oldest", s.Oldest.String()).
+		Msg("New milestone")
+	return nil
+}
+
+// window returns the samples in the window and drops the older ones.
+func (t *tracker) window() []sample {
+	cutoff := time.Now().Add(-inputMilestones.Window)
+	i := sort.Search(len(t.samples), func(i int) bool {
+		return !t.samples[i].At.Before(cutoff)
+	})
+	t.samples = t.samples[i:]
+	return t.samples
+}
+
+func (t *tracker) distributions() (newest, oldest distribution) {
+	samples := t.window()
+	n := make([]time.Duration, len(samples))
+	o := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		n[i] = s.Newest
+		o[i] = s.Oldest
+	}
+	return newDistribution(n), newDistribution(o)
+}
+
+func (t *tracker) logReport() {
+	newest, oldest := t.distributions()
+	log.Info().
+		Int("milestones", len(t.samples)).
+		Str("window", inputMilestones.Window.String()).
+		Str("newestP50", newest.P50.String()).
+		Str("newestP90", newest.P90.String()).
+		Str("oldestP50", oldest.P50.String()).
+		Str("oldestP90", oldest.P90.String()).
+		Str("oldestMax", oldest.Max.String()).
+		Msg("Time to finality")
+}
+
+func (t *tracker) print() {
+	newest, oldest := t.distributions()
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle(fmt.Sprintf("Time to finality of %d milestones", len(t.samples)))
+	tw.AppendHeader(table.Row{"Block", "Min", "Mean", "P50", "P90", "P99", "Max"})
+	for _, d := range []struct {
+		name string
+		d    distribution
+	}{
+		{"Newest", newest},
+		{"Oldest", oldest},
+	} {
+		tw.AppendRow(table.Row{d.name, d.d.Min, d.d.Mean, d.d.P50, d.d.P90, d.d.P99, d.d.Max})
+	}
+	tw.Render()
+}
+
+func newDistribution(values []time.Duration) distribution {
+	if len(values) == 0 {
+		return distribution{}
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, v := range sorted {
+		total += v
+	}
+	percentile := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return distribution{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(0.5),
+		P90:  percentile(0.9),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
@@ -0,0 +1,214 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// The panes of the explorer view.
+const (
+	paneStats        = "stats"
+	paneTxs          = "txs"
+	paneGasPrice     = "gas-price"
+	paneSize         = "size"
+	paneUncles       = "uncles"
+	paneGasUsed      = "gas-used"
+	paneBaseFee      = "base-fee"
+	paneGasHistogram = "gas-histogram"
+	paneBlocks       = "blocks"
+)
+
+// The orders the block table can be sorted in.
+const (
+	sortNewest = "newest"
+	sortOldest = "oldest"
+)
+
+var (
+	// panes are all the panes in the order they're laid out.
+	panes       = []string{paneStats, paneTxs, paneGasPrice, paneSize, paneUncles, paneGasUsed, paneBaseFee, paneGasHistogram, paneBlocks}
+	sparkPanes  = []string{paneTxs, paneGasPrice, paneSize, paneUncles, paneGasUsed}
+	sidePanes   = []string{paneBaseFee, paneGasHistogram}
+	shownPanes  map[string]bool
+	paneRefresh map[string]time.Duration
+)
+
+// applyConfig sets the flags that weren't passed from the monitor section of
+// the config file, for example:
+//
+//	monitor:
+//	  columns: [number, txs, gas-used, base-fee, author]
+//	  sort: oldest
+func applyConfig(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		key := "monitor." + f.Name
+		if err != nil || f.Changed || !viper.IsSet(key) {
+			return
+		}
+		var value string
+		switch f.Value.Type() {
+		case "stringSlice":
+			value = strings.Join(viper.GetStringSlice(key), ",")
+		case "stringToString":
+			var pairs []string
+			for k, v := range viper.GetStringMapString(key) {
+				pairs = append(pairs, k+"="+v)
+			}
+			sort.Strings(pairs)
+			value = strings.Join(pairs, ",")
+		default:
+			value = viper.GetString(key)
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid %s in the config file: %w", key, setErr)
+		}
+	})
+	return err
+}
+
+// parseLayout validates the columns, panes, sort order, and refresh intervals.
+func parseLayout() error {
+	for _, column := range columns {
+		if _, ok := metrics.BlockColumns[column]; !ok {
+			return fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(metrics.BlockColumnNames(), ", "))
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("at least one column is required")
+	}
+
+	shownPanes = make(map[string]bool, len(panes))
+	for _, pane := range shownPaneNames {
+		if !isPane(pane) {
+			return fmt.Errorf("unknown pane %q, expected one of %s", pane, strings.Join(panes, ", "))
+		}
+		shownPanes[pane] = true
+	}
+	if !shownPanes[paneBlocks] {
+		return fmt.Errorf("the %s pane can't be hidden", paneBlocks)
+	}
+
+	if sortOrder != sortNewest && sortOrder != sortOldest {
+		return fmt.Errorf("unknown sort order %q, expected %s or %s", sortOrder, sortNewest, sortOldest)
+	}
+
+	paneRefresh = make(map[string]time.Duration, len(refreshIntervals))
+	for pane, value := range refreshIntervals {
+		if !isPane(pane) {
+			return fmt.Errorf("unknown pane %q, expected one of %s", pane, strings.Join(panes, ", "))
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid refresh interval of the %s pane: %w", pane, err)
+		}
+		paneRefresh[pane] = d
+	}
+	return nil
+}
+
+func isPane(name string) bool {
+	for _, pane := range panes {
+		if pane == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setExplorerGrid lays out the shown panes. The stats are on top, the
+// sparklines with the base fee and gas panes on their right are below them,
+// and the block table takes the rest of the height. It returns the fraction of
+// the height the block table takes.
+func setExplorerGrid(grid *ui.Grid, blockTable ui.Drawable, termUi uiSkeleton) float64 {
+	var rows []interface{}
+	blocksHeight := 1.0
+
+	if shownPanes[paneStats] {
+		rows = append(rows, ui.NewRow(1.0/10,
+			ui.NewCol(1.0/5, termUi.h0),
+			ui.NewCol(1.0/5, termUi.h1),
+			ui.NewCol(1.0/5, termUi.h2),
+			ui.NewCol(1.0/5, termUi.h3),
+			ui.NewCol(1.0/5, termUi.h4),
+		))
+		blocksHeight -= 1.0 / 10
+	}
+
+	var sparks, sides []ui.Drawable
+	for _, pane := range sparkPanes {
+		if shownPanes[pane] {
+			sparks = append(sparks, termUi.panes[pane])
+		}
+	}
+	for _, pane := range sidePanes {
+		if shownPanes[pane] {
+			sides = append(sides, termUi.panes[pane])
+		}
+	}
+
+	if len(sparks) > 0 || len(sides) > 0 {
+		sparkWidth, sideWidth := 1.0, 1.0
+		if len(sides) > 0 && len(sparks) > 0 {
+			sparkWidth, sideWidth = 3.0/4, 1.0/4
+		}
+		var cols []interface{}
+		for _, spark := range sparks {
+			cols = append(cols, ui.NewCol(sparkWidth/float64(len(sparks)), spark))
+		}
+		if len(sides) > 0 {
+			var sideRows []interface{}
+			for _, side := range sides {
+				sideRows = append(sideRows, ui.NewRow(1.0/float64(len(sides)), side))
+			}
+			cols = append(cols, ui.NewCol(sideWidth, sideRows...))
+		}
+		rows = append(rows, ui.NewRow(4.0/10, cols...))
+		blocksHeight -= 4.0 / 10
+	}
+
+	rows = append(rows, ui.NewRow(blocksHeight, blockTable))
+	grid.Set(rows...)
+	return blocksHeight
+}
+
+// getWindowSize returns the number of blocks that fit in the block table.
+func getWindowSize(termHeight int, blocksHeight float64) int {
+	return max(1, int(float64(termHeight)*blocksHeight)-4)
+}
+
+// refreshDue returns whether the pane is shown and its refresh interval has
+// passed since it was last updated, and marks it as updated.
+func refreshDue(pane string, lastRefresh map[string]time.Time, now time.Time) bool {
+	if !shownPanes[pane] {
+		return false
+	}
+	d, ok := paneRefresh[pane]
+	if !ok {
+		return true
+	}
+	if now.Sub(lastRefresh[pane]) < d {
+		return false
+	}
+	lastRefresh[pane] = now
+	return true
+}
+
+// anyRefreshDue returns whether a pane with a refresh interval is due to be
+// updated, without marking it.
+func anyRefreshDue(lastRefresh map[string]time.Time, now time.Time) bool {
+	for pane, d := range paneRefresh {
+		if shownPanes[pane] && now.Sub(lastRefresh[pane]) >= d {
+			return true
+		}
+	}
+	return false
+}
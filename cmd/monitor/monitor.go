@@ -19,10 +19,12 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"image"
 	"math/big"
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +38,7 @@ import (
 	"github.com/gizak/termui/v3/widgets"
 	"github.com/maticnetwork/polygon-cli/metrics"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
@@ -49,10 +52,40 @@ var (
 	intervalStr    string
 	interval       time.Duration
 
+	sinkURL         string
+	sinkFormat      string
+	sinkIntervalStr string
+	sink            *metrics.Sink
+
+	gasHistoryBlocks         int
+	baseFeeChangeDenominator uint64
+
+	columns          []string
+	shownPaneNames   []string
+	sortOrder        string
+	refreshIntervals map[string]string
+
+	once       bool
+	onceJSON   bool
+	maxHeadAge time.Duration
+	minPeers   uint64
+
+	recordFile  string
+	replayFile  string
+	replaySpeed float64
+	rec         *recorder
+
 	one  = big.NewInt(1)
 	zero = big.NewInt(0)
 )
 
+const (
+	// projectedBlocks is the number of blocks the base fee is projected for.
+	projectedBlocks = 3
+	// gasHistogramBuckets is the number of bars of the gas used histogram.
+	gasHistogramBuckets = 10
+)
+
 type (
 	monitorStatus struct {
 		ChainID   *big.Int
@@ -82,8 +115,13 @@ type (
 		sl2 *widgets.Sparkline
 		sl3 *widgets.Sparkline
 		sl4 *widgets.Sparkline
+		bf  *widgets.Paragraph
+		gh  *widgets.BarChart
 		b1  *widgets.List
 		b2  *widgets.List
+
+		// panes are the sparkline, base fee, and gas panes by name.
+		panes map[string]ui.Drawable
 	}
 	monitorMode int
 )
@@ -146,6 +184,10 @@ func prependLatestBlocks(ctx context.Context, ms *monitorStatus, rpc *ethrpc.Cli
 }
 
 func appendOlderBlocks(ctx context.Context, ms *monitorStatus, rpc *ethrpc.Client) {
+	// There's nothing to fetch when replaying a recording.
+	if rpc == nil {
+		return
+	}
 	to := new(big.Int).Sub(ms.MinBlockRetrieved, one)
 	from := new(big.Int).Sub(to, big.NewInt(int64(batchSize-1)))
 	if from.Cmp(zero) < 0 {
@@ -180,10 +222,8 @@ func fetchBlocks(ctx context.Context, ec *ethclient.Client, ms *monitorStatus, r
 		batchSize = 50
 	}
 
-	ms.HeadBlock = new(big.Int).SetUint64(cs.HeadBlock)
-	ms.ChainID = cs.ChainID
-	ms.PeerCount = cs.PeerCount
-	ms.GasPrice = cs.GasPrice
+	rec.Record(recordEvent{ChainState: cs})
+	ms.setChainState(cs)
 
 	prependLatestBlocks(ctx, ms, rpc)
 	appendOlderBlocks(ctx, ms, rpc)
@@ -191,13 +231,64 @@ func fetchBlocks(ctx context.Context, ec *ethclient.Client, ms *monitorStatus, r
 	return
 }
 
+func (ms *monitorStatus) setChainState(cs *chainState) {
+	ms.HeadBlock = new(big.Int).SetUint64(cs.HeadBlock)
+	ms.ChainID = cs.ChainID
+	ms.PeerCount = cs.PeerCount
+	ms.GasPrice = cs.GasPrice
+
+	sink.Emit(metrics.Point{
+		Name: "monitor_chain",
+		Fields: map[string]float64{
+			"head_block": float64(cs.HeadBlock),
+			"peer_count": float64(cs.PeerCount),
+			"gas_price":  float64(cs.GasPrice.Uint64()),
+		},
+	})
+}
+
 // monitorCmd represents the monitor command
 var MonitorCmd = &cobra.Command{
 	Use:   "monitor url",
 	Short: "Monitor blocks using a JSON-RPC endpoint.",
 	Long:  usage,
-	Args:  cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if replayFile != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		if err := parseLayout(); err != nil {
+			return err
+		}
+		if replayFile != "" {
+			if recordFile != "" {
+				return fmt.Errorf("record and replay can't be used together")
+			}
+			if once {
+				return fmt.Errorf("once and replay can't be used together")
+			}
+			if replaySpeed <= 0 {
+				return fmt.Errorf("replay-speed must be greater than zero")
+			}
+		}
+		if onceJSON && !once {
+			return fmt.Errorf("json can only be used with once")
+		}
+		if (maxHeadAge > 0 || minPeers > 0) && !once {
+			return fmt.Errorf("max-head-age and min-peers can only be used with once")
+		}
+		if gasHistoryBlocks <= 0 {
+			return fmt.Errorf("gas-history must be greater than zero")
+		}
+		if replayFile != "" {
+			return nil
+		}
+
 		// validate url argument
 		_, err := url.Parse(args[0])
 		if err != nil {
@@ -209,6 +300,22 @@ var MonitorCmd = &cobra.Command{
 			return err
 		}
 
+		if sinkURL != "" {
+			sinkInterval, err := time.ParseDuration(sinkIntervalStr)
+			if err != nil {
+				return err
+			}
+			sink, err = metrics.NewSink(metrics.SinkOptions{
+				URL:      sinkURL,
+				Format:   sinkFormat,
+				Interval: sinkInterval,
+				Tags:     map[string]string{"rpc": args[0]},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
 		// validate batch-size flag
 		if batchSizeValue == "auto" {
 			batchSize = -1
@@ -226,45 +333,71 @@ var MonitorCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
 		ctx := cmd.Context()
 
-		rpc, err := ethrpc.DialContext(ctx, args[0])
+		if replayFile != "" {
+			return replayMonitor(ctx)
+		}
+
+		if recordFile != "" {
+			var err error
+			if rec, err = newRecorder(recordFile); err != nil {
+				return err
+			}
+			defer rec.Close()
+		}
+
+		rpc, err := util.DialRPC(ctx, args[0])
 		if err != nil {
 			log.Error().Err(err).Msg("Unable to dial rpc")
-			return err
+			return util.UnreachableError(err)
 		}
 		ec := ethclient.NewClient(rpc)
 
-		ms := new(monitorStatus)
+		if once {
+			return runOnce(ctx, ec, rpc)
+		}
+
+		ms := newMonitorStatus()
 
-		ms.MaxBlockRetrieved = big.NewInt(0)
-		ms.BlocksLock.Lock()
-		ms.Blocks = make(map[string]rpctypes.PolyBlock, 0)
-		ms.BlocksLock.Unlock()
-		ms.ChainID = big.NewInt(0)
+		sinkDone := make(chan struct{})
+		go func() {
+			defer close(sinkDone)
+			sink.Run(ctx)
+		}()
 
 		isUiRendered := false
 		errChan := make(chan error)
 		go func() {
 			for {
 				err = fetchBlocks(ctx, ec, ms, rpc, isUiRendered)
-				if err != nil {
-					continue
-				}
-
-				if !isUiRendered {
+				if err == nil && !isUiRendered {
 					go func() {
 						errChan <- renderMonitorUI(ctx, ec, ms, rpc)
 					}()
 					isUiRendered = true
 				}
 
-				time.Sleep(interval)
+				select {
+				case <-ctx.Done():
+					// The UI returns on its own once the context is
+					// cancelled.
+					if !isUiRendered {
+						errChan <- nil
+					}
+					return
+				case <-time.After(interval):
+				}
 			}
 		}()
 
 		err = <-errChan
-		return err
+		if err != nil {
+			return err
+		}
+		<-sinkDone
+		return nil
 	},
 }
 
@@ -294,26 +427,84 @@ func (ms *monitorStatus) getBlockRange(ctx context.Context, from, to *big.Int, r
 		if b.Error != nil {
 			return b.Error
 		}
-		pb := rpctypes.NewPolyBlock(b.Result.(*rpctypes.RawBlockResponse))
+		raw := b.Result.(*rpctypes.RawBlockResponse)
+		rec.Record(recordEvent{Block: raw})
+		ms.addBlock(raw)
+	}
 
-		ms.BlocksLock.Lock()
-		ms.Blocks[pb.Number().String()] = pb
-		ms.BlocksLock.Unlock()
+	return nil
+}
 
-		if ms.MaxBlockRetrieved.Cmp(pb.Number()) == -1 {
-			ms.MaxBlockRetrieved = pb.Number()
+func newMonitorStatus() *monitorStatus {
+	ms := new(monitorStatus)
+
+	ms.MaxBlockRetrieved = big.NewInt(0)
+	ms.BlocksLock.Lock()
+	ms.Blocks = make(map[string]rpctypes.PolyBlock, 0)
+	ms.BlocksLock.Unlock()
+	ms.ChainID = big.NewInt(0)
+	return ms
+}
+
+func (ms *monitorStatus) addBlock(raw *rpctypes.RawBlockResponse) {
+	pb := rpctypes.NewPolyBlock(raw)
+
+	ms.BlocksLock.Lock()
+	ms.Blocks[pb.Number().String()] = pb
+	parent, hasParent := ms.Blocks[new(big.Int).Sub(pb.Number(), one).String()]
+	ms.BlocksLock.Unlock()
+
+	// Only new blocks are emitted, the older blocks fetched to fill the
+	// UI would be out of order.
+	if sink != nil && pb.Number().Cmp(ms.MaxBlockRetrieved) == 1 {
+		fields := map[string]float64{
+			"number":       float64(pb.Number().Uint64()),
+			"transactions": float64(len(pb.Transactions())),
+			"gas_used":     float64(pb.GasUsed()),
+			"gas_limit":    float64(pb.GasLimit()),
+			"size":         float64(pb.Size()),
+		}
+		if baseFee := pb.BaseFee(); baseFee != nil {
+			fields["base_fee"] = float64(baseFee.Uint64())
 		}
-		if ms.MinBlockRetrieved == nil || (ms.MinBlockRetrieved.Cmp(pb.Number()) == 1 && pb.Number().Cmp(zero) == 1) {
-			ms.MinBlockRetrieved = pb.Number()
+		if hasParent && pb.Time() >= parent.Time() {
+			fields["block_interval"] = float64(pb.Time() - parent.Time())
 		}
+		sink.Emit(metrics.Point{
+			Name:   "monitor_block",
+			Fields: fields,
+			Time:   time.Unix(int64(pb.Time()), 0),
+		})
 	}
 
-	return nil
+	if ms.MaxBlockRetrieved.Cmp(pb.Number()) == -1 {
+		ms.MaxBlockRetrieved = pb.Number()
+	}
+	if ms.MinBlockRetrieved == nil || (ms.MinBlockRetrieved.Cmp(pb.Number()) == 1 && pb.Number().Cmp(zero) == 1) {
+		ms.MinBlockRetrieved = pb.Number()
+	}
 }
 
 func init() {
 	MonitorCmd.PersistentFlags().StringVarP(&batchSizeValue, "batch-size", "b", "auto", "Number of requests per batch")
 	MonitorCmd.PersistentFlags().StringVarP(&intervalStr, "interval", "i", "5s", "Amount of time between batch block rpc calls")
+	MonitorCmd.PersistentFlags().StringVar(&sinkURL, "metrics-sink", "", "URL of a time-series database write endpoint to store the block metrics in")
+	MonitorCmd.PersistentFlags().StringVar(&sinkFormat, "metrics-sink-format", metrics.SinkFormatInflux, "The format of the metrics sink, either influx or remote-write")
+	MonitorCmd.PersistentFlags().StringVar(&sinkIntervalStr, "metrics-sink-interval", "10s", "How often the metrics are written to the sink")
+	MonitorCmd.PersistentFlags().IntVar(&gasHistoryBlocks, "gas-history", 100, "Number of latest blocks in the gas used histogram")
+	MonitorCmd.PersistentFlags().Uint64Var(&baseFeeChangeDenominator, "base-fee-change-denominator", metrics.DefaultBaseFeeChangeDenominator, "EIP-1559 base fee change denominator of the chain that the base fee projection uses, also set by --network")
+	MonitorCmd.PersistentFlags().StringVar(&recordFile, "record", "", "File to record everything the monitor fetches in, to replay the session later")
+	MonitorCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "File of a recorded session to replay instead of connecting to an rpc")
+	MonitorCmd.PersistentFlags().Float64Var(&replaySpeed, "replay-speed", 1, "How much faster than recorded the session is replayed")
+	MonitorCmd.PersistentFlags().StringSliceVar(&columns, "columns", metrics.DefaultBlockColumns, fmt.Sprintf("Columns of the block table in order (%s)", strings.Join(metrics.BlockColumnNames(), "|")))
+	MonitorCmd.PersistentFlags().StringSliceVar(&shownPaneNames, "panes", panes, "Panes to show, the rest are hidden to make room for the block table")
+	MonitorCmd.PersistentFlags().StringVar(&sortOrder, "sort", sortNewest, "Order of the block table, either newest or oldest block first")
+	MonitorCmd.PersistentFlags().BoolVar(&once, "once", false, "Fetch a single round of data, print a summary, and exit instead of starting the UI")
+	MonitorCmd.PersistentFlags().BoolVar(&onceJSON, "json", false, "Print the summary of --once as JSON")
+	MonitorCmd.PersistentFlags().DurationVar(&maxHeadAge, "max-head-age", 0, "Exit with the threshold exit code if the head block of --once is older than this (0 for no threshold)")
+	MonitorCmd.PersistentFlags().Uint64Var(&minPeers, "min-peers", 0, "Exit with the threshold exit code if the node of --once has fewer peers than this (0 for no threshold)")
+	MonitorCmd.PersistentFlags().StringToStringVar(&refreshIntervals, "refresh", nil, "How often panes are updated, for example stats=1s,blocks=10s (default on every new block)")
+	_ = MonitorCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions([]string{sortNewest, sortOldest}, cobra.ShellCompDirectiveNoFileComp))
 }
 
 func setUISkeleton() (blockTable *widgets.List, grid *ui.Grid, blockGrid *ui.Grid, termUi uiSkeleton) {
@@ -361,6 +552,26 @@ func setUISkeleton() (blockTable *widgets.List, grid *ui.Grid, blockGrid *ui.Gri
 	slg4 := widgets.NewSparklineGroup(termUi.sl4)
 	slg4.Title = "Gas Used"
 
+	termUi.bf = widgets.NewParagraph()
+	termUi.bf.Title = "Base Fee Projection"
+
+	termUi.gh = widgets.NewBarChart()
+	termUi.gh.Title = "Gas Used / Limit"
+	termUi.gh.BarColors = []ui.Color{ui.ColorCyan}
+	termUi.gh.LabelStyles = []ui.Style{ui.NewStyle(ui.ColorWhite)}
+	termUi.gh.NumStyles = []ui.Style{ui.NewStyle(ui.ColorBlack)}
+	termUi.gh.NumFormatter = func(v float64) string { return fmt.Sprintf("%.0f", v) }
+
+	termUi.panes = map[string]ui.Drawable{
+		paneTxs:          slg0,
+		paneGasPrice:     slg1,
+		paneSize:         slg2,
+		paneUncles:       slg3,
+		paneGasUsed:      slg4,
+		paneBaseFee:      termUi.bf,
+		paneGasHistogram: termUi.gh,
+	}
+
 	grid = ui.NewGrid()
 	blockGrid = ui.NewGrid()
 
@@ -387,25 +598,6 @@ func setUISkeleton() (blockTable *widgets.List, grid *ui.Grid, blockGrid *ui.Gri
 		),
 	)
 
-	grid.Set(
-		ui.NewRow(1.0/10,
-			ui.NewCol(1.0/5, termUi.h0),
-			ui.NewCol(1.0/5, termUi.h1),
-			ui.NewCol(1.0/5, termUi.h2),
-			ui.NewCol(1.0/5, termUi.h3),
-			ui.NewCol(1.0/5, termUi.h4),
-		),
-
-		ui.NewRow(4.0/10,
-			ui.NewCol(1.0/5, slg0),
-			ui.NewCol(1.0/5, slg1),
-			ui.NewCol(1.0/5, slg2),
-			ui.NewCol(1.0/5, slg3),
-			ui.NewCol(1.0/5, slg4),
-		),
-		ui.NewRow(5.0/10, blockTable),
-	)
-
 	return
 }
 
@@ -433,17 +625,28 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 	currentMode := monitorModeExplorer
 
 	blockTable, grid, blockGrid, termUi := setUISkeleton()
+	blocksHeight := setExplorerGrid(grid, blockTable, termUi)
 
 	termWidth, termHeight := ui.TerminalDimensions()
-	windowSize = termHeight/2 - 4
+	windowSize = getWindowSize(termHeight, blocksHeight)
 	grid.SetRect(0, 0, termWidth, termHeight)
 	blockGrid.SetRect(0, 0, termWidth, termHeight)
 
+	// The names are resolved in the background, so the views show them from
+	// a redraw after the block is opened on.
+	var namer metrics.AddressNamer
+	if util.ResolveNames() && rpc != nil {
+		names := util.NewNames(rpc)
+		go names.Run(ctx)
+		namer = names.Label
+	}
+
 	var selectedBlock rpctypes.PolyBlock
 	var setBlock = false
 	var allBlocks metrics.SortableBlocks
 	var renderedBlocks metrics.SortableBlocks
 	windowOffset := 0
+	lastRefresh := make(map[string]time.Time)
 
 	redraw := func(ms *monitorStatus, force ...bool) {
 		log.Debug().Interface("ms", ms).Msg("Redrawing")
@@ -452,15 +655,16 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 			// TODO add some help context?
 		} else if currentMode == monitorModeBlock {
 			// render a block
-			termUi.b1.Rows = metrics.GetSimpleBlockFields(selectedBlock)
-			termUi.b2.Rows = metrics.GetSimpleBlockTxFields(selectedBlock, ms.ChainID)
+			termUi.b1.Rows = metrics.GetSimpleBlockFields(selectedBlock, namer)
+			termUi.b2.Rows = metrics.GetSimpleBlockTxFields(selectedBlock, ms.ChainID, namer)
 
 			ui.Clear()
 			ui.Render(blockGrid)
 			return
 		}
 
-		if blockTable.SelectedRow == 0 || len(force) > 0 && force[0] {
+		now := time.Now()
+		if len(force) > 0 && force[0] || blockTable.SelectedRow == 0 && refreshDue(paneBlocks, lastRefresh, now) {
 			allBlocks = updateAllBlocks(ms)
 			sort.Sort(allBlocks)
 		}
@@ -471,21 +675,50 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 		end := len(allBlocks) - windowOffset
 		renderedBlocks = allBlocks[start:end]
 
-		termUi.h0.Text = fmt.Sprintf("Height: %s\nTime: %s", ms.HeadBlock.String(), time.Now().Format("02 Jan 06 15:04:05 MST"))
-		gasGwei := new(big.Int).Div(ms.GasPrice, metrics.UnitShannon)
-		termUi.h1.Text = fmt.Sprintf("%s gwei", gasGwei.String())
-		termUi.h2.Text = fmt.Sprintf("%d", ms.PeerCount)
-		termUi.h3.Text = ms.ChainID.String()
-		termUi.h4.Text = fmt.Sprintf("%0.2f", metrics.GetMeanBlockTime(renderedBlocks))
+		if refreshDue(paneStats, lastRefresh, now) {
+			termUi.h0.Text = fmt.Sprintf("Height: %s\nTime: %s", ms.HeadBlock.String(), now.Format("02 Jan 06 15:04:05 MST"))
+			gasGwei := new(big.Int).Div(ms.GasPrice, metrics.UnitShannon)
+			termUi.h1.Text = fmt.Sprintf("%s gwei", gasGwei.String())
+			termUi.h2.Text = fmt.Sprintf("%d", ms.PeerCount)
+			termUi.h3.Text = ms.ChainID.String()
+			termUi.h4.Text = fmt.Sprintf("%0.2f", metrics.GetMeanBlockTime(renderedBlocks))
+		}
+
+		if refreshDue(paneTxs, lastRefresh, now) {
+			termUi.sl0.Data = metrics.GetTxsPerBlock(renderedBlocks)
+		}
+		if refreshDue(paneGasPrice, lastRefresh, now) {
+			termUi.sl1.Data = metrics.GetMeanGasPricePerBlock(renderedBlocks)
+		}
+		if refreshDue(paneSize, lastRefresh, now) {
+			termUi.sl2.Data = metrics.GetSizePerBlock(renderedBlocks)
+		}
+		if refreshDue(paneUncles, lastRefresh, now) {
+			termUi.sl3.Data = metrics.GetUnclesPerBlock(renderedBlocks)
+		}
+		if refreshDue(paneGasUsed, lastRefresh, now) {
+			termUi.sl4.Data = metrics.GetGasPerBlock(renderedBlocks)
+		}
 
-		termUi.sl0.Data = metrics.GetTxsPerBlock(renderedBlocks)
-		termUi.sl1.Data = metrics.GetMeanGasPricePerBlock(renderedBlocks)
-		termUi.sl2.Data = metrics.GetSizePerBlock(renderedBlocks)
-		termUi.sl3.Data = metrics.GetUnclesPerBlock(renderedBlocks)
-		termUi.sl4.Data = metrics.GetGasPerBlock(renderedBlocks)
+		if refreshDue(paneBaseFee, lastRefresh, now) {
+			termUi.bf.Text = getBaseFeeProjection(allBlocks)
+		}
+		if refreshDue(paneGasHistogram, lastRefresh, now) {
+			historyStart := len(allBlocks) - gasHistoryBlocks
+			if historyStart < 0 {
+				historyStart = 0
+			}
+			termUi.gh.Data, termUi.gh.Labels = metrics.GetGasUsedHistogram(allBlocks[historyStart:], gasHistogramBuckets)
+			termUi.gh.BarWidth = max(1, termUi.gh.Inner.Dx()/gasHistogramBuckets-termUi.gh.BarGap)
+		}
 
 		// If a row has not been selected, continue to update the list with new blocks.
-		rows, title := metrics.GetSimpleBlockRecords(renderedBlocks)
+		rows, title := metrics.GetBlockRecords(renderedBlocks, columns)
+		if sortOrder == sortOldest {
+			for i, j := 1, len(rows)-1; i < j; i, j = i+1, j-1 {
+				rows[i], rows[j] = rows[j], rows[i]
+			}
+		}
 		blockTable.Rows = rows
 		blockTable.Title = title
 
@@ -500,7 +733,14 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 			}
 		}
 
+		// The selected row counts from the newest block, so it's flipped
+		// while the table is drawn oldest block first.
+		selectedRow := blockTable.SelectedRow
+		if sortOrder == sortOldest && selectedRow > 0 {
+			blockTable.SelectedRow = len(blockTable.Rows) - selectedRow
+		}
 		ui.Render(grid)
+		blockTable.SelectedRow = selectedRow
 	}
 
 	currentBn := ms.HeadBlock
@@ -515,6 +755,7 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 		forceRedraw := false
 		select {
 		case e := <-uiEvents:
+			e = translateEvent(e, blockTable, currentMode)
 			switch e.ID {
 			case "q", "<C-c>":
 				return nil
@@ -526,12 +767,28 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 				if blockTable.SelectedRow > 0 {
 					currentMode = monitorModeBlock
 				}
+			case "<MouseLeft>":
+				// Clicking a row selects the block, and clicking it again
+				// opens it.
+				row := e.Payload.(ui.Mouse).Y - blockTable.Inner.Min.Y
+				if sortOrder == sortOldest {
+					row = len(blockTable.Rows) - row
+				}
+				if row < 1 || row > len(renderedBlocks) {
+					break
+				}
+				if blockTable.SelectedRow == row {
+					currentMode = monitorModeBlock
+					break
+				}
+				blockTable.SelectedRow = row
+				setBlock = true
 			case "<Resize>":
 				payload := e.Payload.(ui.Resize)
 				grid.SetRect(0, 0, payload.Width, payload.Height)
 				blockGrid.SetRect(0, 0, payload.Width, payload.Height)
 				_, termHeight = ui.TerminalDimensions()
-				windowSize = termHeight/2 - 4
+				windowSize = getWindowSize(termHeight, blocksHeight)
 				ui.Clear()
 			case "<Up>", "<Down>":
 				if currentMode == monitorModeBlock {
@@ -642,15 +899,78 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 			if !forceRedraw {
 				redraw(ms)
 			}
+		case <-ctx.Done():
+			return nil
 		case <-ticker:
 			if currentBn != ms.HeadBlock {
 				currentBn = ms.HeadBlock
 				redraw(ms)
+			} else if currentMode == monitorModeExplorer && anyRefreshDue(lastRefresh, time.Now()) {
+				redraw(ms)
 			}
 		}
 	}
 }
 
+// translateEvent maps the mouse wheel to the arrow keys and, when the block
+// table is sorted oldest block first, flips the direction of the keys so that
+// they move the selection the way they point. Clicks outside of the block
+// table are dropped.
+func translateEvent(e ui.Event, blockTable *widgets.List, mode monitorMode) ui.Event {
+	switch e.ID {
+	case "<MouseWheelUp>":
+		e.ID = "<Up>"
+	case "<MouseWheelDown>":
+		e.ID = "<Down>"
+	case "<MouseLeft>":
+		m := e.Payload.(ui.Mouse)
+		if mode != monitorModeExplorer || !image.Pt(m.X, m.Y).In(blockTable.Inner) {
+			e.ID = "<MouseIgnored>"
+		}
+		return e
+	}
+	if mode != monitorModeExplorer || sortOrder != sortOldest {
+		return e
+	}
+	switch e.ID {
+	case "<Up>":
+		e.ID = "<Down>"
+	case "<Down>":
+		e.ID = "<Up>"
+	case "<PageUp>", "<C-b>":
+		e.ID = "<PageDown>"
+	case "<PageDown>", "<C-f>":
+		e.ID = "<PageUp>"
+	}
+	return e
+}
+
+// getBaseFeeProjection describes the base fee of the next blocks if they're as
+// full as the latest block.
+func getBaseFeeProjection(blocks []rpctypes.PolyBlock) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	latest := blocks[len(blocks)-1]
+	projected := metrics.ProjectBaseFees(latest, projectedBlocks, baseFeeChangeDenominator)
+	if len(projected) == 0 {
+		return "No base fee"
+	}
+
+	text := fmt.Sprintf("Current: %s\nFullness: %0.1f%%", formatGwei(latest.BaseFee()), float64(latest.GasUsed())/float64(latest.GasLimit())*100)
+	for i, baseFee := range projected {
+		change := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Sub(baseFee, latest.BaseFee())), new(big.Float).SetInt(latest.BaseFee()))
+		f, _ := change.Float64()
+		text += fmt.Sprintf("\n+%d: %s (%+0.1f%%)", i+1, formatGwei(baseFee), f*100)
+	}
+	return text
+}
+
+func formatGwei(wei *big.Int) string {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), new(big.Float).SetInt(metrics.UnitShannon)).Float64()
+	return fmt.Sprintf("%0.2f gwei", gwei)
+}
+
 func max(nums ...int) int {
 	m := nums[0]
 	for _, n := range nums {
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+)
+
+// snapshot summarizes a single round of monitor data.
+type snapshot struct {
+	ChainID           string   `json:"chainId"`
+	HeadBlock         uint64   `json:"headBlock"`
+	HeadBlockHash     string   `json:"headBlockHash"`
+	HeadBlockAge      int64    `json:"headBlockAgeSeconds"`
+	PeerCount         uint64   `json:"peerCount"`
+	GasPrice          string   `json:"gasPrice"`
+	FromBlock         uint64   `json:"fromBlock"`
+	ToBlock           uint64   `json:"toBlock"`
+	Blocks            int      `json:"blocks"`
+	MeanBlockTime     float64  `json:"meanBlockTime"`
+	MeanTxsPerBlock   float64  `json:"meanTxsPerBlock"`
+	MeanGasUsedRatio  float64  `json:"meanGasUsedRatio"`
+	BaseFee           string   `json:"baseFee,omitempty"`
+	ProjectedBaseFees []string `json:"projectedBaseFees,omitempty"`
+}
+
+// runOnce fetches a single round of data, prints its summary, and flushes the
+// metrics sink.
+func runOnce(ctx context.Context, ec *ethclient.Client, rpc *ethrpc.Client) error {
+	ms := newMonitorStatus()
+	if err := fetchBlocks(ctx, ec, ms, rpc, false); err != nil {
+		return util.UnreachableError(err)
+	}
+
+	blocks := metrics.SortableBlocks(updateAllBlocks(ms))
+	if len(blocks) == 0 {
+		return util.UnreachableError(fmt.Errorf("unable to fetch any blocks"))
+	}
+	sort.Sort(blocks)
+	latest := blocks[len(blocks)-1]
+
+	s := snapshot{
+		ChainID:       ms.ChainID.String(),
+		HeadBlock:     ms.HeadBlock.Uint64(),
+		HeadBlockHash: latest.Hash().Hex(),
+		HeadBlockAge:  time.Now().Unix() - int64(latest.Time()),
+		PeerCount:     ms.PeerCount,
+		GasPrice:      ms.GasPrice.String(),
+		FromBlock:     blocks[0].Number().Uint64(),
+		ToBlock:       latest.Number().Uint64(),
+		Blocks:        len(blocks),
+		MeanBlockTime: metrics.GetMeanBlockTime(blocks),
+	}
+	var txs int
+	var gasUsedRatio float64
+	for _, block := range blocks {
+		txs += len(block.Transactions())
+		if block.GasLimit() > 0 {
+			gasUsedRatio += float64(block.GasUsed()) / float64(block.GasLimit())
+		}
+	}
+	s.MeanTxsPerBlock = float64(txs) / float64(len(blocks))
+	s.MeanGasUsedRatio = gasUsedRatio / float64(len(blocks))
+	projected := metrics.ProjectBaseFees(latest, projectedBlocks, baseFeeChangeDenominator)
+	if baseFee := latest.BaseFee(); baseFee != nil {
+		s.BaseFee = baseFee.String()
+		for _, fee := range projected {
+			s.ProjectedBaseFees = append(s.ProjectedBaseFees, fee.String())
+		}
+	}
+
+	if err := sink.Flush(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to write metrics")
+	}
+
+	if onceJSON {
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		printSnapshot(s, ms.GasPrice, latest.BaseFee(), projected)
+	}
+	return checkThresholds(s)
+}
+
+// checkThresholds returns a threshold error if the snapshot breached
+// --max-head-age or --min-peers.
+func checkThresholds(s snapshot) error {
+	if age := time.Duration(s.HeadBlockAge) * time.Second; maxHeadAge > 0 && age > maxHeadAge {
+		return util.ThresholdError("the head block is %s old, more than %s", age, maxHeadAge)
+	}
+	if minPeers > 0 && s.PeerCount < minPeers {
+		return util.ThresholdError("the node has %d peers, fewer than %d", s.PeerCount, minPeers)
+	}
+	return nil
+}
+
+func printSnapshot(s snapshot, gasPrice, baseFee *big.Int, projected []*big.Int) {
+	w := os.Stdout
+	fmt.Fprintf(w, "Chain ID:        %s\n", s.ChainID)
+	fmt.Fprintf(w, "Head block:      %d (%ds ago)\n", s.HeadBlock, s.HeadBlockAge)
+	fmt.Fprintf(w, "Peers:           %d\n", s.PeerCount)
+	fmt.Fprintf(w, "Gas price:       %s\n", formatGwei(gasPrice))
+	fmt.Fprintf(w, "Blocks:          %d (%d to %d)\n", s.Blocks, s.FromBlock, s.ToBlock)
+	fmt.Fprintf(w, "Avg block time:  %0.2fs\n", s.MeanBlockTime)
+	fmt.Fprintf(w, "Avg txs / block: %0.2f\n", s.MeanTxsPerBlock)
+	fmt.Fprintf(w, "Avg gas used:    %0.1f%%\n", s.MeanGasUsedRatio*100)
+	if baseFee == nil {
+		return
+	}
+	next := make([]string, 0, len(projected))
+	for i, fee := range projected {
+		next = append(next, fmt.Sprintf("+%d: %s", i+1, formatGwei(fee)))
+	}
+	fmt.Fprintf(w, "Base fee:        %s (%s)\n", formatGwei(baseFee), strings.Join(next, ", "))
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	// recordEvent is a line of a recording. It has either the chain state or
+	// a block that was fetched at the time.
+	recordEvent struct {
+		Time       time.Time                  `json:"time"`
+		ChainState *chainState                `json:"chainState,omitempty"`
+		Block      *rpctypes.RawBlockResponse `json:"block,omitempty"`
+	}
+
+	// recorder persists everything the monitor fetches so that the session
+	// can be replayed.
+	recorder struct {
+		mu  sync.Mutex
+		f   *os.File
+		enc *json.Encoder
+	}
+)
+
+func newRecorder(path string) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create recording: %w", err)
+	}
+	return &recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record appends the event to the recording. Failures are logged rather than
+// interrupting the monitor.
+func (r *recorder) Record(ev recordEvent) {
+	if r == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(ev); err != nil {
+		log.Error().Err(err).Msg("Unable to write to the recording")
+	}
+}
+
+func (r *recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// readRecording reads all the events of a recording.
+func readRecording(path string) ([]recordEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording: %w", err)
+	}
+	defer f.Close()
+
+	var events []recordEvent
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var ev recordEvent
+		err := dec.Decode(&ev)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read event %d of the recording: %w", len(events), err)
+		}
+		events = append(events, ev)
+	}
+
+	for _, ev := range events {
+		if ev.ChainState != nil {
+			return events, nil
+		}
+	}
+	return nil, fmt.Errorf("the recording has no chain state")
+}
+
+// replayEvents applies the events to the monitor status, waiting between
+// them as long as they were apart when recorded divided by the speed. It
+// calls onReady once the first chain state has been applied, which is when
+// the UI can be rendered.
+func replayEvents(ctx context.Context, ms *monitorStatus, events []recordEvent, speed float64, onReady func()) {
+	ready := false
+	for i, ev := range events {
+		if i > 0 && ready {
+			wait := time.Duration(float64(ev.Time.Sub(events[i-1].Time)) / speed)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		if ev.Block != nil {
+			ms.addBlock(ev.Block)
+		}
+		if ev.ChainState != nil {
+			ms.setChainState(ev.ChainState)
+			if !ready {
+				ready = true
+				onReady()
+			}
+		}
+	}
+	log.Debug().Int("events", len(events)).Msg("Finished replaying the recording")
+}
+
+// replayMonitor renders a recorded session in the same UI as a live one.
+func replayMonitor(ctx context.Context) error {
+	events, err := readRecording(replayFile)
+	if err != nil {
+		return err
+	}
+
+	ms := newMonitorStatus()
+	errChan := make(chan error, 1)
+	go replayEvents(ctx, ms, events, replaySpeed, func() {
+		go func() {
+			errChan <- renderMonitorUI(ctx, nil, ms, nil)
+		}()
+	})
+	return <-errChan
+}
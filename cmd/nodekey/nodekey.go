@@ -18,6 +18,7 @@ package nodekey
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/binary"
@@ -184,12 +185,17 @@ func keyTypeToInt(keyType string) (int, error) {
 	}
 }
 
-func generateDevp2pNodeKey() (nodeKeyOut, error) {
-	nodeKey, err := gethcrypto.GenerateKey()
-
-	if *inputNodeKeyFile != "" {
-		nodeKey, err = gethcrypto.LoadECDSA(*inputNodeKeyFile)
+// NewDevp2pKey generates a devp2p node key, or loads it from the file if one
+// is given. The file has the private key in hex format.
+func NewDevp2pKey(file string) (*ecdsa.PrivateKey, error) {
+	if file != "" {
+		return gethcrypto.LoadECDSA(file)
 	}
+	return gethcrypto.GenerateKey()
+}
+
+func generateDevp2pNodeKey() (nodeKeyOut, error) {
+	nodeKey, err := NewDevp2pKey(*inputNodeKeyFile)
 	if err != nil {
 		return nodeKeyOut{}, fmt.Errorf("could not generate key: %w", err)
 	}
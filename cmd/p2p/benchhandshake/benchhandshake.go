@@ -0,0 +1,390 @@
+package benchhandshake
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	phaseDial       = "tcp dial"
+	phaseEncryption = "ecies handshake"
+	phaseLocal      = "ecies handshake (local)"
+	phaseHello      = "hello"
+	phaseStatus     = "status"
+	phaseTotal      = "total"
+
+	// maxFrameSize is the largest message an RLPx frame can hold.
+	maxFrameSize = 0xffffff
+)
+
+type (
+	benchParams struct {
+		Enode     string
+		Count     int
+		Interval  time.Duration
+		Timeout   time.Duration
+		FrameSize int
+		JSON      bool
+	}
+
+	// phaseStats summarizes the latencies of a phase in milliseconds.
+	phaseStats struct {
+		Phase   string  `json:"phase"`
+		Samples int     `json:"samples"`
+		Min     float64 `json:"minMs,omitempty"`
+		Avg     float64 `json:"avgMs,omitempty"`
+		P50     float64 `json:"p50Ms,omitempty"`
+		P95     float64 `json:"p95Ms,omitempty"`
+		P99     float64 `json:"p99Ms,omitempty"`
+		Max     float64 `json:"maxMs,omitempty"`
+	}
+
+	// frameStats is the throughput of encrypting and decrypting RLPx frames
+	// in memory.
+	frameStats struct {
+		Size         int     `json:"size"`
+		Frames       int     `json:"frames"`
+		MiBPerSecond float64 `json:"mibPerSecond"`
+	}
+
+	benchResult struct {
+		Node     string         `json:"node"`
+		Attempts int            `json:"attempts"`
+		Failures int            `json:"failures"`
+		Errors   map[string]int `json:"errors,omitempty"`
+		Phases   []phaseStats   `json:"phases"`
+		Frames   *frameStats    `json:"frames,omitempty"`
+	}
+)
+
+var inputBenchParams benchParams
+
+// BenchHandshakeCmd measures how long the phases of peering with a node take.
+var BenchHandshakeCmd = &cobra.Command{
+	Use:   "bench-handshake",
+	Short: "Measure the latency of the TCP dial, encryption handshake, Hello, and Status exchange with a node.",
+	Long: `Peer with the node --count times and measure every phase of establishing the
+connection separately, to tell whether a slow peer is slow because of the
+network, the cryptography, or the client:
+
+  tcp dial                 the TCP connection, which is about one round trip
+  ecies handshake          the RLPx encryption handshake, which is a round
+                           trip along with the key agreement on both sides
+  ecies handshake (local)  the same handshake between two connections in
+                           memory, which is the cost of the cryptography alone
+  hello                    the devp2p protocol handshake
+  status                   the eth status exchange
+
+An encryption handshake that takes much longer than the dial and the local
+handshake together points at a busy node, while a Hello or Status exchange that
+takes much longer than the dial points at the client, for example because it
+reads the chain head from a slow database.
+
+The throughput of encrypting and decrypting --frame-size messages in memory is
+reported as well, which is the cost of RLPx encryption for every message a peer
+sends.
+
+Geth drops connections from the same IP address within 30 seconds of the last
+one, unless it's on the local network, so set --interval to 30s when
+benchmarking geth nodes that aren't on the local network.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputBenchParams.Count < 1 {
+			return fmt.Errorf("count must be at least one")
+		}
+		if inputBenchParams.FrameSize < 0 || inputBenchParams.FrameSize > maxFrameSize {
+			return fmt.Errorf("frame size must be between 0 and %d", maxFrameSize)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		node, err := p2p.ParseNode(inputBenchParams.Enode)
+		if err != nil {
+			return err
+		}
+
+		result, err := bench(cmd.Context(), node)
+		if err != nil {
+			return err
+		}
+
+		if inputBenchParams.JSON {
+			out, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		} else {
+			printResult(result)
+		}
+
+		if result.Attempts > 0 && result.Failures == result.Attempts {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("unable to peer with the node in %d attempts", result.Attempts)
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := BenchHandshakeCmd.Flags()
+	flagSet.StringVar(&inputBenchParams.Enode, "enode", "", "The enode or enr of the node to benchmark.")
+	flagSet.IntVarP(&inputBenchParams.Count, "count", "c", 100, "The number of times to peer with the node.")
+	flagSet.DurationVarP(&inputBenchParams.Interval, "interval", "i", 0, "How long to wait between the attempts.")
+	flagSet.DurationVarP(&inputBenchParams.Timeout, "timeout", "t", p2p.DefaultHandshakeTimeout, "How long each phase can take before the attempt fails.")
+	flagSet.IntVar(&inputBenchParams.FrameSize, "frame-size", 1<<20, "The size of the messages encrypted in memory to measure the frame throughput. Set to 0 to skip it.")
+	flagSet.BoolVar(&inputBenchParams.JSON, "json", false, "Output the results as JSON.")
+	if err := BenchHandshakeCmd.MarkFlagRequired("enode"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark enode as required flag")
+	}
+}
+
+// bench peers with the node and measures the handshakes in memory, until the
+// count is reached or the context is cancelled.
+func bench(ctx context.Context, node *enode.Node) (*benchResult, error) {
+	client := p2p.NewClient(p2p.ClientConfig{
+		DialTimeout:      inputBenchParams.Timeout,
+		HandshakeTimeout: inputBenchParams.Timeout,
+	})
+
+	result := &benchResult{Node: node.URLv4(), Errors: make(map[string]int)}
+	samples := make(map[string][]time.Duration)
+
+	for i := 1; i <= inputBenchParams.Count; i++ {
+		if i > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(inputBenchParams.Interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		result.Attempts++
+
+		timings, err := peer(ctx, client, node)
+		for phase, d := range map[string]time.Duration{
+			phaseDial:       timings.Dial,
+			phaseEncryption: timings.Encryption,
+			phaseHello:      timings.Hello,
+			phaseStatus:     timings.Status,
+		} {
+			if d > 0 {
+				samples[phase] = append(samples[phase], d)
+			}
+		}
+		if err != nil {
+			// Attempts interrupted by the shutdown aren't failures.
+			if ctx.Err() != nil {
+				result.Attempts--
+				break
+			}
+			result.Failures++
+			result.Errors[err.Error()]++
+			log.Warn().Err(err).Int("attempt", i).Msg("Peering failed")
+			continue
+		}
+		samples[phaseTotal] = append(samples[phaseTotal], timings.Total())
+
+		log.Info().
+			Int("attempt", i).
+			Dur("dial", timings.Dial).
+			Dur("encryption", timings.Encryption).
+			Dur("hello", timings.Hello).
+			Dur("status", timings.Status).
+			Msg("Peered")
+	}
+
+	local, frames, err := benchLocal(result.Attempts)
+	if err != nil {
+		return nil, err
+	}
+	samples[phaseLocal] = local
+	result.Frames = frames
+
+	for _, phase := range []string{phaseDial, phaseEncryption, phaseLocal, phaseHello, phaseStatus, phaseTotal} {
+		result.Phases = append(result.Phases, newPhaseStats(phase, samples[phase]))
+	}
+	return result, nil
+}
+
+// peer dials the node and performs the handshakes, and returns how long the
+// phases took, including the ones before a phase failed.
+func peer(ctx context.Context, client *p2p.Client, node *enode.Node) (p2p.PeerTimings, error) {
+	conn, err := client.Dial(ctx, node)
+	if err != nil {
+		return p2p.PeerTimings{}, err
+	}
+	defer conn.Close()
+
+	_, _, err = conn.PeerContext(ctx)
+	return conn.Timings(), err
+}
+
+// benchLocal performs the encryption handshake between two connections in
+// memory count times, and measures the throughput of the frames between them.
+func benchLocal(count int) ([]time.Duration, *frameStats, error) {
+	initiatorKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	recipientKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		durations []time.Duration
+		frames    *frameStats
+	)
+	for i := 0; i < count; i++ {
+		initiator, recipient, d, err := localHandshake(initiatorKey, recipientKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("local handshake failed: %w", err)
+		}
+		durations = append(durations, d)
+
+		// The frames are only measured once, over the last connection.
+		if i == count-1 && inputBenchParams.FrameSize > 0 {
+			frames, err = benchFrames(initiator, recipient, count)
+		}
+		initiator.Close()
+		recipient.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("local frame benchmark failed: %w", err)
+		}
+	}
+	return durations, frames, nil
+}
+
+// localHandshake performs the encryption handshake between two connections over
+// an in-memory pipe, and returns how long it took the initiator.
+func localHandshake(initiatorKey, recipientKey *ecdsa.PrivateKey) (*rlpx.Conn, *rlpx.Conn, time.Duration, error) {
+	a, b := net.Pipe()
+	initiator := rlpx.NewConn(a, &recipientKey.PublicKey)
+	recipient := rlpx.NewConn(b, nil)
+
+	errCh := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		_, err := recipient.Handshake(recipientKey)
+		errCh <- err
+	}()
+	_, err := initiator.Handshake(initiatorKey)
+	d := time.Since(start)
+	if err == nil {
+		err = <-errCh
+	}
+	if err != nil {
+		initiator.Close()
+		recipient.Close()
+		return nil, nil, 0, err
+	}
+	return initiator, recipient, d, nil
+}
+
+// benchFrames writes count messages of the frame size from the initiator to
+// the recipient, and returns the throughput of encrypting and decrypting them.
+func benchFrames(initiator, recipient *rlpx.Conn, count int) (*frameStats, error) {
+	payload := make([]byte, inputBenchParams.FrameSize)
+	errCh := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		for i := 0; i < count; i++ {
+			if _, _, _, err := recipient.Read(); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- nil
+	}()
+	for i := 0; i < count; i++ {
+		if _, err := initiator.Write(0x10, payload); err != nil {
+			return nil, err
+		}
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start).Seconds()
+	return &frameStats{
+		Size:         inputBenchParams.FrameSize,
+		Frames:       count,
+		MiBPerSecond: float64(count*inputBenchParams.FrameSize) / (1 << 20) / elapsed,
+	}, nil
+}
+
+func newPhaseStats(phase string, durations []time.Duration) phaseStats {
+	stats := phaseStats{Phase: phase, Samples: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	ms := make([]float64, 0, len(durations))
+	var total float64
+	for _, d := range durations {
+		v := float64(d) / float64(time.Millisecond)
+		ms = append(ms, v)
+		total += v
+	}
+	sort.Float64s(ms)
+	percentile := func(p float64) float64 {
+		return ms[int(p*float64(len(ms)-1))]
+	}
+
+	stats.Min = ms[0]
+	stats.Avg = total / float64(len(ms))
+	stats.P50 = percentile(0.5)
+	stats.P95 = percentile(0.95)
+	stats.P99 = percentile(0.99)
+	stats.Max = ms[len(ms)-1]
+	return stats
+}
+
+func printResult(result *benchResult) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Phase", "Samples", "Min (ms)", "Avg (ms)", "P50 (ms)", "P95 (ms)", "P99 (ms)", "Max (ms)"})
+	for _, p := range result.Phases {
+		if p.Samples == 0 {
+			t.AppendRow(table.Row{p.Phase, 0, "-", "-", "-", "-", "-", "-"})
+			continue
+		}
+		t.AppendRow(table.Row{
+			p.Phase, p.Samples,
+			fmt.Sprintf("%.2f", p.Min), fmt.Sprintf("%.2f", p.Avg), fmt.Sprintf("%.2f", p.P50),
+			fmt.Sprintf("%.2f", p.P95), fmt.Sprintf("%.2f", p.P99), fmt.Sprintf("%.2f", p.Max),
+		})
+	}
+	t.Render()
+
+	if result.Frames != nil {
+		fmt.Printf("RLPx frames: %.1f MiB/s with %d messages of %d bytes\n", result.Frames.MiBPerSecond, result.Frames.Frames, result.Frames.Size)
+	}
+	fmt.Printf("Attempts: %d, failures: %d\n", result.Attempts, result.Failures)
+
+	errs := make([]string, 0, len(result.Errors))
+	for err := range result.Errors {
+		errs = append(errs, err)
+	}
+	sort.Strings(errs)
+	for _, err := range errs {
+		fmt.Printf("  %dx %s\n", result.Errors[err], err)
+	}
+}
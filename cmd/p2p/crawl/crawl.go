@@ -1,7 +1,9 @@
 package crawl
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -24,6 +26,21 @@ type (
 		Database             string
 		RevalidationInterval string
 		revalidationInterval time.Duration
+		Fingerprint          bool
+		SummaryFile          string
+		ASNFile              string
+		SybilMinIDs          int
+		SybilSubnetIDs       int
+		SybilASNShare        float64
+		IncludeCIDR          string
+		ExcludeCIDR          string
+		ipFilter             *p2p.IPFilter
+	}
+
+	// crawlSummary is written to the summary file after the crawl.
+	crawlSummary struct {
+		Nodes int         `json:"nodes"`
+		Sybil sybilReport `json:"sybil"`
 	}
 )
 
@@ -41,6 +58,12 @@ var CrawlCmd = &cobra.Command{
 	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
 		inputCrawlParams.NodesFile = args[0]
 
+		// The bootnodes are validated here rather than being marked as a
+		// required flag, so the subcommands don't inherit the requirement.
+		if inputCrawlParams.Bootnodes == "" {
+			return fmt.Errorf("at least one bootnode is required")
+		}
+
 		inputCrawlParams.timeout, err = time.ParseDuration(inputCrawlParams.Timeout)
 		if err != nil {
 			return err
@@ -51,6 +74,17 @@ var CrawlCmd = &cobra.Command{
 			return err
 		}
 
+		inputCrawlParams.ipFilter, err = p2p.ParseIPFilter(inputCrawlParams.IncludeCIDR, inputCrawlParams.ExcludeCIDR)
+		if err != nil {
+			return err
+		}
+
+		if inputStaticParams.File != "" {
+			if _, err = newStaticFilter(inputStaticParams); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -59,6 +93,13 @@ var CrawlCmd = &cobra.Command{
 			return err
 		}
 
+		var asns asnTable
+		if inputCrawlParams.ASNFile != "" {
+			if asns, err = loadASNTable(inputCrawlParams.ASNFile); err != nil {
+				return fmt.Errorf("unable to load ASN file: %w", err)
+			}
+		}
+
 		var cfg discover.Config
 		cfg.PrivateKey, _ = crypto.GenerateKey()
 		bn, err := p2p.ParseBootnodes(inputCrawlParams.Bootnodes)
@@ -66,6 +107,7 @@ var CrawlCmd = &cobra.Command{
 			return fmt.Errorf("unable to parse bootnodes: %w", err)
 		}
 		cfg.Bootnodes = bn
+		cfg.NetRestrict = inputCrawlParams.ipFilter.Netlist()
 
 		db, err := enode.OpenDB(inputCrawlParams.Database)
 		if err != nil {
@@ -89,8 +131,18 @@ var CrawlCmd = &cobra.Command{
 
 		log.Info().Msg("Starting crawl")
 
-		output := c.run(inputCrawlParams.timeout, inputCrawlParams.Threads)
-		return p2p.WriteNodesJSON(inputCrawlParams.NodesFile, output)
+		output := c.run(cmd.Context(), inputCrawlParams.timeout, inputCrawlParams.Threads)
+		classify(output)
+		if err = p2p.WriteNodesJSON(inputCrawlParams.NodesFile, output); err != nil {
+			return err
+		}
+		if inputStaticParams.File != "" {
+			if err = writeStaticNodes(inputStaticParams.File, output); err != nil {
+				return err
+			}
+		}
+
+		return writeSummary(output, asns)
 	},
 }
 
@@ -98,12 +150,94 @@ func init() {
 	CrawlCmd.PersistentFlags().StringVarP(&inputCrawlParams.Bootnodes, "bootnodes", "b", "",
 		`Comma separated nodes used for bootstrapping. At least one bootnode is
 required, so other nodes in the network can discover each other.`)
-	if err := CrawlCmd.MarkPersistentFlagRequired("bootnodes"); err != nil {
-		log.Error().Err(err).Msg("Failed to mark bootnodes as required persistent flag")
-	}
 	CrawlCmd.PersistentFlags().StringVarP(&inputCrawlParams.Timeout, "timeout", "t", "30m0s", "Time limit for the crawl.")
 	CrawlCmd.PersistentFlags().IntVarP(&inputCrawlParams.Threads, "parallel", "p", 16, "How many parallel discoveries to attempt.")
 	CrawlCmd.PersistentFlags().Uint64VarP(&inputCrawlParams.NetworkID, "network-id", "n", 0, "Filter discovered nodes by this network id.")
 	CrawlCmd.PersistentFlags().StringVarP(&inputCrawlParams.Database, "database", "d", "", "Node database for updating and storing client information.")
 	CrawlCmd.PersistentFlags().StringVarP(&inputCrawlParams.RevalidationInterval, "revalidation-interval", "r", "10m", "The amount of time it takes to retry connecting to a failed peer.")
+	CrawlCmd.PersistentFlags().BoolVar(&inputCrawlParams.Fingerprint, "fingerprint", false,
+		`Whether to peer with the nodes and probe how they respond to requests, to infer
+their client when the Hello name is spoofed or generic. The fingerprints are
+written to the nodes file.`)
+	CrawlCmd.PersistentFlags().StringVar(&inputCrawlParams.IncludeCIDR, "include-cidr", "",
+		`Comma separated networks to restrict the crawl to, for example
+10.0.0.0/8,2001:db8::/32. Nodes outside of them are neither dialed nor contacted
+by the discovery, and are removed from the nodes file.`)
+	CrawlCmd.PersistentFlags().StringVar(&inputCrawlParams.ExcludeCIDR, "exclude-cidr", "",
+		`Comma separated networks to keep the crawl away from. They take precedence
+over the included networks.`)
+	CrawlCmd.PersistentFlags().StringVar(&inputCrawlParams.SummaryFile, "summary", "",
+		"Write the crawl summary with the sybil cluster report to this file as JSON.")
+	CrawlCmd.PersistentFlags().StringVar(&inputCrawlParams.ASNFile, "asn-file", "",
+		`Tab separated IP to ASN table in the ip2asn format, such as
+ip2asn-combined.tsv from iptoasn.com, to also group the nodes by ASN.`)
+	CrawlCmd.PersistentFlags().IntVar(&inputCrawlParams.SybilMinIDs, "sybil-min-ids", 3,
+		"Flag IPs with at least this many node IDs as likely sybil clusters.")
+	CrawlCmd.PersistentFlags().IntVar(&inputCrawlParams.SybilSubnetIDs, "sybil-subnet-ids", 10,
+		"Flag subnets with at least this many node IDs as likely sybil clusters.")
+	CrawlCmd.PersistentFlags().Float64Var(&inputCrawlParams.SybilASNShare, "sybil-asn-share", 0.5,
+		"Flag ASNs with more than this share of the nodes. Zero disables flagging ASNs.")
+
+	CrawlCmd.AddCommand(DiffCmd)
+}
+
+// classify infers the clients of the fingerprinted nodes from the other nodes
+// with the same behavior.
+func classify(nodes p2p.NodeSet) {
+	classifier := p2p.NewClassifier()
+	for _, n := range nodes {
+		if n.Fingerprint != nil {
+			classifier.Observe(n.Fingerprint)
+		}
+	}
+
+	var spoofed int
+	for _, n := range nodes {
+		if n.Fingerprint == nil {
+			continue
+		}
+		classifier.Classify(n.Fingerprint)
+		if n.Fingerprint.Spoofed {
+			spoofed++
+			log.Info().
+				Str("id", n.N.ID().String()).
+				Str("name", n.Fingerprint.Name).
+				Str("inferred", n.Fingerprint.InferredClient).
+				Msg("Hello name doesn't match the behavior")
+		}
+	}
+	if spoofed > 0 {
+		log.Info().Int("spoofed", spoofed).Msg("Found nodes with spoofed Hello names")
+	}
+}
+
+// writeSummary logs the likely sybil clusters of the crawled nodes and writes
+// the summary file if one is set.
+func writeSummary(nodes p2p.NodeSet, asns asnTable) error {
+	summary := crawlSummary{
+		Nodes: len(nodes),
+		Sybil: analyzeSybil(nodes, asns, inputCrawlParams.SybilMinIDs, inputCrawlParams.SybilSubnetIDs, inputCrawlParams.SybilASNShare),
+	}
+
+	for _, c := range summary.Sybil.Clusters {
+		if !c.Flagged {
+			continue
+		}
+		log.Warn().
+			Str("kind", c.Kind).
+			Str("key", c.Key).
+			Int("nodes", c.Nodes).
+			Strs("reasons", c.Reasons).
+			Msg("Likely sybil cluster")
+	}
+	log.Info().Int("nodes", summary.Nodes).Int("flagged", summary.Sybil.Flagged).Msg("Crawl summary")
+
+	if inputCrawlParams.SummaryFile == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(summary, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(inputCrawlParams.SummaryFile, b, 0644)
 }
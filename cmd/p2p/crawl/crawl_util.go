@@ -1,6 +1,7 @@
 package crawl
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/maticnetwork/polygon-cli/p2p"
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 type crawler struct {
@@ -29,6 +31,7 @@ const (
 	nodeRemoved = iota
 	nodeSkipRecent
 	nodeSkipIncompat
+	nodeSkipFiltered
 	nodeAdded
 	nodeUpdated
 )
@@ -56,7 +59,9 @@ func newCrawler(input p2p.NodeSet, disc resolver, iters ...enode.Iterator) *craw
 	return c
 }
 
-func (c *crawler) run(timeout time.Duration, nthreads int) p2p.NodeSet {
+// run crawls until the timeout is reached after revalidating the input set, or
+// the context is cancelled, and returns the output set.
+func (c *crawler) run(ctx context.Context, timeout time.Duration, nthreads int) p2p.NodeSet {
 	var (
 		timeoutTimer = time.NewTimer(timeout)
 		timeoutCh    <-chan time.Time
@@ -73,13 +78,18 @@ func (c *crawler) run(timeout time.Duration, nthreads int) p2p.NodeSet {
 		go c.runIterator(doneCh, it)
 	}
 	var (
-		added   uint64
-		updated uint64
-		skipped uint64
-		recent  uint64
-		removed uint64
-		wg      sync.WaitGroup
+		added    uint64
+		updated  uint64
+		skipped  uint64
+		filtered uint64
+		recent   uint64
+		removed  uint64
+		wg       sync.WaitGroup
 	)
+	// The crawl runs until the timeout, so there's no total to report the
+	// progress towards, only the number of nodes that were checked.
+	progress := util.NewProgress("crawl", "nodes", 0)
+	defer progress.Done()
 	wg.Add(nthreads)
 	for i := 0; i < nthreads; i++ {
 		go func() {
@@ -87,11 +97,14 @@ func (c *crawler) run(timeout time.Duration, nthreads int) p2p.NodeSet {
 			for {
 				select {
 				case n := <-c.ch:
-					switch c.updateNode(n) {
+					progress.Add(1)
+					switch c.updateNode(ctx, n) {
 					case nodeSkipIncompat:
 						atomic.AddUint64(&skipped, 1)
 					case nodeSkipRecent:
 						atomic.AddUint64(&recent, 1)
+					case nodeSkipFiltered:
+						atomic.AddUint64(&filtered, 1)
 					case nodeRemoved:
 						atomic.AddUint64(&removed, 1)
 					case nodeAdded:
@@ -122,6 +135,9 @@ loop:
 			}
 		case <-timeoutCh:
 			break loop
+		case <-ctx.Done():
+			log.Info().Msg("Crawl interrupted, writing the nodes found so far")
+			break loop
 		case <-statusTicker.C:
 			log.Info().
 				Uint64("added", atomic.LoadUint64(&added)).
@@ -129,6 +145,7 @@ loop:
 				Uint64("removed", atomic.LoadUint64(&removed)).
 				Uint64("ignored(recent)", atomic.LoadUint64(&removed)).
 				Uint64("ignored(incompatible)", atomic.LoadUint64(&skipped)).
+				Uint64("ignored(filtered)", atomic.LoadUint64(&filtered)).
 				Msg("Crawling in progress")
 		}
 	}
@@ -157,32 +174,47 @@ func (c *crawler) runIterator(done chan<- enode.Iterator, it enode.Iterator) {
 
 // shouldSkipNode filters out nodes by their network id. If there is a status
 // message, skip nodes that don't have the correct network id. Otherwise, skip
-// nodes that are unable to peer.
-func shouldSkipNode(n *enode.Node) bool {
-	if inputCrawlParams.NetworkID == 0 {
-		return false
+// nodes that are unable to peer. With --fingerprint, the nodes are peered with
+// even without a network id and the fingerprint of the node is returned.
+func shouldSkipNode(ctx context.Context, n *enode.Node) (bool, *p2p.Fingerprint) {
+	if inputCrawlParams.NetworkID == 0 && !inputCrawlParams.Fingerprint {
+		return false, nil
 	}
 
-	conn, err := p2p.Dial(n)
+	conn, hello, status, err := p2p.NewClient(p2p.ClientConfig{}).Peer(ctx, n)
 	if err != nil {
-		log.Error().Err(err).Msg("Dial failed")
-		return true
+		log.Error().Err(err).Msg("Peer failed")
+		return inputCrawlParams.NetworkID != 0, nil
 	}
 	defer conn.Close()
 
-	hello, status, err := conn.Peer()
-	if err != nil {
-		log.Error().Err(err).Msg("Peer failed")
-		return true
+	log.Debug().Interface("hello", hello).Interface("status", status).Msg("Message received")
+	if inputCrawlParams.NetworkID != 0 && inputCrawlParams.NetworkID != status.NetworkID {
+		return true, nil
+	}
+	if !inputCrawlParams.Fingerprint {
+		return false, nil
 	}
 
-	log.Debug().Interface("hello", hello).Interface("status", status).Msg("Message received")
-	return inputCrawlParams.NetworkID != status.NetworkID
+	fp, err := conn.Fingerprint(ctx, hello, status)
+	if err != nil {
+		log.Debug().Err(err).Str("id", n.ID().String()).Msg("Fingerprinting failed")
+	}
+	return false, fp
 }
 
 // updateNode updates the info about the given node, and returns a status about
 // what changed.
-func (c *crawler) updateNode(n *enode.Node) int {
+func (c *crawler) updateNode(ctx context.Context, n *enode.Node) int {
+	// Nodes outside of the crawled networks aren't contacted, and the ones
+	// from the input set are dropped.
+	if !inputCrawlParams.ipFilter.Allowed(n.IP()) {
+		c.mu.Lock()
+		delete(c.output, n.ID())
+		c.mu.Unlock()
+		return nodeSkipFiltered
+	}
+
 	c.mu.RLock()
 	node, ok := c.output[n.ID()]
 	c.mu.RUnlock()
@@ -194,9 +226,13 @@ func (c *crawler) updateNode(n *enode.Node) int {
 	}
 
 	// Filter out incompatible nodes.
-	if shouldSkipNode(n) {
+	skip, fp := shouldSkipNode(ctx, n)
+	if skip {
 		return nodeSkipIncompat
 	}
+	if fp != nil {
+		node.Fingerprint = fp
+	}
 
 	// Request the node record.
 	status := nodeUpdated
@@ -212,6 +248,8 @@ func (c *crawler) updateNode(n *enode.Node) int {
 	} else {
 		node.N = nn
 		node.Seq = nn.Seq()
+		node.Entries = p2p.ParseENREntries(nn)
+		node.Family = p2p.AddressFamily(nn)
 		node.Score++
 		if node.FirstResponse.IsZero() {
 			node.FirstResponse = node.LastCheck
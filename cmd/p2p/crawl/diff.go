@@ -0,0 +1,199 @@
+package crawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type (
+	// snapshotNode is the subset of fields shared by the crawl nodes file and
+	// the ping output. The client name is only available in the latter.
+	snapshotNode struct {
+		Record *enode.Node `json:"record"`
+		Hello  *struct {
+			Name string
+		} `json:"hello,omitempty"`
+	}
+	snapshot map[enode.ID]snapshotNode
+
+	nodeChange struct {
+		ID  enode.ID `json:"id"`
+		Old string   `json:"old"`
+		New string   `json:"new"`
+	}
+
+	clientCount struct {
+		Client string `json:"client"`
+		Old    int    `json:"old"`
+		New    int    `json:"new"`
+	}
+
+	diffSummary struct {
+		OldNodes       int           `json:"oldNodes"`
+		NewNodes       int           `json:"newNodes"`
+		Added          int           `json:"added"`
+		Removed        int           `json:"removed"`
+		Unchanged      int           `json:"unchanged"`
+		AddressChanges int           `json:"addressChanges"`
+		ClientChanges  int           `json:"clientChanges"`
+		ChurnRate      float64       `json:"churnRate"`
+		Clients        []clientCount `json:"clients,omitempty"`
+	}
+
+	diffOutput struct {
+		Summary        diffSummary  `json:"summary"`
+		Added          []string     `json:"added,omitempty"`
+		Removed        []string     `json:"removed,omitempty"`
+		AddressChanges []nodeChange `json:"addressChanges,omitempty"`
+		ClientChanges  []nodeChange `json:"clientChanges,omitempty"`
+	}
+)
+
+var (
+	inputDiffSummaryOnly bool
+)
+
+// DiffCmd compares two crawl snapshots.
+var DiffCmd = &cobra.Command{
+	Use:   "diff [old nodes file] [new nodes file]",
+	Short: "Compare two crawl snapshots and report the network churn.",
+	Long: `Compare two nodes files and report which nodes were added or removed, which
+nodes changed their IP address or port, and the churn rate between the two
+snapshots. The churn rate is the number of added and removed nodes relative to
+the size of the old snapshot.
+
+The output of the ping command can be used as well. Since it includes the
+Hello message of each peer, client version migrations will also be reported.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldSet, err := loadSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+		newSet, err := loadSnapshot(args[1])
+		if err != nil {
+			return err
+		}
+
+		out := diffSnapshots(oldSet, newSet)
+		if inputDiffSummaryOnly {
+			out = diffOutput{Summary: out.Summary}
+		}
+
+		b, err := json.MarshalIndent(out, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+func init() {
+	DiffCmd.Flags().BoolVarP(&inputDiffSummaryOnly, "summary", "s", false, "Only output the aggregate summary.")
+}
+
+func loadSnapshot(file string) (snapshot, error) {
+	var s snapshot
+	if err := common.LoadJSON(file, &s); err != nil {
+		return nil, fmt.Errorf("unable to load %s: %w", file, err)
+	}
+	return s, nil
+}
+
+func address(n *enode.Node) string {
+	if n == nil {
+		return ""
+	}
+	return p2p.TCPAddress(n)
+}
+
+// client returns the client name without the version, e.g. "bor" for
+// "bor/v0.4.0/linux-amd64/go1.20.5".
+func client(n snapshotNode) string {
+	if n.Hello == nil || n.Hello.Name == "" {
+		return ""
+	}
+	return strings.ToLower(strings.Split(n.Hello.Name, "/")[0])
+}
+
+func diffSnapshots(oldSet, newSet snapshot) diffOutput {
+	var out diffOutput
+	clients := make(map[string]*clientCount)
+	countClient := func(n snapshotNode, isNew bool) {
+		name := client(n)
+		if name == "" {
+			return
+		}
+		c, ok := clients[name]
+		if !ok {
+			c = &clientCount{Client: name}
+			clients[name] = c
+		}
+		if isNew {
+			c.New++
+		} else {
+			c.Old++
+		}
+	}
+
+	for id, o := range oldSet {
+		countClient(o, false)
+
+		n, ok := newSet[id]
+		if !ok {
+			out.Removed = append(out.Removed, id.String())
+			continue
+		}
+
+		changed := false
+		if address(o.Record) != address(n.Record) {
+			out.AddressChanges = append(out.AddressChanges, nodeChange{ID: id, Old: address(o.Record), New: address(n.Record)})
+			changed = true
+		}
+		if o.Hello != nil && n.Hello != nil && o.Hello.Name != n.Hello.Name {
+			out.ClientChanges = append(out.ClientChanges, nodeChange{ID: id, Old: o.Hello.Name, New: n.Hello.Name})
+			changed = true
+		}
+		if !changed {
+			out.Summary.Unchanged++
+		}
+	}
+
+	for id, n := range newSet {
+		countClient(n, true)
+		if _, ok := oldSet[id]; !ok {
+			out.Added = append(out.Added, id.String())
+		}
+	}
+
+	sort.Strings(out.Added)
+	sort.Strings(out.Removed)
+	sort.Slice(out.AddressChanges, func(i, j int) bool { return out.AddressChanges[i].ID.String() < out.AddressChanges[j].ID.String() })
+	sort.Slice(out.ClientChanges, func(i, j int) bool { return out.ClientChanges[i].ID.String() < out.ClientChanges[j].ID.String() })
+
+	out.Summary.OldNodes = len(oldSet)
+	out.Summary.NewNodes = len(newSet)
+	out.Summary.Added = len(out.Added)
+	out.Summary.Removed = len(out.Removed)
+	out.Summary.AddressChanges = len(out.AddressChanges)
+	out.Summary.ClientChanges = len(out.ClientChanges)
+	if len(oldSet) > 0 {
+		out.Summary.ChurnRate = float64(len(out.Added)+len(out.Removed)) / float64(len(oldSet))
+	}
+
+	for _, c := range clients {
+		out.Summary.Clients = append(out.Summary.Clients, *c)
+	}
+	sort.Slice(out.Summary.Clients, func(i, j int) bool { return out.Summary.Clients[i].Client < out.Summary.Clients[j].Client })
+
+	return out
+}
@@ -0,0 +1,203 @@
+package crawl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type (
+	staticParams struct {
+		File    string
+		Limit   int
+		ForkID  string
+		Clients string
+		MaxAge  time.Duration
+	}
+
+	// staticFilter selects the nodes of the static nodes file.
+	staticFilter struct {
+		forkHash []byte
+		forkNext *uint64
+		clients  map[string]bool
+		maxAge   time.Duration
+	}
+)
+
+var inputStaticParams staticParams
+
+// StaticNodesCmd exports the best nodes of a nodes file as static nodes.
+var StaticNodesCmd = &cobra.Command{
+	Use:   "static-nodes [nodes file]",
+	Short: "Export the best reachable nodes of a crawl in the static-nodes.json format of bor and geth.",
+	Long: `Write the reachable nodes of a nodes file with the highest scores as a list of
+enode URLs, which is the format of the static-nodes.json and trusted-nodes.json
+files of bor and geth. The nodes are written to --static-nodes, or stdout if it
+isn't set. The crawl writes the file itself after crawling if --static-nodes is
+set, with the same filters.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes, err := p2p.LoadNodesJSON(args[0])
+		if err != nil {
+			return err
+		}
+		file := inputStaticParams.File
+		if file == "" {
+			file = "-"
+		}
+		return writeStaticNodes(file, nodes)
+	},
+}
+
+func init() {
+	CrawlCmd.PersistentFlags().StringVar(&inputStaticParams.File, "static-nodes", "",
+		`Write the best reachable nodes to this file in the static-nodes.json format of
+bor and geth, - for stdout. The crawl writes it after crawling.`)
+	CrawlCmd.PersistentFlags().IntVar(&inputStaticParams.Limit, "static-limit", 25, "Maximum number of nodes in the static nodes file.")
+	CrawlCmd.PersistentFlags().StringVar(&inputStaticParams.ForkID, "static-fork-id", "",
+		`Only include nodes advertising this fork ID in their ENR, as the fork hash
+optionally followed by the next fork, for example 0x12345678 or 0x12345678:0.`)
+	CrawlCmd.PersistentFlags().StringVar(&inputStaticParams.Clients, "static-clients", "",
+		`Comma separated clients to only include the nodes of, for example bor,geth.
+The client is only known for fingerprinted nodes, see --fingerprint.`)
+	CrawlCmd.PersistentFlags().DurationVar(&inputStaticParams.MaxAge, "static-max-age", 0,
+		"Only include nodes that responded within this long (0 for any node that responded).")
+
+	CrawlCmd.AddCommand(StaticNodesCmd)
+}
+
+// writeStaticNodes writes the best reachable nodes that pass the filters of
+// the flags to the file.
+func writeStaticNodes(file string, nodes p2p.NodeSet) error {
+	filter, err := newStaticFilter(inputStaticParams)
+	if err != nil {
+		return err
+	}
+
+	selected := selectStaticNodes(nodes, filter, time.Now(), inputStaticParams.Limit)
+	if len(selected) == 0 {
+		log.Warn().Int("nodes", len(nodes)).Msg("None of the nodes are reachable and pass the static nodes filters")
+	}
+	if err = p2p.WriteStaticNodes(file, selected); err != nil {
+		return err
+	}
+	log.Info().Int("nodes", len(selected)).Str("file", file).Msg("Wrote static nodes")
+	return nil
+}
+
+// newStaticFilter validates the static nodes flags, which the crawl does
+// before crawling rather than once it's done.
+func newStaticFilter(params staticParams) (*staticFilter, error) {
+	if params.Limit < 1 {
+		return nil, fmt.Errorf("the static nodes limit should be at least 1")
+	}
+	f := &staticFilter{maxAge: params.MaxAge}
+
+	if params.ForkID != "" {
+		hash, next, hasNext := strings.Cut(params.ForkID, ":")
+		b, err := hexutil.Decode(hash)
+		if err != nil || len(b) != 4 {
+			return nil, fmt.Errorf("the fork hash %s should be 4 hex encoded bytes", hash)
+		}
+		f.forkHash = b
+		if hasNext {
+			n, err := strconv.ParseUint(next, 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid next fork %s: %w", next, err)
+			}
+			f.forkNext = &n
+		}
+	}
+
+	if params.Clients != "" {
+		f.clients = make(map[string]bool)
+		for _, c := range strings.Split(params.Clients, ",") {
+			if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+				f.clients[c] = true
+			}
+		}
+	}
+	return f, nil
+}
+
+// match reports whether the node is reachable and passes the filters.
+func (f *staticFilter) match(node p2p.NodeJSON, now time.Time) bool {
+	if node.N == nil || node.N.TCP() == 0 || node.Score <= 0 || node.LastResponse.IsZero() {
+		return false
+	}
+	if f.maxAge > 0 && now.Sub(node.LastResponse) > f.maxAge {
+		return false
+	}
+
+	if f.forkHash != nil {
+		// Version 1 nodes files don't have the entries, but they can be
+		// derived from the record.
+		entries := node.Entries
+		if entries == nil {
+			entries = p2p.ParseENREntries(node.N)
+		}
+		if entries.Eth == nil || !bytes.Equal(entries.Eth.ForkHash, f.forkHash) {
+			return false
+		}
+		if f.forkNext != nil && entries.Eth.ForkNext != *f.forkNext {
+			return false
+		}
+	}
+
+	if f.clients != nil && !f.clients[nodeClient(node)] {
+		return false
+	}
+	return true
+}
+
+// nodeClient returns the client of a fingerprinted node, preferring the
+// inferred client since Hello names can be spoofed.
+func nodeClient(node p2p.NodeJSON) string {
+	if node.Fingerprint == nil {
+		return ""
+	}
+	if c := node.Fingerprint.InferredClient; c != "" {
+		return strings.ToLower(c)
+	}
+	return p2p.ClientName(node.Fingerprint.Name)
+}
+
+// selectStaticNodes returns up to limit nodes that pass the filter, with the
+// highest scores first and the most recently responding first among equal
+// scores.
+func selectStaticNodes(nodes p2p.NodeSet, filter *staticFilter, now time.Time, limit int) []*enode.Node {
+	ranked := make([]p2p.NodeJSON, 0, len(nodes))
+	for _, node := range nodes {
+		if filter.match(node, now) {
+			ranked = append(ranked, node)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if !ranked[i].LastResponse.Equal(ranked[j].LastResponse) {
+			return ranked[i].LastResponse.After(ranked[j].LastResponse)
+		}
+		return bytes.Compare(ranked[i].N.ID().Bytes(), ranked[j].N.ID().Bytes()) < 0
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	selected := make([]*enode.Node, 0, len(ranked))
+	for _, node := range ranked {
+		selected = append(selected, node.N)
+	}
+	return selected
+}
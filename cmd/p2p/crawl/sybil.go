@@ -0,0 +1,252 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	clusterIP     = "ip"
+	clusterSubnet = "subnet"
+	clusterASN    = "asn"
+
+	// minSequentialPorts is the length of a run of consecutive ports on the
+	// same IP that is flagged, since it's typical of many nodes started from
+	// one script.
+	minSequentialPorts = 3
+
+	// maxASNClusters is the number of the largest ASNs reported.
+	maxASNClusters = 10
+)
+
+type (
+	// sybilCluster is a group of node IDs sharing an IP, subnet, or ASN.
+	sybilCluster struct {
+		Kind    string     `json:"kind"`
+		Key     string     `json:"key"`
+		Name    string     `json:"name,omitempty"`
+		Nodes   int        `json:"nodes"`
+		Share   float64    `json:"share"`
+		Ports   []int      `json:"ports,omitempty"`
+		Flagged bool       `json:"flagged"`
+		Reasons []string   `json:"reasons,omitempty"`
+		NodeIDs []enode.ID `json:"ids,omitempty"`
+		IPs     int        `json:"ips,omitempty"`
+		addrs   map[string]struct{}
+	}
+
+	sybilReport struct {
+		Nodes    int            `json:"nodes"`
+		Flagged  int            `json:"flagged"`
+		Clusters []sybilCluster `json:"clusters,omitempty"`
+	}
+
+	// asnRange maps an IP range to its autonomous system.
+	asnRange struct {
+		start, end net.IP
+		asn        uint32
+		name       string
+	}
+	asnTable []asnRange
+)
+
+// analyzeSybil groups the node IDs by IP, subnet, and, if an ASN table is
+// given, by ASN. IPs with at least minIDs node IDs or a run of sequential
+// ports, and subnets with at least subnetIDs node IDs are flagged as likely
+// sybil clusters. ASNs are only flagged if they have more than the maxShare of
+// the nodes, since many honest nodes are hosted by the same cloud providers.
+func analyzeSybil(nodes p2p.NodeSet, asns asnTable, minIDs, subnetIDs int, maxShare float64) sybilReport {
+	groups := map[string]map[string]*sybilCluster{
+		clusterIP:     {},
+		clusterSubnet: {},
+		clusterASN:    {},
+	}
+	add := func(kind, key, name string, n *enode.Node) {
+		c, ok := groups[kind][key]
+		if !ok {
+			c = &sybilCluster{Kind: kind, Key: key, Name: name, addrs: make(map[string]struct{})}
+			groups[kind][key] = c
+		}
+		c.Nodes++
+		c.NodeIDs = append(c.NodeIDs, n.ID())
+		c.addrs[n.IP().String()] = struct{}{}
+		if kind == clusterIP && n.TCP() != 0 {
+			c.Ports = append(c.Ports, n.TCP())
+		}
+	}
+
+	var total int
+	for _, node := range nodes {
+		n := node.N
+		if n == nil || n.IP() == nil {
+			continue
+		}
+		total++
+		add(clusterIP, n.IP().String(), "", n)
+		add(clusterSubnet, sybilSubnet(n.IP()), "", n)
+		if asn, ok := asns.lookup(n.IP()); ok {
+			add(clusterASN, fmt.Sprintf("AS%d", asn.asn), asn.name, n)
+		}
+	}
+
+	report := sybilReport{Nodes: total}
+	for kind, clusters := range groups {
+		var list []*sybilCluster
+		for _, c := range clusters {
+			c.Share = float64(c.Nodes) / float64(total)
+			c.IPs = len(c.addrs)
+			sort.Ints(c.Ports)
+			sort.Slice(c.NodeIDs, func(i, j int) bool { return bytes.Compare(c.NodeIDs[i][:], c.NodeIDs[j][:]) < 0 })
+
+			switch kind {
+			case clusterIP:
+				c.IPs = 0
+				if c.Nodes >= minIDs {
+					c.Reasons = append(c.Reasons, fmt.Sprintf("%d node IDs on one IP", c.Nodes))
+				}
+				if run := longestPortRun(c.Ports); run >= minSequentialPorts {
+					c.Reasons = append(c.Reasons, fmt.Sprintf("%d sequential ports", run))
+				}
+			case clusterSubnet:
+				if c.Nodes >= subnetIDs {
+					c.Reasons = append(c.Reasons, fmt.Sprintf("%d node IDs on %d IPs in one subnet", c.Nodes, c.IPs))
+				}
+			case clusterASN:
+				if maxShare > 0 && c.Share > maxShare {
+					c.Reasons = append(c.Reasons, fmt.Sprintf("%.0f%% of the nodes in one ASN", c.Share*100))
+				}
+			}
+			c.Flagged = len(c.Reasons) > 0
+			if c.Flagged || kind == clusterASN {
+				list = append(list, c)
+			}
+		}
+
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Nodes != list[j].Nodes {
+				return list[i].Nodes > list[j].Nodes
+			}
+			return list[i].Key < list[j].Key
+		})
+		for i, c := range list {
+			// Only the largest ASNs are reported unless they're flagged.
+			if kind == clusterASN && i >= maxASNClusters && !c.Flagged {
+				break
+			}
+			if kind == clusterASN {
+				c.NodeIDs = nil
+			}
+			if c.Flagged {
+				report.Flagged++
+			}
+			report.Clusters = append(report.Clusters, *c)
+		}
+	}
+
+	sort.SliceStable(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i], report.Clusters[j]
+		if a.Flagged != b.Flagged {
+			return a.Flagged
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Nodes > b.Nodes
+	})
+	return report
+}
+
+// sybilSubnet returns the /24 prefix of IPv4 addresses and the /48 prefix of
+// IPv6 addresses, which are the smallest blocks usually routed to one host.
+func sybilSubnet(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// longestPortRun returns the length of the longest run of consecutive ports
+// in the sorted ports.
+func longestPortRun(ports []int) int {
+	var longest, run int
+	for i, port := range ports {
+		switch {
+		case i > 0 && port == ports[i-1]:
+			continue
+		case i > 0 && port == ports[i-1]+1:
+			run++
+		default:
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// loadASNTable reads a tab separated table of IP ranges and their ASNs in the
+// format of the ip2asn databases: range start, range end, AS number, country
+// code, and AS description. Ranges with AS number 0 are unrouted and skipped.
+func loadASNTable(file string) (asnTable, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var table asnTable
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("invalid IP range on line %d of %s", line, file)
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AS number on line %d of %s: %w", line, file, err)
+		}
+		if asn == 0 {
+			continue
+		}
+		r := asnRange{start: start.To16(), end: end.To16(), asn: uint32(asn)}
+		if len(fields) >= 5 {
+			r.name = fields[4]
+		}
+		table = append(table, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(table, func(i, j int) bool { return bytes.Compare(table[i].start, table[j].start) < 0 })
+	return table, nil
+}
+
+// lookup returns the range containing the IP.
+func (t asnTable) lookup(ip net.IP) (asnRange, bool) {
+	ip = ip.To16()
+	i := sort.Search(len(t), func(i int) bool { return bytes.Compare(t[i].start, ip) > 0 })
+	if i == 0 {
+		return asnRange{}, false
+	}
+	r := t[i-1]
+	if bytes.Compare(ip, r.end) > 0 {
+		return asnRange{}, false
+	}
+	return r, true
+}
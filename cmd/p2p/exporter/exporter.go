@@ -0,0 +1,334 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type (
+	exporterParams struct {
+		NodesFile string
+		Listen    string
+		Interval  string
+		interval  time.Duration
+		Timeout   string
+		timeout   time.Duration
+		Threads   int
+		Pprof     bool
+	}
+
+	// exporterMetrics are the metrics of the checked nodes. The series of the
+	// nodes that are removed from the nodes file are deleted.
+	exporterMetrics struct {
+		up              *prometheus.GaugeVec
+		latency         *prometheus.GaugeVec
+		protocolVersion *prometheus.GaugeVec
+		info            *prometheus.GaugeVec
+		checks          *prometheus.CounterVec
+		lastCheck       *prometheus.GaugeVec
+
+		// infos holds the info labels of each node, so the old series is
+		// deleted when the client or capabilities change.
+		infos map[enode.ID]prometheus.Labels
+		nodes map[enode.ID]*enode.Node
+		mu    sync.Mutex
+	}
+)
+
+var (
+	inputExporterParams exporterParams
+)
+
+// ExporterCmd represents the exporter command. It checks the liveness of a set
+// of nodes and serves the results as Prometheus metrics.
+var ExporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Check the liveness of a set of nodes and expose the results as Prometheus metrics.",
+	Long: `Dial and handshake with every node of the nodes file each interval, and expose
+whether the nodes are up, the handshake latency, and the protocol version as
+Prometheus metrics on /metrics. The nodes file can be the output of the crawl
+command, a JSON array of nodes, or one node per line. It's reloaded every
+interval, so nodes can be added and removed without restarting the exporter.
+
+The /healthz liveness and /readyz readiness endpoints are served next to the
+metrics for Kubernetes probes. The exporter is ready once the nodes file was
+loaded and checked, and isn't ready while the nodes file can't be loaded.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		if inputExporterParams.NodesFile == "" {
+			return errors.New("a nodes file is required")
+		}
+		if inputExporterParams.Threads < 1 {
+			return errors.New("parallel must be at least one")
+		}
+
+		inputExporterParams.interval, err = time.ParseDuration(inputExporterParams.Interval)
+		if err != nil {
+			return err
+		}
+
+		inputExporterParams.timeout, err = time.ParseDuration(inputExporterParams.Timeout)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		registry := prometheus.NewRegistry()
+		m := newExporterMetrics(registry)
+
+		// loadErr is why the last check of the nodes didn't happen, which is
+		// what the readiness depends on.
+		var (
+			loadErr = errors.New("the nodes haven't been checked yet")
+			loadMu  sync.Mutex
+		)
+		health := metrics.NewHealth()
+		health.AddCheck("nodes", func() error {
+			loadMu.Lock()
+			defer loadMu.Unlock()
+			return loadErr
+		})
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		health.Register(mux, inputExporterParams.Pprof)
+		server := &http.Server{Addr: inputExporterParams.Listen, Handler: mux}
+
+		serverErr := make(chan error, 1)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+		defer server.Close()
+
+		log.Info().Str("listen", inputExporterParams.Listen).Msg("Starting exporter")
+
+		for {
+			nodes, err := loadNodes(inputExporterParams.NodesFile)
+			if err != nil {
+				log.Error().Err(err).Msg("Unable to load the nodes file")
+			} else {
+				m.check(ctx, nodes)
+			}
+			loadMu.Lock()
+			loadErr = err
+			loadMu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case err := <-serverErr:
+				return err
+			case <-time.After(inputExporterParams.interval):
+			}
+		}
+	},
+}
+
+func init() {
+	ExporterCmd.PersistentFlags().StringVar(&inputExporterParams.NodesFile, "nodes", "", "The nodes file with the nodes to check.")
+	ExporterCmd.PersistentFlags().StringVar(&inputExporterParams.Listen, "listen", ":9100", "The address to serve the metrics on.")
+	ExporterCmd.PersistentFlags().StringVarP(&inputExporterParams.Interval, "interval", "i", "1m", "The amount of time between the checks of a node.")
+	ExporterCmd.PersistentFlags().StringVarP(&inputExporterParams.Timeout, "timeout", "t", "10s", "The time limit for the dial, and separately the handshake, of a node.")
+	ExporterCmd.PersistentFlags().IntVarP(&inputExporterParams.Threads, "parallel", "p", 16, "How many nodes to check in parallel.")
+	ExporterCmd.PersistentFlags().BoolVar(&inputExporterParams.Pprof, "pprof", false, "Whether to serve pprof on /debug/pprof/ next to the metrics.")
+}
+
+// loadNodes loads the nodes from a nodes file written by the crawler, or from
+// a list of nodes.
+func loadNodes(file string) ([]*enode.Node, error) {
+	if set, err := p2p.LoadNodesJSON(file); err == nil {
+		return set.Nodes(), nil
+	}
+	nodes, err := p2p.ParseNodeList(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found in %s", file)
+	}
+	return nodes, nil
+}
+
+func newExporterMetrics(registry prometheus.Registerer) *exporterMetrics {
+	labels := []string{"id", "address"}
+	m := &exporterMetrics{
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "p2p_node_up",
+			Help: "Whether the last dial and handshake with the node succeeded.",
+		}, labels),
+		latency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "p2p_node_handshake_seconds",
+			Help: "The time it took to dial the node and complete the handshake and status exchange.",
+		}, labels),
+		protocolVersion: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "p2p_node_protocol_version",
+			Help: "The eth protocol version of the status exchange with the node.",
+		}, labels),
+		info: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "p2p_node_info",
+			Help: "The client, capabilities, and network ID the node advertised.",
+		}, append(labels, "client", "caps", "network_id")),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "p2p_node_checks_total",
+			Help: "The number of checks of the node by result.",
+		}, append(labels, "result")),
+		lastCheck: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "p2p_node_last_check_timestamp_seconds",
+			Help: "The time of the last check of the node.",
+		}, labels),
+		infos: make(map[enode.ID]prometheus.Labels),
+		nodes: make(map[enode.ID]*enode.Node),
+	}
+	registry.MustRegister(m.up, m.latency, m.protocolVersion, m.info, m.checks, m.lastCheck)
+	return m
+}
+
+func nodeLabels(n *enode.Node) prometheus.Labels {
+	return prometheus.Labels{
+		"id":      n.ID().String(),
+		"address": p2p.TCPAddress(n),
+	}
+}
+
+// check checks the nodes in parallel and updates their metrics. The metrics
+// of the nodes that aren't in the list anymore are deleted.
+func (m *exporterMetrics) check(ctx context.Context, nodes []*enode.Node) {
+	current := make(map[enode.ID]struct{}, len(nodes))
+	for _, n := range nodes {
+		current[n.ID()] = struct{}{}
+	}
+	m.mu.Lock()
+	for id, n := range m.nodes {
+		if _, ok := current[id]; !ok {
+			m.delete(id, n)
+		}
+	}
+	m.mu.Unlock()
+
+	client := p2p.NewClient(p2p.ClientConfig{
+		DialTimeout:      inputExporterParams.timeout,
+		HandshakeTimeout: inputExporterParams.timeout,
+	})
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, inputExporterParams.Threads)
+		up  int
+		mu  sync.Mutex
+	)
+	for _, n := range nodes {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(n *enode.Node) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if m.checkNode(ctx, client, n) {
+				mu.Lock()
+				up++
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	log.Info().Int("nodes", len(nodes)).Int("up", up).Msg("Checked nodes")
+}
+
+// checkNode dials and handshakes with the node and returns whether it's up.
+func (m *exporterMetrics) checkNode(ctx context.Context, client *p2p.Client, n *enode.Node) bool {
+	labels := nodeLabels(n)
+	start := time.Now()
+	conn, hello, status, err := client.Peer(ctx, n)
+	latency := time.Since(start)
+	if conn != nil {
+		conn.Close()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.nodes[n.ID()]; ok && nodeLabels(old)["address"] != labels["address"] {
+		m.delete(n.ID(), old)
+	}
+	m.nodes[n.ID()] = n
+	m.lastCheck.With(labels).SetToCurrentTime()
+
+	if err != nil {
+		// Don't count the checks interrupted by the shutdown.
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Debug().Err(err).Str("node", n.URLv4()).Msg("Node is down")
+		m.up.With(labels).Set(0)
+		m.checks.With(withLabel(labels, "result", "down")).Inc()
+		return false
+	}
+
+	m.up.With(labels).Set(1)
+	m.checks.With(withLabel(labels, "result", "up")).Inc()
+	m.latency.With(labels).Set(latency.Seconds())
+	m.protocolVersion.With(labels).Set(float64(status.ProtocolVersion))
+
+	caps := make([]string, 0, len(hello.Caps))
+	for _, c := range hello.Caps {
+		caps = append(caps, c.String())
+	}
+	info := withLabel(labels, "client", hello.Name)
+	info["caps"] = strings.Join(caps, ",")
+	info["network_id"] = fmt.Sprint(status.NetworkID)
+	if old, ok := m.infos[n.ID()]; ok {
+		m.info.Delete(old)
+	}
+	m.infos[n.ID()] = info
+	m.info.With(info).Set(1)
+	return true
+}
+
+// delete deletes the series of the node. The caller must hold the lock.
+func (m *exporterMetrics) delete(id enode.ID, n *enode.Node) {
+	labels := nodeLabels(n)
+	m.up.Delete(labels)
+	m.latency.Delete(labels)
+	m.protocolVersion.Delete(labels)
+	m.lastCheck.Delete(labels)
+	m.checks.DeletePartialMatch(labels)
+	if info, ok := m.infos[id]; ok {
+		m.info.Delete(info)
+		delete(m.infos, id)
+	}
+	delete(m.nodes, id)
+}
+
+func withLabel(labels prometheus.Labels, name, value string) prometheus.Labels {
+	l := make(prometheus.Labels, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	l[name] = value
+	return l
+}
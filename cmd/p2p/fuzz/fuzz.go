@@ -0,0 +1,510 @@
+package fuzz
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	mrand "math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	// The outcomes of a fuzz case. Closed, hang, and unreachable are the
+	// interesting ones, a well behaved node either disconnects with a reason,
+	// responds, or ignores the message.
+	outcomeDisconnect  = "disconnect"
+	outcomeResponse    = "response"
+	outcomeIgnored     = "ignored"
+	outcomeClosed      = "closed"
+	outcomeHang        = "hang"
+	outcomeUnreachable = "unreachable"
+
+	// maxPayloadOutput is the number of payload bytes written per case.
+	maxPayloadOutput = 4096
+
+	// baseProtocolLength is the number of message codes reserved by the base
+	// protocol, after which the eth codes start.
+	baseProtocolLength = 16
+)
+
+type (
+	fuzzParams struct {
+		Enode       string
+		Cases       int
+		Seed        int64
+		Timeout     time.Duration
+		Mutators    []string
+		OutputFile  string
+		StopOnCrash bool
+	}
+
+	// seedMessage is a valid message the mutated messages are derived from.
+	seedMessage struct {
+		name    string
+		code    uint64
+		payload []byte
+	}
+
+	// mutator returns a mutated copy of the message.
+	mutator func(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte)
+
+	// fuzzCase is a mutated message and how the node reacted to it.
+	fuzzCase struct {
+		Case      int           `json:"case"`
+		Message   string        `json:"message"`
+		Mutator   string        `json:"mutator"`
+		Code      uint64        `json:"code"`
+		Size      int           `json:"size"`
+		Payload   hexutil.Bytes `json:"payload"`
+		Truncated bool          `json:"truncated,omitempty"`
+		Outcome   string        `json:"outcome"`
+		Reason    string        `json:"reason,omitempty"`
+		Latency   string        `json:"latency,omitempty"`
+	}
+)
+
+var (
+	inputFuzzParams fuzzParams
+
+	mutators = map[string]mutator{
+		"truncate":  mutateTruncate,
+		"length":    mutateLength,
+		"code":      mutateCode,
+		"oversized": mutateOversized,
+		"flip":      mutateFlip,
+		"append":    mutateAppend,
+		"empty":     mutateEmpty,
+	}
+)
+
+// FuzzCmd sends mutated devp2p messages to a node.
+var FuzzCmd = &cobra.Command{
+	Use:   "fuzz",
+	Short: "Send malformed devp2p messages to a node and record how it reacts.",
+	Long: `Peer with the node and send it structurally mutated messages, the p2p
+analogue of rpcfuzz. Every case takes a valid message, like a Ping, Status,
+GetBlockHeaders, or Transactions message, and applies one of the mutators:
+
+  truncate   cut the RLP payload short
+  length     corrupt the length in the RLP header
+  code       send the payload with another, possibly unknown, message code
+  oversized  replace the payload with huge or deeply nested lists
+  flip       flip random bytes of the payload
+  append     add trailing garbage after the payload
+  empty      send the message without a payload
+
+How the node reacts is written as JSON, one case per line:
+
+  disconnect   the node disconnected with a reason
+  response     the node responded to the message
+  ignored      the node ignored the message and still answers pings
+  closed       the node closed the connection without a disconnect message
+  hang         the node stopped answering pings within --timeout
+  unreachable  the node couldn't be peered with after the case, which
+               suggests it crashed
+
+The connection is reused until the node drops it. Nodes throttle inbound
+connections from the same IP, so fuzzing a node that isn't on the local network
+might report peering failures that aren't crashes. Only fuzz nodes you operate.
+
+The cases are generated from --seed, so a run can be repeated against a node
+with the same chain head. The payloads are also written, up to 4 KiB.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputFuzzParams.Cases < 1 {
+			return fmt.Errorf("cases must be at least one")
+		}
+		for _, m := range inputFuzzParams.Mutators {
+			if _, ok := mutators[m]; !ok {
+				return fmt.Errorf("unknown mutator %s, expected one of [%s]", m, strings.Join(mutatorNames(), ", "))
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		node, err := p2p.ParseNode(inputFuzzParams.Enode)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if inputFuzzParams.OutputFile != "" {
+			f, err := os.Create(inputFuzzParams.OutputFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		bw := bufio.NewWriter(w)
+		defer bw.Flush()
+
+		return fuzz(ctx, node, json.NewEncoder(bw))
+	},
+}
+
+func init() {
+	names := mutatorNames()
+
+	flagSet := FuzzCmd.Flags()
+	flagSet.StringVar(&inputFuzzParams.Enode, "enode", "", "The enode or enr of the node to fuzz.")
+	flagSet.IntVarP(&inputFuzzParams.Cases, "cases", "c", 100, "The number of mutated messages to send.")
+	flagSet.Int64Var(&inputFuzzParams.Seed, "seed", 123456, "The seed the cases are generated from.")
+	flagSet.DurationVarP(&inputFuzzParams.Timeout, "timeout", "t", 5*time.Second, "How long to wait for the node to react to a message.")
+	flagSet.StringSliceVarP(&inputFuzzParams.Mutators, "mutators", "m", names, "The mutators applied to the messages.")
+	flagSet.StringVarP(&inputFuzzParams.OutputFile, "output", "o", "", "Write the cases to output file. (default stdout)")
+	flagSet.BoolVar(&inputFuzzParams.StopOnCrash, "stop-on-crash", true, "Stop once the node is unreachable.")
+	if err := FuzzCmd.MarkFlagRequired("enode"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark enode as required flag")
+	}
+}
+
+func mutatorNames() []string {
+	names := make([]string, 0, len(mutators))
+	for name := range mutators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fuzz runs the cases against the node and writes them to the encoder.
+func fuzz(ctx context.Context, node *enode.Node, enc *json.Encoder) error {
+	client := p2p.NewClient(p2p.ClientConfig{
+		HandshakeTimeout: inputFuzzParams.Timeout,
+		RequestTimeout:   inputFuzzParams.Timeout,
+	})
+
+	conn, _, status, err := client.Peer(ctx, node)
+	if err != nil {
+		return fmt.Errorf("unable to peer with the node: %w", err)
+	}
+	r := mrand.New(mrand.NewSource(inputFuzzParams.Seed))
+	seeds, err := seedMessages(r, status)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	var (
+		outcomes = make(map[string]int)
+		names    = append([]string{}, inputFuzzParams.Mutators...)
+	)
+	sort.Strings(names)
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < inputFuzzParams.Cases && ctx.Err() == nil; i++ {
+		// The message is generated before peering so that the cases only
+		// depend on the seed and the head of the node.
+		seed := seeds[r.Intn(len(seeds))]
+		name := names[r.Intn(len(names))]
+		code, payload := mutators[name](r, seed.code, append([]byte{}, seed.payload...))
+
+		c := fuzzCase{
+			Case:    i,
+			Message: seed.name,
+			Mutator: name,
+			Code:    code,
+			Size:    len(payload),
+			Payload: payload,
+		}
+		if len(payload) > maxPayloadOutput {
+			c.Payload = payload[:maxPayloadOutput]
+			c.Truncated = true
+		}
+
+		if conn == nil {
+			if conn, _, _, err = client.Peer(ctx, node); err != nil {
+				return fmt.Errorf("unable to peer with the node before case %d: %w", i, err)
+			}
+		}
+
+		start := time.Now()
+		if _, err = conn.Conn.Write(code, payload); err != nil {
+			c.Outcome, c.Reason = outcomeClosed, err.Error()
+		} else {
+			c.Outcome, c.Reason = observe(ctx, conn, code)
+		}
+		c.Latency = time.Since(start).String()
+
+		// The connection is only kept if the node is still talking to it.
+		if c.Outcome != outcomeIgnored && c.Outcome != outcomeResponse {
+			conn.Close()
+			conn = nil
+		}
+
+		if c.Outcome == outcomeClosed || c.Outcome == outcomeHang {
+			if conn, _, _, err = client.Peer(ctx, node); err != nil {
+				c.Reason = fmt.Sprintf("%s after %s: %v", c.Outcome, c.Reason, err)
+				c.Outcome = outcomeUnreachable
+			}
+		}
+
+		outcomes[c.Outcome]++
+		logger := log.Debug()
+		if c.Outcome == outcomeClosed || c.Outcome == outcomeHang || c.Outcome == outcomeUnreachable {
+			logger = log.Warn()
+		}
+		logger.Int("case", i).Str("message", c.Message).Str("mutator", c.Mutator).Uint64("code", c.Code).
+			Str("outcome", c.Outcome).Str("reason", c.Reason).Msg("Fuzzed")
+
+		if err = enc.Encode(c); err != nil {
+			return err
+		}
+		if c.Outcome == outcomeUnreachable && inputFuzzParams.StopOnCrash {
+			log.Error().Int("case", i).Msg("The node is unreachable, stopping")
+			break
+		}
+	}
+
+	log.Info().Interface("outcomes", outcomes).Msg("Finished fuzzing")
+	return nil
+}
+
+// observe waits for the node to react to the message with the code. If it
+// neither disconnects nor responds in time, it's pinged to tell whether it
+// ignored the message or hangs.
+func observe(ctx context.Context, conn *p2p.Conn, code uint64) (string, string) {
+	if err := conn.SetReadDeadline(time.Now().Add(inputFuzzParams.Timeout)); err != nil {
+		return outcomeClosed, err.Error()
+	}
+
+loop:
+	for {
+		switch msg := conn.Read().(type) {
+		case *p2p.Disconnect:
+			return outcomeDisconnect, msg.Reason.String()
+		case *p2p.Disconnects:
+			if len(*msg) > 0 {
+				return outcomeDisconnect, (*msg)[0].String()
+			}
+			return outcomeDisconnect, ""
+		case *p2p.Ping:
+			if err := conn.Write(&p2p.Pong{}); err != nil {
+				return outcomeClosed, err.Error()
+			}
+		case *p2p.Error:
+			if strings.Contains(msg.Error(), "timeout") {
+				break loop
+			}
+			return outcomeClosed, msg.Error()
+		default:
+			// Requests are answered with the next message code, anything else
+			// is gossip that's unrelated to the case.
+			if uint64(msg.Code()) == code+1 && code >= baseProtocolLength {
+				return outcomeResponse, fmt.Sprintf("code %d", msg.Code())
+			}
+		}
+	}
+
+	if _, err := conn.Ping(ctx); err != nil {
+		if strings.Contains(err.Error(), "disconnect") {
+			return outcomeDisconnect, err.Error()
+		}
+		if strings.Contains(err.Error(), "timeout") {
+			return outcomeHang, err.Error()
+		}
+		return outcomeClosed, err.Error()
+	}
+	return outcomeIgnored, ""
+}
+
+// seedMessages returns the valid messages the cases are derived from, which
+// refer to the head of the node where possible so they aren't rejected for
+// unknown blocks alone. The random parts are read from r.
+func seedMessages(r *mrand.Rand, status *p2p.Status) ([]seedMessage, error) {
+	secret := make([]byte, 32)
+	r.Read(secret)
+	key, err := crypto.ToECDSA(secret)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(status.NetworkID))
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Gas:      21000,
+		GasPrice: common.Big1,
+		To:       &common.Address{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var hash common.Hash
+	r.Read(hash[:])
+
+	msgs := []struct {
+		name string
+		msg  p2p.Message
+	}{
+		{"Ping", &p2p.Ping{}},
+		{"Status", status},
+		{"NewBlockHashes", &p2p.NewBlockHashes{{Hash: status.Head, Number: 1}}},
+		{"Transactions", &p2p.Transactions{tx}},
+		{"GetBlockHeaders", &p2p.GetBlockHeaders{RequestId: 1, GetBlockHeadersPacket: &eth.GetBlockHeadersPacket{Origin: eth.HashOrNumber{Hash: status.Head}, Amount: 16, Reverse: true}}},
+		{"GetBlockBodies", &p2p.GetBlockBodies{RequestId: 2, GetBlockBodiesPacket: []common.Hash{status.Head}}},
+		{"NewPooledTransactionHashes", &p2p.NewPooledTransactionHashes66{hash}},
+		{"GetPooledTransactions", &p2p.GetPooledTransactions{RequestId: 3, GetPooledTransactionsPacket: []common.Hash{hash}}},
+	}
+
+	seeds := make([]seedMessage, 0, len(msgs))
+	for _, m := range msgs {
+		payload, err := rlp.EncodeToBytes(m.msg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode %s: %w", m.name, err)
+		}
+		seeds = append(seeds, seedMessage{name: m.name, code: uint64(m.msg.Code()), payload: payload})
+	}
+	return seeds, nil
+}
+
+func mutateTruncate(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	if len(payload) == 0 {
+		return code, payload
+	}
+	return code, payload[:r.Intn(len(payload))]
+}
+
+// mutateLength corrupts the length of the outermost RLP item, so it claims to
+// be shorter or longer than the payload.
+func mutateLength(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	if len(payload) == 0 {
+		return code, payload
+	}
+	switch b := payload[0]; {
+	case b >= 0xf8:
+		// Long list, the next b-0xf7 bytes are the length.
+		if n := int(b - 0xf7); len(payload) > n {
+			payload[1+r.Intn(n)] ^= byte(1 + r.Intn(255))
+		}
+	case b >= 0xc0:
+		payload[0] = 0xc0 + byte(r.Intn(56))
+	case b >= 0xb8:
+		if n := int(b - 0xb7); len(payload) > n {
+			payload[1+r.Intn(n)] ^= byte(1 + r.Intn(255))
+		}
+	case b >= 0x80:
+		payload[0] = 0x80 + byte(r.Intn(56))
+	default:
+		// A single byte, claim it's a long list instead.
+		payload = append([]byte{0xf8, byte(r.Intn(256))}, payload...)
+	}
+	return code, payload
+}
+
+// mutateCode sends the payload with a code of the base protocol, another eth
+// message, or a code past the negotiated protocols.
+func mutateCode(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	for {
+		var c uint64
+		switch r.Intn(3) {
+		case 0:
+			c = uint64(r.Intn(baseProtocolLength))
+		case 1:
+			c = baseProtocolLength + uint64(r.Intn(17))
+		default:
+			c = baseProtocolLength + 17 + uint64(r.Intn(1024))
+		}
+		if c != code {
+			return c, payload
+		}
+	}
+}
+
+// mutateOversized replaces the payload with lists that are much larger than
+// what peers serve, or nested deeper than what decoders expect.
+func mutateOversized(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	randomHashes := func(n int) []common.Hash {
+		hashes := make([]common.Hash, n)
+		for i := range hashes {
+			r.Read(hashes[i][:])
+		}
+		return hashes
+	}
+
+	var msg p2p.Message
+	switch r.Intn(4) {
+	case 0:
+		msg = &p2p.GetBlockHeaders{RequestId: r.Uint64(), GetBlockHeadersPacket: &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Number: r.Uint64()},
+			Amount: math.MaxUint64,
+			Skip:   math.MaxUint64,
+		}}
+	case 1:
+		msg = &p2p.GetBlockBodies{RequestId: r.Uint64(), GetBlockBodiesPacket: randomHashes(10000 + r.Intn(90000))}
+	case 2:
+		hashes := p2p.NewPooledTransactionHashes66(randomHashes(10000 + r.Intn(90000)))
+		msg = &hashes
+	default:
+		return code, nestedList(100 + r.Intn(1900))
+	}
+
+	oversized, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return code, payload
+	}
+	return uint64(msg.Code()), oversized
+}
+
+// nestedList returns an empty list nested in the given number of lists.
+func nestedList(depth int) []byte {
+	list := []byte{0xc0}
+	for i := 0; i < depth; i++ {
+		header := rlp.AppendUint64(nil, uint64(len(list)))
+		if len(list) < 56 {
+			list = append([]byte{0xc0 + byte(len(list))}, list...)
+			continue
+		}
+		// AppendUint64 prefixes the length with its own string header.
+		lengthBytes := header[1:]
+		if len(header) == 1 {
+			lengthBytes = header
+		}
+		list = append(append([]byte{0xf7 + byte(len(lengthBytes))}, lengthBytes...), list...)
+	}
+	return list
+}
+
+func mutateFlip(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	if len(payload) == 0 {
+		return code, payload
+	}
+	for n := 1 + r.Intn(8); n > 0; n-- {
+		payload[r.Intn(len(payload))] ^= byte(1 + r.Intn(255))
+	}
+	return code, payload
+}
+
+func mutateAppend(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	junk := make([]byte, 1+r.Intn(64))
+	r.Read(junk)
+	return code, append(payload, junk...)
+}
+
+func mutateEmpty(r *mrand.Rand, code uint64, payload []byte) (uint64, []byte) {
+	return code, nil
+}
@@ -0,0 +1,380 @@
+package headers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	// maxHeadersServe is the maximum number of headers peers serve per
+	// request.
+	maxHeadersServe = 1024
+)
+
+type (
+	headersParams struct {
+		From        uint64
+		To          uint64
+		Step        uint64
+		NetworkID   uint64
+		OutputFile  string
+		MaxAttempts int
+	}
+
+	// segment is a range of headers between two skeleton headers. The first
+	// header of the segment is the skeleton header itself.
+	segment struct {
+		index    int
+		from     uint64
+		amount   uint64
+		first    *types.Header
+		next     *types.Header
+		attempts int
+	}
+
+	result struct {
+		index   int
+		headers []*types.Header
+	}
+
+	peer struct {
+		conn   *p2p.Conn
+		name   string
+		status *p2p.Status
+	}
+)
+
+var (
+	inputHeadersParams headersParams
+)
+
+// HeadersCmd downloads and verifies a segment of the header chain from peers.
+var HeadersCmd = &cobra.Command{
+	Use:   "headers [enode/enr or nodes file]",
+	Short: "Download and verify a header chain segment directly from peers.",
+	Long: `Download the headers between --from and --to from peers using GetBlockHeaders,
+without needing an RPC endpoint.
+
+A skeleton of every --step header is requested from the first peer, and the gaps
+between the skeleton headers are filled in parallel by all peers. Every filled
+segment must link to the skeleton header that starts it and to the parent hash
+of the next one, otherwise it's requested again from another peer. This means
+the output is a single hash linked chain, but it's only as trustworthy as the
+peers. Compare the hash of the last header against a trusted source if needed.
+
+The headers are written in order as JSON, one per line.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputHeadersParams.To < inputHeadersParams.From {
+			return fmt.Errorf("--to must be greater or equal to --from")
+		}
+		if inputHeadersParams.Step == 0 || inputHeadersParams.Step > maxHeadersServe {
+			return fmt.Errorf("--step must be between 1 and %d", maxHeadersServe)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		var nodes []*enode.Node
+		if inputSet, err := p2p.LoadNodesJSON(args[0]); err == nil {
+			nodes = inputSet.Nodes()
+		} else if node, err := p2p.ParseNode(args[0]); err == nil {
+			nodes = append(nodes, node)
+		} else {
+			return err
+		}
+
+		peers := connect(ctx, nodes)
+		if len(peers) == 0 {
+			return fmt.Errorf("unable to peer with any of the %d nodes", len(nodes))
+		}
+		defer func() {
+			for _, p := range peers {
+				p.conn.Close()
+			}
+		}()
+		log.Info().Int("peers", len(peers)).Msg("Connected to peers")
+
+		skeleton, err := fetchSkeleton(ctx, peers[0])
+		if err != nil {
+			return fmt.Errorf("unable to fetch skeleton from %s: %w", peers[0].name, err)
+		}
+		log.Info().Int("headers", len(skeleton)).Msg("Fetched skeleton")
+
+		var w io.Writer = os.Stdout
+		if inputHeadersParams.OutputFile != "" {
+			f, err := os.Create(inputHeadersParams.OutputFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		bw := bufio.NewWriter(w)
+		defer bw.Flush()
+
+		return fill(ctx, peers, skeleton, json.NewEncoder(bw))
+	},
+}
+
+func init() {
+	HeadersCmd.Flags().Uint64Var(&inputHeadersParams.From, "from", 0, "The first block number of the segment.")
+	HeadersCmd.Flags().Uint64Var(&inputHeadersParams.To, "to", 0, "The last block number of the segment.")
+	HeadersCmd.Flags().Uint64Var(&inputHeadersParams.Step, "step", 192, "The distance between skeleton headers, which is also the number of headers per fill request.")
+	HeadersCmd.Flags().Uint64VarP(&inputHeadersParams.NetworkID, "network-id", "n", 0, "Only use peers with this network ID. (default any)")
+	HeadersCmd.Flags().StringVarP(&inputHeadersParams.OutputFile, "output", "o", "", "Write the headers to output file. (default stdout)")
+	HeadersCmd.Flags().IntVar(&inputHeadersParams.MaxAttempts, "max-attempts", 5, "The number of times a segment is requested before giving up.")
+	if err := HeadersCmd.MarkFlagRequired("to"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark to as required flag")
+	}
+}
+
+// connect peers with the nodes in parallel and returns the peers that are on
+// the expected network.
+func connect(ctx context.Context, nodes []*enode.Node) []*peer {
+	var (
+		peers []*peer
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, 16)
+	)
+
+	client := p2p.NewClient(p2p.ClientConfig{})
+	for _, n := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n *enode.Node) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			conn, hello, status, err := client.Peer(ctx, n)
+			if err != nil {
+				log.Debug().Err(err).Str("node", n.String()).Msg("Peer failed")
+				return
+			}
+			if inputHeadersParams.NetworkID != 0 && status.NetworkID != inputHeadersParams.NetworkID {
+				log.Debug().Uint64("networkId", status.NetworkID).Str("node", n.String()).Msg("Skipping peer on a different network")
+				conn.Close()
+				return
+			}
+
+			mu.Lock()
+			peers = append(peers, &peer{conn: conn, name: hello.Name, status: status})
+			mu.Unlock()
+		}(n)
+	}
+	wg.Wait()
+
+	return peers
+}
+
+// fetchSkeleton requests every step header between from and to, and the to
+// header itself so the last segment can be verified as well.
+func fetchSkeleton(ctx context.Context, p *peer) ([]*types.Header, error) {
+	var (
+		from = inputHeadersParams.From
+		to   = inputHeadersParams.To
+		step = inputHeadersParams.Step
+	)
+
+	var skeleton []*types.Header
+	for number := from; number <= to; {
+		amount := (to-number)/step + 1
+		if amount > maxHeadersServe {
+			amount = maxHeadersServe
+		}
+
+		headers, err := p.conn.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Number: number},
+			Amount: amount,
+			Skip:   step - 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(headers)) != amount {
+			return nil, fmt.Errorf("expected %d skeleton headers, got %d", amount, len(headers))
+		}
+		for i, h := range headers {
+			if expected := number + uint64(i)*step; h.Number.Uint64() != expected {
+				return nil, fmt.Errorf("expected skeleton header %d, got %d", expected, h.Number.Uint64())
+			}
+		}
+
+		skeleton = append(skeleton, headers...)
+		number += amount * step
+	}
+
+	last := skeleton[len(skeleton)-1]
+	if last.Number.Uint64() != to {
+		headers, err := p.conn.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Number: to},
+			Amount: 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(headers) != 1 || headers[0].Number.Uint64() != to {
+			return nil, fmt.Errorf("peer didn't return header %d", to)
+		}
+		skeleton = append(skeleton, headers[0])
+	}
+
+	return skeleton, nil
+}
+
+// verifySegment checks that the headers link to each other, start with the
+// first skeleton header, and that the next skeleton header is their child.
+func verifySegment(s *segment, headers []*types.Header) error {
+	if uint64(len(headers)) != s.amount {
+		return fmt.Errorf("expected %d headers, got %d", s.amount, len(headers))
+	}
+	if headers[0].Hash() != s.first.Hash() {
+		return fmt.Errorf("header %d doesn't match the skeleton", s.from)
+	}
+	for i := 1; i < len(headers); i++ {
+		if headers[i].Number.Uint64() != s.from+uint64(i) {
+			return fmt.Errorf("expected header %d, got %d", s.from+uint64(i), headers[i].Number.Uint64())
+		}
+		if headers[i].ParentHash != headers[i-1].Hash() {
+			return fmt.Errorf("header %d doesn't link to its parent", headers[i].Number.Uint64())
+		}
+	}
+	if s.next != nil && s.next.ParentHash != headers[len(headers)-1].Hash() {
+		return fmt.Errorf("skeleton header %d doesn't link to the segment", s.next.Number.Uint64())
+	}
+	return nil
+}
+
+// fill requests the segments between the skeleton headers from all peers and
+// writes the verified headers in order.
+func fill(ctx context.Context, peers []*peer, skeleton []*types.Header, enc *json.Encoder) error {
+	var segments []*segment
+	for i, h := range skeleton {
+		s := &segment{index: i, from: h.Number.Uint64(), amount: 1, first: h}
+		if i+1 < len(skeleton) {
+			s.next = skeleton[i+1]
+			s.amount = s.next.Number.Uint64() - s.from
+		}
+		segments = append(segments, s)
+	}
+
+	var (
+		tasks   = make(chan *segment, len(segments))
+		results = make(chan result)
+		errCh   = make(chan error, 1)
+		wg      sync.WaitGroup
+	)
+	for _, s := range segments {
+		tasks <- s
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p *peer) {
+			defer wg.Done()
+			for {
+				var s *segment
+				select {
+				case s = <-tasks:
+				case <-ctx.Done():
+					return
+				}
+
+				headers, reqErr := p.conn.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+					Origin: eth.HashOrNumber{Number: s.from},
+					Amount: s.amount,
+				})
+				err := reqErr
+				if err == nil {
+					err = verifySegment(s, headers)
+				}
+				if err == nil {
+					select {
+					case results <- result{s.index, headers}:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+
+				log.Warn().Err(err).Str("peer", p.name).Uint64("from", s.from).Msg("Segment failed")
+				if s.attempts++; s.attempts >= inputHeadersParams.MaxAttempts {
+					select {
+					case errCh <- fmt.Errorf("segment from %d failed %d times: %w", s.from, s.attempts, err):
+					default:
+					}
+					return
+				}
+				tasks <- s
+
+				// Drop the peer if the request failed rather than the
+				// verification, the segment will be picked up by the other
+				// peers.
+				if reqErr != nil {
+					return
+				}
+			}
+		}(p)
+	}
+
+	// Close the results once all peers are gone so that the writer doesn't
+	// wait forever.
+	peersDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(peersDone)
+	}()
+
+	pending := make(map[int][]*types.Header)
+	for next := 0; next < len(segments); {
+		select {
+		case r := <-results:
+			pending[r.index] = r.headers
+		case err := <-errCh:
+			return err
+		case <-peersDone:
+			return fmt.Errorf("no peers left, %d of %d segments written", next, len(segments))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		for headers, ok := pending[next]; ok; headers, ok = pending[next] {
+			for _, h := range headers {
+				if err := enc.Encode(h); err != nil {
+					return err
+				}
+			}
+			delete(pending, next)
+			next++
+			log.Debug().Int("segment", next).Int("segments", len(segments)).Msg("Wrote segment")
+		}
+	}
+
+	last := skeleton[len(skeleton)-1]
+	log.Info().
+		Uint64("from", inputHeadersParams.From).
+		Uint64("to", last.Number.Uint64()).
+		Str("hash", last.Hash().Hex()).
+		Msg("Header chain verified")
+	return nil
+}
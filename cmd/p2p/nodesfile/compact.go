@@ -0,0 +1,85 @@
+package nodesfile
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type compactParams struct {
+	MaxAge   time.Duration
+	MinScore int
+	Limit    int
+	Strip    bool
+}
+
+var inputCompactParams compactParams
+
+var compactCmd = &cobra.Command{
+	Use:   "compact [input nodes file] [output nodes file]",
+	Short: "Shrink a nodes file by dropping stale nodes and indentation.",
+	Long: `Rewrite a nodes file without indentation, dropping invalid entries, nodes that
+haven't responded within --max-age, and nodes with a score below --min-score.
+With --limit, only the nodes with the highest scores are kept, and --strip
+migrates the file to schema version 1 to drop the derived fields. The output
+file is - for stdout, and defaults to overwriting the input file.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output := args[0]
+		if len(args) > 1 {
+			output = args[1]
+		}
+
+		nodes, skipped, err := loadNodes(args[0])
+		if err != nil {
+			return err
+		}
+		before := len(nodes)
+
+		now := time.Now()
+		for id, node := range nodes {
+			if expired(node, inputCompactParams.MaxAge, now) || node.Score < inputCompactParams.MinScore {
+				delete(nodes, id)
+				continue
+			}
+			if inputCompactParams.Strip {
+				node.Migrate(p2p.NodesSchemaV1)
+				nodes[id] = node
+			}
+		}
+
+		if limit := inputCompactParams.Limit; limit > 0 && len(nodes) > limit {
+			ranked := make([]p2p.NodeJSON, 0, len(nodes))
+			for _, node := range nodes {
+				ranked = append(ranked, node)
+			}
+			sort.Slice(ranked, func(i, j int) bool {
+				if ranked[i].Score != ranked[j].Score {
+					return ranked[i].Score > ranked[j].Score
+				}
+				return ranked[i].LastResponse.After(ranked[j].LastResponse)
+			})
+			for _, node := range ranked[limit:] {
+				delete(nodes, node.N.ID())
+			}
+		}
+
+		log.Info().
+			Int("invalid", skipped).
+			Int("removed", before-len(nodes)).
+			Int("nodes", len(nodes)).
+			Msg("Compacted nodes file")
+		return p2p.WriteNodesJSONCompact(output, nodes)
+	},
+}
+
+func init() {
+	compactCmd.Flags().DurationVar(&inputCompactParams.MaxAge, "max-age", 0, "Drop nodes that haven't responded for longer than this (0 to keep them).")
+	compactCmd.Flags().IntVar(&inputCompactParams.MinScore, "min-score", 0, "Drop nodes with a lower score than this.")
+	compactCmd.Flags().IntVar(&inputCompactParams.Limit, "limit", 0, "Keep at most this many nodes, the ones with the highest scores (0 for no limit).")
+	compactCmd.Flags().BoolVar(&inputCompactParams.Strip, "strip", false, "Drop the ENR entries, address family, and fingerprint of the nodes.")
+}
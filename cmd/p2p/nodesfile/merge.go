@@ -0,0 +1,111 @@
+package nodesfile
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+// The strategies to resolve nodes that are in more than one file.
+const (
+	preferNewest = "newest"
+	preferFirst  = "first"
+	preferLast   = "last"
+)
+
+type mergeParams struct {
+	Output  string
+	Prefer  string
+	Compact bool
+}
+
+var inputMergeParams mergeParams
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [nodes files...]",
+	Short: "Merge nodes files into one.",
+	Long: `Merge nodes files, for example from several crawlers or sensors, into one. When
+a node is in more than one file, --prefer decides which entry is kept: newest
+keeps the record with the highest seq, or the most recent response if the seqs
+are equal, and combines the response times and scores of the entries, while
+first and last keep the entry of the first or last file it's in. Invalid
+entries are dropped.`,
+	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch inputMergeParams.Prefer {
+		case preferNewest, preferFirst, preferLast:
+			return nil
+		default:
+			return fmt.Errorf("unknown conflict resolution %q", inputMergeParams.Prefer)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		merged := make(p2p.NodeSet)
+		var conflicts int
+		for _, file := range args {
+			nodes, skipped, err := loadNodes(file)
+			if err != nil {
+				return err
+			}
+			if skipped > 0 {
+				log.Warn().Str("file", file).Int("dropped", skipped).Msg("Dropped invalid entries")
+			}
+
+			for id, node := range nodes {
+				existing, ok := merged[id]
+				if !ok {
+					merged[id] = node
+					continue
+				}
+				conflicts++
+				merged[id] = resolve(existing, node, inputMergeParams.Prefer)
+			}
+		}
+
+		log.Info().Int("files", len(args)).Int("nodes", len(merged)).Int("conflicts", conflicts).Msg("Merged nodes files")
+		return writeNodes(inputMergeParams.Output, merged, inputMergeParams.Compact)
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVarP(&inputMergeParams.Output, "output", "o", "-", "Where to write the merged nodes file (- for stdout).")
+	mergeCmd.Flags().StringVar(&inputMergeParams.Prefer, "prefer", preferNewest, "How to resolve nodes in more than one file (newest|first|last).")
+	mergeCmd.Flags().BoolVar(&inputMergeParams.Compact, "compact", false, "Write the merged nodes file without indentation.")
+}
+
+// resolve returns the entry that is kept of the node in the merged file and
+// one of the files merged into it.
+func resolve(merged, node p2p.NodeJSON, prefer string) p2p.NodeJSON {
+	switch prefer {
+	case preferFirst:
+		return merged
+	case preferLast:
+		return node
+	}
+
+	keep, other := merged, node
+	if node.N.Seq() > merged.N.Seq() ||
+		(node.N.Seq() == merged.N.Seq() && node.LastResponse.After(merged.LastResponse)) {
+		keep, other = node, merged
+	}
+
+	if !other.FirstResponse.IsZero() && (keep.FirstResponse.IsZero() || other.FirstResponse.Before(keep.FirstResponse)) {
+		keep.FirstResponse = other.FirstResponse
+	}
+	if other.LastResponse.After(keep.LastResponse) {
+		keep.LastResponse = other.LastResponse
+	}
+	if other.LastCheck.After(keep.LastCheck) {
+		keep.LastCheck = other.LastCheck
+	}
+	if other.Score > keep.Score {
+		keep.Score = other.Score
+	}
+	if keep.Fingerprint == nil {
+		keep.Fingerprint = other.Fingerprint
+	}
+	return keep
+}
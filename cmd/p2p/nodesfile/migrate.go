@@ -0,0 +1,47 @@
+package nodesfile
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+var inputMigrateVersion int
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [input nodes file] [output nodes file]",
+	Short: "Convert a nodes file to another schema version.",
+	Long: `Convert a nodes file or a list of enode URLs or ENRs to the schema version set
+with --to. Migrating to version 2 derives the ENR entries and the address family
+from the records, and migrating to version 1 drops the fields added in version 2
+so the file can be read by the geth devp2p tool. Invalid entries are dropped.
+The output file is - for stdout.`,
+	Args: cobra.ExactArgs(2),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputMigrateVersion != p2p.NodesSchemaV1 && inputMigrateVersion != p2p.NodesSchemaV2 {
+			return fmt.Errorf("unsupported schema version %d", inputMigrateVersion)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nodes, skipped, err := loadNodes(args[0])
+		if err != nil {
+			return err
+		}
+
+		for id, node := range nodes {
+			node.Migrate(inputMigrateVersion)
+			nodes[id] = node
+		}
+
+		log.Info().Int("nodes", len(nodes)).Int("dropped", skipped).Int("schema", inputMigrateVersion).Msg("Migrated nodes file")
+		return p2p.WriteNodesJSON(args[1], nodes)
+	},
+}
+
+func init() {
+	migrateCmd.Flags().IntVar(&inputMigrateVersion, "to", p2p.NodesSchemaV2, "Schema version to migrate to (1 or 2).")
+}
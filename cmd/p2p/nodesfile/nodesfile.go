@@ -0,0 +1,164 @@
+package nodesfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+// NodesFileCmd groups the commands that maintain nodes files.
+var NodesFileCmd = &cobra.Command{
+	Use:   "nodes-file",
+	Short: "Validate, migrate, merge, and compact nodes files.",
+	Long: `Maintain the nodes files written by the crawl and the sensor as their schema
+evolves. There are two schema versions: version 1 is the schema of the geth
+devp2p tool, and version 2 adds the decoded ENR entries, the address family, and
+the fingerprint of the nodes. Lists of enode URLs or ENRs, like the static nodes
+file of geth, are read as well.`,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	NodesFileCmd.AddCommand(validateCmd)
+	NodesFileCmd.AddCommand(migrateCmd)
+	NodesFileCmd.AddCommand(mergeCmd)
+	NodesFileCmd.AddCommand(compactCmd)
+}
+
+// rawNode is a single entry of a nodes file before it's decoded, so that
+// invalid entries don't fail reading the rest of the file.
+type rawNode struct {
+	Key string
+	Raw json.RawMessage
+}
+
+// readNodes reads the entries of the nodes file one at a time and calls fn for
+// each of them, in the order of the file and including duplicate keys. Entries
+// of lists of URLs are turned into version 1 entries keyed by their node ID.
+func readNodes(file string, fn func(rawNode) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", file, err)
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", file, err)
+			}
+			key, _ := tok.(string)
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return fmt.Errorf("unable to read %s: %w", file, err)
+			}
+			if err := fn(rawNode{Key: key, Raw: raw}); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			var url string
+			if err := dec.Decode(&url); err != nil {
+				return fmt.Errorf("unable to read %s: %w", file, err)
+			}
+			entry, err := urlEntry(url)
+			if err != nil {
+				return fmt.Errorf("invalid node %q in %s: %w", url, file, err)
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s is neither a nodes file nor a list of nodes", file)
+	}
+
+	if _, err := dec.Token(); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to read %s: %w", file, err)
+	}
+	return nil
+}
+
+func urlEntry(url string) (rawNode, error) {
+	n, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return rawNode{}, err
+	}
+	raw, err := json.Marshal(p2p.NodeJSON{Seq: n.Seq(), N: n})
+	if err != nil {
+		return rawNode{}, err
+	}
+	return rawNode{Key: n.ID().String(), Raw: raw}, nil
+}
+
+// decode decodes the entry and checks that its key matches the record.
+func (r rawNode) decode() (enode.ID, p2p.NodeJSON, error) {
+	var node p2p.NodeJSON
+	id, err := enode.ParseID(r.Key)
+	if err != nil {
+		return id, node, fmt.Errorf("invalid node ID: %w", err)
+	}
+	if err := json.Unmarshal(r.Raw, &node); err != nil {
+		return id, node, fmt.Errorf("invalid record: %w", err)
+	}
+	if node.N == nil {
+		return id, node, errors.New("missing record")
+	}
+	if node.N.ID() != id {
+		return id, node, fmt.Errorf("node ID doesn't match the record ID %s", node.N.ID())
+	}
+	return id, node, nil
+}
+
+// loadNodes reads the valid entries of the nodes file, and returns the number
+// of entries that were skipped because they're invalid.
+func loadNodes(file string) (p2p.NodeSet, int, error) {
+	nodes := make(p2p.NodeSet)
+	var skipped int
+	err := readNodes(file, func(r rawNode) error {
+		id, node, err := r.decode()
+		if err != nil {
+			skipped++
+			return nil
+		}
+		nodes[id] = node
+		return nil
+	})
+	return nodes, skipped, err
+}
+
+// expired returns whether the node hasn't responded within the max age. Nodes
+// that were checked but never responded are expired as well.
+func expired(node p2p.NodeJSON, maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	if node.LastResponse.IsZero() {
+		return !node.LastCheck.IsZero()
+	}
+	return now.Sub(node.LastResponse) > maxAge
+}
+
+func writeNodes(file string, nodes p2p.NodeSet, compact bool) error {
+	if compact {
+		return p2p.WriteNodesJSONCompact(file, nodes)
+	}
+	return p2p.WriteNodesJSON(file, nodes)
+}
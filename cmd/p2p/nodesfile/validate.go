@@ -0,0 +1,124 @@
+package nodesfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type (
+	validateParams struct {
+		MaxAge time.Duration
+	}
+
+	nodeProblem struct {
+		Key     string `json:"key"`
+		Problem string `json:"problem"`
+	}
+
+	validateReport struct {
+		File       string        `json:"file"`
+		Schema     int           `json:"schema"`
+		Nodes      int           `json:"nodes"`
+		Valid      int           `json:"valid"`
+		Invalid    int           `json:"invalid"`
+		Duplicates int           `json:"duplicates"`
+		Expired    int           `json:"expired"`
+		Problems   []nodeProblem `json:"problems,omitempty"`
+	}
+)
+
+var inputValidateParams validateParams
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [nodes file]",
+	Short: "Check a nodes file for invalid, duplicate, and expired records.",
+	Long: `Check every entry of a nodes file and report the problems as JSON. Entries are
+invalid if the record can't be decoded or its signature is wrong, the key isn't
+the ID of the record, or the seq doesn't match the record. Keys that appear
+more than once are reported as duplicates, and nodes that haven't responded
+within --max-age as expired. The command fails if any problems are found.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report, err := validate(args[0], inputValidateParams.MaxAge, time.Now())
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+
+		if len(report.Problems) > 0 {
+			return fmt.Errorf("found %d problems in %s", len(report.Problems), args[0])
+		}
+		log.Info().Int("nodes", report.Nodes).Msg("Nodes file is valid")
+		return nil
+	},
+}
+
+func init() {
+	validateCmd.Flags().DurationVar(&inputValidateParams.MaxAge, "max-age", 24*time.Hour,
+		"Report nodes that haven't responded for longer than this as expired (0 to disable).")
+}
+
+func validate(file string, maxAge time.Duration, now time.Time) (validateReport, error) {
+	report := validateReport{File: file, Schema: p2p.NodesSchemaV1}
+	seen := make(map[string]struct{})
+	records := make(map[enode.ID]string)
+	problem := func(key, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, nodeProblem{Key: key, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	err := readNodes(file, func(r rawNode) error {
+		report.Nodes++
+		if _, ok := seen[r.Key]; ok {
+			report.Duplicates++
+			problem(r.Key, "duplicate node ID")
+			return nil
+		}
+		seen[r.Key] = struct{}{}
+
+		id, node, err := r.decode()
+		if err != nil {
+			report.Invalid++
+			problem(r.Key, "%v", err)
+			return nil
+		}
+		if key, ok := records[id]; ok {
+			report.Duplicates++
+			problem(r.Key, "same record as %s", key)
+			return nil
+		}
+		records[id] = r.Key
+
+		if node.SchemaVersion() > report.Schema {
+			report.Schema = node.SchemaVersion()
+		}
+		if node.Seq != node.N.Seq() {
+			report.Invalid++
+			problem(r.Key, "seq %d doesn't match the record seq %d", node.Seq, node.N.Seq())
+			return nil
+		}
+		if expired(node, maxAge, now) {
+			report.Expired++
+			if node.LastResponse.IsZero() {
+				problem(r.Key, "never responded")
+			} else {
+				problem(r.Key, "expired, last response %s ago", now.Sub(node.LastResponse).Truncate(time.Second))
+			}
+			return nil
+		}
+		report.Valid++
+		return nil
+	})
+	return report, err
+}
@@ -0,0 +1,284 @@
+package orderflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p/database"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	orderflowParams struct {
+		ProjectID  string
+		SensorID   string
+		From       uint64
+		To         uint64
+		Threads    int
+		OutputFile string
+
+		JaipurBlock uint64
+	}
+
+	blockReport struct {
+		Number       uint64         `json:"number"`
+		Hash         common.Hash    `json:"hash"`
+		Producer     common.Address `json:"producer"`
+		FirstSeen    time.Time      `json:"firstSeen"`
+		Transactions int            `json:"transactions"`
+		Private      int            `json:"private"`
+	}
+
+	producerReport struct {
+		Address      common.Address `json:"address"`
+		Blocks       int            `json:"blocks"`
+		Transactions int            `json:"transactions"`
+		Private      int            `json:"private"`
+		PrivateRatio float64        `json:"privateRatio"`
+	}
+
+	report struct {
+		From         uint64            `json:"from"`
+		To           uint64            `json:"to"`
+		Transactions int               `json:"transactions"`
+		Private      int               `json:"private"`
+		PrivateRatio float64           `json:"privateRatio"`
+		Producers    []*producerReport `json:"producers"`
+		Blocks       []*blockReport    `json:"blocks"`
+	}
+)
+
+var (
+	inputOrderflowParams orderflowParams
+)
+
+// OrderflowCmd reports the transactions of mined blocks that the sensors never
+// saw in the public mempool.
+var OrderflowCmd = &cobra.Command{
+	Use:   "orderflow",
+	Short: "Report private order flow per block producer from sensor data.",
+	Long: `Analyze the blocks and transactions written to datastore by the sensors, and
+report how many transactions of each block were never received from a peer
+before the block itself was received. These transactions were most likely sent
+to the block producer privately, for example by searchers or builders.
+
+The block is considered received when the first block event was written, or at
+the header timestamp if no sensor wrote one. The producer is the coinbase of the
+block, or the signer recovered from the bor seal in the extra data if the
+coinbase is empty, which is the case for Polygon PoS and other clique based
+chains. Bor seals only cover the base fee from --jaipur-block, which is also set
+by --network.
+
+The report is aggregated per producer and includes every block found between
+--from and --to. Blocks that weren't written by the sensors are skipped. The
+results are only as complete as the sensor coverage of the network, so a high
+number of private transactions can also mean the sensors are poorly connected.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputOrderflowParams.To < inputOrderflowParams.From {
+			return fmt.Errorf("--to must be greater or equal to --from")
+		}
+		if inputOrderflowParams.Threads < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		db, err := database.NewDatastoreReader(ctx, inputOrderflowParams.ProjectID)
+		if err != nil {
+			return fmt.Errorf("unable to connect to datastore: %w", err)
+		}
+		defer db.Close()
+
+		r := report{
+			From: inputOrderflowParams.From,
+			To:   inputOrderflowParams.To,
+		}
+		producers := make(map[common.Address]*producerReport)
+
+		for number := inputOrderflowParams.From; number <= inputOrderflowParams.To; number++ {
+			blocks, err := db.BlocksByNumber(ctx, number)
+			if err != nil {
+				return fmt.Errorf("unable to get block %d: %w", number, err)
+			}
+			if len(blocks) == 0 {
+				log.Warn().Uint64("number", number).Msg("Block not found")
+			}
+
+			for hash, block := range blocks {
+				b, err := analyzeBlock(ctx, db, hash, block)
+				if err != nil {
+					return err
+				}
+				if b == nil {
+					continue
+				}
+				log.Debug().Interface("block", b).Msg("Analyzed block")
+
+				r.Blocks = append(r.Blocks, b)
+				r.Transactions += b.Transactions
+				r.Private += b.Private
+
+				p, ok := producers[b.Producer]
+				if !ok {
+					p = &producerReport{Address: b.Producer}
+					producers[b.Producer] = p
+				}
+				p.Blocks++
+				p.Transactions += b.Transactions
+				p.Private += b.Private
+			}
+		}
+
+		r.PrivateRatio = ratio(r.Private, r.Transactions)
+		for _, p := range producers {
+			p.PrivateRatio = ratio(p.Private, p.Transactions)
+			r.Producers = append(r.Producers, p)
+		}
+		sort.Slice(r.Producers, func(i, j int) bool {
+			return r.Producers[i].Private > r.Producers[j].Private
+		})
+		sort.SliceStable(r.Blocks, func(i, j int) bool {
+			return r.Blocks[i].Number < r.Blocks[j].Number
+		})
+
+		var w io.Writer = os.Stdout
+		if inputOrderflowParams.OutputFile != "" {
+			f, err := os.Create(inputOrderflowParams.OutputFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	},
+}
+
+func init() {
+	OrderflowCmd.Flags().StringVarP(&inputOrderflowParams.ProjectID, "project-id", "P", "", "GCP project ID of the sensor datastore.")
+	OrderflowCmd.Flags().StringVarP(&inputOrderflowParams.SensorID, "sensor-id", "s", "", "Only use the events of this sensor. (default all sensors)")
+	OrderflowCmd.Flags().Uint64Var(&inputOrderflowParams.From, "from", 0, "The first block number to analyze.")
+	OrderflowCmd.Flags().Uint64Var(&inputOrderflowParams.To, "to", 0, "The last block number to analyze.")
+	OrderflowCmd.Flags().IntVarP(&inputOrderflowParams.Threads, "parallel", "p", 16, "How many transaction events to query in parallel.")
+	OrderflowCmd.Flags().StringVarP(&inputOrderflowParams.OutputFile, "output", "o", "", "Write the report to output file. (default stdout)")
+	OrderflowCmd.Flags().Uint64Var(&inputOrderflowParams.JaipurBlock, "jaipur-block", 0, "The bor Jaipur block, from which the seals cover the base fee.")
+	if err := OrderflowCmd.MarkFlagRequired("project-id"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark project-id as required flag")
+	}
+	if err := OrderflowCmd.MarkFlagRequired("to"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark to as required flag")
+	}
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// analyzeBlock counts the transactions of the block that were first received
+// after the block. It returns nil for header only blocks, since the
+// transactions aren't known.
+func analyzeBlock(ctx context.Context, db *database.DatastoreReader, hash common.Hash, block *database.DatastoreBlock) (*blockReport, error) {
+	if block.DatastoreHeader == nil || block.Transactions == nil {
+		return nil, nil
+	}
+
+	header, err := block.Header()
+	if err != nil {
+		return nil, fmt.Errorf("invalid header of block %s: %w", hash, err)
+	}
+
+	firstSeen, err := db.FirstBlockEvent(ctx, hash, inputOrderflowParams.SensorID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get block events of %s: %w", hash, err)
+	}
+	if firstSeen.IsZero() {
+		firstSeen = block.Time
+	}
+
+	b := &blockReport{
+		Number:       header.Number.Uint64(),
+		Hash:         hash,
+		Producer:     header.Coinbase,
+		FirstSeen:    firstSeen,
+		Transactions: len(block.Transactions),
+	}
+	if b.Producer == (common.Address{}) {
+		b.Producer = signer(hash, header)
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, inputOrderflowParams.Threads)
+		errs  []error
+		count int
+	)
+	for _, key := range block.Transactions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key *datastore.Key) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			seen, err := db.FirstTransactionEvent(ctx, key, inputOrderflowParams.SensorID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to get transaction events of %s: %w", key.Name, err))
+				return
+			}
+			if seen.IsZero() || !seen.Before(firstSeen) {
+				count++
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	b.Private = count
+
+	return b, nil
+}
+
+// signer recovers the address that sealed the header. The zero address is
+// returned if the header isn't sealed the bor way.
+func signer(hash common.Hash, header *types.Header) common.Address {
+	// Make sure the header was converted back exactly, otherwise the
+	// recovered signer would be a random address.
+	if header.Hash() != hash {
+		log.Warn().Str("hash", hash.Hex()).Msg("Stored header doesn't match its hash")
+		return common.Address{}
+	}
+
+	signer, err := util.BorSealSigner(header, inputOrderflowParams.JaipurBlock)
+	if err != nil {
+		return common.Address{}
+	}
+	return signer
+}
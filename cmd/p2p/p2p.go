@@ -5,8 +5,15 @@ import (
 
 	_ "embed"
 
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/benchhandshake"
 	"github.com/maticnetwork/polygon-cli/cmd/p2p/crawl"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/exporter"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/fuzz"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/headers"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/nodesfile"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/orderflow"
 	"github.com/maticnetwork/polygon-cli/cmd/p2p/ping"
+	"github.com/maticnetwork/polygon-cli/cmd/p2p/prove"
 	"github.com/maticnetwork/polygon-cli/cmd/p2p/sensor"
 )
 
@@ -23,4 +30,11 @@ func init() {
 	P2pCmd.AddCommand(sensor.SensorCmd)
 	P2pCmd.AddCommand(crawl.CrawlCmd)
 	P2pCmd.AddCommand(ping.PingCmd)
+	P2pCmd.AddCommand(prove.ProveCmd)
+	P2pCmd.AddCommand(headers.HeadersCmd)
+	P2pCmd.AddCommand(orderflow.OrderflowCmd)
+	P2pCmd.AddCommand(exporter.ExporterCmd)
+	P2pCmd.AddCommand(fuzz.FuzzCmd)
+	P2pCmd.AddCommand(nodesfile.NodesFileCmd)
+	P2pCmd.AddCommand(benchhandshake.BenchHandshakeCmd)
 }
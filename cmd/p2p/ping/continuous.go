@@ -0,0 +1,151 @@
+package ping
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	pingModeHandshake = "handshake"
+	pingModePing      = "ping"
+)
+
+// latencyStats summarizes the round trip times of the pings of a node in
+// milliseconds, and the share of the pings that weren't answered.
+type latencyStats struct {
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+	Loss     float64 `json:"loss"`
+	Min      float64 `json:"minMs,omitempty"`
+	Avg      float64 `json:"avgMs,omitempty"`
+	P95      float64 `json:"p95Ms,omitempty"`
+	P99      float64 `json:"p99Ms,omitempty"`
+	Max      float64 `json:"maxMs,omitempty"`
+}
+
+// pingContinuously pings the node every interval until the count is reached,
+// or until the context is cancelled if the count is zero. In the handshake
+// mode every ping is a new connection, and the round trip time includes the
+// dial, the encryption handshake, and the status exchange. In the ping mode a
+// single connection is kept, and redialed if it fails, to send devp2p pings.
+// In the discovery mode discv4 pings are sent over UDP.
+func pingContinuously(ctx context.Context, node *enode.Node) (*p2p.Hello, *p2p.Status, latencyStats) {
+	var (
+		hello  *p2p.Hello
+		status *p2p.Status
+		conn   *p2p.Conn
+		disc   *discv4Conn
+		rtts   []time.Duration
+		sent   int
+	)
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	// peer keeps the hello and status of the last successful connection.
+	client := p2p.NewClient(p2p.ClientConfig{})
+	peer := func() (*p2p.Conn, error) {
+		c, h, s, err := client.Peer(ctx, node)
+		if err == nil {
+			hello, status = h, s
+		}
+		return c, err
+	}
+
+	for seq := 1; inputPingParams.Count == 0 || seq <= inputPingParams.Count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(inputPingParams.interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		sent++
+
+		var (
+			rtt time.Duration
+			err error
+		)
+		if inputPingParams.Mode == pingModeDiscovery {
+			if disc == nil {
+				if disc, err = newDiscv4Conn(node); err != nil {
+					log.Error().Err(err).Str("node", node.URLv4()).Msg("Unable to send discovery packets")
+					return hello, status, newLatencyStats(sent-1, rtts)
+				}
+				defer disc.close()
+			}
+			rtt, _, err = disc.ping()
+		} else if inputPingParams.Mode == pingModePing {
+			if conn == nil {
+				conn, err = peer()
+			}
+			if err == nil {
+				if rtt, err = conn.Ping(ctx); err != nil {
+					conn.Close()
+					conn = nil
+				}
+			}
+		} else {
+			start := time.Now()
+			var c *p2p.Conn
+			if c, err = peer(); err == nil {
+				rtt = time.Since(start)
+				c.Close()
+			}
+		}
+
+		if err != nil {
+			// Pings interrupted by the shutdown aren't lost.
+			if ctx.Err() != nil {
+				sent--
+				break
+			}
+			log.Warn().Err(err).Str("node", node.URLv4()).Int("seq", seq).Msg("Ping failed")
+			continue
+		}
+
+		rtts = append(rtts, rtt)
+		log.Info().Str("node", node.URLv4()).Int("seq", seq).Dur("rtt", rtt).Msg("Ping")
+	}
+
+	return hello, status, newLatencyStats(sent, rtts)
+}
+
+func newLatencyStats(sent int, rtts []time.Duration) latencyStats {
+	stats := latencyStats{Sent: sent, Received: len(rtts)}
+	if sent > 0 {
+		stats.Loss = float64(sent-len(rtts)) / float64(sent)
+	}
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	ms := make([]float64, 0, len(rtts))
+	var total float64
+	for _, rtt := range rtts {
+		v := float64(rtt) / float64(time.Millisecond)
+		ms = append(ms, v)
+		total += v
+	}
+	sort.Float64s(ms)
+	percentile := func(p float64) float64 {
+		return ms[int(p*float64(len(ms)-1))]
+	}
+
+	stats.Min = ms[0]
+	stats.Avg = total / float64(len(ms))
+	stats.P95 = percentile(0.95)
+	stats.P99 = percentile(0.99)
+	stats.Max = ms[len(ms)-1]
+	return stats
+}
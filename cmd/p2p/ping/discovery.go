@@ -0,0 +1,341 @@
+package ping
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/discover/v4wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	pingModeDiscovery = "discovery"
+
+	// discoveryTimeout limits waiting for each discovery response.
+	discoveryTimeout = 2 * time.Second
+	// neighborsWait is how long to wait for more neighbors packets after the
+	// first one, since the nodes are split over several packets.
+	neighborsWait = 500 * time.Millisecond
+	// maxNeighbors is the number of nodes returned for a findnode request.
+	maxNeighbors = 16
+)
+
+type (
+	// discoveryEndpoint is the endpoint the node advertises in its record.
+	discoveryEndpoint struct {
+		IP  string `json:"ip"`
+		UDP int    `json:"udp"`
+		TCP int    `json:"tcp"`
+	}
+
+	// discoveryResult is what a node answered on a discovery protocol.
+	discoveryResult struct {
+		Reachable bool               `json:"reachable"`
+		RTT       float64            `json:"rttMs,omitempty"`
+		ENRSeq    uint64             `json:"enrSeq,omitempty"`
+		Neighbors *int               `json:"neighbors,omitempty"`
+		Record    *enode.Node        `json:"record,omitempty"`
+		Endpoint  *discoveryEndpoint `json:"endpoint,omitempty"`
+		Error     string             `json:"error,omitempty"`
+	}
+
+	discoveryResults struct {
+		V4 discoveryResult `json:"discv4"`
+		V5 discoveryResult `json:"discv5"`
+	}
+
+	// discv4Conn sends discv4 packets to a single node. The node's pings are
+	// answered while waiting for responses, which is the endpoint proof the
+	// node needs before it answers findnode and ENR requests.
+	discv4Conn struct {
+		conn   *net.UDPConn
+		key    *ecdsa.PrivateKey
+		to     *net.UDPAddr
+		id     enode.ID
+		pinged bool
+	}
+)
+
+// pingDiscovery pings the node on both discovery protocols without a TCP
+// connection, which tells UDP reachability apart from RLPx reachability.
+func pingDiscovery(node *enode.Node) discoveryResults {
+	return discoveryResults{
+		V4: pingDiscv4(node),
+		V5: pingDiscv5(node),
+	}
+}
+
+func pingDiscv4(node *enode.Node) discoveryResult {
+	var result discoveryResult
+	c, err := newDiscv4Conn(node)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer c.close()
+
+	rtt, pong, err := c.ping()
+	if err != nil {
+		result.Error = fmt.Sprintf("ping failed: %v", err)
+		return result
+	}
+	result.Reachable = true
+	result.RTT = float64(rtt) / float64(time.Millisecond)
+	result.ENRSeq = pong.ENRSeq
+
+	var errs []string
+	c.waitPing()
+	if neighbors, err := c.findnode(); err != nil {
+		errs = append(errs, fmt.Sprintf("findnode failed: %v", err))
+	} else {
+		result.Neighbors = &neighbors
+	}
+	if record, err := c.requestENR(); err != nil {
+		errs = append(errs, fmt.Sprintf("enr request failed: %v", err))
+	} else {
+		result.Record = record
+		result.Endpoint = newDiscoveryEndpoint(record)
+	}
+	result.Error = strings.Join(errs, "; ")
+	return result
+}
+
+func pingDiscv5(node *enode.Node) discoveryResult {
+	var result discoveryResult
+	if node.UDP() == 0 {
+		result.Error = "the node has no udp port"
+		return result
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	db, err := enode.OpenDB("")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer db.Close()
+
+	ln := enode.NewLocalNode(db, key)
+	socket, err := p2p.Listen(ln)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	disc, err := discover.ListenV5(socket, ln, discover.Config{PrivateKey: key})
+	if err != nil {
+		socket.Close()
+		result.Error = err.Error()
+		return result
+	}
+	defer disc.Close()
+
+	start := time.Now()
+	if err = disc.Ping(node); err != nil {
+		result.Error = fmt.Sprintf("ping failed: %v", err)
+		return result
+	}
+	result.Reachable = true
+	result.RTT = float64(time.Since(start)) / float64(time.Millisecond)
+
+	// Requesting the record is a findnode request at distance zero.
+	record, err := disc.RequestENR(node)
+	if err != nil {
+		result.Error = fmt.Sprintf("findnode failed: %v", err)
+		return result
+	}
+	result.ENRSeq = record.Seq()
+	result.Record = record
+	result.Endpoint = newDiscoveryEndpoint(record)
+	return result
+}
+
+func newDiscoveryEndpoint(n *enode.Node) *discoveryEndpoint {
+	e := &discoveryEndpoint{UDP: n.UDP(), TCP: n.TCP()}
+	if ip := n.IP(); ip != nil {
+		e.IP = ip.String()
+	}
+	return e
+}
+
+func newDiscv4Conn(node *enode.Node) (*discv4Conn, error) {
+	to := p2p.UDPEndpoint(node)
+	if to.Port == 0 {
+		return nil, errors.New("the node has no udp port")
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &discv4Conn{
+		conn: conn,
+		key:  key,
+		to:   to,
+		id:   node.ID(),
+	}, nil
+}
+
+func (c *discv4Conn) close() {
+	c.conn.Close()
+}
+
+func expiration() uint64 {
+	return uint64(time.Now().Add(discoveryTimeout).Unix())
+}
+
+// send writes the packet to the node and returns its hash.
+func (c *discv4Conn) send(req v4wire.Packet) ([]byte, error) {
+	packet, hash, err := v4wire.Encode(c.key, req)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.conn.WriteToUDP(packet, c.to)
+	return hash, err
+}
+
+// receive reads the packets of the node until one matches or the deadline
+// passes.
+func (c *discv4Conn) receive(deadline time.Time, match func(v4wire.Packet) bool) (v4wire.Packet, error) {
+	buf := make([]byte, 1280)
+	for {
+		if err := c.conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, from, err := c.conn.ReadFromUDP(buf)
+		if err != nil {
+			return nil, err
+		}
+		packet, key, hash, err := v4wire.Decode(buf[:n])
+		if err != nil || key.ID() != c.id {
+			continue
+		}
+
+		if _, ok := packet.(*v4wire.Ping); ok {
+			c.pinged = true
+			if _, err := c.send(&v4wire.Pong{
+				To:         v4wire.NewEndpoint(from, 0),
+				ReplyTok:   hash,
+				Expiration: expiration(),
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if match(packet) {
+			return packet, nil
+		}
+	}
+}
+
+func (c *discv4Conn) ping() (time.Duration, *v4wire.Pong, error) {
+	start := time.Now()
+	hash, err := c.send(&v4wire.Ping{
+		Version:    4,
+		From:       v4wire.NewEndpoint(c.conn.LocalAddr().(*net.UDPAddr), 0),
+		To:         v4wire.NewEndpoint(c.to, 0),
+		Expiration: expiration(),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	packet, err := c.receive(start.Add(discoveryTimeout), func(p v4wire.Packet) bool {
+		pong, ok := p.(*v4wire.Pong)
+		return ok && bytes.Equal(pong.ReplyTok, hash)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return time.Since(start), packet.(*v4wire.Pong), nil
+}
+
+// waitPing waits for the node to ping back after the first ping, so that it
+// has the endpoint proof before the other requests are sent.
+func (c *discv4Conn) waitPing() {
+	if c.pinged {
+		return
+	}
+	_, _ = c.receive(time.Now().Add(neighborsWait), func(p v4wire.Packet) bool {
+		_, ok := p.(*v4wire.Ping)
+		return ok
+	})
+}
+
+// findnode requests the neighbors of a random target and returns how many
+// nodes were received.
+func (c *discv4Conn) findnode() (int, error) {
+	target, err := crypto.GenerateKey()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = c.send(&v4wire.Findnode{
+		Target:     v4wire.EncodePubkey(&target.PublicKey),
+		Expiration: expiration(),
+	}); err != nil {
+		return 0, err
+	}
+
+	var (
+		nodes    int
+		received bool
+		deadline = time.Now().Add(discoveryTimeout)
+	)
+	for nodes < maxNeighbors {
+		packet, err := c.receive(deadline, func(p v4wire.Packet) bool {
+			_, ok := p.(*v4wire.Neighbors)
+			return ok
+		})
+		if err != nil {
+			if received {
+				break
+			}
+			return 0, err
+		}
+		nodes += len(packet.(*v4wire.Neighbors).Nodes)
+		if !received {
+			received = true
+			deadline = time.Now().Add(neighborsWait)
+		}
+	}
+	return nodes, nil
+}
+
+func (c *discv4Conn) requestENR() (*enode.Node, error) {
+	hash, err := c.send(&v4wire.ENRRequest{Expiration: expiration()})
+	if err != nil {
+		return nil, err
+	}
+
+	packet, err := c.receive(time.Now().Add(discoveryTimeout), func(p v4wire.Packet) bool {
+		resp, ok := p.(*v4wire.ENRResponse)
+		return ok && bytes.Equal(resp.ReplyTok, hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record := packet.(*v4wire.ENRResponse).Record
+	n, err := enode.New(enode.ValidSchemes, &record)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != c.id {
+		return nil, fmt.Errorf("received the record of a different node %s", n.ID())
+	}
+	return n, nil
+}
@@ -2,6 +2,7 @@ package ping
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/maticnetwork/polygon-cli/p2p"
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 type (
@@ -19,12 +21,19 @@ type (
 		OutputFile string
 		NodesFile  string
 		Listen     bool
+		Count      int
+		Interval   string
+		interval   time.Duration
+		Mode       string
 	}
 	pingNodeJSON struct {
 		Record *enode.Node `json:"record"`
 		Hello  *p2p.Hello  `json:"hello,omitempty"`
 		Status *p2p.Status `json:"status,omitempty"`
 		Error  string      `json:"error,omitempty"`
+
+		Latency   *latencyStats     `json:"latency,omitempty"`
+		Discovery *discoveryResults `json:"discovery,omitempty"`
 	}
 	pingNodeSet map[enode.ID]pingNodeJSON
 )
@@ -41,8 +50,33 @@ var PingCmd = &cobra.Command{
 This command will establish a handshake and status exchange to get the Hello and
 Status messages and output JSON. If providing a enode/enr rather than a node file,
 then the connection will remain open by default (--listen=true), and you can see
-other messages the peer sends (e.g. blocks, transactions, etc.).`,
+other messages the peer sends (e.g. blocks, transactions, etc.).
+
+Set --count to ping the nodes repeatedly every --interval, like ICMP ping, and
+report the min, avg, p95, and p99 round trip times and the loss rate of each
+node. A count of 0 pings until interrupted. In the handshake mode every ping is
+a new connection, and the round trip time includes the dial, the encryption
+handshake, and the status exchange. In the ping mode a single connection is
+kept to send devp2p pings, and it's redialed if it fails.
+
+The discovery mode only sends discv4 and discv5 packets over UDP, without a TCP
+connection, to tell UDP reachability apart from RLPx reachability. It reports
+which discovery protocols the node answers ping and findnode requests on, and
+the endpoint the node advertises in its record. With --count, the round trip
+times of discv4 pings are measured.`,
 	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		if inputPingParams.Count < 0 {
+			return fmt.Errorf("count must not be negative")
+		}
+		switch inputPingParams.Mode {
+		case pingModeHandshake, pingModePing, pingModeDiscovery:
+		default:
+			return fmt.Errorf("mode must be one of [%s, %s, %s]", pingModeHandshake, pingModePing, pingModeDiscovery)
+		}
+		inputPingParams.interval, err = time.ParseDuration(inputPingParams.Interval)
+		return err
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		nodes := []*enode.Node{}
 		if inputSet, err := p2p.LoadNodesJSON(args[0]); err == nil {
@@ -54,6 +88,11 @@ other messages the peer sends (e.g. blocks, transactions, etc.).`,
 			return err
 		}
 
+		continuous := inputPingParams.Count != 1
+		if continuous || inputPingParams.Mode == pingModeDiscovery {
+			inputPingParams.Listen = false
+		}
+
 		output := make(pingNodeSet)
 
 		var (
@@ -65,7 +104,7 @@ other messages the peer sends (e.g. blocks, transactions, etc.).`,
 		sem := make(chan bool, inputPingParams.Threads)
 
 		count := &p2p.MessageCount{}
-		go p2p.LogMessageCount(count, time.NewTicker(time.Second))
+		go p2p.LogMessageCount(count, time.NewTicker(time.Second), nil, nil)
 
 		// Ping each node in the slice.
 		for _, n := range nodes {
@@ -82,6 +121,26 @@ other messages the peer sends (e.g. blocks, transactions, etc.).`,
 					errStr string
 				)
 
+				if continuous {
+					hello, status, stats := pingContinuously(cmd.Context(), node)
+					log.Info().Str("node", node.URLv4()).Interface("latency", stats).Msg("Ping statistics")
+
+					mutex.Lock()
+					output[node.ID()] = pingNodeJSON{Record: node, Hello: hello, Status: status, Latency: &stats}
+					mutex.Unlock()
+					return
+				}
+
+				if inputPingParams.Mode == pingModeDiscovery {
+					results := pingDiscovery(node)
+					log.Info().Str("node", node.URLv4()).Interface("discovery", results).Msg("Discovery results")
+
+					mutex.Lock()
+					output[node.ID()] = pingNodeJSON{Record: node, Discovery: &results}
+					mutex.Unlock()
+					return
+				}
+
 				conn, err := p2p.Dial(node)
 				if err != nil {
 					log.Error().Err(err).Msg("Dial failed")
@@ -105,7 +164,7 @@ other messages the peer sends (e.g. blocks, transactions, etc.).`,
 
 				// Save the results to the output map.
 				mutex.Lock()
-				output[node.ID()] = pingNodeJSON{node, hello, status, errStr}
+				output[node.ID()] = pingNodeJSON{Record: node, Hello: hello, Status: status, Error: errStr}
 				mutex.Unlock()
 			}(n)
 		}
@@ -123,14 +182,51 @@ other messages the peer sends (e.g. blocks, transactions, etc.).`,
 			return err
 		}
 
+		var failed int
+		for _, n := range output {
+			if n.failed() {
+				failed++
+			}
+		}
+		cmd.SilenceUsage = true
+		if failed == len(output) && failed > 0 {
+			return util.UnreachableError(fmt.Errorf("unable to reach any of the %d nodes", failed))
+		}
+		if failed > 0 {
+			return util.PartialError("unable to reach %d of the %d nodes", failed, len(output))
+		}
 		return nil
 	},
 }
 
+// failed returns whether the node couldn't be reached, which is a failed dial
+// or handshake, no answered pings, or no answer on either discovery protocol.
+func (n pingNodeJSON) failed() bool {
+	switch {
+	case n.Latency != nil:
+		return n.Latency.Received == 0
+	case n.Discovery != nil:
+		return !n.Discovery.V4.Reachable && !n.Discovery.V5.Reachable
+	default:
+		return n.Error != ""
+	}
+}
+
 func init() {
 	PingCmd.PersistentFlags().StringVarP(&inputPingParams.OutputFile, "output", "o", "", "Write ping results to output file. (default stdout)")
 	PingCmd.PersistentFlags().IntVarP(&inputPingParams.Threads, "parallel", "p", 16, "How many parallel pings to attempt.")
 	PingCmd.PersistentFlags().BoolVarP(&inputPingParams.Listen, "listen", "l", true,
 		`Keep the connection open and listen to the peer. This only works if the first
 argument is an enode/enr, not a nodes file.`)
+	PingCmd.PersistentFlags().IntVarP(&inputPingParams.Count, "count", "c", 1, "Number of times to ping each node. Use 0 to ping until interrupted.")
+	PingCmd.PersistentFlags().StringVarP(&inputPingParams.Interval, "interval", "i", "1s", "Amount of time between the pings of a node.")
+	PingCmd.PersistentFlags().StringVarP(&inputPingParams.Mode, "mode", "m", pingModeHandshake,
+		`How the nodes are pinged, either handshake to connect for every ping, ping to
+send devp2p pings over a single connection when the count isn't 1, or discovery
+to only send discovery packets over UDP.`)
+	_ = PingCmd.RegisterFlagCompletionFunc("mode", cobra.FixedCompletions([]string{
+		pingModeHandshake + "\tconnect for every ping",
+		pingModePing + "\tsend devp2p pings over a single connection",
+		pingModeDiscovery + "\tonly send discovery packets over UDP",
+	}, cobra.ShellCompDirectiveNoFileComp))
 }
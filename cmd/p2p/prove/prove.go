@@ -0,0 +1,314 @@
+package prove
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+type (
+	proveParams struct {
+		Node      string
+		Account   string
+		Slots     []string
+		BlockHash string
+	}
+
+	blockJSON struct {
+		Number    uint64      `json:"number"`
+		Hash      common.Hash `json:"hash"`
+		StateRoot common.Hash `json:"stateRoot"`
+	}
+
+	accountJSON struct {
+		Address     common.Address `json:"address"`
+		Hash        common.Hash    `json:"hash"`
+		Exists      bool           `json:"exists"`
+		Nonce       uint64         `json:"nonce"`
+		Balance     *hexutil.Big   `json:"balance"`
+		StorageRoot common.Hash    `json:"storageRoot"`
+		CodeHash    common.Hash    `json:"codeHash"`
+		Verified    bool           `json:"verified"`
+	}
+
+	slotJSON struct {
+		Slot     common.Hash `json:"slot"`
+		Hash     common.Hash `json:"hash"`
+		Value    common.Hash `json:"value"`
+		Verified bool        `json:"verified"`
+	}
+
+	proveOutput struct {
+		Peer    string      `json:"peer"`
+		Block   blockJSON   `json:"block"`
+		Account accountJSON `json:"account"`
+		Storage []slotJSON  `json:"storage,omitempty"`
+	}
+)
+
+var (
+	inputProveParams proveParams
+)
+
+// ProveCmd requests state from a peer using the snap protocol and verifies the
+// returned Merkle proofs.
+var ProveCmd = &cobra.Command{
+	Use:   "prove",
+	Short: "Request and verify an account and its storage from a peer over snap.",
+	Long: `Request the account range that contains an address from a peer using the snap
+protocol, and verify the Merkle range proof against the state root of a block
+header received from the same peer. Storage slots given with --slot are
+requested and verified against the storage root of the account.
+
+The state is proven at the head the peer advertises in its status message,
+unless a block hash is given. Peers only serve the state of recent blocks, so
+older block hashes will time out. A proof that the account or slot doesn't
+exist is verified the same way.
+
+The command exits with an error if any of the proofs fail to verify.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		node, err := p2p.ParseNode(inputProveParams.Node)
+		if err != nil {
+			return fmt.Errorf("unable to parse node: %w", err)
+		}
+		if !common.IsHexAddress(inputProveParams.Account) {
+			return fmt.Errorf("invalid account address %s", inputProveParams.Account)
+		}
+		address := common.HexToAddress(inputProveParams.Account)
+
+		slots := make([]common.Hash, 0, len(inputProveParams.Slots))
+		for _, s := range inputProveParams.Slots {
+			b, err := hexutil.DecodeBig(s)
+			if err != nil {
+				return fmt.Errorf("invalid storage slot %s: %w", s, err)
+			}
+			slots = append(slots, common.BigToHash(b))
+		}
+
+		client := p2p.NewClient(p2p.ClientConfig{Caps: p2p.SnapCaps})
+		conn, hello, status, err := client.Peer(ctx, node)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if !hasSnap(hello) {
+			return fmt.Errorf("peer %s doesn't support snap/1", hello.Name)
+		}
+
+		hash := status.Head
+		if inputProveParams.BlockHash != "" {
+			hash = common.HexToHash(inputProveParams.BlockHash)
+		}
+		headers, err := conn.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Hash: hash},
+			Amount: 1,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to get block header: %w", err)
+		}
+		if len(headers) != 1 || headers[0].Hash() != hash {
+			return fmt.Errorf("peer didn't return the header of block %s", hash)
+		}
+		header := headers[0]
+
+		out := proveOutput{
+			Peer: hello.Name,
+			Block: blockJSON{
+				Number:    header.Number.Uint64(),
+				Hash:      hash,
+				StateRoot: header.Root,
+			},
+		}
+
+		out.Account, err = proveAccount(cmd, conn, header.Root, address)
+		if err != nil {
+			return err
+		}
+
+		failed := !out.Account.Verified
+		for _, slot := range slots {
+			s, err := proveSlot(cmd, conn, header.Root, out.Account, slot)
+			if err != nil {
+				return err
+			}
+			failed = failed || !s.Verified
+			out.Storage = append(out.Storage, s)
+		}
+
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(b))
+
+		if failed {
+			return fmt.Errorf("proof verification failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	ProveCmd.Flags().StringVarP(&inputProveParams.Node, "enode", "e", "", "The enode or enr of the peer to request the state from.")
+	ProveCmd.Flags().StringVarP(&inputProveParams.Account, "account", "a", "", "The address of the account to prove.")
+	ProveCmd.Flags().StringSliceVarP(&inputProveParams.Slots, "slot", "s", nil, "The storage slots of the account to prove. Can be given multiple times.")
+	ProveCmd.Flags().StringVarP(&inputProveParams.BlockHash, "block", "b", "", "The hash of the block to prove the state at. (default head of the peer)")
+	if err := ProveCmd.MarkFlagRequired("enode"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark enode as required flag")
+	}
+	if err := ProveCmd.MarkFlagRequired("account"); err != nil {
+		log.Error().Err(err).Msg("Failed to mark account as required flag")
+	}
+}
+
+func hasSnap(hello *p2p.Hello) bool {
+	for _, c := range hello.Caps {
+		if c.Name == "snap" && c.Version == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// proofDB loads the proof nodes into a database keyed by their hash, which is
+// what the trie package expects.
+func proofDB(proof [][]byte) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		_ = db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// verifyRange verifies the range proof starting at origin. Responses without a
+// proof are expected to contain the whole trie.
+func verifyRange(root common.Hash, origin []byte, keys, values [][]byte, proof [][]byte) error {
+	if len(proof) == 0 {
+		_, err := trie.VerifyRangeProof(root, nil, nil, keys, values, nil)
+		return err
+	}
+
+	var last []byte
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	_, err := trie.VerifyRangeProof(root, origin, last, keys, values, proofDB(proof))
+	return err
+}
+
+func proveAccount(cmd *cobra.Command, conn *p2p.Conn, root common.Hash, address common.Address) (accountJSON, error) {
+	hash := crypto.Keccak256Hash(address.Bytes())
+	out := accountJSON{
+		Address:     address,
+		Hash:        hash,
+		Balance:     (*hexutil.Big)(new(big.Int)),
+		StorageRoot: types.EmptyRootHash,
+		CodeHash:    common.BytesToHash(crypto.Keccak256(nil)),
+	}
+
+	res, err := conn.RequestAccountRange(cmd.Context(), root, hash, hash, 1)
+	if err != nil {
+		return out, fmt.Errorf("unable to get account range: %w", err)
+	}
+	if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+		return out, fmt.Errorf("peer returned an empty response, the state of the block might not be available")
+	}
+
+	keys := make([][]byte, len(res.Accounts))
+	values := make([][]byte, len(res.Accounts))
+	for i, account := range res.Accounts {
+		keys[i] = account.Hash.Bytes()
+		if values[i], err = snapshot.FullAccountRLP(account.Body); err != nil {
+			return out, fmt.Errorf("invalid account body: %w", err)
+		}
+	}
+
+	if err := verifyRange(root, hash.Bytes(), keys, values, res.Proof); err != nil {
+		log.Error().Err(err).Msg("Account proof verification failed")
+		return out, nil
+	}
+	out.Verified = true
+
+	if len(res.Accounts) == 0 || res.Accounts[0].Hash != hash {
+		return out, nil
+	}
+
+	account, err := snapshot.FullAccount(res.Accounts[0].Body)
+	if err != nil {
+		return out, err
+	}
+	out.Exists = true
+	out.Nonce = account.Nonce
+	out.Balance = (*hexutil.Big)(account.Balance)
+	if len(account.Root) > 0 {
+		out.StorageRoot = common.BytesToHash(account.Root)
+	}
+	if len(account.CodeHash) > 0 {
+		out.CodeHash = common.BytesToHash(account.CodeHash)
+	}
+
+	return out, nil
+}
+
+func proveSlot(cmd *cobra.Command, conn *p2p.Conn, root common.Hash, account accountJSON, slot common.Hash) (slotJSON, error) {
+	hash := crypto.Keccak256Hash(slot.Bytes())
+	out := slotJSON{Slot: slot, Hash: hash}
+
+	// There is nothing to request if the account has no storage, the empty
+	// root already proves that the slot is empty.
+	if !account.Exists || account.StorageRoot == types.EmptyRootHash {
+		out.Verified = account.Verified
+		return out, nil
+	}
+
+	res, err := conn.RequestStorageRanges(cmd.Context(), root, []common.Hash{account.Hash}, hash.Bytes(), hash.Bytes(), 1)
+	if err != nil {
+		return out, fmt.Errorf("unable to get storage range: %w", err)
+	}
+
+	var keys, values [][]byte
+	if len(res.Slots) > 0 {
+		for _, s := range res.Slots[0] {
+			keys = append(keys, s.Hash.Bytes())
+			values = append(values, s.Body)
+		}
+	}
+
+	if err := verifyRange(account.StorageRoot, hash.Bytes(), keys, values, res.Proof); err != nil {
+		log.Error().Err(err).Str("slot", slot.Hex()).Msg("Storage proof verification failed")
+		return out, nil
+	}
+	out.Verified = true
+
+	for i, key := range keys {
+		if common.BytesToHash(key) != hash {
+			continue
+		}
+		_, content, _, err := rlp.Split(values[i])
+		if err != nil {
+			return out, fmt.Errorf("invalid storage value: %w", err)
+		}
+		out.Value = common.BytesToHash(content)
+	}
+
+	return out, nil
+}
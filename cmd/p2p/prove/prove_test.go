@@ -0,0 +1,129 @@
+package prove
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testTrie returns a trie keyed by hashes like the state and storage tries,
+// with its keys in order.
+func testTrie(t *testing.T) (*trie.Trie, [][]byte) {
+	tr := trie.NewEmpty(trie.NewDatabase(memorydb.New()))
+	var keys [][]byte
+	for i := byte(1); i <= 16; i++ {
+		key := crypto.Keccak256([]byte{i})
+		if err := tr.TryUpdate(key, []byte{0x80 + i}); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return tr, keys
+}
+
+// rangeProof returns the proof of the origin and the last key, like a snap
+// peer responds with for a range.
+func rangeProof(t *testing.T, tr *trie.Trie, origin, last []byte) [][]byte {
+	db := memorydb.New()
+	for _, key := range [][]byte{origin, last} {
+		if err := tr.Prove(key, 0, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var proof [][]byte
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		proof = append(proof, common.CopyBytes(it.Value()))
+	}
+	return proof
+}
+
+func TestVerifyRange(t *testing.T) {
+	tr, keys := testTrie(t)
+	root := tr.Hash()
+	value := func(key []byte) []byte {
+		v, err := tr.TryGet(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	// An origin between the fourth and fifth key, which isn't in the trie.
+	absent := common.CopyBytes(keys[3])
+	absent[len(absent)-1]++
+
+	var allValues [][]byte
+	for _, key := range keys {
+		allValues = append(allValues, value(key))
+	}
+
+	tests := []struct {
+		name    string
+		origin  []byte
+		keys    [][]byte
+		values  [][]byte
+		proof   [][]byte
+		wantErr bool
+	}{
+		{
+			name:   "existing key",
+			origin: keys[5],
+			keys:   keys[5:6],
+			values: [][]byte{value(keys[5])},
+			proof:  rangeProof(t, tr, keys[5], keys[5]),
+		},
+		{
+			name:   "absent key",
+			origin: absent,
+			keys:   keys[4:5],
+			values: [][]byte{value(keys[4])},
+			proof:  rangeProof(t, tr, absent, keys[4]),
+		},
+		{
+			name:    "wrong value",
+			origin:  keys[5],
+			keys:    keys[5:6],
+			values:  [][]byte{{0x01}},
+			proof:   rangeProof(t, tr, keys[5], keys[5]),
+			wantErr: true,
+		},
+		{
+			name:    "skipped key",
+			origin:  keys[4],
+			keys:    keys[5:6],
+			values:  [][]byte{value(keys[5])},
+			proof:   rangeProof(t, tr, keys[4], keys[5]),
+			wantErr: true,
+		},
+		{
+			name:   "whole trie",
+			keys:   keys,
+			values: allValues,
+		},
+		{
+			name:    "incomplete trie",
+			keys:    keys[1:],
+			values:  allValues[1:],
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyRange(root, tc.origin, tc.keys, tc.values, tc.proof)
+			if tc.wantErr && err == nil {
+				t.Error("expected the range to be rejected")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected the range to be valid, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package sensor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+// maxReorgBlocks is how many blocks below the head are remembered to measure
+// the depth of reorgs.
+const maxReorgBlocks = 256
+
+const (
+	alertBlockGap  = "block_gap"
+	alertReorg     = "reorg"
+	alertPeers     = "peer_count"
+	alertTxSilence = "transaction_silence"
+)
+
+type (
+	alertOptions struct {
+		Webhook    string
+		SensorID   string
+		BlockGap   time.Duration
+		ReorgDepth int
+		MinPeers   int
+		TxSilence  time.Duration
+	}
+
+	// alert is the JSON payload posted to the webhook. Alerts for conditions
+	// that last, such as a block gap, are posted once when the condition starts
+	// and again with Resolved set when it ends.
+	alert struct {
+		Kind     string       `json:"kind"`
+		SensorID string       `json:"sensorId"`
+		Time     time.Time    `json:"time"`
+		Message  string       `json:"message"`
+		Resolved bool         `json:"resolved,omitempty"`
+		Number   uint64       `json:"number,omitempty"`
+		Hash     *common.Hash `json:"hash,omitempty"`
+		Depth    int          `json:"depth,omitempty"`
+		Peers    *int         `json:"peers,omitempty"`
+		Since    float64      `json:"sinceSeconds,omitempty"`
+	}
+
+	// alerter detects anomalies in what the sensor observes and posts them to
+	// a webhook. A zero threshold disables its detector.
+	alerter struct {
+		opts   alertOptions
+		client *http.Client
+		alerts chan alert
+
+		// The canonical chain as seen by the sensor, which is the longest chain
+		// of the blocks received.
+		headers   map[common.Hash]*types.Header
+		canonical map[uint64]common.Hash
+		head      uint64
+		lastBlock time.Time
+		lastTx    time.Time
+		mu        sync.Mutex
+
+		// The conditions currently alerted.
+		firing map[string]bool
+	}
+)
+
+func newAlerter(opts alertOptions) *alerter {
+	now := time.Now()
+	return &alerter{
+		opts:      opts,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		alerts:    make(chan alert, 64),
+		headers:   make(map[common.Hash]*types.Header),
+		canonical: make(map[uint64]common.Hash),
+		lastBlock: now,
+		lastTx:    now,
+		firing:    make(map[string]bool),
+	}
+}
+
+// observeHeader updates the canonical chain with the header and alerts when
+// a new head replaces at least the configured number of canonical blocks.
+func (a *alerter) observeHeader(header *types.Header) {
+	hash := header.Hash()
+	number := header.Number.Uint64()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.headers[hash]; ok {
+		return
+	}
+	a.headers[hash] = header
+	if number <= a.head && a.head > 0 {
+		return
+	}
+
+	// Walk back the new chain until it joins the canonical chain, replacing
+	// the canonical blocks of the old chain on the way.
+	var depth int
+	cur := header
+	for {
+		n := cur.Number.Uint64()
+		old, ok := a.canonical[n]
+		if ok && old == cur.Hash() {
+			break
+		}
+		if ok && n <= a.head {
+			depth++
+		}
+		a.canonical[n] = cur.Hash()
+		parent, ok := a.headers[cur.ParentHash]
+		if !ok || n == 0 {
+			break
+		}
+		cur = parent
+	}
+
+	a.head = number
+	a.lastBlock = time.Now()
+	a.prune()
+
+	if a.opts.ReorgDepth > 0 && depth >= a.opts.ReorgDepth {
+		a.send(alert{
+			Kind:    alertReorg,
+			Message: fmt.Sprintf("Reorg of %d blocks to block %d", depth, number),
+			Number:  number,
+			Hash:    &hash,
+			Depth:   depth,
+		})
+	}
+}
+
+// prune forgets the blocks too far below the head to be reorged.
+func (a *alerter) prune() {
+	if a.head < maxReorgBlocks {
+		return
+	}
+	oldest := a.head - maxReorgBlocks
+	for n := range a.canonical {
+		if n < oldest {
+			delete(a.canonical, n)
+		}
+	}
+	if len(a.headers) < 2*maxReorgBlocks {
+		return
+	}
+	for hash, header := range a.headers {
+		if header.Number.Uint64() < oldest {
+			delete(a.headers, hash)
+		}
+	}
+}
+
+// observeCounts records when transactions were last announced. It's called
+// with the message counts of every second.
+func (a *alerter) observeCounts(c p2p.MessageCount) {
+	if c.Transactions+c.TransactionHashes == 0 {
+		return
+	}
+	a.mu.Lock()
+	a.lastTx = time.Now()
+	a.mu.Unlock()
+}
+
+// send queues the alert without blocking the caller.
+func (a *alerter) send(al alert) {
+	select {
+	case a.alerts <- al:
+	default:
+		log.Warn().Str("kind", al.Kind).Msg("Dropped alert, the webhook isn't keeping up")
+	}
+}
+
+// toggle sends an alert when the condition starts or ends.
+func (a *alerter) toggle(active bool, al alert) {
+	if active == a.firing[al.Kind] {
+		return
+	}
+	a.firing[al.Kind] = active
+	al.Resolved = !active
+	a.send(al)
+}
+
+// check evaluates the conditions that last and alerts on their changes.
+func (a *alerter) check(peers int) {
+	a.mu.Lock()
+	blockGap := time.Since(a.lastBlock)
+	txSilence := time.Since(a.lastTx)
+	head := a.head
+	a.mu.Unlock()
+
+	if a.opts.BlockGap > 0 {
+		a.toggle(blockGap > a.opts.BlockGap, alert{
+			Kind:    alertBlockGap,
+			Message: fmt.Sprintf("No new block for %s after block %d", blockGap.Round(time.Second), head),
+			Number:  head,
+			Since:   blockGap.Seconds(),
+		})
+	}
+	if a.opts.TxSilence > 0 {
+		a.toggle(txSilence > a.opts.TxSilence, alert{
+			Kind:    alertTxSilence,
+			Message: fmt.Sprintf("No transactions announced for %s", txSilence.Round(time.Second)),
+			Since:   txSilence.Seconds(),
+		})
+	}
+	if a.opts.MinPeers > 0 {
+		a.toggle(peers < a.opts.MinPeers, alert{
+			Kind:    alertPeers,
+			Message: fmt.Sprintf("Connected to %d peers, fewer than %d", peers, a.opts.MinPeers),
+			Peers:   &peers,
+		})
+	}
+}
+
+// run checks the conditions every second and posts the alerts until the
+// context is done.
+func (a *alerter) run(ctx context.Context, peers func() int) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(peers())
+		case al := <-a.alerts:
+			al.SensorID = a.opts.SensorID
+			al.Time = time.Now().UTC()
+			if err := a.post(ctx, al); err != nil {
+				log.Error().Err(err).Str("kind", al.Kind).Msg("Failed to post alert to the webhook")
+				continue
+			}
+			log.Info().Str("kind", al.Kind).Bool("resolved", al.Resolved).Msg(al.Message)
+		}
+	}
+}
+
+func (a *alerter) post(ctx context.Context, al alert) error {
+	body, err := json.Marshal(al)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("webhook responded with %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
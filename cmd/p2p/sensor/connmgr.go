@@ -0,0 +1,682 @@
+package sensor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+	"github.com/maticnetwork/polygon-cli/p2p/database"
+)
+
+const (
+	// clientWeight and subnetWeight are the score penalties applied to a
+	// candidate for every connected peer that runs the same client or is in
+	// the same subnet. This keeps the peer set from being dominated by a single
+	// client implementation or hosting provider.
+	clientWeight = 2.0
+	subnetWeight = 4.0
+
+	// minSessionDuration is the amount of time a peer needs to stay connected
+	// for the session to be considered successful. Peers that drop earlier are
+	// treated like failed dials so their backoff keeps growing.
+	minSessionDuration = time.Minute
+)
+
+type (
+	// candidate is a node that can be dialed by the connection manager.
+	candidate struct {
+		node         *enode.Node
+		score        int
+		client       string
+		failures     int
+		nextDial     time.Time
+		incompatible bool
+		static       bool
+	}
+
+	// peerConn is an established connection.
+	peerConn struct {
+		conn      *p2p.Conn
+		node      *enode.Node
+		client    string
+		subnet    string
+		inbound   bool
+		connected time.Time
+
+		// fingerprint is only set when fingerprinting is enabled.
+		fingerprint *p2p.Fingerprint
+	}
+
+	connManagerOptions struct {
+		TargetPeers  int
+		MaxPeers     int
+		MaxInbound   int
+		MaxDials     int
+		NetworkID    uint64
+		Genesis      common.Hash
+		SensorID     string
+		Backoff      time.Duration
+		MaxBackoff   time.Duration
+		StaticNodes  []*enode.Node
+		TrustedNodes []*enode.Node
+		ShardIndex   int
+		ShardCount   int
+		Key          *ecdsa.PrivateKey
+		Listener     net.Listener
+		Database     database.Database
+		Count        *p2p.MessageCount
+		Incompatible func(enode.ID)
+		// Compatible is called when a node is found to be on the network.
+		Compatible func(enode.ID)
+		// MaxCandidates limits the number of candidates that are kept.
+		MaxCandidates int
+		// Fingerprint probes the peers after peering to infer their client.
+		Fingerprint bool
+		// PeerChanged is called when a peer connects or disconnects.
+		PeerChanged func(peer *peerConn, connected bool)
+	}
+
+	// connManager maintains the target number of outbound peers by dialing the
+	// best candidates, accepts inbound connections while there are free slots,
+	// and reconnects to dropped peers with an exponential backoff.
+	connManager struct {
+		opts       connManagerOptions
+		client     *p2p.Client
+		candidates map[enode.ID]*candidate
+		peers      map[enode.ID]*peerConn
+		dialing    map[enode.ID]struct{}
+		trusted    map[enode.ID]struct{}
+		classifier *p2p.Classifier
+		wakeCh     chan struct{}
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+
+		// disconnects counts why peers disconnect from the sensor.
+		disconnects *disconnectStats
+	}
+)
+
+func newConnManager(opts connManagerOptions) *connManager {
+	if opts.MaxDials < 1 {
+		opts.MaxDials = 1
+	}
+	m := &connManager{
+		opts:        opts,
+		client:      p2p.NewClient(p2p.ClientConfig{Key: opts.Key}),
+		candidates:  make(map[enode.ID]*candidate),
+		peers:       make(map[enode.ID]*peerConn),
+		dialing:     make(map[enode.ID]struct{}),
+		trusted:     make(map[enode.ID]struct{}),
+		classifier:  p2p.NewClassifier(),
+		disconnects: newDisconnectStats(),
+		wakeCh:      make(chan struct{}, 1),
+	}
+
+	// Static nodes are always dialed and trusted nodes are always accepted.
+	// Neither of them count towards the peer limits.
+	for _, n := range opts.StaticNodes {
+		m.candidates[n.ID()] = &candidate{node: n, static: true}
+		m.trusted[n.ID()] = struct{}{}
+	}
+	for _, n := range opts.TrustedNodes {
+		m.trusted[n.ID()] = struct{}{}
+	}
+
+	return m
+}
+
+// add registers or updates a dial candidate. The score is the liveness score
+// of the node from the discovery.
+func (m *connManager) add(n *enode.Node, score int) {
+	if n.TCP() == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	c, ok := m.candidates[n.ID()]
+	if !ok && !m.inShard(n.ID()) {
+		m.mu.Unlock()
+		return
+	}
+	if !ok && len(m.candidates) >= m.opts.MaxCandidates && !m.evict(score) {
+		m.mu.Unlock()
+		return
+	}
+	if !ok {
+		c = &candidate{}
+		m.candidates[n.ID()] = c
+	}
+	if !c.static {
+		c.node = n
+	}
+	c.score = score
+	m.mu.Unlock()
+
+	if !ok {
+		m.wake()
+	}
+}
+
+// evict drops the worst candidate to make room for a new one with the score,
+// and returns false if there is no candidate worse than the new one. Candidates
+// on a different network are dropped first, then the ones with the lowest
+// score. Static nodes and nodes that are connected or being dialed are never
+// dropped. The caller must hold the lock.
+func (m *connManager) evict(score int) bool {
+	var (
+		worstID enode.ID
+		worst   *candidate
+	)
+	for id, c := range m.candidates {
+		if c.static {
+			continue
+		}
+		if _, ok := m.peers[id]; ok {
+			continue
+		}
+		if _, ok := m.dialing[id]; ok {
+			continue
+		}
+		if worst == nil || worse(c, worst) {
+			worstID, worst = id, c
+		}
+	}
+	if worst == nil || (!worst.incompatible && worst.score >= score) {
+		return false
+	}
+	delete(m.candidates, worstID)
+	return true
+}
+
+// worse returns whether candidate a is less useful than candidate b.
+func worse(a, b *candidate) bool {
+	if a.incompatible != b.incompatible {
+		return a.incompatible
+	}
+	return a.score < b.score
+}
+
+// inShard returns whether the node belongs to the shard of this sensor. The
+// shard is derived from the node ID prefix, which is uniformly distributed, so
+// sensors with the same shard count and different indexes split the peers
+// evenly without overlap.
+func (m *connManager) inShard(id enode.ID) bool {
+	if m.opts.ShardCount <= 1 {
+		return true
+	}
+	return binary.BigEndian.Uint64(id[:8])%uint64(m.opts.ShardCount) == uint64(m.opts.ShardIndex)
+}
+
+// isIncompatible returns whether the node was previously found to be on a
+// different network.
+func (m *connManager) isIncompatible(id enode.ID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.candidates[id]
+	return ok && c.incompatible
+}
+
+// counts returns the number of outbound peers, inbound peers, and candidates.
+func (m *connManager) counts() (outbound, inbound, candidates int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range m.peers {
+		if p.inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	return outbound, inbound, len(m.candidates)
+}
+
+// connectedPeers returns a copy of the connected peers, ordered by how long
+// they have been connected.
+func (m *connManager) connectedPeers() []peerConn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	peers := make([]peerConn, 0, len(m.peers))
+	for _, p := range m.peers {
+		peers = append(peers, *p)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].connected.Before(peers[j].connected)
+	})
+	return peers
+}
+
+func (m *connManager) wake() {
+	select {
+	case m.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// run starts accepting inbound connections and schedules dials until the
+// context is cancelled. It then closes all connections and returns once the
+// pending database writes of the peers are done.
+func (m *connManager) run(ctx context.Context) {
+	if m.opts.Listener != nil {
+		go m.acceptLoop(ctx)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.wakeCh:
+		case <-ctx.Done():
+			break loop
+		}
+
+		for _, n := range m.schedule() {
+			m.wg.Add(1)
+			go func(n *enode.Node) {
+				defer m.wg.Done()
+				m.dial(ctx, n)
+			}(n)
+		}
+	}
+
+	if m.opts.Listener != nil {
+		m.opts.Listener.Close()
+	}
+
+	m.mu.Lock()
+	log.Info().Int("peers", len(m.peers)).Msg("Closing peer connections")
+	for _, p := range m.peers {
+		p.conn.Close()
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// schedule selects the candidates that should be dialed to reach the target
+// number of outbound peers and marks them as dialing.
+func (m *connManager) schedule() []*enode.Node {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var outbound, total int
+	clients := make(map[string]int)
+	subnets := make(map[string]int)
+	for id, p := range m.peers {
+		if _, ok := m.trusted[id]; !ok {
+			total++
+			if !p.inbound {
+				outbound++
+			}
+		}
+		if p.client != "" {
+			clients[p.client]++
+		}
+		subnets[p.subnet]++
+	}
+
+	type ranked struct {
+		c        *candidate
+		priority float64
+	}
+
+	var (
+		now     = time.Now()
+		nodes   []*enode.Node
+		pending int
+	)
+	eligible := make([]ranked, 0, len(m.candidates))
+	for id, c := range m.candidates {
+		_, connected := m.peers[id]
+		_, dialing := m.dialing[id]
+		if c.static && dialing {
+			continue
+		}
+		if dialing {
+			pending++
+		}
+		if connected || dialing || c.incompatible || now.Before(c.nextDial) {
+			continue
+		}
+
+		// Static nodes are dialed as soon as they are due regardless of the
+		// peer limits.
+		if c.static {
+			m.dialing[id] = struct{}{}
+			nodes = append(nodes, c.node)
+			continue
+		}
+
+		priority := float64(c.score) - subnetWeight*float64(subnets[subnet(c.node.IP())])
+		if c.client != "" {
+			priority -= clientWeight * float64(clients[c.client])
+		}
+		eligible = append(eligible, ranked{c, priority})
+	}
+
+	need := m.opts.TargetPeers - outbound - pending
+	if free := m.opts.MaxPeers - total - pending; free < need {
+		need = free
+	}
+	if free := m.opts.MaxDials - pending; free < need {
+		need = free
+	}
+	if need <= 0 {
+		return nodes
+	}
+
+	sort.Slice(eligible, func(i, j int) bool {
+		return eligible[i].priority > eligible[j].priority
+	})
+	if len(eligible) > need {
+		eligible = eligible[:need]
+	}
+
+	for _, r := range eligible {
+		m.dialing[r.c.node.ID()] = struct{}{}
+		nodes = append(nodes, r.c.node)
+	}
+	return nodes
+}
+
+// dial connects to the node and serves the connection until it drops.
+func (m *connManager) dial(ctx context.Context, n *enode.Node) {
+	conn, err := m.client.Dial(ctx, n)
+	if err != nil {
+		log.Debug().Err(err).Str("node", n.String()).Msg("Dial failed")
+		m.dialFailed(n.ID())
+		return
+	}
+
+	if err := m.serve(ctx, conn, false); err != nil {
+		log.Debug().Err(err).Str("node", n.String()).Msg("Peer failed")
+		m.dialFailed(n.ID())
+	}
+}
+
+// dialFailed releases the dial slot and backs off the candidate.
+func (m *connManager) dialFailed(id enode.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.dialing, id)
+	if c, ok := m.candidates[id]; ok {
+		c.failures++
+		c.nextDial = time.Now().Add(m.backoff(c))
+	}
+	m.wake()
+}
+
+// backoff returns the delay before the next dial of the candidate. The delay
+// doubles after each consecutive failure, except for static nodes which are
+// always redialed after the initial delay.
+func (m *connManager) backoff(c *candidate) time.Duration {
+	delay := m.opts.Backoff
+	if c.static {
+		return delay
+	}
+	failures := c.failures
+	if failures < 1 {
+		failures = 1
+	}
+	for i := 1; i < failures && delay < m.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > m.opts.MaxBackoff {
+		delay = m.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (m *connManager) acceptLoop(ctx context.Context) {
+	for {
+		fd, err := m.opts.Listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Debug().Err(err).Msg("Failed to accept connection")
+			continue
+		}
+
+		// The identity of the peer is only known after the handshake, so the
+		// connection is only rejected early if there are no trusted nodes that
+		// could be exempt from the limits.
+		m.mu.Lock()
+		full := len(m.trusted) == 0 && !m.hasSlot(true)
+		m.mu.Unlock()
+
+		if full {
+			fd.Close()
+			continue
+		}
+
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			conn, err := m.client.Accept(ctx, fd)
+			if err != nil {
+				log.Debug().Err(err).Str("addr", fd.RemoteAddr().String()).Msg("Inbound handshake failed")
+				return
+			}
+
+			// Peers of other shards are left to the other sensors, unless
+			// they are trusted.
+			id := conn.Node().ID()
+			m.mu.Lock()
+			_, trusted := m.trusted[id]
+			m.mu.Unlock()
+			if !trusted && !m.inShard(id) {
+				conn.Close()
+				return
+			}
+
+			if err := m.serve(ctx, conn, true); err != nil {
+				log.Debug().Err(err).Str("node", conn.Node().String()).Msg("Inbound peer failed")
+			}
+		}()
+	}
+}
+
+// serve exchanges the hello and status messages, registers the peer, and reads
+// messages until the connection drops. An error is only returned if the peer
+// couldn't be established.
+func (m *connManager) serve(ctx context.Context, conn *p2p.Conn, inbound bool) error {
+	defer conn.Close()
+	conn.SensorID = m.opts.SensorID
+	n := conn.Node()
+
+	hello, status, err := conn.PeerContext(ctx)
+	if err != nil {
+		m.disconnects.record(m.clientOf(n, hello), err)
+		return err
+	}
+
+	log.Debug().Interface("hello", hello).Interface("status", status).Msg("Peering messages received")
+
+	if status.NetworkID != m.opts.NetworkID ||
+		(m.opts.Genesis != (common.Hash{}) && status.Genesis != m.opts.Genesis) {
+		m.markIncompatible(n.ID())
+		return nil
+	}
+	if m.opts.Compatible != nil {
+		m.opts.Compatible(n.ID())
+	}
+
+	peer := &peerConn{
+		conn:      conn,
+		node:      n,
+		client:    clientName(hello.Name),
+		subnet:    subnet(n.IP()),
+		inbound:   inbound,
+		connected: time.Now(),
+	}
+	if m.opts.Fingerprint {
+		if peer.fingerprint, err = conn.Fingerprint(ctx, hello, status); err != nil {
+			log.Debug().Err(err).Str("node", n.String()).Msg("Fingerprinting failed")
+			return nil
+		}
+		m.classifier.Observe(peer.fingerprint)
+		m.classifier.Classify(peer.fingerprint)
+		if peer.fingerprint.Spoofed {
+			log.Info().
+				Str("node", n.String()).
+				Str("name", hello.Name).
+				Str("inferred", peer.fingerprint.InferredClient).
+				Msg("Hello name doesn't match the behavior")
+		}
+	}
+	if !m.register(ctx, peer) {
+		return nil
+	}
+	if m.opts.PeerChanged != nil {
+		m.opts.PeerChanged(peer, true)
+	}
+
+	if err := conn.ReadAndServe(m.opts.Database, m.opts.Count); err != nil {
+		m.disconnects.record(peer.client, err)
+		log.Debug().Err(err).Str("node", n.String()).Msg("Received error")
+	}
+
+	m.unregister(peer)
+	if m.opts.PeerChanged != nil {
+		m.opts.PeerChanged(peer, false)
+	}
+	return nil
+}
+
+// hasSlot returns whether there is room for another peer that isn't trusted.
+// The caller must hold the lock.
+func (m *connManager) hasSlot(inbound bool) bool {
+	var total, inbounds int
+	for id, p := range m.peers {
+		if _, ok := m.trusted[id]; ok {
+			continue
+		}
+		total++
+		if p.inbound {
+			inbounds++
+		}
+	}
+	if inbound && inbounds >= m.opts.MaxInbound {
+		return false
+	}
+	return total < m.opts.MaxPeers
+}
+
+// register adds the peer to the peer set. It returns false if the peer is
+// already connected or there are no free slots. Static and trusted nodes are
+// always registered.
+func (m *connManager) register(ctx context.Context, peer *peerConn) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := peer.node.ID()
+	delete(m.dialing, id)
+
+	// Connections that finish peering during shutdown would otherwise be
+	// missed when closing the peers.
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if _, ok := m.peers[id]; ok {
+		return false
+	}
+	if _, ok := m.trusted[id]; !ok && !m.hasSlot(peer.inbound) {
+		return false
+	}
+	m.peers[id] = peer
+
+	if c, ok := m.candidates[id]; ok {
+		c.client = peer.client
+	}
+
+	log.Debug().Str("node", peer.node.String()).Bool("inbound", peer.inbound).Str("client", peer.client).Msg("Peer connected")
+	return true
+}
+
+// unregister removes the peer from the peer set and schedules the reconnect.
+func (m *connManager) unregister(peer *peerConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := peer.node.ID()
+	delete(m.peers, id)
+
+	if c, ok := m.candidates[id]; ok {
+		if time.Since(peer.connected) < minSessionDuration {
+			c.failures++
+		} else {
+			c.failures = 0
+		}
+		c.nextDial = time.Now().Add(m.backoff(c))
+	}
+
+	log.Debug().Str("node", peer.node.String()).Dur("duration", time.Since(peer.connected)).Msg("Peer disconnected")
+	m.wake()
+}
+
+func (m *connManager) markIncompatible(id enode.ID) {
+	m.mu.Lock()
+	delete(m.dialing, id)
+	if c, ok := m.candidates[id]; ok {
+		if c.static {
+			log.Warn().Str("node", c.node.String()).Msg("Static node is on a different network")
+		}
+		c.incompatible = true
+	}
+	m.mu.Unlock()
+
+	if m.opts.Incompatible != nil {
+		m.opts.Incompatible(id)
+	}
+	m.wake()
+}
+
+// clientName returns the client name without the version, e.g. "bor" for
+// "bor/v0.4.0/linux-amd64/go1.20.5".
+func clientName(name string) string {
+	return strings.ToLower(strings.Split(name, "/")[0])
+}
+
+// clientOf returns the client of a node that failed to peer, which is the one
+// of its hello message, or the one of its last connection if the hello wasn't
+// received.
+func (m *connManager) clientOf(n *enode.Node, hello *p2p.Hello) string {
+	if hello != nil {
+		return clientName(hello.Name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.candidates[n.ID()]; ok {
+		return c.client
+	}
+	return ""
+}
+
+// subnet returns the /16 prefix of IPv4 addresses and /32 prefix of IPv6
+// addresses. It's used as a proxy for the hosting provider and region of a
+// peer.
+func subnet(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(32, 128)).String()
+}
@@ -0,0 +1,353 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+const (
+	// maxDashboardRows is the number of announcing peers and recent blocks
+	// shown in the dashboard.
+	maxDashboardRows = 50
+	// maxDashboardLogs is the number of log lines kept for the logs pane.
+	maxDashboardLogs = 500
+)
+
+type (
+	// dashboardBlock is a block announced to the sensor. The number and time
+	// are only known once a peer sends the header.
+	dashboardBlock struct {
+		hash      common.Hash
+		number    uint64
+		time      uint64
+		firstSeen time.Time
+		firstPeer string
+	}
+
+	// announcer counts the blocks and transactions a peer sent.
+	announcer struct {
+		id     string
+		blocks uint64
+		first  uint64
+		txs    uint64
+	}
+
+	// messageRate is the number of messages of a type received in the last
+	// second and since the sensor started.
+	messageRate struct {
+		name  string
+		last  int32
+		total int64
+	}
+
+	// dashboard collects what the sensor receives and renders it in the
+	// terminal in place of the logs, which are shown in a pane instead.
+	dashboard struct {
+		sensorID  string
+		networkID uint64
+		started   time.Time
+
+		blocks     map[common.Hash]*dashboardBlock
+		order      []common.Hash
+		announcers map[string]*announcer
+		rates      []messageRate
+		logs       []string
+		mu         sync.Mutex
+
+		logger zerolog.Logger
+	}
+)
+
+func newDashboard(sensorID string, networkID uint64) *dashboard {
+	return &dashboard{
+		sensorID:   sensorID,
+		networkID:  networkID,
+		started:    time.Now(),
+		blocks:     make(map[common.Hash]*dashboardBlock),
+		announcers: make(map[string]*announcer),
+		rates:      messageRates(p2p.MessageCount{}),
+	}
+}
+
+// messageRates returns the counts of each message type in a fixed order.
+func messageRates(c p2p.MessageCount) []messageRate {
+	return []messageRate{
+		{name: "Blocks", last: c.Blocks},
+		{name: "Block Hashes", last: c.BlockHashes},
+		{name: "Block Headers", last: c.BlockHeaders},
+		{name: "Block Bodies", last: c.BlockBodies},
+		{name: "Header Requests", last: c.BlockHeaderRequests},
+		{name: "Body Requests", last: c.BlockBodiesRequests},
+		{name: "Transactions", last: c.Transactions},
+		{name: "Transaction Hashes", last: c.TransactionHashes},
+		{name: "Transaction Requests", last: c.TransactionRequests},
+		{name: "Pings", last: c.Pings},
+		{name: "Errors", last: c.Errors},
+		{name: "Disconnects", last: c.Disconnects},
+	}
+}
+
+// start takes over the terminal and sends the logs to the logs pane. stop
+// must be called to restore the terminal.
+func (d *dashboard) start() error {
+	if err := ui.Init(); err != nil {
+		return err
+	}
+	d.logger = log.Logger
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: d, NoColor: true, TimeFormat: "15:04:05"})
+	return nil
+}
+
+func (d *dashboard) stop() {
+	log.Logger = d.logger
+	ui.Close()
+}
+
+// Write adds the lines to the logs pane.
+func (d *dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		d.logs = append(d.logs, line)
+	}
+	if len(d.logs) > maxDashboardLogs {
+		d.logs = d.logs[len(d.logs)-maxDashboardLogs:]
+	}
+	return len(p), nil
+}
+
+// observeCounts updates the message rates with the counts of the last
+// second.
+func (d *dashboard) observeCounts(c p2p.MessageCount) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rates := messageRates(c)
+	for i := range rates {
+		rates[i].total = d.rates[i].total + int64(rates[i].last)
+	}
+	d.rates = rates
+}
+
+// observeBlock records that the peer announced the block. The peer is nil
+// when the block was only received as a header.
+func (d *dashboard) observeBlock(peer *enode.Node, hash common.Hash, header *types.Header) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	b, ok := d.blocks[hash]
+	if !ok {
+		b = &dashboardBlock{hash: hash, firstSeen: time.Now(), firstPeer: "-"}
+		d.blocks[hash] = b
+		d.order = append(d.order, hash)
+		if len(d.order) > maxObservedBlocks {
+			delete(d.blocks, d.order[0])
+			d.order = d.order[1:]
+		}
+	}
+	if header != nil && b.number == 0 {
+		b.number = header.Number.Uint64()
+		b.time = header.Time
+	}
+	if peer == nil {
+		return
+	}
+
+	a := d.announcer(peer)
+	a.blocks++
+	if b.firstPeer == "-" {
+		b.firstPeer = a.id
+		a.first++
+	}
+}
+
+func (d *dashboard) observeTransactions(peer *enode.Node, count int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.announcer(peer).txs += uint64(count)
+}
+
+// announcer returns the stats of the peer. The caller must hold the lock.
+func (d *dashboard) announcer(peer *enode.Node) *announcer {
+	id := peer.ID().TerminalString()
+	a, ok := d.announcers[id]
+	if !ok {
+		a = &announcer{id: id}
+		d.announcers[id] = a
+	}
+	return a
+}
+
+// run redraws the dashboard every second until the context is cancelled. The
+// sensor is stopped with cancel when q or ctrl-c is pressed.
+func (d *dashboard) run(ctx context.Context, cancel context.CancelFunc, s *sensor) {
+	header := widgets.NewParagraph()
+	header.Title = "Sensor"
+
+	peers := newDashboardTable("Peers", ui.ColorGreen)
+	rates := newDashboardTable("Messages", ui.ColorYellow)
+	announcers := newDashboardTable("Top Announcing Peers", ui.ColorCyan)
+	blocks := newDashboardTable("Recent Blocks", ui.ColorMagenta)
+
+	logs := widgets.NewList()
+	logs.Title = "Logs"
+	logs.WrapText = false
+
+	grid := ui.NewGrid()
+	grid.Set(
+		ui.NewRow(1.0/10, header),
+		ui.NewRow(4.0/10,
+			ui.NewCol(3.0/5, peers),
+			ui.NewCol(2.0/5, rates),
+		),
+		ui.NewRow(5.0/10,
+			ui.NewCol(3.0/10, announcers),
+			ui.NewCol(3.0/10, blocks),
+			ui.NewCol(4.0/10, logs),
+		),
+	)
+	width, height := ui.TerminalDimensions()
+	grid.SetRect(0, 0, width, height)
+
+	redraw := func() {
+		outbound, inbound, candidates := s.conns.counts()
+		header.Text = fmt.Sprintf("ID: %s    Network ID: %d    Outbound: %d    Inbound: %d    Candidates: %d    Uptime: %s    Press q to quit",
+			d.sensorID, d.networkID, outbound, inbound, candidates, time.Since(d.started).Truncate(time.Second))
+		peers.Rows = d.peerRows(s.conns.connectedPeers())
+
+		d.mu.Lock()
+		rates.Rows = d.rateRows()
+		announcers.Rows = d.announcerRows()
+		blocks.Rows = d.blockRows()
+		logs.Rows = append([]string(nil), d.logs...)
+		d.mu.Unlock()
+		if len(logs.Rows) > 0 {
+			logs.ScrollBottom()
+		}
+
+		ui.Render(grid)
+	}
+
+	events := ui.PollEvents()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	redraw()
+	for {
+		select {
+		case e := <-events:
+			switch e.ID {
+			case "q", "<C-c>":
+				cancel()
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				grid.SetRect(0, 0, payload.Width, payload.Height)
+				ui.Clear()
+				redraw()
+			}
+		case <-ticker.C:
+			redraw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func newDashboardTable(title string, color ui.Color) *widgets.Table {
+	t := widgets.NewTable()
+	t.Title = title
+	t.RowSeparator = false
+	t.TextStyle = ui.NewStyle(ui.ColorWhite)
+	t.RowStyles[0] = ui.NewStyle(color, ui.ColorClear, ui.ModifierBold)
+	return t
+}
+
+func (d *dashboard) peerRows(peers []peerConn) [][]string {
+	rows := [][]string{{"Peer", "Client", "Direction", "Address", "Connected"}}
+	for _, p := range peers {
+		direction := "outbound"
+		if p.inbound {
+			direction = "inbound"
+		}
+		client := p.client
+		if fp := p.fingerprint; fp != nil && fp.Spoofed {
+			client = fmt.Sprintf("%s (%s?)", p.client, fp.InferredClient)
+		}
+		rows = append(rows, []string{
+			p.node.ID().TerminalString(),
+			client,
+			direction,
+			p2p.TCPAddress(p.node),
+			time.Since(p.connected).Truncate(time.Second).String(),
+		})
+	}
+	return rows
+}
+
+// rateRows returns the message rates. The caller must hold the lock.
+func (d *dashboard) rateRows() [][]string {
+	rows := [][]string{{"Message", "Per Second", "Total"}}
+	for _, r := range d.rates {
+		rows = append(rows, []string{r.name, fmt.Sprint(r.last), fmt.Sprint(r.total)})
+	}
+	return rows
+}
+
+// announcerRows returns the peers that announced the most blocks first. The
+// caller must hold the lock.
+func (d *dashboard) announcerRows() [][]string {
+	announcers := make([]*announcer, 0, len(d.announcers))
+	for _, a := range d.announcers {
+		announcers = append(announcers, a)
+	}
+	sort.Slice(announcers, func(i, j int) bool {
+		if announcers[i].first != announcers[j].first {
+			return announcers[i].first > announcers[j].first
+		}
+		if announcers[i].blocks != announcers[j].blocks {
+			return announcers[i].blocks > announcers[j].blocks
+		}
+		return announcers[i].id < announcers[j].id
+	})
+
+	rows := [][]string{{"Peer", "First", "Blocks", "Txs"}}
+	for i, a := range announcers {
+		if i == maxDashboardRows {
+			break
+		}
+		rows = append(rows, []string{a.id, fmt.Sprint(a.first), fmt.Sprint(a.blocks), fmt.Sprint(a.txs)})
+	}
+	return rows
+}
+
+// blockRows returns the most recently announced blocks. The caller must hold
+// the lock.
+func (d *dashboard) blockRows() [][]string {
+	rows := [][]string{{"Number", "Hash", "Delay", "First Peer"}}
+	for i := len(d.order) - 1; i >= 0 && len(rows) <= maxDashboardRows; i-- {
+		b := d.blocks[d.order[i]]
+		number, delay := "-", "-"
+		if b.number > 0 {
+			number = fmt.Sprint(b.number)
+			delay = b.firstSeen.Sub(time.Unix(int64(b.time), 0)).Truncate(time.Millisecond).String()
+		}
+		rows = append(rows, []string{number, b.hash.TerminalString(), delay, b.firstPeer})
+	}
+	return rows
+}
@@ -0,0 +1,103 @@
+package sensor
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	ethp2p "github.com/ethereum/go-ethereum/p2p"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+// unknownClient is the client of peers that disconnect before their hello
+// message is received and that haven't been connected to before.
+const unknownClient = "unknown"
+
+// disconnectStats counts the reasons peers give when they disconnect from the
+// sensor, by the client they run. It tells why the sensor gets dropped, for
+// example whether the peers are full or consider the sensor useless.
+type disconnectStats struct {
+	mu     sync.Mutex
+	counts map[string]map[ethp2p.DiscReason]uint64
+}
+
+func newDisconnectStats() *disconnectStats {
+	return &disconnectStats{counts: make(map[string]map[ethp2p.DiscReason]uint64)}
+}
+
+// record counts the disconnect of the error, if the peer disconnected.
+func (d *disconnectStats) record(client string, err error) {
+	var disc *p2p.DisconnectError
+	if !errors.As(err, &disc) {
+		return
+	}
+	if client == "" {
+		client = unknownClient
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.counts[client] == nil {
+		d.counts[client] = make(map[ethp2p.DiscReason]uint64)
+	}
+	d.counts[client][disc.Reason]++
+}
+
+// summary returns the number of disconnects by client and reason.
+func (d *disconnectStats) summary() map[string]map[string]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	summary := make(map[string]map[string]uint64, len(d.counts))
+	for client, reasons := range d.counts {
+		summary[client] = make(map[string]uint64, len(reasons))
+		for reason, n := range reasons {
+			summary[client][reason.String()] = n
+		}
+	}
+	return summary
+}
+
+// emit emits the number of disconnects since the sensor started, with a point
+// for every client and reason.
+func (d *disconnectStats) emit(sink *metrics.Sink) {
+	if sink == nil {
+		return
+	}
+	summary := d.summary()
+	clients := make([]string, 0, len(summary))
+	for client := range summary {
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+
+	var points []metrics.Point
+	for _, client := range clients {
+		for reason, n := range summary[client] {
+			points = append(points, metrics.Point{
+				Name:   "sensor_disconnects",
+				Tags:   map[string]string{"client": client, "reason": reason},
+				Fields: map[string]float64{"count": float64(n)},
+			})
+		}
+	}
+	sink.Emit(points...)
+}
+
+// logSummary logs the number of disconnects by client and reason, along with
+// the total of every reason.
+func (d *disconnectStats) logSummary() {
+	summary := d.summary()
+	totals := make(map[string]uint64)
+	for _, reasons := range summary {
+		for reason, n := range reasons {
+			totals[reason] += n
+		}
+	}
+	log.Info().
+		Interface("reasons", totals).
+		Interface("clients", summary).
+		Msg("Disconnects received")
+}
@@ -0,0 +1,186 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
+)
+
+// eventSink publishes the events of the sensor to a message broker. Events are
+// published as JSON to the subject, or routing key, <prefix>.blocks,
+// <prefix>.transactions, or <prefix>.peers.
+type eventSink interface {
+	publish(subject string, data []byte) error
+	close() error
+}
+
+// newEventSink connects to the broker of the URL, which is selected by its
+// scheme: nats:// for NATS JetStream, or amqp:// and amqps:// for RabbitMQ.
+func newEventSink(rawURL, prefix string) (eventSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse sink URL: %w", err)
+	}
+	switch u.Scheme {
+	case "nats", "tls":
+		return newNATSSink(rawURL, prefix)
+	case "amqp", "amqps":
+		return newAMQPSink(rawURL)
+	default:
+		return nil, fmt.Errorf("unsupported sink scheme %q, expected nats, amqp, or amqps", u.Scheme)
+	}
+}
+
+type natsSink struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+// newNATSSink connects to NATS and creates a JetStream stream capturing the
+// subjects of the prefix if no stream does already.
+func newNATSSink(rawURL, prefix string) (*natsSink, error) {
+	conn, err := nats.Connect(rawURL, nats.Name("polycli-sensor"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to NATS: %w", err)
+	}
+	js, err := conn.JetStream(nats.PublishAsyncErrHandler(func(_ nats.JetStream, msg *nats.Msg, err error) {
+		log.Warn().Err(err).Str("subject", msg.Subject).Msg("Failed to publish event to JetStream")
+	}))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	subjects := prefix + ".>"
+	if _, err = js.StreamNameBySubject(subjects); errors.Is(err, nats.ErrNoMatchingStream) {
+		name := strings.ToUpper(strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(prefix))
+		_, err = js.AddStream(&nats.StreamConfig{Name: name, Subjects: []string{subjects}})
+		if err == nil {
+			log.Info().Str("stream", name).Str("subjects", subjects).Msg("Created JetStream stream")
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to find or create a JetStream stream for %s: %w", subjects, err)
+	}
+
+	return &natsSink{conn: conn, js: js}, nil
+}
+
+func (s *natsSink) publish(subject string, data []byte) error {
+	_, err := s.js.PublishAsync(subject, data)
+	return err
+}
+
+func (s *natsSink) close() error {
+	select {
+	case <-s.js.PublishAsyncComplete():
+	case <-time.After(5 * time.Second):
+		log.Warn().Int("pending", s.js.PublishAsyncPending()).Msg("Timed out waiting for JetStream acknowledgements")
+	}
+	return s.conn.Drain()
+}
+
+// amqpSink publishes to the amq.topic exchange that every RabbitMQ server has,
+// so consumers bind their queues with routing keys such as sensor.blocks.
+type amqpSink struct {
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+func newAMQPSink(rawURL string) (*amqpSink, error) {
+	conn, err := amqp.Dial(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &amqpSink{conn: conn, ch: ch}, nil
+}
+
+func (s *amqpSink) publish(subject string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.ch.PublishWithContext(ctx, "amq.topic", subject, false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        data,
+	})
+}
+
+func (s *amqpSink) close() error {
+	s.ch.Close()
+	return s.conn.Close()
+}
+
+// attach subscribes the sink to all events and publishes them until the
+// stream is closed. Like slow gRPC subscribers, a sink that can't keep up
+// drops events.
+func (s *streamServer) attach(sink eventSink, prefix string) {
+	sub := &subscriber{
+		events:       make(chan *pb.SensorEvent, streamBufferSize),
+		blocks:       true,
+		transactions: true,
+		peers:        true,
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var failed uint64
+		for {
+			select {
+			case <-s.done:
+				s.mu.Lock()
+				delete(s.subs, sub)
+				s.mu.Unlock()
+				if err := sink.close(); err != nil {
+					log.Error().Err(err).Msg("Failed to close event sink")
+				}
+				if dropped := atomic.LoadUint64(&sub.dropped); dropped > 0 || failed > 0 {
+					log.Warn().Uint64("dropped", dropped).Uint64("failed", failed).Msg("Events weren't published to the sink")
+				}
+				return
+			case event := <-sub.events:
+				data, err := protojson.Marshal(event)
+				if err != nil {
+					failed++
+					continue
+				}
+				if err = sink.publish(prefix+"."+eventSubject(event), data); err != nil {
+					failed++
+					log.Debug().Err(err).Msg("Failed to publish event to the sink")
+				}
+			}
+		}
+	}()
+}
+
+func eventSubject(event *pb.SensorEvent) string {
+	switch event.Event.(type) {
+	case *pb.SensorEvent_Block:
+		return "blocks"
+	case *pb.SensorEvent_Transaction:
+		return "transactions"
+	default:
+		return "peers"
+	}
+}
@@ -0,0 +1,416 @@
+package sensor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	manifestKubernetes = "kubernetes"
+	manifestSystemd    = "systemd"
+	manifestCloudInit  = "cloud-init"
+
+	// The GCP service account key is mounted at manifestSecret in the
+	// container, and read from manifestHostSecret by systemd.
+	manifestSecret     = "/secrets/gcp-credentials.json"
+	manifestHostSecret = "/etc/polycli/gcp-credentials.json"
+)
+
+type (
+	manifestParams struct {
+		Format    string
+		Name      string
+		Namespace string
+		Image     string
+		Binary    string
+		DataDir   string
+		Replicas  int
+		CPU       string
+		Memory    string
+		Storage   string
+	}
+
+	// manifestSensor is a sensor of the fleet, which is a shard if there's
+	// more than one.
+	manifestSensor struct {
+		Name string
+		Args []string
+	}
+
+	manifestData struct {
+		manifestParams
+		Sensors     []manifestSensor
+		NodesFile   string
+		Credentials string
+		Port        int
+		PprofPort   uint
+		GRPCPort    string
+		HealthPort  string
+		// Units are the rendered systemd units of the sensors.
+		Units []string
+	}
+
+	manifestUnit struct {
+		*manifestData
+		Sensor manifestSensor
+	}
+)
+
+var inputManifestParams manifestParams
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Generate a Kubernetes, systemd, or cloud-init manifest that runs the sensor with the given flags.",
+	Long: `Print a ready-to-apply manifest that runs the sensor with the sensor flags given
+to this command, for example:
+
+  polycli p2p sensor manifest --bootnodes enode://... --network-id 137 --sensor-id sensor --replicas 4
+
+With more than one replica, every replica is a shard of the peers with its own
+sensor ID. If a GCP project is set, the manifest has a placeholder for the
+service account key that has to be filled in before it's applied.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch inputManifestParams.Format {
+		case manifestKubernetes, manifestSystemd, manifestCloudInit:
+		default:
+			return fmt.Errorf("the format must be one of %s, %s, or %s", manifestKubernetes, manifestSystemd, manifestCloudInit)
+		}
+		if inputManifestParams.Replicas < 1 {
+			return errors.New("replicas must be at least one")
+		}
+		if inputManifestParams.Replicas > 1 && cmd.Flags().Changed("shard-index") {
+			return errors.New("the shard index is set for every replica")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data := manifestData{
+			manifestParams: inputManifestParams,
+			NodesFile:      inputManifestParams.DataDir + "/nodes.json",
+			Port:           inputSensorParams.Port,
+		}
+		if inputSensorParams.ProjectID != "" {
+			data.Credentials = manifestSecret
+			if inputManifestParams.Format != manifestKubernetes {
+				data.Credentials = manifestHostSecret
+			}
+		}
+		if inputSensorParams.ShouldRunPprof {
+			data.PprofPort = inputSensorParams.PprofPort
+		}
+		if inputSensorParams.GRPCAddr != "" {
+			_, port, err := net.SplitHostPort(inputSensorParams.GRPCAddr)
+			if err != nil {
+				return fmt.Errorf("invalid gRPC address: %w", err)
+			}
+			data.GRPCPort = port
+		}
+		if inputSensorParams.HealthAddr != "" {
+			_, port, err := net.SplitHostPort(inputSensorParams.HealthAddr)
+			if err != nil {
+				return fmt.Errorf("invalid health address: %w", err)
+			}
+			data.HealthPort = port
+		}
+		data.Sensors = manifestSensors(cmd.InheritedFlags(), inputManifestParams.Replicas)
+
+		tmpl, err := template.New("manifest").Funcs(template.FuncMap{
+			"quote":   strconv.Quote,
+			"systemd": systemdQuote,
+			// systemd sizes are powers of 1024 without the i of Kubernetes.
+			"bytes": func(s string) string { return strings.TrimSuffix(s, "i") },
+			"indent": func(n int, s string) string {
+				lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+				for i, line := range lines {
+					if line != "" {
+						lines[i] = strings.Repeat(" ", n) + line
+					}
+				}
+				return strings.Join(lines, "\n")
+			},
+		}).Parse(manifestTemplates)
+		if err != nil {
+			return err
+		}
+		for _, sensor := range data.Sensors {
+			var sb strings.Builder
+			if err = tmpl.ExecuteTemplate(&sb, "unit", manifestUnit{&data, sensor}); err != nil {
+				return err
+			}
+			data.Units = append(data.Units, sb.String())
+		}
+		return tmpl.ExecuteTemplate(os.Stdout, inputManifestParams.Format, data)
+	},
+}
+
+func init() {
+	SensorCmd.AddCommand(manifestCmd)
+
+	f := manifestCmd.Flags()
+	f.StringVar(&inputManifestParams.Format, "format", manifestKubernetes, "The format of the manifest: kubernetes, systemd, or cloud-init.")
+	f.StringVar(&inputManifestParams.Name, "name", "polycli-sensor", "The name of the deployments or the systemd unit.")
+	f.StringVar(&inputManifestParams.Namespace, "namespace", "default", "The Kubernetes namespace.")
+	f.StringVar(&inputManifestParams.Image, "image", "ghcr.io/maticnetwork/polygon-cli:latest", "The container image with polycli.")
+	f.StringVar(&inputManifestParams.Binary, "binary", "/usr/local/bin/polycli", "The path of polycli on the host for systemd and cloud-init.")
+	f.StringVar(&inputManifestParams.DataDir, "data-dir", "/var/lib/polycli-sensor", "The directory of the nodes file in the container.")
+	f.IntVar(&inputManifestParams.Replicas, "replicas", 1, "The number of sensors, each connecting to a different shard of the peers.")
+	f.StringVar(&inputManifestParams.CPU, "cpu", "1", "The CPU request of a sensor.")
+	f.StringVar(&inputManifestParams.Memory, "memory", "2Gi", "The memory request and limit of a sensor.")
+	f.StringVar(&inputManifestParams.Storage, "storage", "1Gi", "The size of the volume with the nodes file of a sensor.")
+}
+
+// manifestSensors returns the name and the arguments of every sensor. The
+// arguments are the sensor flags that were set, and the shard flags and
+// sensor ID of every replica.
+func manifestSensors(flags *pflag.FlagSet, replicas int) []manifestSensor {
+	skip := map[string]bool{"config": true, "tui": true}
+	if replicas > 1 {
+		skip["shard-index"] = true
+		skip["shard-count"] = true
+		skip["sensor-id"] = true
+	}
+
+	var common []string
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed && !skip[f.Name] {
+			common = append(common, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+		}
+	})
+	sort.Strings(common)
+
+	if replicas == 1 {
+		return []manifestSensor{{Name: inputManifestParams.Name, Args: common}}
+	}
+	sensors := make([]manifestSensor, replicas)
+	for i := range sensors {
+		args := append([]string(nil), common...)
+		args = append(args,
+			fmt.Sprintf("--sensor-id=%s-%d", inputSensorParams.SensorID, i),
+			fmt.Sprintf("--shard-index=%d", i),
+			fmt.Sprintf("--shard-count=%d", replicas),
+		)
+		sensors[i] = manifestSensor{Name: fmt.Sprintf("%s-%d", inputManifestParams.Name, i), Args: args}
+	}
+	return sensors
+}
+
+// systemdQuote quotes an argument of ExecStart, where % starts a specifier
+// and $ a variable.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s)
+	return `"` + s + `"`
+}
+
+const manifestTemplates = `
+{{- define "kubernetes" -}}
+{{- $d := . -}}
+{{- if .Credentials -}}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.Name}}-gcp
+  namespace: {{.Namespace}}
+type: Opaque
+stringData:
+  # Replace with the key of a service account that can write to the datastore.
+  gcp-credentials.json: "<GCP SERVICE ACCOUNT KEY>"
+---
+{{end -}}
+{{- range $i, $s := .Sensors}}
+{{- if $i}}
+---
+{{end -}}
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: {{$s.Name}}-data
+  namespace: {{$d.Namespace}}
+spec:
+  accessModes: ["ReadWriteOnce"]
+  resources:
+    requests:
+      storage: {{$d.Storage}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{$s.Name}}
+  namespace: {{$d.Namespace}}
+  labels:
+    app.kubernetes.io/name: {{$d.Name}}
+    app.kubernetes.io/instance: {{$s.Name}}
+spec:
+  replicas: 1
+  # The sensor writes the nodes file on exit, so only one pod may use it.
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: {{$s.Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{$d.Name}}
+        app.kubernetes.io/instance: {{$s.Name}}
+    spec:
+      terminationGracePeriodSeconds: 60
+      initContainers:
+        - name: nodes-file
+          image: busybox:stable
+          command: ["sh", "-c", "test -f {{$d.NodesFile}} || echo '{}' > {{$d.NodesFile}}"]
+          volumeMounts:
+            - name: data
+              mountPath: {{$d.DataDir}}
+      containers:
+        - name: sensor
+          image: {{$d.Image}}
+          args:
+            - p2p
+            - sensor
+            - {{quote $d.NodesFile}}
+{{- range $s.Args}}
+            - {{quote .}}
+{{- end}}
+{{- if $d.Credentials}}
+          env:
+            - name: GOOGLE_APPLICATION_CREDENTIALS
+              value: {{$d.Credentials}}
+{{- end}}
+{{- if or $d.Port $d.PprofPort $d.GRPCPort $d.HealthPort}}
+          ports:
+{{- if $d.Port}}
+            - name: p2p-tcp
+              containerPort: {{$d.Port}}
+              protocol: TCP
+            - name: p2p-udp
+              containerPort: {{$d.Port}}
+              protocol: UDP
+{{- end}}
+{{- if $d.PprofPort}}
+            - name: pprof
+              containerPort: {{$d.PprofPort}}
+{{- end}}
+{{- if $d.GRPCPort}}
+            - name: grpc
+              containerPort: {{$d.GRPCPort}}
+{{- end}}
+{{- if $d.HealthPort}}
+            - name: health
+              containerPort: {{$d.HealthPort}}
+{{- end}}
+{{- end}}
+{{- if $d.HealthPort}}
+          # The sensor is only ready once it's connected to a peer, which can
+          # take a while after it starts.
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: health
+            periodSeconds: 30
+          readinessProbe:
+            httpGet:
+              path: /readyz
+              port: health
+            initialDelaySeconds: 30
+            periodSeconds: 10
+{{- end}}
+          resources:
+            requests:
+              cpu: {{quote $d.CPU}}
+              memory: {{quote $d.Memory}}
+            limits:
+              memory: {{quote $d.Memory}}
+          volumeMounts:
+            - name: data
+              mountPath: {{$d.DataDir}}
+{{- if $d.Credentials}}
+            - name: gcp
+              mountPath: /secrets
+              readOnly: true
+{{- end}}
+      volumes:
+        - name: data
+          persistentVolumeClaim:
+            claimName: {{$s.Name}}-data
+{{- if $d.Credentials}}
+        - name: gcp
+          secret:
+            secretName: {{$d.Name}}-gcp
+{{- end}}
+{{- end}}
+{{end -}}
+
+{{- define "unit" -}}
+[Unit]
+Description=polycli p2p sensor {{.Sensor.Name}}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+DynamicUser=yes
+StateDirectory={{.Sensor.Name}}
+ExecStartPre=/bin/sh -c 'test -f /var/lib/{{.Sensor.Name}}/nodes.json || echo "{}" > /var/lib/{{.Sensor.Name}}/nodes.json'
+ExecStart={{.Binary}} p2p sensor /var/lib/{{.Sensor.Name}}/nodes.json{{range .Sensor.Args}} {{systemd .}}{{end}}
+{{- if .Credentials}}
+LoadCredential=gcp-credentials.json:{{.Credentials}}
+Environment=GOOGLE_APPLICATION_CREDENTIALS=%d/gcp-credentials.json
+{{- end}}
+# The sensor writes the nodes file when it's stopped.
+KillSignal=SIGINT
+TimeoutStopSec=60
+Restart=always
+RestartSec=10
+LimitNOFILE=65536
+MemoryMax={{bytes .Memory}}
+
+[Install]
+WantedBy=multi-user.target
+{{end -}}
+
+{{- define "systemd" -}}
+{{- if .Credentials -}}
+# Copy the key of a service account that can write to the datastore to
+# {{.Credentials}} before starting the sensors.
+{{end -}}
+{{- range $i, $u := .Units}}
+{{- if $i}}
+{{end -}}
+# /etc/systemd/system/{{(index $.Sensors $i).Name}}.service
+{{$u}}
+{{- end}}
+{{- end -}}
+
+{{- define "cloud-init" -}}
+#cloud-config
+# polycli has to be installed at {{.Binary}}, for example by the image.
+write_files:
+{{- if .Credentials}}
+  - path: {{.Credentials}}
+    permissions: "0600"
+    # Replace with the key of a service account that can write to the datastore.
+    content: |
+      <GCP SERVICE ACCOUNT KEY>
+{{- end}}
+{{- range $i, $u := .Units}}
+  - path: /etc/systemd/system/{{(index $.Sensors $i).Name}}.service
+    content: |
+{{indent 6 $u}}
+{{- end}}
+runcmd:
+  - systemctl daemon-reload
+{{- range .Sensors}}
+  - systemctl enable --now {{.Name}}.service
+{{- end}}
+{{end -}}
+`
@@ -0,0 +1,193 @@
+package sensor
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/p2p/database"
+)
+
+// maxObservedBlocks is the number of recent block hashes remembered so the
+// propagation latency is only measured for the first peer sending a block.
+const maxObservedBlocks = 1024
+
+// observedDatabase passes the blocks and transactions received from peers to
+// the transaction comparer, the metrics sink, the dashboard, the event stream,
+// and the alerter before writing them to the underlying database. Any of them
+// can be nil.
+type observedDatabase struct {
+	db     database.Database
+	cmp    *txComparer
+	sink   *metrics.Sink
+	dash   *dashboard
+	stream *streamServer
+	alerts *alerter
+
+	blocks map[common.Hash]struct{}
+	order  []common.Hash
+	mu     sync.Mutex
+}
+
+func newObservedDatabase(db database.Database, cmp *txComparer, sink *metrics.Sink, dash *dashboard, stream *streamServer, alerts *alerter) *observedDatabase {
+	return &observedDatabase{
+		db:     db,
+		cmp:    cmp,
+		sink:   sink,
+		dash:   dash,
+		stream: stream,
+		alerts: alerts,
+		blocks: make(map[common.Hash]struct{}),
+	}
+}
+
+// firstSeen returns whether this is the first time the block hash is seen.
+func (d *observedDatabase) firstSeen(hash common.Hash) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.blocks[hash]; ok {
+		return false
+	}
+
+	d.blocks[hash] = struct{}{}
+	d.order = append(d.order, hash)
+	if len(d.order) > maxObservedBlocks {
+		delete(d.blocks, d.order[0])
+		d.order = d.order[1:]
+	}
+	return true
+}
+
+func (d *observedDatabase) WriteBlock(ctx context.Context, peer *enode.Node, block *types.Block, td *big.Int) {
+	if d.dash != nil {
+		d.dash.observeBlock(peer, block.Hash(), block.Header())
+	}
+
+	if d.stream != nil {
+		d.stream.publishBlock(peer, block, td)
+	}
+
+	if d.alerts != nil {
+		d.alerts.observeHeader(block.Header())
+	}
+
+	if d.sink != nil && d.firstSeen(block.Hash()) {
+		latency := time.Since(time.Unix(int64(block.Time()), 0))
+		d.sink.Emit(metrics.Point{
+			Name: "sensor_block",
+			Fields: map[string]float64{
+				"number":              float64(block.NumberU64()),
+				"transactions":        float64(len(block.Transactions())),
+				"gas_used":            float64(block.GasUsed()),
+				"propagation_latency": latency.Seconds(),
+			},
+		})
+	}
+
+	if d.db != nil {
+		d.db.WriteBlock(ctx, peer, block, td)
+	}
+}
+
+func (d *observedDatabase) WriteBlockHeaders(ctx context.Context, headers []*types.Header) {
+	if d.dash != nil {
+		for _, header := range headers {
+			d.dash.observeBlock(nil, header.Hash(), header)
+		}
+	}
+
+	if d.alerts != nil {
+		for _, header := range headers {
+			d.alerts.observeHeader(header)
+		}
+	}
+
+	if d.db != nil {
+		d.db.WriteBlockHeaders(ctx, headers)
+	}
+}
+
+func (d *observedDatabase) WriteBlockHashes(ctx context.Context, peer *enode.Node, hashes []common.Hash) {
+	if d.dash != nil {
+		for _, hash := range hashes {
+			d.dash.observeBlock(peer, hash, nil)
+		}
+	}
+
+	if d.db != nil {
+		d.db.WriteBlockHashes(ctx, peer, hashes)
+	}
+}
+
+func (d *observedDatabase) WriteBlockBody(ctx context.Context, body *eth.BlockBody, hash common.Hash) {
+	if d.db != nil {
+		d.db.WriteBlockBody(ctx, body, hash)
+	}
+}
+
+func (d *observedDatabase) WriteTransactions(ctx context.Context, peer *enode.Node, txs []*types.Transaction) {
+	if d.dash != nil {
+		d.dash.observeTransactions(peer, len(txs))
+	}
+
+	if d.cmp != nil {
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		d.cmp.observe(sourceP2P, hashes...)
+	}
+
+	if d.stream != nil {
+		d.stream.publishTransactions(peer, txs)
+	}
+
+	if d.db != nil {
+		d.db.WriteTransactions(ctx, peer, txs)
+	}
+}
+
+func (d *observedDatabase) HasParentBlock(ctx context.Context, hash common.Hash) bool {
+	if d.db != nil {
+		return d.db.HasParentBlock(ctx, hash)
+	}
+	return true
+}
+
+func (d *observedDatabase) MaxConcurrentWrites() int {
+	if d.db != nil {
+		return d.db.MaxConcurrentWrites()
+	}
+	return inputSensorParams.MaxConcurrentDatabaseWrites
+}
+
+// ShouldWriteBlocks is true when a sink, the dashboard, the event stream, or
+// the alerter is configured so that the peers send the blocks, rather than just
+// their hashes, to measure their propagation, stream them, or detect reorgs.
+func (d *observedDatabase) ShouldWriteBlocks() bool {
+	return d.sink != nil || d.dash != nil || d.stream != nil || d.alerts != nil || (d.db != nil && d.db.ShouldWriteBlocks())
+}
+
+// ShouldWriteBlockEvents is true when the dashboard is shown so that it sees
+// which peers announce the blocks.
+func (d *observedDatabase) ShouldWriteBlockEvents() bool {
+	return d.dash != nil || (d.db != nil && d.db.ShouldWriteBlockEvents())
+}
+
+// ShouldWriteTransactions is true when comparing or streaming transactions so
+// that announced transactions are requested from the peers.
+func (d *observedDatabase) ShouldWriteTransactions() bool {
+	return d.cmp != nil || d.stream != nil || (d.db != nil && d.db.ShouldWriteTransactions())
+}
+
+func (d *observedDatabase) ShouldWriteTransactionEvents() bool {
+	return d.db != nil && d.db.ShouldWriteTransactionEvents()
+}
@@ -2,6 +2,9 @@ package sensor
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/rs/zerolog/log"
 
+	"github.com/maticnetwork/polygon-cli/metrics"
 	"github.com/maticnetwork/polygon-cli/p2p"
 	"github.com/maticnetwork/polygon-cli/p2p/database"
 )
@@ -21,13 +25,21 @@ type sensor struct {
 	inputIter enode.Iterator
 	nodeCh    chan *enode.Node
 	db        database.Database
-	peers     map[string]struct{}
+	conns     *connManager
 	count     *p2p.MessageCount
+	sink      *metrics.Sink
+	sampler   *database.Sampler
+	dash      *dashboard
+	alerts    *alerter
+
+	// compatible are the nodes of the output set that exchanged a matching
+	// status message, which are the only ones written to the nodes file.
+	compatible map[enode.ID]struct{}
 
 	// settings
 	revalidateInterval time.Duration
+	disconnectInterval time.Duration
 	outputMutex        sync.RWMutex
-	peersMutex         sync.RWMutex
 }
 
 const (
@@ -60,30 +72,35 @@ func newSensor(input p2p.NodeSet, disc resolver, iters ...enode.Iterator) *senso
 			ShouldWriteBlockEvents:       inputSensorParams.ShouldWriteBlockEvents,
 			ShouldWriteTransactions:      inputSensorParams.ShouldWriteTransactions,
 			ShouldWriteTransactionEvents: inputSensorParams.ShouldWriteTransactionEvents,
+			DedupeWindow:                 inputSensorParams.DedupeWindow,
 		}),
-		peers: make(map[string]struct{}),
-		count: &p2p.MessageCount{},
+		count:      &p2p.MessageCount{},
+		compatible: make(map[enode.ID]struct{}, len(input)),
 	}
 	s.iters = append(s.iters, s.inputIter)
 	// Copy input to output initially. Any nodes that fail validation
-	// will be dropped from output during the run.
+	// will be dropped from output during the run. The input is a nodes file,
+	// so its nodes were already found to be on the network.
 	for id, n := range input {
 		s.output[id] = n
+		s.compatible[id] = struct{}{}
 	}
 	return s
 }
 
 // run will start nthreads number of goroutines for discovery and a goroutine
-// for logging.
-func (s *sensor) run(nthreads int) {
+// for logging. It returns once the context is cancelled and the peer
+// connections are closed.
+func (s *sensor) run(ctx context.Context, nthreads int) {
 	statusTicker := time.NewTicker(time.Second * 8)
+	defer statusTicker.Stop()
 
 	if nthreads < 1 {
 		nthreads = 1
 	}
 
 	for _, it := range s.iters {
-		go s.runIterator(it)
+		go s.runIterator(ctx, it)
 	}
 
 	var (
@@ -98,7 +115,14 @@ func (s *sensor) run(nthreads int) {
 	for i := 0; i < nthreads; i++ {
 		go func() {
 			for {
-				switch s.updateNode(<-s.nodeCh) {
+				var n *enode.Node
+				select {
+				case n = <-s.nodeCh:
+				case <-ctx.Done():
+					return
+				}
+
+				switch s.updateNode(n) {
 				case nodeSkipIncompat:
 					atomic.AddUint64(&skipped, 1)
 				case nodeSkipRecent:
@@ -115,81 +139,92 @@ func (s *sensor) run(nthreads int) {
 	}
 
 	// Start logging message counts and peer status.
-	go p2p.LogMessageCount(s.count, time.NewTicker(time.Second))
+	countTicker := time.NewTicker(time.Second)
+	defer countTicker.Stop()
+	var observe func(p2p.MessageCount)
+	if s.dash != nil || s.alerts != nil {
+		observe = func(c p2p.MessageCount) {
+			if s.dash != nil {
+				s.dash.observeCounts(c)
+			}
+			if s.alerts != nil {
+				s.alerts.observeCounts(c)
+			}
+		}
+	}
+	go p2p.LogMessageCount(s.count, countTicker, s.sink, observe)
+
+	// Start the connection manager which dials and accepts the peers.
+	connsDone := make(chan struct{})
+	go func() {
+		s.conns.run(ctx)
+		close(connsDone)
+	}()
+
+	// The disconnect summary is only logged when the sensor stops if there's
+	// no interval.
+	var disconnectC <-chan time.Time
+	if s.disconnectInterval > 0 {
+		disconnectTicker := time.NewTicker(s.disconnectInterval)
+		defer disconnectTicker.Stop()
+		disconnectC = disconnectTicker.C
+	}
 
 	for {
-		<-statusTicker.C
-		s.peersMutex.RLock()
+		select {
+		case <-statusTicker.C:
+		case <-disconnectC:
+			s.conns.disconnects.logSummary()
+			continue
+		case <-ctx.Done():
+			log.Info().Msg("Stopping sensor")
+			for _, it := range s.iters {
+				it.Close()
+			}
+			<-connsDone
+			s.conns.disconnects.logSummary()
+			return
+		}
+
+		outbound, inbound, candidates := s.conns.counts()
+		s.sink.Emit(metrics.Point{
+			Name: "sensor_peers",
+			Fields: map[string]float64{
+				"outbound":   float64(outbound),
+				"inbound":    float64(inbound),
+				"candidates": float64(candidates),
+			},
+		})
+
+		s.conns.disconnects.emit(s.sink)
+		s.logDropped()
+
 		log.Info().
 			Uint64("added", atomic.LoadUint64(&added)).
 			Uint64("updated", atomic.LoadUint64(&updated)).
 			Uint64("removed", atomic.LoadUint64(&removed)).
 			Uint64("ignored(recent)", atomic.LoadUint64(&removed)).
 			Uint64("ignored(incompatible)", atomic.LoadUint64(&skipped)).
-			Int("peers", len(s.peers)).
+			Int("outbound", outbound).
+			Int("inbound", inbound).
+			Int("candidates", candidates).
 			Msg("Discovery in progress")
-		s.peersMutex.RUnlock()
 	}
 }
 
-func (s *sensor) runIterator(it enode.Iterator) {
+func (s *sensor) runIterator(ctx context.Context, it enode.Iterator) {
 	for it.Next() {
-		s.nodeCh <- it.Node()
-	}
-}
-
-// peerNode peers with nodes with matching network IDs. Nodes will exchange
-// hello and status messages to determine the network ID. If the network IDs
-// match then a connection will be opened with the node to receive blocks and
-// transactions.
-func (s *sensor) peerNode(n *enode.Node) bool {
-	conn, err := p2p.Dial(n)
-	if err != nil {
-		log.Debug().Err(err).Msg("Dial failed")
-		return true
-	}
-	conn.SensorID = inputSensorParams.SensorID
-
-	hello, status, err := conn.Peer()
-	if err != nil {
-		log.Debug().Err(err).Msg("Peer failed")
-		conn.Close()
-		return true
-	}
-
-	log.Debug().Interface("hello", hello).Interface("status", status).Msg("Peering messages received")
-
-	skip := inputSensorParams.NetworkID != status.NetworkID
-	if !skip {
-		s.peersMutex.Lock()
-		defer s.peersMutex.Unlock()
-
-		// Don't open duplicate connections with peers. The enode is used here
-		// rather than the enr because the enr can change. Ensure the maximum
-		// number of peers is not exceeded to prevent memory overuse.
-		peer := n.URLv4()
-		if _, ok := s.peers[peer]; !ok && len(s.peers) < inputSensorParams.MaxPeers {
-			s.peers[peer] = struct{}{}
-
-			go func() {
-				defer conn.Close()
-				if err := conn.ReadAndServe(s.db, s.count); err != nil {
-					log.Debug().Err(err).Msg("Received error")
-				}
-
-				s.peersMutex.Lock()
-				defer s.peersMutex.Unlock()
-				delete(s.peers, peer)
-			}()
+		select {
+		case s.nodeCh <- it.Node():
+		case <-ctx.Done():
+			return
 		}
 	}
-
-	return skip
 }
 
 // updateNode updates the info about the given node, and returns a status about
-// what changed. If the node is compatible, then it will peer with the node and
-// start receiving block and transaction data.
+// what changed. Nodes that respond are handed to the connection manager, which
+// decides whether to peer with them.
 func (s *sensor) updateNode(n *enode.Node) int {
 	s.outputMutex.RLock()
 	node, ok := s.output[n.ID()]
@@ -201,8 +236,8 @@ func (s *sensor) updateNode(n *enode.Node) int {
 		return nodeSkipRecent
 	}
 
-	// Skip the node if unable to peer.
-	if s.peerNode(n) {
+	// Skip nodes that were found to be on a different network.
+	if s.conns.isIncompatible(n.ID()) {
 		return nodeSkipIncompat
 	}
 
@@ -220,6 +255,7 @@ func (s *sensor) updateNode(n *enode.Node) int {
 	} else {
 		node.N = nn
 		node.Seq = nn.Seq()
+		node.Family = p2p.AddressFamily(nn)
 		node.Score++
 		if node.FirstResponse.IsZero() {
 			node.FirstResponse = node.LastCheck
@@ -240,15 +276,108 @@ func (s *sensor) updateNode(n *enode.Node) int {
 
 	log.Debug().Str("node", n.String()).Uint64("seq", n.Seq()).Int("score", node.Score).Msg("Updating node")
 	s.output[n.ID()] = node
+	s.conns.add(node.N, node.Score)
 
-	// Update the nodes file at the end of each iteration.
-	if err := p2p.WriteNodesJSON(inputSensorParams.NodesFile, s.output); err != nil {
-		log.Error().Err(err).Msg("Failed to write nodes json")
+	// Update the nodes file if the node is in it.
+	if _, ok := s.compatible[n.ID()]; ok {
+		if err := s.writeCompatibleNodes(); err != nil {
+			log.Error().Err(err).Msg("Failed to write nodes json")
+		}
 	}
 
 	return status
 }
 
+// writeNodes writes the compatible nodes of the output set to the nodes file.
+func (s *sensor) writeNodes() error {
+	s.outputMutex.RLock()
+	defer s.outputMutex.RUnlock()
+
+	return s.writeCompatibleNodes()
+}
+
+// writeCompatibleNodes writes the nodes of the output set that are on the
+// network to the nodes file. Nodes that only responded to the discovery could
+// be on any network, so they're left out. The caller must hold the lock.
+func (s *sensor) writeCompatibleNodes() error {
+	nodes := make(p2p.NodeSet, len(s.compatible))
+	for id := range s.compatible {
+		if n, ok := s.output[id]; ok {
+			nodes[id] = n
+		}
+	}
+	return p2p.WriteNodesJSON(inputSensorParams.NodesFile, nodes)
+}
+
+// addCompatible adds a node to the nodes file. This is called by the
+// connection manager when a node exchanged a status message of the network.
+func (s *sensor) addCompatible(id enode.ID) {
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+
+	if _, ok := s.compatible[id]; ok {
+		return
+	}
+	s.compatible[id] = struct{}{}
+	if _, ok := s.output[id]; !ok {
+		return
+	}
+	if err := s.writeCompatibleNodes(); err != nil {
+		log.Error().Err(err).Msg("Failed to write nodes json")
+	}
+}
+
+// removeNode removes a node from the output set. This is called by the
+// connection manager when a node turns out to be on a different network.
+func (s *sensor) removeNode(id enode.ID) {
+	s.outputMutex.Lock()
+	defer s.outputMutex.Unlock()
+
+	delete(s.output, id)
+	delete(s.compatible, id)
+}
+
+// logDropped logs and emits the number of items that were dropped by the
+// sampler.
+func (s *sensor) logDropped() {
+	if s.sampler == nil {
+		return
+	}
+
+	dropped := s.sampler.Dropped()
+	fields := make(map[string]float64, 2*len(dropped))
+	for t, d := range dropped {
+		fields[t+"_sampled"] = float64(d.Sampled)
+		fields[t+"_limited"] = float64(d.Limited)
+	}
+
+	s.sink.Emit(metrics.Point{Name: "sensor_dropped", Fields: fields})
+	log.Info().Interface("dropped", dropped).Msg("Dropped database writes")
+}
+
+// parseMessageRates parses comma separated message type and value pairs, such
+// as transactions=0.01,blocks=1.
+func parseMessageRates(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		t, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected type=value, got %s", pair)
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", t, err)
+		}
+		rates[strings.TrimSpace(t)] = f
+	}
+	return rates, nil
+}
+
 func truncNow() time.Time {
 	return time.Now().UTC().Truncate(1 * time.Second)
 }
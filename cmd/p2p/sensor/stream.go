@@ -0,0 +1,228 @@
+package sensor
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
+	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
+)
+
+// streamBufferSize is the number of events buffered for each subscriber.
+// Events are dropped for subscribers that fall further behind, so a slow
+// subscriber doesn't slow down the sensor.
+const streamBufferSize = 4096
+
+type (
+	// streamServer serves the gRPC streaming API of the sensor.
+	streamServer struct {
+		pb.UnimplementedSensorServer
+
+		sensorID string
+		subs     map[*subscriber]struct{}
+		mu       sync.RWMutex
+		done     chan struct{}
+		wg       sync.WaitGroup
+	}
+
+	subscriber struct {
+		events       chan *pb.SensorEvent
+		blocks       bool
+		transactions bool
+		peers        bool
+		dropped      uint64
+	}
+)
+
+func newStreamServer(sensorID string) *streamServer {
+	return &streamServer{
+		sensorID: sensorID,
+		subs:     make(map[*subscriber]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// close stops the event sinks after they published their pending events.
+func (s *streamServer) close() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// serve serves the API and the gRPC health service on the listener until the
+// server is stopped.
+func (s *streamServer) serve(ctx context.Context, ln net.Listener, health *metrics.Health) *grpc.Server {
+	server := grpc.NewServer()
+	pb.RegisterSensorServer(server, s)
+	health.RegisterGRPC(ctx, server, pb.Sensor_ServiceDesc.ServiceName)
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			log.Error().Err(err).Msg("Failed to serve the gRPC API")
+		}
+	}()
+	log.Info().Str("address", ln.Addr().String()).Msg("Serving the gRPC API")
+	return server
+}
+
+func (s *streamServer) Subscribe(req *pb.SubscribeRequest, stream pb.Sensor_SubscribeServer) error {
+	sub := &subscriber{
+		events:       make(chan *pb.SensorEvent, streamBufferSize),
+		blocks:       req.Blocks,
+		transactions: req.Transactions,
+		peers:        req.Peers,
+	}
+	if !sub.blocks && !sub.transactions && !sub.peers {
+		sub.blocks, sub.transactions, sub.peers = true, true, true
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, sub)
+		s.mu.Unlock()
+		if dropped := atomic.LoadUint64(&sub.dropped); dropped > 0 {
+			log.Warn().Uint64("dropped", dropped).Msg("Dropped events of a slow gRPC subscriber")
+		}
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-sub.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish sends the event to the subscribers of its type without blocking.
+func (s *streamServer) publish(peer *enode.Node, event *pb.SensorEvent) {
+	event.Time = timestamppb.Now()
+	event.SensorId = s.sensorID
+	if peer != nil {
+		event.Peer = peer.URLv4()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for sub := range s.subs {
+		switch event.Event.(type) {
+		case *pb.SensorEvent_Block:
+			if !sub.blocks {
+				continue
+			}
+		case *pb.SensorEvent_Transaction:
+			if !sub.transactions {
+				continue
+			}
+		case *pb.SensorEvent_PeerEvent:
+			if !sub.peers {
+				continue
+			}
+		}
+		select {
+		case sub.events <- event:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// active returns whether there are any subscribers, so events aren't built
+// when nobody receives them.
+func (s *streamServer) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.subs) > 0
+}
+
+func (s *streamServer) publishBlock(peer *enode.Node, block *types.Block, td *big.Int) {
+	if !s.active() {
+		return
+	}
+	b := &pb.ObservedBlock{
+		Hash:       block.Hash().Hex(),
+		Number:     hexutil.EncodeBig(block.Number()),
+		ParentHash: block.ParentHash().Hex(),
+		Timestamp:  hexutil.EncodeUint64(block.Time()),
+		Miner:      block.Coinbase().Hex(),
+		GasLimit:   hexutil.EncodeUint64(block.GasLimit()),
+		GasUsed:    hexutil.EncodeUint64(block.GasUsed()),
+	}
+	if block.BaseFee() != nil {
+		b.BaseFeePerGas = hexutil.EncodeBig(block.BaseFee())
+	}
+	if td != nil {
+		b.TotalDifficulty = hexutil.EncodeBig(td)
+	}
+	for _, tx := range block.Transactions() {
+		b.Transactions = append(b.Transactions, tx.Hash().Hex())
+	}
+	if data, err := rlp.EncodeToBytes(block); err == nil {
+		b.Rlp = data
+	}
+	s.publish(peer, &pb.SensorEvent{Event: &pb.SensorEvent_Block{Block: b}})
+}
+
+func (s *streamServer) publishTransactions(peer *enode.Node, txs []*types.Transaction) {
+	if !s.active() {
+		return
+	}
+	for _, tx := range txs {
+		t := &pb.ObservedTransaction{
+			Hash:                 tx.Hash().Hex(),
+			Type:                 hexutil.EncodeUint64(uint64(tx.Type())),
+			Nonce:                hexutil.EncodeUint64(tx.Nonce()),
+			Value:                hexutil.EncodeBig(tx.Value()),
+			Gas:                  hexutil.EncodeUint64(tx.Gas()),
+			GasPrice:             hexutil.EncodeBig(tx.GasPrice()),
+			MaxFeePerGas:         hexutil.EncodeBig(tx.GasFeeCap()),
+			MaxPriorityFeePerGas: hexutil.EncodeBig(tx.GasTipCap()),
+			ChainId:              hexutil.EncodeBig(tx.ChainId()),
+		}
+		if tx.To() != nil {
+			to := tx.To().Hex()
+			t.To = &to
+		}
+		if data, err := tx.MarshalBinary(); err == nil {
+			t.Raw = data
+		}
+		s.publish(peer, &pb.SensorEvent{Event: &pb.SensorEvent_Transaction{Transaction: t}})
+	}
+}
+
+func (s *streamServer) publishPeer(p *peerConn, connected bool) {
+	if !s.active() {
+		return
+	}
+	e := &pb.PeerEvent{
+		Kind:    pb.PeerEvent_CONNECTED,
+		Client:  p.client,
+		Inbound: p.inbound,
+	}
+	if fp := p.fingerprint; fp != nil {
+		e.InferredClient = fp.InferredClient
+		e.Signature = fp.Signature
+		e.Spoofed = fp.Spoofed
+	}
+	if !connected {
+		e.Kind = pb.PeerEvent_DISCONNECTED
+		e.ConnectedSeconds = time.Since(p.connected).Seconds()
+	}
+	s.publish(p.node, &pb.SensorEvent{Event: &pb.SensorEvent_PeerEvent{PeerEvent: e}})
+}
@@ -0,0 +1,202 @@
+package sensor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	sourceP2P = "p2p"
+	sourceRPC = "rpc"
+)
+
+type (
+	// sighting tracks when a transaction was first seen on each side.
+	sighting struct {
+		p2p time.Time
+		rpc time.Time
+	}
+
+	// unmatchedTx is written to the comparison output for every transaction
+	// that was only seen on one side within the window.
+	unmatchedTx struct {
+		Hash      common.Hash `json:"hash"`
+		Source    string      `json:"source"`
+		FirstSeen time.Time   `json:"firstSeen"`
+	}
+
+	txCompareStats struct {
+		Both     uint64
+		P2POnly  uint64
+		RPCOnly  uint64
+		P2PFirst uint64
+		RPCFirst uint64
+		// lead is the sum of the time the p2p sighting was ahead of the rpc
+		// sighting. It's negative when the rpc node was faster.
+		lead time.Duration
+	}
+
+	// txComparer compares the pending transactions of an RPC node with the
+	// transactions received over p2p.
+	txComparer struct {
+		url    string
+		window time.Duration
+		out    *json.Encoder
+		txs    map[common.Hash]*sighting
+		stats  txCompareStats
+		mu     sync.Mutex
+	}
+)
+
+func newTxComparer(url string, window time.Duration, out *os.File) *txComparer {
+	c := &txComparer{
+		url:    url,
+		window: window,
+		txs:    make(map[common.Hash]*sighting),
+	}
+	if out != nil {
+		c.out = json.NewEncoder(out)
+	}
+	return c
+}
+
+func (c *txComparer) observe(source string, hashes ...common.Hash) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, hash := range hashes {
+		s, ok := c.txs[hash]
+		if !ok {
+			s = &sighting{}
+			c.txs[hash] = s
+		}
+		if source == sourceP2P && s.p2p.IsZero() {
+			s.p2p = now
+		} else if source == sourceRPC && s.rpc.IsZero() {
+			s.rpc = now
+		}
+	}
+}
+
+// sweep classifies the transactions that were first seen more than a window
+// ago. If flush is true, all transactions are classified.
+func (c *txComparer) sweep(flush bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for hash, s := range c.txs {
+		first := s.p2p
+		if first.IsZero() || (!s.rpc.IsZero() && s.rpc.Before(first)) {
+			first = s.rpc
+		}
+		if !flush && now.Sub(first) < c.window {
+			continue
+		}
+		delete(c.txs, hash)
+
+		switch {
+		case !s.p2p.IsZero() && !s.rpc.IsZero():
+			c.stats.Both++
+			c.stats.lead += s.rpc.Sub(s.p2p)
+			if s.p2p.Before(s.rpc) {
+				c.stats.P2PFirst++
+			} else {
+				c.stats.RPCFirst++
+			}
+			continue
+		case !s.p2p.IsZero():
+			c.stats.P2POnly++
+			c.write(unmatchedTx{hash, sourceP2P, s.p2p})
+		default:
+			c.stats.RPCOnly++
+			c.write(unmatchedTx{hash, sourceRPC, s.rpc})
+		}
+	}
+}
+
+func (c *txComparer) write(tx unmatchedTx) {
+	if c.out == nil {
+		return
+	}
+	if err := c.out.Encode(tx); err != nil {
+		log.Error().Err(err).Msg("Failed to write unmatched transaction")
+	}
+}
+
+func (c *txComparer) logStats(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lead float64
+	if c.stats.Both > 0 {
+		lead = (c.stats.lead / time.Duration(c.stats.Both)).Seconds() * 1000
+	}
+
+	log.Info().
+		Uint64("both", c.stats.Both).
+		Uint64("p2pOnly", c.stats.P2POnly).
+		Uint64("rpcOnly", c.stats.RPCOnly).
+		Uint64("p2pFirst", c.stats.P2PFirst).
+		Uint64("rpcFirst", c.stats.RPCFirst).
+		Float64("meanP2PLeadMs", lead).
+		Int("pending", len(c.txs)).
+		Msg(msg)
+}
+
+// run subscribes to the pending transactions of the RPC node until the
+// context is cancelled. The remaining transactions are then classified so the
+// output isn't missing the last window.
+func (c *txComparer) run(ctx context.Context) {
+	defer func() {
+		c.sweep(true)
+		c.logStats("Final transaction pool comparison")
+	}()
+
+	client, err := util.DialRPC(ctx, c.url)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to dial the rpc to compare transactions with")
+		return
+	}
+	defer client.Close()
+
+	hashes := make(chan common.Hash, 1024)
+	sub, err := client.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to subscribe to pending transactions")
+		return
+	}
+	defer sub.Unsubscribe()
+
+	sweepTicker := time.NewTicker(time.Second)
+	defer sweepTicker.Stop()
+	statsTicker := time.NewTicker(c.window)
+	defer statsTicker.Stop()
+
+	for {
+		select {
+		case hash := <-hashes:
+			c.observe(sourceRPC, hash)
+		case err := <-sub.Err():
+			log.Error().Err(err).Msg("Pending transaction subscription failed")
+			return
+		case <-sweepTicker.C:
+			c.sweep(false)
+		case <-statsTicker.C:
+			c.logStats("Transaction pool comparison")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sensor
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// sensorWizardSteps are the parameters that --interactive asks for. The
+// bootnodes, network ID, and genesis hash aren't asked for if a network
+// preset is chosen, since it sets them.
+func sensorWizardSteps(cmd *cobra.Command) []util.WizardStep {
+	hasNetwork := func() bool {
+		f := cmd.Flags().Lookup("network")
+		return f != nil && f.Value.String() != ""
+	}
+	return []util.WizardStep{{
+		Prompt:   "Nodes file to read and write the discovered nodes to",
+		Required: true,
+	}, {
+		Flag:    "network",
+		Prompt:  "Network preset, or empty to enter the bootnodes and network ID",
+		Choices: util.NetworkNames(),
+	}, {
+		Flag:     "bootnodes",
+		Prompt:   "Comma separated bootnodes",
+		Required: true,
+		Skip:     hasNetwork,
+		Validate: func(s string) error {
+			_, err := p2p.ParseNodeList(s)
+			return err
+		},
+	}, {
+		Flag:     "network-id",
+		Prompt:   "Network ID",
+		Required: true,
+		Skip:     hasNetwork,
+		Validate: func(s string) error {
+			if id, err := strconv.ParseUint(s, 10, 64); err != nil || id == 0 {
+				return errors.New("the network ID must be a number greater than zero")
+			}
+			return nil
+		},
+	}, {
+		Flag:   "genesis-hash",
+		Prompt: "Genesis hash to only peer with nodes of, or empty for any",
+		Skip:   hasNetwork,
+		Validate: func(s string) error {
+			var h common.Hash
+			return h.UnmarshalText([]byte(s))
+		},
+	}, {
+		Flag:   "max-peers",
+		Prompt: "Maximum number of peers",
+	}, {
+		Flag:   "port",
+		Prompt: "TCP port to accept inbound connections on, 0 to disable them",
+	}, {
+		Flag:   "database",
+		Prompt: "Node database to write to, or empty for none",
+	}, {
+		Flag:    "tui",
+		Prompt:  "Show the terminal UI",
+		Choices: []string{"true", "false"},
+	}}
+}
@@ -8,7 +8,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	"golang.org/x/crypto/sha3"
 
@@ -16,6 +15,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 var (
@@ -45,11 +46,15 @@ var ParseETHWalletCmd = &cobra.Command{
 		// it would be nice to have a generic reader
 		if *inputRawHexPrivateKey != "" {
 			ks := keystore.NewKeyStore(*inputKeyStoreDirectory, keystore.StandardScryptN, keystore.StandardScryptP)
-			pk, err := crypto.HexToECDSA(*inputRawHexPrivateKey)
+			pk, err := util.ResolvePrivateKey(*inputRawHexPrivateKey)
+			if err != nil {
+				return err
+			}
+			password, err := util.ResolveSecret(*inputPassword)
 			if err != nil {
 				return err
 			}
-			_, err = ks.ImportECDSA(pk, *inputPassword)
+			_, err = ks.ImportECDSA(pk, password)
 			if err != nil {
 				return err
 			}
@@ -65,7 +70,11 @@ var ParseETHWalletCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		d, err := keystore.DecryptDataV3(k.Crypto, *inputPassword)
+		password, err := util.ResolveSecret(*inputPassword)
+		if err != nil {
+			return err
+		}
+		d, err := keystore.DecryptDataV3(k.Crypto, password)
 		if err != nil {
 			return err
 		}
@@ -85,8 +94,8 @@ var ParseETHWalletCmd = &cobra.Command{
 func init() {
 	flagSet := ParseETHWalletCmd.PersistentFlags()
 	inputFileName = flagSet.String("file", "", "Provide a file with the key information ")
-	inputPassword = flagSet.String("password", "", "An optional password use to unlock the key")
-	inputRawHexPrivateKey = flagSet.String("hexkey", "", "An optional hexkey that would be use to generate a geth style key")
+	inputPassword = flagSet.String("password", "", "An optional password use to unlock the key. "+util.SecretHelp)
+	inputRawHexPrivateKey = flagSet.String("hexkey", "", "An optional hexkey that would be use to generate a geth style key. "+util.SecretHelp)
 	inputKeyStoreDirectory = flagSet.String("keystore", "/tmp/keystore", "The directory where keys would be stored when importing a raw hex")
 }
 
@@ -0,0 +1,297 @@
+package profileblocks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	selectorCreate   = "create"
+	selectorFallback = "fallback"
+)
+
+type (
+	profileBlocksParams struct {
+		URL    string
+		From   uint64
+		To     uint64
+		Top    int
+		Output string
+	}
+
+	callFrame struct {
+		Type    string             `json:"type"`
+		From    ethcommon.Address  `json:"from"`
+		To      *ethcommon.Address `json:"to"`
+		Input   hexutil.Bytes      `json:"input"`
+		GasUsed hexutil.Uint64     `json:"gasUsed"`
+		Error   string             `json:"error,omitempty"`
+		Calls   []callFrame        `json:"calls,omitempty"`
+	}
+
+	txTrace struct {
+		Result *callFrame `json:"result"`
+		Error  string     `json:"error,omitempty"`
+	}
+
+	contractStats struct {
+		Address      ethcommon.Address `json:"address"`
+		Calls        uint64            `json:"calls"`
+		SelfGas      uint64            `json:"selfGas"`
+		InclusiveGas uint64            `json:"inclusiveGas"`
+	}
+
+	selectorStats struct {
+		Address  ethcommon.Address `json:"address"`
+		Selector string            `json:"selector"`
+		Calls    uint64            `json:"calls"`
+		SelfGas  uint64            `json:"selfGas"`
+		Reverted uint64            `json:"reverted"`
+	}
+
+	selectorKey struct {
+		address  ethcommon.Address
+		selector string
+	}
+
+	profile struct {
+		From         uint64           `json:"from"`
+		To           uint64           `json:"to"`
+		Blocks       uint64           `json:"blocks"`
+		Transactions uint64           `json:"transactions"`
+		GasUsed      uint64           `json:"gasUsed"`
+		Contracts    []*contractStats `json:"contracts"`
+		Selectors    []*selectorStats `json:"selectors"`
+
+		contracts map[ethcommon.Address]*contractStats
+		selectors map[selectorKey]*selectorStats
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage              string
+	inputProfileBlocks profileBlocksParams
+)
+
+// ProfileBlocksCmd represents the profile-blocks command
+var ProfileBlocksCmd = &cobra.Command{
+	Use:   "profile-blocks url",
+	Short: "Aggregate the gas used per contract and function selector over a range of blocks.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputProfileBlocks.URL = args[0]
+
+		if inputProfileBlocks.To < inputProfileBlocks.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputProfileBlocks.Top <= 0 {
+			return fmt.Errorf("the number of rows must be greater than zero")
+		}
+		if !slices.Contains([]string{"text", "json"}, inputProfileBlocks.Output) {
+			return fmt.Errorf("output must be one of [text, json]")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputProfileBlocks.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		p := &profile{
+			From:      inputProfileBlocks.From,
+			To:        inputProfileBlocks.To,
+			contracts: make(map[ethcommon.Address]*contractStats),
+			selectors: make(map[selectorKey]*selectorStats),
+		}
+		for n := inputProfileBlocks.From; n <= inputProfileBlocks.To; n++ {
+			traces, err := traceBlock(ctx, rpc, n)
+			if err != nil {
+				return err
+			}
+			p.addBlock(traces)
+			log.Debug().Uint64("number", n).Int("transactions", len(traces)).Msg("Traced block")
+		}
+		p.sort()
+
+		if inputProfileBlocks.Output == "json" {
+			out, err := json.MarshalIndent(p, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		p.print(inputProfileBlocks.Top)
+		return nil
+	},
+}
+
+func init() {
+	ProfileBlocksCmd.PersistentFlags().Uint64Var(&inputProfileBlocks.From, "from", 0, "the first block of the range to profile")
+	ProfileBlocksCmd.PersistentFlags().Uint64Var(&inputProfileBlocks.To, "to", 0, "the last block of the range to profile")
+	ProfileBlocksCmd.PersistentFlags().IntVar(&inputProfileBlocks.Top, "top", 20, "the number of contracts and selectors to show")
+	ProfileBlocksCmd.PersistentFlags().StringVarP(&inputProfileBlocks.Output, "output", "o", "text", "the output format [text, json]")
+}
+
+func traceBlock(ctx context.Context, rpc *ethrpc.Client, number uint64) ([]txTrace, error) {
+	var traces []txTrace
+	config := map[string]interface{}{"tracer": "callTracer"}
+	if err := rpc.CallContext(ctx, &traces, "debug_traceBlockByNumber", hexutil.EncodeUint64(number), config); err != nil {
+		return nil, fmt.Errorf("unable to trace block %d: %w", number, err)
+	}
+	for i, t := range traces {
+		if t.Error != "" || t.Result == nil {
+			return nil, fmt.Errorf("unable to trace transaction %d of block %d: %s", i, number, t.Error)
+		}
+	}
+	return traces, nil
+}
+
+func (p *profile) addBlock(traces []txTrace) {
+	p.Blocks++
+	for _, t := range traces {
+		p.Transactions++
+		p.GasUsed += uint64(t.Result.GasUsed)
+		p.addFrame(t.Result)
+	}
+}
+
+// addFrame attributes the gas of the frame that wasn't spent by its sub calls
+// to the contract and selector of the frame.
+func (p *profile) addFrame(f *callFrame) {
+	self := uint64(f.GasUsed)
+	for i := range f.Calls {
+		child := uint64(f.Calls[i].GasUsed)
+		if child > self {
+			child = self
+		}
+		self -= child
+		p.addFrame(&f.Calls[i])
+	}
+
+	// The address of a failed creation isn't known.
+	if f.To == nil {
+		return
+	}
+
+	c, ok := p.contracts[*f.To]
+	if !ok {
+		c = &contractStats{Address: *f.To}
+		p.contracts[*f.To] = c
+	}
+	c.Calls++
+	c.SelfGas += self
+	c.InclusiveGas += uint64(f.GasUsed)
+
+	key := selectorKey{address: *f.To, selector: frameSelector(f)}
+	s, ok := p.selectors[key]
+	if !ok {
+		s = &selectorStats{Address: key.address, Selector: key.selector}
+		p.selectors[key] = s
+	}
+	s.Calls++
+	s.SelfGas += self
+	if f.Error != "" {
+		s.Reverted++
+	}
+}
+
+func frameSelector(f *callFrame) string {
+	switch {
+	case f.Type == "CREATE" || f.Type == "CREATE2":
+		return selectorCreate
+	case len(f.Input) < 4:
+		return selectorFallback
+	default:
+		return hexutil.Encode(f.Input[:4])
+	}
+}
+
+// sort orders the contracts and selectors by the gas they spent.
+func (p *profile) sort() {
+	p.Contracts = make([]*contractStats, 0, len(p.contracts))
+	for _, c := range p.contracts {
+		p.Contracts = append(p.Contracts, c)
+	}
+	sort.Slice(p.Contracts, func(i, j int) bool {
+		if p.Contracts[i].SelfGas != p.Contracts[j].SelfGas {
+			return p.Contracts[i].SelfGas > p.Contracts[j].SelfGas
+		}
+		return p.Contracts[i].Address.Hex() < p.Contracts[j].Address.Hex()
+	})
+
+	p.Selectors = make([]*selectorStats, 0, len(p.selectors))
+	for _, s := range p.selectors {
+		p.Selectors = append(p.Selectors, s)
+	}
+	sort.Slice(p.Selectors, func(i, j int) bool {
+		if p.Selectors[i].SelfGas != p.Selectors[j].SelfGas {
+			return p.Selectors[i].SelfGas > p.Selectors[j].SelfGas
+		}
+		if p.Selectors[i].Address != p.Selectors[j].Address {
+			return p.Selectors[i].Address.Hex() < p.Selectors[j].Address.Hex()
+		}
+		return p.Selectors[i].Selector < p.Selectors[j].Selector
+	})
+}
+
+func (p *profile) share(gas uint64) string {
+	if p.GasUsed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(gas)/float64(p.GasUsed)*100)
+}
+
+func (p *profile) print(top int) {
+	fmt.Printf("Blocks %d to %d: %d blocks, %d transactions, %d gas used\n", p.From, p.To, p.Blocks, p.Transactions, p.GasUsed)
+
+	ct := table.NewWriter()
+	ct.SetOutputMirror(os.Stdout)
+	ct.SetTitle("Contracts")
+	ct.AppendHeader(table.Row{"Address", "Calls", "Self Gas", "Share", "Inclusive Gas"})
+	for i, c := range p.Contracts {
+		if i == top {
+			break
+		}
+		ct.AppendRow(table.Row{c.Address.Hex(), c.Calls, c.SelfGas, p.share(c.SelfGas), c.InclusiveGas})
+	}
+	ct.Render()
+
+	st := table.NewWriter()
+	st.SetOutputMirror(os.Stdout)
+	st.SetTitle("Selectors")
+	st.AppendHeader(table.Row{"Address", "Selector", "Calls", "Reverted", "Self Gas", "Share"})
+	for i, s := range p.Selectors {
+		if i == top {
+			break
+		}
+		st.AppendRow(table.Row{s.Address.Hex(), s.Selector, s.Calls, s.Reverted, s.SelfGas, p.share(s.SelfGas)})
+	}
+	st.Render()
+}
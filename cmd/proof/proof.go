@@ -0,0 +1,154 @@
+package proof
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	proofParams struct {
+		RPCURL  string
+		Address string
+		Slots   []string
+		Block   string
+	}
+
+	rpcHeader struct {
+		Number    hexutil.Uint64 `json:"number"`
+		Hash      ethcommon.Hash `json:"hash"`
+		StateRoot ethcommon.Hash `json:"stateRoot"`
+	}
+
+	// verifiedProof is the output, which only has values that were proven
+	// against the state root.
+	verifiedProof struct {
+		Block     uint64         `json:"block"`
+		BlockHash ethcommon.Hash `json:"blockHash"`
+		StateRoot ethcommon.Hash `json:"stateRoot"`
+		*util.VerifiedAccount
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage      string
+	inputProof proofParams
+)
+
+// ProofCmd fetches and verifies account and storage proofs.
+var ProofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "Fetch the proof of an account and its storage slots and verify it against the state root.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !addressbook.Valid(inputProof.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputProof.Address)
+		}
+		for _, s := range inputProof.Slots {
+			if _, err := util.ParseSlot(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputProof.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		header, err := getHeader(ctx, rpc, inputProof.Block)
+		if err != nil {
+			return err
+		}
+
+		address, err := addressbook.NewResolver(rpc).Resolve(ctx, inputProof.Address)
+		if err != nil {
+			return err
+		}
+		slots := make([]ethcommon.Hash, len(inputProof.Slots))
+		for i, s := range inputProof.Slots {
+			slots[i], _ = util.ParseSlot(s)
+		}
+
+		// The proof is requested by number rather than the block argument so
+		// that it's for the same block as the state root.
+		var p util.AccountProof
+		if err = rpc.CallContext(ctx, &p, "eth_getProof", address, slots, header.Number); err != nil {
+			return fmt.Errorf("unable to get the proof: %w", err)
+		}
+		log.Debug().Uint64("block", uint64(header.Number)).Int("nodes", len(p.AccountProof)).Msg("Fetched proof")
+
+		account, err := util.VerifyAccountProof(header.StateRoot, address, slots, &p)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the proof failed verification: %w", err)
+		}
+		out := verifiedProof{
+			Block:           uint64(header.Number),
+			BlockHash:       header.Hash,
+			StateRoot:       header.StateRoot,
+			VerifiedAccount: account,
+		}
+
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+func init() {
+	flagSet := ProofCmd.Flags()
+	flagSet.StringVar(&inputProof.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputProof.Address, "address", "", "The address of the account to prove. "+addressbook.Help)
+	flagSet.StringSliceVar(&inputProof.Slots, "slot", nil, "The storage slots to prove, as hex or decimal. Can be repeated")
+	flagSet.StringVar(&inputProof.Block, "block", "latest", "The block number, hash, or tag to prove the account at")
+	_ = ProofCmd.MarkFlagRequired("address")
+}
+
+// getHeader returns the header of the block number, hash, or tag.
+func getHeader(ctx context.Context, rpc *ethrpc.Client, block string) (*rpcHeader, error) {
+	var (
+		header *rpcHeader
+		err    error
+	)
+	switch {
+	case len(block) == 66 && strings.HasPrefix(block, "0x"):
+		err = rpc.CallContext(ctx, &header, "eth_getBlockByHash", block, false)
+	case strings.HasPrefix(block, "0x"):
+		err = rpc.CallContext(ctx, &header, "eth_getBlockByNumber", block, false)
+	default:
+		if n, ok := new(big.Int).SetString(block, 10); ok {
+			block = hexutil.EncodeBig(n)
+		}
+		err = rpc.CallContext(ctx, &header, "eth_getBlockByNumber", block, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get block %s: %w", block, err)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+	return header, nil
+}
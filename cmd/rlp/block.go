@@ -0,0 +1,187 @@
+package rlp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type blockOutput struct {
+	Hash             *common.Hash             `json:"hash,omitempty"`
+	Header           map[string]interface{}   `json:"header,omitempty"`
+	Transactions     []interface{}            `json:"transactions"`
+	Uncles           []map[string]interface{} `json:"uncles"`
+	Withdrawals      []hexutil.Bytes          `json:"withdrawals,omitempty"`
+	TransactionsRoot common.Hash              `json:"transactionsRoot"`
+	UnclesHash       common.Hash              `json:"unclesHash"`
+	Errors           []string                 `json:"errors,omitempty"`
+}
+
+// typedTx summarizes the transactions whose type go-ethereum doesn't know.
+type typedTx struct {
+	Type hexutil.Uint64  `json:"type"`
+	Hash common.Hash     `json:"hash"`
+	From *common.Address `json:"from,omitempty"`
+	Raw  hexutil.Bytes   `json:"raw"`
+}
+
+var blockCmd = &cobra.Command{
+	Use:   "block [hex]",
+	Short: "Decode a raw block or block body and check its transactions root and uncles hash.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+		out, err := decodeBlock(data)
+		if err != nil {
+			return err
+		}
+		if err = printJSON(out); err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return errors.New("the body doesn't match the header")
+		}
+		return nil
+	},
+}
+
+// decodeBlock decodes a block, which is the header followed by the body, or
+// just the body, which is the transactions, the uncles, and after Shanghai
+// the withdrawals.
+func decodeBlock(data []byte) (*blockOutput, error) {
+	var items []ethrlp.RawValue
+	if err := ethrlp.DecodeBytes(data, &items); err != nil {
+		return nil, fmt.Errorf("unable to decode the block: %w", err)
+	}
+
+	out := &blockOutput{Transactions: []interface{}{}, Uncles: []map[string]interface{}{}}
+	var header *types.Header
+	if len(items) > 0 && isHeader(items[0]) {
+		fields, h, err := decodeHeader(items[0], jaipurBlock)
+		if err != nil {
+			return nil, err
+		}
+		hash := crypto.Keccak256Hash(items[0])
+		out.Hash, out.Header, header = &hash, fields, h
+		items = items[1:]
+	}
+	if len(items) < 2 || len(items) > 3 {
+		return nil, fmt.Errorf("the block body has %d items rather than 2 or 3", len(items))
+	}
+
+	var txs []ethrlp.RawValue
+	if err := ethrlp.DecodeBytes(items[0], &txs); err != nil {
+		return nil, fmt.Errorf("unable to decode the transactions: %w", err)
+	}
+	encoded := make([][]byte, 0, len(txs))
+	for i, raw := range txs {
+		tx, envelope, err := decodeBlockTx(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode transaction %d: %w", i, err)
+		}
+		out.Transactions = append(out.Transactions, tx)
+		encoded = append(encoded, envelope)
+	}
+	out.TransactionsRoot = util.DeriveListRoot(encoded)
+
+	var uncles []ethrlp.RawValue
+	if err := ethrlp.DecodeBytes(items[1], &uncles); err != nil {
+		return nil, fmt.Errorf("unable to decode the uncles: %w", err)
+	}
+	for i, raw := range uncles {
+		uncle, _, err := decodeHeader(raw, jaipurBlock)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode uncle %d: %w", i, err)
+		}
+		out.Uncles = append(out.Uncles, uncle)
+	}
+	out.UnclesHash = crypto.Keccak256Hash(items[1])
+
+	if len(items) == 3 {
+		var withdrawals []ethrlp.RawValue
+		if err := ethrlp.DecodeBytes(items[2], &withdrawals); err != nil {
+			return nil, fmt.Errorf("unable to decode the withdrawals: %w", err)
+		}
+		for _, w := range withdrawals {
+			out.Withdrawals = append(out.Withdrawals, hexutil.Bytes(w))
+		}
+	}
+
+	if header != nil {
+		if out.TransactionsRoot != header.TxHash {
+			out.Errors = append(out.Errors, fmt.Sprintf("transactions root %s doesn't match header %s", out.TransactionsRoot, header.TxHash))
+		}
+		if out.UnclesHash != header.UncleHash {
+			out.Errors = append(out.Errors, fmt.Sprintf("uncles hash %s doesn't match header %s", out.UnclesHash, header.UncleHash))
+		}
+	}
+	return out, nil
+}
+
+// isHeader returns whether the item is a header rather than the transactions
+// of a body. Headers start with the 32 byte parent hash, while transactions
+// are either lists or typed envelopes that are much longer.
+func isHeader(item []byte) bool {
+	kind, content, _, err := ethrlp.Split(item)
+	if err != nil || kind != ethrlp.List {
+		return false
+	}
+	kind, first, _, err := ethrlp.Split(content)
+	return err == nil && kind == ethrlp.String && len(first) == common.HashLength
+}
+
+// decodeBlockTx decodes a transaction of a block, which is a list for legacy
+// transactions and a string holding the envelope for typed ones, and returns
+// it with its consensus encoding.
+func decodeBlockTx(raw []byte) (interface{}, []byte, error) {
+	kind, envelope, _, err := ethrlp.Split(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind == ethrlp.List {
+		envelope = raw
+	}
+
+	tx := new(types.Transaction)
+	if err = tx.UnmarshalBinary(envelope); err == nil {
+		return tx, envelope, nil
+	}
+	if len(envelope) == 0 || kind == ethrlp.List {
+		return nil, nil, err
+	}
+
+	out := &typedTx{Type: hexutil.Uint64(envelope[0]), Hash: crypto.Keccak256Hash(envelope), Raw: envelope}
+	var from common.Address
+	switch envelope[0] {
+	case util.BlobTxType:
+		btx, _, err := util.DecodeBlobTx(envelope)
+		if err != nil {
+			return nil, nil, err
+		}
+		from, err = btx.Sender()
+		if err == nil {
+			out.From = &from
+		}
+	case util.SetCodeTxType:
+		stx := new(util.SetCodeTx)
+		if err := stx.UnmarshalBinary(envelope); err != nil {
+			return nil, nil, err
+		}
+		from, err = stx.Sender()
+		if err == nil {
+			out.From = &from
+		}
+	}
+	return out, envelope, nil
+}
@@ -0,0 +1,127 @@
+package rlp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+)
+
+type decodeOutput struct {
+	RLP     interface{} `json:"rlp"`
+	Type    string      `json:"type,omitempty"`
+	Decoded interface{} `json:"decoded,omitempty"`
+}
+
+var decodeCmd = &cobra.Command{
+	Use:   "decode [hex]",
+	Short: "Decode RLP into nested JSON and detect common payloads.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+		data, err := hexutil.Decode(input)
+		if err != nil {
+			return fmt.Errorf("unable to decode hex input: %w", err)
+		}
+
+		tree, err := decodeRLP(data)
+		if err != nil {
+			return err
+		}
+
+		out := decodeOutput{RLP: tree}
+		out.Type, out.Decoded = detectPayload(data)
+
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+// decodeRLP converts the RLP data into nested slices of hex strings. Top level
+// data that contains multiple concatenated items is returned as a list.
+func decodeRLP(data []byte) (interface{}, error) {
+	var items []interface{}
+	for len(data) > 0 {
+		item, rest, err := decodeItem(data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		data = rest
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return items, nil
+}
+
+func decodeItem(data []byte) (interface{}, []byte, error) {
+	kind, content, rest, err := ethrlp.Split(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kind != ethrlp.List {
+		return hexutil.Encode(content), rest, nil
+	}
+
+	list := []interface{}{}
+	for len(content) > 0 {
+		var item interface{}
+		item, content, err = decodeItem(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rest, nil
+}
+
+// detectPayload tries to decode the data as common ethereum types. The order
+// matters because the more strict types are tried first.
+func detectPayload(data []byte) (string, interface{}) {
+	header := new(types.Header)
+	if err := ethrlp.DecodeBytes(data, header); err == nil {
+		return "header", header
+	}
+
+	block := new(types.Block)
+	if err := ethrlp.DecodeBytes(data, block); err == nil {
+		return "block", map[string]interface{}{
+			"hash":         block.Hash(),
+			"header":       block.Header(),
+			"transactions": block.Transactions(),
+			"uncles":       block.Uncles(),
+		}
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err == nil {
+		return "transaction", tx
+	}
+
+	receipt := new(types.Receipt)
+	if err := receipt.UnmarshalBinary(data); err == nil {
+		return "receipt", receipt
+	}
+
+	var stored []*types.ReceiptForStorage
+	if err := ethrlp.DecodeBytes(data, &stored); err == nil && len(stored) > 0 {
+		receipts := make([]*types.Receipt, 0, len(stored))
+		for _, r := range stored {
+			receipts = append(receipts, (*types.Receipt)(r))
+		}
+		return "receipts", receipts
+	}
+
+	return "", nil
+}
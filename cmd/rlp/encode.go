@@ -0,0 +1,82 @@
+package rlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+)
+
+var encodeCmd = &cobra.Command{
+	Use:   "encode [json]",
+	Short: "Encode JSON into RLP.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+
+		// Numbers are decoded as json.Number so large integers aren't
+		// truncated to a float.
+		var v interface{}
+		decoder := json.NewDecoder(strings.NewReader(input))
+		decoder.UseNumber()
+		if err = decoder.Decode(&v); err != nil {
+			return fmt.Errorf("unable to parse json input: %w", err)
+		}
+
+		item, err := toEncodable(v)
+		if err != nil {
+			return err
+		}
+		out, err := ethrlp.EncodeToBytes(item)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hexutil.Encode(out))
+		return nil
+	},
+}
+
+// toEncodable converts the decoded JSON value into types that the rlp package
+// knows how to encode.
+func toEncodable(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case []interface{}:
+		list := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			encodable, err := toEncodable(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, encodable)
+		}
+		return list, nil
+	case json.Number:
+		n, ok := new(big.Int).SetString(value.String(), 10)
+		if !ok || n.Sign() < 0 {
+			return nil, fmt.Errorf("%s is not a positive integer", value)
+		}
+		return n, nil
+	case string:
+		if strings.HasPrefix(value, "0x") {
+			b, err := hexutil.Decode(value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode hex string %s: %w", value, err)
+			}
+			return b, nil
+		}
+		return []byte(value), nil
+	case bool:
+		return value, nil
+	case nil:
+		return []byte{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported json value %v", value)
+	}
+}
@@ -0,0 +1,127 @@
+package rlp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// londonHeaderFields is the number of header fields that go-ethereum decodes,
+// up to the base fee.
+const londonHeaderFields = 16
+
+// headerExtensions are the header fields that were added after London, which
+// the go-ethereum version used by polycli doesn't know about, and whether they
+// are quantities or hashes.
+var headerExtensions = []struct {
+	Name     string
+	Quantity bool
+}{
+	{"withdrawalsRoot", false},
+	{"blobGasUsed", true},
+	{"excessBlobGas", true},
+	{"parentBeaconBlockRoot", false},
+	{"requestsHash", false},
+}
+
+var headerCmd = &cobra.Command{
+	Use:   "header [hex]",
+	Short: "Decode a raw block header, including the bor extra data.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+		header, _, err := decodeHeader(data, jaipurBlock)
+		if err != nil {
+			return err
+		}
+		return printJSON(header)
+	},
+}
+
+// decodeHeader decodes the header into its JSON fields. The fields after
+// London are decoded separately, and the hash is the hash of all of them. The
+// signer of bor headers is recovered with the Jaipur block.
+func decodeHeader(data []byte, jaipurBlock uint64) (map[string]interface{}, *types.Header, error) {
+	var items []ethrlp.RawValue
+	if err := ethrlp.DecodeBytes(data, &items); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode the header: %w", err)
+	}
+	known := items
+	if len(known) > londonHeaderFields {
+		known = items[:londonHeaderFields]
+	}
+	encoded, err := ethrlp.EncodeToBytes(known)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := new(types.Header)
+	if err = ethrlp.DecodeBytes(encoded, header); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode the header: %w", err)
+	}
+
+	b, err := json.Marshal(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, nil, err
+	}
+	fields["hash"] = crypto.Keccak256Hash(data)
+
+	for i, item := range items[len(known):] {
+		if i >= len(headerExtensions) {
+			fields[fmt.Sprintf("field%d", len(known)+i)] = hexutil.Bytes(item)
+			continue
+		}
+		ext := headerExtensions[i]
+		if ext.Quantity {
+			var v uint64
+			if err = ethrlp.DecodeBytes(item, &v); err != nil {
+				return nil, nil, fmt.Errorf("unable to decode %s: %w", ext.Name, err)
+			}
+			fields[ext.Name] = hexutil.Uint64(v)
+			continue
+		}
+		var h common.Hash
+		if err = ethrlp.DecodeBytes(item, &h); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode %s: %w", ext.Name, err)
+		}
+		fields[ext.Name] = h
+	}
+
+	// Ethereum headers have at most 32 bytes of extra data, so anything that
+	// has room for a seal is treated as a bor header. The seal hash doesn't
+	// cover the fields after London, so the signer is only recovered without
+	// them. Bor only covers the base fee from the Jaipur block.
+	if extra, err := util.ParseBorExtra(header.Extra); err == nil {
+		bor := map[string]interface{}{"extra": extra}
+		if len(items) <= londonHeaderFields {
+			if signer, err := util.BorSealSigner(header, jaipurBlock); err == nil {
+				bor["signer"] = signer
+			}
+		}
+		fields["bor"] = bor
+	}
+	return fields, header, nil
+}
+
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
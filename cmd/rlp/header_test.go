@@ -0,0 +1,67 @@
+package rlp
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+func TestDecodeHeaderSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA("42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	tests := []struct {
+		name   string
+		number int64
+		jaipur uint64
+	}{
+		{"before jaipur", 99, 100},
+		{"at jaipur", 100, 100},
+		{"without jaipur", 100, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := &types.Header{
+				Difficulty: big.NewInt(16),
+				Number:     big.NewInt(tc.number),
+				GasLimit:   30000000,
+				Extra:      make([]byte, 32+crypto.SignatureLength),
+				BaseFee:    big.NewInt(30000000000),
+			}
+			sig, err := crypto.Sign(util.BorSealHash(header, tc.jaipur).Bytes(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(header.Extra[32:], sig)
+			data, err := ethrlp.EncodeToBytes(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fields, _, err := decodeHeader(data, tc.jaipur)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fields["hash"] != header.Hash() {
+				t.Errorf("expected the hash %s, got %v", header.Hash().Hex(), fields["hash"])
+			}
+			bor, ok := fields["bor"].(map[string]interface{})
+			if !ok {
+				t.Fatal("expected the header to be decoded as a bor header")
+			}
+			if got, ok := bor["signer"].(common.Address); !ok || got != signer {
+				t.Errorf("expected the signer %s, got %v", signer.Hex(), bor["signer"])
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package rlp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type decodedLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+type decodedReceipt struct {
+	Type              hexutil.Uint64  `json:"type"`
+	Root              hexutil.Bytes   `json:"root,omitempty"`
+	Status            *hexutil.Uint64 `json:"status,omitempty"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	LogsBloom         types.Bloom     `json:"logsBloom"`
+	Logs              []decodedLog    `json:"logs"`
+}
+
+type receiptsOutput struct {
+	Format       string           `json:"format"`
+	Receipts     []decodedReceipt `json:"receipts"`
+	ReceiptsRoot common.Hash      `json:"receiptsRoot"`
+	LogsBloom    types.Bloom      `json:"logsBloom"`
+	Errors       []string         `json:"errors,omitempty"`
+}
+
+// receiptFields is the consensus encoding of a receipt, which storage
+// receipts encode without the bloom.
+type receiptFields struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	Logs              []*types.Log
+}
+
+type storageReceiptFields struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*types.Log
+}
+
+var receiptsCmd = &cobra.Command{
+	Use:   "receipts [hex]",
+	Short: "Decode raw receipts and compute their receipts root.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+		out, err := decodeReceipts(data)
+		if err != nil {
+			return err
+		}
+		if err = printJSON(out); err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return errors.New("the logs blooms don't match the logs")
+		}
+		return nil
+	},
+}
+
+// decodeReceipts decodes a single typed receipt, a list of consensus encoded
+// receipts like the ones in eth protocol messages, or a list of storage
+// encoded receipts like the ones in the database. Storage receipts don't have
+// a type, so the receipts root is only right for legacy transactions.
+func decodeReceipts(data []byte) (*receiptsOutput, error) {
+	out := &receiptsOutput{Format: "consensus"}
+	var items []ethrlp.RawValue
+	if len(data) > 0 && data[0] < 0x7f {
+		items = []ethrlp.RawValue{data}
+		out.Format = "typed"
+	} else if err := ethrlp.DecodeBytes(data, &items); err != nil {
+		return nil, fmt.Errorf("unable to decode the receipts: %w", err)
+	}
+
+	var encoded [][]byte
+	var previous uint64
+	for i, item := range items {
+		// Typed receipts are wrapped in a string inside lists.
+		envelope := item
+		if out.Format != "typed" {
+			kind, content, _, err := ethrlp.Split(item)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode receipt %d: %w", i, err)
+			}
+			if kind == ethrlp.String {
+				envelope = content
+			}
+		}
+		r, envelope, err := decodeReceipt(envelope, out)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode receipt %d: %w", i, err)
+		}
+		if uint64(r.CumulativeGasUsed) < previous {
+			out.Errors = append(out.Errors, fmt.Sprintf("the cumulative gas used of receipt %d decreases", i))
+		} else {
+			r.GasUsed = r.CumulativeGasUsed - hexutil.Uint64(previous)
+		}
+		previous = uint64(r.CumulativeGasUsed)
+
+		for j := range out.LogsBloom {
+			out.LogsBloom[j] |= r.LogsBloom[j]
+		}
+		out.Receipts = append(out.Receipts, *r)
+		encoded = append(encoded, envelope)
+	}
+	out.ReceiptsRoot = util.DeriveListRoot(encoded)
+	return out, nil
+}
+
+// decodeReceipt decodes the receipt and returns it with its consensus
+// encoding, which is the type followed by the RLP list for typed receipts.
+func decodeReceipt(envelope []byte, out *receiptsOutput) (*decodedReceipt, []byte, error) {
+	r := &decodedReceipt{}
+	payload := envelope
+	if len(envelope) > 0 && envelope[0] < 0x7f {
+		r.Type = hexutil.Uint64(envelope[0])
+		payload = envelope[1:]
+	}
+
+	var fields receiptFields
+	if err := ethrlp.DecodeBytes(payload, &fields); err != nil {
+		var stored storageReceiptFields
+		if err = ethrlp.DecodeBytes(payload, &stored); err != nil {
+			return nil, nil, err
+		}
+		out.Format = "storage"
+		fields = receiptFields{
+			PostStateOrStatus: stored.PostStateOrStatus,
+			CumulativeGasUsed: stored.CumulativeGasUsed,
+			Bloom:             types.BytesToBloom(types.LogsBloom(stored.Logs)),
+			Logs:              stored.Logs,
+		}
+		if envelope, err = ethrlp.EncodeToBytes(&fields); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch len(fields.PostStateOrStatus) {
+	case 0, 1:
+		status := hexutil.Uint64(0)
+		if len(fields.PostStateOrStatus) == 1 {
+			status = hexutil.Uint64(fields.PostStateOrStatus[0])
+		}
+		r.Status = &status
+	default:
+		r.Root = fields.PostStateOrStatus
+	}
+	r.CumulativeGasUsed = hexutil.Uint64(fields.CumulativeGasUsed)
+	r.LogsBloom = fields.Bloom
+	r.Logs = []decodedLog{}
+	for _, l := range fields.Logs {
+		topics := l.Topics
+		if topics == nil {
+			topics = []common.Hash{}
+		}
+		r.Logs = append(r.Logs, decodedLog{Address: l.Address, Topics: topics, Data: l.Data})
+	}
+
+	if computed := types.BytesToBloom(types.LogsBloom(fields.Logs)); computed != fields.Bloom {
+		out.Errors = append(out.Errors, fmt.Sprintf("the logs bloom of receipt %d doesn't match its logs", len(out.Receipts)))
+	}
+	return r, envelope, nil
+}
@@ -0,0 +1,89 @@
+package rlp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// RlpCmd represents the rlp command
+var RlpCmd = &cobra.Command{
+	Use:   "rlp",
+	Short: "Decode and encode RLP data.",
+	Long:  usage,
+}
+
+// inputFile is the file that the header, block, and receipts commands read
+// from.
+var inputFile string
+
+// jaipurBlock is the bor fork from which the seals of the headers cover the
+// base fee.
+var jaipurBlock uint64
+
+func init() {
+	RlpCmd.AddCommand(decodeCmd)
+	RlpCmd.AddCommand(encodeCmd)
+	RlpCmd.AddCommand(headerCmd)
+	RlpCmd.AddCommand(blockCmd)
+	RlpCmd.AddCommand(receiptsCmd)
+
+	for _, cmd := range []*cobra.Command{headerCmd, blockCmd, receiptsCmd} {
+		cmd.Flags().StringVar(&inputFile, "file", "", "Read the raw or hex encoded RLP from a file rather than the arguments or stdin")
+	}
+	for _, cmd := range []*cobra.Command{headerCmd, blockCmd} {
+		cmd.Flags().Uint64Var(&jaipurBlock, "jaipur-block", 0, "The bor Jaipur block, from which the seals cover the base fee")
+	}
+}
+
+// getInputData returns the first argument if there is one, otherwise it
+// reads stdin.
+func getInputData(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimSpace(args[0]), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("unable to read stdin: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readInput returns the RLP in the file if one is set, or the argument or
+// stdin otherwise. Files can either be hex encoded or raw binary.
+func readInput(args []string) ([]byte, error) {
+	if inputFile == "" {
+		input, err := getInputData(args)
+		if err != nil {
+			return nil, err
+		}
+		data, err := hexutil.Decode(input)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode hex input: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(data))
+	if decoded, err := hexutil.Decode(text); err == nil {
+		return decoded, nil
+	}
+	if decoded, err := hex.DecodeString(text); err == nil && len(text) > 0 {
+		return decoded, nil
+	}
+	return data, nil
+}
@@ -17,9 +17,15 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/maticnetwork/polygon-cli/cmd/fork"
 	"github.com/maticnetwork/polygon-cli/cmd/p2p"
 	"github.com/maticnetwork/polygon-cli/cmd/parseethwallet"
@@ -30,24 +36,58 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/maticnetwork/polygon-cli/cmd/abi"
+	"github.com/maticnetwork/polygon-cli/cmd/addressbook"
+	"github.com/maticnetwork/polygon-cli/cmd/approvals"
+	"github.com/maticnetwork/polygon-cli/cmd/blob"
+	"github.com/maticnetwork/polygon-cli/cmd/blockstats"
+	"github.com/maticnetwork/polygon-cli/cmd/borvalidators"
+	"github.com/maticnetwork/polygon-cli/cmd/call"
+	"github.com/maticnetwork/polygon-cli/cmd/convert"
+	"github.com/maticnetwork/polygon-cli/cmd/devnet"
 	"github.com/maticnetwork/polygon-cli/cmd/dumpblocks"
+	"github.com/maticnetwork/polygon-cli/cmd/ecrecover"
 	"github.com/maticnetwork/polygon-cli/cmd/forge"
+	"github.com/maticnetwork/polygon-cli/cmd/forkid"
+	"github.com/maticnetwork/polygon-cli/cmd/forknode"
+	"github.com/maticnetwork/polygon-cli/cmd/genesis"
 	"github.com/maticnetwork/polygon-cli/cmd/hash"
+	"github.com/maticnetwork/polygon-cli/cmd/interfaces"
+	"github.com/maticnetwork/polygon-cli/cmd/lightverify"
 	"github.com/maticnetwork/polygon-cli/cmd/loadtest"
 	"github.com/maticnetwork/polygon-cli/cmd/metricsToDash"
+	"github.com/maticnetwork/polygon-cli/cmd/milestones"
 	"github.com/maticnetwork/polygon-cli/cmd/mnemonic"
 	"github.com/maticnetwork/polygon-cli/cmd/monitor"
 	"github.com/maticnetwork/polygon-cli/cmd/nodekey"
+	"github.com/maticnetwork/polygon-cli/cmd/profileblocks"
+	"github.com/maticnetwork/polygon-cli/cmd/proof"
+	"github.com/maticnetwork/polygon-cli/cmd/rlp"
 	"github.com/maticnetwork/polygon-cli/cmd/rpc"
+	"github.com/maticnetwork/polygon-cli/cmd/rpccapabilities"
 	"github.com/maticnetwork/polygon-cli/cmd/rpcfuzz"
+	"github.com/maticnetwork/polygon-cli/cmd/sign"
+	"github.com/maticnetwork/polygon-cli/cmd/simulate"
+	"github.com/maticnetwork/polygon-cli/cmd/storage"
+	"github.com/maticnetwork/polygon-cli/cmd/token"
+	"github.com/maticnetwork/polygon-cli/cmd/trie"
+	"github.com/maticnetwork/polygon-cli/cmd/tx"
+	"github.com/maticnetwork/polygon-cli/cmd/verify"
+	"github.com/maticnetwork/polygon-cli/cmd/verifyheaders"
+	"github.com/maticnetwork/polygon-cli/cmd/verifyreceipts"
 	"github.com/maticnetwork/polygon-cli/cmd/version"
 	"github.com/maticnetwork/polygon-cli/cmd/wallet"
+	"github.com/maticnetwork/polygon-cli/cmd/wsstress"
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 var (
 	cfgFile   string
 	verbosity int
 	pretty    bool
+	network   string
+	progress  string
+	transport = util.DefaultRPCTransport()
+	names     util.NameOptions
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -55,10 +95,22 @@ var rootCmd *cobra.Command
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+//
+// The command context is cancelled on SIGINT or SIGTERM so long running
+// commands can shut down cleanly and flush their output. A second signal
+// terminates the process immediately. The exit code tells the kind of failure
+// apart, see util.ExitCode.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(util.ExitCode(err))
 	}
 }
 
@@ -98,8 +150,24 @@ func NewPolycliCommand() *cobra.Command {
 		Use:   "polycli",
 		Short: "A Swiss Army knife of blockchain tools.",
 		Long:  "Polycli is a collection of tools that are meant to be useful while building, testing, and running block chain applications.",
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			setLogLevel(verbosity, pretty)
+			if err := util.SetProgressMode(progress); err != nil {
+				return util.ConfigError(err)
+			}
+			if err := util.SetRPCTransport(transport); err != nil {
+				return util.ConfigError(err)
+			}
+			if err := util.SetNameOptions(names); err != nil {
+				return util.ConfigError(err)
+			}
+			if network == "" {
+				return nil
+			}
+			if err := applyNetwork(cmd, network); err != nil {
+				return util.ConfigError(err)
+			}
+			return nil
 		},
 	}
 
@@ -107,6 +175,17 @@ func NewPolycliCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.polygon-cli.yaml)")
 	cmd.PersistentFlags().IntVarP(&verbosity, "verbosity", "v", 400, "0 - Silent\n100 Fatal\n200 Error\n300 Warning\n400 Info\n500 Debug\n600 Trace")
 	cmd.PersistentFlags().BoolVar(&pretty, "pretty-logs", true, "Should logs be in pretty format or JSON")
+	cmd.PersistentFlags().StringVar(&network, "network", "", fmt.Sprintf(`Network preset (%s) that sets the chain ID,
+bootnodes, genesis hash, fork ID, Jaipur block, base fee change denominator, and default RPC URL of the command if they aren't set`, strings.Join(util.NetworkNames(), "|")))
+	cmd.PersistentFlags().StringVar(&progress, "progress", util.ProgressAuto, fmt.Sprintf(`How long running commands report their progress (%s),
+auto draws a bar on terminals and logs the progress otherwise`, strings.Join(util.ProgressModes(), "|")))
+	cmd.PersistentFlags().IntVar(&transport.MaxConnsPerHost, "rpc-max-conns-per-host", transport.MaxConnsPerHost, "Maximum number of connections to an HTTP RPC endpoint, 0 doesn't limit them")
+	cmd.PersistentFlags().BoolVar(&transport.HTTP2, "rpc-http2", transport.HTTP2, "Use HTTP/2 with https RPC endpoints that support it")
+	cmd.PersistentFlags().DurationVar(&transport.KeepAlive, "rpc-keep-alive", transport.KeepAlive, "How long idle connections to HTTP RPC endpoints are kept for reuse, 0 opens a new connection for every request")
+	cmd.PersistentFlags().IntVar(&transport.TLSSessionCache, "rpc-tls-session-cache", transport.TLSSessionCache, "Number of TLS sessions to cache to resume them without a full handshake, 0 disables session resumption")
+	cmd.PersistentFlags().BoolVar(&transport.Sticky, "rpc-sticky", transport.Sticky, "Keep the cookies of HTTP RPC endpoints, so load balancers with cookie based session affinity stick to one node")
+	cmd.PersistentFlags().BoolVar(&names.Resolve, "resolve-names", false, "Show the ENS names of the addresses that are displayed, like the senders and recipients of monitor")
+	cmd.PersistentFlags().StringVar(&names.Registry, "name-registry", "", "Address of an ENS compatible registry to resolve names with, like the one of a Polygon name service (defaults to the ENS registry)")
 
 	// Define local flags which will only run when this action is called directly.
 	cmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
@@ -115,25 +194,128 @@ func NewPolycliCommand() *cobra.Command {
 	// Define commands.
 	cmd.AddCommand(
 		abi.ABICmd,
+		addressbook.AddressBookCmd,
+		approvals.ApprovalsCmd,
+		blob.BlobCmd,
+		blockstats.BlockStatsCmd,
+		borvalidators.BorValidatorsCmd,
+		call.CallCmd,
+		convert.ConvertCmd,
+		devnet.DevnetCmd,
 		dumpblocks.DumpblocksCmd,
+		ecrecover.EcrecoverCmd,
 		forge.ForgeCmd,
 		fork.ForkCmd,
+		forkid.ForkIDCmd,
+		forknode.ForkNodeCmd,
+		genesis.GenesisCmd,
 		hash.HashCmd,
+		interfaces.InterfacesCmd,
+		lightverify.LightVerifyCmd,
 		loadtest.LoadtestCmd,
 		metricsToDash.MetricsToDashCmd,
+		milestones.MilestonesCmd,
 		mnemonic.MnemonicCmd,
 		monitor.MonitorCmd,
 		nodekey.NodekeyCmd,
 		p2p.P2pCmd,
 		parseethwallet.ParseETHWalletCmd,
+		profileblocks.ProfileBlocksCmd,
+		proof.ProofCmd,
+		rlp.RlpCmd,
 		rpc.RpcCmd,
+		rpccapabilities.RPCCapabilitiesCmd,
 		rpcfuzz.RPCFuzzCmd,
+		sign.SignCmd,
+		simulate.SimulateCmd,
+		storage.StorageCmd,
+		token.TokenCmd,
+		trie.TrieCmd,
+		tx.TxCmd,
+		verify.VerifyCmd,
+		verifyheaders.VerifyHeadersCmd,
+		verifyreceipts.VerifyReceiptsCmd,
 		version.VersionCmd,
 		wallet.WalletCmd,
+		wsstress.WSStressCmd,
 	)
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return util.ConfigError(err)
+	})
+	markConfigErrors(cmd)
+	registerCompletions(cmd)
 	return cmd
 }
 
+// markConfigErrors marks the errors of the argument validation and pre-run
+// hooks of the command and its subcommands as config errors, since that's
+// where the commands validate their flags and arguments. Errors that are
+// already classified, like unreachable endpoints, are kept as they are.
+func markConfigErrors(cmd *cobra.Command) {
+	mark := func(err error) error {
+		if util.ExitCode(err) == util.ExitFailure {
+			return util.ConfigError(err)
+		}
+		return err
+	}
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			return mark(args(cmd, a))
+		}
+	}
+	if preRunE := cmd.PreRunE; preRunE != nil {
+		cmd.PreRunE = func(cmd *cobra.Command, a []string) error {
+			return mark(preRunE(cmd, a))
+		}
+	}
+	for _, c := range cmd.Commands() {
+		markConfigErrors(c)
+	}
+}
+
+// applyNetwork sets the flags of the command that the network preset has a
+// value for, unless they were set explicitly. RPC URLs are only set for
+// commands that need one, since an RPC URL enables extra checks in others.
+func applyNetwork(cmd *cobra.Command, name string) error {
+	n, err := util.LookupNetwork(name)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{
+		"chain-id":   strconv.FormatUint(n.ChainID, 10),
+		"network-id": strconv.FormatUint(n.ChainID, 10),
+		"bootnodes":  strings.Join(n.Bootnodes, ","),
+		"rpc-url":    n.RPCURL,
+		// The fork ID of the ENRs of the static nodes of a crawl.
+		"static-fork-id": n.FormatForkID(),
+	}
+	if n.Genesis != (ethcommon.Hash{}) {
+		values["genesis-hash"] = n.Genesis.Hex()
+	}
+	if n.JaipurBlock > 0 {
+		values["jaipur-block"] = strconv.FormatUint(n.JaipurBlock, 10)
+	}
+	if n.BaseFeeChangeDenominator > 0 {
+		values["base-fee-change-denominator"] = strconv.FormatUint(n.BaseFeeChangeDenominator, 10)
+	}
+
+	for flag, value := range values {
+		f := cmd.Flags().Lookup(flag)
+		if f == nil || f.Changed || value == "" {
+			continue
+		}
+		if flag == "rpc-url" && f.DefValue == "" {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			return fmt.Errorf("unable to set --%s for network %s: %w", flag, n.Name, err)
+		}
+		log.Debug().Str("flag", flag).Str("value", value).Str("network", n.Name).Msg("Set flag from network preset")
+	}
+	return nil
+}
+
 // setLogLevel sets the log level based on the flags.
 func setLogLevel(verbosity int, pretty bool) {
 	if verbosity < 100 {
@@ -152,10 +334,12 @@ func setLogLevel(verbosity int, pretty bool) {
 		zerolog.SetGlobalLevel(zerolog.TraceLevel)
 	}
 
+	// Secrets resolved by the commands are redacted from the logs.
 	if pretty {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: util.RedactWriter(util.ProgressWriter(os.Stderr))})
 		log.Debug().Msg("Starting logger in console mode")
 	} else {
+		log.Logger = log.Output(util.RedactWriter(util.ProgressWriter(os.Stderr)))
 		log.Debug().Msg("Starting logger in JSON mode")
 	}
 }
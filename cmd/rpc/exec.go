@@ -0,0 +1,369 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	execParams struct {
+		RPCURL      string
+		Vars        []string
+		BatchSize   int
+		Concurrency int
+		Output      string
+	}
+
+	// execRequest is a line of the requests file.
+	execRequest struct {
+		Name   string            `json:"name,omitempty"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params,omitempty"`
+		Loop   *execLoop         `json:"loop,omitempty"`
+		Expect *execExpect       `json:"expect,omitempty"`
+	}
+
+	// execLoop repeats a request for every value of a variable, either the
+	// integers from From to To or the listed values.
+	execLoop struct {
+		Var    string   `json:"var"`
+		From   int64    `json:"from"`
+		To     int64    `json:"to"`
+		Values []string `json:"values"`
+	}
+
+	// execExpect are the assertions on the response of a request.
+	execExpect struct {
+		Error     bool            `json:"error,omitempty"`
+		ErrorCode *int            `json:"errorCode,omitempty"`
+		Result    json.RawMessage `json:"result,omitempty"`
+		Contains  string          `json:"contains,omitempty"`
+		NotNull   bool            `json:"notNull,omitempty"`
+	}
+
+	execError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// execResult is a line of the output.
+	execResult struct {
+		Line     int               `json:"line"`
+		Name     string            `json:"name,omitempty"`
+		Method   string            `json:"method"`
+		Params   []json.RawMessage `json:"params"`
+		Result   json.RawMessage   `json:"result,omitempty"`
+		Error    *execError        `json:"error,omitempty"`
+		Latency  string            `json:"latency"`
+		Passed   *bool             `json:"passed,omitempty"`
+		Failures []string          `json:"failures,omitempty"`
+
+		expect *execExpect
+	}
+)
+
+var inputExec execParams
+
+var execCmd = &cobra.Command{
+	Use:   "exec requests.jsonl",
+	Short: "Execute a file of JSON-RPC requests with batching, concurrency, and assertions on the responses.",
+	Args:  cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputExec.BatchSize < 1 {
+			return errors.New("batch size must be at least one")
+		}
+		if inputExec.Concurrency < 1 {
+			return errors.New("concurrency must be at least one")
+		}
+		for _, v := range inputExec.Vars {
+			if !strings.Contains(v, "=") {
+				return fmt.Errorf("the variable %s should be formatted as name=value", v)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		vars := make(map[string]interface{})
+		for _, v := range inputExec.Vars {
+			name, value, _ := strings.Cut(v, "=")
+			vars[name] = value
+		}
+		results, err := readExecRequests(args[0], vars)
+		if err != nil {
+			return err
+		}
+		log.Info().Int("requests", len(results)).Msg("Read requests")
+
+		rpc, err := util.DialRPC(ctx, inputExec.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, inputExec.Concurrency)
+		)
+		for start := 0; start < len(results); start += inputExec.BatchSize {
+			end := start + inputExec.BatchSize
+			if end > len(results) {
+				end = len(results)
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(batch []*execResult) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				execBatch(ctx, rpc, batch)
+			}(results[start:end])
+		}
+		wg.Wait()
+
+		out := os.Stdout
+		if inputExec.Output != "" {
+			if out, err = os.Create(inputExec.Output); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+
+		var errored, passed, failed int
+		enc := json.NewEncoder(out)
+		for _, r := range results {
+			if r.Error != nil {
+				errored++
+			}
+			if r.Passed != nil {
+				if *r.Passed {
+					passed++
+				} else {
+					failed++
+					log.Error().Int("line", r.Line).Str("name", r.Name).Str("method", r.Method).Strs("failures", r.Failures).Msg("Assertion failed")
+				}
+			}
+			if err = enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		log.Info().Int("requests", len(results)).Int("errors", errored).Int("passed", passed).Int("failed", failed).Msg("Done")
+
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d of %d assertions failed", failed, passed+failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	RpcCmd.AddCommand(execCmd)
+
+	flagSet := execCmd.Flags()
+	flagSet.StringVar(&inputExec.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringSliceVar(&inputExec.Vars, "var", nil, "A template variable formatted as name=value. Can be repeated")
+	flagSet.IntVar(&inputExec.BatchSize, "batch-size", 1, "The number of requests sent per batch. Requests aren't batched if it's one")
+	flagSet.IntVar(&inputExec.Concurrency, "concurrency", 1, "The number of requests or batches sent in parallel")
+	flagSet.StringVar(&inputExec.Output, "output", "", "The file to write the responses to as JSON lines. Otherwise they're written to stdout")
+}
+
+// execFuncs are the functions that can be used in the templates of requests.
+var execFuncs = template.FuncMap{
+	"hex": func(v interface{}) (string, error) {
+		n, ok := new(big.Int).SetString(fmt.Sprint(v), 0)
+		if !ok {
+			return "", fmt.Errorf("%v isn't a number", v)
+		}
+		return hexutil.EncodeBig(n), nil
+	},
+	"add": func(a, b int64) int64 { return a + b },
+	"sub": func(a, b int64) int64 { return a - b },
+	"mul": func(a, b int64) int64 { return a * b },
+}
+
+// readExecRequests reads the requests of the file and expands their loops and
+// templates. Empty lines and lines starting with # are skipped.
+func readExecRequests(file string, vars map[string]interface{}) ([]*execResult, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []*execResult
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// The templates are inside of JSON strings, so the line can be
+		// decoded to find its loop before it's rendered.
+		var req execRequest
+		if err = json.Unmarshal([]byte(text), &req); err != nil {
+			return nil, fmt.Errorf("unable to decode line %d: %w", line, err)
+		}
+		tmpl, err := template.New(fmt.Sprint(line)).Funcs(execFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the template of line %d: %w", line, err)
+		}
+
+		for _, value := range req.Loop.values() {
+			data := make(map[string]interface{}, len(vars)+1)
+			for k, v := range vars {
+				data[k] = v
+			}
+			if req.Loop != nil {
+				data[req.Loop.Var] = value
+			}
+
+			var buf bytes.Buffer
+			if err = tmpl.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("unable to render line %d: %w", line, err)
+			}
+			var rendered execRequest
+			if err = json.Unmarshal(buf.Bytes(), &rendered); err != nil {
+				return nil, fmt.Errorf("line %d isn't valid JSON once rendered: %w", line, err)
+			}
+			if rendered.Method == "" {
+				return nil, fmt.Errorf("line %d has no method", line)
+			}
+			if rendered.Params == nil {
+				rendered.Params = []json.RawMessage{}
+			}
+			results = append(results, &execResult{
+				Line:   line,
+				Name:   rendered.Name,
+				Method: rendered.Method,
+				Params: rendered.Params,
+				expect: rendered.Expect,
+			})
+		}
+	}
+	return results, scanner.Err()
+}
+
+// values returns the values of the loop variable, or a single nil value for
+// requests without a loop.
+func (l *execLoop) values() []interface{} {
+	if l == nil {
+		return []interface{}{nil}
+	}
+	var values []interface{}
+	if len(l.Values) > 0 {
+		for _, v := range l.Values {
+			values = append(values, v)
+		}
+		return values
+	}
+	for i := l.From; i <= l.To; i++ {
+		values = append(values, i)
+	}
+	return values
+}
+
+// execBatch sends the requests, as a batch unless there's only one, and
+// checks the assertions on their responses.
+func execBatch(ctx context.Context, rpc *ethrpc.Client, batch []*execResult) {
+	elems := make([]ethrpc.BatchElem, len(batch))
+	for i, r := range batch {
+		args := make([]interface{}, len(r.Params))
+		for j, p := range r.Params {
+			args[j] = p
+		}
+		elems[i] = ethrpc.BatchElem{Method: r.Method, Args: args, Result: &batch[i].Result}
+	}
+
+	start := time.Now()
+	var err error
+	if len(elems) == 1 {
+		elems[0].Error = rpc.CallContext(ctx, elems[0].Result, elems[0].Method, elems[0].Args...)
+	} else {
+		err = rpc.BatchCallContext(ctx, elems)
+	}
+	latency := time.Since(start).String()
+
+	for i, r := range batch {
+		r.Latency = latency
+		// An error of the batch applies to every request of the batch.
+		reqErr := err
+		if reqErr == nil {
+			reqErr = elems[i].Error
+		}
+		if reqErr != nil {
+			r.Error = &execError{Code: -1, Message: reqErr.Error()}
+			var rpcErr ethrpc.Error
+			if errors.As(reqErr, &rpcErr) {
+				r.Error.Code = rpcErr.ErrorCode()
+			}
+			r.Result = nil
+		}
+		r.check()
+	}
+}
+
+// check sets whether the response passed the assertions of the request.
+func (r *execResult) check() {
+	e := r.expect
+	if e == nil {
+		return
+	}
+
+	var failures []string
+	switch {
+	case e.Error || e.ErrorCode != nil:
+		if r.Error == nil {
+			failures = append(failures, "expected an error")
+		} else if e.ErrorCode != nil && r.Error.Code != *e.ErrorCode {
+			failures = append(failures, fmt.Sprintf("expected error code %d, got %d", *e.ErrorCode, r.Error.Code))
+		}
+	case r.Error != nil:
+		failures = append(failures, fmt.Sprintf("unexpected error: %s", r.Error.Message))
+	default:
+		if e.NotNull && (len(r.Result) == 0 || string(r.Result) == "null") {
+			failures = append(failures, "expected a result")
+		}
+		if e.Contains != "" && !strings.Contains(string(r.Result), e.Contains) {
+			failures = append(failures, fmt.Sprintf("expected the result to contain %s", e.Contains))
+		}
+		if len(e.Result) > 0 && !jsonEqual(e.Result, r.Result) {
+			failures = append(failures, fmt.Sprintf("expected the result %s, got %s", string(e.Result), string(r.Result)))
+		}
+	}
+
+	passed := len(failures) == 0
+	r.Passed = &passed
+	r.Failures = failures
+}
+
+// jsonEqual compares the JSON values regardless of formatting and key order.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -24,9 +24,9 @@ import (
 
 	_ "embed"
 
-	ethrpc "github.com/ethereum/go-ethereum/rpc"
-
 	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
 )
 
 //go:embed usage.md
@@ -39,7 +39,7 @@ var RpcCmd = &cobra.Command{
 	Long:  usage,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		ec, err := ethrpc.DialContext(ctx, args[0])
+		ec, err := util.DialRPC(ctx, args[0])
 		if err != nil {
 			return err
 		}
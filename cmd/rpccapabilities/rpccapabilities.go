@@ -0,0 +1,319 @@
+package rpccapabilities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/cmd/version"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	statusSupported   = "supported"
+	statusUnsupported = "unsupported"
+	statusDenied      = "denied"
+	statusError       = "error"
+
+	// methodNotFound is the JSON-RPC error code for unknown methods.
+	methodNotFound = -32601
+
+	zeroAddress = "0x0000000000000000000000000000000000000000"
+	zeroHash    = "0x0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+type (
+	capabilitiesParams struct {
+		RPCURL     string
+		Namespaces []string
+		Samples    int
+		Timeout    time.Duration
+		JSON       bool
+	}
+
+	// probe is a cheap call of a method. The parameters are chosen so that an
+	// error other than method not found still proves the method exists.
+	probe struct {
+		Namespace string
+		Method    string
+		Params    []interface{}
+	}
+
+	methodResult struct {
+		Namespace string  `json:"namespace"`
+		Method    string  `json:"method"`
+		Status    string  `json:"status"`
+		Error     string  `json:"error,omitempty"`
+		MinMs     float64 `json:"minMs"`
+		AvgMs     float64 `json:"avgMs"`
+		MaxMs     float64 `json:"maxMs"`
+	}
+
+	namespaceResult struct {
+		Namespace string `json:"namespace"`
+		Supported int    `json:"supported"`
+		Total     int    `json:"total"`
+	}
+
+	capabilities struct {
+		PolycliVersion string            `json:"polycliVersion"`
+		ClientVersion  string            `json:"clientVersion,omitempty"`
+		Namespaces     []namespaceResult `json:"namespaces"`
+		Methods        []methodResult    `json:"methods"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage             string
+	inputCapabilities capabilitiesParams
+
+	probes = []probe{
+		{"eth", "eth_chainId", nil},
+		{"eth", "eth_blockNumber", nil},
+		{"eth", "eth_syncing", nil},
+		{"eth", "eth_gasPrice", nil},
+		{"eth", "eth_maxPriorityFeePerGas", nil},
+		{"eth", "eth_feeHistory", []interface{}{"0x1", "latest", []int{}}},
+		{"eth", "eth_getBlockByNumber", []interface{}{"latest", false}},
+		{"eth", "eth_getBlockReceipts", []interface{}{"latest"}},
+		{"eth", "eth_getBalance", []interface{}{zeroAddress, "latest"}},
+		{"eth", "eth_getCode", []interface{}{zeroAddress, "latest"}},
+		{"eth", "eth_getStorageAt", []interface{}{zeroAddress, "0x0", "latest"}},
+		{"eth", "eth_getTransactionCount", []interface{}{zeroAddress, "latest"}},
+		{"eth", "eth_getTransactionByHash", []interface{}{zeroHash}},
+		{"eth", "eth_getTransactionReceipt", []interface{}{zeroHash}},
+		{"eth", "eth_call", []interface{}{map[string]string{"to": zeroAddress}, "latest"}},
+		{"eth", "eth_estimateGas", []interface{}{map[string]string{"to": zeroAddress}}},
+		{"eth", "eth_createAccessList", []interface{}{map[string]string{"to": zeroAddress}, "latest"}},
+		{"eth", "eth_getLogs", []interface{}{map[string]string{"fromBlock": "latest", "toBlock": "latest"}}},
+		{"eth", "eth_getProof", []interface{}{zeroAddress, []string{}, "latest"}},
+		{"net", "net_version", nil},
+		{"net", "net_listening", nil},
+		{"net", "net_peerCount", nil},
+		{"web3", "web3_clientVersion", nil},
+		{"web3", "web3_sha3", []interface{}{"0x"}},
+		{"txpool", "txpool_status", nil},
+		{"txpool", "txpool_inspect", nil},
+		{"debug", "debug_getRawHeader", []interface{}{"latest"}},
+		{"debug", "debug_getRawReceipts", []interface{}{"latest"}},
+		{"debug", "debug_traceTransaction", []interface{}{zeroHash}},
+		{"debug", "debug_traceCall", []interface{}{map[string]string{"to": zeroAddress}, "latest"}},
+		{"trace", "trace_transaction", []interface{}{zeroHash}},
+		{"trace", "trace_block", []interface{}{"latest"}},
+		{"trace", "trace_call", []interface{}{map[string]string{"to": zeroAddress}, []string{"trace"}, "latest"}},
+		{"bor", "bor_getAuthor", []interface{}{"latest"}},
+		{"bor", "bor_getCurrentValidators", nil},
+		{"bor", "bor_getCurrentProposer", nil},
+		{"bor", "bor_getSnapshot", []interface{}{"latest"}},
+	}
+)
+
+// RPCCapabilitiesCmd probes which methods an RPC endpoint supports.
+var RPCCapabilitiesCmd = &cobra.Command{
+	Use:   "rpc-capabilities",
+	Short: "Probe which namespaces and methods an RPC endpoint supports.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputCapabilities.Samples < 1 {
+			return fmt.Errorf("--samples must be at least 1")
+		}
+		known := make(map[string]bool)
+		for _, p := range probes {
+			known[p.Namespace] = true
+		}
+		for _, ns := range inputCapabilities.Namespaces {
+			if !known[ns] {
+				return fmt.Errorf("unknown namespace %s", ns)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputCapabilities.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		namespaces := make(map[string]bool)
+		for _, ns := range inputCapabilities.Namespaces {
+			namespaces[ns] = true
+		}
+
+		caps := capabilities{PolycliVersion: version.Version}
+		summary := make(map[string]*namespaceResult)
+		var order []string
+		for _, p := range probes {
+			if len(namespaces) > 0 && !namespaces[p.Namespace] {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			r := probeMethod(ctx, rpc, p)
+			log.Debug().Interface("result", r).Msg("Probed method")
+			caps.Methods = append(caps.Methods, r)
+
+			s, ok := summary[p.Namespace]
+			if !ok {
+				s = &namespaceResult{Namespace: p.Namespace}
+				summary[p.Namespace] = s
+				order = append(order, p.Namespace)
+			}
+			s.Total++
+			// Methods that returned another error exist, they just didn't
+			// like the probe parameters.
+			if r.Status == statusSupported || r.Status == statusError {
+				s.Supported++
+			}
+		}
+		for _, ns := range order {
+			caps.Namespaces = append(caps.Namespaces, *summary[ns])
+		}
+
+		var clientVersion string
+		if err := rpc.CallContext(ctx, &clientVersion, "web3_clientVersion"); err == nil {
+			caps.ClientVersion = clientVersion
+		}
+
+		if inputCapabilities.JSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(caps)
+		}
+
+		printTables(caps)
+		return nil
+	},
+}
+
+func init() {
+	RPCCapabilitiesCmd.Flags().StringVar(&inputCapabilities.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	RPCCapabilitiesCmd.Flags().StringSliceVar(&inputCapabilities.Namespaces, "namespaces", nil, "Only probe these namespaces: eth, net, web3, txpool, debug, trace, bor (default all)")
+	RPCCapabilitiesCmd.Flags().IntVar(&inputCapabilities.Samples, "samples", 3, "The number of times each method is called to measure the latency")
+	RPCCapabilitiesCmd.Flags().DurationVar(&inputCapabilities.Timeout, "timeout", 10*time.Second, "The timeout of each call")
+	RPCCapabilitiesCmd.Flags().BoolVar(&inputCapabilities.JSON, "json", false, "Output the support matrix as JSON")
+}
+
+// probeMethod calls the method the configured number of times. The status is
+// taken from the first call, since calls failing with anything other than
+// method not found still mean the method exists.
+func probeMethod(ctx context.Context, rpc *ethrpc.Client, p probe) methodResult {
+	r := methodResult{Namespace: p.Namespace, Method: p.Method}
+
+	var total time.Duration
+	for i := 0; i < inputCapabilities.Samples; i++ {
+		callCtx, cancel := context.WithTimeout(ctx, inputCapabilities.Timeout)
+		start := time.Now()
+		var result json.RawMessage
+		err := rpc.CallContext(callCtx, &result, p.Method, p.Params...)
+		elapsed := time.Since(start)
+		cancel()
+
+		if i == 0 {
+			r.Status = classify(err)
+			if err != nil {
+				r.Error = err.Error()
+			}
+		}
+
+		ms := float64(elapsed.Microseconds()) / 1000
+		if i == 0 || ms < r.MinMs {
+			r.MinMs = ms
+		}
+		if ms > r.MaxMs {
+			r.MaxMs = ms
+		}
+		total += elapsed
+		r.AvgMs = float64(total.Microseconds()) / 1000 / float64(i+1)
+
+		if r.Status == statusUnsupported || r.Status == statusDenied {
+			break
+		}
+	}
+
+	return r
+}
+
+// classify maps the error of a call to a support status.
+func classify(err error) string {
+	if err == nil {
+		return statusSupported
+	}
+
+	var httpErr ethrpc.HTTPError
+	if errors.As(err, &httpErr) && (httpErr.StatusCode == 401 || httpErr.StatusCode == 403) {
+		return statusDenied
+	}
+
+	var rpcErr ethrpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFound {
+		return statusUnsupported
+	}
+
+	// Providers don't agree on the error code for disabled methods, so the
+	// message is checked as well.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"method not found", "does not exist", "not available", "not supported", "unsupported method"} {
+		if strings.Contains(msg, s) {
+			return statusUnsupported
+		}
+	}
+	for _, s := range []string{"not allowed", "not whitelisted", "forbidden", "unauthorized"} {
+		if strings.Contains(msg, s) {
+			return statusDenied
+		}
+	}
+
+	return statusError
+}
+
+func printTables(caps capabilities) {
+	fmt.Printf("Polygon CLI Version %s\n", caps.PolycliVersion)
+	if caps.ClientVersion != "" {
+		fmt.Printf("Client Version %s\n", caps.ClientVersion)
+	}
+
+	nt := table.NewWriter()
+	nt.SetOutputMirror(os.Stdout)
+	nt.AppendHeader(table.Row{"Namespace", "Supported", "Total"})
+	for _, ns := range caps.Namespaces {
+		nt.AppendRow(table.Row{ns.Namespace, ns.Supported, ns.Total})
+	}
+	nt.Render()
+
+	mt := table.NewWriter()
+	mt.SetOutputMirror(os.Stdout)
+	mt.AppendHeader(table.Row{"Method", "Status", "Min (ms)", "Avg (ms)", "Max (ms)", "Error"})
+	for _, m := range caps.Methods {
+		errMsg := m.Error
+		if len(errMsg) > 60 {
+			errMsg = errMsg[:57] + "..."
+		}
+		mt.AppendRow(table.Row{
+			m.Method,
+			m.Status,
+			fmt.Sprintf("%.1f", m.MinMs),
+			fmt.Sprintf("%.1f", m.AvgMs),
+			fmt.Sprintf("%.1f", m.MaxMs),
+			errMsg,
+		})
+	}
+	mt.Render()
+}
@@ -0,0 +1,612 @@
+package rpcfuzz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/maticnetwork/polygon-cli/cmd/rpcfuzz/testreporter"
+	"github.com/rs/zerolog/log"
+)
+
+// The gateway probes check the behaviors of whatever sits in front of the
+// client, like caching, batch limits, method allowlists, and rate limiting.
+// They send raw HTTP requests, since the status codes, headers, and request
+// IDs matter, and their results are reported separately from the client
+// conformance tests.
+
+type (
+	// gatewayRequest and gatewayMessage are the raw JSON-RPC messages, so the
+	// IDs and error objects are compared as they were sent.
+	gatewayRequest struct {
+		JSONRPC string        `json:"jsonrpc"`
+		ID      interface{}   `json:"id"`
+		Method  string        `json:"method"`
+		Params  []interface{} `json:"params"`
+	}
+	gatewayMessage struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *RPCJSONError   `json:"error,omitempty"`
+	}
+
+	// gatewayResponse is an HTTP response of the endpoint.
+	gatewayResponse struct {
+		Status int
+		Header http.Header
+		Body   []byte
+	}
+
+	gatewayProbe struct {
+		Name   string
+		Method string
+		Run    func(ctx context.Context, url string, tr *testreporter.TestResult)
+	}
+)
+
+const (
+	// gatewayTimeout limits each request of the gateway probes.
+	gatewayTimeout = 30 * time.Second
+	// gatewayBurstConcurrency is the number of requests in flight while
+	// probing the rate limits.
+	gatewayBurstConcurrency = 10
+)
+
+var (
+	gatewayResults testreporter.TestResults
+
+	gatewayClient = &http.Client{Timeout: gatewayTimeout}
+
+	// rateLimitHeaders are the headers gateways use to announce their limits.
+	rateLimitHeaders = []string{
+		"Retry-After",
+		"X-RateLimit-Limit",
+		"X-RateLimit-Remaining",
+		"X-RateLimit-Reset",
+		"RateLimit-Limit",
+		"RateLimit-Remaining",
+		"RateLimit-Reset",
+		"RateLimit-Policy",
+	}
+
+	gatewayProbes = []gatewayProbe{
+		{"GatewayCacheRequestID", "gateway_cache", probeRequestIDs},
+		{"GatewayCacheFullTransactions", "gateway_cache", probeFullTransactionsKey},
+		{"GatewayCacheBlockTags", "gateway_cache", probeBlockTags},
+		{"GatewayCacheLatestAdvances", "gateway_cache", probeLatestAdvances},
+		{"GatewayBatchSizeLimit", "gateway_batch", probeBatchLimit},
+		{"GatewayBatchEmpty", "gateway_batch", probeEmptyBatch},
+		{"GatewayBatchPartialFailure", "gateway_batch", probePartialBatch},
+		{"GatewayMethodAllowlist", "gateway_allowlist", probeAllowlist},
+		{"GatewayRateLimitHeaders", "gateway_ratelimit", probeRateLimit},
+	}
+)
+
+// runGatewayProbes runs the gateway probes against the HTTP endpoint and
+// collects the results in gatewayResults.
+func runGatewayProbes(ctx context.Context, url string) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		log.Warn().Str("url", url).Msg("The gateway probes only support HTTP endpoints")
+		return
+	}
+
+	for _, p := range gatewayProbes {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Trace().Str("name", p.Name).Msg("Running gateway probe")
+
+		tr := testreporter.New(p.Name, p.Method, 0)
+		p.Run(ctx, url, &tr)
+		tr.NumberOfTestsRan = tr.NumberOfTestsPassed + tr.NumberOfTestsFailed
+		if tr.NumberOfTestsRan == 0 {
+			log.Warn().Str("name", p.Name).Msg("Skipped gateway probe")
+			continue
+		}
+		gatewayResults.AddTestResult(tr)
+	}
+}
+
+func gatewayPost(ctx context.Context, url string, payload interface{}) (*gatewayResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gatewayClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayResponse{Status: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
+
+// gatewayCall sends a single request and decodes the response. Responses that
+// aren't JSON-RPC, like HTML error pages, are errors.
+func gatewayCall(ctx context.Context, url string, req gatewayRequest) (*gatewayMessage, *gatewayResponse, error) {
+	if req.JSONRPC == "" {
+		req.JSONRPC = "2.0"
+	}
+	if req.Params == nil {
+		req.Params = []interface{}{}
+	}
+	resp, err := gatewayPost(ctx, url, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var msg gatewayMessage
+	if err = json.Unmarshal(resp.Body, &msg); err != nil {
+		return nil, resp, fmt.Errorf("the HTTP %d response isn't JSON-RPC: %s", resp.Status, truncate(resp.Body))
+	}
+	return &msg, resp, nil
+}
+
+func truncate(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 120 {
+		return s[:117] + "..."
+	}
+	return s
+}
+
+// sameID returns whether the raw ID of the response is the ID that was sent.
+func sameID(sent interface{}, received json.RawMessage) bool {
+	want, err := json.Marshal(sent)
+	if err != nil {
+		return false
+	}
+	var got bytes.Buffer
+	if err = json.Compact(&got, received); err != nil {
+		return false
+	}
+	return bytes.Equal(want, got.Bytes())
+}
+
+// probeRequestIDs sends the same request with different IDs. Caches that
+// store whole responses return the ID of the request that filled the cache.
+func probeRequestIDs(ctx context.Context, url string, tr *testreporter.TestResult) {
+	ids := []interface{}{1, 2, "polycli", json.Number("9007199254740993"), 1}
+	for _, id := range ids {
+		req := gatewayRequest{ID: id, Method: "eth_blockNumber"}
+		msg, _, err := gatewayCall(ctx, url, req)
+		args := []interface{}{req}
+		switch {
+		case err != nil:
+			tr.Fail(args, nil, err)
+		case !sameID(id, msg.ID):
+			tr.Fail(args, msg, fmt.Errorf("sent the id %v but received %s", id, string(msg.ID)))
+		default:
+			tr.Pass(args, msg, nil)
+		}
+	}
+}
+
+// probeFullTransactionsKey requests the latest block with and without full
+// transactions. Caches that ignore the second parameter return the same body
+// for both.
+func probeFullTransactionsKey(ctx context.Context, url string, tr *testreporter.TestResult) {
+	number, err := gatewayBlockNumber(ctx, url)
+	if err != nil {
+		tr.Fail(nil, nil, err)
+		return
+	}
+	// The block is requested by number so that every request is for the same
+	// block, which is also the one most likely to be cached.
+	tag := hexutil.EncodeUint64(number)
+	for _, full := range []bool{true, false, true} {
+		req := gatewayRequest{ID: 1, Method: "eth_getBlockByNumber", Params: []interface{}{tag, full}}
+		msg, _, err := gatewayCall(ctx, url, req)
+		args := []interface{}{req}
+		if err != nil {
+			tr.Fail(args, nil, err)
+			continue
+		}
+		if msg.Error != nil {
+			tr.Fail(args, msg, msg.Error)
+			continue
+		}
+
+		var block struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		if err = json.Unmarshal(msg.Result, &block); err != nil {
+			tr.Fail(args, msg, err)
+			continue
+		}
+		if len(block.Transactions) > 0 {
+			isObject := bytes.HasPrefix(bytes.TrimSpace(block.Transactions[0]), []byte("{"))
+			if isObject != full {
+				tr.Fail(args, msg, fmt.Errorf("requested full transactions %t but received the other kind", full))
+				continue
+			}
+		}
+		tr.Pass(args, len(block.Transactions), nil)
+	}
+}
+
+// probeBlockTags checks that the block tags resolve consistently with block
+// numbers, which they don't if a gateway caches a tag for too long or routes
+// requests to nodes at different heights.
+func probeBlockTags(ctx context.Context, url string, tr *testreporter.TestResult) {
+	latest, err := gatewayBlock(ctx, url, "latest")
+	if err != nil {
+		tr.Fail([]interface{}{"latest"}, nil, err)
+		return
+	}
+	byNumber, err := gatewayBlock(ctx, url, hexutil.EncodeUint64(latest.number))
+	if err != nil {
+		tr.Fail([]interface{}{latest.number}, nil, err)
+	} else if byNumber.hash != latest.hash {
+		tr.Fail([]interface{}{latest.number}, byNumber.hash, fmt.Errorf("the latest block %d was %s but by number it's %s", latest.number, latest.hash, byNumber.hash))
+	} else {
+		tr.Pass([]interface{}{latest.number}, byNumber.hash, nil)
+	}
+
+	earliest, err := gatewayBlock(ctx, url, "earliest")
+	if err != nil {
+		tr.Fail([]interface{}{"earliest"}, nil, err)
+	} else if earliest.number != 0 {
+		tr.Fail([]interface{}{"earliest"}, earliest.number, fmt.Errorf("the earliest block is %d rather than 0", earliest.number))
+	} else {
+		tr.Pass([]interface{}{"earliest"}, earliest.number, nil)
+	}
+
+	// The finalized and safe tags aren't supported by every chain, but they
+	// should never be ahead of the latest block.
+	for _, tag := range []string{"safe", "finalized"} {
+		b, err := gatewayBlock(ctx, url, tag)
+		if err != nil {
+			log.Debug().Err(err).Str("tag", tag).Msg("The block tag isn't supported")
+			continue
+		}
+		current, err := gatewayBlockNumber(ctx, url)
+		if err != nil {
+			tr.Fail([]interface{}{tag}, nil, err)
+			continue
+		}
+		if b.number > current {
+			tr.Fail([]interface{}{tag}, b.number, fmt.Errorf("the %s block %d is ahead of the latest block %d", tag, b.number, current))
+			continue
+		}
+		tr.Pass([]interface{}{tag}, b.number, nil)
+	}
+}
+
+// probeLatestAdvances waits for a new block and checks that the latest tag
+// follows. Chains that don't produce a block in time are skipped.
+func probeLatestAdvances(ctx context.Context, url string, tr *testreporter.TestResult) {
+	start, err := gatewayBlock(ctx, url, "latest")
+	if err != nil {
+		tr.Fail([]interface{}{"latest"}, nil, err)
+		return
+	}
+
+	deadline := time.Now().Add(*testGatewayWait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		number, err := gatewayBlockNumber(ctx, url)
+		if err != nil {
+			tr.Fail(nil, nil, err)
+			return
+		}
+		if number <= start.number {
+			continue
+		}
+
+		latest, err := gatewayBlock(ctx, url, "latest")
+		args := []interface{}{"latest", number}
+		if err != nil {
+			tr.Fail(args, nil, err)
+		} else if latest.number < number {
+			tr.Fail(args, latest.number, fmt.Errorf("eth_blockNumber is %d but the latest block is still %d", number, latest.number))
+		} else {
+			tr.Pass(args, latest.number, nil)
+		}
+		return
+	}
+	log.Warn().Dur("wait", *testGatewayWait).Msg("No new block was produced to check the latest tag against")
+}
+
+type gatewayBlockHeader struct {
+	number uint64
+	hash   string
+}
+
+func gatewayBlock(ctx context.Context, url, tag string) (*gatewayBlockHeader, error) {
+	msg, _, err := gatewayCall(ctx, url, gatewayRequest{ID: 1, Method: "eth_getBlockByNumber", Params: []interface{}{tag, false}})
+	if err != nil {
+		return nil, err
+	}
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	var block struct {
+		Number hexutil.Uint64 `json:"number"`
+		Hash   string         `json:"hash"`
+	}
+	if err = json.Unmarshal(msg.Result, &block); err != nil {
+		return nil, fmt.Errorf("unable to decode the %s block: %w", tag, err)
+	}
+	return &gatewayBlockHeader{number: uint64(block.Number), hash: block.Hash}, nil
+}
+
+func gatewayBlockNumber(ctx context.Context, url string) (uint64, error) {
+	msg, _, err := gatewayCall(ctx, url, gatewayRequest{ID: 1, Method: "eth_blockNumber"})
+	if err != nil {
+		return 0, err
+	}
+	if msg.Error != nil {
+		return 0, msg.Error
+	}
+	var number hexutil.Uint64
+	if err = json.Unmarshal(msg.Result, &number); err != nil {
+		return 0, err
+	}
+	return uint64(number), nil
+}
+
+// probeBatchLimit doubles the batch size until the gateway rejects the batch
+// or the maximum is reached. Rejecting a batch is fine as long as it's done
+// with a JSON-RPC error, and accepted batches must have every response.
+func probeBatchLimit(ctx context.Context, url string, tr *testreporter.TestResult) {
+	for size := 1; size <= *testGatewayMaxBatch; size *= 2 {
+		batch := make([]gatewayRequest, size)
+		for i := range batch {
+			batch[i] = gatewayRequest{JSONRPC: "2.0", ID: i, Method: "eth_chainId", Params: []interface{}{}}
+		}
+		args := []interface{}{fmt.Sprintf("batch of %d", size)}
+
+		resp, err := gatewayPost(ctx, url, batch)
+		if err != nil {
+			tr.Fail(args, nil, err)
+			return
+		}
+
+		if resp.Status == http.StatusTooManyRequests {
+			log.Warn().Int("size", size).Msg("The batch was rate limited before its size limit was found")
+			return
+		}
+
+		var msgs []gatewayMessage
+		if err = json.Unmarshal(resp.Body, &msgs); err != nil {
+			var msg gatewayMessage
+			if json.Unmarshal(resp.Body, &msg) == nil && msg.Error != nil {
+				log.Info().Int("size", size).Str("error", msg.Error.Message).Msg("The batch size limit was reached")
+				tr.Pass(args, msg, nil)
+			} else {
+				tr.Fail(args, truncate(resp.Body), fmt.Errorf("the batch of %d was rejected with HTTP %d rather than a JSON-RPC error", size, resp.Status))
+			}
+			return
+		}
+
+		if err = checkBatchResponses(batch, msgs); err != nil {
+			tr.Fail(args, len(msgs), err)
+			return
+		}
+		tr.Pass(args, len(msgs), nil)
+	}
+}
+
+func checkBatchResponses(batch []gatewayRequest, msgs []gatewayMessage) error {
+	if len(msgs) != len(batch) {
+		return fmt.Errorf("sent %d requests but received %d responses", len(batch), len(msgs))
+	}
+	seen := make(map[string]bool, len(msgs))
+	for _, m := range msgs {
+		seen[string(m.ID)] = true
+	}
+	for _, req := range batch {
+		id, _ := json.Marshal(req.ID)
+		if !seen[string(id)] {
+			return fmt.Errorf("there's no response for the id %s", string(id))
+		}
+	}
+	return nil
+}
+
+// probeEmptyBatch sends an empty batch, which should be answered with a single
+// invalid request error.
+func probeEmptyBatch(ctx context.Context, url string, tr *testreporter.TestResult) {
+	args := []interface{}{"[]"}
+	resp, err := gatewayPost(ctx, url, []interface{}{})
+	if err != nil {
+		tr.Fail(args, nil, err)
+		return
+	}
+	var msg gatewayMessage
+	if err = json.Unmarshal(resp.Body, &msg); err != nil || msg.Error == nil {
+		tr.Fail(args, truncate(resp.Body), fmt.Errorf("expected an invalid request error but received HTTP %d", resp.Status))
+		return
+	}
+	if msg.Error.Code != -32600 {
+		tr.Fail(args, msg, fmt.Errorf("expected the error code -32600 but received %d", msg.Error.Code))
+		return
+	}
+	tr.Pass(args, msg, nil)
+}
+
+// probePartialBatch sends a batch with an unknown method. Only that request
+// should fail rather than the whole batch.
+func probePartialBatch(ctx context.Context, url string, tr *testreporter.TestResult) {
+	batch := []gatewayRequest{
+		{JSONRPC: "2.0", ID: 1, Method: "eth_chainId", Params: []interface{}{}},
+		{JSONRPC: "2.0", ID: 2, Method: "polycli_doesNotExist", Params: []interface{}{}},
+		{JSONRPC: "2.0", ID: 3, Method: "eth_blockNumber", Params: []interface{}{}},
+	}
+	args := []interface{}{batch}
+	resp, err := gatewayPost(ctx, url, batch)
+	if err != nil {
+		tr.Fail(args, nil, err)
+		return
+	}
+
+	var msgs []gatewayMessage
+	if err = json.Unmarshal(resp.Body, &msgs); err != nil {
+		tr.Fail(args, truncate(resp.Body), fmt.Errorf("the batch failed as a whole with HTTP %d", resp.Status))
+		return
+	}
+	if err = checkBatchResponses(batch, msgs); err != nil {
+		tr.Fail(args, msgs, err)
+		return
+	}
+	for _, m := range msgs {
+		failed := m.Error != nil
+		if failed != (string(m.ID) == "2") {
+			tr.Fail(args, msgs, fmt.Errorf("the response of the id %s has the wrong outcome", string(m.ID)))
+			return
+		}
+	}
+	tr.Pass(args, msgs, nil)
+}
+
+// probeAllowlist calls methods gateways usually block. Blocking them is fine,
+// but it should be done with a JSON-RPC error rather than an HTTP error, and
+// the admin and personal namespaces should never be exposed.
+func probeAllowlist(ctx context.Context, url string, tr *testreporter.TestResult) {
+	methods := []gatewayRequest{
+		{ID: 1, Method: "admin_nodeInfo"},
+		{ID: 1, Method: "admin_peers"},
+		{ID: 1, Method: "personal_listAccounts"},
+		{ID: 1, Method: "miner_setEtherbase", Params: []interface{}{"0x0000000000000000000000000000000000000000"}},
+		{ID: 1, Method: "debug_getRawHeader", Params: []interface{}{"latest"}},
+		{ID: 1, Method: "txpool_status"},
+		{ID: 1, Method: "eth_accounts"},
+	}
+	for _, req := range methods {
+		args := []interface{}{req.Method}
+		msg, resp, err := gatewayCall(ctx, url, req)
+		if err != nil {
+			tr.Fail(args, nil, err)
+			continue
+		}
+		if resp.Status != http.StatusOK {
+			tr.Fail(args, msg, fmt.Errorf("the method was answered with HTTP %d", resp.Status))
+			continue
+		}
+
+		outcome := "allowed"
+		if msg.Error != nil {
+			outcome = fmt.Sprintf("denied (%d %s)", msg.Error.Code, msg.Error.Message)
+		}
+		sensitive := strings.HasPrefix(req.Method, "admin_") || strings.HasPrefix(req.Method, "personal_") || strings.HasPrefix(req.Method, "miner_")
+		if msg.Error == nil && sensitive {
+			tr.Fail(args, outcome, errors.New("the method is exposed"))
+			continue
+		}
+		tr.Pass(args, outcome, nil)
+	}
+}
+
+// probeRateLimit sends a burst of requests. Rate limiting is fine as long as
+// the limited responses say when to retry.
+func probeRateLimit(ctx context.Context, url string, tr *testreporter.TestResult) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sem      = make(chan struct{}, gatewayBurstConcurrency)
+		statuses = make(map[int]int)
+		limited  []*gatewayResponse
+		headers  = make(map[string]string)
+		errs     int
+	)
+	for i := 0; i < *testGatewayBurst; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			msg, resp, err := gatewayCall(ctx, url, gatewayRequest{ID: i, Method: "eth_chainId"})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if resp == nil {
+				errs++
+				return
+			}
+			statuses[resp.Status]++
+			for _, h := range rateLimitHeaders {
+				if v := resp.Header.Get(h); v != "" {
+					headers[h] = v
+				}
+			}
+			// Some gateways limit with a JSON-RPC error instead of a 429.
+			if resp.Status == http.StatusTooManyRequests || (err == nil && msg.Error != nil && msg.Error.Code == -32005) {
+				limited = append(limited, resp)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	args := []interface{}{fmt.Sprintf("burst of %d", *testGatewayBurst)}
+	result := map[string]interface{}{"statuses": statuses, "limited": len(limited), "headers": headers}
+	log.Info().Interface("statuses", statuses).Int("limited", len(limited)).Interface("headers", headers).Msg("Probed the rate limits")
+
+	if errs > 0 {
+		tr.Fail(args, result, fmt.Errorf("%d requests failed without a response", errs))
+		return
+	}
+	for _, resp := range limited {
+		hasHeader := false
+		for _, h := range rateLimitHeaders {
+			if resp.Header.Get(h) != "" {
+				hasHeader = true
+				break
+			}
+		}
+		if !hasHeader {
+			tr.Fail(args, result, errors.New("rate limited responses don't have a Retry-After or rate limit header"))
+			return
+		}
+	}
+	tr.Pass(args, result, nil)
+}
+
+// exportGatewayResults prints and exports the gateway results next to the
+// client results, so deviations of the gateway aren't mixed with the client's.
+func exportGatewayResults() {
+	if len(gatewayResults.Tests) == 0 {
+		return
+	}
+	gatewayResults.GenerateTabularResult()
+	gatewayResults.TableWriter.SetTitle("Gateway")
+	if *testExportJson {
+		gatewayResults.ExportResultToJSON(filepath.Join(*testOutputExportPath, "output-gateway.json"))
+	}
+	if *testExportCSV {
+		gatewayResults.ExportResultToCSV(filepath.Join(*testOutputExportPath, "output-gateway.csv"))
+	}
+	if *testExportMarkdown {
+		gatewayResults.ExportResultToMarkdown(filepath.Join(*testOutputExportPath, "output-gateway.md"))
+	}
+	if *testExportHTML {
+		gatewayResults.ExportResultToHTML(filepath.Join(*testOutputExportPath, "output-gateway.html"))
+	}
+	gatewayResults.PrintTabularResult()
+}
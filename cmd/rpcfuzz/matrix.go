@@ -0,0 +1,300 @@
+package rpcfuzz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/maticnetwork/polygon-cli/cmd/rpcfuzz/testreporter"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+)
+
+// The compatibility matrix runs the conformance tests against several clients,
+// like a Geth, a Reth, an Erigon, and a Nethermind node of the same network,
+// and compares the outcome of every test across them. A client diverges on a
+// test when its outcome isn't the one of the majority of the clients, which
+// points at the client rather than at the test.
+
+type (
+	matrixClient struct {
+		Label string `json:"label"`
+		URL   string `json:"url"`
+	}
+
+	// matrixTest is the outcome of a test on every client.
+	matrixTest struct {
+		Name     string            `json:"name"`
+		Method   string            `json:"method"`
+		Outcomes map[string]string `json:"outcomes"`
+		// Majority is empty when no outcome has the majority.
+		Majority string `json:"majority"`
+	}
+
+	// matrixCell sums up the tests of a method on a client.
+	matrixCell struct {
+		Passed         int      `json:"passed"`
+		Ran            int      `json:"ran"`
+		Unsupported    bool     `json:"unsupported"`
+		DivergentTests []string `json:"divergentTests,omitempty"`
+	}
+
+	matrixRow struct {
+		Method  string                 `json:"method"`
+		Clients map[string]*matrixCell `json:"clients"`
+	}
+
+	matrixReport struct {
+		Clients []matrixClient `json:"clients"`
+		Methods []matrixRow    `json:"methods"`
+		Tests   []matrixTest   `json:"tests"`
+	}
+)
+
+const (
+	outcomePass        = "pass"
+	outcomeFail        = "fail"
+	outcomeUnsupported = "unsupported"
+
+	// methodNotFoundCode is the JSON-RPC error of a method the client doesn't
+	// have.
+	methodNotFoundCode = -32601
+)
+
+var matrixClients []matrixClient
+
+// parseMatrixClients parses the label=url clients of --matrix.
+func parseMatrixClients(values []string) ([]matrixClient, error) {
+	clients := make([]matrixClient, 0, len(values))
+	labels := make(map[string]bool)
+	for _, v := range values {
+		label, url, ok := strings.Cut(v, "=")
+		if !ok || label == "" || url == "" {
+			return nil, fmt.Errorf("the client %s isn't in the label=url format", v)
+		}
+		if labels[label] {
+			return nil, fmt.Errorf("the label %s is used by more than one client", label)
+		}
+		labels[label] = true
+		clients = append(clients, matrixClient{Label: label, URL: url})
+	}
+	if len(clients) < 2 {
+		return nil, errors.New("the compatibility matrix needs at least 2 clients")
+	}
+	return clients, nil
+}
+
+// runMatrix runs the tests against the clients one after the other, and
+// reports how each of them compares to the others.
+func runMatrix(ctx context.Context, clients []matrixClient) error {
+	if *testFuzz || *testGateway {
+		log.Warn().Msg("The compatibility matrix doesn't fuzz or probe gateways, so --fuzz and --gateway are ignored")
+	}
+
+	tests := make(map[string]*matrixTest)
+	var names []string
+	for _, c := range clients {
+		log.Info().Str("client", c.Label).Str("url", c.URL).Msg("Running the tests")
+		results, err := runClientTests(ctx, c)
+		if err != nil {
+			return fmt.Errorf("unable to test the client %s: %w", c.Label, err)
+		}
+		for _, r := range results {
+			t, ok := tests[r.Name]
+			if !ok {
+				t = &matrixTest{Name: r.Name, Method: r.Method, Outcomes: make(map[string]string)}
+				tests[r.Name] = t
+				names = append(names, r.Name)
+			}
+			t.Outcomes[c.Label] = testOutcome(r)
+		}
+	}
+
+	report := matrixReport{Clients: clients}
+	for _, name := range names {
+		t := tests[name]
+		t.Majority = majorityOutcome(t.Outcomes, len(clients))
+		report.Tests = append(report.Tests, *t)
+	}
+	report.Methods = matrixRows(clients, report.Tests)
+
+	tw := matrixTable(clients, report.Methods)
+	if *testExportJson {
+		exportMatrixJSON(filepath.Join(*testOutputExportPath, "output-matrix.json"), report)
+	}
+	if *testExportCSV {
+		writeMatrixFile(filepath.Join(*testOutputExportPath, "output-matrix.csv"), tw.RenderCSV())
+	}
+	if *testExportMarkdown {
+		writeMatrixFile(filepath.Join(*testOutputExportPath, "output-matrix.md"), tw.RenderMarkdown())
+	}
+	if *testExportHTML {
+		writeMatrixFile(filepath.Join(*testOutputExportPath, "output-matrix.html"), tw.RenderHTML())
+	}
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetStyle(table.StyleColoredBright)
+	tw.Render()
+	return nil
+}
+
+// runClientTests runs the tests that aren't fuzzed against the client.
+func runClientTests(ctx context.Context, c matrixClient) ([]testreporter.TestResult, error) {
+	rpcClient, err := util.DialRPC(ctx, c.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer rpcClient.Close()
+
+	restore, err := setupClient(ctx, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	var results []testreporter.TestResult
+	for _, t := range allTests {
+		if !shouldRunTest(t) {
+			continue
+		}
+		log.Trace().Str("client", c.Label).Str("name", t.GetName()).Str("method", t.GetMethod()).Msg("Running Test")
+		results = append(results, CallRPCAndValidate(ctx, rpcClient, t))
+	}
+	return results, nil
+}
+
+// testOutcome tells a test that passed, one of a method the client doesn't
+// have, and one that failed apart, since an unsupported method is a gap rather
+// than a bug.
+func testOutcome(r testreporter.TestResult) string {
+	if r.NumberOfTestsFailed == 0 {
+		return outcomePass
+	}
+	for _, err := range r.Errors {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+			return outcomeUnsupported
+		}
+	}
+	return outcomeFail
+}
+
+// majorityOutcome returns the outcome of more than half of the clients, if
+// any.
+func majorityOutcome(outcomes map[string]string, clients int) string {
+	counts := make(map[string]int)
+	for _, o := range outcomes {
+		counts[o]++
+	}
+	for o, n := range counts {
+		if 2*n > clients {
+			return o
+		}
+	}
+	return ""
+}
+
+// matrixRows sums up the tests by method, sorted by method.
+func matrixRows(clients []matrixClient, tests []matrixTest) []matrixRow {
+	rows := make(map[string]*matrixRow)
+	for _, t := range tests {
+		row, ok := rows[t.Method]
+		if !ok {
+			row = &matrixRow{Method: t.Method, Clients: make(map[string]*matrixCell)}
+			for _, c := range clients {
+				row.Clients[c.Label] = &matrixCell{Unsupported: true}
+			}
+			rows[t.Method] = row
+		}
+		for _, c := range clients {
+			outcome, ok := t.Outcomes[c.Label]
+			if !ok {
+				continue
+			}
+			cell := row.Clients[c.Label]
+			cell.Ran++
+			if outcome == outcomePass {
+				cell.Passed++
+			}
+			if outcome != outcomeUnsupported {
+				cell.Unsupported = false
+			}
+			if outcome != t.Majority {
+				cell.DivergentTests = append(cell.DivergentTests, t.Name)
+			}
+		}
+	}
+
+	sorted := make([]matrixRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, *row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Method < sorted[j].Method
+	})
+	return sorted
+}
+
+// matrixTable renders the methods by clients, where a cell is the number of
+// tests the client passed, and is marked when the client diverges from the
+// majority on any of the tests of the method.
+func matrixTable(clients []matrixClient, rows []matrixRow) table.Writer {
+	tw := table.NewWriter()
+	tw.SetTitle("Compatibility Matrix")
+	header := table.Row{"Method"}
+	for _, c := range clients {
+		header = append(header, c.Label)
+	}
+	tw.AppendHeader(header)
+
+	divergent := make(map[string]int)
+	for _, row := range rows {
+		r := table.Row{row.Method}
+		for _, c := range clients {
+			cell := row.Clients[c.Label]
+			text := fmt.Sprintf("%d/%d", cell.Passed, cell.Ran)
+			if cell.Unsupported {
+				text = outcomeUnsupported
+			}
+			if len(cell.DivergentTests) > 0 {
+				text += " *"
+				divergent[c.Label]++
+			}
+			r = append(r, text)
+		}
+		tw.AppendRow(r)
+	}
+
+	footer := table.Row{"Divergent methods"}
+	for _, c := range clients {
+		footer = append(footer, divergent[c.Label])
+	}
+	tw.AppendFooter(footer)
+	tw.SetCaption("* diverges from the majority of the clients on at least one test of the method")
+	return tw
+}
+
+func exportMatrixJSON(filePath string, report matrixReport) {
+	jsonContent, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		log.Error().Err(err).Msg("Error while trying to marshal the compatibility matrix to json")
+		return
+	}
+	writeMatrixFile(filePath, string(jsonContent))
+}
+
+func writeMatrixFile(filePath string, content string) {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		log.Error().Err(err).Msg("Error while trying to create file directory")
+		return
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		log.Error().Err(err).Msg("Error while trying to write the compatibility matrix")
+	}
+}
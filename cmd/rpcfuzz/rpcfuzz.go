@@ -36,6 +36,7 @@ import (
 	"github.com/maticnetwork/polygon-cli/cmd/rpcfuzz/argfuzz"
 	"github.com/maticnetwork/polygon-cli/cmd/rpcfuzz/testreporter"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
+	"github.com/maticnetwork/polygon-cli/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/xeipuuv/gojsonschema"
@@ -152,6 +153,12 @@ var (
 	testExportCSV         *bool
 	testExportMarkdown    *bool
 	testExportHTML        *bool
+	testSnapshot          *bool
+	testGateway           *bool
+	testGatewayMaxBatch   *int
+	testGatewayBurst      *int
+	testGatewayWait       *time.Duration
+	testMatrix            *[]string
 	testAccountNonce      uint64
 	testAccountNonceMutex sync.Mutex
 	currentChainID        *big.Int
@@ -1689,11 +1696,16 @@ func CallRPCAndValidate(ctx context.Context, rpcClient *rpc.Client, currTest RPC
 	currTestResult := testreporter.New(currTest.GetName(), currTest.GetMethod(), 1)
 	args := currTest.GetArgs()
 
-	var result interface{}
-	err := rpcClient.CallContext(ctx, &result, currTest.GetMethod(), args...)
+	var (
+		result interface{}
+		err    error
+	)
+	withSnapshot(ctx, rpcClient, currTest, func() {
+		err = rpcClient.CallContext(ctx, &result, currTest.GetMethod(), args...)
+	})
 
 	if err != nil && !currTest.ExpectError() {
-		currTestResult.Fail(args, result, errors.New("Method test failed: "+err.Error()))
+		currTestResult.Fail(args, result, fmt.Errorf("Method test failed: %w", err))
 		return currTestResult
 	}
 	if err == nil && currTest.ExpectError() {
@@ -1725,8 +1737,13 @@ func CallRPCWithFuzzAndValidate(ctx context.Context, rpcClient *rpc.Client, curr
 		args := originalArgs
 		fuzzer.Fuzz(&args)
 
-		var result interface{}
-		err := rpcClient.CallContext(ctx, &result, currTest.GetMethod(), args...)
+		var (
+			result interface{}
+			err    error
+		)
+		withSnapshot(ctx, rpcClient, currTest, func() {
+			err = rpcClient.CallContext(ctx, &result, currTest.GetMethod(), args...)
+		})
 
 		if err != nil {
 			currTestResult.Fail(args, result, err)
@@ -1785,23 +1802,19 @@ var RPCFuzzCmd = &cobra.Command{
 			log.Warn().Msg("Setting --export-path must pair with a export type: --json, --csv, --md, or --html")
 		}
 
-		rpcClient, err := rpc.DialContext(ctx, args[0])
-		if err != nil {
-			return err
+		if len(matrixClients) > 0 {
+			return runMatrix(ctx, matrixClients)
 		}
-		nonce, err := GetTestAccountNonce(ctx, rpcClient)
+
+		rpcClient, err := util.DialRPC(ctx, args[0])
 		if err != nil {
 			return err
 		}
-		chainId, err := GetCurrentChainID(ctx, rpcClient)
+		restore, err := setupClient(ctx, rpcClient)
 		if err != nil {
 			return err
 		}
-		testAccountNonce = nonce
-		currentChainID = chainId
-
-		log.Trace().Uint64("nonce", nonce).Uint64("chainid", chainId.Uint64()).Msg("Doing test setup")
-		setupTests(ctx, rpcClient)
+		defer restore()
 
 		for _, t := range allTests {
 			if !shouldRunTest(t) {
@@ -1813,7 +1826,12 @@ var RPCFuzzCmd = &cobra.Command{
 			currTestResult := CallRPCAndValidate(ctx, rpcClient, t)
 			testResults.AddTestResult(currTestResult)
 
-			if *testFuzz {
+			if *testFuzz && *testSnapshot && isDestructive(t) {
+				// The fuzzed cases of destructive tests are run one at a time
+				// so that reverting one doesn't interfere with another.
+				log.Info().Str("method", t.GetMethod()).Msg("Running with fuzzed args and snapshots")
+				testResults.AddTestResult(CallRPCWithFuzzAndValidate(ctx, rpcClient, t))
+			} else if *testFuzz {
 				fuzzedTestsGroup.Add(1)
 
 				log.Info().Str("method", t.GetMethod()).Msg("Running with fuzzed args")
@@ -1851,14 +1869,28 @@ var RPCFuzzCmd = &cobra.Command{
 		}
 		testResults.PrintTabularResult()
 
+		if *testGateway {
+			runGatewayProbes(ctx, args[0])
+			exportGatewayResults()
+		}
+
 		return nil
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 1 {
+		if len(*testMatrix) > 0 {
+			if len(args) != 0 {
+				return fmt.Errorf("Expected no arguments with --matrix, but got %d", len(args))
+			}
+			clients, err := parseMatrixClients(*testMatrix)
+			if err != nil {
+				return err
+			}
+			matrixClients = clients
+		} else if len(args) != 1 {
 			return fmt.Errorf("Expected 1 argument, but got %d", len(args))
 		}
 
-		privateKey, err := ethcrypto.HexToECDSA(*testPrivateHexKey)
+		privateKey, err := util.ResolvePrivateKey(*testPrivateHexKey)
 		if err != nil {
 			log.Error().Err(err).Msg("Couldn't process the hex private key")
 			return err
@@ -1885,6 +1917,42 @@ var RPCFuzzCmd = &cobra.Command{
 	},
 }
 
+// setupClient reads the nonce of the test account and the chain ID of the
+// client, and sets up the tests against it. The returned function reverts
+// the node to its initial state when snapshots are enabled.
+func setupClient(ctx context.Context, rpcClient *rpc.Client) (func(), error) {
+	nonce, err := GetTestAccountNonce(ctx, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+	chainId, err := GetCurrentChainID(ctx, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+	testAccountNonce = nonce
+	currentChainID = chainId
+
+	restore := func() {}
+	if *testSnapshot {
+		// The initial snapshot both checks that the node supports
+		// snapshots and restores the node once all the tests are done.
+		initialSnapshot, err := takeSnapshot(ctx, rpcClient)
+		if err != nil {
+			return nil, fmt.Errorf("unable to snapshot the node, which needs to support evm_snapshot and evm_revert: %w", err)
+		}
+		restore = func() {
+			if err := revertSnapshot(ctx, rpcClient, initialSnapshot); err != nil {
+				log.Error().Err(err).Msg("Unable to revert the node to its initial state")
+			}
+		}
+	}
+
+	log.Trace().Uint64("nonce", nonce).Uint64("chainid", chainId.Uint64()).Msg("Doing test setup")
+	allTests = make([]RPCTest, 0)
+	setupTests(ctx, rpcClient)
+	return restore, nil
+}
+
 func shouldRunTest(t RPCTest) bool {
 	for _, ns := range enabledNamespaces {
 		if strings.HasPrefix(t.GetMethod(), ns) {
@@ -1897,7 +1965,7 @@ func shouldRunTest(t RPCTest) bool {
 func init() {
 	flagSet := RPCFuzzCmd.PersistentFlags()
 
-	testPrivateHexKey = flagSet.String("private-key", codeQualityPrivateKey, "The hex encoded private key that we'll use to sending transactions")
+	testPrivateHexKey = flagSet.String("private-key", codeQualityPrivateKey, "The hex encoded private key that we'll use to sending transactions. "+util.SecretHelp)
 	testContractAddress = flagSet.String("contract-address", "0x6fda56c57b0acadb96ed5624ac500c0429d59429", "The address of a contract that can be used for testing")
 	testNamespaces = flagSet.String("namespaces", "eth,web3,net,debug", "Comma separated list of rpc namespaces to test")
 	testFuzz = flagSet.Bool("fuzz", false, "Flag to indicate whether to fuzz input or not.")
@@ -1908,6 +1976,12 @@ func init() {
 	testExportCSV = flagSet.Bool("csv", false, "Flag to indicate that output will be exported as a CSV.")
 	testExportMarkdown = flagSet.Bool("md", false, "Flag to indicate that output will be exported as a Markdown.")
 	testExportHTML = flagSet.Bool("html", false, "Flag to indicate that output will be exported as a HTML.")
+	testSnapshot = flagSet.Bool("snapshot", false, "Flag to indicate whether to revert the node with evm_snapshot and evm_revert after every test that sends transactions or mines, as supported by anvil and hardhat.")
+	testGateway = flagSet.Bool("gateway", false, "Flag to indicate whether to probe the caching, batching, allowlist, and rate limiting behaviors of an RPC gateway.")
+	testGatewayMaxBatch = flagSet.Int("gateway-max-batch", 1000, "The largest batch size sent when probing the batch size limit of the gateway.")
+	testGatewayBurst = flagSet.Int("gateway-burst", 50, "The number of requests sent at once when probing the rate limits of the gateway.")
+	testGatewayWait = flagSet.Duration("gateway-wait", 30*time.Second, "How long to wait for a new block when probing whether the gateway caches the latest block.")
+	testMatrix = flagSet.StringArray("matrix", nil, "A labeled client to run the tests against, like reth=http://localhost:8545. Repeat it to compare clients in a compatibility matrix instead of testing a single endpoint.")
 
 	argfuzz.SetSeed(seed)
 
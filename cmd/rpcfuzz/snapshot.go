@@ -0,0 +1,90 @@
+package rpcfuzz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// destructiveMethodPrefixes are the methods that change the state of the node
+// by sending transactions, mining, or manipulating the chain. With snapshots
+// enabled, the node is reverted after each of their tests and fuzzed cases.
+var destructiveMethodPrefixes = []string{
+	"eth_sendTransaction",
+	"eth_sendRawTransaction",
+	"eth_submitWork",
+	"eth_submitHashrate",
+	"personal_sendTransaction",
+	"miner_",
+	"evm_",
+	"anvil_",
+	"hardhat_",
+}
+
+func isDestructive(t RPCTest) bool {
+	for _, prefix := range destructiveMethodPrefixes {
+		if strings.HasPrefix(t.GetMethod(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeSnapshot snapshots the state of the node with evm_snapshot, which is
+// supported by anvil, hardhat, and ganache, and returns the snapshot ID.
+func takeSnapshot(ctx context.Context, rpcClient *rpc.Client) (string, error) {
+	var id string
+	if err := rpcClient.CallContext(ctx, &id, "evm_snapshot"); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// revertSnapshot reverts the node to the snapshot. Most nodes delete the
+// snapshot when reverting, so a snapshot can only be reverted to once.
+func revertSnapshot(ctx context.Context, rpcClient *rpc.Client, id string) error {
+	var reverted bool
+	if err := rpcClient.CallContext(ctx, &reverted, "evm_revert", id); err != nil {
+		return err
+	}
+	if !reverted {
+		return fmt.Errorf("the snapshot %s wasn't reverted", id)
+	}
+	return nil
+}
+
+// withSnapshot runs the call of a destructive test between a snapshot and a
+// revert of the node, so that every test and fuzzed case starts from the same
+// state. The nonce of the test account is restored along with the node.
+func withSnapshot(ctx context.Context, rpcClient *rpc.Client, currTest RPCTest, call func()) {
+	if !*testSnapshot || !isDestructive(currTest) {
+		call()
+		return
+	}
+
+	testAccountNonceMutex.Lock()
+	nonce := testAccountNonce
+	testAccountNonceMutex.Unlock()
+
+	id, err := takeSnapshot(ctx, rpcClient)
+	if err != nil {
+		log.Error().Err(err).Str("name", currTest.GetName()).Msg("Unable to snapshot the node, so the test won't be reverted")
+		call()
+		return
+	}
+
+	call()
+
+	if err = revertSnapshot(ctx, rpcClient, id); err != nil {
+		log.Error().Err(err).Str("name", currTest.GetName()).Str("snapshot", id).Msg("Unable to revert the node")
+		return
+	}
+
+	testAccountNonceMutex.Lock()
+	testAccountNonce = nonce
+	testAccountNonceMutex.Unlock()
+	log.Trace().Str("name", currTest.GetName()).Str("snapshot", id).Msg("Reverted the node")
+}
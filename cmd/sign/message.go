@@ -0,0 +1,66 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/signer"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type messageParams struct {
+	PrivateKey string
+	File       string
+	Hex        bool
+	Raw        bool
+}
+
+var inputMessage messageParams
+
+var messageCmd = &cobra.Command{
+	Use:   "message [message]",
+	Short: "Sign a message like personal_sign, or a raw 32 byte digest.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := signer.New(cmd.Context(), inputMessage.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("unable to load the signer: %w", err)
+		}
+
+		// Raw digests are always hex encoded.
+		message, err := util.ReadMessage(inputMessage.File, inputMessage.Hex || inputMessage.Raw, args)
+		if err != nil {
+			return err
+		}
+		digest, err := util.MessageDigest(message, inputMessage.Raw)
+		if err != nil {
+			return err
+		}
+
+		signature, err := s.SignHash(cmd.Context(), digest)
+		if err != nil {
+			return fmt.Errorf("unable to sign the message: %w", err)
+		}
+		// Use the 27 and 28 recovery ids that personal_sign returns.
+		signature[crypto.RecoveryIDOffset] += 27
+
+		log.Debug().
+			Str("address", s.Address().Hex()).
+			Str("digest", hexutil.Encode(digest)).
+			Msg("Signed message")
+		fmt.Println(hexutil.Encode(signature))
+		return nil
+	},
+}
+
+func init() {
+	flagSet := messageCmd.Flags()
+	flagSet.StringVar(&inputMessage.PrivateKey, "private-key", "", "The hex encoded private key to sign the message with. "+util.SecretHelp+". "+signer.Help)
+	flagSet.StringVar(&inputMessage.File, "file", "", "Read the message from a file rather than the arguments or stdin")
+	flagSet.BoolVar(&inputMessage.Hex, "hex", false, "Decode the message as hex before signing")
+	flagSet.BoolVar(&inputMessage.Raw, "raw", false, "Sign the hex encoded 32 byte digest as is, without the personal_sign prefix")
+	_ = messageCmd.MarkFlagRequired("private-key")
+}
@@ -0,0 +1,21 @@
+package sign
+
+import (
+	_ "embed"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// SignCmd represents the sign command
+var SignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign messages with a private key.",
+	Long:  usage,
+}
+
+func init() {
+	SignCmd.AddCommand(messageCmd)
+}
@@ -0,0 +1,408 @@
+package simulate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	methodAuto     = "auto"
+	methodCallMany = "callmany"
+	methodTrace    = "trace"
+)
+
+type (
+	simulateParams struct {
+		URL             string
+		InputFileName   string
+		Block           string
+		Method          string
+		ShouldStateDiff bool
+	}
+
+	// callArgs is the transaction object accepted by eth_call, eth_callMany
+	// and debug_traceCall.
+	callArgs struct {
+		From                 *ethcommon.Address `json:"from,omitempty"`
+		To                   *ethcommon.Address `json:"to,omitempty"`
+		Gas                  *hexutil.Uint64    `json:"gas,omitempty"`
+		GasPrice             *hexutil.Big       `json:"gasPrice,omitempty"`
+		MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas,omitempty"`
+		MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
+		Value                *hexutil.Big       `json:"value,omitempty"`
+		Nonce                *hexutil.Uint64    `json:"nonce,omitempty"`
+		Data                 *hexutil.Bytes     `json:"data,omitempty"`
+	}
+
+	// accountState is an account in the output of the prestate tracer.
+	accountState struct {
+		Balance *hexutil.Big                      `json:"balance,omitempty"`
+		Nonce   uint64                            `json:"nonce,omitempty"`
+		Code    hexutil.Bytes                     `json:"code,omitempty"`
+		Storage map[ethcommon.Hash]ethcommon.Hash `json:"storage,omitempty"`
+	}
+	stateMap map[ethcommon.Address]*accountState
+
+	// overrideAccount is an account of the state overrides of debug_traceCall.
+	// Unlike the prestate tracer output the quantities are hex encoded, and
+	// the storage slots in stateDiff are set on top of the existing storage,
+	// while state replaces the whole storage.
+	overrideAccount struct {
+		Nonce     *hexutil.Uint64                    `json:"nonce,omitempty"`
+		Balance   *hexutil.Big                       `json:"balance,omitempty"`
+		Code      *hexutil.Bytes                     `json:"code,omitempty"`
+		State     *map[ethcommon.Hash]ethcommon.Hash `json:"state,omitempty"`
+		StateDiff map[ethcommon.Hash]ethcommon.Hash  `json:"stateDiff,omitempty"`
+	}
+	overrideMap map[ethcommon.Address]*overrideAccount
+
+	stateDiff struct {
+		Pre  stateMap `json:"pre"`
+		Post stateMap `json:"post"`
+	}
+
+	callFrame struct {
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+		Output  hexutil.Bytes  `json:"output"`
+		Error   string         `json:"error,omitempty"`
+	}
+
+	callManyResult struct {
+		Value hexutil.Bytes `json:"value"`
+		Error interface{}   `json:"error,omitempty"`
+	}
+
+	// simulationResult is the output for a single transaction of the bundle.
+	simulationResult struct {
+		Index      int        `json:"index"`
+		From       string     `json:"from,omitempty"`
+		To         string     `json:"to,omitempty"`
+		GasUsed    *uint64    `json:"gasUsed,omitempty"`
+		ReturnData string     `json:"returnData"`
+		Error      string     `json:"error,omitempty"`
+		StateDiff  *stateDiff `json:"stateDiff,omitempty"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage               string
+	inputSimulateParams simulateParams
+
+	errCallManyNotSupported = errors.New("eth_callMany is not supported by this endpoint")
+)
+
+// SimulateCmd represents the simulate command
+var SimulateCmd = &cobra.Command{
+	Use:   "simulate url",
+	Short: "Simulate a bundle of transactions on top of a block.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputSimulateParams.URL = args[0]
+
+		if !slices.Contains([]string{methodAuto, methodCallMany, methodTrace}, inputSimulateParams.Method) {
+			return fmt.Errorf("method must be one of [%s, %s, %s]", methodAuto, methodCallMany, methodTrace)
+		}
+		if inputSimulateParams.ShouldStateDiff && inputSimulateParams.Method == methodCallMany {
+			return fmt.Errorf("state diffs are only available with the %s method", methodTrace)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputSimulateParams.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		raw, err := getInputData(&inputSimulateParams)
+		if err != nil {
+			return err
+		}
+
+		var chainID hexutil.Big
+		if err = rpc.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+			return fmt.Errorf("unable to fetch chain id: %w", err)
+		}
+
+		txs, err := parseBundle(raw, chainID.ToInt())
+		if err != nil {
+			return err
+		}
+		log.Info().Int("transactions", len(txs)).Str("block", inputSimulateParams.Block).Msg("Simulating bundle")
+
+		var results []simulationResult
+		switch inputSimulateParams.Method {
+		case methodCallMany:
+			results, err = simulateCallMany(ctx, rpc, txs, &inputSimulateParams)
+		case methodTrace:
+			results, err = simulateTrace(ctx, rpc, txs, &inputSimulateParams)
+		default:
+			if inputSimulateParams.ShouldStateDiff {
+				results, err = simulateTrace(ctx, rpc, txs, &inputSimulateParams)
+				break
+			}
+			results, err = simulateCallMany(ctx, rpc, txs, &inputSimulateParams)
+			if errors.Is(err, errCallManyNotSupported) {
+				log.Info().Msg("Falling back to debug_traceCall")
+				results, err = simulateTrace(ctx, rpc, txs, &inputSimulateParams)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+func init() {
+	flagSet := SimulateCmd.PersistentFlags()
+	flagSet.StringVarP(&inputSimulateParams.InputFileName, "file", "f", "", "A JSON file containing the array of transactions to simulate (default stdin)")
+	flagSet.StringVarP(&inputSimulateParams.Block, "block", "b", "latest", "The block number, hash, or tag to simulate on top of")
+	flagSet.StringVarP(&inputSimulateParams.Method, "method", "m", methodAuto, "The simulation method to use [auto, callmany, trace]")
+	flagSet.BoolVar(&inputSimulateParams.ShouldStateDiff, "state-diff", false, "Include the state diff of each transaction in the output (requires the trace method)")
+}
+
+func getInputData(params *simulateParams) ([]byte, error) {
+	if params.InputFileName != "" {
+		return os.ReadFile(params.InputFileName)
+	}
+	return io.ReadAll(os.Stdin)
+}
+
+// parseBundle converts the input into call objects. Raw transactions are
+// decoded and their sender is recovered so they can be executed as calls.
+func parseBundle(raw []byte, chainID *big.Int) ([]callArgs, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("the input should be a JSON array: %w", err)
+	}
+
+	txs := make([]callArgs, 0, len(items))
+	for i, item := range items {
+		var rawTx string
+		if err := json.Unmarshal(item, &rawTx); err == nil {
+			args, err := rawTxToCallArgs(rawTx, chainID)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode raw transaction at index %d: %w", i, err)
+			}
+			txs = append(txs, *args)
+			continue
+		}
+
+		var args callArgs
+		if err := json.Unmarshal(item, &args); err != nil {
+			return nil, fmt.Errorf("unable to decode call object at index %d: %w", i, err)
+		}
+		txs = append(txs, args)
+	}
+	return txs, nil
+}
+
+func rawTxToCallArgs(rawTx string, chainID *big.Int) (*callArgs, error) {
+	data, err := hexutil.Decode(strings.TrimSpace(rawTx))
+	if err != nil {
+		return nil, err
+	}
+	tx := new(ethtypes.Transaction)
+	if err = tx.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	from, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recover sender: %w", err)
+	}
+
+	gas := hexutil.Uint64(tx.Gas())
+	nonce := hexutil.Uint64(tx.Nonce())
+	input := hexutil.Bytes(tx.Data())
+	args := &callArgs{
+		From:  &from,
+		To:    tx.To(),
+		Gas:   &gas,
+		Value: (*hexutil.Big)(tx.Value()),
+		Nonce: &nonce,
+		Data:  &input,
+	}
+	if tx.Type() == ethtypes.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+	return args, nil
+}
+
+// simulateCallMany executes the whole bundle in a single eth_callMany request.
+// The gas used isn't part of the eth_callMany response.
+func simulateCallMany(ctx context.Context, rpc *ethrpc.Client, txs []callArgs, params *simulateParams) ([]simulationResult, error) {
+	bundle := map[string]interface{}{"transactions": txs}
+	simulationContext := map[string]interface{}{"blockNumber": params.Block, "transactionIndex": -1}
+
+	var res [][]callManyResult
+	err := rpc.CallContext(ctx, &res, "eth_callMany", []interface{}{bundle}, simulationContext)
+	if err != nil {
+		var rpcErr ethrpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+			return nil, errCallManyNotSupported
+		}
+		return nil, fmt.Errorf("eth_callMany failed: %w", err)
+	}
+	if len(res) != 1 || len(res[0]) != len(txs) {
+		return nil, fmt.Errorf("unexpected eth_callMany response size")
+	}
+
+	results := make([]simulationResult, 0, len(txs))
+	for i, r := range res[0] {
+		result := newSimulationResult(i, txs[i])
+		result.ReturnData = r.Value.String()
+		if r.Error != nil {
+			result.Error = fmt.Sprint(r.Error)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// simulateTrace executes each transaction with debug_traceCall. In order for
+// the transactions to be applied in sequence, the post state of every
+// transaction is accumulated and passed as a state override to the next one.
+func simulateTrace(ctx context.Context, rpc *ethrpc.Client, txs []callArgs, params *simulateParams) ([]simulationResult, error) {
+	overrides := make(overrideMap)
+	results := make([]simulationResult, 0, len(txs))
+
+	for i, tx := range txs {
+		var frame callFrame
+		callConfig := map[string]interface{}{"tracer": "callTracer", "stateOverrides": overrides}
+		if err := rpc.CallContext(ctx, &frame, "debug_traceCall", tx, params.Block, callConfig); err != nil {
+			return nil, fmt.Errorf("debug_traceCall failed for transaction %d: %w", i, err)
+		}
+
+		var diff stateDiff
+		diffConfig := map[string]interface{}{
+			"tracer":         "prestateTracer",
+			"tracerConfig":   map[string]interface{}{"diffMode": true},
+			"stateOverrides": overrides,
+		}
+		if err := rpc.CallContext(ctx, &diff, "debug_traceCall", tx, params.Block, diffConfig); err != nil {
+			return nil, fmt.Errorf("unable to retrieve the state diff for transaction %d: %w", i, err)
+		}
+		mergeStateOverrides(overrides, diff)
+
+		result := newSimulationResult(i, tx)
+		gasUsed := uint64(frame.GasUsed)
+		result.GasUsed = &gasUsed
+		result.ReturnData = frame.Output.String()
+		result.Error = frame.Error
+		if params.ShouldStateDiff {
+			result.StateDiff = &diff
+		}
+		results = append(results, result)
+
+		log.Debug().Int("index", i).Uint64("gasUsed", gasUsed).Str("error", frame.Error).Msg("Simulated transaction")
+	}
+	return results, nil
+}
+
+// mergeStateOverrides applies the state diff of a transaction on top of the
+// existing overrides. The prestate tracer only includes the fields that
+// changed in the post state, so each field is merged individually. It also
+// leaves out the storage slots that were zeroed and the accounts that were
+// deleted, which are only found in the pre state.
+func mergeStateOverrides(overrides overrideMap, diff stateDiff) {
+	for addr, state := range diff.Post {
+		current, ok := overrides[addr]
+		if !ok {
+			current = new(overrideAccount)
+			overrides[addr] = current
+		}
+		if state.Balance != nil {
+			current.Balance = state.Balance
+		}
+		if state.Nonce != 0 {
+			nonce := hexutil.Uint64(state.Nonce)
+			current.Nonce = &nonce
+		}
+		if len(state.Code) > 0 {
+			code := state.Code
+			current.Code = &code
+		}
+		for k, v := range state.Storage {
+			current.setSlot(k, v)
+		}
+	}
+
+	for addr, state := range diff.Pre {
+		post, ok := diff.Post[addr]
+		if !ok {
+			overrides[addr] = &overrideAccount{
+				Nonce:   new(hexutil.Uint64),
+				Balance: new(hexutil.Big),
+				Code:    new(hexutil.Bytes),
+				State:   &map[ethcommon.Hash]ethcommon.Hash{},
+			}
+			continue
+		}
+		for k := range state.Storage {
+			if _, ok := post.Storage[k]; !ok {
+				overrides[addr].setSlot(k, ethcommon.Hash{})
+			}
+		}
+	}
+}
+
+// setSlot overrides a storage slot. Geth rejects an account with both state
+// and stateDiff, so the slot goes in the state once the storage is replaced.
+func (a *overrideAccount) setSlot(k, v ethcommon.Hash) {
+	if a.State != nil {
+		(*a.State)[k] = v
+		return
+	}
+	if a.StateDiff == nil {
+		a.StateDiff = make(map[ethcommon.Hash]ethcommon.Hash)
+	}
+	a.StateDiff[k] = v
+}
+
+func newSimulationResult(index int, tx callArgs) simulationResult {
+	result := simulationResult{Index: index}
+	if tx.From != nil {
+		result.From = tx.From.Hex()
+	}
+	if tx.To != nil {
+		result.To = tx.To.Hex()
+	}
+	return result
+}
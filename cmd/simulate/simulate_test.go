@@ -0,0 +1,213 @@
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testSender   = ethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	testContract = ethcommon.HexToAddress("0x2000000000000000000000000000000000000002")
+	testSlot     = ethcommon.Hash{}
+)
+
+// gethOverrideAccount has the fields and encoding of the account overrides
+// that geth accepts in debug_traceCall.
+type gethOverrideAccount struct {
+	Nonce     *hexutil.Uint64                   `json:"nonce"`
+	Code      *hexutil.Bytes                    `json:"code"`
+	Balance   *hexutil.Big                      `json:"balance"`
+	State     map[ethcommon.Hash]ethcommon.Hash `json:"state"`
+	StateDiff map[ethcommon.Hash]ethcommon.Hash `json:"stateDiff"`
+}
+
+type gethTraceConfig struct {
+	Tracer         string                                    `json:"tracer"`
+	TracerConfig   json.RawMessage                           `json:"tracerConfig"`
+	StateOverrides map[ethcommon.Address]gethOverrideAccount `json:"stateOverrides"`
+}
+
+const (
+	opRead     byte = 0x00
+	opStore    byte = 0x01
+	opClear    byte = 0x02
+	opDestruct byte = 0x03
+)
+
+// debugService simulates a contract whose behavior depends on the first byte
+// of the call data: it returns its slot, stores 1 in it, zeroes it, or
+// deletes the contract. The state diffs follow the diff mode of geth's
+// prestate tracer, which leaves zeroed slots and deleted accounts out of the
+// post state.
+type debugService struct {
+	slot      ethcommon.Hash
+	overrides []map[ethcommon.Address]gethOverrideAccount
+}
+
+func (s *debugService) TraceCall(ctx context.Context, args callArgs, block string, rawConfig json.RawMessage) (json.RawMessage, error) {
+	// Decode the config like geth does, but reject the fields it ignores so
+	// the test catches overrides that would be silently dropped.
+	dec := json.NewDecoder(bytes.NewReader(rawConfig))
+	dec.DisallowUnknownFields()
+	var config gethTraceConfig
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid trace config: %w", err)
+	}
+
+	slot := s.slot
+	if o, ok := config.StateOverrides[testContract]; ok {
+		if o.State != nil && o.StateDiff != nil {
+			return nil, fmt.Errorf("account %s has both 'state' and 'stateDiff'", testContract.Hex())
+		}
+		if o.State != nil {
+			slot = o.State[testSlot]
+		} else if v, ok := o.StateDiff[testSlot]; ok {
+			slot = v
+		}
+	}
+	op := opRead
+	if len(*args.Data) > 0 {
+		op = (*args.Data)[0]
+	}
+
+	if config.Tracer == "callTracer" {
+		s.overrides = append(s.overrides, config.StateOverrides)
+		output := hexutil.Bytes{}
+		if op == opRead {
+			output = slot.Bytes()
+		}
+		return json.Marshal(callFrame{GasUsed: 21000, Output: output})
+	}
+
+	nonce := uint64(*args.Nonce) + 1
+	diff := stateDiff{
+		Pre:  stateMap{testSender: {Nonce: uint64(*args.Nonce)}},
+		Post: stateMap{testSender: {Nonce: nonce}},
+	}
+	pre := &accountState{Storage: map[ethcommon.Hash]ethcommon.Hash{testSlot: slot}}
+	switch op {
+	case opStore:
+		diff.Pre[testContract] = pre
+		diff.Post[testContract] = &accountState{Storage: map[ethcommon.Hash]ethcommon.Hash{testSlot: ethcommon.BigToHash(ethcommon.Big1)}}
+	case opClear:
+		diff.Pre[testContract] = pre
+		diff.Post[testContract] = &accountState{}
+	case opDestruct:
+		pre.Balance = (*hexutil.Big)(ethcommon.Big1)
+		pre.Code = hexutil.Bytes{0x60, 0x00}
+		diff.Pre[testContract] = pre
+	}
+	return json.Marshal(diff)
+}
+
+func TestSimulateTraceDependentBundle(t *testing.T) {
+	one := ethcommon.BigToHash(ethcommon.Big1)
+	tests := []struct {
+		name string
+		slot ethcommon.Hash
+		ops  []byte
+		want ethcommon.Hash
+	}{
+		{
+			name: "store then read",
+			ops:  []byte{opStore, opRead},
+			want: one,
+		},
+		{
+			name: "store, clear then read",
+			ops:  []byte{opStore, opClear, opRead},
+		},
+		{
+			name: "clear an existing slot then read",
+			slot: one,
+			ops:  []byte{opClear, opRead},
+		},
+		{
+			name: "destruct then read",
+			slot: one,
+			ops:  []byte{opDestruct, opRead},
+		},
+		{
+			name: "destruct, store then read",
+			slot: one,
+			ops:  []byte{opDestruct, opStore, opRead},
+			want: one,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := ethrpc.NewServer()
+			service := &debugService{slot: tc.slot}
+			if err := server.RegisterName("debug", service); err != nil {
+				t.Fatal(err)
+			}
+			defer server.Stop()
+			client := ethrpc.DialInProc(server)
+			defer client.Close()
+
+			params := &simulateParams{Block: "latest"}
+			gas := hexutil.Uint64(100000)
+			txs := make([]callArgs, len(tc.ops))
+			for i, op := range tc.ops {
+				nonce := hexutil.Uint64(i)
+				data := hexutil.Bytes{op}
+				txs[i] = callArgs{From: &testSender, To: &testContract, Gas: &gas, Nonce: &nonce, Data: &data}
+			}
+
+			results, err := simulateTrace(context.Background(), client, txs, params)
+			if err != nil {
+				t.Fatalf("simulating the bundle failed: %v", err)
+			}
+			if len(results) != len(txs) {
+				t.Fatalf("expected %d results, got %d", len(txs), len(results))
+			}
+
+			// The last transaction only sees the earlier writes through the
+			// overrides.
+			if got := results[len(results)-1].ReturnData; got != tc.want.Hex() {
+				t.Errorf("the last transaction returned %s, want %s", got, tc.want.Hex())
+			}
+
+			if len(service.overrides) != len(txs) {
+				t.Fatalf("expected %d traced calls, got %d", len(txs), len(service.overrides))
+			}
+			if len(service.overrides[0]) != 0 {
+				t.Errorf("the first transaction shouldn't have overrides, got %v", service.overrides[0])
+			}
+			sender := service.overrides[len(txs)-1][testSender]
+			if sender.Nonce == nil || int(*sender.Nonce) != len(txs)-1 {
+				t.Errorf("expected the nonce of the sender to be overridden with %d, got %v", len(txs)-1, sender.Nonce)
+			}
+		})
+	}
+}
+
+func TestMergeStateOverridesDeletedAccount(t *testing.T) {
+	overrides := make(overrideMap)
+	mergeStateOverrides(overrides, stateDiff{
+		Pre: stateMap{testContract: {
+			Balance: (*hexutil.Big)(ethcommon.Big1),
+			Nonce:   1,
+			Code:    hexutil.Bytes{0x60, 0x00},
+			Storage: map[ethcommon.Hash]ethcommon.Hash{testSlot: ethcommon.BigToHash(ethcommon.Big1)},
+		}},
+		Post: stateMap{},
+	})
+
+	out, err := json.Marshal(overrides[testContract])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"nonce":"0x0","balance":"0x0","code":"0x","state":{}}`
+	if string(out) != want {
+		t.Errorf("expected the deleted account to be reset with %s, got %s", want, out)
+	}
+}
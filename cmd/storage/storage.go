@@ -0,0 +1,625 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	storageParams struct {
+		RPCURL      string
+		Address     string
+		Block       string
+		Layout      string
+		Slots       uint64
+		Keys        []string
+		MaxElements uint64
+		BatchSize   uint64
+		Concurrency uint64
+		JSON        bool
+	}
+
+	// storageLayout is the storage layout that solc outputs with
+	// --storage-layout, or the storageLayout of the standard JSON output.
+	storageLayout struct {
+		Storage []storageEntry         `json:"storage"`
+		Types   map[string]storageType `json:"types"`
+	}
+
+	storageEntry struct {
+		Label  string `json:"label"`
+		Offset uint64 `json:"offset"`
+		Slot   string `json:"slot"`
+		Type   string `json:"type"`
+	}
+
+	storageType struct {
+		Encoding      string         `json:"encoding"`
+		Label         string         `json:"label"`
+		NumberOfBytes string         `json:"numberOfBytes"`
+		Key           string         `json:"key,omitempty"`
+		Value         string         `json:"value,omitempty"`
+		Base          string         `json:"base,omitempty"`
+		Members       []storageEntry `json:"members,omitempty"`
+	}
+
+	// variable is a value at a location in storage. Its order is the path of
+	// indexes from the top level variable it's part of, so that the values
+	// are printed in the order of the layout however their slots are derived.
+	variable struct {
+		Label    string
+		Slot     *big.Int
+		Offset   uint64
+		Type     string
+		OmitZero bool
+		order    []int
+	}
+
+	storageValue struct {
+		Slot   ethcommon.Hash `json:"slot"`
+		Offset uint64         `json:"offset"`
+		Label  string         `json:"label"`
+		Type   string         `json:"type"`
+		Value  string         `json:"value"`
+		order  []int
+	}
+
+	// dumper reads the slots that the variables need and decodes them.
+	dumper struct {
+		rpc     *ethrpc.Client
+		address ethcommon.Address
+		block   string
+		types   map[string]storageType
+		keys    map[string][]string
+		words   map[ethcommon.Hash]ethcommon.Hash
+		values  []storageValue
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage        string
+	inputStorage storageParams
+
+	slotModulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+	// The EIP-1967 proxy slots are keccak256 of their name minus one.
+	proxySlots = []struct {
+		Label string
+		Name  string
+	}{
+		{"EIP-1967 implementation", "eip1967.proxy.implementation"},
+		{"EIP-1967 admin", "eip1967.proxy.admin"},
+		{"EIP-1967 beacon", "eip1967.proxy.beacon"},
+	}
+)
+
+// StorageCmd reads and decodes the storage of a contract.
+var StorageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Read and decode the storage slots of a contract with its Solidity storage layout.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !addressbook.Valid(inputStorage.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputStorage.Address)
+		}
+		if inputStorage.Layout == "" && inputStorage.Slots == 0 {
+			return fmt.Errorf("either a storage layout or a number of slots is required")
+		}
+		for _, k := range inputStorage.Keys {
+			if label, key, ok := strings.Cut(k, "="); !ok || label == "" || key == "" {
+				return fmt.Errorf("the key %s isn't in the label=key format", k)
+			}
+		}
+		if inputStorage.BatchSize == 0 || inputStorage.Concurrency == 0 {
+			return fmt.Errorf("the batch size and concurrency must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		layout := &storageLayout{Types: make(map[string]storageType)}
+		if inputStorage.Layout != "" {
+			var err error
+			if layout, err = readLayout(inputStorage.Layout); err != nil {
+				return err
+			}
+		}
+
+		rpc, err := util.DialRPC(ctx, inputStorage.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		address, err := addressbook.NewResolver(rpc).Resolve(ctx, inputStorage.Address)
+		if err != nil {
+			return err
+		}
+
+		block := inputStorage.Block
+		if block != "latest" {
+			n, ok := new(big.Int).SetString(block, 0)
+			if !ok {
+				return fmt.Errorf("the block %s isn't a number", block)
+			}
+			block = hexutil.EncodeBig(n)
+		}
+
+		d := &dumper{
+			rpc:     rpc,
+			address: address,
+			block:   block,
+			types:   layout.Types,
+			keys:    make(map[string][]string),
+			words:   make(map[ethcommon.Hash]ethcommon.Hash),
+		}
+		for _, k := range inputStorage.Keys {
+			label, key, _ := strings.Cut(k, "=")
+			d.keys[label] = append(d.keys[label], key)
+		}
+		if err = d.dump(ctx, rootVariables(layout, inputStorage.Slots)); err != nil {
+			return err
+		}
+
+		if inputStorage.JSON {
+			out, err := json.MarshalIndent(d.values, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		printValues(d.values)
+		return nil
+	},
+}
+
+func init() {
+	flagSet := StorageCmd.Flags()
+	flagSet.StringVar(&inputStorage.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputStorage.Address, "address", "", "The address of the contract to read the storage of. "+addressbook.Help)
+	flagSet.StringVar(&inputStorage.Block, "block", "latest", "The block number to read the storage at")
+	flagSet.StringVar(&inputStorage.Layout, "layout", "", "The Solidity storage layout JSON of the contract, as output by solc --storage-layout")
+	flagSet.Uint64Var(&inputStorage.Slots, "slots", 0, "The number of slots from slot 0 to read undecoded, in addition to the layout")
+	flagSet.StringArrayVar(&inputStorage.Keys, "key", nil, "A key of a mapping to read the value of, as label=key, like balances=0x85da99c8a7c2c95964c8efd687e95e632fc533d6")
+	flagSet.Uint64Var(&inputStorage.MaxElements, "max-elements", 16, "The maximum number of elements of an array, or slots of a string or bytes value, to read")
+	flagSet.Uint64Var(&inputStorage.BatchSize, "batch-size", 100, "The number of slots to read in a JSON-RPC batch")
+	flagSet.Uint64Var(&inputStorage.Concurrency, "concurrency", 4, "The number of batches to read in parallel")
+	flagSet.BoolVar(&inputStorage.JSON, "json", false, "Output the storage as JSON")
+	_ = StorageCmd.MarkFlagRequired("address")
+}
+
+// readLayout reads a storage layout, either on its own or as the
+// storageLayout field of a compiler output for a single contract.
+func readLayout(path string) (*storageLayout, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var wrapped struct {
+		StorageLayout *storageLayout `json:"storageLayout"`
+	}
+	layout := new(storageLayout)
+	if err = json.Unmarshal(b, &wrapped); err == nil && wrapped.StorageLayout != nil {
+		layout = wrapped.StorageLayout
+	} else if err = json.Unmarshal(b, layout); err != nil {
+		return nil, fmt.Errorf("unable to parse the storage layout: %w", err)
+	}
+	if layout.Types == nil {
+		layout.Types = make(map[string]storageType)
+	}
+	for _, e := range layout.Storage {
+		if _, ok := layout.Types[e.Type]; !ok {
+			return nil, fmt.Errorf("the type %s of %s isn't in the storage layout", e.Type, e.Label)
+		}
+	}
+	return layout, nil
+}
+
+// rootVariables returns the variables of the layout, the raw slots, and the
+// EIP-1967 proxy slots, which are only shown if they're set.
+func rootVariables(layout *storageLayout, slots uint64) []variable {
+	const rawType, proxyType = "t_bytes32", "t_address"
+	if _, ok := layout.Types[rawType]; !ok {
+		layout.Types[rawType] = storageType{Encoding: "inplace", Label: "bytes32", NumberOfBytes: "32"}
+	}
+	if _, ok := layout.Types[proxyType]; !ok {
+		layout.Types[proxyType] = storageType{Encoding: "inplace", Label: "address", NumberOfBytes: "20"}
+	}
+
+	var vars []variable
+	for _, e := range layout.Storage {
+		slot, _ := new(big.Int).SetString(e.Slot, 10)
+		if slot == nil {
+			slot = new(big.Int)
+		}
+		vars = append(vars, variable{Label: e.Label, Slot: slot, Offset: e.Offset, Type: e.Type, order: []int{len(vars)}})
+	}
+	for i := uint64(0); i < slots; i++ {
+		vars = append(vars, variable{Label: fmt.Sprintf("slot %d", i), Slot: new(big.Int).SetUint64(i), Type: rawType, order: []int{len(vars)}})
+	}
+	for _, p := range proxySlots {
+		slot := new(big.Int).SetBytes(crypto.Keccak256([]byte(p.Name)))
+		slot.Sub(slot, big.NewInt(1))
+		vars = append(vars, variable{Label: p.Label, Slot: slot, Type: proxyType, OmitZero: true, order: []int{len(vars)}})
+	}
+	return vars
+}
+
+// dump resolves the variables, reading the slots that they need in rounds,
+// since the slots of dynamic arrays and long strings depend on the values of
+// other slots.
+func (d *dumper) dump(ctx context.Context, queue []variable) error {
+	for len(queue) > 0 {
+		var blocked []variable
+		missing := make(map[ethcommon.Hash]struct{})
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			parts, need, err := d.resolve(v)
+			if err != nil {
+				return err
+			}
+			if len(need) > 0 {
+				blocked = append(blocked, v)
+				for _, h := range need {
+					missing[h] = struct{}{}
+				}
+				continue
+			}
+			queue = append(queue, parts...)
+		}
+		if len(blocked) == 0 {
+			break
+		}
+
+		slots := make([]ethcommon.Hash, 0, len(missing))
+		for h := range missing {
+			slots = append(slots, h)
+		}
+		if err := d.fetch(ctx, slots); err != nil {
+			return err
+		}
+		queue = blocked
+	}
+
+	sort.SliceStable(d.values, func(i, j int) bool {
+		a, b := d.values[i].order, d.values[j].order
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return nil
+}
+
+// fetch reads the slots with eth_getStorageAt in batches, running up to the
+// concurrency of batches in parallel.
+func (d *dumper) fetch(ctx context.Context, slots []ethcommon.Hash) error {
+	log.Debug().Int("slots", len(slots)).Msg("Reading storage slots")
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		pool     = make(chan bool, inputStorage.Concurrency)
+	)
+	for start := 0; start < len(slots); start += int(inputStorage.BatchSize) {
+		end := start + int(inputStorage.BatchSize)
+		if end > len(slots) {
+			end = len(slots)
+		}
+		batch := slots[start:end]
+
+		pool <- true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-pool }()
+
+			results := make([]hexutil.Bytes, len(batch))
+			elems := make([]ethrpc.BatchElem, len(batch))
+			for i, slot := range batch {
+				elems[i] = ethrpc.BatchElem{
+					Method: "eth_getStorageAt",
+					Args:   []interface{}{d.address, slot, d.block},
+					Result: &results[i],
+				}
+			}
+			err := d.rpc.BatchCallContext(ctx, elems)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for i, e := range elems {
+				if err == nil && e.Error != nil {
+					err = fmt.Errorf("unable to read slot %s: %w", batch[i].Hex(), e.Error)
+				}
+				if err == nil {
+					d.words[batch[i]] = ethcommon.BytesToHash(results[i])
+				}
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// resolve decodes the variable if the slots it needs have been read, and
+// returns the slots to read otherwise. Variables that are made of other
+// variables, like structs and arrays, are returned as their parts.
+func (d *dumper) resolve(v variable) (parts []variable, need []ethcommon.Hash, err error) {
+	t, ok := d.types[v.Type]
+	if !ok {
+		return nil, nil, fmt.Errorf("the type %s of %s isn't in the storage layout", v.Type, v.Label)
+	}
+	slot := slotHash(v.Slot)
+
+	switch {
+	case t.Encoding == "mapping":
+		keys := d.keys[v.Label]
+		if len(keys) == 0 {
+			d.add(v, t, "mapping, pass --key "+v.Label+"=<key> to read values")
+			return nil, nil, nil
+		}
+		keyType := d.types[t.Key]
+		for i, key := range keys {
+			encoded, err := encodeKey(keyType.Label, key)
+			if err != nil {
+				return nil, nil, fmt.Errorf("the key %s of %s isn't valid: %w", key, v.Label, err)
+			}
+			s := new(big.Int).SetBytes(crypto.Keccak256(encoded, slot.Bytes()))
+			parts = append(parts, v.part(fmt.Sprintf("%s[%s]", v.Label, key), s, 0, t.Value, i))
+		}
+		return parts, nil, nil
+
+	case len(t.Members) > 0:
+		for i, m := range t.Members {
+			s, _ := new(big.Int).SetString(m.Slot, 10)
+			if s == nil {
+				s = new(big.Int)
+			}
+			parts = append(parts, v.part(v.Label+"."+m.Label, s.Add(s, v.Slot), m.Offset, m.Type, i))
+		}
+		return parts, nil, nil
+
+	case t.Encoding == "dynamic_array":
+		word, ok := d.words[slot]
+		if !ok {
+			return nil, []ethcommon.Hash{slot}, nil
+		}
+		length := word.Big()
+		d.add(v, t, "length "+length.String())
+		base := new(big.Int).SetBytes(crypto.Keccak256(slot.Bytes()))
+		return d.elements(v, t, base, length), nil, nil
+
+	case t.Base != "":
+		// A static array, whose length is the last one in its type, like
+		// uint256[2][3] being 3 arrays of uint256[2].
+		n := t.Label[strings.LastIndex(t.Label, "[")+1 : len(t.Label)-1]
+		length, ok := new(big.Int).SetString(n, 10)
+		if !ok {
+			return nil, nil, fmt.Errorf("unable to get the length of %s", t.Label)
+		}
+		return d.elements(v, t, v.Slot, length), nil, nil
+
+	case t.Encoding == "bytes":
+		word, ok := d.words[slot]
+		if !ok {
+			return nil, []ethcommon.Hash{slot}, nil
+		}
+		value, need := d.decodeBytes(t, slot, word)
+		if len(need) > 0 {
+			return nil, need, nil
+		}
+		d.add(v, t, value)
+		return nil, nil, nil
+	}
+
+	word, ok := d.words[slot]
+	if !ok {
+		return nil, []ethcommon.Hash{slot}, nil
+	}
+	size, _ := strconv.ParseUint(t.NumberOfBytes, 10, 64)
+	if size == 0 || size > 32 || v.Offset+size > 32 {
+		return nil, nil, fmt.Errorf("the size %s of %s isn't valid", t.NumberOfBytes, v.Label)
+	}
+	b := word[32-v.Offset-size : 32-v.Offset]
+	if v.OmitZero && new(big.Int).SetBytes(b).Sign() == 0 {
+		return nil, nil, nil
+	}
+	d.add(v, t, decodeValue(t.Label, b))
+	return nil, nil, nil
+}
+
+// elements returns the elements of an array that starts at base, up to the
+// maximum number of elements. Elements of 16 bytes or less are packed into
+// slots, larger ones take up whole slots.
+func (d *dumper) elements(v variable, t storageType, base, length *big.Int) []variable {
+	n := length.Uint64()
+	if !length.IsUint64() || n > inputStorage.MaxElements {
+		n = inputStorage.MaxElements
+	}
+	size, _ := strconv.ParseUint(d.types[t.Base].NumberOfBytes, 10, 64)
+	if size == 0 {
+		size = 32
+	}
+
+	var parts []variable
+	for i := uint64(0); i < n; i++ {
+		s := new(big.Int)
+		var offset uint64
+		if size < 32 {
+			perSlot := 32 / size
+			s.SetUint64(i / perSlot)
+			offset = (i % perSlot) * size
+		} else {
+			s.SetUint64(i * ((size + 31) / 32))
+		}
+		parts = append(parts, v.part(fmt.Sprintf("%s[%d]", v.Label, i), s.Add(s, base), offset, t.Base, int(i)))
+	}
+	return parts
+}
+
+// decodeBytes decodes a string or bytes value. Values shorter than 32 bytes
+// are stored in the slot with their length times two, longer ones store
+// their length times two plus one and their data from the keccak256 of the
+// slot on.
+func (d *dumper) decodeBytes(t storageType, slot, word ethcommon.Hash) (string, []ethcommon.Hash) {
+	var data []byte
+	var length uint64
+	if word[31]&1 == 0 {
+		length = uint64(word[31] / 2)
+		data = word[:length]
+	} else {
+		l := word.Big()
+		l.Rsh(l, 1)
+		length = l.Uint64()
+		slots := (length + 31) / 32
+		if !l.IsUint64() || slots > inputStorage.MaxElements {
+			slots = inputStorage.MaxElements
+		}
+		base := new(big.Int).SetBytes(crypto.Keccak256(slot.Bytes()))
+		var need []ethcommon.Hash
+		for i := uint64(0); i < slots; i++ {
+			h := slotHash(new(big.Int).Add(base, new(big.Int).SetUint64(i)))
+			w, ok := d.words[h]
+			if !ok {
+				need = append(need, h)
+				continue
+			}
+			data = append(data, w.Bytes()...)
+		}
+		if len(need) > 0 {
+			return "", need
+		}
+		if uint64(len(data)) > length {
+			data = data[:length]
+		}
+	}
+
+	value := hexutil.Encode(data)
+	if t.Label == "string" {
+		value = strconv.Quote(string(data))
+	}
+	if uint64(len(data)) < length {
+		value = fmt.Sprintf("%s... (%d bytes)", value, length)
+	}
+	return value, nil
+}
+
+func (d *dumper) add(v variable, t storageType, value string) {
+	d.values = append(d.values, storageValue{
+		Slot:   slotHash(v.Slot),
+		Offset: v.Offset,
+		Label:  v.Label,
+		Type:   t.Label,
+		Value:  value,
+		order:  v.order,
+	})
+}
+
+func (v variable) part(label string, slot *big.Int, offset uint64, typ string, index int) variable {
+	order := append(append([]int{}, v.order...), index)
+	return variable{Label: label, Slot: slot, Offset: offset, Type: typ, order: order}
+}
+
+func slotHash(n *big.Int) ethcommon.Hash {
+	return ethcommon.BigToHash(new(big.Int).Mod(n, slotModulus))
+}
+
+// decodeValue formats the bytes of a value type by its Solidity type.
+func decodeValue(label string, b []byte) string {
+	switch {
+	case strings.HasPrefix(label, "address"), strings.HasPrefix(label, "contract "):
+		return ethcommon.BytesToAddress(b).Hex()
+	case label == "bool":
+		return strconv.FormatBool(b[len(b)-1] != 0)
+	case strings.HasPrefix(label, "uint"), strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(b).String()
+	case strings.HasPrefix(label, "int"):
+		n := new(big.Int).SetBytes(b)
+		if b[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+		}
+		return n.String()
+	}
+	return hexutil.Encode(b)
+}
+
+// encodeKey encodes a mapping key the way Solidity hashes it with the slot
+// of the mapping: value types are padded to 32 bytes, and strings and bytes
+// are hashed as they are.
+func encodeKey(label, key string) ([]byte, error) {
+	switch {
+	case label == "string":
+		return []byte(key), nil
+	case label == "bytes":
+		return hexutil.Decode(key)
+	case strings.HasPrefix(label, "address"), strings.HasPrefix(label, "contract "):
+		if !ethcommon.IsHexAddress(key) {
+			return nil, fmt.Errorf("not an address")
+		}
+		return ethcommon.LeftPadBytes(ethcommon.HexToAddress(key).Bytes(), 32), nil
+	case label == "bool":
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return ethcommon.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	case strings.HasPrefix(label, "bytes"):
+		b, err := hexutil.Decode(key)
+		if err != nil || len(b) > 32 {
+			return nil, fmt.Errorf("not a hex value of up to 32 bytes")
+		}
+		return ethcommon.RightPadBytes(b, 32), nil
+	}
+	n, ok := new(big.Int).SetString(key, 0)
+	if !ok {
+		return nil, fmt.Errorf("not a number")
+	}
+	if n.Sign() < 0 {
+		n.Add(n, slotModulus)
+	}
+	return ethcommon.BigToHash(n).Bytes(), nil
+}
+
+func printValues(values []storageValue) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle("Storage of " + inputStorage.Address)
+	t.AppendHeader(table.Row{"Slot", "Offset", "Label", "Type", "Value"})
+	for _, v := range values {
+		t.AppendRow(table.Row{v.Slot.Hex(), v.Offset, v.Label, v.Type, v.Value})
+	}
+	t.Render()
+}
@@ -0,0 +1,427 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// tokenABI has the ERC-20 metadata functions and the ERC-4626 vault functions
+// that are read.
+const tokenABI = `[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"asset","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"totalAssets","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"convertToShares","stateMutability":"view","inputs":[{"name":"assets","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"convertToAssets","stateMutability":"view","inputs":[{"name":"shares","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"previewDeposit","stateMutability":"view","inputs":[{"name":"assets","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"previewMint","stateMutability":"view","inputs":[{"name":"shares","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"previewWithdraw","stateMutability":"view","inputs":[{"name":"assets","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"previewRedeem","stateMutability":"view","inputs":[{"name":"shares","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"maxDeposit","stateMutability":"view","inputs":[{"name":"receiver","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"maxMint","stateMutability":"view","inputs":[{"name":"receiver","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"maxWithdraw","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"maxRedeem","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+type (
+	tokenParams struct {
+		RPCURL  string
+		Address string
+		Holder  string
+		Block   string
+		Amount  string
+		JSON    bool
+	}
+
+	tokenInfo struct {
+		Address     ethcommon.Address  `json:"address"`
+		Name        string             `json:"name,omitempty"`
+		Symbol      string             `json:"symbol,omitempty"`
+		Decimals    *uint8             `json:"decimals,omitempty"`
+		TotalSupply *big.Int           `json:"totalSupply,omitempty"`
+		Holder      *ethcommon.Address `json:"holder,omitempty"`
+		Balance     *big.Int           `json:"balance,omitempty"`
+		Vault       *vaultInfo         `json:"vault,omitempty"`
+	}
+
+	// vaultInfo are the parameters of an ERC-4626 vault and the results of
+	// the sanity checks on its conversions.
+	vaultInfo struct {
+		Asset         ethcommon.Address `json:"asset"`
+		AssetSymbol   string            `json:"assetSymbol,omitempty"`
+		AssetDecimals *uint8            `json:"assetDecimals,omitempty"`
+		TotalAssets   *big.Int          `json:"totalAssets"`
+		AssetBalance  *big.Int          `json:"assetBalance,omitempty"`
+		Amount        *big.Int          `json:"amount"`
+		Conversions   []vaultValue      `json:"conversions"`
+		Limits        []vaultValue      `json:"limits,omitempty"`
+		Checks        []vaultCheck      `json:"checks"`
+	}
+
+	vaultValue struct {
+		Name  string   `json:"name"`
+		Value *big.Int `json:"value"`
+	}
+
+	vaultCheck struct {
+		Name   string `json:"name"`
+		Passed bool   `json:"passed"`
+		Detail string `json:"detail"`
+	}
+
+	// caller calls the view functions of a contract at a block.
+	caller struct {
+		contract *bind.BoundContract
+		opts     *bind.CallOpts
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage      string
+	inputToken tokenParams
+
+	parsedTokenABI, _ = gethabi.JSON(strings.NewReader(tokenABI))
+)
+
+// TokenCmd inspects ERC-20 tokens and ERC-4626 vaults.
+var TokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Inspect an ERC-20 token and, if it's an ERC-4626 vault, sanity check its parameters and conversions.",
+	Long:  usage,
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !addressbook.Valid(inputToken.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputToken.Address)
+		}
+		if inputToken.Holder != "" && !addressbook.Valid(inputToken.Holder) {
+			return fmt.Errorf("the holder %s isn't a valid address", inputToken.Holder)
+		}
+		if inputToken.Amount != "" {
+			if n, ok := new(big.Int).SetString(inputToken.Amount, 0); !ok || n.Sign() <= 0 {
+				return fmt.Errorf("the amount %s isn't a positive integer", inputToken.Amount)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		rpc, err := util.DialRPC(ctx, inputToken.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+		ec := ethclient.NewClient(rpc)
+
+		opts := &bind.CallOpts{Context: ctx}
+		if inputToken.Block != "latest" {
+			n, ok := new(big.Int).SetString(inputToken.Block, 0)
+			if !ok {
+				return fmt.Errorf("the block %s isn't a number", inputToken.Block)
+			}
+			opts.BlockNumber = n
+		}
+
+		resolver := addressbook.NewResolver(rpc)
+		address, err := resolver.Resolve(ctx, inputToken.Address)
+		if err != nil {
+			return err
+		}
+		var holder *ethcommon.Address
+		if inputToken.Holder != "" {
+			h, err := resolver.Resolve(ctx, inputToken.Holder)
+			if err != nil {
+				return err
+			}
+			holder = &h
+		}
+
+		code, err := ec.CodeAt(ctx, address, opts.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if len(code) == 0 {
+			return fmt.Errorf("%s has no code", address.Hex())
+		}
+
+		info := inspectToken(ec, opts, address, holder)
+		if info.Vault != nil {
+			cmd.SilenceUsage = true
+		}
+
+		if inputToken.JSON {
+			out, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		} else {
+			printToken(info)
+		}
+
+		if info.Vault != nil {
+			for _, c := range info.Vault.Checks {
+				if !c.Passed {
+					return fmt.Errorf("the vault failed the %s check", c.Name)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := TokenCmd.Flags()
+	flagSet.StringVar(&inputToken.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputToken.Address, "address", "", "The address of the token or vault. "+addressbook.Help)
+	flagSet.StringVar(&inputToken.Holder, "holder", "", "An address to get the balance and vault limits of. "+addressbook.Help)
+	flagSet.StringVar(&inputToken.Block, "block", "latest", "The block number to inspect the token at")
+	flagSet.StringVar(&inputToken.Amount, "amount", "", "The amount of assets, in base units, that the vault conversions are checked with (default one whole asset)")
+	flagSet.BoolVar(&inputToken.JSON, "json", false, "Output the token as JSON")
+	_ = TokenCmd.MarkFlagRequired("address")
+}
+
+func newCaller(ec *ethclient.Client, opts *bind.CallOpts, address ethcommon.Address) *caller {
+	return &caller{contract: bind.NewBoundContract(address, parsedTokenABI, ec, nil, nil), opts: opts}
+}
+
+func (c *caller) call(method string, args ...interface{}) (interface{}, error) {
+	var out []interface{}
+	if err := c.contract.Call(c.opts, &out, method, args...); err != nil {
+		return nil, err
+	}
+	if len(out) != 1 {
+		return nil, fmt.Errorf("%s returned %d values", method, len(out))
+	}
+	return out[0], nil
+}
+
+func (c *caller) bigInt(method string, args ...interface{}) (*big.Int, error) {
+	out, err := c.call(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := out.(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s didn't return a uint256", method)
+	}
+	return n, nil
+}
+
+// metadata reads the optional ERC-20 metadata. Tokens don't have to implement
+// it, so the fields that can't be read are left empty.
+func (c *caller) metadata() (name, symbol string, decimals *uint8) {
+	if out, err := c.call("name"); err == nil {
+		name, _ = out.(string)
+	}
+	if out, err := c.call("symbol"); err == nil {
+		symbol, _ = out.(string)
+	}
+	if out, err := c.call("decimals"); err == nil {
+		if d, ok := out.(uint8); ok {
+			decimals = &d
+		}
+	}
+	return name, symbol, decimals
+}
+
+func inspectToken(ec *ethclient.Client, opts *bind.CallOpts, address ethcommon.Address, holder *ethcommon.Address) *tokenInfo {
+	c := newCaller(ec, opts, address)
+	info := &tokenInfo{Address: address}
+	info.Name, info.Symbol, info.Decimals = c.metadata()
+
+	var err error
+	if info.TotalSupply, err = c.bigInt("totalSupply"); err != nil {
+		log.Warn().Err(err).Msg("Unable to get the total supply, the contract might not be an ERC-20 token")
+	}
+	if holder != nil {
+		info.Holder = holder
+		if info.Balance, err = c.bigInt("balanceOf", *holder); err != nil {
+			log.Warn().Err(err).Msg("Unable to get the balance of the holder")
+		}
+	}
+
+	// A vault is detected by its asset, which has to be a contract.
+	out, err := c.call("asset")
+	if err != nil {
+		log.Debug().Err(err).Msg("The token isn't an ERC-4626 vault")
+		return info
+	}
+	asset, ok := out.(ethcommon.Address)
+	if !ok {
+		return info
+	}
+	code, err := ec.CodeAt(opts.Context, asset, opts.BlockNumber)
+	if err != nil || len(code) == 0 {
+		log.Warn().Str("asset", asset.Hex()).Msg("The asset of the vault has no code, so it isn't treated as an ERC-4626 vault")
+		return info
+	}
+
+	vault, err := inspectVault(ec, opts, c, asset, info)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to read the ERC-4626 vault parameters")
+		return info
+	}
+	info.Vault = vault
+	return info
+}
+
+// inspectVault reads the parameters of the vault and checks that its
+// conversions round in favor of the vault, as ERC-4626 requires, so that
+// depositing and redeeming can't take assets out of the vault.
+func inspectVault(ec *ethclient.Client, opts *bind.CallOpts, c *caller, asset ethcommon.Address, info *tokenInfo) (*vaultInfo, error) {
+	v := &vaultInfo{Asset: asset}
+	ac := newCaller(ec, opts, asset)
+	_, v.AssetSymbol, v.AssetDecimals = ac.metadata()
+
+	var err error
+	if v.TotalAssets, err = c.bigInt("totalAssets"); err != nil {
+		return nil, err
+	}
+	if v.AssetBalance, err = ac.bigInt("balanceOf", info.Address); err != nil {
+		log.Debug().Err(err).Msg("Unable to get the asset balance of the vault")
+	}
+
+	v.Amount = big.NewInt(1)
+	if inputToken.Amount != "" {
+		v.Amount, _ = new(big.Int).SetString(inputToken.Amount, 0)
+	} else if v.AssetDecimals != nil {
+		v.Amount = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*v.AssetDecimals)), nil)
+	}
+	assets := v.Amount
+
+	values := make(map[string]*big.Int)
+	read := func(name, method string, arg interface{}) *big.Int {
+		if err != nil {
+			return nil
+		}
+		var n *big.Int
+		if n, err = c.bigInt(method, arg); err != nil {
+			err = fmt.Errorf("unable to call %s: %w", method, err)
+			return nil
+		}
+		values[name] = n
+		v.Conversions = append(v.Conversions, vaultValue{Name: name, Value: n})
+		return n
+	}
+	shares := read("convertToShares(assets)", "convertToShares", assets)
+	roundTrip := read("convertToAssets(convertToShares(assets))", "convertToAssets", shares)
+	sharesAssets := read("convertToAssets(shares)", "convertToAssets", shares)
+	deposit := read("previewDeposit(assets)", "previewDeposit", assets)
+	mint := read("previewMint(shares)", "previewMint", shares)
+	withdraw := read("previewWithdraw(assets)", "previewWithdraw", assets)
+	redeem := read("previewRedeem(shares)", "previewRedeem", shares)
+	depositRedeem := read("previewRedeem(previewDeposit(assets))", "previewRedeem", deposit)
+	if err != nil {
+		return nil, err
+	}
+
+	check := func(name string, passed bool, format string, args ...interface{}) {
+		v.Checks = append(v.Checks, vaultCheck{Name: name, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+	}
+	check("convert round trip", roundTrip.Cmp(assets) <= 0,
+		"converting %s assets to shares and back gives %s assets", assets, roundTrip)
+	check("previewDeposit", deposit.Cmp(shares) <= 0,
+		"depositing %s assets mints %s shares, convertToShares gives %s", assets, deposit, shares)
+	check("previewMint", mint.Cmp(sharesAssets) >= 0,
+		"minting %s shares costs %s assets, convertToAssets gives %s", shares, mint, sharesAssets)
+	check("previewWithdraw", withdraw.Cmp(shares) >= 0,
+		"withdrawing %s assets burns %s shares, convertToShares gives %s", assets, withdraw, shares)
+	check("previewRedeem", redeem.Cmp(sharesAssets) <= 0,
+		"redeeming %s shares returns %s assets, convertToAssets gives %s", shares, redeem, sharesAssets)
+	check("deposit then redeem", depositRedeem.Cmp(assets) <= 0,
+		"depositing %s assets and redeeming the shares returns %s assets", assets, depositRedeem)
+	if v.AssetBalance != nil && v.TotalAssets.Cmp(v.AssetBalance) != 0 {
+		log.Info().Str("totalAssets", v.TotalAssets.String()).Str("assetBalance", v.AssetBalance.String()).Msg("The total assets of the vault differ from its asset balance, which is expected if the assets are deployed elsewhere")
+	}
+
+	if info.Holder != nil {
+		for _, method := range []string{"maxDeposit", "maxMint", "maxWithdraw", "maxRedeem"} {
+			n, err := c.bigInt(method, *info.Holder)
+			if err != nil {
+				log.Warn().Err(err).Str("method", method).Msg("Unable to get the vault limit")
+				continue
+			}
+			v.Limits = append(v.Limits, vaultValue{Name: method, Value: n})
+		}
+	}
+	return v, nil
+}
+
+func printToken(info *tokenInfo) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle("Token")
+	t.AppendRow(table.Row{"Address", info.Address.Hex()})
+	t.AppendRow(table.Row{"Name", info.Name})
+	t.AppendRow(table.Row{"Symbol", info.Symbol})
+	if info.Decimals != nil {
+		t.AppendRow(table.Row{"Decimals", *info.Decimals})
+	}
+	if info.TotalSupply != nil {
+		t.AppendRow(table.Row{"Total Supply", info.TotalSupply})
+	}
+	if info.Holder != nil && info.Balance != nil {
+		t.AppendRow(table.Row{"Balance of " + info.Holder.Hex(), info.Balance})
+	}
+	t.Render()
+
+	v := info.Vault
+	if v == nil {
+		return
+	}
+	vt := table.NewWriter()
+	vt.SetOutputMirror(os.Stdout)
+	vt.SetTitle("ERC-4626 Vault")
+	vt.AppendRow(table.Row{"Asset", v.Asset.Hex()})
+	vt.AppendRow(table.Row{"Asset Symbol", v.AssetSymbol})
+	if v.AssetDecimals != nil {
+		vt.AppendRow(table.Row{"Asset Decimals", *v.AssetDecimals})
+	}
+	vt.AppendRow(table.Row{"Total Assets", v.TotalAssets})
+	if v.AssetBalance != nil {
+		vt.AppendRow(table.Row{"Asset Balance", v.AssetBalance})
+	}
+	vt.AppendRow(table.Row{"Assets", v.Amount})
+	for _, c := range v.Conversions {
+		vt.AppendRow(table.Row{c.Name, c.Value})
+	}
+	for _, l := range v.Limits {
+		vt.AppendRow(table.Row{l.Name, l.Value})
+	}
+	vt.Render()
+
+	ct := table.NewWriter()
+	ct.SetOutputMirror(os.Stdout)
+	ct.SetTitle("Checks")
+	ct.AppendHeader(table.Row{"Check", "Result", "Detail"})
+	for _, c := range v.Checks {
+		result := "pass"
+		if !c.Passed {
+			result = "fail"
+		}
+		ct.AppendRow(table.Row{c.Name, result, c.Detail})
+	}
+	ct.Render()
+}
@@ -0,0 +1,282 @@
+package trie
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// The kinds of tries that roots can be computed of.
+const (
+	trieTransactions = "transactions"
+	trieReceipts     = "receipts"
+	trieState        = "state"
+	trieRaw          = "raw"
+)
+
+type (
+	rootParams struct {
+		Type     string
+		Secure   bool
+		Expected string
+	}
+
+	rootOutput struct {
+		Type     string          `json:"type"`
+		Entries  int             `json:"entries"`
+		Root     ethcommon.Hash  `json:"root"`
+		Expected *ethcommon.Hash `json:"expected,omitempty"`
+		Match    *bool           `json:"match,omitempty"`
+	}
+)
+
+var inputRoot rootParams
+
+var rootCmd = &cobra.Command{
+	Use:   "root [file]",
+	Short: "Compute the transactions, receipts, state, or raw trie root of the data in a file.",
+	Long: `Compute the root of a Merkle-Patricia trie from the data in the file, or stdin if
+there's no file. --type selects the trie:
+
+  transactions  a JSON array of raw transactions or transaction objects, a block
+                with its transactions, or the RLP list of a block body
+  receipts      a JSON array of raw receipts or receipt objects, like the ones
+                eth_getBlockReceipts returns, or an RLP list of receipts
+  state         a genesis file or just its alloc
+  raw           a JSON array of {"key", "value"} entries or an object of keys
+                to values, with the keys hashed first if --secure is set
+
+With --expected, the command fails if the root doesn't match.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch inputRoot.Type {
+		case trieTransactions, trieReceipts, trieState, trieRaw:
+		default:
+			return fmt.Errorf("unknown trie type %q", inputRoot.Type)
+		}
+		if inputRoot.Expected != "" {
+			if _, err := hexutil.Decode(inputRoot.Expected); err != nil || len(inputRoot.Expected) != 66 {
+				return fmt.Errorf("the expected root %s isn't a 32 byte hex hash", inputRoot.Expected)
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+
+		out := rootOutput{Type: inputRoot.Type}
+		switch inputRoot.Type {
+		case trieTransactions, trieReceipts:
+			items, err := decodeItems(data, inputRoot.Type)
+			if err != nil {
+				return err
+			}
+			out.Entries = len(items)
+			out.Root = util.DeriveListRoot(items)
+		case trieState:
+			alloc, err := decodeAlloc(data)
+			if err != nil {
+				return err
+			}
+			out.Entries = len(alloc)
+			if out.Root, err = util.StateRoot(alloc); err != nil {
+				return err
+			}
+		case trieRaw:
+			entries, err := decodeEntries(data)
+			if err != nil {
+				return err
+			}
+			out.Entries = len(entries)
+			if out.Root, err = util.TrieRoot(entries, inputRoot.Secure); err != nil {
+				return err
+			}
+		}
+
+		if inputRoot.Expected != "" {
+			expected := ethcommon.HexToHash(inputRoot.Expected)
+			match := expected == out.Root
+			out.Expected, out.Match = &expected, &match
+		}
+		if err = printJSON(out); err != nil {
+			return err
+		}
+		if out.Match != nil && !*out.Match {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the root %s doesn't match the expected root %s", out.Root.Hex(), out.Expected.Hex())
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := rootCmd.Flags()
+	flagSet.StringVarP(&inputRoot.Type, "type", "t", trieTransactions, "The trie to compute the root of (transactions|receipts|state|raw)")
+	flagSet.BoolVar(&inputRoot.Secure, "secure", false, "Hash the keys of a raw trie with keccak256 like the state and storage tries do")
+	flagSet.StringVar(&inputRoot.Expected, "expected", "", "The root the computed root has to match")
+}
+
+// decodeItems returns the consensus encoding of the transactions or receipts
+// in the input, which is what their trie holds.
+func decodeItems(data []byte, kind string) ([][]byte, error) {
+	if !isJSON(data) {
+		var items []ethrlp.RawValue
+		if err := ethrlp.DecodeBytes(decodeRLPInput(data), &items); err != nil {
+			return nil, fmt.Errorf("unable to decode the RLP list: %w", err)
+		}
+		if kind == trieTransactions {
+			if txs, ok := bodyTransactions(items); ok {
+				items = txs
+			}
+		}
+		encoded := make([][]byte, 0, len(items))
+		for i, item := range items {
+			envelope, err := unwrapEnvelope(item)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode item %d: %w", i, err)
+			}
+			encoded = append(encoded, envelope)
+		}
+		return encoded, nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		// A block has its transactions in a field.
+		var block struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		if kind != trieTransactions || json.Unmarshal(data, &block) != nil || block.Transactions == nil {
+			return nil, fmt.Errorf("expected a JSON array of %s", kind)
+		}
+		list = block.Transactions
+	}
+
+	encoded := make([][]byte, 0, len(list))
+	for i, item := range list {
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(item, &raw); err == nil {
+			envelope, err := unwrapEnvelope(raw)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode item %d: %w", i, err)
+			}
+			encoded = append(encoded, envelope)
+			continue
+		}
+
+		var err error
+		var envelope []byte
+		if kind == trieTransactions {
+			tx := new(types.Transaction)
+			if err = json.Unmarshal(item, tx); err == nil {
+				envelope, err = tx.MarshalBinary()
+			}
+		} else {
+			receipt := new(types.Receipt)
+			if err = json.Unmarshal(item, receipt); err == nil {
+				envelope, err = receipt.MarshalBinary()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode item %d: %w", i, err)
+		}
+		encoded = append(encoded, envelope)
+	}
+	return encoded, nil
+}
+
+// bodyTransactions returns the transactions of the list if it's a block body,
+// which is the list of the transactions followed by the list of the uncle
+// headers, and the withdrawals after Shanghai. The uncles tell a body apart
+// from a list of transactions, since they're a list of lists while a
+// transaction is either a list of fields or a typed envelope.
+func bodyTransactions(items []ethrlp.RawValue) ([]ethrlp.RawValue, bool) {
+	if len(items) != 2 && len(items) != 3 {
+		return nil, false
+	}
+	var uncles []ethrlp.RawValue
+	if ethrlp.DecodeBytes(items[1], &uncles) != nil {
+		return nil, false
+	}
+	for _, uncle := range uncles {
+		if kind, _, _, err := ethrlp.Split(uncle); err != nil || kind != ethrlp.List {
+			return nil, false
+		}
+	}
+	var txs []ethrlp.RawValue
+	if ethrlp.DecodeBytes(items[0], &txs) != nil {
+		return nil, false
+	}
+	return txs, true
+}
+
+// unwrapEnvelope returns the consensus encoding of an item, which is the RLP
+// list of legacy items and the type followed by the payload of typed ones.
+// Typed items are wrapped in an RLP string in lists, like in block bodies.
+func unwrapEnvelope(item []byte) ([]byte, error) {
+	if len(item) == 0 {
+		return nil, fmt.Errorf("the item is empty")
+	}
+	if item[0] < 0x80 {
+		return item, nil
+	}
+	kind, content, _, err := ethrlp.Split(item)
+	if err != nil {
+		return nil, err
+	}
+	if kind == ethrlp.List {
+		return item, nil
+	}
+	if len(content) == 0 || content[0] >= 0x80 {
+		return nil, fmt.Errorf("the item isn't a typed envelope")
+	}
+	return content, nil
+}
+
+// decodeAlloc returns the alloc of a genesis file, or the input itself if
+// it's just an alloc.
+func decodeAlloc(data []byte) (core.GenesisAlloc, error) {
+	var genesis struct {
+		Alloc core.GenesisAlloc `json:"alloc"`
+	}
+	if err := json.Unmarshal(data, &genesis); err == nil && genesis.Alloc != nil {
+		return genesis.Alloc, nil
+	}
+	var alloc core.GenesisAlloc
+	if err := json.Unmarshal(data, &alloc); err != nil {
+		return nil, fmt.Errorf("unable to decode the alloc: %w", err)
+	}
+	return alloc, nil
+}
+
+// decodeEntries returns the entries of a raw trie, which are either an array
+// of key and value objects or an object of keys to values.
+func decodeEntries(data []byte) ([]util.TrieEntry, error) {
+	var entries []util.TrieEntry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		return entries, nil
+	}
+	var object map[string]hexutil.Bytes
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of entries or an object of keys to values")
+	}
+	for key, value := range object {
+		k, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, fmt.Errorf("the key %s isn't valid hex: %w", key, err)
+		}
+		entries = append(entries, util.TrieEntry{Key: k, Value: value})
+	}
+	return entries, nil
+}
@@ -0,0 +1,135 @@
+package trie
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// testBlock returns a block whose transaction root is derived by geth, with
+// the legacy transactions first so a list of them can look like a body.
+func testBlock(t *testing.T, uncles int) *types.Block {
+	key, err := crypto.HexToECDSA("42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(137)
+	signer := types.LatestSignerForChainID(chainID)
+	to := ethcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	var txs types.Transactions
+	for i, data := range []types.TxData{
+		&types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(30000000000), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30000000000), Gas: 21000, To: &to, Value: big.NewInt(2)},
+		&types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(30000000000), Gas: 30000, To: &to, AccessList: types.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{}}}}},
+		&types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(30000000000), GasFeeCap: big.NewInt(60000000000), Gas: 50000, Data: []byte{0x60, 0x00}},
+	} {
+		tx, err := types.SignNewTx(key, signer, data)
+		if err != nil {
+			t.Fatalf("unable to sign transaction %d: %v", i, err)
+		}
+		txs = append(txs, tx)
+	}
+
+	var uncleHeaders []*types.Header
+	for i := 0; i < uncles; i++ {
+		uncleHeaders = append(uncleHeaders, &types.Header{Number: big.NewInt(int64(99 - i)), Difficulty: big.NewInt(1), GasLimit: 30000000})
+	}
+	header := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), GasLimit: 30000000}
+	return types.NewBlock(header, txs, uncleHeaders, nil, ethtrie.NewStackTrie(nil))
+}
+
+func TestDecodeItemsTransactionRoot(t *testing.T) {
+	encode := func(v interface{}) []byte {
+		b, err := ethrlp.EncodeToBytes(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	rawJSON := func(txs types.Transactions) []byte {
+		var raw []hexutil.Bytes
+		for _, tx := range txs {
+			b, err := tx.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw = append(raw, b)
+		}
+		b, err := json.Marshal(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	block := testBlock(t, 0)
+	withUncles := testBlock(t, 2)
+	legacy := block.Transactions()[:2]
+
+	tests := []struct {
+		name    string
+		data    []byte
+		entries int
+		want    ethcommon.Hash
+	}{
+		{
+			name:    "body",
+			data:    encode(block.Body()),
+			entries: 4,
+			want:    block.TxHash(),
+		},
+		{
+			name:    "hex body with uncles",
+			data:    []byte(hexutil.Encode(encode(withUncles.Body()))),
+			entries: 4,
+			want:    withUncles.TxHash(),
+		},
+		{
+			name:    "transaction list",
+			data:    encode(block.Transactions()),
+			entries: 4,
+			want:    block.TxHash(),
+		},
+		{
+			name:    "two legacy transactions",
+			data:    encode(legacy),
+			entries: 2,
+			want:    types.DeriveSha(legacy, ethtrie.NewStackTrie(nil)),
+		},
+		{
+			name:    "raw transactions",
+			data:    rawJSON(block.Transactions()),
+			entries: 4,
+			want:    block.TxHash(),
+		},
+		{
+			name: "empty body",
+			data: encode(&types.Body{}),
+			want: types.EmptyRootHash,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := decodeItems(tc.data, trieTransactions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tc.entries {
+				t.Errorf("expected %d transactions, got %d", tc.entries, len(items))
+			}
+			if root := util.DeriveListRoot(items); root != tc.want {
+				t.Errorf("expected the root %s, got %s", tc.want.Hex(), root.Hex())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// TrieCmd represents the trie command
+var TrieCmd = &cobra.Command{
+	Use:   "trie",
+	Short: "Compute and verify Merkle-Patricia trie roots and proofs.",
+	Long:  usage,
+}
+
+func init() {
+	TrieCmd.AddCommand(rootCmd)
+	TrieCmd.AddCommand(verifyProofCmd)
+}
+
+// readInput returns the contents of the file in the arguments, or of stdin if
+// there's no argument.
+func readInput(args []string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if len(args) > 0 {
+		data, err = os.ReadFile(args[0])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the input: %w", err)
+	}
+	return data, nil
+}
+
+// isJSON returns whether the input is a JSON array or object rather than RLP.
+func isJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '[' || data[0] == '{')
+}
+
+// decodeRLPInput decodes hex encoded input, with or without the 0x prefix,
+// and returns binary input as is.
+func decodeRLPInput(data []byte) []byte {
+	text := strings.TrimSpace(string(data))
+	if decoded, err := hexutil.Decode(text); err == nil {
+		return decoded
+	}
+	if decoded, err := hex.DecodeString(text); err == nil && len(text) > 0 {
+		return decoded
+	}
+	return data
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,141 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrlp "github.com/ethereum/go-ethereum/rlp"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+type (
+	verifyProofParams struct {
+		Root   string
+		Key    string
+		Index  int64
+		Secure bool
+		Value  string
+	}
+
+	verifyProofOutput struct {
+		Root   ethcommon.Hash `json:"root"`
+		Key    hexutil.Bytes  `json:"key"`
+		Exists bool           `json:"exists"`
+		Value  hexutil.Bytes  `json:"value"`
+	}
+)
+
+var inputVerifyProof verifyProofParams
+
+var verifyProofCmd = &cobra.Command{
+	Use:   "verify-proof [proof file]",
+	Short: "Verify a Merkle-Patricia proof of a key against a trie root.",
+	Long: `Verify the Merkle-Patricia proof of --key against --root and print the proven
+value, which is empty if the proof shows that the key isn't in the trie. The
+proof is read from the file, or stdin if there's no file, as a JSON array of
+hex encoded nodes or one node per line.
+
+The keys of transaction and receipt tries are the RLP encoded indexes, which
+--index sets. The keys of state and storage tries are hashed, which --secure
+does for the address or slot in --key. With --value, the command fails unless
+the proven value matches.`,
+	Args: cobra.MaximumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		hasKey, hasIndex := cmd.Flags().Changed("key"), cmd.Flags().Changed("index")
+		if hasKey == hasIndex {
+			return fmt.Errorf("exactly one of --key and --index is required")
+		}
+		if hasIndex && inputVerifyProof.Index < 0 {
+			return fmt.Errorf("the index can't be negative")
+		}
+		if _, err := hexutil.Decode(inputVerifyProof.Root); err != nil || len(inputVerifyProof.Root) != 66 {
+			return fmt.Errorf("the root %s isn't a 32 byte hex hash", inputVerifyProof.Root)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var key []byte
+		var err error
+		if cmd.Flags().Changed("index") {
+			if key, err = ethrlp.EncodeToBytes(uint64(inputVerifyProof.Index)); err != nil {
+				return err
+			}
+		} else if key, err = hexutil.Decode(inputVerifyProof.Key); err != nil {
+			return fmt.Errorf("unable to decode the key: %w", err)
+		}
+
+		data, err := readInput(args)
+		if err != nil {
+			return err
+		}
+		nodes, err := decodeProof(data)
+		if err != nil {
+			return err
+		}
+
+		root := ethcommon.HexToHash(inputVerifyProof.Root)
+		value, err := util.VerifyProof(root, key, nodes, inputVerifyProof.Secure)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("invalid proof: %w", err)
+		}
+		if err = printJSON(verifyProofOutput{Root: root, Key: key, Exists: len(value) > 0, Value: value}); err != nil {
+			return err
+		}
+
+		if inputVerifyProof.Value != "" {
+			expected, err := hexutil.Decode(inputVerifyProof.Value)
+			if err != nil {
+				return fmt.Errorf("unable to decode the expected value: %w", err)
+			}
+			if !bytes.Equal(expected, value) {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("the proven value doesn't match the expected value")
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := verifyProofCmd.Flags()
+	flagSet.StringVar(&inputVerifyProof.Root, "root", "", "The root of the trie the proof is for")
+	flagSet.StringVar(&inputVerifyProof.Key, "key", "", "The hex encoded key the proof is for")
+	flagSet.Int64Var(&inputVerifyProof.Index, "index", 0, "The index of the transaction or receipt the proof is for, instead of --key")
+	flagSet.BoolVar(&inputVerifyProof.Secure, "secure", false, "Hash the key with keccak256 first, like the state and storage tries do")
+	flagSet.StringVar(&inputVerifyProof.Value, "value", "", "The hex encoded value the proof has to prove")
+	_ = verifyProofCmd.MarkFlagRequired("root")
+}
+
+// decodeProof decodes the proof nodes, which are either a JSON array of hex
+// strings or one hex string per line.
+func decodeProof(data []byte) ([]hexutil.Bytes, error) {
+	var nodes []hexutil.Bytes
+	if isJSON(data) {
+		if err := json.Unmarshal(data, &nodes); err != nil {
+			return nil, fmt.Errorf("unable to decode the proof: %w", err)
+		}
+		return nodes, nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		node, err := hexutil.Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode proof node on line %d: %w", i+1, err)
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("the proof is empty")
+	}
+	return nodes, nil
+}
@@ -0,0 +1,44 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeProof(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][]byte
+		wantErr bool
+	}{
+		{name: "json", data: `["0xc20102", "0x80"]`, want: [][]byte{{0xc2, 0x01, 0x02}, {0x80}}},
+		{name: "lines", data: "0xc20102\n\n  0x80  \n", want: [][]byte{{0xc2, 0x01, 0x02}, {0x80}}},
+		{name: "empty", data: "\n", wantErr: true},
+		{name: "bad line", data: "0xc20102\nc2", wantErr: true},
+		{name: "bad json", data: `["0xc2010"]`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, err := decodeProof([]byte(tc.data))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d nodes", len(nodes))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != len(tc.want) {
+				t.Fatalf("expected %d nodes, got %d", len(tc.want), len(nodes))
+			}
+			for i := range nodes {
+				if !bytes.Equal(nodes[i], tc.want[i]) {
+					t.Errorf("expected node %d to be %x, got %x", i, tc.want[i], nodes[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package tx
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	// The Fjord estimate of the compressed size of a transaction, scaled by
+	// 1e6, is fjordIntercept + fjordFastLZCoef * its FastLZ compressed size,
+	// and at least fjordMinTxSize.
+	fjordIntercept  = -42_585_600
+	fjordFastLZCoef = 836_500
+	fjordMinTxSize  = 100
+
+	// blobGasPerBlob is the blob gas of a blob of EIP-4844.
+	blobGasPerBlob = 1 << 17
+)
+
+type calldataParams struct {
+	RPCUrl            string
+	RawTx             bool
+	L1BaseFee         uint64
+	BlobBaseFee       uint64
+	BaseFeeScalar     uint64
+	BlobBaseFeeScalar uint64
+}
+
+var inputCalldata calldataParams
+
+type calldataRule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Gas         uint64   `json:"gas"`
+	Cost        *big.Int `json:"cost,omitempty"`
+}
+
+type calldataReport struct {
+	Bytes            int            `json:"bytes"`
+	ZeroBytes        int            `json:"zeroBytes"`
+	NonZeroBytes     int            `json:"nonZeroBytes"`
+	TxBytes          int            `json:"txBytes,omitempty"`
+	FastLZSize       int            `json:"fastLZSize"`
+	DeflateSize      int            `json:"deflateSize"`
+	CompressionRatio float64        `json:"compressionRatio"`
+	FjordSize        int            `json:"fjordEstimatedSize"`
+	Blobs            int            `json:"blobs"`
+	Rules            []calldataRule `json:"rules"`
+}
+
+var calldataCmd = &cobra.Command{
+	Use:   "calldata [calldata | hash | raw transaction]",
+	Short: "Estimate what posting calldata to L1 costs under different pricing rules.",
+	Long: `Count the zero and non-zero bytes of calldata and estimate its L1 data gas under
+the pricing rules of Ethereum and OP Stack rollups, along with its compressed
+size. The input is hex encoded calldata, or the raw transaction with --raw-tx,
+and it's read from stdin if there's no argument. With --rpc-url, a 32 byte
+input is looked up as a transaction hash instead.
+
+The costs are included with --l1-base-fee and --blob-base-fee, in wei.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+		data, err := hexutil.Decode(input)
+		if err != nil {
+			return fmt.Errorf("unable to decode hex input: %w", err)
+		}
+
+		rawTx := inputCalldata.RawTx
+		if len(data) == common.HashLength && inputCalldata.RPCUrl != "" {
+			if data, err = fetchRawTx(cmd, input); err != nil {
+				return err
+			}
+			rawTx = true
+		}
+
+		var txData []byte
+		if rawTx {
+			tx, err := decodeTx(data)
+			if err != nil {
+				return err
+			}
+			txData, data = data, tx.Input
+		}
+
+		b, err := json.MarshalIndent(estimateCalldata(data, txData), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+func init() {
+	flagSet := calldataCmd.Flags()
+	flagSet.StringVar(&inputCalldata.RPCUrl, "rpc-url", "", "The RPC endpoint url to look up transaction hashes at")
+	flagSet.BoolVar(&inputCalldata.RawTx, "raw-tx", false, "The input is a raw transaction rather than calldata")
+	flagSet.Uint64Var(&inputCalldata.L1BaseFee, "l1-base-fee", 0, "The L1 base fee in wei to estimate the costs with")
+	flagSet.Uint64Var(&inputCalldata.BlobBaseFee, "blob-base-fee", 0, "The L1 blob base fee in wei to estimate the costs with")
+	flagSet.Uint64Var(&inputCalldata.BaseFeeScalar, "base-fee-scalar", 5227, "The OP Stack base fee scalar of the Fjord estimate")
+	flagSet.Uint64Var(&inputCalldata.BlobBaseFeeScalar, "blob-base-fee-scalar", 1014213, "The OP Stack blob base fee scalar of the Fjord estimate")
+}
+
+func fetchRawTx(cmd *cobra.Command, hash string) ([]byte, error) {
+	rpc, err := util.DialRPC(cmd.Context(), inputCalldata.RPCUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer rpc.Close()
+
+	var raw hexutil.Bytes
+	if err = rpc.CallContext(cmd.Context(), &raw, "eth_getRawTransactionByHash", hash); err != nil {
+		return nil, fmt.Errorf("unable to fetch the transaction: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("transaction %s not found", hash)
+	}
+	return raw, nil
+}
+
+// estimateCalldata reports the byte counts, compressed sizes, and L1 gas of
+// the calldata. The Fjord estimate is of the whole transaction if there is
+// one, since that's what an OP Stack chain posts.
+func estimateCalldata(data, txData []byte) calldataReport {
+	r := calldataReport{Bytes: len(data), TxBytes: len(txData)}
+	for _, b := range data {
+		if b == 0 {
+			r.ZeroBytes++
+		} else {
+			r.NonZeroBytes++
+		}
+	}
+	r.DeflateSize = deflateLen(data)
+	if len(data) > 0 {
+		r.CompressionRatio = float64(r.DeflateSize) / float64(len(data))
+	}
+
+	posted := data
+	if txData != nil {
+		posted = txData
+	}
+	r.FastLZSize = flzCompressLen(posted)
+	fjordSize := fjordIntercept + fjordFastLZCoef*int64(r.FastLZSize)
+	if fjordSize < fjordMinTxSize*1e6 {
+		fjordSize = fjordMinTxSize * 1e6
+	}
+	r.FjordSize = int(fjordSize / 1e6)
+
+	blobCapacity := util.FieldElementsPerBlob * util.BlobDataPerFieldElement
+	r.Blobs = (len(data) + blobCapacity - 1) / blobCapacity
+
+	zeros, nonZeros := uint64(r.ZeroBytes), uint64(r.NonZeroBytes)
+	l1BaseFee := new(big.Int).SetUint64(inputCalldata.L1BaseFee)
+	blobBaseFee := new(big.Int).SetUint64(inputCalldata.BlobBaseFee)
+	gasCost := func(gas uint64, fee *big.Int) *big.Int {
+		if fee.Sign() == 0 {
+			return nil
+		}
+		return new(big.Int).Mul(new(big.Int).SetUint64(gas), fee)
+	}
+
+	r.Rules = []calldataRule{{
+		Name:        "frontier",
+		Description: "4 gas per zero byte and 68 per non-zero byte",
+		Gas:         4*zeros + 68*nonZeros,
+	}, {
+		Name:        "istanbul",
+		Description: "4 gas per zero byte and 16 per non-zero byte of EIP-2028",
+		Gas:         4*zeros + 16*nonZeros,
+	}, {
+		Name:        "prague-floor",
+		Description: "10 gas per zero byte and 40 per non-zero byte of the EIP-7623 floor",
+		Gas:         10*zeros + 40*nonZeros,
+	}}
+	for i := range r.Rules {
+		r.Rules[i].Cost = gasCost(r.Rules[i].Gas, l1BaseFee)
+	}
+
+	// The Fjord fee is the estimated size times 16 times the base fee and
+	// base fee scalar plus the blob base fee and its scalar, all scaled by
+	// 1e6 twice.
+	fjord := calldataRule{
+		Name:        "op-fjord",
+		Description: "16 gas per byte of the FastLZ size estimate of OP Stack Fjord",
+		Gas:         uint64(fjordSize*16) / 1e6,
+	}
+	if l1BaseFee.Sign() != 0 || blobBaseFee.Sign() != 0 {
+		fee := new(big.Int).Mul(l1BaseFee, new(big.Int).SetUint64(16*inputCalldata.BaseFeeScalar))
+		fee.Add(fee, new(big.Int).Mul(blobBaseFee, new(big.Int).SetUint64(inputCalldata.BlobBaseFeeScalar)))
+		fee.Mul(fee, big.NewInt(fjordSize))
+		fjord.Cost = fee.Div(fee, big.NewInt(1e12))
+	}
+
+	blob := calldataRule{
+		Name:        "blob",
+		Description: fmt.Sprintf("%d blob gas per blob of EIP-4844", blobGasPerBlob),
+		Gas:         uint64(r.Blobs) * blobGasPerBlob,
+	}
+	blob.Cost = gasCost(blob.Gas, blobBaseFee)
+
+	r.Rules = append(r.Rules, fjord, blob)
+	return r
+}
+
+// deflateLen returns the size of the data compressed with deflate at the best
+// compression level, which is close to what rollup batchers achieve with
+// zlib.
+func deflateLen(data []byte) int {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Len()
+}
+
+// flzCompressLen returns the size of the data compressed with FastLZ level 1,
+// which is what the OP Stack Fjord fee estimate is based on. It follows the
+// LibZip implementation the chain uses, without producing the output.
+func flzCompressLen(ib []byte) int {
+	n := 0
+	ht := make([]uint32, 8192)
+	u24 := func(i uint32) uint32 {
+		return uint32(ib[i]) | uint32(ib[i+1])<<8 | uint32(ib[i+2])<<16
+	}
+	cmp := func(p, q, e uint32) uint32 {
+		l := uint32(0)
+		for e -= q; l < e; l++ {
+			if ib[p+l] != ib[q+l] {
+				e = 0
+			}
+		}
+		return l
+	}
+	literals := func(r uint32) {
+		n += int(0x21 * (r / 0x20))
+		if r %= 0x20; r != 0 {
+			n += int(r + 1)
+		}
+	}
+	match := func(l uint32) {
+		l--
+		n += int(3 * (l / 262))
+		if l%262 >= 6 {
+			n += 3
+		} else {
+			n += 2
+		}
+	}
+	hash := func(v uint32) uint32 {
+		return ((2654435769 * v) >> 19) & 0x1fff
+	}
+	setNextHash := func(ip uint32) uint32 {
+		ht[hash(u24(ip))] = ip
+		return ip + 1
+	}
+
+	a := uint32(0)
+	ipLimit := uint32(0)
+	if len(ib) >= 13 {
+		ipLimit = uint32(len(ib)) - 13
+	}
+	for ip := a + 2; ip < ipLimit; {
+		var r, d uint32
+		for {
+			s := u24(ip)
+			h := hash(s)
+			r = ht[h]
+			ht[h] = ip
+			d = ip - r
+			if ip >= ipLimit {
+				break
+			}
+			ip++
+			if d <= 0x1fff && s == u24(r) {
+				break
+			}
+		}
+		if ip >= ipLimit {
+			break
+		}
+		ip--
+		if ip > a {
+			literals(ip - a)
+		}
+		l := cmp(r+3, ip+3, ipLimit+9)
+		match(l)
+		ip = setNextHash(setNextHash(ip + l))
+		a = ip
+	}
+	literals(uint32(len(ib)) - a)
+	return n
+}
@@ -0,0 +1,362 @@
+package tx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	// cellsPerBlob is the number of cell proofs per blob of EIP-7594.
+	cellsPerBlob = 128
+)
+
+type decodeParams struct {
+	ChainID uint64
+}
+
+var inputDecode decodeParams
+
+type decodedAuthorization struct {
+	ChainID   *hexutil.Big    `json:"chainId"`
+	Address   common.Address  `json:"address"`
+	Nonce     hexutil.Uint64  `json:"nonce"`
+	YParity   hexutil.Uint64  `json:"yParity"`
+	R         *hexutil.Big    `json:"r"`
+	S         *hexutil.Big    `json:"s"`
+	Authority *common.Address `json:"authority,omitempty"`
+}
+
+type decodedSidecar struct {
+	Version     hexutil.Uint64  `json:"version"`
+	Blobs       int             `json:"blobs"`
+	Commitments []hexutil.Bytes `json:"commitments"`
+	Proofs      []hexutil.Bytes `json:"proofs"`
+}
+
+type decodedTx struct {
+	Type                 hexutil.Uint64         `json:"type"`
+	TypeName             string                 `json:"typeName"`
+	Hash                 common.Hash            `json:"hash"`
+	SigningHash          common.Hash            `json:"signingHash"`
+	ChainID              *hexutil.Big           `json:"chainId,omitempty"`
+	Nonce                hexutil.Uint64         `json:"nonce"`
+	GasPrice             *hexutil.Big           `json:"gasPrice,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big           `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerGas         *hexutil.Big           `json:"maxFeePerGas,omitempty"`
+	MaxFeePerBlobGas     *hexutil.Big           `json:"maxFeePerBlobGas,omitempty"`
+	Gas                  hexutil.Uint64         `json:"gas"`
+	To                   *common.Address        `json:"to"`
+	Value                *hexutil.Big           `json:"value"`
+	Input                hexutil.Bytes          `json:"input"`
+	AccessList           *types.AccessList      `json:"accessList,omitempty"`
+	BlobVersionedHashes  []common.Hash          `json:"blobVersionedHashes,omitempty"`
+	Sidecar              *decodedSidecar        `json:"sidecar,omitempty"`
+	AuthorizationList    []decodedAuthorization `json:"authorizationList,omitempty"`
+	V                    *hexutil.Big           `json:"v"`
+	R                    *hexutil.Big           `json:"r"`
+	S                    *hexutil.Big           `json:"s"`
+	From                 *common.Address        `json:"from,omitempty"`
+	Valid                bool                   `json:"valid"`
+	Errors               []string               `json:"errors,omitempty"`
+	Warnings             []string               `json:"warnings,omitempty"`
+}
+
+func (d *decodedTx) fail(format string, args ...interface{}) {
+	d.Errors = append(d.Errors, fmt.Sprintf(format, args...))
+}
+
+func (d *decodedTx) warn(format string, args ...interface{}) {
+	d.Warnings = append(d.Warnings, fmt.Sprintf(format, args...))
+}
+
+var decodeCmd = &cobra.Command{
+	Use:   "decode [raw transaction]",
+	Short: "Decode a raw transaction of any type and verify its signature.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, err := getInputData(args)
+		if err != nil {
+			return err
+		}
+		data, err := hexutil.Decode(input)
+		if err != nil {
+			return fmt.Errorf("unable to decode hex input: %w", err)
+		}
+
+		tx, err := decodeTx(data)
+		if err != nil {
+			return err
+		}
+		if inputDecode.ChainID != 0 {
+			checkChainID(tx, new(big.Int).SetUint64(inputDecode.ChainID))
+		}
+		tx.Valid = len(tx.Errors) == 0
+
+		b, err := json.MarshalIndent(tx, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		if !tx.Valid {
+			return errors.New("the transaction isn't valid")
+		}
+		return nil
+	},
+}
+
+func init() {
+	decodeCmd.Flags().Uint64Var(&inputDecode.ChainID, "chain-id", 0, "The chain ID the transaction is expected to be for (0 to skip the check)")
+}
+
+// decodeTx decodes the raw transaction by its type. The types that
+// go-ethereum knows about are decoded by it, and the newer ones by util.
+func decodeTx(data []byte) (*decodedTx, error) {
+	if len(data) == 0 {
+		return nil, errors.New("the transaction is empty")
+	}
+	switch {
+	case data[0] >= 0xc0, data[0] == types.AccessListTxType, data[0] == types.DynamicFeeTxType:
+		return decodeGethTx(data)
+	case data[0] == util.BlobTxType:
+		return decodeBlobTx(data)
+	case data[0] == util.SetCodeTxType:
+		return decodeSetCodeTx(data)
+	default:
+		return nil, fmt.Errorf("unknown transaction type %#x", data[0])
+	}
+}
+
+func decodeGethTx(data []byte) (*decodedTx, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("unable to decode the transaction: %w", err)
+	}
+	v, r, s := tx.RawSignatureValues()
+	out := &decodedTx{
+		Type:  hexutil.Uint64(tx.Type()),
+		Hash:  tx.Hash(),
+		Nonce: hexutil.Uint64(tx.Nonce()),
+		Gas:   hexutil.Uint64(tx.Gas()),
+		To:    tx.To(),
+		Value: (*hexutil.Big)(tx.Value()),
+		Input: tx.Data(),
+		V:     (*hexutil.Big)(v),
+		R:     (*hexutil.Big)(r),
+		S:     (*hexutil.Big)(s),
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		out.TypeName = "legacy"
+		out.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.AccessListTxType:
+		out.TypeName = "access-list"
+		out.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		out.TypeName = "dynamic-fee"
+		out.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		out.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		out.AccessList = &accessList
+	}
+
+	// Legacy transactions without EIP-155 replay protection are signed
+	// without a chain ID.
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		out.ChainID = (*hexutil.Big)(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	} else {
+		out.warn("the transaction isn't replay protected, so it's valid on every chain")
+	}
+	out.SigningHash = signer.Hash(tx)
+
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		out.fail("invalid signature: %v", err)
+	} else {
+		out.From = &from
+	}
+	return out, nil
+}
+
+func decodeBlobTx(data []byte) (*decodedTx, error) {
+	tx, sidecar, err := util.DecodeBlobTx(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the blob transaction: %w", err)
+	}
+	to := tx.To
+	accessList := tx.AccessList
+	out := &decodedTx{
+		Type:                 util.BlobTxType,
+		TypeName:             "blob",
+		ChainID:              (*hexutil.Big)(tx.ChainID),
+		Nonce:                hexutil.Uint64(tx.Nonce),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap),
+		MaxFeePerBlobGas:     (*hexutil.Big)(tx.BlobFeeCap),
+		Gas:                  hexutil.Uint64(tx.Gas),
+		To:                   &to,
+		Value:                (*hexutil.Big)(tx.Value),
+		Input:                tx.Data,
+		AccessList:           &accessList,
+		BlobVersionedHashes:  tx.BlobHashes,
+		V:                    (*hexutil.Big)(tx.V),
+		R:                    (*hexutil.Big)(tx.R),
+		S:                    (*hexutil.Big)(tx.S),
+	}
+	if out.Hash, err = tx.Hash(); err != nil {
+		return nil, err
+	}
+	if out.SigningHash, err = tx.SigningHash(); err != nil {
+		return nil, err
+	}
+	if from, err := tx.Sender(); err != nil {
+		out.fail("invalid signature: %v", err)
+	} else {
+		out.From = &from
+	}
+
+	if len(tx.BlobHashes) == 0 {
+		out.fail("the transaction has no blobs")
+	}
+	for i, h := range tx.BlobHashes {
+		if h[0] != util.BlobCommitmentVersionKZG {
+			out.fail("blob %d has an unknown versioned hash version %#x", i, h[0])
+		}
+	}
+	if sidecar != nil {
+		out.Sidecar = decodeSidecar(out, tx, sidecar)
+	}
+	return out, nil
+}
+
+// decodeSidecar checks that the sidecar has a blob, commitment, and the
+// proofs for every versioned hash, that the commitments match the hashes, and
+// that the KZG proofs of version 0 sidecars are valid. The cell proofs of
+// version 1 sidecars aren't verified.
+func decodeSidecar(out *decodedTx, tx *util.BlobTx, sidecar *util.BlobSidecar) *decodedSidecar {
+	decoded := &decodedSidecar{Version: hexutil.Uint64(sidecar.Version), Blobs: len(sidecar.Blobs)}
+	for _, c := range sidecar.Commitments {
+		decoded.Commitments = append(decoded.Commitments, c)
+	}
+	for _, p := range sidecar.Proofs {
+		decoded.Proofs = append(decoded.Proofs, p)
+	}
+
+	n := len(tx.BlobHashes)
+	if len(sidecar.Blobs) != n || len(sidecar.Commitments) != n {
+		out.fail("the sidecar has %d blobs and %d commitments for %d versioned hashes", len(sidecar.Blobs), len(sidecar.Commitments), n)
+	}
+	proofs := n
+	if sidecar.Version == 1 {
+		proofs = n * cellsPerBlob
+	}
+	if len(sidecar.Proofs) != proofs {
+		out.fail("the sidecar has %d proofs rather than %d", len(sidecar.Proofs), proofs)
+	}
+	for i, blob := range sidecar.Blobs {
+		if len(blob) != util.BlobSize {
+			out.fail("blob %d is %d bytes rather than %d", i, len(blob), util.BlobSize)
+		}
+	}
+	for i, c := range sidecar.Commitments {
+		if i >= n {
+			break
+		}
+		if h := util.KZGToVersionedHash(c); h != tx.BlobHashes[i] {
+			out.fail("the commitment of blob %d hashes to %s rather than %s", i, h, tx.BlobHashes[i])
+		}
+		if sidecar.Version != 0 || i >= len(sidecar.Blobs) || i >= len(sidecar.Proofs) || len(sidecar.Blobs[i]) != util.BlobSize {
+			continue
+		}
+		if err := util.VerifyBlobProof(sidecar.Blobs[i], c, sidecar.Proofs[i]); err != nil {
+			out.fail("the KZG proof of blob %d isn't valid: %v", i, err)
+		}
+	}
+	return decoded
+}
+
+func decodeSetCodeTx(data []byte) (*decodedTx, error) {
+	tx := new(util.SetCodeTx)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("unable to decode the set code transaction: %w", err)
+	}
+	to := tx.To
+	accessList := tx.AccessList
+	out := &decodedTx{
+		Type:                 util.SetCodeTxType,
+		TypeName:             "set-code",
+		ChainID:              (*hexutil.Big)(tx.ChainID),
+		Nonce:                hexutil.Uint64(tx.Nonce),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap),
+		Gas:                  hexutil.Uint64(tx.Gas),
+		To:                   &to,
+		Value:                (*hexutil.Big)(tx.Value),
+		Input:                tx.Data,
+		AccessList:           &accessList,
+		V:                    (*hexutil.Big)(tx.V),
+		R:                    (*hexutil.Big)(tx.R),
+		S:                    (*hexutil.Big)(tx.S),
+	}
+	var err error
+	if out.Hash, err = tx.Hash(); err != nil {
+		return nil, err
+	}
+	if out.SigningHash, err = tx.SigningHash(); err != nil {
+		return nil, err
+	}
+	if from, err := tx.Sender(); err != nil {
+		out.fail("invalid signature: %v", err)
+	} else {
+		out.From = &from
+	}
+
+	if len(tx.AuthList) == 0 {
+		out.fail("the authorization list is empty")
+	}
+	for i, auth := range tx.AuthList {
+		decoded := decodedAuthorization{
+			ChainID: (*hexutil.Big)(auth.ChainID),
+			Address: auth.Address,
+			Nonce:   hexutil.Uint64(auth.Nonce),
+			YParity: hexutil.Uint64(auth.V),
+			R:       (*hexutil.Big)(auth.R),
+			S:       (*hexutil.Big)(auth.S),
+		}
+		// Invalid authorizations are skipped rather than invalidating the
+		// transaction.
+		if authority, err := auth.Authority(); err != nil {
+			out.warn("authorization %d has an invalid signature: %v", i, err)
+		} else {
+			decoded.Authority = &authority
+		}
+		out.AuthorizationList = append(out.AuthorizationList, decoded)
+	}
+	return out, nil
+}
+
+// checkChainID checks that the transaction and its authorizations are for the
+// chain. Authorizations with a chain ID of zero are valid on every chain.
+func checkChainID(tx *decodedTx, chainID *big.Int) {
+	if tx.ChainID != nil && tx.ChainID.ToInt().Cmp(chainID) != 0 {
+		tx.fail("the chain ID is %s rather than %s", tx.ChainID.ToInt(), chainID)
+	}
+	for i, auth := range tx.AuthorizationList {
+		if c := auth.ChainID.ToInt(); c.Sign() != 0 && c.Cmp(chainID) != 0 {
+			tx.warn("authorization %d is for chain %s rather than %s", i, c, chainID)
+		}
+	}
+}
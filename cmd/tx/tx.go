@@ -0,0 +1,40 @@
+package tx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "embed"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// TxCmd represents the tx command
+var TxCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Decode and inspect raw transactions.",
+	Long:  usage,
+}
+
+func init() {
+	TxCmd.AddCommand(decodeCmd)
+	TxCmd.AddCommand(calldataCmd)
+}
+
+// getInputData returns the first argument if there is one, otherwise it
+// reads stdin.
+func getInputData(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimSpace(args[0]), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("unable to read stdin: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
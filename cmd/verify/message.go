@@ -0,0 +1,204 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/addressbook"
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+const (
+	methodECRecover = "ecrecover"
+	methodERC1271   = "erc1271"
+)
+
+type (
+	messageParams struct {
+		Address   string
+		Signature string
+		File      string
+		Hex       bool
+		Raw       bool
+		RPCURL    string
+		Block     string
+	}
+
+	verifyResult struct {
+		Valid   bool               `json:"valid"`
+		Address ethcommon.Address  `json:"address"`
+		Signer  *ethcommon.Address `json:"signer,omitempty"`
+		Method  string             `json:"method,omitempty"`
+		Digest  hexutil.Bytes      `json:"digest"`
+	}
+)
+
+var (
+	inputMessage messageParams
+
+	// erc1271MagicValue is the selector of isValidSignature(bytes32,bytes),
+	// which is what the function returns for valid signatures.
+	erc1271MagicValue = hexutil.MustDecode("0x1626ba7e")
+)
+
+var messageCmd = &cobra.Command{
+	Use:   "message [message]",
+	Short: "Verify the signature of a message by an account, or by a contract with ERC-1271.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if !addressbook.Valid(inputMessage.Address) {
+			return fmt.Errorf("the address %s isn't valid", inputMessage.Address)
+		}
+		if !ethcommon.IsHexAddress(inputMessage.Address) && inputMessage.RPCURL == "" {
+			return fmt.Errorf("%s needs --rpc-url to be resolved on its chain", inputMessage.Address)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		signature, err := hexutil.Decode(inputMessage.Signature)
+		if err != nil {
+			return fmt.Errorf("unable to decode signature: %w", err)
+		}
+		message, err := util.ReadMessage(inputMessage.File, inputMessage.Hex || inputMessage.Raw, args)
+		if err != nil {
+			return err
+		}
+		digest, err := util.MessageDigest(message, inputMessage.Raw)
+		if err != nil {
+			return err
+		}
+
+		address, err := resolveAddress(cmd.Context())
+		if err != nil {
+			return err
+		}
+		result := verifyResult{
+			Address: address,
+			Digest:  digest,
+		}
+
+		// Smart contract signatures don't have to be 65 bytes, so failing to
+		// recover a signer isn't an error by itself.
+		if signer, err := util.RecoverSigner(digest, signature); err != nil {
+			log.Debug().Err(err).Msg("Unable to recover a signer")
+		} else {
+			result.Signer = &signer
+			if signer == result.Address {
+				result.Valid = true
+				result.Method = methodECRecover
+			}
+		}
+
+		if !result.Valid && inputMessage.RPCURL != "" {
+			valid, err := isValidSignature(cmd.Context(), result.Address, digest, signature)
+			if err != nil {
+				return err
+			}
+			if valid {
+				result.Valid = true
+				result.Method = methodERC1271
+			}
+		}
+
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		if !result.Valid {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the signature isn't valid for %s", result.Address.Hex())
+		}
+		return nil
+	},
+}
+
+func init() {
+	flagSet := messageCmd.Flags()
+	flagSet.StringVar(&inputMessage.Address, "address", "", "The address of the account or contract that signed the message. "+addressbook.Help)
+	flagSet.StringVar(&inputMessage.Signature, "signature", "", "The hex encoded signature")
+	flagSet.StringVar(&inputMessage.File, "file", "", "Read the message from a file rather than the arguments or stdin")
+	flagSet.BoolVar(&inputMessage.Hex, "hex", false, "Decode the message as hex before verifying")
+	flagSet.BoolVar(&inputMessage.Raw, "raw", false, "Verify the signature of the hex encoded 32 byte digest as is, without the personal_sign prefix")
+	flagSet.StringVar(&inputMessage.RPCURL, "rpc-url", "", "The RPC endpoint url used to verify contract signatures with ERC-1271")
+	flagSet.StringVar(&inputMessage.Block, "block", "latest", "The block number, hash, or tag contract signatures are verified at")
+	_ = messageCmd.MarkFlagRequired("address")
+	_ = messageCmd.MarkFlagRequired("signature")
+}
+
+// resolveAddress returns the address of --address, which is only dialed for
+// when it's an alias or a name.
+func resolveAddress(ctx context.Context) (ethcommon.Address, error) {
+	if ethcommon.IsHexAddress(inputMessage.Address) {
+		return ethcommon.HexToAddress(inputMessage.Address), nil
+	}
+	rpc, err := util.DialRPC(ctx, inputMessage.RPCURL)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	defer rpc.Close()
+	return addressbook.NewResolver(rpc).Resolve(ctx, inputMessage.Address)
+}
+
+// isValidSignature asks the contract at the address whether the signature of
+// the digest is valid as described in ERC-1271. Accounts without code can't
+// sign with ERC-1271, so their signatures are never valid.
+func isValidSignature(ctx context.Context, address ethcommon.Address, digest, signature []byte) (bool, error) {
+	rpc, err := util.DialRPC(ctx, inputMessage.RPCURL)
+	if err != nil {
+		return false, err
+	}
+	defer rpc.Close()
+
+	var code hexutil.Bytes
+	if err = rpc.CallContext(ctx, &code, "eth_getCode", address, inputMessage.Block); err != nil {
+		return false, fmt.Errorf("unable to get the code of %s: %w", address.Hex(), err)
+	}
+	if len(code) == 0 {
+		log.Debug().Str("address", address.Hex()).Msg("The address has no code to verify the signature with")
+		return false, nil
+	}
+
+	data, err := encodeIsValidSignature(digest, signature)
+	if err != nil {
+		return false, err
+	}
+	args := map[string]any{"to": address, "data": hexutil.Bytes(data)}
+
+	var out hexutil.Bytes
+	if err = rpc.CallContext(ctx, &out, "eth_call", args, inputMessage.Block); err != nil {
+		// Contracts revert on invalid signatures as often as they return
+		// something other than the magic value.
+		log.Debug().Err(err).Msg("The isValidSignature call failed")
+		return false, nil
+	}
+	return len(out) >= 4 && bytes.Equal(out[:4], erc1271MagicValue), nil
+}
+
+// encodeIsValidSignature returns the call data of
+// isValidSignature(bytes32,bytes).
+func encodeIsValidSignature(digest, signature []byte) ([]byte, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("the digest should be 32 bytes, got %d", len(digest))
+	}
+
+	data := make([]byte, 0, 4+32*3+len(signature)+31)
+	data = append(data, erc1271MagicValue...)
+	data = append(data, digest...)
+	// The offset of the signature bytes from the start of the arguments.
+	data = append(data, ethcommon.LeftPadBytes([]byte{64}, 32)...)
+	data = append(data, ethcommon.LeftPadBytes(new(big.Int).SetInt64(int64(len(signature))).Bytes(), 32)...)
+	data = append(data, signature...)
+	if pad := len(signature) % 32; pad != 0 {
+		data = append(data, make([]byte, 32-pad)...)
+	}
+	return data, nil
+}
@@ -0,0 +1,21 @@
+package verify
+
+import (
+	_ "embed"
+
+	"github.com/spf13/cobra"
+)
+
+//go:embed usage.md
+var usage string
+
+// VerifyCmd represents the verify command
+var VerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify message signatures of accounts and contracts.",
+	Long:  usage,
+}
+
+func init() {
+	VerifyCmd.AddCommand(messageCmd)
+}
@@ -0,0 +1,177 @@
+package verifyheaders
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+type (
+	verifyHeadersParams struct {
+		URL        string
+		From       uint64
+		To         uint64
+		BatchSize  uint64
+		SignersStr []string
+		SkipSeal   bool
+		Clique     bool
+		Sprint     uint64
+		Jaipur     uint64
+		signers    map[ethcommon.Address]struct{}
+	}
+
+	// headerFailure describes why a header didn't verify.
+	headerFailure struct {
+		Number uint64
+		Hash   ethcommon.Hash
+		Reason string
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage              string
+	inputVerifyHeaders verifyHeadersParams
+)
+
+// VerifyHeadersCmd represents the verify-headers command
+var VerifyHeadersCmd = &cobra.Command{
+	Use:   "verify-headers url",
+	Short: "Verify the hashes, parent links, and seals of headers returned by an RPC.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputVerifyHeaders.URL = args[0]
+
+		if inputVerifyHeaders.To < inputVerifyHeaders.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputVerifyHeaders.BatchSize == 0 {
+			return fmt.Errorf("the batch size must be greater than zero")
+		}
+		if inputVerifyHeaders.Sprint == 0 {
+			return fmt.Errorf("the sprint length must be greater than zero")
+		}
+
+		inputVerifyHeaders.signers = make(map[ethcommon.Address]struct{})
+		for _, s := range inputVerifyHeaders.SignersStr {
+			if !ethcommon.IsHexAddress(s) {
+				return fmt.Errorf("%s is not a valid signer address", s)
+			}
+			inputVerifyHeaders.signers[ethcommon.HexToAddress(s)] = struct{}{}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputVerifyHeaders.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		var (
+			parent   *types.Header
+			failures []headerFailure
+			signers  = make(map[ethcommon.Address]uint64)
+			verified uint64
+		)
+
+		for start := inputVerifyHeaders.From; start <= inputVerifyHeaders.To; start += inputVerifyHeaders.BatchSize {
+			end := start + inputVerifyHeaders.BatchSize - 1
+			if end > inputVerifyHeaders.To {
+				end = inputVerifyHeaders.To
+			}
+
+			log.Info().Uint64("start", start).Uint64("end", end).Msg("Getting range")
+			blocks, err := util.GetBlockRange(ctx, start, end, rpc)
+			if err != nil {
+				return err
+			}
+
+			for _, raw := range blocks {
+				header, claimedHash, err := util.ParseHeader(*raw)
+				if err != nil {
+					return err
+				}
+
+				var reasons []string
+				if header.Hash() != claimedHash {
+					reasons = append(reasons, fmt.Sprintf("hash mismatch, computed %s", header.Hash().Hex()))
+				}
+				if parent != nil && header.ParentHash != parent.Hash() {
+					reasons = append(reasons, fmt.Sprintf("parent hash %s doesn't match previous header %s", header.ParentHash.Hex(), parent.Hash().Hex()))
+				}
+				// The genesis header isn't sealed.
+				if !inputVerifyHeaders.SkipSeal && header.Number.Sign() > 0 {
+					signer, err := sealSigner(header)
+					if err != nil {
+						reasons = append(reasons, fmt.Sprintf("unable to recover signer: %s", err))
+					} else {
+						signers[signer]++
+						if _, ok := inputVerifyHeaders.signers[signer]; len(inputVerifyHeaders.signers) > 0 && !ok {
+							reasons = append(reasons, fmt.Sprintf("unexpected signer %s", signer.Hex()))
+						}
+					}
+					if !inputVerifyHeaders.Clique {
+						if err := util.CheckBorValidatorBytes(header, inputVerifyHeaders.Sprint); err != nil {
+							reasons = append(reasons, err.Error())
+						}
+					}
+				}
+
+				if len(reasons) > 0 {
+					failure := headerFailure{Number: header.Number.Uint64(), Hash: claimedHash, Reason: strings.Join(reasons, "; ")}
+					log.Error().Uint64("number", failure.Number).Str("hash", failure.Hash.Hex()).Str("reason", failure.Reason).Msg("Header failed verification")
+					failures = append(failures, failure)
+				} else {
+					verified++
+				}
+				parent = header
+			}
+		}
+
+		for signer, count := range signers {
+			log.Info().Str("signer", signer.Hex()).Uint64("blocks", count).Msg("Signer summary")
+		}
+		log.Info().Uint64("verified", verified).Int("failed", len(failures)).Msg("Done")
+
+		if len(failures) > 0 {
+			return fmt.Errorf("%d headers failed verification", len(failures))
+		}
+		return nil
+	},
+}
+
+func init() {
+	VerifyHeadersCmd.PersistentFlags().Uint64Var(&inputVerifyHeaders.From, "from", 0, "the first block of the range to verify")
+	VerifyHeadersCmd.PersistentFlags().Uint64Var(&inputVerifyHeaders.To, "to", 0, "the last block of the range to verify")
+	VerifyHeadersCmd.PersistentFlags().Uint64VarP(&inputVerifyHeaders.BatchSize, "batch-size", "b", 100, "the number of headers to fetch per batch request")
+	VerifyHeadersCmd.PersistentFlags().StringSliceVar(&inputVerifyHeaders.SignersStr, "signers", []string{}, "a list of expected signer addresses")
+	VerifyHeadersCmd.PersistentFlags().BoolVar(&inputVerifyHeaders.SkipSeal, "skip-seal", false, "don't verify the seal in the extra data")
+	VerifyHeadersCmd.PersistentFlags().BoolVar(&inputVerifyHeaders.Clique, "clique", false, "verify clique seals rather than bor seals")
+	VerifyHeadersCmd.PersistentFlags().Uint64Var(&inputVerifyHeaders.Sprint, "sprint", 16, "the bor sprint length, to check that only sprint end headers have validator bytes")
+	VerifyHeadersCmd.PersistentFlags().Uint64Var(&inputVerifyHeaders.Jaipur, "jaipur-block", 0, "the bor Jaipur block, from which the seals cover the base fee")
+}
+
+// sealSigner recovers the signer of the header with the clique or bor seal
+// hash.
+func sealSigner(header *types.Header) (ethcommon.Address, error) {
+	if inputVerifyHeaders.Clique {
+		return util.SealSigner(header)
+	}
+	return util.BorSealSigner(header, inputVerifyHeaders.Jaipur)
+}
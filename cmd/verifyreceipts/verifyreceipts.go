@@ -0,0 +1,302 @@
+package verifyreceipts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/maticnetwork/polygon-cli/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+type (
+	verifyReceiptsParams struct {
+		URL       string
+		From      uint64
+		To        uint64
+		BatchSize uint64
+	}
+
+	rpcBlock struct {
+		Hash         ethcommon.Hash `json:"hash"`
+		Transactions []struct {
+			Hash ethcommon.Hash     `json:"hash"`
+			From ethcommon.Address  `json:"from"`
+			To   *ethcommon.Address `json:"to"`
+		} `json:"transactions"`
+	}
+
+	rpcLog struct {
+		Address ethcommon.Address `json:"address"`
+		Topics  []ethcommon.Hash  `json:"topics"`
+		Data    hexutil.Bytes     `json:"data"`
+	}
+
+	// rpcReceipt has the fields of a receipt that are part of its consensus
+	// encoding. They are encoded here rather than using types.Receipt so that
+	// transaction types unknown to go-ethereum can be verified as well.
+	rpcReceipt struct {
+		TransactionHash   ethcommon.Hash  `json:"transactionHash"`
+		Type              hexutil.Uint64  `json:"type"`
+		Status            *hexutil.Uint64 `json:"status"`
+		Root              hexutil.Bytes   `json:"root"`
+		CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+		LogsBloom         types.Bloom     `json:"logsBloom"`
+		Logs              []rpcLog        `json:"logs"`
+	}
+
+	// encodedReceipts implements types.DerivableList to compute the receipts
+	// root.
+	encodedReceipts [][]byte
+
+	blockFailure struct {
+		Number uint64
+		Hash   ethcommon.Hash
+		Reason string
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage               string
+	inputVerifyReceipts verifyReceiptsParams
+)
+
+// VerifyReceiptsCmd represents the verify-receipts command
+var VerifyReceiptsCmd = &cobra.Command{
+	Use:   "verify-receipts url",
+	Short: "Recompute the receipts roots and logs blooms of blocks from the receipts returned by an RPC.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the rpc url")
+		}
+		if _, err := url.Parse(args[0]); err != nil {
+			return err
+		}
+		inputVerifyReceipts.URL = args[0]
+
+		if inputVerifyReceipts.To < inputVerifyReceipts.From {
+			return fmt.Errorf("the to block must be greater than or equal to the from block")
+		}
+		if inputVerifyReceipts.BatchSize == 0 {
+			return fmt.Errorf("the batch size must be greater than zero")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpc, err := util.DialRPC(ctx, inputVerifyReceipts.URL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		var (
+			failures []blockFailure
+			verified uint64
+			receipts uint64
+		)
+
+		for start := inputVerifyReceipts.From; start <= inputVerifyReceipts.To; start += inputVerifyReceipts.BatchSize {
+			end := start + inputVerifyReceipts.BatchSize - 1
+			if end > inputVerifyReceipts.To {
+				end = inputVerifyReceipts.To
+			}
+
+			log.Info().Uint64("start", start).Uint64("end", end).Msg("Getting range")
+			blocks, err := util.GetBlockRange(ctx, start, end, rpc)
+			if err != nil {
+				return err
+			}
+
+			for _, raw := range blocks {
+				header, block, err := parseBlock(*raw)
+				if err != nil {
+					return err
+				}
+
+				rs, err := getReceipts(ctx, rpc, block)
+				if err != nil {
+					return err
+				}
+				receipts += uint64(len(rs))
+
+				reasons, err := verifyBlock(header, block, rs)
+				if err != nil {
+					return err
+				}
+
+				if len(reasons) > 0 {
+					failure := blockFailure{Number: header.Number.Uint64(), Hash: block.Hash, Reason: strings.Join(reasons, "; ")}
+					log.Error().Uint64("number", failure.Number).Str("hash", failure.Hash.Hex()).Str("reason", failure.Reason).Msg("Receipts failed verification")
+					failures = append(failures, failure)
+				} else {
+					verified++
+				}
+			}
+		}
+
+		log.Info().Uint64("verified", verified).Int("failed", len(failures)).Uint64("receipts", receipts).Msg("Done")
+
+		if len(failures) > 0 {
+			return fmt.Errorf("the receipts of %d blocks failed verification", len(failures))
+		}
+		return nil
+	},
+}
+
+func init() {
+	VerifyReceiptsCmd.PersistentFlags().Uint64Var(&inputVerifyReceipts.From, "from", 0, "the first block of the range to verify")
+	VerifyReceiptsCmd.PersistentFlags().Uint64Var(&inputVerifyReceipts.To, "to", 0, "the last block of the range to verify")
+	VerifyReceiptsCmd.PersistentFlags().Uint64VarP(&inputVerifyReceipts.BatchSize, "batch-size", "b", 50, "the number of blocks to fetch per batch request")
+}
+
+func parseBlock(raw json.RawMessage) (*types.Header, *rpcBlock, error) {
+	block := new(rpcBlock)
+	if err := json.Unmarshal(raw, block); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode block: %w", err)
+	}
+
+	header := new(types.Header)
+	if err := json.Unmarshal(raw, header); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode header %s: %w", block.Hash.Hex(), err)
+	}
+	return header, block, nil
+}
+
+// getReceipts fetches the receipts of the transactions in the block, in the
+// same order as the transactions.
+func getReceipts(ctx context.Context, rpc *ethrpc.Client, block *rpcBlock) ([]*rpcReceipt, error) {
+	if len(block.Transactions) == 0 {
+		return nil, nil
+	}
+
+	receipts := make([]*rpcReceipt, len(block.Transactions))
+	elems := make([]ethrpc.BatchElem, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		receipts[i] = new(rpcReceipt)
+		elems[i] = ethrpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{tx.Hash},
+			Result: receipts[i],
+		}
+	}
+
+	// Some RPCs don't respond to batches with a single request correctly.
+	if len(elems) == 1 {
+		elems[0].Error = rpc.CallContext(ctx, elems[0].Result, elems[0].Method, elems[0].Args...)
+	} else if err := rpc.BatchCallContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("unable to get receipt of %s: %w", block.Transactions[i].Hash.Hex(), elem.Error)
+		}
+	}
+	return receipts, nil
+}
+
+// isStateSync returns whether the transaction is a bor state sync transaction.
+// Bor returns them with the block, but their receipts aren't part of the
+// receipts root.
+func isStateSync(from ethcommon.Address, to *ethcommon.Address) bool {
+	return from == (ethcommon.Address{}) && to != nil && *to == (ethcommon.Address{})
+}
+
+// verifyBlock recomputes the receipts root and logs bloom of the block and
+// returns the reasons the receipts don't match the header.
+func verifyBlock(header *types.Header, block *rpcBlock, receipts []*rpcReceipt) ([]string, error) {
+	var (
+		reasons []string
+		encoded encodedReceipts
+		bloom   types.Bloom
+	)
+
+	for i, r := range receipts {
+		tx := block.Transactions[i]
+		if r.TransactionHash != tx.Hash {
+			reasons = append(reasons, fmt.Sprintf("receipt %d is for transaction %s instead of %s", i, r.TransactionHash.Hex(), tx.Hash.Hex()))
+		}
+		if isStateSync(tx.From, tx.To) {
+			continue
+		}
+
+		logs := make([]*types.Log, 0, len(r.Logs))
+		for _, l := range r.Logs {
+			logs = append(logs, &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+		}
+		computed := types.BytesToBloom(types.LogsBloom(logs))
+		if computed != r.LogsBloom {
+			reasons = append(reasons, fmt.Sprintf("logs bloom of receipt %s doesn't match its logs", tx.Hash.Hex()))
+		}
+		for i := range bloom {
+			bloom[i] |= computed[i]
+		}
+
+		b, err := encodeReceipt(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode receipt %s: %w", tx.Hash.Hex(), err)
+		}
+		encoded = append(encoded, b)
+	}
+
+	if root := types.DeriveSha(encoded, trie.NewStackTrie(nil)); root != header.ReceiptHash {
+		reasons = append(reasons, fmt.Sprintf("receipts root %s doesn't match header %s", root.Hex(), header.ReceiptHash.Hex()))
+	}
+	if bloom != header.Bloom {
+		reasons = append(reasons, "logs bloom doesn't match header")
+	}
+	return reasons, nil
+}
+
+// encodeReceipt returns the consensus encoding of the receipt, which is the
+// type followed by the RLP list of the status, cumulative gas used, bloom and
+// logs.
+func encodeReceipt(r *rpcReceipt) ([]byte, error) {
+	var status []byte
+	switch {
+	case len(r.Root) > 0:
+		status = r.Root
+	case r.Status != nil && *r.Status == 1:
+		status = []byte{0x01}
+	default:
+		status = []byte{}
+	}
+
+	logs := make([][]interface{}, 0, len(r.Logs))
+	for _, l := range r.Logs {
+		topics := l.Topics
+		if topics == nil {
+			topics = []ethcommon.Hash{}
+		}
+		logs = append(logs, []interface{}{l.Address, topics, []byte(l.Data)})
+	}
+
+	payload, err := rlp.EncodeToBytes([]interface{}{status, uint64(r.CumulativeGasUsed), r.LogsBloom, logs})
+	if err != nil {
+		return nil, err
+	}
+	if r.Type == types.LegacyTxType {
+		return payload, nil
+	}
+	return append([]byte{byte(r.Type)}, payload...), nil
+}
+
+func (e encodedReceipts) Len() int { return len(e) }
+
+func (e encodedReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	w.Write(e[i])
+}
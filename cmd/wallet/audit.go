@@ -0,0 +1,379 @@
+package wallet
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// multicall3ABI has the two functions of Multicall3 used to batch the balance
+// lookups into a single call.
+const multicall3ABI = `[
+	{"name":"aggregate3","type":"function","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+	{"name":"getEthBalance","type":"function","stateMutability":"view","inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]}
+]`
+
+type (
+	auditParams struct {
+		File        string
+		RPCURL      string
+		Block       string
+		BatchSize   int
+		Concurrency int
+		Multicall   string
+		Output      string
+	}
+
+	// auditAccount is an address from the input file, which is either the
+	// address itself or derived from a private key.
+	auditAccount struct {
+		Address ethcommon.Address
+		FromKey bool
+		Balance *big.Int
+		Nonce   uint64
+		Code    int
+		Err     error
+	}
+
+	// multicall3Call and multicall3Result are the tuples of aggregate3.
+	multicall3Call struct {
+		Target       ethcommon.Address
+		AllowFailure bool
+		CallData     []byte
+	}
+	multicall3Result struct {
+		Success    bool
+		ReturnData []byte
+	}
+)
+
+var (
+	inputAudit auditParams
+
+	multicall3, _ = gethabi.JSON(strings.NewReader(multicall3ABI))
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report the balance, nonce, and code of a file of addresses or private keys as CSV.",
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if inputAudit.BatchSize < 1 {
+			return errors.New("batch size must be at least one")
+		}
+		if inputAudit.Concurrency < 1 {
+			return errors.New("concurrency must be at least one")
+		}
+		if inputAudit.File == "" && *inputMnemonic == "" && *inputMnemonicFile == "" {
+			return errors.New("either a file or a mnemonic is required")
+		}
+		if inputAudit.Multicall != "" && !ethcommon.IsHexAddress(inputAudit.Multicall) {
+			return fmt.Errorf("the multicall address %s isn't valid", inputAudit.Multicall)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		var accounts []*auditAccount
+		var err error
+		if inputAudit.File != "" {
+			accounts, err = readAuditAccounts(inputAudit.File)
+		} else {
+			accounts, err = deriveAuditAccounts()
+		}
+		if err != nil {
+			return err
+		}
+
+		rpc, err := util.DialRPC(ctx, inputAudit.RPCURL)
+		if err != nil {
+			return err
+		}
+		defer rpc.Close()
+
+		multicall, err := getMulticall(ctx, rpc)
+		if err != nil {
+			return err
+		}
+
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, inputAudit.Concurrency)
+		)
+		for start := 0; start < len(accounts); start += inputAudit.BatchSize {
+			end := start + inputAudit.BatchSize
+			if end > len(accounts) {
+				end = len(accounts)
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(batch []*auditAccount) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				if err := auditBatch(ctx, rpc, multicall, batch); err != nil {
+					for _, a := range batch {
+						a.Err = err
+					}
+				}
+			}(accounts[start:end])
+		}
+		wg.Wait()
+
+		out := os.Stdout
+		if inputAudit.Output != "" {
+			if out, err = os.Create(inputAudit.Output); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		return writeAudit(out, accounts)
+	},
+}
+
+func init() {
+	WalletCmd.AddCommand(auditCmd)
+
+	flagSet := auditCmd.Flags()
+	flagSet.StringVar(&inputAudit.File, "file", "", "A file with an address or hex encoded private key per line. Otherwise the addresses of --mnemonic are audited")
+	flagSet.StringVar(&inputAudit.RPCURL, "rpc-url", "http://localhost:8545", "The RPC endpoint url")
+	flagSet.StringVar(&inputAudit.Block, "block", "latest", "The block number, hash, or tag the accounts are audited at")
+	flagSet.IntVar(&inputAudit.BatchSize, "batch-size", 100, "The number of accounts looked up per RPC batch and multicall")
+	flagSet.IntVar(&inputAudit.Concurrency, "concurrency", 4, "The number of batches looked up in parallel")
+	flagSet.StringVar(&inputAudit.Multicall, "multicall", "0xcA11bde05977b3631167028862bE2a173976CA11", "The Multicall3 address used to batch balance lookups. Balances are looked up one by one if it's empty or has no code")
+	flagSet.StringVar(&inputAudit.Output, "output", "", "The CSV file to write. Otherwise it's written to stdout")
+}
+
+// readAuditAccounts reads the addresses and private keys of the file. Empty
+// lines and lines starting with # are skipped.
+func readAuditAccounts(file string) ([]*auditAccount, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var accounts []*auditAccount
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if ethcommon.IsHexAddress(text) {
+			accounts = append(accounts, &auditAccount{Address: ethcommon.HexToAddress(text)})
+			continue
+		}
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(text, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d is neither an address nor a private key", line)
+		}
+		accounts = append(accounts, &auditAccount{Address: crypto.PubkeyToAddress(key.PublicKey), FromKey: true})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no addresses or private keys found in %s", file)
+	}
+	return accounts, nil
+}
+
+// deriveAuditAccounts derives the accounts of the mnemonic with the wallet
+// flags, including the passphrase.
+func deriveAuditAccounts() ([]*auditAccount, error) {
+	mnemonic, err := getFileOrFlag(inputMnemonicFile, inputMnemonic)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := newWallet(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	key, err := pw.ExportHDAddresses(int(*inputAddressesToGenerate))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*auditAccount, 0, len(key.Addresses))
+	for _, a := range key.Addresses {
+		accounts = append(accounts, &auditAccount{Address: ethcommon.HexToAddress(a.ETHAddress), FromKey: true})
+	}
+	return accounts, nil
+}
+
+// getMulticall returns the Multicall3 address if it's deployed on the chain,
+// and nil otherwise.
+func getMulticall(ctx context.Context, rpc *ethrpc.Client) (*ethcommon.Address, error) {
+	if inputAudit.Multicall == "" {
+		return nil, nil
+	}
+	address := ethcommon.HexToAddress(inputAudit.Multicall)
+
+	var code hexutil.Bytes
+	if err := rpc.CallContext(ctx, &code, "eth_getCode", address, inputAudit.Block); err != nil {
+		return nil, fmt.Errorf("unable to get the code of the multicall contract: %w", err)
+	}
+	if len(code) == 0 {
+		log.Warn().Str("multicall", address.Hex()).Msg("The multicall contract isn't deployed, so balances are looked up one by one")
+		return nil, nil
+	}
+	return &address, nil
+}
+
+// auditBatch looks up the nonces and code of the accounts with an RPC batch,
+// and the balances with a multicall if there is one.
+func auditBatch(ctx context.Context, rpc *ethrpc.Client, multicall *ethcommon.Address, accounts []*auditAccount) error {
+	var (
+		elems    []ethrpc.BatchElem
+		nonces   = make([]hexutil.Uint64, len(accounts))
+		codes    = make([]hexutil.Bytes, len(accounts))
+		balances = make([]hexutil.Big, len(accounts))
+	)
+	for i, a := range accounts {
+		elems = append(elems,
+			ethrpc.BatchElem{Method: "eth_getTransactionCount", Args: []any{a.Address, inputAudit.Block}, Result: &nonces[i]},
+			ethrpc.BatchElem{Method: "eth_getCode", Args: []any{a.Address, inputAudit.Block}, Result: &codes[i]},
+		)
+		if multicall == nil {
+			elems = append(elems, ethrpc.BatchElem{Method: "eth_getBalance", Args: []any{a.Address, inputAudit.Block}, Result: &balances[i]})
+		}
+	}
+	if err := rpc.BatchCallContext(ctx, elems); err != nil {
+		return err
+	}
+
+	var multicallBalances []*big.Int
+	if multicall != nil {
+		var err error
+		if multicallBalances, err = getBalances(ctx, rpc, *multicall, accounts); err != nil {
+			return err
+		}
+	}
+
+	perAccount := len(elems) / len(accounts)
+	for i, a := range accounts {
+		for _, elem := range elems[i*perAccount : (i+1)*perAccount] {
+			if elem.Error != nil {
+				a.Err = fmt.Errorf("%s failed: %w", elem.Method, elem.Error)
+			}
+		}
+		a.Nonce = uint64(nonces[i])
+		a.Code = len(codes[i])
+		if multicallBalances != nil {
+			a.Balance = multicallBalances[i]
+			if a.Balance == nil {
+				a.Err = errors.New("getEthBalance failed")
+			}
+		} else {
+			a.Balance = balances[i].ToInt()
+		}
+	}
+	return nil
+}
+
+// getBalances looks up the balances of the accounts with a single call to
+// getEthBalance of Multicall3 per account batched with aggregate3.
+func getBalances(ctx context.Context, rpc *ethrpc.Client, multicall ethcommon.Address, accounts []*auditAccount) ([]*big.Int, error) {
+	calls := make([]multicall3Call, len(accounts))
+	for i, a := range accounts {
+		data, err := multicall3.Pack("getEthBalance", a.Address)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = multicall3Call{Target: multicall, CallData: data}
+	}
+	data, err := multicall3.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+
+	var out hexutil.Bytes
+	args := map[string]any{"to": multicall, "data": hexutil.Bytes(data)}
+	if err = rpc.CallContext(ctx, &out, "eth_call", args, inputAudit.Block); err != nil {
+		return nil, fmt.Errorf("the multicall failed: %w", err)
+	}
+
+	var results []multicall3Result
+	if err = multicall3.UnpackIntoInterface(&results, "aggregate3", out); err != nil {
+		return nil, fmt.Errorf("unable to decode the multicall result: %w", err)
+	}
+	if len(results) != len(accounts) {
+		return nil, fmt.Errorf("the multicall returned %d results for %d calls", len(results), len(accounts))
+	}
+
+	// The balances of the calls that failed are left nil.
+	balances := make([]*big.Int, len(results))
+	for i, r := range results {
+		if r.Success {
+			balances[i] = new(big.Int).SetBytes(r.ReturnData)
+		}
+	}
+	return balances, nil
+}
+
+func writeAudit(w io.Writer, accounts []*auditAccount) error {
+	var (
+		funded int
+		total  = new(big.Int)
+		failed int
+	)
+
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"address", "source", "balance", "nonce", "is_contract", "code_size", "error"}); err != nil {
+		return err
+	}
+	for _, a := range accounts {
+		source := "address"
+		if a.FromKey {
+			source = "key"
+		}
+		record := []string{a.Address.Hex(), source, "", "", "", "", ""}
+		if a.Err != nil {
+			failed++
+			record[6] = a.Err.Error()
+		} else {
+			if a.Balance.Sign() > 0 {
+				funded++
+				total.Add(total, a.Balance)
+			}
+			record[2] = a.Balance.String()
+			record[3] = strconv.FormatUint(a.Nonce, 10)
+			record[4] = strconv.FormatBool(a.Code > 0)
+			record[5] = strconv.Itoa(a.Code)
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+
+	log.Info().
+		Int("accounts", len(accounts)).
+		Int("funded", funded).
+		Str("total", total.String()).
+		Int("failed", failed).
+		Msg("Audited accounts")
+	return out.Error()
+}
@@ -0,0 +1,50 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var inputSeedPublicOnly bool
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Print the BIP-39 seed, BIP-32 root key, and account xpub of a mnemonic.",
+	Long: `Derive the BIP-39 seed of a mnemonic and passphrase, and print it along with
+the BIP-32 root key and the extended keys of the account at --path. With
+--public-only, only the root and account xpubs are printed, which can be shared
+to derive the addresses of the account without the private keys.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mnemonic, err := getFileOrFlag(inputMnemonicFile, inputMnemonic)
+		if err != nil {
+			return err
+		}
+		if mnemonic == "" {
+			return errors.New("a mnemonic is required")
+		}
+		pw, err := newWallet(mnemonic)
+		if err != nil {
+			return err
+		}
+
+		key, err := pw.ExportSeed()
+		if err != nil {
+			return err
+		}
+		if inputSeedPublicOnly {
+			key.Seed, key.RootKey, key.AccountPrivateKey = "", "", ""
+		}
+		out, _ := json.MarshalIndent(key, " ", " ")
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+func init() {
+	WalletCmd.AddCommand(seedCmd)
+	seedCmd.Flags().BoolVar(&inputSeedPublicOnly, "public-only", false, "Only print the extended public keys")
+}
@@ -0,0 +1,380 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// vanityBatchSize is the number of keys or salts a thread tries before
+	// the attempts are counted and the context is checked.
+	vanityBatchSize = 1 << 12
+	// vanityProgressInterval is how often the progress is logged and the
+	// checkpoint is written.
+	vanityProgressInterval = 5 * time.Second
+)
+
+type (
+	vanityParams struct {
+		Prefix        string
+		Suffix        string
+		CaseSensitive bool
+		Threads       int
+		Count         int
+		Checkpoint    string
+		Deployer      string
+		InitCode      string
+		InitCodeHash  string
+
+		prefix       string
+		suffix       string
+		deployer     ethcommon.Address
+		initCodeHash []byte
+	}
+
+	// vanityMatch is an address that matches the pattern. For CREATE2 salts
+	// the address is the one of the contract deployed with the salt.
+	vanityMatch struct {
+		Address    string `json:"address"`
+		PrivateKey string `json:"privateKey,omitempty"`
+		Deployer   string `json:"deployer,omitempty"`
+		Salt       string `json:"salt,omitempty"`
+	}
+
+	// vanityCheckpoint is the progress of a search, so that a search that was
+	// interrupted can be resumed. CREATE2 salts are the base followed by a
+	// counter, and the search resumes from the next counter.
+	vanityCheckpoint struct {
+		Prefix   string        `json:"prefix"`
+		Suffix   string        `json:"suffix"`
+		Attempts uint64        `json:"attempts"`
+		Matches  []vanityMatch `json:"matches"`
+		SaltBase hexutil.Bytes `json:"saltBase,omitempty"`
+		Next     uint64        `json:"next,omitempty"`
+	}
+
+	vanitySearch struct {
+		checkpoint vanityCheckpoint
+		attempts   atomic.Uint64
+		next       atomic.Uint64
+		found      chan vanityMatch
+		mu         sync.Mutex
+	}
+)
+
+var inputVanity vanityParams
+
+var vanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Grind private keys or CREATE2 salts for addresses that match a pattern.",
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkVanityFlags()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		s, err := newVanitySearch()
+		if err != nil {
+			return err
+		}
+
+		difficulty := vanityDifficulty(inputVanity.Prefix, inputVanity.Suffix, inputVanity.CaseSensitive)
+		log.Info().
+			Str("prefix", inputVanity.Prefix).
+			Str("suffix", inputVanity.Suffix).
+			Float64("difficulty", difficulty).
+			Int("threads", inputVanity.Threads).
+			Msg("Starting vanity address search")
+
+		var wg sync.WaitGroup
+		for i := 0; i < inputVanity.Threads; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if inputVanity.Deployer != "" {
+					s.grindSalts(ctx)
+				} else {
+					s.grindKeys(ctx)
+				}
+			}()
+		}
+
+		ticker := time.NewTicker(vanityProgressInterval)
+		defer ticker.Stop()
+		start, startAttempts := time.Now(), s.attempts.Load()
+		for len(s.checkpoint.Matches) < inputVanity.Count {
+			select {
+			case m := <-s.found:
+				s.addMatch(m)
+				out, _ := json.Marshal(m)
+				fmt.Println(string(out))
+			case <-ticker.C:
+				rate := float64(s.attempts.Load()-startAttempts) / time.Since(start).Seconds()
+				logVanityProgress(s.attempts.Load(), rate, difficulty)
+				s.save()
+			case <-ctx.Done():
+				// Keep the progress of interrupted searches.
+				cancel()
+				wg.Wait()
+				s.save()
+				return nil
+			}
+		}
+
+		cancel()
+		wg.Wait()
+		s.save()
+		return nil
+	},
+}
+
+func init() {
+	WalletCmd.AddCommand(vanityCmd)
+
+	flagSet := vanityCmd.Flags()
+	flagSet.StringVar(&inputVanity.Prefix, "prefix", "", "The hex characters the address should start with")
+	flagSet.StringVar(&inputVanity.Suffix, "suffix", "", "The hex characters the address should end with")
+	flagSet.BoolVar(&inputVanity.CaseSensitive, "case-sensitive", false, "Match the case of the EIP-55 checksum address")
+	flagSet.IntVar(&inputVanity.Threads, "threads", runtime.NumCPU(), "The number of threads to grind with")
+	flagSet.IntVar(&inputVanity.Count, "count", 1, "The number of matching addresses to find")
+	flagSet.StringVar(&inputVanity.Checkpoint, "checkpoint", "", "A file the progress is saved to and resumed from")
+	flagSet.StringVar(&inputVanity.Deployer, "deployer", "", "Grind CREATE2 salts for contracts deployed by this address rather than private keys")
+	flagSet.StringVar(&inputVanity.InitCode, "init-code", "", "The hex encoded init code of the contract when grinding CREATE2 salts")
+	flagSet.StringVar(&inputVanity.InitCodeHash, "init-code-hash", "", "The keccak256 hash of the init code of the contract when grinding CREATE2 salts")
+}
+
+func checkVanityFlags() error {
+	p := &inputVanity
+	p.Prefix = strings.TrimPrefix(p.Prefix, "0x")
+	if p.Prefix == "" && p.Suffix == "" {
+		return errors.New("a prefix or a suffix is required")
+	}
+	if len(p.Prefix)+len(p.Suffix) > 2*ethcommon.AddressLength {
+		return errors.New("the prefix and suffix are longer than an address")
+	}
+	for _, c := range p.Prefix + p.Suffix {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return fmt.Errorf("the prefix and suffix should be hex, got %q", c)
+		}
+	}
+	if !p.CaseSensitive {
+		p.Prefix = strings.ToLower(p.Prefix)
+		p.Suffix = strings.ToLower(p.Suffix)
+	}
+	p.prefix = strings.ToLower(p.Prefix)
+	p.suffix = strings.ToLower(p.Suffix)
+	if p.Threads < 1 {
+		return errors.New("threads must be at least one")
+	}
+	if p.Count < 1 {
+		return errors.New("count must be at least one")
+	}
+
+	if p.Deployer == "" {
+		if p.InitCode != "" || p.InitCodeHash != "" {
+			return errors.New("a deployer is required to grind CREATE2 salts")
+		}
+		return nil
+	}
+	if !ethcommon.IsHexAddress(p.Deployer) {
+		return fmt.Errorf("the deployer %s isn't a valid address", p.Deployer)
+	}
+	p.deployer = ethcommon.HexToAddress(p.Deployer)
+
+	switch {
+	case p.InitCodeHash != "":
+		hash, err := hexutil.Decode(p.InitCodeHash)
+		if err != nil || len(hash) != 32 {
+			return errors.New("the init code hash should be 32 hex encoded bytes")
+		}
+		p.initCodeHash = hash
+	case p.InitCode != "":
+		code, err := hexutil.Decode(p.InitCode)
+		if err != nil {
+			return fmt.Errorf("unable to decode init code: %w", err)
+		}
+		p.initCodeHash = crypto.Keccak256(code)
+	default:
+		return errors.New("either the init code or the init code hash is required to grind CREATE2 salts")
+	}
+	return nil
+}
+
+// vanityDifficulty returns the expected number of attempts to find a match.
+// Every hex character is one in 16, and when the case matters every letter is
+// also one in two since the checksum is random.
+func vanityDifficulty(prefix, suffix string, caseSensitive bool) float64 {
+	pattern := prefix + suffix
+	difficulty := math.Pow(16, float64(len(pattern)))
+	if caseSensitive {
+		for _, c := range pattern {
+			if (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+				difficulty *= 2
+			}
+		}
+	}
+	return difficulty
+}
+
+// logVanityProgress logs the attempts and the expected time to the next match.
+// Every attempt is independent, so the expected time doesn't depend on how
+// long the search has already run.
+func logVanityProgress(attempts uint64, rate, difficulty float64) {
+	probability := 1 - math.Pow(1-1/difficulty, float64(attempts))
+	event := log.Info().
+		Uint64("attempts", attempts).
+		Str("rate", fmt.Sprintf("%.0f/s", rate)).
+		Str("probability", fmt.Sprintf("%.2f%%", probability*100))
+	if rate > 0 {
+		event = event.Str("eta", time.Duration(difficulty/rate*float64(time.Second)).Truncate(time.Second).String())
+	}
+	event.Msg("Searching")
+}
+
+func newVanitySearch() (*vanitySearch, error) {
+	s := &vanitySearch{found: make(chan vanityMatch, inputVanity.Count)}
+	s.checkpoint.Prefix = inputVanity.Prefix
+	s.checkpoint.Suffix = inputVanity.Suffix
+
+	if inputVanity.Checkpoint != "" {
+		data, err := os.ReadFile(inputVanity.Checkpoint)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("unable to read checkpoint: %w", err)
+		default:
+			var cp vanityCheckpoint
+			if err = json.Unmarshal(data, &cp); err != nil {
+				return nil, fmt.Errorf("unable to parse checkpoint: %w", err)
+			}
+			if cp.Prefix != inputVanity.Prefix || cp.Suffix != inputVanity.Suffix {
+				return nil, fmt.Errorf("the checkpoint is for the prefix %q and suffix %q", cp.Prefix, cp.Suffix)
+			}
+			s.checkpoint = cp
+			log.Info().Uint64("attempts", cp.Attempts).Int("matches", len(cp.Matches)).Msg("Resuming from checkpoint")
+		}
+	}
+	s.attempts.Store(s.checkpoint.Attempts)
+	s.next.Store(s.checkpoint.Next)
+
+	if inputVanity.Deployer != "" && len(s.checkpoint.SaltBase) == 0 {
+		base := make([]byte, 24)
+		if _, err := rand.Read(base); err != nil {
+			return nil, err
+		}
+		s.checkpoint.SaltBase = base
+	}
+	return s, nil
+}
+
+func (s *vanitySearch) addMatch(m vanityMatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoint.Matches = append(s.checkpoint.Matches, m)
+}
+
+// save writes the checkpoint. Salt batches that were in progress are skipped
+// when resuming, which loses a little coverage but never repeats a salt.
+func (s *vanitySearch) save() {
+	if inputVanity.Checkpoint == "" {
+		return
+	}
+
+	s.mu.Lock()
+	s.checkpoint.Attempts = s.attempts.Load()
+	s.checkpoint.Next = s.next.Load()
+	data, err := json.MarshalIndent(s.checkpoint, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to encode checkpoint")
+		return
+	}
+
+	// The checkpoint has private keys, so it's only readable by the owner.
+	if err = os.WriteFile(inputVanity.Checkpoint, data, 0600); err != nil {
+		log.Error().Err(err).Msg("Unable to write checkpoint")
+	}
+}
+
+// matches returns whether the address matches the pattern. The lowercase hex
+// is checked first so the checksum is only computed for likely matches.
+func matches(address ethcommon.Address, buf []byte) bool {
+	hex.Encode(buf, address[:])
+	if !strings.HasPrefix(string(buf), inputVanity.prefix) || !strings.HasSuffix(string(buf), inputVanity.suffix) {
+		return false
+	}
+	if !inputVanity.CaseSensitive {
+		return true
+	}
+	checksum := address.Hex()[2:]
+	return strings.HasPrefix(checksum, inputVanity.Prefix) && strings.HasSuffix(checksum, inputVanity.Suffix)
+}
+
+func (s *vanitySearch) report(ctx context.Context, m vanityMatch) {
+	select {
+	case s.found <- m:
+	case <-ctx.Done():
+	}
+}
+
+func (s *vanitySearch) grindKeys(ctx context.Context) {
+	buf := make([]byte, 2*ethcommon.AddressLength)
+	for ctx.Err() == nil {
+		for i := 0; i < vanityBatchSize; i++ {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				log.Error().Err(err).Msg("Unable to generate key")
+				return
+			}
+			address := crypto.PubkeyToAddress(key.PublicKey)
+			if matches(address, buf) {
+				s.report(ctx, vanityMatch{
+					Address:    address.Hex(),
+					PrivateKey: hex.EncodeToString(crypto.FromECDSA(key)),
+				})
+			}
+		}
+		s.attempts.Add(vanityBatchSize)
+	}
+}
+
+func (s *vanitySearch) grindSalts(ctx context.Context) {
+	buf := make([]byte, 2*ethcommon.AddressLength)
+	var salt [32]byte
+	copy(salt[:], s.checkpoint.SaltBase)
+	for ctx.Err() == nil {
+		start := s.next.Add(vanityBatchSize) - vanityBatchSize
+		for i := start; i < start+vanityBatchSize; i++ {
+			binary.BigEndian.PutUint64(salt[24:], i)
+			address := crypto.CreateAddress2(inputVanity.deployer, salt, inputVanity.initCodeHash)
+			if matches(address, buf) {
+				s.report(ctx, vanityMatch{
+					Address:  address.Hex(),
+					Deployer: inputVanity.deployer.Hex(),
+					Salt:     hexutil.Encode(salt[:]),
+				})
+			}
+		}
+		s.attempts.Add(vanityBatchSize)
+	}
+}
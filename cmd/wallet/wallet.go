@@ -20,10 +20,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	_ "embed"
 
 	"github.com/maticnetwork/polygon-cli/hdwallet"
+	"github.com/maticnetwork/polygon-cli/util"
 	"github.com/spf13/cobra"
 )
 
@@ -67,23 +69,7 @@ var WalletCmd = &cobra.Command{
 		// mnemonic = "maid palace spring laptop shed when text taxi pupil movie athlete tag"
 		// mnemonic = "crop cash unable insane eight faith inflict route frame loud box vibrant"
 		// mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
-		password, err := getFileOrFlag(inputPasswordFile, inputPassword)
-		if err != nil {
-			return err
-		}
-		pw, err := hdwallet.NewPolyWallet(mnemonic, password)
-		if err != nil {
-			return err
-		}
-		err = pw.SetPath(*inputPath)
-		if err != nil {
-			return err
-		}
-		err = pw.SetIterations(*inputKDFIterations)
-		if err != nil {
-			return err
-		}
-		err = pw.SetUseRawEntropy(*inputUseRawEntropy)
+		pw, err := newWallet(mnemonic)
 		if err != nil {
 			return err
 		}
@@ -118,6 +104,29 @@ var WalletCmd = &cobra.Command{
 	},
 }
 
+// newWallet creates a wallet from the mnemonic with the BIP-39 passphrase,
+// derivation path, and KDF settings of the wallet flags.
+func newWallet(mnemonic string) (*hdwallet.PolyWallet, error) {
+	password, err := getFileOrFlag(inputPasswordFile, inputPassword)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := hdwallet.NewPolyWallet(strings.TrimSpace(mnemonic), password)
+	if err != nil {
+		return nil, err
+	}
+	if err = pw.SetPath(*inputPath); err != nil {
+		return nil, err
+	}
+	if err = pw.SetIterations(*inputKDFIterations); err != nil {
+		return nil, err
+	}
+	if err = pw.SetUseRawEntropy(*inputUseRawEntropy); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
 func getFileOrFlag(filename *string, flag *string) (string, error) {
 	if filename == nil && flag == nil {
 		return "", fmt.Errorf("both the filename and the flag pointers are nil")
@@ -127,10 +136,12 @@ func getFileOrFlag(filename *string, flag *string) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("could not open the specified file %s. Got error %s", *filename, err.Error())
 		}
-		return string(filedata), nil
+		// The trailing newline of the file isn't part of the value, which
+		// matters for passwords since any character changes the seed.
+		return strings.TrimRight(string(filedata), "\r\n"), nil
 	}
 	if flag != nil {
-		return *flag, nil
+		return util.ResolveSecret(*flag)
 	}
 	return "", fmt.Errorf("unable to determine flat or filename")
 }
@@ -145,9 +156,9 @@ func init() {
 	// 60 - ether
 	// 966 - matic
 	inputPath = WalletCmd.PersistentFlags().String("path", "m/44'/60'/0'", "What would you like the derivation path to be")
-	inputPassword = WalletCmd.PersistentFlags().String("password", "", "Password used along with the mnemonic")
+	inputPassword = WalletCmd.PersistentFlags().String("password", "", "BIP-39 passphrase used along with the mnemonic. "+util.SecretHelp)
 	inputPasswordFile = WalletCmd.PersistentFlags().String("password-file", "", "Password stored in a file used along with the mnemonic")
-	inputMnemonic = WalletCmd.PersistentFlags().String("mnemonic", "", "A mnemonic phrase used to generate entropy")
+	inputMnemonic = WalletCmd.PersistentFlags().String("mnemonic", "", "A mnemonic phrase used to generate entropy. "+util.SecretHelp)
 	inputMnemonicFile = WalletCmd.PersistentFlags().String("mnemonic-file", "", "A mneomonic phrase written in a file used to generate entropy")
 	inputUseRawEntropy = WalletCmd.PersistentFlags().Bool("raw-entropy", false, "substrate and polkda dot don't follow strict bip39 and use raw entropy")
 	inputRootOnly = WalletCmd.PersistentFlags().Bool("root-only", false, "don't produce HD accounts. Just produce a single wallet")
@@ -0,0 +1,543 @@
+package wsstress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_ "embed"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	subNewHeads            = "newHeads"
+	subLogs                = "logs"
+	subPendingTransactions = "newPendingTransactions"
+
+	// droppedGracePeriod excludes the notifications first seen at the end of
+	// the run from the dropped count, since the other connections might not
+	// have received them yet.
+	droppedGracePeriod = 5 * time.Second
+)
+
+type (
+	wsStressParams struct {
+		URL           string
+		Connections   int
+		Subscriptions []string
+		RampStep      int
+		RampInterval  uint64
+		Duration      uint64
+		LogsAddresses []string
+		LogsTopics    []string
+		Output        string
+	}
+
+	// event is a notification that was received by at least one connection.
+	event struct {
+		first     time.Time
+		receivers map[int]struct{}
+	}
+
+	connection struct {
+		id           int
+		subscribedAt time.Time
+		closedAt     time.Time
+		closed       bool
+	}
+
+	// tracker collects the notifications of all of the connections.
+	tracker struct {
+		mu          sync.Mutex
+		active      int64
+		events      map[string]map[string]*event
+		delays      map[string][]float64
+		stageDelays map[string]map[int64][]float64
+		headLatency []float64
+		connections []*connection
+		failed      int
+	}
+
+	latencyStats struct {
+		Count uint64  `json:"count"`
+		Mean  float64 `json:"meanMs"`
+		P50   float64 `json:"p50Ms"`
+		P90   float64 `json:"p90Ms"`
+		P99   float64 `json:"p99Ms"`
+		Max   float64 `json:"maxMs"`
+	}
+
+	stageStats struct {
+		Connections int64        `json:"connections"`
+		Delay       latencyStats `json:"delay"`
+	}
+
+	subscriptionStats struct {
+		Subscription  string        `json:"subscription"`
+		Events        int           `json:"events"`
+		Notifications uint64        `json:"notifications"`
+		Expected      uint64        `json:"expected"`
+		Dropped       uint64        `json:"dropped"`
+		DropRate      float64       `json:"dropRate"`
+		Delay         latencyStats  `json:"delay"`
+		HeadLatency   *latencyStats `json:"headLatency,omitempty"`
+		Stages        []stageStats  `json:"stages"`
+	}
+
+	report struct {
+		Connections  int                  `json:"connections"`
+		Failed       int                  `json:"failed"`
+		Disconnected int                  `json:"disconnected"`
+		Duration     float64              `json:"durationSeconds"`
+		Subscription []*subscriptionStats `json:"subscriptions"`
+	}
+
+	headNotification struct {
+		Hash      ethcommon.Hash `json:"hash"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+	}
+
+	logNotification struct {
+		TransactionHash ethcommon.Hash `json:"transactionHash"`
+		LogIndex        hexutil.Uint   `json:"logIndex"`
+		Removed         bool           `json:"removed"`
+	}
+)
+
+var (
+	//go:embed usage.md
+	usage         string
+	inputWSStress wsStressParams
+)
+
+// WSStressCmd represents the ws-stress command
+var WSStressCmd = &cobra.Command{
+	Use:   "ws-stress url",
+	Short: "Stress the subscriptions of a WebSocket RPC endpoint with many connections.",
+	Long:  usage,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected exactly one argument: the websocket rpc url")
+		}
+		u, err := url.Parse(args[0])
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			return fmt.Errorf("the rpc url must be a websocket url")
+		}
+		inputWSStress.URL = args[0]
+
+		if inputWSStress.Connections <= 0 {
+			return fmt.Errorf("the number of connections must be greater than zero")
+		}
+		if inputWSStress.RampStep < 0 {
+			return fmt.Errorf("the ramp step can't be negative")
+		}
+		if inputWSStress.Duration == 0 {
+			return fmt.Errorf("the duration must be greater than zero")
+		}
+		if len(inputWSStress.Subscriptions) == 0 {
+			return fmt.Errorf("at least one subscription is required")
+		}
+		for _, s := range inputWSStress.Subscriptions {
+			if !slices.Contains([]string{subNewHeads, subLogs, subPendingTransactions}, s) {
+				return fmt.Errorf("subscriptions must be one of [%s, %s, %s]", subNewHeads, subLogs, subPendingTransactions)
+			}
+		}
+		for _, a := range inputWSStress.LogsAddresses {
+			if !ethcommon.IsHexAddress(a) {
+				return fmt.Errorf("invalid logs address: %s", a)
+			}
+		}
+		if !slices.Contains([]string{"text", "json"}, inputWSStress.Output) {
+			return fmt.Errorf("output must be one of [text, json]")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		duration := time.Duration(inputWSStress.Duration) * time.Second
+		ctx, cancel := context.WithTimeout(cmd.Context(), duration)
+		defer cancel()
+
+		t := &tracker{
+			events:      make(map[string]map[string]*event),
+			delays:      make(map[string][]float64),
+			stageDelays: make(map[string]map[int64][]float64),
+		}
+		for _, s := range inputWSStress.Subscriptions {
+			t.events[s] = make(map[string]*event)
+			t.stageDelays[s] = make(map[int64][]float64)
+		}
+
+		start := time.Now()
+		step := inputWSStress.RampStep
+		if step == 0 {
+			step = inputWSStress.Connections
+		}
+
+		var wg sync.WaitGroup
+		for started := 0; started < inputWSStress.Connections && ctx.Err() == nil; {
+			end := started + step
+			if end > inputWSStress.Connections {
+				end = inputWSStress.Connections
+			}
+			for ; started < end; started++ {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					t.runConnection(ctx, id)
+				}(started)
+			}
+			log.Info().Int("connections", started).Msg("Opened connections")
+
+			if started < inputWSStress.Connections {
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Duration(inputWSStress.RampInterval) * time.Second):
+				}
+			}
+		}
+
+		<-ctx.Done()
+		wg.Wait()
+		if cmd.Context().Err() != nil {
+			return cmd.Context().Err()
+		}
+
+		r := t.report(time.Since(start))
+		if inputWSStress.Output == "json" {
+			out, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		r.print()
+		return nil
+	},
+}
+
+func init() {
+	WSStressCmd.PersistentFlags().IntVarP(&inputWSStress.Connections, "connections", "c", 10, "the number of websocket connections to open")
+	WSStressCmd.PersistentFlags().StringSliceVarP(&inputWSStress.Subscriptions, "subscriptions", "s", []string{subNewHeads}, "the subscriptions of each connection [newHeads, logs, newPendingTransactions]")
+	WSStressCmd.PersistentFlags().IntVar(&inputWSStress.RampStep, "ramp-step", 0, "the number of connections to open at a time, 0 opens them all at once")
+	WSStressCmd.PersistentFlags().Uint64Var(&inputWSStress.RampInterval, "ramp-interval", 30, "the number of seconds to wait between ramp steps")
+	WSStressCmd.PersistentFlags().Uint64VarP(&inputWSStress.Duration, "duration", "d", 60, "the number of seconds to run for, including the ramp")
+	WSStressCmd.PersistentFlags().StringSliceVar(&inputWSStress.LogsAddresses, "logs-address", nil, "only subscribe to the logs of these addresses")
+	WSStressCmd.PersistentFlags().StringSliceVar(&inputWSStress.LogsTopics, "logs-topic", nil, "only subscribe to logs with one of these first topics")
+	WSStressCmd.PersistentFlags().StringVarP(&inputWSStress.Output, "output", "o", "text", "the output format [text, json]")
+}
+
+// subscriptionArgs returns the arguments of eth_subscribe for the subscription.
+func subscriptionArgs(sub string) []interface{} {
+	if sub != subLogs {
+		return []interface{}{sub}
+	}
+	filter := make(map[string]interface{})
+	if len(inputWSStress.LogsAddresses) > 0 {
+		filter["address"] = inputWSStress.LogsAddresses
+	}
+	if len(inputWSStress.LogsTopics) > 0 {
+		filter["topics"] = [][]string{inputWSStress.LogsTopics}
+	}
+	return []interface{}{sub, filter}
+}
+
+// runConnection subscribes a new connection and tracks its notifications until
+// the context is done or the connection fails.
+func (t *tracker) runConnection(ctx context.Context, id int) {
+	client, err := ethrpc.DialContext(ctx, inputWSStress.URL)
+	if err != nil {
+		log.Error().Err(err).Int("connection", id).Msg("Unable to connect")
+		t.fail()
+		return
+	}
+	defer client.Close()
+
+	notifications := make(map[string]chan json.RawMessage, len(inputWSStress.Subscriptions))
+	subs := make([]*ethrpc.ClientSubscription, 0, len(inputWSStress.Subscriptions))
+	for _, s := range inputWSStress.Subscriptions {
+		ch := make(chan json.RawMessage, 256)
+		sub, err := client.EthSubscribe(ctx, ch, subscriptionArgs(s)...)
+		if err != nil {
+			log.Error().Err(err).Int("connection", id).Str("subscription", s).Msg("Unable to subscribe")
+			t.fail()
+			return
+		}
+		defer sub.Unsubscribe()
+		notifications[s] = ch
+		subs = append(subs, sub)
+	}
+
+	conn := t.open(id)
+	defer t.close(conn)
+
+	errs := make(chan error, len(subs))
+	for _, sub := range subs {
+		go func(sub *ethrpc.ClientSubscription) {
+			if err, ok := <-sub.Err(); ok && err != nil {
+				errs <- err
+			}
+		}(sub)
+	}
+
+	var wg sync.WaitGroup
+	for s, ch := range notifications {
+		wg.Add(1)
+		go func(s string, ch chan json.RawMessage) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case raw := <-ch:
+					t.observe(conn, s, raw, time.Now())
+				}
+			}
+		}(s, ch)
+	}
+
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		log.Error().Err(err).Int("connection", id).Msg("Subscription failed")
+		t.mu.Lock()
+		conn.closed = true
+		t.mu.Unlock()
+	}
+	wg.Wait()
+}
+
+func (t *tracker) fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed++
+}
+
+func (t *tracker) open(id int) *connection {
+	conn := &connection{id: id, subscribedAt: time.Now()}
+	atomic.AddInt64(&t.active, 1)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connections = append(t.connections, conn)
+	return conn
+}
+
+func (t *tracker) close(conn *connection) {
+	atomic.AddInt64(&t.active, -1)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conn.closedAt = time.Now()
+}
+
+// observe records a notification received by the connection.
+func (t *tracker) observe(conn *connection, sub string, raw json.RawMessage, received time.Time) {
+	key, timestamp, err := notificationKey(sub, raw)
+	if err != nil {
+		log.Warn().Err(err).Str("subscription", sub).Msg("Unable to decode notification")
+		return
+	}
+	if key == "" {
+		return
+	}
+	stage := atomic.LoadInt64(&t.active)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.events[sub][key]
+	if !ok {
+		e = &event{first: received, receivers: make(map[int]struct{})}
+		t.events[sub][key] = e
+	} else {
+		delay := float64(received.Sub(e.first).Microseconds()) / 1000
+		t.delays[sub] = append(t.delays[sub], delay)
+		t.stageDelays[sub][stage] = append(t.stageDelays[sub][stage], delay)
+	}
+	e.receivers[conn.id] = struct{}{}
+
+	if timestamp > 0 {
+		latency := float64(received.Sub(time.Unix(int64(timestamp), 0)).Microseconds()) / 1000
+		t.headLatency = append(t.headLatency, latency)
+	}
+}
+
+// notificationKey returns the identifier of the notification, along with the
+// block timestamp for new heads.
+func notificationKey(sub string, raw json.RawMessage) (string, uint64, error) {
+	switch sub {
+	case subNewHeads:
+		var h headNotification
+		if err := json.Unmarshal(raw, &h); err != nil {
+			return "", 0, err
+		}
+		return h.Hash.Hex(), uint64(h.Timestamp), nil
+	case subLogs:
+		var l logNotification
+		if err := json.Unmarshal(raw, &l); err != nil {
+			return "", 0, err
+		}
+		// Removed logs are only sent on reorgs, so they aren't compared.
+		if l.Removed {
+			return "", 0, nil
+		}
+		return fmt.Sprintf("%s:%d", l.TransactionHash.Hex(), l.LogIndex), 0, nil
+	default:
+		// Some providers send the full transactions instead of the hashes.
+		var hash ethcommon.Hash
+		if err := json.Unmarshal(raw, &hash); err == nil {
+			return hash.Hex(), 0, nil
+		}
+		var tx struct {
+			Hash ethcommon.Hash `json:"hash"`
+		}
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			return "", 0, err
+		}
+		return tx.Hash.Hex(), 0, nil
+	}
+}
+
+func newLatencyStats(values []float64) latencyStats {
+	if len(values) == 0 {
+		return latencyStats{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, v := range sorted {
+		total += v
+	}
+	percentile := func(p float64) float64 {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return latencyStats{
+		Count: uint64(len(sorted)),
+		Mean:  total / float64(len(sorted)),
+		P50:   percentile(0.5),
+		P90:   percentile(0.9),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+func (t *tracker) report(elapsed time.Duration) *report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := &report{
+		Connections: len(t.connections),
+		Failed:      t.failed,
+		Duration:    elapsed.Seconds(),
+	}
+	for _, c := range t.connections {
+		if c.closed {
+			r.Disconnected++
+		}
+	}
+
+	cutoff := time.Now().Add(-droppedGracePeriod)
+	for _, sub := range inputWSStress.Subscriptions {
+		s := &subscriptionStats{
+			Subscription: sub,
+			Events:       len(t.events[sub]),
+			Delay:        newLatencyStats(t.delays[sub]),
+		}
+		for _, e := range t.events[sub] {
+			s.Notifications += uint64(len(e.receivers))
+			if e.first.After(cutoff) {
+				continue
+			}
+			for _, c := range t.connections {
+				if e.first.Before(c.subscribedAt) || e.first.After(c.closedAt) {
+					continue
+				}
+				s.Expected++
+				if _, ok := e.receivers[c.id]; !ok {
+					s.Dropped++
+				}
+			}
+		}
+		if s.Expected > 0 {
+			s.DropRate = float64(s.Dropped) / float64(s.Expected)
+		}
+		if sub == subNewHeads {
+			headLatency := newLatencyStats(t.headLatency)
+			s.HeadLatency = &headLatency
+		}
+
+		stages := make([]int64, 0, len(t.stageDelays[sub]))
+		for stage := range t.stageDelays[sub] {
+			stages = append(stages, stage)
+		}
+		sort.Slice(stages, func(i, j int) bool { return stages[i] < stages[j] })
+		for _, stage := range stages {
+			s.Stages = append(s.Stages, stageStats{Connections: stage, Delay: newLatencyStats(t.stageDelays[sub][stage])})
+		}
+		r.Subscription = append(r.Subscription, s)
+	}
+	return r
+}
+
+func (r *report) print() {
+	fmt.Printf("Connections: %d opened, %d failed, %d disconnected over %.0fs\n", r.Connections, r.Failed, r.Disconnected, r.Duration)
+
+	st := table.NewWriter()
+	st.SetOutputMirror(os.Stdout)
+	st.AppendHeader(table.Row{"Subscription", "Events", "Notifications", "Dropped", "Drop Rate", "Delay p50 (ms)", "Delay p99 (ms)", "Delay Max (ms)", "Head Latency p50 (ms)"})
+	for _, s := range r.Subscription {
+		headLatency := "-"
+		if s.HeadLatency != nil && s.HeadLatency.Count > 0 {
+			headLatency = fmt.Sprintf("%.1f", s.HeadLatency.P50)
+		}
+		st.AppendRow(table.Row{
+			s.Subscription,
+			s.Events,
+			s.Notifications,
+			s.Dropped,
+			fmt.Sprintf("%.2f%%", s.DropRate*100),
+			fmt.Sprintf("%.1f", s.Delay.P50),
+			fmt.Sprintf("%.1f", s.Delay.P99),
+			fmt.Sprintf("%.1f", s.Delay.Max),
+			headLatency,
+		})
+	}
+	st.Render()
+
+	rt := table.NewWriter()
+	rt.SetOutputMirror(os.Stdout)
+	rt.SetTitle("Delay per ramp stage")
+	rt.AppendHeader(table.Row{"Subscription", "Connections", "Notifications", "Mean (ms)", "p50 (ms)", "p90 (ms)", "p99 (ms)", "Max (ms)"})
+	for _, s := range r.Subscription {
+		for _, stage := range s.Stages {
+			d := stage.Delay
+			rt.AppendRow(table.Row{
+				s.Subscription,
+				stage.Connections,
+				d.Count,
+				fmt.Sprintf("%.1f", d.Mean),
+				fmt.Sprintf("%.1f", d.P50),
+				fmt.Sprintf("%.1f", d.P90),
+				fmt.Sprintf("%.1f", d.P99),
+				fmt.Sprintf("%.1f", d.Max),
+			})
+		}
+	}
+	rt.Render()
+}
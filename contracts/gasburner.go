@@ -0,0 +1,63 @@
+package contracts
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// The gas burner is written in assembly so each loop only runs the opcode
+// being measured. The runtime code is prefixed with a deploy header like
+// asm/deploy-header.easm with the runtime length of 0xbc:
+// evm compile asm/gas-burner.easm
+
+//go:embed GasBurner.bin
+var RawGasBurnerBin string
+
+// GasBurnerOpcodes maps the names of the opcodes the gas burner can loop to
+// the selector passed in the first byte of the call data.
+var GasBurnerOpcodes = map[string]byte{
+	"sstore": 0x00,
+	"sload":  0x01,
+	"keccak": 0x02,
+	"call":   0x03,
+	"memory": 0x04,
+}
+
+// GasBurnerOpcodeNames returns the names of the opcodes in a stable order.
+func GasBurnerOpcodeNames() []string {
+	names := make([]string, 0, len(GasBurnerOpcodes))
+	for name := range GasBurnerOpcodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return GasBurnerOpcodes[names[i]] < GasBurnerOpcodes[names[j]]
+	})
+	return names
+}
+
+// DeployGasBurner deploys a new gas burner contract.
+func DeployGasBurner(opts *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *ethtypes.Transaction, *bind.BoundContract, error) {
+	return bind.DeployContract(opts, abi.ABI{}, common.FromHex(RawGasBurnerBin), backend)
+}
+
+// NewGasBurner binds an already deployed gas burner contract.
+func NewGasBurner(address common.Address, backend bind.ContractBackend) *bind.BoundContract {
+	return bind.NewBoundContract(address, abi.ABI{}, backend, backend, backend)
+}
+
+// BurnGas calls the gas burner to loop the opcode until the gas limit of the
+// transaction is nearly used up. The gas limit has to be set in the options
+// since estimating it would only give the cost of a single iteration.
+func BurnGas(burner *bind.BoundContract, opts *bind.TransactOpts, opcode string) (*ethtypes.Transaction, error) {
+	selector, ok := GasBurnerOpcodes[opcode]
+	if !ok {
+		return nil, fmt.Errorf("unknown gas burner opcode %s", opcode)
+	}
+	return burner.RawTransact(opts, []byte{selector})
+}
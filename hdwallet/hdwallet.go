@@ -38,6 +38,7 @@ type (
 	}
 	PolyWalletExport struct {
 		RootKey           string               `json:",omitempty"`
+		RootPublicKey     string               `json:",omitempty"`
 		Seed              string               `json:",omitempty"`
 		Mnemonic          string               `json:",omitempty"`
 		Passphrase        string               `json:",omitempty"`
@@ -297,6 +298,29 @@ func (p *PolyWallet) ExportRootAddress() (*PolyWalletExport, error) {
 	return pwe, nil
 }
 
+// ExportSeed exports the BIP-39 seed, the BIP-32 root key, and the extended
+// keys of the account at the derivation path without deriving any addresses.
+func (p *PolyWallet) ExportSeed() (*PolyWalletExport, error) {
+	pwe := new(PolyWalletExport)
+	pwe.Seed = hex.EncodeToString(p.rawSeed)
+	pwe.DerivationPath = p.derivationPath
+
+	rootKey, err := p.GetKeyForPath("m")
+	if err != nil {
+		return nil, err
+	}
+	pwe.RootKey = rootKey.String()
+	pwe.RootPublicKey = rootKey.PublicKey().String()
+
+	accountKey, err := p.GetKeyForPath(p.derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	pwe.AccountPrivateKey = accountKey.String()
+	pwe.AccountPublicKey = accountKey.PublicKey().String()
+	return pwe, nil
+}
+
 func (p *PolyWallet) ExportHDAddresses(count int) (*PolyWalletExport, error) {
 	pwe := new(PolyWalletExport)
 	pwe.Mnemonic = p.Mnemonic
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthInterval is how often the readiness of the gRPC health service is
+// updated.
+const healthInterval = 5 * time.Second
+
+// Health serves the liveness and readiness of a long-running command, for
+// Kubernetes probes and load balancers. The command is live as long as it
+// serves the endpoints, and ready when all of its checks pass.
+type Health struct {
+	mu     sync.Mutex
+	checks map[string]func() error
+}
+
+// NewHealth returns a Health without checks, which is ready.
+func NewHealth() *Health {
+	return &Health{checks: make(map[string]func() error)}
+}
+
+// AddCheck adds a readiness check, which returns why the command isn't ready.
+func (h *Health) AddCheck(name string, check func() error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Ready runs the checks and returns the errors of the ones that failed by
+// name, which is empty when the command is ready.
+func (h *Health) Ready() map[string]string {
+	h.mu.Lock()
+	checks := make(map[string]func() error, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.Unlock()
+
+	failed := make(map[string]string)
+	for name, check := range checks {
+		if err := check(); err != nil {
+			failed[name] = err.Error()
+		}
+	}
+	return failed
+}
+
+// Register adds /healthz for liveness and /readyz for readiness to the mux,
+// and the pprof endpoints under /debug/pprof/ if withPprof is set.
+func (h *Health) Register(mux *http.ServeMux, withPprof bool) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", h.serveReady)
+
+	if withPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+}
+
+// serveReady responds with the checks and whether they passed, with a 503
+// status if any of them failed.
+func (h *Health) serveReady(w http.ResponseWriter, r *http.Request) {
+	failed := h.Ready()
+
+	h.mu.Lock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+
+	checks := make(map[string]string, len(names))
+	for _, name := range names {
+		checks[name] = "ok"
+		if err, ok := failed[name]; ok {
+			checks[name] = err
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(failed) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ready":  len(failed) == 0,
+		"checks": checks,
+	})
+}
+
+// RegisterGRPC registers the standard gRPC health service on the server,
+// which has to be done before the server serves. The overall health and the
+// services are reported as serving while the checks pass, until the context
+// is done.
+func (h *Health) RegisterGRPC(ctx context.Context, server *grpc.Server, services ...string) {
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+
+	update := func() {
+		status := grpc_health_v1.HealthCheckResponse_SERVING
+		if len(h.Ready()) > 0 {
+			status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+		hs.SetServingStatus("", status)
+		for _, service := range services {
+			hs.SetServingStatus(service, status)
+		}
+	}
+	update()
+
+	go func() {
+		ticker := time.NewTicker(healthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				hs.Shutdown()
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+}
+
+// ServeHealth serves the health endpoints on the listener until the context
+// is done.
+func ServeHealth(ctx context.Context, ln net.Listener, h *Health, withPprof bool) {
+	mux := http.NewServeMux()
+	h.Register(mux, withPprof)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Failed to serve the health endpoints")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	log.Info().Str("address", ln.Addr().String()).Msg("Serving the health endpoints")
+}
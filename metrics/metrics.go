@@ -29,6 +29,16 @@ var (
 	UnitMegaether = new(big.Int).Mul(UnitGrand, big.NewInt(1000))    // | 1,000,000,000,000,000,000,000,000 | 10^24^ | | Megaether
 )
 
+const (
+	// elasticityMultiplier is the EIP-1559 parameter used to project the base
+	// fee.
+	elasticityMultiplier = 2
+	// DefaultBaseFeeChangeDenominator is the EIP-1559 base fee change
+	// denominator. Polygon PoS raised it to 16 in the Delhi hard fork and to
+	// 64 in the Bhilai hard fork.
+	DefaultBaseFeeChangeDenominator = 8
+)
+
 type (
 	SortableBlocks []rpctypes.PolyBlock
 )
@@ -125,38 +135,177 @@ func GetMeanGasPricePerBlock(blocks []rpctypes.PolyBlock) []float64 {
 	return gasPrices
 }
 
-func GetSimpleBlockRecords(blocks []rpctypes.PolyBlock) ([]string, string) {
-	bs := SortableBlocks(blocks)
-	sort.Sort(bs)
+// ProjectBaseFees returns the base fees of the next blocks following the
+// EIP-1559 rules with the base fee change denominator of the chain, assuming
+// that they're as full as the given block.
+func ProjectBaseFees(block rpctypes.PolyBlock, count int, denominator uint64) []*big.Int {
+	baseFee := block.BaseFee()
+	target := block.GasLimit() / elasticityMultiplier
+	if baseFee == nil || baseFee.Sign() == 0 || target == 0 || denominator == 0 {
+		return nil
+	}
 
-	// if we ever choose to utilize terminal width for column resizing
-	// width, _, err := term.GetSize(0)
-	// if err != nil {
-	// 	return []string{}
-	// }
+	gasUsed := new(big.Int).SetUint64(block.GasUsed())
+	gasTarget := new(big.Int).SetUint64(target)
+	projected := make([]*big.Int, 0, count)
+	for i := 0; i < count; i++ {
+		baseFee = nextBaseFee(baseFee, gasUsed, gasTarget, new(big.Int).SetUint64(denominator))
+		projected = append(projected, baseFee)
+	}
+	return projected
+}
 
-	headerVariables := []string{"Block #", "Timestamp", "Block Time", "Tx Count", "Gas Used", "Block Hash", "Author"}
+func nextBaseFee(baseFee, gasUsed, gasTarget, denominator *big.Int) *big.Int {
+	cmp := gasUsed.Cmp(gasTarget)
+	if cmp == 0 {
+		return new(big.Int).Set(baseFee)
+	}
 
-	proportion := []int{10, 20, 5, 5, 5, 60}
+	delta := new(big.Int).Sub(gasUsed, gasTarget)
+	delta.Abs(delta)
+	delta.Mul(delta, baseFee)
+	delta.Div(delta, gasTarget)
+	delta.Div(delta, denominator)
+	if cmp < 0 {
+		next := new(big.Int).Sub(baseFee, delta)
+		if next.Sign() < 0 {
+			next.SetInt64(0)
+		}
+		return next
+	}
+	// The base fee always increases by at least one wei when the block is
+	// above the target.
+	if delta.Sign() == 0 {
+		delta.SetInt64(1)
+	}
+	return new(big.Int).Add(baseFee, delta)
+}
 
-	header := ""
-	for i, prop := range proportion {
-		header += headerVariables[i] + strings.Repeat("─", prop)
+// GetGasUsedHistogram returns how many of the blocks used each share of their
+// gas limit, with buckets of equal size from 0 to 100%.
+func GetGasUsedHistogram(blocks []rpctypes.PolyBlock, buckets int) ([]float64, []string) {
+	counts := make([]float64, buckets)
+	labels := make([]string, buckets)
+	for i := range labels {
+		labels[i] = fmt.Sprintf("%d%%", i*100/buckets)
 	}
-	header += headerVariables[len(headerVariables)-1]
+	for _, b := range blocks {
+		if b.GasLimit() == 0 {
+			continue
+		}
+		i := int(b.GasUsed() * uint64(buckets) / b.GasLimit())
+		if i >= buckets {
+			i = buckets - 1
+		}
+		counts[i]++
+	}
+	return counts, labels
+}
 
-	if len(blocks) < 1 {
-		return nil, header
+// BlockColumn is a column of the block table.
+type BlockColumn struct {
+	Header  string
+	Padding int
+	Value   func(blocks SortableBlocks, j int, author ethcommon.Address) string
+}
+
+// BlockColumns are the columns of the block table that can be selected by
+// name.
+var BlockColumns = map[string]BlockColumn{
+	"number": {"Block #", 10, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return bs[j].Number().String()
+	}},
+	"timestamp": {"Timestamp", 20, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return time.Unix(int64(bs[j].Time()), 0).Format("02 Jan 06 15:04:05 MST")
+	}},
+	"block-time": {"Block Time", 5, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		if j == 0 {
+			return "-"
+		}
+		return strconv.FormatUint(bs[j].Time()-bs[j-1].Time(), 10)
+	}},
+	"txs": {"Tx Count", 5, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return strconv.Itoa(len(bs[j].Transactions()))
+	}},
+	"gas-used": {"Gas Used", 5, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return strconv.FormatUint(bs[j].GasUsed(), 10)
+	}},
+	"gas-limit": {"Gas Limit", 5, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return strconv.FormatUint(bs[j].GasLimit(), 10)
+	}},
+	"base-fee": {"Base Fee", 8, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		if bs[j].BaseFee() == nil {
+			return "-"
+		}
+		return bs[j].BaseFee().String()
+	}},
+	"size": {"Size", 5, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return strconv.FormatUint(bs[j].Size(), 10)
+	}},
+	"uncles": {"Uncles", 2, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return strconv.Itoa(len(bs[j].Uncles()))
+	}},
+	"hash": {"Block Hash", 60, func(bs SortableBlocks, j int, _ ethcommon.Address) string {
+		return bs[j].Hash().String()
+	}},
+	"author": {"Author", 0, func(_ SortableBlocks, _ int, author ethcommon.Address) string {
+		return author.String()
+	}},
+}
+
+// DefaultBlockColumns are the columns of the block table by default.
+var DefaultBlockColumns = []string{"number", "timestamp", "block-time", "txs", "gas-used", "hash", "author"}
+
+// BlockColumnNames returns the names of the block table columns in order.
+func BlockColumnNames() []string {
+	names := make([]string, 0, len(BlockColumns))
+	for name := range BlockColumns {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	isMined := true
+func GetSimpleBlockRecords(blocks []rpctypes.PolyBlock) ([]string, string) {
+	return GetBlockRecords(blocks, DefaultBlockColumns)
+}
+
+// GetBlockRecords returns the rows of the block table with the columns, newest
+// block first, and its header. Unknown columns are ignored.
+func GetBlockRecords(blocks []rpctypes.PolyBlock, columns []string) ([]string, string) {
+	bs := SortableBlocks(blocks)
+	sort.Sort(bs)
+
+	cols := make([]BlockColumn, 0, len(columns))
+	for _, name := range columns {
+		if col, ok := BlockColumns[name]; ok {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, ""
+	}
 
-	if blocks[0].Miner().String() == "0x0000000000000000000000000000000000000000" {
+	isMined := true
+	if len(blocks) > 0 && blocks[0].Miner().String() == "0x0000000000000000000000000000000000000000" {
 		isMined = false
 	}
 
-	if !isMined {
-		header = strings.Replace(header, "Author", "Signer", 1)
+	header := ""
+	for i, col := range cols {
+		label := col.Header
+		if label == "Author" && !isMined {
+			label = "Signer"
+		}
+		if i == len(cols)-1 {
+			header += label
+			break
+		}
+		header += label + strings.Repeat("─", col.Padding)
+	}
+
+	if len(blocks) < 1 {
+		return nil, header
 	}
 
 	// Set the first row to blank so that there is some space between the blocks
@@ -165,48 +314,54 @@ func GetSimpleBlockRecords(blocks []rpctypes.PolyBlock) ([]string, string) {
 
 	for j := len(bs) - 1; j >= 0; j = j - 1 {
 		author := bs[j].Miner()
-		ts := bs[j].Time()
-		ut := time.Unix(int64(ts), 0)
 		if !isMined {
 			signer, err := ecrecover(&bs[j])
 			if err == nil {
 				author = ethcommon.HexToAddress("0x" + hex.EncodeToString(signer))
 			}
 		}
-		blockTime := "-"
-		if j > 0 {
-			blockTime = strconv.FormatUint(bs[j].Time()-bs[j-1].Time(), 10)
-		}
-
-		recordVariables := []string{
-			fmt.Sprintf("%d", bs[j].Number()),
-			ut.Format("02 Jan 06 15:04:05 MST"),
-			blockTime,
-			fmt.Sprintf("%d", len(bs[j].Transactions())),
-			fmt.Sprintf("%d", bs[j].GasUsed()),
-			bs[j].Hash().String(),
-			author.String(),
-		}
 
 		record := " "
-		for i := 0; i < len(recordVariables)-1; i++ {
-			record += recordVariables[i] + strings.Repeat(" ", len(headerVariables[i])+proportion[i]-len(recordVariables[i]))
+		for i, col := range cols {
+			value := col.Value(bs, j, author)
+			if i == len(cols)-1 {
+				record += value
+				break
+			}
+			pad := len(col.Header) + col.Padding - len(value)
+			if pad < 1 {
+				pad = 1
+			}
+			record += value + strings.Repeat(" ", pad)
 		}
-		record += recordVariables[len(recordVariables)-1]
 
 		records = append(records, record)
 	}
 	return records, header
 }
 
-func GetSimpleBlockFields(block rpctypes.PolyBlock) []string {
+// AddressNamer returns the name of an address, or an empty string if it
+// doesn't have one.
+type AddressNamer func(ethcommon.Address) string
+
+// formatAddress appends the name of the address, if there's one.
+func formatAddress(address ethcommon.Address, namer AddressNamer) string {
+	if namer != nil {
+		if name := namer(address); name != "" {
+			return fmt.Sprintf("%s (%s)", address, name)
+		}
+	}
+	return address.String()
+}
+
+func GetSimpleBlockFields(block rpctypes.PolyBlock, namer AddressNamer) []string {
 	ts := block.Time()
 	ut := time.Unix(int64(ts), 0)
 
 	author := "Mined  by"
 
-	authorAddress := block.Miner().String()
-	if authorAddress == "0x0000000000000000000000000000000000000000" {
+	authorAddress := formatAddress(block.Miner(), namer)
+	if block.Miner() == (ethcommon.Address{}) {
 		author = "Signed by"
 		signer, err := ecrecover(&block)
 		if err == nil {
@@ -236,17 +391,17 @@ func GetSimpleBlockFields(block rpctypes.PolyBlock) []string {
 		fmt.Sprintf("Nonce:        %d", block.Nonce()),
 	}
 }
-func GetSimpleBlockTxFields(block rpctypes.PolyBlock, chainID *big.Int) []string {
+func GetSimpleBlockTxFields(block rpctypes.PolyBlock, chainID *big.Int, namer AddressNamer) []string {
 	fields := make([]string, 0)
 	blank := ""
 	for _, tx := range block.Transactions() {
-		txFields := GetSimpleTxFields(tx, chainID, block.BaseFee())
+		txFields := GetSimpleTxFields(tx, chainID, block.BaseFee(), namer)
 		fields = append(fields, blank)
 		fields = append(fields, txFields...)
 	}
 	return fields
 }
-func GetSimpleTxFields(tx rpctypes.PolyTransaction, chainID, baseFee *big.Int) []string {
+func GetSimpleTxFields(tx rpctypes.PolyTransaction, chainID, baseFee *big.Int, namer AddressNamer) []string {
 	fields := make([]string, 0)
 	fields = append(fields, fmt.Sprintf("Tx Hash: %s", tx.Hash()))
 
@@ -259,8 +414,8 @@ func GetSimpleTxFields(tx rpctypes.PolyTransaction, chainID, baseFee *big.Int) [
 		txMethod = hex.EncodeToString(tx.Data()[0:4])
 	}
 
-	fields = append(fields, fmt.Sprintf("To: %s", tx.To()))
-	fields = append(fields, fmt.Sprintf("From: %s", tx.From()))
+	fields = append(fields, fmt.Sprintf("To: %s", formatAddress(tx.To(), namer)))
+	fields = append(fields, fmt.Sprintf("From: %s", formatAddress(tx.From(), namer)))
 	fields = append(fields, fmt.Sprintf("Method: %s", txMethod))
 	fields = append(fields, fmt.Sprintf("Value: %s", tx.Value()))
 	fields = append(fields, fmt.Sprintf("Gas Limit: %d", tx.Gas()))
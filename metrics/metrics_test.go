@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+func testBlock(t *testing.T, number, gasLimit, gasUsed uint64, baseFee *big.Int) rpctypes.PolyBlock {
+	raw := fmt.Sprintf(`{"number":"%#x","gasLimit":"%#x","gasUsed":"%#x","baseFeePerGas":"%#x"}`, number, gasLimit, gasUsed, baseFee)
+	var r rpctypes.RawBlockResponse
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		t.Fatal(err)
+	}
+	return rpctypes.NewPolyBlock(&r)
+}
+
+// The projection with the Ethereum denominator matches the base fees that
+// geth calculates for blocks as full as the first one.
+func TestProjectBaseFeesGeth(t *testing.T) {
+	for _, gasUsed := range []uint64{0, 1, 7500000, 15000000, 15000001, 22500000, 30000000} {
+		t.Run(fmt.Sprint(gasUsed), func(t *testing.T) {
+			header := &ethtypes.Header{
+				Number:   big.NewInt(20000000),
+				GasLimit: 30000000,
+				GasUsed:  gasUsed,
+				BaseFee:  big.NewInt(30000000000),
+			}
+			block := testBlock(t, header.Number.Uint64(), header.GasLimit, header.GasUsed, header.BaseFee)
+			projected := ProjectBaseFees(block, 5, DefaultBaseFeeChangeDenominator)
+			if len(projected) != 5 {
+				t.Fatalf("expected 5 base fees, got %d", len(projected))
+			}
+			for i, got := range projected {
+				want := misc.CalcBaseFee(params.MainnetChainConfig, header)
+				if got.Cmp(want) != 0 {
+					t.Errorf("expected the base fee %d to be %s, got %s", i, want, got)
+				}
+				header = &ethtypes.Header{
+					Number:   new(big.Int).Add(header.Number, big.NewInt(1)),
+					GasLimit: header.GasLimit,
+					GasUsed:  header.GasUsed,
+					BaseFee:  want,
+				}
+			}
+		})
+	}
+}
+
+func TestProjectBaseFees(t *testing.T) {
+	tests := []struct {
+		name        string
+		gasUsed     uint64
+		baseFee     int64
+		denominator uint64
+		want        []int64
+	}{
+		{
+			name:        "delhi full blocks",
+			gasUsed:     30000000,
+			baseFee:     30000000000,
+			denominator: 16,
+			want:        []int64{31875000000, 33867187500, 35983886718},
+		},
+		{
+			name:        "bhilai full blocks",
+			gasUsed:     30000000,
+			baseFee:     30000000000,
+			denominator: 64,
+			want:        []int64{30468750000, 30944824218, 31428337096},
+		},
+		{
+			name:        "bhilai empty blocks",
+			gasUsed:     0,
+			baseFee:     30000000000,
+			denominator: 64,
+			want:        []int64{29531250000, 29069824219, 28615608216},
+		},
+		{
+			name:        "bhilai blocks on target",
+			gasUsed:     15000000,
+			baseFee:     30000000000,
+			denominator: 64,
+			want:        []int64{30000000000, 30000000000, 30000000000},
+		},
+		{
+			name:        "bhilai minimum increase",
+			gasUsed:     15000001,
+			baseFee:     7,
+			denominator: 64,
+			want:        []int64{8, 9, 10},
+		},
+		{
+			name:        "no denominator",
+			gasUsed:     30000000,
+			baseFee:     30000000000,
+			denominator: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			block := testBlock(t, 80000000, 30000000, tc.gasUsed, big.NewInt(tc.baseFee))
+			projected := ProjectBaseFees(block, 3, tc.denominator)
+			if len(projected) != len(tc.want) {
+				t.Fatalf("expected %d base fees, got %d", len(tc.want), len(projected))
+			}
+			for i, got := range projected {
+				if got.Int64() != tc.want[i] {
+					t.Errorf("expected the base fee %d to be %d, got %s", i, tc.want[i], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SinkFormatInflux writes the points using the Influx line protocol, which
+	// is also accepted by VictoriaMetrics on its /write endpoint.
+	SinkFormatInflux = "influx"
+	// SinkFormatRemoteWrite writes the points using the Prometheus remote write
+	// protocol.
+	SinkFormatRemoteWrite = "remote-write"
+
+	// maxBufferedPoints limits the memory used when the sink can't be reached.
+	// The oldest points are dropped first.
+	maxBufferedPoints = 100000
+)
+
+// Point is a set of measurements taken at the same time. For the remote write
+// format every field becomes a series named after the point and the field.
+type Point struct {
+	Name   string
+	Tags   map[string]string
+	Fields map[string]float64
+	Time   time.Time
+}
+
+// SinkOptions configures a Sink.
+type SinkOptions struct {
+	// URL is the write endpoint, for example http://localhost:8086/write?db=polycli
+	// for InfluxDB or http://localhost:8428/api/v1/write for VictoriaMetrics
+	// remote write. Credentials in the URL are sent using basic auth.
+	URL string
+	// Format is either SinkFormatInflux or SinkFormatRemoteWrite.
+	Format string
+	// Interval is how often the buffered points are written.
+	Interval time.Duration
+	// Tags are added to every point, for example to tell sensors apart.
+	Tags map[string]string
+}
+
+// Sink buffers points and periodically writes them to a time-series database.
+// A nil Sink discards all points, so callers don't have to check whether a
+// sink was configured.
+type Sink struct {
+	opts   SinkOptions
+	url    string
+	user   *url.Userinfo
+	client *http.Client
+	encode func([]Point) ([]byte, http.Header, error)
+
+	points []Point
+	mu     sync.Mutex
+}
+
+// NewSink validates the options and creates a sink. Run needs to be called for
+// the points to be written.
+func NewSink(opts SinkOptions) (*Sink, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("sink url must be http or https")
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("sink interval must be positive")
+	}
+
+	s := &Sink{
+		opts:   opts,
+		user:   u.User,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	u.User = nil
+	s.url = u.String()
+
+	switch opts.Format {
+	case SinkFormatInflux:
+		s.encode = encodeInflux
+	case SinkFormatRemoteWrite:
+		s.encode = encodeRemoteWrite
+	default:
+		return nil, fmt.Errorf("unknown sink format %s, expected %s or %s", opts.Format, SinkFormatInflux, SinkFormatRemoteWrite)
+	}
+
+	return s, nil
+}
+
+// Emit queues the points to be written. Points without a time are given the
+// current time. It never blocks on the database.
+func (s *Sink) Emit(points ...Point) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range points {
+		if p.Time.IsZero() {
+			p.Time = now
+		}
+		if len(s.opts.Tags) > 0 {
+			tags := make(map[string]string, len(s.opts.Tags)+len(p.Tags))
+			for k, v := range s.opts.Tags {
+				tags[k] = v
+			}
+			for k, v := range p.Tags {
+				tags[k] = v
+			}
+			p.Tags = tags
+		}
+		s.points = append(s.points, p)
+	}
+
+	if dropped := len(s.points) - maxBufferedPoints; dropped > 0 {
+		log.Warn().Int("dropped", dropped).Msg("Metrics sink buffer is full, dropping oldest points")
+		s.points = append(s.points[:0], s.points[dropped:]...)
+	}
+}
+
+// Run writes the buffered points every interval until the context is
+// cancelled, and then writes the remaining points.
+func (s *Sink) Run(ctx context.Context) {
+	if s == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to write metrics")
+			}
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+			if err := s.Flush(flushCtx); err != nil {
+				log.Error().Err(err).Msg("Failed to write metrics")
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// Flush writes the buffered points. The points are kept for the next flush if
+// the write fails.
+func (s *Sink) Flush(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	points := s.points
+	s.points = nil
+	s.mu.Unlock()
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	err := s.write(ctx, points)
+	if err != nil {
+		s.mu.Lock()
+		s.points = append(points, s.points...)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *Sink) write(ctx context.Context, points []Point) error {
+	body, header, err := s.encode(points)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if s.user != nil {
+		password, _ := s.user.Password()
+		req.SetBasicAuth(s.user.Username(), password)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sink responded with %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	log.Trace().Int("points", len(points)).Msg("Wrote metrics")
+	return nil
+}
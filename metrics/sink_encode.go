@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeInflux encodes the points using the line protocol with nanosecond
+// precision.
+func encodeInflux(points []Point) ([]byte, http.Header, error) {
+	var b bytes.Buffer
+	for _, p := range points {
+		if len(p.Fields) == 0 {
+			continue
+		}
+
+		b.WriteString(influxMeasurementEscaper.Replace(p.Name))
+		for _, k := range sortedKeys(p.Tags) {
+			if p.Tags[k] == "" {
+				continue
+			}
+			b.WriteByte(',')
+			b.WriteString(influxKeyEscaper.Replace(k))
+			b.WriteByte('=')
+			b.WriteString(influxKeyEscaper.Replace(p.Tags[k]))
+		}
+
+		sep := byte(' ')
+		for _, k := range sortedKeys(p.Fields) {
+			b.WriteByte(sep)
+			b.WriteString(influxKeyEscaper.Replace(k))
+			b.WriteByte('=')
+			b.WriteString(strconv.FormatFloat(p.Fields[k], 'g', -1, 64))
+			sep = ','
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(p.Time.UnixNano(), 10))
+		b.WriteByte('\n')
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	return b.Bytes(), header, nil
+}
+
+// encodeRemoteWrite encodes the points as a snappy compressed WriteRequest.
+// The protobuf messages are small enough that they are encoded by hand rather
+// than pulling in the Prometheus module:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeRemoteWrite(points []Point) ([]byte, http.Header, error) {
+	var req []byte
+	for _, p := range points {
+		tags := sortedKeys(p.Tags)
+		for _, field := range sortedKeys(p.Fields) {
+			var series []byte
+
+			// Labels have to be sorted by name, and __name__ sorts before
+			// any valid label name.
+			series = appendLabel(series, "__name__", p.Name+"_"+field)
+			for _, k := range tags {
+				if p.Tags[k] != "" {
+					series = appendLabel(series, k, p.Tags[k])
+				}
+			}
+
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(p.Fields[field]))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(p.Time.UnixMilli()))
+
+			series = protowire.AppendTag(series, 2, protowire.BytesType)
+			series = protowire.AppendBytes(series, sample)
+
+			req = protowire.AppendTag(req, 1, protowire.BytesType)
+			req = protowire.AppendBytes(req, series)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-protobuf")
+	header.Set("Content-Encoding", "snappy")
+	header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	return snappy.Encode(nil, req), header, nil
+}
+
+func appendLabel(b []byte, name, value string) []byte {
+	var label []byte
+	label = protowire.AppendTag(label, 1, protowire.BytesType)
+	label = protowire.AppendString(label, name)
+	label = protowire.AppendTag(label, 2, protowire.BytesType)
+	label = protowire.AppendString(label, value)
+
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, label)
+}
@@ -0,0 +1,436 @@
+package p2p
+
+import (
+	"container/list"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultDialTimeout      = 20 * time.Second
+	DefaultHandshakeTimeout = 20 * time.Second
+	DefaultRequestTimeout   = 20 * time.Second
+)
+
+var (
+	// DefaultCaps are the capabilities advertised in the hello message if none
+	// are configured.
+	DefaultCaps = []p2p.Cap{
+		{Name: "eth", Version: 66},
+	}
+
+	// SnapCaps are the capabilities required to make snap requests.
+	SnapCaps = []p2p.Cap{
+		{Name: "eth", Version: 66},
+		{Name: "snap", Version: 1},
+	}
+)
+
+// ClientConfig configures a Client. Zero values are replaced by the defaults.
+type ClientConfig struct {
+	// Key is the node key used for the encryption handshake. A new key is
+	// generated for every outbound connection if it's nil. Accepting inbound
+	// connections requires a key.
+	Key *ecdsa.PrivateKey
+	// Caps are the capabilities advertised in the hello message.
+	Caps []p2p.Cap
+	// DialTimeout limits establishing the TCP connection.
+	DialTimeout time.Duration
+	// HandshakeTimeout limits the encryption handshake, and separately the
+	// protocol handshake and status exchange.
+	HandshakeTimeout time.Duration
+	// RequestTimeout limits each request that doesn't have a context deadline.
+	RequestTimeout time.Duration
+	// Logger is used for the connection logs. The global logger is used if nil.
+	Logger *zerolog.Logger
+}
+
+// PeerTimings are how long each phase of establishing a connection took, which
+// tells the network round trip apart from the cost of the handshakes. Phases
+// that weren't performed are zero.
+type PeerTimings struct {
+	// Dial is the TCP connection, which is about one network round trip.
+	Dial time.Duration
+	// Encryption is the ECIES handshake of RLPx, which is a round trip along
+	// with the key agreement on both sides.
+	Encryption time.Duration
+	// Hello is the devp2p protocol handshake.
+	Hello time.Duration
+	// Status is the eth status exchange.
+	Status time.Duration
+}
+
+// Total returns the time of all the phases.
+func (t PeerTimings) Total() time.Duration {
+	return t.Dial + t.Encryption + t.Hello + t.Status
+}
+
+// Client establishes devp2p connections. It holds no state other than its
+// configuration so it's safe to use concurrently.
+type Client struct {
+	cfg ClientConfig
+}
+
+// NewClient creates a client with the given configuration.
+func NewClient(cfg ClientConfig) *Client {
+	if len(cfg.Caps) == 0 {
+		cfg.Caps = DefaultCaps
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	if cfg.HandshakeTimeout == 0 {
+		cfg.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = &log.Logger
+	}
+	return &Client{cfg: cfg}
+}
+
+// newConn wraps the rlpx connection with the client configuration.
+func (c *Client) newConn(conn *rlpx.Conn, key *ecdsa.PrivateKey) *Conn {
+	return &Conn{
+		Conn:             conn,
+		ourKey:           key,
+		caps:             c.cfg.Caps,
+		handshakeTimeout: c.cfg.HandshakeTimeout,
+		requestTimeout:   c.cfg.RequestTimeout,
+		requests:         list.New(),
+		requestNum:       0,
+	}
+}
+
+// Dial connects to the node and performs the encryption handshake.
+func (c *Client) Dial(ctx context.Context, n *enode.Node) (*Conn, error) {
+	var err error
+	key := c.cfg.Key
+	if key == nil {
+		if key, err = crypto.GenerateKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Dual-stack nodes are dialed on IPv6 if IPv4 fails.
+	addrs := TCPEndpoints(n)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("node has no TCP endpoint")
+	}
+	dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+	var (
+		fd    net.Conn
+		start time.Time
+	)
+	for _, addr := range addrs {
+		start = time.Now()
+		if fd, err = dialer.DialContext(ctx, "tcp", addr.String()); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := c.newConn(rlpx.NewConn(fd, n.Pubkey()), key)
+	conn.node = n
+	conn.logger = c.cfg.Logger.With().Str("peer", n.URLv4()).Logger()
+	conn.timings.Dial = time.Since(start)
+
+	done, err := conn.deadline(ctx, c.cfg.HandshakeTimeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer done()
+
+	start = time.Now()
+	if _, err = conn.Handshake(conn.ourKey); err != nil {
+		conn.Close()
+		return nil, contextError(ctx, err)
+	}
+	conn.timings.Encryption = time.Since(start)
+
+	return conn, nil
+}
+
+// Accept performs the receiving side of the encryption handshake on an inbound
+// connection. The client key must be the same key that is advertised in the
+// node record, otherwise the remote peer will reject the handshake.
+func (c *Client) Accept(ctx context.Context, fd net.Conn) (*Conn, error) {
+	if c.cfg.Key == nil {
+		fd.Close()
+		return nil, fmt.Errorf("a node key is required to accept connections")
+	}
+
+	conn := c.newConn(rlpx.NewConn(fd, nil), c.cfg.Key)
+
+	done, err := conn.deadline(ctx, c.cfg.HandshakeTimeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	pub, err := conn.Handshake(conn.ourKey)
+	if err != nil {
+		conn.Close()
+		return nil, contextError(ctx, err)
+	}
+	conn.timings.Encryption = time.Since(start)
+
+	addr, _ := fd.RemoteAddr().(*net.TCPAddr)
+	if addr == nil {
+		addr = &net.TCPAddr{}
+	}
+	conn.node = enode.NewV4(pub, addr.IP, addr.Port, 0)
+	conn.logger = c.cfg.Logger.With().Str("peer", conn.node.URLv4()).Logger()
+
+	return conn, nil
+}
+
+// Peer dials the node and performs the protocol handshake and status exchange.
+// The connection is closed if peering fails.
+func (c *Client) Peer(ctx context.Context, n *enode.Node) (*Conn, *Hello, *Status, error) {
+	conn, err := c.Dial(ctx, n)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	hello, status, err := conn.PeerContext(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, hello, nil, err
+	}
+
+	return conn, hello, status, nil
+}
+
+// deadline sets the connection deadline to the earlier of the context deadline
+// and the timeout, and interrupts pending reads and writes if the context is
+// cancelled. The returned function must be called to clear the deadline.
+func (c *Conn) deadline(ctx context.Context, timeout time.Duration) (func(), error) {
+	d := time.Now().Add(timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(d) {
+		d = dl
+	}
+	if err := c.SetDeadline(d); err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	return func() {
+		close(stop)
+		_ = c.SetDeadline(time.Time{})
+	}, nil
+}
+
+// contextError returns the context error instead of the resulting i/o timeout
+// if the context was cancelled.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// PeerContext performs both the protocol handshake and the status message
+// exchange with the node.
+func (c *Conn) PeerContext(ctx context.Context) (*Hello, *Status, error) {
+	done, err := c.deadline(ctx, c.handshakeTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	hello, err := c.handshake()
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("handshake failed: %w", contextError(ctx, err))
+	}
+	c.timings.Hello = time.Since(start)
+
+	if done, err = c.deadline(ctx, c.handshakeTimeout); err != nil {
+		return hello, nil, err
+	}
+	defer done()
+	start = time.Now()
+	status, err := c.statusExchange()
+	if err != nil {
+		return hello, nil, fmt.Errorf("status exchange failed: %w", contextError(ctx, err))
+	}
+	c.timings.Status = time.Since(start)
+
+	return hello, status, nil
+}
+
+// Timings returns how long the phases of establishing the connection took so
+// far.
+func (c *Conn) Timings() PeerTimings {
+	return c.timings
+}
+
+// request writes the request and reads until the response with the same
+// request ID is received. Pings are answered and other messages are dropped
+// while waiting.
+func (c *Conn) request(ctx context.Context, req Message, code int) (Message, error) {
+	done, err := c.deadline(ctx, c.requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	if err := c.Write(req); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	for {
+		switch msg := c.Read().(type) {
+		case *Error:
+			return nil, contextError(ctx, msg.Unwrap())
+		case *Disconnect, *Disconnects:
+			return nil, disconnectError(msg)
+		case *Ping:
+			if err := c.Write(&Pong{}); err != nil {
+				return nil, contextError(ctx, err)
+			}
+		default:
+			if msg.Code() == code && msg.ReqID() == req.ReqID() {
+				return msg, nil
+			}
+		}
+	}
+}
+
+// Ping sends a devp2p ping to the peer and returns how long it took to receive
+// the pong. Pings from the peer are answered and other messages are dropped
+// while waiting.
+func (c *Conn) Ping(ctx context.Context) (time.Duration, error) {
+	done, err := c.deadline(ctx, c.requestTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	start := time.Now()
+	if err := c.Write(&Ping{}); err != nil {
+		return 0, contextError(ctx, err)
+	}
+
+	for {
+		switch msg := c.Read().(type) {
+		case *Pong:
+			return time.Since(start), nil
+		case *Error:
+			return 0, contextError(ctx, msg.Unwrap())
+		case *Disconnect, *Disconnects:
+			return 0, disconnectError(msg)
+		case *Ping:
+			if err := c.Write(&Pong{}); err != nil {
+				return 0, contextError(ctx, err)
+			}
+		}
+	}
+}
+
+// RequestHeaders requests block headers from the peer.
+func (c *Conn) RequestHeaders(ctx context.Context, query *eth.GetBlockHeadersPacket) ([]*types.Header, error) {
+	req := &GetBlockHeaders{
+		RequestId:             rand.Uint64(),
+		GetBlockHeadersPacket: query,
+	}
+	msg, err := c.request(ctx, req, (BlockHeaders{}).Code())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*BlockHeaders).BlockHeadersPacket, nil
+}
+
+// RequestBodies requests the block bodies of the given block hashes from the
+// peer.
+func (c *Conn) RequestBodies(ctx context.Context, hashes []common.Hash) ([]*eth.BlockBody, error) {
+	req := &GetBlockBodies{
+		RequestId:            rand.Uint64(),
+		GetBlockBodiesPacket: hashes,
+	}
+	msg, err := c.request(ctx, req, (BlockBodies{}).Code())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*BlockBodies).BlockBodiesPacket, nil
+}
+
+// RequestPooledTransactions requests the transactions with the given hashes
+// from the peer's transaction pool.
+func (c *Conn) RequestPooledTransactions(ctx context.Context, hashes []common.Hash) ([]*types.Transaction, error) {
+	req := &GetPooledTransactions{
+		RequestId:                   rand.Uint64(),
+		GetPooledTransactionsPacket: hashes,
+	}
+	msg, err := c.request(ctx, req, (PooledTransactions{}).Code())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*PooledTransactions).PooledTransactionsPacket, nil
+}
+
+// RequestAccountRange requests the accounts of the state trie with the given
+// root, starting at the origin hash, along with the boundary proofs. The peer
+// needs to support snap, see SnapCaps.
+func (c *Conn) RequestAccountRange(ctx context.Context, root, origin, limit common.Hash, bytes uint64) (*AccountRange, error) {
+	req := &GetAccountRange{
+		ID:     rand.Uint64(),
+		Root:   root,
+		Origin: origin,
+		Limit:  limit,
+		Bytes:  bytes,
+	}
+	msg, err := c.request(ctx, req, (AccountRange{}).Code())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*AccountRange), nil
+}
+
+// RequestStorageRanges requests the storage slots of the accounts in the state
+// trie with the given root, along with the boundary proofs. The peer needs to
+// support snap, see SnapCaps.
+func (c *Conn) RequestStorageRanges(ctx context.Context, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) (*StorageRanges, error) {
+	req := &GetStorageRanges{
+		ID:       rand.Uint64(),
+		Root:     root,
+		Accounts: accounts,
+		Origin:   origin,
+		Limit:    limit,
+		Bytes:    bytes,
+	}
+	msg, err := c.request(ctx, req, (StorageRanges{}).Code())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*StorageRanges), nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/rs/zerolog/log"
 )
 
@@ -32,6 +33,13 @@ type Datastore struct {
 	shouldWriteBlockEvents       bool
 	shouldWriteTransactions      bool
 	shouldWriteTransactionEvents bool
+
+	// The recently written hashes of each kind, which are skipped when written
+	// again. They are nil if deduplication is disabled.
+	writtenTxs     *lru.Cache[common.Hash, struct{}]
+	writtenBlocks  *lru.Cache[common.Hash, struct{}]
+	writtenBodies  *lru.Cache[common.Hash, struct{}]
+	writtenHeaders *lru.Cache[common.Hash, struct{}]
 }
 
 // DatastoreEvent can represent a peer sending the sensor a transaction hash or
@@ -101,6 +109,11 @@ type DatastoreOptions struct {
 	ShouldWriteBlockEvents       bool
 	ShouldWriteTransactions      bool
 	ShouldWriteTransactionEvents bool
+	// DedupeWindow is the number of recently written hashes of each kind that
+	// are remembered, so the same transaction or block received from many
+	// peers is only written once. The events are still written for every
+	// peer. Deduplication is disabled if it's zero.
+	DedupeWindow int
 }
 
 // NewDatastore connects to datastore and creates the client. This should
@@ -112,7 +125,7 @@ func NewDatastore(ctx context.Context, opts DatastoreOptions) Database {
 		return nil
 	}
 
-	return &Datastore{
+	d := &Datastore{
 		client:                       client,
 		sensorID:                     opts.SensorID,
 		maxConcurrentWrites:          opts.MaxConcurrentWrites,
@@ -121,6 +134,27 @@ func NewDatastore(ctx context.Context, opts DatastoreOptions) Database {
 		shouldWriteTransactions:      opts.ShouldWriteTransactions,
 		shouldWriteTransactionEvents: opts.ShouldWriteTransactionEvents,
 	}
+
+	if opts.DedupeWindow > 0 {
+		// The size is positive so creating the caches can't fail.
+		d.writtenTxs, _ = lru.New[common.Hash, struct{}](opts.DedupeWindow)
+		d.writtenBlocks, _ = lru.New[common.Hash, struct{}](opts.DedupeWindow)
+		d.writtenBodies, _ = lru.New[common.Hash, struct{}](opts.DedupeWindow)
+		d.writtenHeaders, _ = lru.New[common.Hash, struct{}](opts.DedupeWindow)
+	}
+
+	return d
+}
+
+// firstWrite returns whether the hash wasn't recently written, and marks it as
+// written. The hash is marked before the write happens so concurrent writes of
+// the same hash are skipped too.
+func firstWrite(written *lru.Cache[common.Hash, struct{}], hash common.Hash) bool {
+	if written == nil {
+		return true
+	}
+	ok, _ := written.ContainsOrAdd(hash, struct{}{})
+	return !ok
 }
 
 // WriteBlock writes the block and the block event to datastore.
@@ -129,7 +163,7 @@ func (d *Datastore) WriteBlock(ctx context.Context, peer *enode.Node, block *typ
 		d.writeEvent(peer, blockEventsKind, block.Hash(), blocksKind)
 	}
 
-	if !d.ShouldWriteBlocks() {
+	if !d.ShouldWriteBlocks() || !firstWrite(d.writtenBlocks, block.Hash()) {
 		return
 	}
 
@@ -207,7 +241,7 @@ func (d *Datastore) WriteBlockHeaders(ctx context.Context, headers []*types.Head
 // instead. It will write the uncles and transactions to datastore if they
 // don't already exist.
 func (d *Datastore) WriteBlockBody(ctx context.Context, body *eth.BlockBody, hash common.Hash) {
-	if !d.ShouldWriteBlocks() {
+	if !d.ShouldWriteBlocks() || !firstWrite(d.writtenBodies, hash) {
 		return
 	}
 
@@ -405,6 +439,10 @@ func (d *Datastore) writeEvents(ctx context.Context, peer *enode.Node, eventKind
 // writeBlockHeader will write the block header to datastore if it doesn't
 // exist.
 func (d *Datastore) writeBlockHeader(ctx context.Context, header *types.Header) {
+	if !firstWrite(d.writtenHeaders, header.Hash()) {
+		return
+	}
+
 	key := datastore.NameKey(blocksKind, header.Hash().Hex(), nil)
 
 	_, err := d.client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
@@ -424,16 +462,23 @@ func (d *Datastore) writeBlockHeader(ctx context.Context, header *types.Header)
 }
 
 // writeTransactions will write the transactions to datastore and return the
-// transaction hashes.
+// transaction hashes. Recently written transactions are skipped.
 func (d *Datastore) writeTransactions(ctx context.Context, txs []*types.Transaction) {
 	keys := make([]*datastore.Key, 0, len(txs))
 	transactions := make([]*DatastoreTransaction, 0, len(txs))
 
 	for _, tx := range txs {
+		if !firstWrite(d.writtenTxs, tx.Hash()) {
+			continue
+		}
 		keys = append(keys, datastore.NameKey(transactionsKind, tx.Hash().Hex(), nil))
 		transactions = append(transactions, newDatastoreTransaction(tx))
 	}
 
+	if len(keys) == 0 {
+		return
+	}
+
 	if _, err := d.client.PutMulti(ctx, keys, transactions); err != nil {
 		log.Error().Err(err).Msg("Failed to write transactions")
 	}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DatastoreReader queries the data written by the sensors.
+type DatastoreReader struct {
+	client *datastore.Client
+}
+
+// NewDatastoreReader connects to the datastore of the project.
+func NewDatastoreReader(ctx context.Context, projectID string) (*DatastoreReader, error) {
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &DatastoreReader{client: client}, nil
+}
+
+// Close closes the datastore client.
+func (r *DatastoreReader) Close() error {
+	return r.client.Close()
+}
+
+// BlocksByNumber returns the blocks with the given number keyed by their hash.
+// There can be more than one block if sensors saw reorgs or uncles.
+func (r *DatastoreReader) BlocksByNumber(ctx context.Context, number uint64) (map[common.Hash]*DatastoreBlock, error) {
+	var blocks []*DatastoreBlock
+	query := datastore.NewQuery(blocksKind).FilterField("Number", "=", strconv.FormatUint(number, 10))
+	keys, err := r.client.GetAll(ctx, query, &blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[common.Hash]*DatastoreBlock, len(keys))
+	for i, key := range keys {
+		m[common.HexToHash(key.Name)] = blocks[i]
+	}
+	return m, nil
+}
+
+// FirstBlockEvent returns when the block hash was first received by a sensor.
+// The time is zero if it was never received. Events of all sensors are used if
+// the sensor ID is empty.
+func (r *DatastoreReader) FirstBlockEvent(ctx context.Context, hash common.Hash, sensorID string) (time.Time, error) {
+	return r.firstEvent(ctx, blockEventsKind, datastore.NameKey(blocksKind, hash.Hex(), nil), sensorID)
+}
+
+// FirstTransactionEvent returns when the transaction hash was first received
+// by a sensor. The time is zero if it was never received. Events of all
+// sensors are used if the sensor ID is empty.
+func (r *DatastoreReader) FirstTransactionEvent(ctx context.Context, key *datastore.Key, sensorID string) (time.Time, error) {
+	return r.firstEvent(ctx, transactionEventsKind, key, sensorID)
+}
+
+// firstEvent returns the earliest event of the hash. The events aren't sorted
+// by the query because that would require a composite index.
+func (r *DatastoreReader) firstEvent(ctx context.Context, kind string, hash *datastore.Key, sensorID string) (time.Time, error) {
+	query := datastore.NewQuery(kind).FilterField("Hash", "=", hash)
+	if sensorID != "" {
+		query = query.FilterField("SensorId", "=", sensorID)
+	}
+
+	var events []*DatastoreEvent
+	if _, err := r.client.GetAll(ctx, query, &events); err != nil {
+		return time.Time{}, err
+	}
+
+	var first time.Time
+	for _, event := range events {
+		if first.IsZero() || event.Time.Before(first) {
+			first = event.Time
+		}
+	}
+	return first, nil
+}
+
+// Header converts the stored header back to a types.Header. This is the
+// inverse of newDatastoreHeader.
+func (h *DatastoreHeader) Header() (*types.Header, error) {
+	header := &types.Header{
+		ParentHash:  common.HexToHash(h.ParentHash.Name),
+		UncleHash:   common.HexToHash(h.UncleHash),
+		Coinbase:    common.HexToAddress(h.Coinbase),
+		Root:        common.HexToHash(h.Root),
+		TxHash:      common.HexToHash(h.TxHash),
+		ReceiptHash: common.HexToHash(h.ReceiptHash),
+		Bloom:       types.BytesToBloom(h.Bloom),
+		Time:        uint64(h.Time.Unix()),
+		Extra:       h.Extra,
+		MixDigest:   common.HexToHash(h.MixDigest),
+	}
+
+	var ok bool
+	if header.Difficulty, ok = new(big.Int).SetString(h.Difficulty, 10); !ok {
+		return nil, fmt.Errorf("invalid difficulty %s", h.Difficulty)
+	}
+	if header.Number, ok = new(big.Int).SetString(h.Number, 10); !ok {
+		return nil, fmt.Errorf("invalid number %s", h.Number)
+	}
+	if h.BaseFee != "<nil>" {
+		if header.BaseFee, ok = new(big.Int).SetString(h.BaseFee, 10); !ok {
+			return nil, fmt.Errorf("invalid base fee %s", h.BaseFee)
+		}
+	}
+
+	var err error
+	if header.GasLimit, err = strconv.ParseUint(h.GasLimit, 10, 64); err != nil {
+		return nil, err
+	}
+	if header.GasUsed, err = strconv.ParseUint(h.GasUsed, 10, 64); err != nil {
+		return nil, err
+	}
+	nonce, err := strconv.ParseUint(h.Nonce, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	header.Nonce = types.EncodeNonce(nonce)
+
+	return header, nil
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"golang.org/x/time/rate"
+)
+
+// These are the message types that can be sampled and rate limited. They
+// correspond to the Database write methods.
+const (
+	MessageBlocks       = "blocks"
+	MessageBlockHeaders = "block_headers"
+	MessageBlockBodies  = "block_bodies"
+	MessageBlockHashes  = "block_hashes"
+	MessageTransactions = "transactions"
+)
+
+// MessageTypes lists the message types in a stable order.
+var MessageTypes = []string{
+	MessageBlocks,
+	MessageBlockHeaders,
+	MessageBlockBodies,
+	MessageBlockHashes,
+	MessageTransactions,
+}
+
+// SamplerOptions is used when creating a NewSampler.
+type SamplerOptions struct {
+	// Rates are the fractions of the items of each message type that are
+	// written, between 0 and 1. Message types without a rate are all written.
+	Rates map[string]float64
+	// Limits are the maximum number of items of each message type that are
+	// written per second. Message types without a limit aren't limited.
+	Limits map[string]float64
+}
+
+// Dropped counts the items of a message type that weren't written.
+type Dropped struct {
+	Sampled uint64
+	Limited uint64
+}
+
+// Sampler wraps a database and only writes a sample of the items, and no more
+// than the rate limits allow. Items are sampled by their hash, so the same
+// transactions are kept across peers and sensors with the same rate.
+type Sampler struct {
+	Database
+
+	thresholds map[string]uint64
+	limiters   map[string]*rate.Limiter
+	sampled    map[string]*uint64
+	limited    map[string]*uint64
+}
+
+// NewSampler wraps the database with the sampling rates and limits.
+func NewSampler(db Database, opts SamplerOptions) (*Sampler, error) {
+	s := &Sampler{
+		Database:   db,
+		thresholds: make(map[string]uint64),
+		limiters:   make(map[string]*rate.Limiter),
+		sampled:    make(map[string]*uint64),
+		limited:    make(map[string]*uint64),
+	}
+	for _, t := range MessageTypes {
+		s.sampled[t] = new(uint64)
+		s.limited[t] = new(uint64)
+	}
+
+	for t, r := range opts.Rates {
+		if _, ok := s.sampled[t]; !ok {
+			return nil, fmt.Errorf("unknown message type %s", t)
+		}
+		if r < 0 || r > 1 {
+			return nil, fmt.Errorf("sample rate of %s must be between 0 and 1", t)
+		}
+		if r < 1 {
+			s.thresholds[t] = uint64(r * math.MaxUint64)
+		}
+	}
+
+	for t, l := range opts.Limits {
+		if _, ok := s.limited[t]; !ok {
+			return nil, fmt.Errorf("unknown message type %s", t)
+		}
+		if l <= 0 {
+			return nil, fmt.Errorf("rate limit of %s must be positive", t)
+		}
+		burst := int(l)
+		if burst < 1 {
+			burst = 1
+		}
+		s.limiters[t] = rate.NewLimiter(rate.Limit(l), burst)
+	}
+
+	return s, nil
+}
+
+// Dropped returns the number of items that weren't written since the sampler
+// was created, keyed by message type.
+func (s *Sampler) Dropped() map[string]Dropped {
+	dropped := make(map[string]Dropped, len(MessageTypes))
+	for _, t := range MessageTypes {
+		dropped[t] = Dropped{
+			Sampled: atomic.LoadUint64(s.sampled[t]),
+			Limited: atomic.LoadUint64(s.limited[t]),
+		}
+	}
+	return dropped
+}
+
+// keep returns whether the item with the hash should be written.
+func (s *Sampler) keep(t string, hash common.Hash) bool {
+	if threshold, ok := s.thresholds[t]; ok && binary.BigEndian.Uint64(hash[:8]) >= threshold {
+		atomic.AddUint64(s.sampled[t], 1)
+		return false
+	}
+	if limiter, ok := s.limiters[t]; ok && !limiter.Allow() {
+		atomic.AddUint64(s.limited[t], 1)
+		return false
+	}
+	return true
+}
+
+func (s *Sampler) WriteBlock(ctx context.Context, peer *enode.Node, block *types.Block, td *big.Int) {
+	if s.keep(MessageBlocks, block.Hash()) {
+		s.Database.WriteBlock(ctx, peer, block, td)
+	}
+}
+
+func (s *Sampler) WriteBlockHeaders(ctx context.Context, headers []*types.Header) {
+	kept := make([]*types.Header, 0, len(headers))
+	for _, h := range headers {
+		if s.keep(MessageBlockHeaders, h.Hash()) {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) > 0 {
+		s.Database.WriteBlockHeaders(ctx, kept)
+	}
+}
+
+func (s *Sampler) WriteBlockHashes(ctx context.Context, peer *enode.Node, hashes []common.Hash) {
+	kept := make([]common.Hash, 0, len(hashes))
+	for _, h := range hashes {
+		if s.keep(MessageBlockHashes, h) {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) > 0 {
+		s.Database.WriteBlockHashes(ctx, peer, kept)
+	}
+}
+
+func (s *Sampler) WriteBlockBody(ctx context.Context, body *eth.BlockBody, hash common.Hash) {
+	if s.keep(MessageBlockBodies, hash) {
+		s.Database.WriteBlockBody(ctx, body, hash)
+	}
+}
+
+func (s *Sampler) WriteTransactions(ctx context.Context, peer *enode.Node, txs []*types.Transaction) {
+	kept := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if s.keep(MessageTransactions, tx.Hash()) {
+			kept = append(kept, tx)
+		}
+	}
+	if len(kept) > 0 {
+		s.Database.WriteTransactions(ctx, peer, kept)
+	}
+}
@@ -0,0 +1,21 @@
+// Package p2p implements a minimal devp2p client for the eth protocol, along
+// with the node set and ENR helpers used by the p2p commands.
+//
+// A Client dials and peers with nodes. It doesn't hold any global state, so
+// several clients with different configurations can be used at the same time:
+//
+//	client := p2p.NewClient(p2p.ClientConfig{RequestTimeout: 5 * time.Second})
+//	conn, hello, status, err := client.Peer(ctx, node)
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//
+//	headers, err := conn.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+//		Origin: eth.HashOrNumber{Hash: status.Head},
+//		Amount: 1,
+//	})
+//
+// Every request is bounded by the context deadline or the configured timeout,
+// whichever comes first, and cancelling the context interrupts it.
+package p2p
@@ -0,0 +1,134 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/rs/zerolog/log"
+)
+
+// The address families of a node record.
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+	FamilyDual = "dual"
+)
+
+// endpoints returns the IPv4 and IPv6 addresses of the node record along with
+// their ports. The IPv6 ports fall back to the IPv4 ports like the ENR spec
+// requires.
+func endpoints(n *enode.Node) (ip4, ip6 net.IP, tcp4, tcp6, udp4, udp6 int) {
+	var (
+		eip4   enr.IPv4
+		eip6   enr.IPv6
+		etcp   enr.TCP
+		etcp6  enr.TCP6
+		eudp   enr.UDP
+		eudp6  enr.UDP6
+		record = n.Record()
+	)
+	if record.Load(&eip4) == nil {
+		ip4 = net.IP(eip4)
+	}
+	if record.Load(&eip6) == nil {
+		ip6 = net.IP(eip6)
+	}
+	_ = record.Load(&etcp)
+	_ = record.Load(&eudp)
+	tcp4, udp4 = int(etcp), int(eudp)
+	tcp6, udp6 = tcp4, udp4
+	if record.Load(&etcp6) == nil {
+		tcp6 = int(etcp6)
+	}
+	if record.Load(&eudp6) == nil {
+		udp6 = int(eudp6)
+	}
+	return ip4, ip6, tcp4, tcp6, udp4, udp6
+}
+
+// TCPEndpoints returns the addresses to dial the node on, with the IPv4 address
+// first for dual-stack nodes.
+func TCPEndpoints(n *enode.Node) []*net.TCPAddr {
+	ip4, ip6, tcp4, tcp6, _, _ := endpoints(n)
+	var addrs []*net.TCPAddr
+	if ip4 != nil && tcp4 != 0 {
+		addrs = append(addrs, &net.TCPAddr{IP: ip4, Port: tcp4})
+	}
+	if ip6 != nil && tcp6 != 0 {
+		addrs = append(addrs, &net.TCPAddr{IP: ip6, Port: tcp6})
+	}
+	return addrs
+}
+
+// UDPEndpoint returns the discovery address of the node, preferring IPv4.
+func UDPEndpoint(n *enode.Node) *net.UDPAddr {
+	ip4, ip6, _, _, udp4, udp6 := endpoints(n)
+	if ip4 != nil && udp4 != 0 {
+		return &net.UDPAddr{IP: ip4, Port: udp4}
+	}
+	if ip6 != nil && udp6 != 0 {
+		return &net.UDPAddr{IP: ip6, Port: udp6}
+	}
+	return &net.UDPAddr{IP: n.IP(), Port: n.UDP()}
+}
+
+// TCPAddress returns the first address to dial the node on as host:port, with
+// IPv6 addresses in brackets. It's empty if the node has no TCP endpoint.
+func TCPAddress(n *enode.Node) string {
+	if addrs := TCPEndpoints(n); len(addrs) > 0 {
+		return addrs[0].String()
+	}
+	return ""
+}
+
+// AddressFamily returns whether the node record has an IPv4 address, an IPv6
+// address, or both.
+func AddressFamily(n *enode.Node) string {
+	ip4, ip6, _, _, _, _ := endpoints(n)
+	switch {
+	case ip4 != nil && ip6 != nil:
+		return FamilyDual
+	case ip6 != nil:
+		return FamilyIPv6
+	case ip4 != nil:
+		return FamilyIPv4
+	default:
+		return ""
+	}
+}
+
+// SetupNAT determines the external IP with the NAT mechanism, which is any,
+// none, upnp, pmp, or extip:<IP> like geth's --nat flag, and sets it in the
+// node record. External IPv6 addresses can be given with extip as well. With
+// UPnP or NAT-PMP the ports are mapped until the context is done.
+func SetupNAT(ctx context.Context, ln *enode.LocalNode, spec string, tcpPort, udpPort int) error {
+	natm, err := nat.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid NAT mechanism: %w", err)
+	}
+	if natm == nil {
+		return nil
+	}
+
+	ip, err := natm.ExternalIP()
+	if err != nil {
+		return fmt.Errorf("unable to get the external IP with %s: %w", natm, err)
+	}
+	ln.SetStaticIP(ip)
+	log.Info().Str("nat", natm.String()).Str("ip", ip.String()).Msg("Set the external IP")
+
+	if _, ok := natm.(nat.ExtIP); ok {
+		return nil
+	}
+	if tcpPort > 0 {
+		go nat.Map(natm, ctx.Done(), "tcp", tcpPort, tcpPort, "polycli p2p")
+	}
+	if udpPort > 0 {
+		go nat.Map(natm, ctx.Done(), "udp", udpPort, udpPort, "polycli discovery")
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package p2p
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The layers a node can be classified as based on its ENR entries. Execution,
+// consensus, and rollup nodes share the same discovery DHT.
+const (
+	LayerExecution = "execution"
+	LayerConsensus = "consensus"
+	LayerRollup    = "rollup"
+	LayerUnknown   = "unknown"
+)
+
+// standardENRKeys are the keys that are already part of the node record
+// (identity and endpoint) and aren't worth recording again.
+var standardENRKeys = map[string]struct{}{
+	"id":        {},
+	"secp256k1": {},
+	"ip":        {},
+	"ip6":       {},
+	"tcp":       {},
+	"tcp6":      {},
+	"udp":       {},
+	"udp6":      {},
+}
+
+type (
+	// ENREntries are the decoded application specific entries of a node
+	// record.
+	ENREntries struct {
+		Layer    string            `json:"layer"`
+		Eth      *EthEntry         `json:"eth,omitempty"`
+		Eth2     *Eth2Entry        `json:"eth2,omitempty"`
+		Attnets  string            `json:"attnets,omitempty"`
+		Syncnets string            `json:"syncnets,omitempty"`
+		OpStack  *OpStackEntry     `json:"opstack,omitempty"`
+		Les      bool              `json:"les,omitempty"`
+		Snap     bool              `json:"snap,omitempty"`
+		Other    map[string]string `json:"other,omitempty"`
+	}
+
+	// EthEntry is the fork id advertised by execution layer nodes.
+	EthEntry struct {
+		ForkHash hexutil.Bytes `json:"forkHash"`
+		ForkNext uint64        `json:"forkNext"`
+	}
+
+	// Eth2Entry is the SSZ encoded ENRForkID of the consensus layer.
+	Eth2Entry struct {
+		ForkDigest      hexutil.Bytes `json:"forkDigest"`
+		NextForkVersion hexutil.Bytes `json:"nextForkVersion"`
+		NextForkEpoch   uint64        `json:"nextForkEpoch"`
+	}
+
+	// OpStackEntry is the entry advertised by OP stack rollup nodes.
+	OpStackEntry struct {
+		ChainID uint64 `json:"chainId"`
+		Version uint64 `json:"version"`
+	}
+
+	ethENREntry struct {
+		ForkID forkid.ID
+		Rest   []rlp.RawValue `rlp:"tail"`
+	}
+)
+
+// ParseENREntries decodes the known entries of the node record. Entries that
+// aren't known are recorded as hex so that chain specific entries can still be
+// told apart.
+func ParseENREntries(n *enode.Node) *ENREntries {
+	entries := &ENREntries{Layer: LayerUnknown}
+
+	elements := n.Record().AppendElements(nil)
+	// The first element is the sequence number, followed by key value pairs.
+	for i := 1; i+1 < len(elements); i += 2 {
+		key, ok := elements[i].(string)
+		if !ok {
+			continue
+		}
+		raw, ok := elements[i+1].(rlp.RawValue)
+		if !ok {
+			continue
+		}
+		if _, ok := standardENRKeys[key]; ok {
+			continue
+		}
+		if !entries.decode(key, raw) {
+			if entries.Other == nil {
+				entries.Other = make(map[string]string)
+			}
+			entries.Other[key] = hexutil.Encode(raw)
+		}
+	}
+
+	switch {
+	case entries.OpStack != nil:
+		entries.Layer = LayerRollup
+	case entries.Eth2 != nil:
+		entries.Layer = LayerConsensus
+	case entries.Eth != nil || entries.Les || entries.Snap:
+		entries.Layer = LayerExecution
+	}
+
+	return entries
+}
+
+// decode decodes a single entry and returns whether the key is known.
+func (e *ENREntries) decode(key string, raw rlp.RawValue) bool {
+	switch key {
+	case "eth":
+		var entry ethENREntry
+		if err := rlp.DecodeBytes(raw, &entry); err != nil {
+			return false
+		}
+		e.Eth = &EthEntry{ForkHash: entry.ForkID.Hash[:], ForkNext: entry.ForkID.Next}
+	case "eth2":
+		var b []byte
+		if err := rlp.DecodeBytes(raw, &b); err != nil || len(b) < 16 {
+			return false
+		}
+		e.Eth2 = &Eth2Entry{
+			ForkDigest:      b[:4],
+			NextForkVersion: b[4:8],
+			NextForkEpoch:   binary.LittleEndian.Uint64(b[8:16]),
+		}
+	case "attnets", "syncnets":
+		var b []byte
+		if err := rlp.DecodeBytes(raw, &b); err != nil {
+			return false
+		}
+		if key == "attnets" {
+			e.Attnets = hexutil.Encode(b)
+		} else {
+			e.Syncnets = hexutil.Encode(b)
+		}
+	case "opstack":
+		var b []byte
+		if err := rlp.DecodeBytes(raw, &b); err != nil {
+			return false
+		}
+		chainID, n := binary.Uvarint(b)
+		if n <= 0 {
+			return false
+		}
+		version, m := binary.Uvarint(b[n:])
+		if m <= 0 {
+			return false
+		}
+		e.OpStack = &OpStackEntry{ChainID: chainID, Version: version}
+	case "les":
+		e.Les = true
+	case "snap":
+		e.Snap = true
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+)
+
+// The outcomes of a probe.
+const (
+	ProbeAnswered   = "answered"
+	ProbeEmpty      = "empty"
+	ProbeTimeout    = "timeout"
+	ProbeDisconnect = "disconnect"
+	ProbeError      = "error"
+)
+
+const (
+	// maxHeadersProbe is more headers than clients serve in a single response,
+	// so the number of headers returned reveals the limit of the client.
+	maxHeadersProbe = 4096
+
+	// probeTimeout limits each probe.
+	probeTimeout = 5 * time.Second
+
+	// minClassifyPeers is the number of peers with the same signature and a
+	// non-generic Hello name needed to infer the client of a signature.
+	minClassifyPeers = 3
+
+	// minClassifyShare is the share of those peers that need to agree on the
+	// client.
+	minClassifyShare = 2.0 / 3
+)
+
+// Fingerprint is the behavior of a peer, which tells clients apart even when
+// their Hello name is spoofed or generic. The signature is a hash of the
+// behaviors that don't depend on the network, so peers running the same
+// client share it, while the response times are only reported.
+type Fingerprint struct {
+	Name                string   `json:"name"`
+	Caps                []string `json:"caps"`
+	HelloVersion        uint64   `json:"helloVersion"`
+	MaxHeaders          int      `json:"maxHeaders"`
+	UnknownHeaders      string   `json:"unknownHeaders"`
+	UnknownTransactions string   `json:"unknownTransactions"`
+	PingMs              float64  `json:"pingMs,omitempty"`
+	HeadersMs           float64  `json:"headersMs,omitempty"`
+	Signature           string   `json:"signature"`
+
+	// InferredClient is the client of the peers sharing the signature, and
+	// Spoofed is set when it differs from the client in the Hello name.
+	InferredClient string  `json:"inferredClient,omitempty"`
+	Confidence     float64 `json:"confidence,omitempty"`
+	Spoofed        bool    `json:"spoofed,omitempty"`
+}
+
+// Fingerprint probes the peer after the hello and status exchange by measuring
+// how it responds to pings and header requests, how many headers it serves at
+// once, and how it handles requests for unknown blocks and transactions. An
+// error is returned if the peer disconnected, in which case the fingerprint
+// has the outcomes of the probes until then.
+func (c *Conn) Fingerprint(ctx context.Context, hello *Hello, status *Status) (*Fingerprint, error) {
+	fp := &Fingerprint{
+		Name:         hello.Name,
+		HelloVersion: hello.Version,
+	}
+	for _, capability := range hello.Caps {
+		fp.Caps = append(fp.Caps, capability.String())
+	}
+	sort.Strings(fp.Caps)
+	defer fp.sign()
+
+	probe := func(f func(ctx context.Context) error) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+		outcome := probeOutcome(f(ctx))
+		if outcome == ProbeDisconnect {
+			return outcome, errors.New("peer disconnected while fingerprinting")
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(ctxErr, context.DeadlineExceeded) {
+			return outcome, ctxErr
+		}
+		return outcome, nil
+	}
+
+	if _, err := probe(func(ctx context.Context) error {
+		rtt, err := c.Ping(ctx)
+		fp.PingMs = float64(rtt.Microseconds()) / 1000
+		return err
+	}); err != nil {
+		return fp, err
+	}
+
+	if _, err := probe(func(ctx context.Context) error {
+		start := time.Now()
+		headers, err := c.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Hash: status.Genesis},
+			Amount: maxHeadersProbe,
+		})
+		fp.HeadersMs = float64(time.Since(start).Microseconds()) / 1000
+		fp.MaxHeaders = len(headers)
+		return err
+	}); err != nil {
+		return fp, err
+	}
+
+	var err error
+	fp.UnknownHeaders, err = probe(func(ctx context.Context) error {
+		headers, err := c.RequestHeaders(ctx, &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{Hash: randomHash()},
+			Amount: 1,
+		})
+		if err == nil && len(headers) == 0 {
+			return errEmptyResponse
+		}
+		return err
+	})
+	if err != nil {
+		return fp, err
+	}
+
+	fp.UnknownTransactions, err = probe(func(ctx context.Context) error {
+		txs, err := c.RequestPooledTransactions(ctx, []common.Hash{randomHash()})
+		if err == nil && len(txs) == 0 {
+			return errEmptyResponse
+		}
+		return err
+	})
+	return fp, err
+}
+
+// errEmptyResponse marks probes that were answered without any items.
+var errEmptyResponse = errors.New("empty response")
+
+func probeOutcome(err error) string {
+	switch {
+	case err == nil:
+		return ProbeAnswered
+	case errors.Is(err, errEmptyResponse):
+		return ProbeEmpty
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ProbeTimeout
+	case strings.Contains(err.Error(), "disconnect"), strings.Contains(err.Error(), "EOF"):
+		return ProbeDisconnect
+	default:
+		return ProbeError
+	}
+}
+
+func randomHash() common.Hash {
+	var hash common.Hash
+	_, _ = rand.Read(hash[:])
+	return hash
+}
+
+func (fp *Fingerprint) sign() {
+	features := fmt.Sprintf("%s|%d|%d|%s|%s",
+		strings.Join(fp.Caps, ","), fp.HelloVersion, fp.MaxHeaders, fp.UnknownHeaders, fp.UnknownTransactions)
+	sum := sha256.Sum256([]byte(features))
+	fp.Signature = hex.EncodeToString(sum[:4])
+}
+
+// ClientName returns the client in the Hello name without its version, e.g.
+// "bor" for "bor/v1.0.0/linux-amd64/go1.20.5". Names without a version are
+// treated as generic and an empty string is returned.
+func ClientName(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.ToLower(parts[0])
+}
+
+// Classifier infers the client of a fingerprint from the Hello names of the
+// other peers with the same signature. It's safe to use concurrently.
+type Classifier struct {
+	clients map[string]map[string]int
+	mu      sync.Mutex
+}
+
+func NewClassifier() *Classifier {
+	return &Classifier{clients: make(map[string]map[string]int)}
+}
+
+// Observe counts the client of the fingerprint's Hello name for its signature.
+func (c *Classifier) Observe(fp *Fingerprint) {
+	client := ClientName(fp.Name)
+	if client == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts, ok := c.clients[fp.Signature]
+	if !ok {
+		counts = make(map[string]int)
+		c.clients[fp.Signature] = counts
+	}
+	counts[client]++
+}
+
+// Classify sets the inferred client of the fingerprint if enough peers with
+// its signature agree on their client.
+func (c *Classifier) Classify(fp *Fingerprint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best string
+	var bestCount, total int
+	for client, count := range c.clients[fp.Signature] {
+		total += count
+		if count > bestCount || (count == bestCount && client < best) {
+			best, bestCount = client, count
+		}
+	}
+	share := float64(bestCount) / float64(total)
+	if total < minClassifyPeers || share < minClassifyShare {
+		fp.InferredClient, fp.Confidence, fp.Spoofed = "", 0, false
+		return
+	}
+
+	fp.InferredClient = best
+	fp.Confidence = share
+	client := ClientName(fp.Name)
+	fp.Spoofed = client != "" && client != best
+}
@@ -0,0 +1,190 @@
+package p2p
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+)
+
+// IPFilter restricts the nodes that are contacted to the networks of the
+// include list, or any network if it's empty, that aren't in the exclude list.
+// A nil filter allows every IP.
+type IPFilter struct {
+	include []*net.IPNet
+	exclude []*net.IPNet
+}
+
+// ParseIPFilter parses the comma separated CIDR include and exclude lists. It
+// returns nil if both are empty.
+func ParseIPFilter(include, exclude string) (*IPFilter, error) {
+	var f IPFilter
+	var err error
+	if f.include, err = parseCIDRs(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = parseCIDRs(exclude); err != nil {
+		return nil, err
+	}
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Allowed returns whether the IP may be contacted.
+func (f *IPFilter) Allowed(ip net.IP) bool {
+	if f == nil {
+		return true
+	}
+	for _, n := range f.exclude {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, n := range f.include {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Netlist returns the allowed networks, which are the include networks with
+// the exclude networks cut out. It's used to restrict the nodes the discovery
+// contacts, which only supports a list of allowed networks.
+func (f *IPFilter) Netlist() *netutil.Netlist {
+	if f == nil {
+		return nil
+	}
+	var list netutil.Netlist
+	for _, bits := range []int{32, 128} {
+		for _, r := range f.allowedRanges(bits) {
+			for _, n := range rangeToCIDRs(r[0], r[1], bits) {
+				list = append(list, *n)
+			}
+		}
+	}
+	return &list
+}
+
+// allowedRanges returns the allowed ranges of the IP family with the given
+// address length in bits, sorted and without overlaps.
+func (f *IPFilter) allowedRanges(bits int) [][2]*big.Int {
+	var include [][2]*big.Int
+	for _, n := range f.include {
+		if r, ok := netRange(n, bits); ok {
+			include = append(include, r)
+		}
+	}
+	if len(f.include) == 0 {
+		last := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits)), big.NewInt(1))
+		include = append(include, [2]*big.Int{big.NewInt(0), last})
+	}
+	include = mergeRanges(include)
+
+	for _, n := range f.exclude {
+		ex, ok := netRange(n, bits)
+		if !ok {
+			continue
+		}
+		var remaining [][2]*big.Int
+		for _, r := range include {
+			if ex[1].Cmp(r[0]) < 0 || ex[0].Cmp(r[1]) > 0 {
+				remaining = append(remaining, r)
+				continue
+			}
+			if ex[0].Cmp(r[0]) > 0 {
+				remaining = append(remaining, [2]*big.Int{r[0], new(big.Int).Sub(ex[0], big.NewInt(1))})
+			}
+			if ex[1].Cmp(r[1]) < 0 {
+				remaining = append(remaining, [2]*big.Int{new(big.Int).Add(ex[1], big.NewInt(1)), r[1]})
+			}
+		}
+		include = remaining
+	}
+	return include
+}
+
+// netRange returns the first and last address of the network if it's of the
+// IP family with the given address length.
+func netRange(n *net.IPNet, bits int) ([2]*big.Int, bool) {
+	ip := n.IP.To4()
+	if bits == 128 {
+		if ip != nil {
+			return [2]*big.Int{}, false
+		}
+		ip = n.IP.To16()
+	}
+	if ip == nil {
+		return [2]*big.Int{}, false
+	}
+	ones, _ := n.Mask.Size()
+	start := new(big.Int).SetBytes(ip)
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	return [2]*big.Int{start, size.Add(size, start).Sub(size, big.NewInt(1))}, true
+}
+
+func mergeRanges(ranges [][2]*big.Int) [][2]*big.Int {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0].Cmp(ranges[j][0]) < 0 })
+	var merged [][2]*big.Int
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0].Cmp(new(big.Int).Add(merged[n-1][1], big.NewInt(1))) <= 0 {
+			if r[1].Cmp(merged[n-1][1]) > 0 {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// rangeToCIDRs covers the range with the fewest networks.
+func rangeToCIDRs(start, end *big.Int, bits int) []*net.IPNet {
+	var nets []*net.IPNet
+	cur := new(big.Int).Set(start)
+	for cur.Cmp(end) <= 0 {
+		// The largest block that is aligned at the current address and doesn't
+		// extend past the end.
+		size := bits
+		if cur.Sign() > 0 {
+			size = int(cur.TrailingZeroBits())
+		}
+		for size > 0 {
+			last := new(big.Int).Lsh(big.NewInt(1), uint(size))
+			if last.Add(last, cur).Sub(last, big.NewInt(1)).Cmp(end) <= 0 {
+				break
+			}
+			size--
+		}
+
+		ip := make(net.IP, bits/8)
+		cur.FillBytes(ip)
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-size, bits)})
+		cur.Add(cur, new(big.Int).Lsh(big.NewInt(1), uint(size)))
+	}
+	return nets
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/metrics"
 )
 
 // MessageCount is used to help the outer goroutine to receive summary of the
@@ -27,9 +29,10 @@ type MessageCount struct {
 }
 
 // LogMessageCount will log the message counts and reset them based on the
-// ticker. This should be called as with a goroutine or it will block
+// ticker. The counts are also emitted to the sink and passed to observe, which
+// can both be nil. This should be called as with a goroutine or it will block
 // indefinitely.
-func LogMessageCount(count *MessageCount, ticker *time.Ticker) {
+func LogMessageCount(count *MessageCount, ticker *time.Ticker, sink *metrics.Sink, observe func(MessageCount)) {
 	for {
 		if _, ok := <-ticker.C; !ok {
 			return
@@ -50,6 +53,28 @@ func LogMessageCount(count *MessageCount, ticker *time.Ticker) {
 			Disconnects:         atomic.LoadInt32(&count.Disconnects),
 		}
 
+		sink.Emit(metrics.Point{
+			Name: "p2p_messages",
+			Fields: map[string]float64{
+				"block_headers":         float64(c.BlockHeaders),
+				"block_bodies":          float64(c.BlockBodies),
+				"blocks":                float64(c.Blocks),
+				"block_hashes":          float64(c.BlockHashes),
+				"block_header_requests": float64(c.BlockHeaderRequests),
+				"block_bodies_requests": float64(c.BlockBodiesRequests),
+				"transactions":          float64(c.Transactions),
+				"transaction_hashes":    float64(c.TransactionHashes),
+				"transaction_requests":  float64(c.TransactionRequests),
+				"pings":                 float64(c.Pings),
+				"errors":                float64(c.Errors),
+				"disconnects":           float64(c.Disconnects),
+			},
+		})
+
+		if observe != nil {
+			observe(c)
+		}
+
 		if c.BlockHeaders+c.BlockBodies+c.Blocks+c.BlockHashes+
 			c.BlockHeaderRequests+c.BlockBodiesRequests+c.Transactions+
 			c.TransactionHashes+c.TransactionRequests+c.Pings+c.Errors+
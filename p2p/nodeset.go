@@ -13,6 +13,14 @@ import (
 
 const jsonIndent = "    "
 
+// The versions of the nodes file schema. Version 1 is the schema of the geth
+// devp2p tool, and version 2 adds the decoded ENR entries, the address family,
+// and the fingerprint of the nodes.
+const (
+	NodesSchemaV1 = 1
+	NodesSchemaV2 = 2
+)
+
 // NodeSet is the nodes.json file format. It holds a set of node records
 // as a JSON object.
 type NodeSet map[enode.ID]NodeJSON
@@ -29,6 +37,14 @@ type NodeJSON struct {
 	LastResponse  time.Time `json:"lastResponse,omitempty"`
 	// This one tracks the time of our last attempt to contact the node.
 	LastCheck time.Time `json:"lastCheck,omitempty"`
+	// Entries holds the decoded application specific ENR entries, which are
+	// used to tell apart execution, consensus, and rollup nodes.
+	Entries *ENREntries `json:"entries,omitempty"`
+	// Family is the address family of the record, either ipv4, ipv6, or dual.
+	Family string `json:"family,omitempty"`
+	// Fingerprint is the behavior of the node when it was last peered with,
+	// if fingerprinting was enabled.
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
 }
 
 func LoadNodesJSON(file string) (NodeSet, error) {
@@ -44,8 +60,36 @@ func WriteNodesJSON(file string, nodes NodeSet) error {
 	if err != nil {
 		return err
 	}
+	return writeFile(file, nodesJSON)
+}
+
+// WriteNodesJSONCompact writes the nodes without indentation, which makes
+// large nodes files considerably smaller.
+func WriteNodesJSONCompact(file string, nodes NodeSet) error {
+	nodesJSON, err := json.Marshal(nodes)
+	if err != nil {
+		return err
+	}
+	return writeFile(file, nodesJSON)
+}
+
+// WriteStaticNodes writes the enode URLs of the nodes in the format of the
+// static-nodes.json and trusted-nodes.json files of geth and bor.
+func WriteStaticNodes(file string, nodes []*enode.Node) error {
+	urls := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		urls = append(urls, n.URLv4())
+	}
+	nodesJSON, err := json.MarshalIndent(urls, "", jsonIndent)
+	if err != nil {
+		return err
+	}
+	return writeFile(file, append(nodesJSON, '\n'))
+}
+
+func writeFile(file string, nodesJSON []byte) error {
 	if file == "-" {
-		_, err = os.Stdout.Write(nodesJSON)
+		_, err := os.Stdout.Write(nodesJSON)
 		return err
 	}
 	return os.WriteFile(file, nodesJSON, 0644)
@@ -63,3 +107,28 @@ func (ns NodeSet) Nodes() []*enode.Node {
 	})
 	return result
 }
+
+// SchemaVersion returns the schema version of the node, which is version 2 if
+// it has any of the fields added in it.
+func (n NodeJSON) SchemaVersion() int {
+	if n.Entries != nil || n.Family != "" || n.Fingerprint != nil {
+		return NodesSchemaV2
+	}
+	return NodesSchemaV1
+}
+
+// Migrate converts the node to the schema version. Migrating to version 2
+// derives the ENR entries and the address family from the record, and
+// migrating to version 1 drops the fields added in version 2.
+func (n *NodeJSON) Migrate(version int) {
+	switch version {
+	case NodesSchemaV1:
+		n.Entries, n.Family, n.Fingerprint = nil, "", nil
+	case NodesSchemaV2:
+		if n.N == nil {
+			return
+		}
+		n.Entries = ParseENREntries(n.N)
+		n.Family = AddressFamily(n.N)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -13,12 +15,15 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// Listen opens the discovery socket on a random port and sets it as the UDP
+// endpoint of the local node. The socket is dual-stack so IPv6 nodes can be
+// discovered, unless IPv6 is unavailable on the host.
 func Listen(ln *enode.LocalNode) (*net.UDPConn, error) {
-	addr := "0.0.0.0:0"
-
-	socket, err := net.ListenPacket("udp4", addr)
+	socket, err := net.ListenPacket("udp", ":0")
 	if err != nil {
-		return nil, err
+		if socket, err = net.ListenPacket("udp4", "0.0.0.0:0"); err != nil {
+			return nil, err
+		}
 	}
 
 	// Configure UDP endpoint in ENR from listener address.
@@ -103,3 +108,36 @@ func ParseBootnodes(bootnodes string) ([]*enode.Node, error) {
 
 	return nodes, nil
 }
+
+// ParseNodeList parses a list of nodes from either a file or a comma separated
+// string. Files can be a JSON array, like geth's static-nodes.json, or contain
+// one node per line. Empty lines and lines starting with # are ignored.
+func ParseNodeList(list string) ([]*enode.Node, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	records := strings.Split(list, ",")
+	if b, err := os.ReadFile(list); err == nil {
+		records = nil
+		if err := json.Unmarshal(b, &records); err != nil {
+			records = strings.Split(string(b), "\n")
+		}
+	}
+
+	var nodes []*enode.Node
+	for _, record := range records {
+		record = strings.TrimSpace(record)
+		if record == "" || strings.HasPrefix(record, "#") {
+			continue
+		}
+
+		n, err := ParseNode(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node %s: %v", record, err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	return nodes, nil
+}
@@ -1,8 +1,8 @@
 package p2p
 
 import (
-	"container/list"
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -15,74 +15,38 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
-	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
-	"github.com/ethereum/go-ethereum/p2p/rlpx"
 	"github.com/maticnetwork/polygon-cli/p2p/database"
 	"github.com/rs/zerolog/log"
 )
 
-var (
-	timeout = 20 * time.Second
-)
-
 // Dial attempts to Dial the given node and perform a handshake,
-// returning the created Conn if successful.
+// returning the created Conn if successful. It uses a client with the default
+// configuration, see Client for more control.
 func Dial(n *enode.Node) (*Conn, error) {
-	fd, err := net.Dial("tcp", fmt.Sprintf("%v:%d", n.IP(), n.TCP()))
-	if err != nil {
-		return nil, err
-	}
-
-	conn := Conn{
-		Conn:       rlpx.NewConn(fd, n.Pubkey()),
-		node:       n,
-		logger:     log.With().Str("peer", n.URLv4()).Logger(),
-		requests:   list.New(),
-		requestNum: 0,
-	}
-
-	if conn.ourKey, err = crypto.GenerateKey(); err != nil {
-		return nil, err
-	}
-
-	defer func() { _ = conn.SetDeadline(time.Time{}) }()
-	if err = conn.SetDeadline(time.Now().Add(20 * time.Second)); err != nil {
-		return nil, err
-	}
-	if _, err = conn.Handshake(conn.ourKey); err != nil {
-		conn.Close()
-		return nil, err
-	}
+	return NewClient(ClientConfig{}).Dial(context.Background(), n)
+}
 
-	conn.caps = []p2p.Cap{
-		{Name: "eth", Version: 66},
-	}
+// Accept performs the receiving side of the handshake on an inbound
+// connection. The key must be the same key that is advertised in the node
+// record, otherwise the remote peer will reject the handshake.
+func Accept(fd net.Conn, key *ecdsa.PrivateKey) (*Conn, error) {
+	return NewClient(ClientConfig{Key: key}).Accept(context.Background(), fd)
+}
 
-	return &conn, nil
+// Node returns the remote node of the connection.
+func (c *Conn) Node() *enode.Node {
+	return c.node
 }
 
 // Peer performs both the protocol handshake and the status message
 // exchange with the node in order to Peer with it.
 func (c *Conn) Peer() (*Hello, *Status, error) {
-	hello, err := c.handshake()
-	if err != nil {
-		return nil, nil, fmt.Errorf("handshake failed: %v", err)
-	}
-	status, err := c.statusExchange()
-	if err != nil {
-		return hello, nil, fmt.Errorf("status exchange failed: %v", err)
-	}
-	return hello, status, nil
+	return c.PeerContext(context.Background())
 }
 
 // handshake performs a protocol handshake with the node.
 func (c *Conn) handshake() (*Hello, error) {
-	defer func() { _ = c.SetDeadline(time.Time{}) }()
-	if err := c.SetDeadline(time.Now().Add(10 * time.Second)); err != nil {
-		return nil, err
-	}
-
 	// write hello to client
 	pub0 := crypto.FromECDSAPub(&c.ourKey.PublicKey)[1:]
 	ourHandshake := &Hello{
@@ -101,10 +65,8 @@ func (c *Conn) handshake() (*Hello, error) {
 			c.SetSnappy(true)
 		}
 		return msg, nil
-	case *Disconnect:
-		return nil, fmt.Errorf("disconnect received: %v", msg)
-	case *Disconnects:
-		return nil, fmt.Errorf("disconnect received: %v", msg)
+	case *Disconnect, *Disconnects:
+		return nil, disconnectError(msg)
 	default:
 		return nil, fmt.Errorf("bad handshake: %v", msg)
 	}
@@ -112,11 +74,6 @@ func (c *Conn) handshake() (*Hello, error) {
 
 // statusExchange gets the Status message from the given node.
 func (c *Conn) statusExchange() (*Status, error) {
-	defer func() { _ = c.SetDeadline(time.Time{}) }()
-	if err := c.SetDeadline(time.Now().Add(20 * time.Second)); err != nil {
-		return nil, err
-	}
-
 	var status *Status
 loop:
 	for {
@@ -124,10 +81,8 @@ loop:
 		case *Status:
 			status = msg
 			break loop
-		case *Disconnect:
-			return nil, fmt.Errorf("disconnect received: %v", msg)
-		case *Disconnects:
-			return nil, fmt.Errorf("disconnect received: %v", msg)
+		case *Disconnect, *Disconnects:
+			return nil, disconnectError(msg)
 		case *Ping:
 			if err := c.Write(&Pong{}); err != nil {
 				c.logger.Error().Err(err).Msg("Write pong failed")
@@ -158,6 +113,14 @@ func (c *Conn) ReadAndServe(db database.Database, count *MessageCount) error {
 	var dbCh chan struct{}
 	if db != nil {
 		dbCh = make(chan struct{}, db.MaxConcurrentWrites())
+
+		// Wait for the pending writes before returning, so they aren't lost
+		// when the connection is closed during a shutdown.
+		defer func() {
+			for i := 0; i < cap(dbCh); i++ {
+				dbCh <- struct{}{}
+			}
+		}()
 	}
 
 	ctx := context.Background()
@@ -165,7 +128,7 @@ func (c *Conn) ReadAndServe(db database.Database, count *MessageCount) error {
 	for {
 		start := time.Now()
 
-		for time.Since(start) < timeout {
+		for time.Since(start) < c.requestTimeout {
 			if err := c.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
 				c.logger.Error().Err(err).Msg("Failed to set read deadline")
 			}
@@ -328,12 +291,13 @@ func (c *Conn) ReadAndServe(db database.Database, count *MessageCount) error {
 				if !strings.Contains(msg.Error(), "timeout") {
 					return msg.Unwrap()
 				}
-			case *Disconnect:
-				atomic.AddInt32(&count.Disconnects, 1)
-				c.logger.Debug().Msgf("Disconnect received: %v", msg)
-			case *Disconnects:
+			case *Disconnect, *Disconnects:
 				atomic.AddInt32(&count.Disconnects, 1)
 				c.logger.Debug().Msgf("Disconnect received: %v", msg)
+
+				// The peer closes the connection after the disconnect, so
+				// return the reason rather than the read error that follows.
+				return disconnectError(msg)
 			default:
 				c.logger.Info().Interface("msg", msg).Int("code", msg.Code()).Msg("Received message")
 			}
@@ -66,6 +66,31 @@ type Disconnects []p2p.DiscReason
 func (msg Disconnects) Code() int     { return 0x01 }
 func (msg Disconnects) ReqID() uint64 { return 0 }
 
+// DisconnectError is returned when the peer disconnects, so callers can tell
+// why they were dropped.
+type DisconnectError struct {
+	Reason p2p.DiscReason
+}
+
+func (e *DisconnectError) Error() string {
+	return fmt.Sprintf("disconnect received: %v", e.Reason)
+}
+
+// disconnectError returns the error of a Disconnect or Disconnects message.
+// Peers that disconnect without a reason are treated as if they requested it.
+func disconnectError(msg Message) error {
+	reason := p2p.DiscRequested
+	switch msg := msg.(type) {
+	case *Disconnect:
+		reason = msg.Reason
+	case *Disconnects:
+		if len(*msg) > 0 {
+			reason = (*msg)[0]
+		}
+	}
+	return &DisconnectError{Reason: reason}
+}
+
 type Ping struct{}
 
 func (msg Ping) Code() int     { return 0x02 }
@@ -158,6 +183,12 @@ type Conn struct {
 	node   *enode.Node
 	logger zerolog.Logger
 
+	handshakeTimeout time.Duration
+	requestTimeout   time.Duration
+
+	// timings are how long the phases of establishing the connection took.
+	timings PeerTimings
+
 	// requests is used to store the request ID and the block hash. This is used
 	// when fetching block bodies because the eth protocol block bodies do not
 	// contain information about the block hash.
@@ -169,7 +200,8 @@ type Conn struct {
 	oldestBlock *types.Header
 }
 
-// Read reads an eth66 packet from the connection.
+// Read reads an eth66 or snap/1 packet from the connection. The snap codes
+// assume eth/66 is the only other negotiated capability.
 func (c *Conn) Read() Message {
 	code, rawData, _, err := c.Conn.Read()
 	if err != nil {
@@ -242,6 +274,22 @@ func (c *Conn) Read() Message {
 			return errorf("could not rlp decode message: %v", err)
 		}
 		return (*PooledTransactions)(ethMsg)
+	case (GetAccountRange{}).Code():
+		msg = new(GetAccountRange)
+	case (AccountRange{}).Code():
+		msg = new(AccountRange)
+	case (GetStorageRanges{}).Code():
+		msg = new(GetStorageRanges)
+	case (StorageRanges{}).Code():
+		msg = new(StorageRanges)
+	case (GetByteCodes{}).Code():
+		msg = new(GetByteCodes)
+	case (ByteCodes{}).Code():
+		msg = new(ByteCodes)
+	case (GetTrieNodes{}).Code():
+		msg = new(GetTrieNodes)
+	case (TrieNodes{}).Code():
+		msg = new(TrieNodes)
 	default:
 		msg = errorf("invalid message code: %d", code)
 	}
@@ -269,7 +317,7 @@ func (c *Conn) Write(msg Message) error {
 func (c *Conn) ReadSnap(id uint64) (Message, error) {
 	respId := id + 1
 	start := time.Now()
-	for respId != id && time.Since(start) < timeout {
+	for respId != id && time.Since(start) < c.requestTimeout {
 		code, rawData, _, err := c.Conn.Read()
 		if err != nil {
 			return nil, fmt.Errorf("could not read from connection: %v", err)
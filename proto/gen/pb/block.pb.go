@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.1
-// 	protoc        v3.21.12
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
 // source: block.proto
 
 package pb
@@ -25,30 +25,42 @@ type Block struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Author           string         `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
-	Difficulty       string         `protobuf:"bytes,2,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
-	ExtraData        string         `protobuf:"bytes,3,opt,name=extraData,proto3" json:"extraData,omitempty"`
-	GasLimit         string         `protobuf:"bytes,4,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
-	GasUsed          string         `protobuf:"bytes,5,opt,name=gasUsed,proto3" json:"gasUsed,omitempty"`
-	Hash             string         `protobuf:"bytes,6,opt,name=hash,proto3" json:"hash,omitempty"`
-	LogsBloom        string         `protobuf:"bytes,7,opt,name=logsBloom,proto3" json:"logsBloom,omitempty"`
-	Miner            string         `protobuf:"bytes,8,opt,name=miner,proto3" json:"miner,omitempty"`
-	Number           string         `protobuf:"bytes,9,opt,name=number,proto3" json:"number,omitempty"`
-	ParentHash       string         `protobuf:"bytes,10,opt,name=parentHash,proto3" json:"parentHash,omitempty"`
-	ReceiptsRoot     string         `protobuf:"bytes,11,opt,name=receiptsRoot,proto3" json:"receiptsRoot,omitempty"`
-	Sha3Uncles       string         `protobuf:"bytes,12,opt,name=sha3Uncles,proto3" json:"sha3Uncles,omitempty"`
-	Signature        string         `protobuf:"bytes,13,opt,name=signature,proto3" json:"signature,omitempty"`
-	Size             string         `protobuf:"bytes,14,opt,name=size,proto3" json:"size,omitempty"`
-	StateRoot        string         `protobuf:"bytes,15,opt,name=stateRoot,proto3" json:"stateRoot,omitempty"`
-	Step             uint32         `protobuf:"varint,16,opt,name=step,proto3" json:"step,omitempty"`
-	TotalDifficulty  string         `protobuf:"bytes,17,opt,name=totalDifficulty,proto3" json:"totalDifficulty,omitempty"`
-	Timestamp        string         `protobuf:"bytes,18,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Transactions     []*Transaction `protobuf:"bytes,19,rep,name=transactions,proto3" json:"transactions,omitempty"`
-	TransactionsRoot string         `protobuf:"bytes,20,opt,name=transactionsRoot,proto3" json:"transactionsRoot,omitempty"`
-	Uncles           []string       `protobuf:"bytes,21,rep,name=uncles,proto3" json:"uncles,omitempty"`
-	BaseFeePerGas    string         `protobuf:"bytes,22,opt,name=baseFeePerGas,proto3" json:"baseFeePerGas,omitempty"`
-	MixHash          string         `protobuf:"bytes,23,opt,name=mixHash,proto3" json:"mixHash,omitempty"`
-	Nonce            string         `protobuf:"bytes,24,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Author                string         `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	Difficulty            string         `protobuf:"bytes,2,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	ExtraData             string         `protobuf:"bytes,3,opt,name=extraData,proto3" json:"extraData,omitempty"`
+	GasLimit              string         `protobuf:"bytes,4,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
+	GasUsed               string         `protobuf:"bytes,5,opt,name=gasUsed,proto3" json:"gasUsed,omitempty"`
+	Hash                  string         `protobuf:"bytes,6,opt,name=hash,proto3" json:"hash,omitempty"`
+	LogsBloom             string         `protobuf:"bytes,7,opt,name=logsBloom,proto3" json:"logsBloom,omitempty"`
+	Miner                 string         `protobuf:"bytes,8,opt,name=miner,proto3" json:"miner,omitempty"`
+	Number                string         `protobuf:"bytes,9,opt,name=number,proto3" json:"number,omitempty"`
+	ParentHash            string         `protobuf:"bytes,10,opt,name=parentHash,proto3" json:"parentHash,omitempty"`
+	ReceiptsRoot          string         `protobuf:"bytes,11,opt,name=receiptsRoot,proto3" json:"receiptsRoot,omitempty"`
+	Sha3Uncles            string         `protobuf:"bytes,12,opt,name=sha3Uncles,proto3" json:"sha3Uncles,omitempty"`
+	Signature             string         `protobuf:"bytes,13,opt,name=signature,proto3" json:"signature,omitempty"`
+	Size                  string         `protobuf:"bytes,14,opt,name=size,proto3" json:"size,omitempty"`
+	StateRoot             string         `protobuf:"bytes,15,opt,name=stateRoot,proto3" json:"stateRoot,omitempty"`
+	Step                  uint32         `protobuf:"varint,16,opt,name=step,proto3" json:"step,omitempty"`
+	TotalDifficulty       string         `protobuf:"bytes,17,opt,name=totalDifficulty,proto3" json:"totalDifficulty,omitempty"`
+	Timestamp             string         `protobuf:"bytes,18,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transactions          []*Transaction `protobuf:"bytes,19,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	TransactionsRoot      string         `protobuf:"bytes,20,opt,name=transactionsRoot,proto3" json:"transactionsRoot,omitempty"`
+	Uncles                []string       `protobuf:"bytes,21,rep,name=uncles,proto3" json:"uncles,omitempty"`
+	BaseFeePerGas         string         `protobuf:"bytes,22,opt,name=baseFeePerGas,proto3" json:"baseFeePerGas,omitempty"`
+	MixHash               string         `protobuf:"bytes,23,opt,name=mixHash,proto3" json:"mixHash,omitempty"`
+	Nonce                 string         `protobuf:"bytes,24,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Withdrawals           []*Withdrawal  `protobuf:"bytes,25,rep,name=withdrawals,proto3" json:"withdrawals,omitempty"`
+	WithdrawalsRoot       string         `protobuf:"bytes,26,opt,name=withdrawalsRoot,proto3" json:"withdrawalsRoot,omitempty"`
+	BlobGasUsed           string         `protobuf:"bytes,27,opt,name=blobGasUsed,proto3" json:"blobGasUsed,omitempty"`
+	ExcessBlobGas         string         `protobuf:"bytes,28,opt,name=excessBlobGas,proto3" json:"excessBlobGas,omitempty"`
+	ParentBeaconBlockRoot string         `protobuf:"bytes,29,opt,name=parentBeaconBlockRoot,proto3" json:"parentBeaconBlockRoot,omitempty"`
+	// The uncle headers and blob sidecars aren't part of the block returned by
+	// the RPC and are only set when they are dumped.
+	UncleHeaders []*Block       `protobuf:"bytes,30,rep,name=uncleHeaders,proto3" json:"uncleHeaders,omitempty"`
+	BlobSidecars []*BlobSidecar `protobuf:"bytes,31,rep,name=blobSidecars,proto3" json:"blobSidecars,omitempty"`
+	// The version of the schema the record was written with, see SchemaVersion
+	// in proto.go.
+	SchemaVersion uint32 `protobuf:"varint,100,opt,name=schemaVersion,proto3" json:"schemaVersion,omitempty"`
 }
 
 func (x *Block) Reset() {
@@ -251,12 +263,218 @@ func (x *Block) GetNonce() string {
 	return ""
 }
 
+func (x *Block) GetWithdrawals() []*Withdrawal {
+	if x != nil {
+		return x.Withdrawals
+	}
+	return nil
+}
+
+func (x *Block) GetWithdrawalsRoot() string {
+	if x != nil {
+		return x.WithdrawalsRoot
+	}
+	return ""
+}
+
+func (x *Block) GetBlobGasUsed() string {
+	if x != nil {
+		return x.BlobGasUsed
+	}
+	return ""
+}
+
+func (x *Block) GetExcessBlobGas() string {
+	if x != nil {
+		return x.ExcessBlobGas
+	}
+	return ""
+}
+
+func (x *Block) GetParentBeaconBlockRoot() string {
+	if x != nil {
+		return x.ParentBeaconBlockRoot
+	}
+	return ""
+}
+
+func (x *Block) GetUncleHeaders() []*Block {
+	if x != nil {
+		return x.UncleHeaders
+	}
+	return nil
+}
+
+func (x *Block) GetBlobSidecars() []*BlobSidecar {
+	if x != nil {
+		return x.BlobSidecars
+	}
+	return nil
+}
+
+func (x *Block) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+type Withdrawal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index          string `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	ValidatorIndex string `protobuf:"bytes,2,opt,name=validatorIndex,proto3" json:"validatorIndex,omitempty"`
+	Address        string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Amount         string `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+}
+
+func (x *Withdrawal) Reset() {
+	*x = Withdrawal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Withdrawal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Withdrawal) ProtoMessage() {}
+
+func (x *Withdrawal) ProtoReflect() protoreflect.Message {
+	mi := &file_block_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Withdrawal.ProtoReflect.Descriptor instead.
+func (*Withdrawal) Descriptor() ([]byte, []int) {
+	return file_block_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Withdrawal) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *Withdrawal) GetValidatorIndex() string {
+	if x != nil {
+		return x.ValidatorIndex
+	}
+	return ""
+}
+
+func (x *Withdrawal) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Withdrawal) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+type BlobSidecar struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index         string `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	Blob          string `protobuf:"bytes,2,opt,name=blob,proto3" json:"blob,omitempty"`
+	KzgCommitment string `protobuf:"bytes,3,opt,name=kzgCommitment,proto3" json:"kzgCommitment,omitempty"`
+	KzgProof      string `protobuf:"bytes,4,opt,name=kzgProof,proto3" json:"kzgProof,omitempty"`
+	VersionedHash string `protobuf:"bytes,5,opt,name=versionedHash,proto3" json:"versionedHash,omitempty"`
+}
+
+func (x *BlobSidecar) Reset() {
+	*x = BlobSidecar{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_block_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlobSidecar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlobSidecar) ProtoMessage() {}
+
+func (x *BlobSidecar) ProtoReflect() protoreflect.Message {
+	mi := &file_block_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlobSidecar.ProtoReflect.Descriptor instead.
+func (*BlobSidecar) Descriptor() ([]byte, []int) {
+	return file_block_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BlobSidecar) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *BlobSidecar) GetBlob() string {
+	if x != nil {
+		return x.Blob
+	}
+	return ""
+}
+
+func (x *BlobSidecar) GetKzgCommitment() string {
+	if x != nil {
+		return x.KzgCommitment
+	}
+	return ""
+}
+
+func (x *BlobSidecar) GetKzgProof() string {
+	if x != nil {
+		return x.KzgProof
+	}
+	return ""
+}
+
+func (x *BlobSidecar) GetVersionedHash() string {
+	if x != nil {
+		return x.VersionedHash
+	}
+	return ""
+}
+
 var File_block_proto protoreflect.FileDescriptor
 
 var file_block_proto_rawDesc = []byte{
 	0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd5, 0x05, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63,
+	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc2, 0x08, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63,
 	0x6b, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x69, 0x66,
 	0x66, 0x69, 0x63, 0x75, 0x6c, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64,
@@ -301,11 +519,52 @@ var file_block_proto_rawDesc = []byte{
 	0x52, 0x0d, 0x62, 0x61, 0x73, 0x65, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x12,
 	0x18, 0x0a, 0x07, 0x6d, 0x69, 0x78, 0x48, 0x61, 0x73, 0x68, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09,
 	0x52, 0x07, 0x6d, 0x69, 0x78, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e,
-	0x63, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x42,
-	0x35, 0x5a, 0x33, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61,
-	0x74, 0x69, 0x63, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x70, 0x6f, 0x6c, 0x79, 0x67,
-	0x6f, 0x6e, 0x2d, 0x63, 0x6c, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e,
-	0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x63, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12,
+	0x33, 0x0a, 0x0b, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x18, 0x19,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x57, 0x69, 0x74,
+	0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x52, 0x0b, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61,
+	0x77, 0x61, 0x6c, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77,
+	0x61, 0x6c, 0x73, 0x52, 0x6f, 0x6f, 0x74, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x77,
+	0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x73, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x20,
+	0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64, 0x18, 0x1b, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x55, 0x73, 0x65, 0x64,
+	0x12, 0x24, 0x0a, 0x0d, 0x65, 0x78, 0x63, 0x65, 0x73, 0x73, 0x42, 0x6c, 0x6f, 0x62, 0x47, 0x61,
+	0x73, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x78, 0x63, 0x65, 0x73, 0x73, 0x42,
+	0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x12, 0x34, 0x0a, 0x15, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x18,
+	0x1d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x65, 0x61,
+	0x63, 0x6f, 0x6e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x30, 0x0a, 0x0c,
+	0x75, 0x6e, 0x63, 0x6c, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x1e, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x0c, 0x75, 0x6e, 0x63, 0x6c, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x36,
+	0x0a, 0x0c, 0x62, 0x6c, 0x6f, 0x62, 0x53, 0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x73, 0x18, 0x1f,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x6c, 0x6f,
+	0x62, 0x53, 0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x52, 0x0c, 0x62, 0x6c, 0x6f, 0x62, 0x53, 0x69,
+	0x64, 0x65, 0x63, 0x61, 0x72, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x64, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73,
+	0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x7c, 0x0a, 0x0a,
+	0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78,
+	0x12, 0x26, 0x0a, 0x0e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x9f, 0x01, 0x0a, 0x0b, 0x42,
+	0x6c, 0x6f, 0x62, 0x53, 0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78,
+	0x12, 0x12, 0x0a, 0x04, 0x62, 0x6c, 0x6f, 0x62, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x62, 0x6c, 0x6f, 0x62, 0x12, 0x24, 0x0a, 0x0d, 0x6b, 0x7a, 0x67, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6b, 0x7a, 0x67,
+	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x7a,
+	0x67, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6b, 0x7a,
+	0x67, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x24, 0x0a, 0x0d, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x42, 0x35, 0x5a, 0x33,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61, 0x74, 0x69, 0x63,
+	0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x2d,
+	0x63, 0x6c, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x70, 0x62,
+	0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -320,18 +579,23 @@ func file_block_proto_rawDescGZIP() []byte {
 	return file_block_proto_rawDescData
 }
 
-var file_block_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_block_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_block_proto_goTypes = []interface{}{
 	(*Block)(nil),       // 0: proto.Block
-	(*Transaction)(nil), // 1: proto.Transaction
+	(*Withdrawal)(nil),  // 1: proto.Withdrawal
+	(*BlobSidecar)(nil), // 2: proto.BlobSidecar
+	(*Transaction)(nil), // 3: proto.Transaction
 }
 var file_block_proto_depIdxs = []int32{
-	1, // 0: proto.Block.transactions:type_name -> proto.Transaction
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3, // 0: proto.Block.transactions:type_name -> proto.Transaction
+	1, // 1: proto.Block.withdrawals:type_name -> proto.Withdrawal
+	0, // 2: proto.Block.uncleHeaders:type_name -> proto.Block
+	2, // 3: proto.Block.blobSidecars:type_name -> proto.BlobSidecar
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_block_proto_init() }
@@ -353,6 +617,30 @@ func file_block_proto_init() {
 				return nil
 			}
 		}
+		file_block_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Withdrawal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_block_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlobSidecar); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -360,7 +648,7 @@ func file_block_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_block_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,142 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: sensor.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Sensor_Subscribe_FullMethodName = "/proto.Sensor/Subscribe"
+)
+
+// SensorClient is the client API for Sensor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SensorClient interface {
+	// Subscribe streams the events from the time of the subscription until the
+	// client cancels it or the sensor stops. Events are dropped for clients that
+	// don't keep up.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Sensor_SubscribeClient, error)
+}
+
+type sensorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSensorClient(cc grpc.ClientConnInterface) SensorClient {
+	return &sensorClient{cc}
+}
+
+func (c *sensorClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Sensor_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Sensor_ServiceDesc.Streams[0], Sensor_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sensorSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Sensor_SubscribeClient interface {
+	Recv() (*SensorEvent, error)
+	grpc.ClientStream
+}
+
+type sensorSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *sensorSubscribeClient) Recv() (*SensorEvent, error) {
+	m := new(SensorEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SensorServer is the server API for Sensor service.
+// All implementations must embed UnimplementedSensorServer
+// for forward compatibility
+type SensorServer interface {
+	// Subscribe streams the events from the time of the subscription until the
+	// client cancels it or the sensor stops. Events are dropped for clients that
+	// don't keep up.
+	Subscribe(*SubscribeRequest, Sensor_SubscribeServer) error
+	mustEmbedUnimplementedSensorServer()
+}
+
+// UnimplementedSensorServer must be embedded to have forward compatible implementations.
+type UnimplementedSensorServer struct {
+}
+
+func (UnimplementedSensorServer) Subscribe(*SubscribeRequest, Sensor_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedSensorServer) mustEmbedUnimplementedSensorServer() {}
+
+// UnsafeSensorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SensorServer will
+// result in compilation errors.
+type UnsafeSensorServer interface {
+	mustEmbedUnimplementedSensorServer()
+}
+
+func RegisterSensorServer(s grpc.ServiceRegistrar, srv SensorServer) {
+	s.RegisterService(&Sensor_ServiceDesc, srv)
+}
+
+func _Sensor_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SensorServer).Subscribe(m, &sensorSubscribeServer{stream})
+}
+
+type Sensor_SubscribeServer interface {
+	Send(*SensorEvent) error
+	grpc.ServerStream
+}
+
+type sensorSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *sensorSubscribeServer) Send(m *SensorEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Sensor_ServiceDesc is the grpc.ServiceDesc for Sensor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sensor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.Sensor",
+	HandlerType: (*SensorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Sensor_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sensor.proto",
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.1
-// 	protoc        v3.21.12
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
 // source: transaction.proto
 
 package pb
@@ -25,22 +25,32 @@ type Transaction struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Hash             string  `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
-	Nonce            string  `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
-	BlockHash        string  `protobuf:"bytes,3,opt,name=blockHash,proto3" json:"blockHash,omitempty"`
-	BlockNumber      string  `protobuf:"bytes,4,opt,name=blockNumber,proto3" json:"blockNumber,omitempty"`
-	TransactionIndex string  `protobuf:"bytes,5,opt,name=transactionIndex,proto3" json:"transactionIndex,omitempty"`
-	From             string  `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`
-	To               *string `protobuf:"bytes,7,opt,name=to,proto3,oneof" json:"to,omitempty"`
-	Value            string  `protobuf:"bytes,8,opt,name=value,proto3" json:"value,omitempty"`
-	GasPrice         string  `protobuf:"bytes,9,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
-	Gas              string  `protobuf:"bytes,10,opt,name=gas,proto3" json:"gas,omitempty"`
-	Data             string  `protobuf:"bytes,11,opt,name=data,proto3" json:"data,omitempty"`
-	Input            string  `protobuf:"bytes,12,opt,name=input,proto3" json:"input,omitempty"`
-	Type             string  `protobuf:"bytes,13,opt,name=type,proto3" json:"type,omitempty"`
-	V                string  `protobuf:"bytes,14,opt,name=v,proto3" json:"v,omitempty"`
-	S                string  `protobuf:"bytes,15,opt,name=s,proto3" json:"s,omitempty"`
-	R                string  `protobuf:"bytes,16,opt,name=r,proto3" json:"r,omitempty"`
+	Hash                 string         `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Nonce                string         `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	BlockHash            string         `protobuf:"bytes,3,opt,name=blockHash,proto3" json:"blockHash,omitempty"`
+	BlockNumber          string         `protobuf:"bytes,4,opt,name=blockNumber,proto3" json:"blockNumber,omitempty"`
+	TransactionIndex     string         `protobuf:"bytes,5,opt,name=transactionIndex,proto3" json:"transactionIndex,omitempty"`
+	From                 string         `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`
+	To                   *string        `protobuf:"bytes,7,opt,name=to,proto3,oneof" json:"to,omitempty"`
+	Value                string         `protobuf:"bytes,8,opt,name=value,proto3" json:"value,omitempty"`
+	GasPrice             string         `protobuf:"bytes,9,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
+	Gas                  string         `protobuf:"bytes,10,opt,name=gas,proto3" json:"gas,omitempty"`
+	Data                 string         `protobuf:"bytes,11,opt,name=data,proto3" json:"data,omitempty"`
+	Input                string         `protobuf:"bytes,12,opt,name=input,proto3" json:"input,omitempty"`
+	Type                 string         `protobuf:"bytes,13,opt,name=type,proto3" json:"type,omitempty"`
+	V                    string         `protobuf:"bytes,14,opt,name=v,proto3" json:"v,omitempty"`
+	S                    string         `protobuf:"bytes,15,opt,name=s,proto3" json:"s,omitempty"`
+	R                    string         `protobuf:"bytes,16,opt,name=r,proto3" json:"r,omitempty"`
+	ChainId              string         `protobuf:"bytes,17,opt,name=chainId,proto3" json:"chainId,omitempty"`
+	MaxFeePerGas         string         `protobuf:"bytes,18,opt,name=maxFeePerGas,proto3" json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string         `protobuf:"bytes,19,opt,name=maxPriorityFeePerGas,proto3" json:"maxPriorityFeePerGas,omitempty"`
+	AccessList           []*AccessTuple `protobuf:"bytes,20,rep,name=accessList,proto3" json:"accessList,omitempty"`
+	MaxFeePerBlobGas     string         `protobuf:"bytes,21,opt,name=maxFeePerBlobGas,proto3" json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes  []string       `protobuf:"bytes,22,rep,name=blobVersionedHashes,proto3" json:"blobVersionedHashes,omitempty"`
+	YParity              string         `protobuf:"bytes,23,opt,name=yParity,proto3" json:"yParity,omitempty"`
+	// The version of the schema the record was written with, see SchemaVersion
+	// in proto.go.
+	SchemaVersion uint32 `protobuf:"varint,100,opt,name=schemaVersion,proto3" json:"schemaVersion,omitempty"`
 }
 
 func (x *Transaction) Reset() {
@@ -187,11 +197,122 @@ func (x *Transaction) GetR() string {
 	return ""
 }
 
+func (x *Transaction) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *Transaction) GetMaxFeePerGas() string {
+	if x != nil {
+		return x.MaxFeePerGas
+	}
+	return ""
+}
+
+func (x *Transaction) GetMaxPriorityFeePerGas() string {
+	if x != nil {
+		return x.MaxPriorityFeePerGas
+	}
+	return ""
+}
+
+func (x *Transaction) GetAccessList() []*AccessTuple {
+	if x != nil {
+		return x.AccessList
+	}
+	return nil
+}
+
+func (x *Transaction) GetMaxFeePerBlobGas() string {
+	if x != nil {
+		return x.MaxFeePerBlobGas
+	}
+	return ""
+}
+
+func (x *Transaction) GetBlobVersionedHashes() []string {
+	if x != nil {
+		return x.BlobVersionedHashes
+	}
+	return nil
+}
+
+func (x *Transaction) GetYParity() string {
+	if x != nil {
+		return x.YParity
+	}
+	return ""
+}
+
+func (x *Transaction) GetSchemaVersion() uint32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+type AccessTuple struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address     string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	StorageKeys []string `protobuf:"bytes,2,rep,name=storageKeys,proto3" json:"storageKeys,omitempty"`
+}
+
+func (x *AccessTuple) Reset() {
+	*x = AccessTuple{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transaction_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccessTuple) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessTuple) ProtoMessage() {}
+
+func (x *AccessTuple) ProtoReflect() protoreflect.Message {
+	mi := &file_transaction_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessTuple.ProtoReflect.Descriptor instead.
+func (*AccessTuple) Descriptor() ([]byte, []int) {
+	return file_transaction_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AccessTuple) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AccessTuple) GetStorageKeys() []string {
+	if x != nil {
+		return x.StorageKeys
+	}
+	return nil
+}
+
 var File_transaction_proto protoreflect.FileDescriptor
 
 var file_transaction_proto_rawDesc = []byte{
 	0x0a, 0x11, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xff, 0x02, 0x0a, 0x0b, 0x54,
+	0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xc3, 0x05, 0x0a, 0x0b, 0x54,
 	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61,
 	0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x14,
 	0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6e,
@@ -215,11 +336,36 @@ var file_transaction_proto_rawDesc = []byte{
 	0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x01, 0x76,
 	0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x01, 0x76, 0x12, 0x0c, 0x0a, 0x01, 0x73, 0x18, 0x0f,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x01, 0x73, 0x12, 0x0c, 0x0a, 0x01, 0x72, 0x18, 0x10, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x01, 0x72, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x74, 0x6f, 0x42, 0x35, 0x5a, 0x33,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61, 0x74, 0x69, 0x63,
-	0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x2d,
-	0x63, 0x6c, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x70, 0x62,
-	0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x28, 0x09, 0x52, 0x01, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12,
+	0x22, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72,
+	0x47, 0x61, 0x73, 0x12, 0x32, 0x0a, 0x14, 0x6d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69,
+	0x74, 0x79, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x14, 0x6d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x46, 0x65,
+	0x65, 0x50, 0x65, 0x72, 0x47, 0x61, 0x73, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x4c, 0x69, 0x73, 0x74, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x75, 0x70, 0x6c, 0x65, 0x52,
+	0x0a, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x6d,
+	0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72, 0x42, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x18,
+	0x15, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x6d, 0x61, 0x78, 0x46, 0x65, 0x65, 0x50, 0x65, 0x72,
+	0x42, 0x6c, 0x6f, 0x62, 0x47, 0x61, 0x73, 0x12, 0x30, 0x0a, 0x13, 0x62, 0x6c, 0x6f, 0x62, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x16,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x62, 0x6c, 0x6f, 0x62, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x79, 0x50, 0x61,
+	0x72, 0x69, 0x74, 0x79, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x79, 0x50, 0x61, 0x72,
+	0x69, 0x74, 0x79, 0x12, 0x24, 0x0a, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x64, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x74, 0x6f,
+	0x22, 0x49, 0x0a, 0x0b, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x75, 0x70, 0x6c, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x42, 0x35, 0x5a, 0x33, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61, 0x74, 0x69, 0x63, 0x6e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x2d, 0x63,
+	0x6c, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x70, 0x62, 0x3b,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -234,16 +380,18 @@ func file_transaction_proto_rawDescGZIP() []byte {
 	return file_transaction_proto_rawDescData
 }
 
-var file_transaction_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_transaction_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_transaction_proto_goTypes = []interface{}{
 	(*Transaction)(nil), // 0: proto.Transaction
+	(*AccessTuple)(nil), // 1: proto.AccessTuple
 }
 var file_transaction_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: proto.Transaction.accessList:type_name -> proto.AccessTuple
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_transaction_proto_init() }
@@ -264,6 +412,18 @@ func file_transaction_proto_init() {
 				return nil
 			}
 		}
+		file_transaction_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccessTuple); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_transaction_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	type x struct{}
@@ -272,7 +432,7 @@ func file_transaction_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_transaction_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
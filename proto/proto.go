@@ -0,0 +1,15 @@
+// Package proto embeds the schema of the protobuf dumps written by dumpblocks.
+package proto
+
+import "embed"
+
+// SchemaVersion is written to every record of protobuf dumps. It must be
+// incremented whenever fields are added to the schema, so that readers can tell
+// when a record has fields they don't know about. Records written before the
+// schema was versioned have a version of 0.
+const SchemaVersion = 1
+
+// Schemas contains the .proto files of the schema.
+//
+//go:embed block.proto transaction.proto
+var Schemas embed.FS
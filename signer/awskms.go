@@ -0,0 +1,63 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// AWSKMS signs with an ECC_SECG_P256K1 key in AWS KMS.
+type AWSKMS struct {
+	client *kms.KMS
+	keyID  string
+	pub    *ecdsa.PublicKey
+}
+
+// NewAWSKMS returns a signer for the key, using the credentials and region of
+// the default AWS configuration chain.
+func NewAWSKMS(ctx context.Context, keyID string) (*AWSKMS, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("an aws kms key id is required")
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, err
+	}
+	client := kms.New(sess)
+
+	out, err := client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the public key of %s: %w", keyID, err)
+	}
+	if spec := aws.StringValue(out.KeySpec); spec != kms.KeySpecEccSecgP256k1 {
+		return nil, fmt.Errorf("the key spec of %s is %s rather than %s", keyID, spec, kms.KeySpecEccSecgP256k1)
+	}
+	pub, err := parsePublicKey(out.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSKMS{client: client, keyID: keyID, pub: pub}, nil
+}
+
+func (a *AWSKMS) Address() common.Address {
+	return crypto.PubkeyToAddress(*a.pub)
+}
+
+func (a *AWSKMS) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	out, err := a.client.SignWithContext(ctx, &kms.SignInput{
+		KeyId:            aws.String(a.keyID),
+		Message:          hash,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(kms.SigningAlgorithmSpecEcdsaSha256),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with %s: %w", a.keyID, err)
+	}
+	return fromDER(out.Signature, hash, a.pub)
+}
@@ -0,0 +1,69 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+)
+
+const gcpSecp256k1 = "EC_SIGN_SECP256K1_SHA256"
+
+// GCPKMS signs with an EC_SIGN_SECP256K1_SHA256 key version in GCP Cloud KMS.
+type GCPKMS struct {
+	versions *cloudkms.ProjectsLocationsKeyRingsCryptoKeysCryptoKeyVersionsService
+	name     string
+	pub      *ecdsa.PublicKey
+}
+
+// NewGCPKMS returns a signer for the key version, which is named like
+// projects/P/locations/L/keyRings/R/cryptoKeys/K/cryptoKeyVersions/N, using
+// the application default credentials.
+func NewGCPKMS(ctx context.Context, name string) (*GCPKMS, error) {
+	if name == "" {
+		return nil, fmt.Errorf("a gcp kms key version is required")
+	}
+	svc, err := cloudkms.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := svc.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions
+
+	out, err := versions.GetPublicKey(name).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the public key of %s: %w", name, err)
+	}
+	if out.Algorithm != gcpSecp256k1 {
+		return nil, fmt.Errorf("the algorithm of %s is %s rather than %s", name, out.Algorithm, gcpSecp256k1)
+	}
+	pub, err := parsePublicKey([]byte(out.Pem))
+	if err != nil {
+		return nil, err
+	}
+	return &GCPKMS{versions: versions, name: name, pub: pub}, nil
+}
+
+func (g *GCPKMS) Address() common.Address {
+	return crypto.PubkeyToAddress(*g.pub)
+}
+
+// SignHash signs the hash as the digest. KMS only checks that it's 32 bytes
+// long, so the Keccak-256 hash can be passed as the SHA-256 digest.
+func (g *GCPKMS) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	req := &cloudkms.AsymmetricSignRequest{
+		Digest: &cloudkms.Digest{Sha256: base64.StdEncoding.EncodeToString(hash)},
+	}
+	out, err := g.versions.AsymmetricSign(g.name, req).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with %s: %w", g.name, err)
+	}
+	der, err := base64.StdEncoding.DecodeString(out.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return fromDER(der, hash, g.pub)
+}
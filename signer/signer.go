@@ -0,0 +1,176 @@
+// Package signer signs hashes and transactions with secp256k1 keys that are
+// either held locally or in a remote key management service, so that the keys
+// of funded accounts never have to leave it.
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/maticnetwork/polygon-cli/util"
+)
+
+// Help describes the remote signer references for flag usages.
+const Help = "Can also be a awskms:KEY_ID, gcpkms:KEY_VERSION, or vault:MOUNT/KEY remote signer reference"
+
+// Signer signs 32 byte hashes with a secp256k1 key.
+type Signer interface {
+	// Address returns the address of the key.
+	Address() common.Address
+	// SignHash returns the 65 byte [R || S || V] signature of the hash, with
+	// a V of 0 or 1 like crypto.Sign.
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// New returns the signer of the reference, which is one of:
+//
+//	awskms:KEY_ID         an AWS KMS key id, ARN, or alias
+//	gcpkms:KEY_VERSION    a GCP KMS projects/.../cryptoKeyVersions/N name
+//	vault:MOUNT/KEY       a HashiCorp Vault transit key
+//
+// Anything else is resolved as a local private key with util.ResolvePrivateKey.
+func New(ctx context.Context, ref string) (Signer, error) {
+	scheme, id, _ := strings.Cut(ref, ":")
+	switch scheme {
+	case "awskms":
+		return NewAWSKMS(ctx, id)
+	case "gcpkms":
+		return NewGCPKMS(ctx, id)
+	case "vault":
+		return NewVault(ctx, id)
+	}
+
+	key, err := util.ResolvePrivateKey(ref)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocal(key), nil
+}
+
+// IsRemote returns whether the reference is to a remote signer.
+func IsRemote(ref string) bool {
+	scheme, _, _ := strings.Cut(ref, ":")
+	return scheme == "awskms" || scheme == "gcpkms" || scheme == "vault"
+}
+
+// Local signs with a private key in memory.
+type Local struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewLocal returns a signer for the private key.
+func NewLocal(key *ecdsa.PrivateKey) *Local {
+	return &Local{key: key}
+}
+
+// PrivateKey returns the private key of the signer.
+func (l *Local) PrivateKey() *ecdsa.PrivateKey {
+	return l.key
+}
+
+func (l *Local) Address() common.Address {
+	return crypto.PubkeyToAddress(l.key.PublicKey)
+}
+
+func (l *Local) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, l.key)
+}
+
+// NewTransactor returns transact options that sign with the signer, like
+// bind.NewKeyedTransactorWithChainID does with a private key.
+func NewTransactor(s Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, bind.ErrNoChainID
+	}
+	if l, ok := s.(*Local); ok {
+		return bind.NewKeyedTransactorWithChainID(l.key, chainID)
+	}
+
+	from := s.Address()
+	txSigner := types.LatestSignerForChainID(chainID)
+	opts := &bind.TransactOpts{
+		From:    from,
+		Context: context.Background(),
+	}
+	opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if address != from {
+			return nil, bind.ErrNotAuthorized
+		}
+		return SignTx(opts.Context, s, tx, txSigner)
+	}
+	return opts, nil
+}
+
+// SignTx signs the transaction with the signer.
+func SignTx(ctx context.Context, s Signer, tx *types.Transaction, txSigner types.Signer) (*types.Transaction, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sig, err := s.SignHash(ctx, txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txSigner, sig)
+}
+
+// fromDER converts the ASN.1 DER encoded signature that KMSs return to the
+// [R || S || V] format. S is normalized to the lower half of the curve order,
+// which Ethereum requires, and V is found by recovering the public key.
+func fromDER(der []byte, hash []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("unable to decode the signature: %w", err)
+	}
+
+	n := crypto.S256().Params().N
+	if rs.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		rs.S.Sub(n, rs.S)
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	rs.R.FillBytes(sig[:32])
+	rs.S.FillBytes(sig[32:64])
+	want := crypto.FromECDSAPub(pub)
+	for v := byte(0); v < 2; v++ {
+		sig[crypto.RecoveryIDOffset] = v
+		got, err := crypto.Ecrecover(hash, sig)
+		if err == nil && string(got) == string(want) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("the signature doesn't recover to the public key of the signer")
+}
+
+// parsePublicKey parses the PEM or DER encoded SubjectPublicKeyInfo of a
+// secp256k1 key, which crypto/x509 doesn't support.
+func parsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unable to decode the public key: %w", err)
+	}
+	pub, err := crypto.UnmarshalPubkey(info.PublicKey.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("the public key isn't a secp256k1 key: %w", err)
+	}
+	return pub, nil
+}
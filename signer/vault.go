@@ -0,0 +1,96 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Vault signs with a secp256k1 key of a HashiCorp Vault transit engine. The
+// builtin transit engine doesn't support secp256k1 keys, so the mount has to be
+// a transit compatible plugin that does.
+type Vault struct {
+	client *vault.Client
+	mount  string
+	key    string
+	pub    *ecdsa.PublicKey
+}
+
+// NewVault returns a signer for the MOUNT/KEY transit key, using the address
+// and token in VAULT_ADDR and VAULT_TOKEN.
+func NewVault(ctx context.Context, ref string) (*Vault, error) {
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return nil, fmt.Errorf("the vault key %q isn't a MOUNT/KEY reference", ref)
+	}
+	mount, key := ref[:i], ref[i+1:]
+
+	client, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Logical().ReadWithContext(ctx, mount+"/keys/"+key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the transit key %s: %w", ref, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("the transit key %s doesn't exist", ref)
+	}
+	if t, _ := secret.Data["type"].(string); !strings.Contains(t, "secp256k1") {
+		return nil, fmt.Errorf("the transit key %s is a %s key rather than a secp256k1 key", ref, t)
+	}
+	pub, err := latestPublicKey(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the public key of %s: %w", ref, err)
+	}
+	return &Vault{client: client, mount: mount, key: key, pub: pub}, nil
+}
+
+// latestPublicKey returns the public key of the latest version of the key.
+func latestPublicKey(data map[string]interface{}) (*ecdsa.PublicKey, error) {
+	latest, ok := data["latest_version"].(json.Number)
+	if !ok {
+		return nil, errors.New("the latest version is missing")
+	}
+	keys, _ := data["keys"].(map[string]interface{})
+	version, _ := keys[latest.String()].(map[string]interface{})
+	pem, _ := version["public_key"].(string)
+	if pem == "" {
+		return nil, fmt.Errorf("version %s has no public key", latest)
+	}
+	return parsePublicKey([]byte(pem))
+}
+
+func (v *Vault) Address() common.Address {
+	return crypto.PubkeyToAddress(*v.pub)
+}
+
+func (v *Vault) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	secret, err := v.client.Logical().WriteWithContext(ctx, v.mount+"/sign/"+v.key, map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(hash),
+		"prehashed":            true,
+		"marshaling_algorithm": "asn1",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with %s/%s: %w", v.mount, v.key, err)
+	}
+	if secret == nil {
+		return nil, errors.New("vault returned no signature")
+	}
+	// The signature is formatted as vault:vN:base64.
+	signature, _ := secret.Data["signature"].(string)
+	encoded := signature[strings.LastIndex(signature, ":")+1:]
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the signature: %w", err)
+	}
+	return fromDER(der, hash, v.pub)
+}
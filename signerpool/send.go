@@ -0,0 +1,134 @@
+package signerpool
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxFeeBumps is how many times a transaction is replaced before giving up.
+const MaxFeeBumps = 3
+
+// TxSender sends signed transactions, like *ethclient.Client.
+type TxSender interface {
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// BatchCaller sends JSON-RPC batches, like *rpc.Client.
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// SendOptions are the options of sending the transactions of an account.
+type SendOptions struct {
+	// ChainID is the chain that the replacements are signed for.
+	ChainID *big.Int
+	// FeeBump is the percent that the fees of a transaction are raised by
+	// when the node rejects it because a pending transaction already has its
+	// nonce, so it's sent again as a replacement. Zero doesn't replace
+	// transactions.
+	FeeBump uint64
+	// Batch queues the transactions instead of sending them, if it's set.
+	Batch *Batch
+}
+
+// Send sends the transaction, or queues it if the options have a batch. A sent
+// transaction is replaced if it's underpriced.
+func (a *Account) Send(ctx context.Context, client TxSender, tx *types.Transaction, opts SendOptions) error {
+	if opts.Batch != nil {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		opts.Batch.Add(raw)
+		return nil
+	}
+	return a.Replace(ctx, client, tx, client.SendTransaction(ctx, tx), opts)
+}
+
+// Replace sends the transaction again with bumped fees if sending it failed
+// with err because it's an underpriced replacement, and the options have a fee
+// bump. It returns the error of the last send.
+func (a *Account) Replace(ctx context.Context, client TxSender, tx *types.Transaction, err error, opts SendOptions) error {
+	if opts.FeeBump == 0 {
+		return err
+	}
+	for i := 0; i < MaxFeeBumps && Underpriced(err); i++ {
+		data, bumpErr := BumpFees(tx, opts.FeeBump)
+		if bumpErr != nil {
+			return bumpErr
+		}
+		if tx, err = a.SignTx(ctx, data, opts.ChainID); err != nil {
+			return err
+		}
+		log.Debug().Uint64("nonce", tx.Nonce()).Str("hash", tx.Hash().Hex()).Msg("Replacing an underpriced transaction")
+		err = client.SendTransaction(ctx, tx)
+	}
+	return err
+}
+
+// ReplaceRaw is Replace for a raw transaction, like the ones of a batch.
+func (a *Account) ReplaceRaw(ctx context.Context, client TxSender, raw []byte, err error, opts SendOptions) error {
+	if opts.FeeBump == 0 || !Underpriced(err) {
+		return err
+	}
+	tx := new(types.Transaction)
+	if decodeErr := tx.UnmarshalBinary(raw); decodeErr != nil {
+		return err
+	}
+	return a.Replace(ctx, client, tx, err, opts)
+}
+
+// Underpriced reports whether a transaction was rejected because a pending
+// transaction with the same nonce has fees that are too close to its own.
+func Underpriced(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "replacement transaction underpriced")
+}
+
+// Batch queues raw transactions to send them in a single JSON-RPC batch of
+// eth_sendRawTransaction calls. It isn't safe for concurrent use.
+type Batch struct {
+	raw []hexutil.Bytes
+}
+
+// Add queues the raw transaction.
+func (b *Batch) Add(raw []byte) {
+	b.raw = append(b.raw, raw)
+}
+
+// Len returns the number of queued transactions.
+func (b *Batch) Len() int {
+	return len(b.raw)
+}
+
+// Send sends the queued transactions and empties the batch. It returns the
+// raw transactions with the error of each, and an error if the batch itself
+// couldn't be sent.
+func (b *Batch) Send(ctx context.Context, client BatchCaller) (raw []hexutil.Bytes, errs []error, err error) {
+	raw = b.raw
+	b.raw = nil
+
+	elems := make([]rpc.BatchElem, len(raw))
+	for i := range raw {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_sendRawTransaction",
+			Args:   []interface{}{raw[i]},
+			Result: new(common.Hash),
+		}
+	}
+	err = client.BatchCallContext(ctx, elems)
+	errs = make([]error, len(elems))
+	for i, elem := range elems {
+		errs[i] = err
+		if err == nil {
+			errs[i] = elem.Error
+		}
+	}
+	return raw, errs, err
+}
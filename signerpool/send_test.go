@@ -0,0 +1,178 @@
+package signerpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errUnderpriced = errors.New("replacement transaction underpriced")
+
+// txSender rejects the first transactions it gets as underpriced.
+type txSender struct {
+	underpriced int
+	sent        []*types.Transaction
+}
+
+func (s *txSender) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	s.sent = append(s.sent, tx)
+	if len(s.sent) <= s.underpriced {
+		return errUnderpriced
+	}
+	return nil
+}
+
+// batchCaller fails the sends of the batch at the indexes.
+type batchCaller struct {
+	failed map[int]bool
+	sent   int
+}
+
+func (c *batchCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	for i := range b {
+		c.sent++
+		if c.failed[i] {
+			b[i].Error = errUnderpriced
+		}
+	}
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	chainID := big.NewInt(137)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	tests := []struct {
+		name        string
+		underpriced int
+		feeBump     uint64
+		wantErr     bool
+		wantSent    int
+		wantTip     int64
+	}{
+		{
+			name:     "accepted",
+			feeBump:  10,
+			wantSent: 1,
+			wantTip:  100,
+		},
+		{
+			name:        "underpriced without a fee bump",
+			underpriced: 1,
+			wantErr:     true,
+			wantSent:    1,
+			wantTip:     100,
+		},
+		{
+			name:        "replaced once",
+			underpriced: 1,
+			feeBump:     10,
+			wantSent:    2,
+			wantTip:     110,
+		},
+		{
+			name:        "replaced twice",
+			underpriced: 2,
+			feeBump:     20,
+			wantSent:    3,
+			wantTip:     144,
+		},
+		{
+			name:        "replaced too often",
+			underpriced: MaxFeeBumps + 1,
+			feeBump:     10,
+			wantErr:     true,
+			wantSent:    MaxFeeBumps + 1,
+			wantTip:     134,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newTestAccount(t, 0)
+			tx, err := a.SignTx(context.Background(), &types.DynamicFeeTx{ChainID: chainID, Nonce: a.Reserve(), GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000), Gas: 21000, To: &to}, chainID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &txSender{underpriced: tc.underpriced}
+			err = a.Send(context.Background(), client, tx, SendOptions{ChainID: chainID, FeeBump: tc.feeBump})
+			if tc.wantErr && !errors.Is(err, errUnderpriced) {
+				t.Errorf("expected the underpriced error, got %v", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected the transaction to be sent, got %v", err)
+			}
+			if len(client.sent) != tc.wantSent {
+				t.Fatalf("expected %d sends, got %d", tc.wantSent, len(client.sent))
+			}
+			last := client.sent[len(client.sent)-1]
+			if last.Nonce() != tx.Nonce() {
+				t.Errorf("expected the replacement to keep the nonce %d, got %d", tx.Nonce(), last.Nonce())
+			}
+			if last.GasTipCap().Int64() != tc.wantTip {
+				t.Errorf("expected the last send to have a tip of %d, got %d", tc.wantTip, last.GasTipCap().Int64())
+			}
+			from, err := types.Sender(types.LatestSignerForChainID(chainID), last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != a.Address() {
+				t.Errorf("expected the last send to be signed by %s, got %s", a.Address().Hex(), from.Hex())
+			}
+		})
+	}
+}
+
+func TestSendBatch(t *testing.T) {
+	chainID := big.NewInt(137)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	a := newTestAccount(t, 0)
+	client := &txSender{}
+	opts := SendOptions{ChainID: chainID, FeeBump: 10, Batch: new(Batch)}
+
+	var txs []*types.Transaction
+	for i := 0; i < 3; i++ {
+		tx, err := a.SignTx(context.Background(), &types.DynamicFeeTx{ChainID: chainID, Nonce: a.Reserve(), GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000), Gas: 21000, To: &to}, chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = a.Send(context.Background(), client, tx, opts); err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	if len(client.sent) != 0 {
+		t.Fatalf("expected the transactions to be queued, but %d were sent", len(client.sent))
+	}
+	if opts.Batch.Len() != len(txs) {
+		t.Fatalf("expected %d queued transactions, got %d", len(txs), opts.Batch.Len())
+	}
+
+	caller := &batchCaller{failed: map[int]bool{1: true}}
+	raw, errs, err := opts.Batch.Send(context.Background(), caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller.sent != len(txs) || opts.Batch.Len() != 0 {
+		t.Fatalf("expected the %d transactions to be sent and the batch to be emptied, sent %d and kept %d", len(txs), caller.sent, opts.Batch.Len())
+	}
+	for k := range raw {
+		if (errs[k] != nil) != (k == 1) {
+			t.Errorf("unexpected error of transaction %d: %v", k, errs[k])
+		}
+		errs[k] = a.ReplaceRaw(context.Background(), client, raw[k], errs[k], opts)
+		if errs[k] != nil {
+			t.Errorf("expected transaction %d to be sent, got %v", k, errs[k])
+		}
+	}
+	// Only the underpriced transaction is replaced.
+	if len(client.sent) != 1 || client.sent[0].Nonce() != txs[1].Nonce() || client.sent[0].GasTipCap().Int64() != 110 {
+		t.Errorf("expected a single replacement of nonce %d with a tip of 110", txs[1].Nonce())
+	}
+}
@@ -0,0 +1,212 @@
+// Package signerpool manages the nonces of accounts that send transactions
+// concurrently. An Account hands out nonces to any number of senders, and the
+// nonces of transactions that couldn't be sent are handed out again before new
+// ones, so that a failed send doesn't leave a gap that blocks the transactions
+// after it. The fees of a transaction can be bumped to replace it.
+//
+// Transactions are sent with Account.Send, whose options replace the
+// underpriced ones with bumped fees or queue them in a Batch, so that the
+// senders of an account share the same send path.
+//
+// The package only depends on a node to read the pending nonces and send the
+// transactions, so it can be used with any client that has PendingNonceAt and
+// SendTransaction, like *ethclient.Client.
+package signerpool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/maticnetwork/polygon-cli/signer"
+)
+
+// MinBumpPercent is the smallest fee increase that geth and bor accept for a
+// transaction replacing another one with the same nonce.
+const MinBumpPercent = 10
+
+// NonceReader reads the pending nonces of accounts.
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// Account hands out the nonces of a signer. It's safe for concurrent use.
+type Account struct {
+	signer signer.Signer
+
+	mu   sync.Mutex
+	next uint64
+	// released are the nonces that were reserved but not used, sorted.
+	released []uint64
+}
+
+// NewAccount returns an account whose next nonce is nonce.
+func NewAccount(s signer.Signer, nonce uint64) *Account {
+	return &Account{signer: s, next: nonce}
+}
+
+// LoadAccount returns an account whose next nonce is the pending nonce of the
+// node.
+func LoadAccount(ctx context.Context, client NonceReader, s signer.Signer) (*Account, error) {
+	nonce, err := client.PendingNonceAt(ctx, s.Address())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the nonce of %s: %w", s.Address().Hex(), err)
+	}
+	return NewAccount(s, nonce), nil
+}
+
+// Address returns the address of the account.
+func (a *Account) Address() common.Address {
+	return a.signer.Address()
+}
+
+// Signer returns the signer of the account.
+func (a *Account) Signer() signer.Signer {
+	return a.signer
+}
+
+// Reserve returns the nonce of the next transaction, which is the lowest
+// released nonce if there's one. The nonce has to be released if the
+// transaction isn't sent.
+func (a *Account) Reserve() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.released) > 0 {
+		nonce := a.released[0]
+		a.released = a.released[1:]
+		return nonce
+	}
+	nonce := a.next
+	a.next++
+	return nonce
+}
+
+// Release gives back a reserved nonce whose transaction wasn't sent, so that
+// it's reserved again before any new nonce.
+func (a *Account) Release(nonce uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if nonce >= a.next {
+		return
+	}
+	i := sort.Search(len(a.released), func(i int) bool { return a.released[i] >= nonce })
+	if i < len(a.released) && a.released[i] == nonce {
+		return
+	}
+	a.released = append(a.released, 0)
+	copy(a.released[i+1:], a.released[i:])
+	a.released[i] = nonce
+}
+
+// Next returns the nonce that Reserve would return. All the nonces below it
+// have been used, so once the account's nonce on chain reaches it, all of its
+// transactions are mined.
+func (a *Account) Next() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.released) > 0 {
+		return a.released[0]
+	}
+	return a.next
+}
+
+// Sync heals the nonces after transactions were sent outside of the account,
+// or after a send failed because its nonce was already used. The next nonce
+// moves up to the pending nonce of the node, and the released nonces below it
+// are dropped. Nonces are never moved down, since transactions could still be
+// on their way to the node.
+func (a *Account) Sync(ctx context.Context, client NonceReader) error {
+	pending, err := client.PendingNonceAt(ctx, a.Address())
+	if err != nil {
+		return fmt.Errorf("unable to get the nonce of %s: %w", a.Address().Hex(), err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pending > a.next {
+		a.next = pending
+	}
+	i := sort.Search(len(a.released), func(i int) bool { return a.released[i] >= pending })
+	a.released = a.released[i:]
+	return nil
+}
+
+// SignTx signs the transaction for the chain.
+func (a *Account) SignTx(ctx context.Context, data types.TxData, chainID *big.Int) (*types.Transaction, error) {
+	return signer.SignTx(ctx, a.signer, types.NewTx(data), types.LatestSignerForChainID(chainID))
+}
+
+// NonceUsed reports whether the error of sending a transaction means that its
+// nonce was already used, in which case the nonce shouldn't be released and
+// the account should be synced instead. Nodes return these errors as
+// messages, so they're matched by the messages of geth, bor, erigon, and
+// nethermind.
+func NonceUsed(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"nonce too low", "already known", "known transaction", "replacement transaction underpriced", "oldnonce", "alreadyknown"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// BumpFees returns the data of a transaction that replaces the transaction,
+// with the same nonce and the fees raised by percent, rounded up. The percent
+// has to be at least MinBumpPercent for nodes to accept the replacement.
+func BumpFees(tx *types.Transaction, percent uint64) (types.TxData, error) {
+	if percent < MinBumpPercent {
+		return nil, fmt.Errorf("the fees have to be bumped by at least %d percent, got %d", MinBumpPercent, percent)
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bump(tx.GasPrice(), percent),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}, nil
+	case types.AccessListTxType:
+		return &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   bump(tx.GasPrice(), percent),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	case types.DynamicFeeTxType:
+		return &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  bump(tx.GasTipCap(), percent),
+			GasFeeCap:  bump(tx.GasFeeCap(), percent),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	}
+	return nil, fmt.Errorf("the fees of transactions of type %d can't be bumped", tx.Type())
+}
+
+// bump raises the fee by percent, rounded up.
+func bump(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
@@ -0,0 +1,289 @@
+package signerpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/maticnetwork/polygon-cli/signer"
+)
+
+type nonceReader uint64
+
+func (n nonceReader) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return uint64(n), nil
+}
+
+func newTestAccount(t *testing.T, nonce uint64) *Account {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAccount(signer.NewLocal(key), nonce)
+}
+
+func TestAccountConcurrentReserve(t *testing.T) {
+	const (
+		start    = 7
+		senders  = 32
+		requests = 100
+	)
+	a := newTestAccount(t, start)
+
+	var mu sync.Mutex
+	used := make(map[uint64]int)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				nonce := a.Reserve()
+				// Every third send fails, and its nonce is sent again.
+				if (i+j)%3 == 0 {
+					a.Release(nonce)
+					nonce = a.Reserve()
+				}
+				mu.Lock()
+				used[nonce]++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := uint64(senders * requests)
+	if uint64(len(used)) != total {
+		t.Fatalf("expected %d distinct nonces, got %d", total, len(used))
+	}
+	for nonce := uint64(start); nonce < start+total; nonce++ {
+		if used[nonce] != 1 {
+			t.Errorf("nonce %d was used %d times", nonce, used[nonce])
+		}
+	}
+	if next := a.Next(); next != start+total {
+		t.Errorf("expected the next nonce to be %d, got %d", start+total, next)
+	}
+}
+
+func TestAccountNonces(t *testing.T) {
+	type test struct {
+		name     string
+		reserved int
+		released []uint64
+		pending  uint64
+		want     []uint64
+	}
+
+	tests := []test{
+		{
+			name:     "no releases",
+			reserved: 3,
+			want:     []uint64{3, 4},
+		},
+		{
+			name:     "lowest released first",
+			reserved: 5,
+			released: []uint64{3, 1},
+			want:     []uint64{1, 3, 5},
+		},
+		{
+			name:     "duplicate release",
+			reserved: 5,
+			released: []uint64{2, 2},
+			want:     []uint64{2, 5},
+		},
+		{
+			name:     "release of an unreserved nonce",
+			reserved: 2,
+			released: []uint64{2, 9},
+			want:     []uint64{2, 3},
+		},
+		{
+			name:     "sync drops the used releases",
+			reserved: 5,
+			released: []uint64{0, 2, 4},
+			pending:  3,
+			want:     []uint64{4, 5},
+		},
+		{
+			name:     "sync moves the next nonce up",
+			reserved: 2,
+			released: []uint64{1},
+			pending:  10,
+			want:     []uint64{10, 11},
+		},
+		{
+			name:     "sync never moves the next nonce down",
+			reserved: 4,
+			released: []uint64{2},
+			pending:  1,
+			want:     []uint64{2, 4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newTestAccount(t, 0)
+			for i := 0; i < tc.reserved; i++ {
+				a.Reserve()
+			}
+			for _, nonce := range tc.released {
+				a.Release(nonce)
+			}
+			if tc.pending > 0 {
+				if err := a.Sync(context.Background(), nonceReader(tc.pending)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if next := a.Next(); next != tc.want[0] {
+				t.Errorf("expected the next nonce to be %d, got %d", tc.want[0], next)
+			}
+			for i, want := range tc.want {
+				if got := a.Reserve(); got != want {
+					t.Errorf("reservation %d: expected nonce %d, got %d", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNonceUsed(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("nonce too low: next nonce 5, tx nonce 4"), true},
+		{errors.New("already known"), true},
+		{errors.New("Known transaction: 0x1234"), true},
+		{errors.New("replacement transaction underpriced"), true},
+		{errors.New("OldNonce"), true},
+		{errors.New("AlreadyKnown"), true},
+		{errors.New("insufficient funds for gas * price + value"), false},
+		{errors.New("context deadline exceeded"), false},
+	}
+
+	for _, tc := range tests {
+		if got := NonceUsed(tc.err); got != tc.want {
+			t.Errorf("NonceUsed(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		fee     int64
+		percent uint64
+		want    int64
+	}{
+		{0, 10, 0},
+		{1, 10, 2},
+		{9, 10, 10},
+		{10, 10, 11},
+		{100, 10, 110},
+		{101, 10, 112},
+		{15, 12, 17},
+		{1000000000, 10, 1100000000},
+		{30000000001, 10, 33000000002},
+		{100, 100, 200},
+	}
+
+	for _, tc := range tests {
+		if got := bump(big.NewInt(tc.fee), tc.percent); got.Int64() != tc.want {
+			t.Errorf("bump(%d, %d) = %d, want %d", tc.fee, tc.percent, got.Int64(), tc.want)
+		}
+	}
+}
+
+func TestBumpFees(t *testing.T) {
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	chainID := big.NewInt(137)
+
+	tests := []struct {
+		name    string
+		data    types.TxData
+		percent uint64
+		wantErr bool
+		tip     int64
+		feeCap  int64
+	}{
+		{
+			name:    "legacy",
+			data:    &types.LegacyTx{Nonce: 3, GasPrice: big.NewInt(101), Gas: 21000, To: &to},
+			percent: 10,
+			tip:     112,
+			feeCap:  112,
+		},
+		{
+			name:    "access list",
+			data:    &types.AccessListTx{ChainID: chainID, Nonce: 3, GasPrice: big.NewInt(50), Gas: 21000, To: &to},
+			percent: 20,
+			tip:     60,
+			feeCap:  60,
+		},
+		{
+			name:    "dynamic fee",
+			data:    &types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(9), GasFeeCap: big.NewInt(1000), Gas: 21000, To: &to},
+			percent: MinBumpPercent,
+			tip:     10,
+			feeCap:  1100,
+		},
+		{
+			name:    "percent below the minimum",
+			data:    &types.LegacyTx{Nonce: 3, GasPrice: big.NewInt(100), Gas: 21000, To: &to},
+			percent: MinBumpPercent - 1,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := types.NewTx(tc.data)
+			data, err := BumpFees(tx, tc.percent)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			bumped := types.NewTx(data)
+			if bumped.Type() != tx.Type() || bumped.Nonce() != tx.Nonce() || bumped.Gas() != tx.Gas() || *bumped.To() != *tx.To() {
+				t.Errorf("expected the replacement to keep the type, nonce, gas, and recipient of the transaction")
+			}
+			if bumped.GasTipCap().Int64() != tc.tip {
+				t.Errorf("expected a tip of %d, got %d", tc.tip, bumped.GasTipCap().Int64())
+			}
+			if bumped.GasFeeCap().Int64() != tc.feeCap {
+				t.Errorf("expected a fee cap of %d, got %d", tc.feeCap, bumped.GasFeeCap().Int64())
+			}
+		})
+	}
+}
+
+func TestSignTx(t *testing.T) {
+	a := newTestAccount(t, 0)
+	chainID := big.NewInt(137)
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	tx, err := a.SignTx(context.Background(), &types.DynamicFeeTx{ChainID: chainID, Nonce: a.Reserve(), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &to}, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != a.Address() {
+		t.Errorf("expected the transaction to be signed by %s, got %s", a.Address().Hex(), from.Hex())
+	}
+}
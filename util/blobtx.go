@@ -0,0 +1,205 @@
+package util
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// BlobTxType is the EIP-4844 transaction type.
+	BlobTxType = 0x03
+
+	// BlobCommitmentVersionKZG is the version byte of the versioned hashes of
+	// KZG commitments.
+	BlobCommitmentVersionKZG = 0x01
+)
+
+// BlobTx is an EIP-4844 transaction. Like SetCodeTx, the go-ethereum version
+// used by polycli doesn't know about this type, so it's decoded here.
+type BlobTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList ethtypes.AccessList
+	BlobFeeCap *big.Int
+	BlobHashes []common.Hash
+
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+// BlobSidecar holds the blobs of a blob transaction in its network form, which
+// is how it's passed to eth_sendRawTransaction. Version 0 has a KZG proof per
+// blob and version 1 has the cell proofs of EIP-7594.
+type BlobSidecar struct {
+	Version     uint8
+	Blobs       [][]byte
+	Commitments [][]byte
+	Proofs      [][]byte
+}
+
+// DecodeBlobTx decodes the typed transaction envelope of a blob transaction.
+// The sidecar is nil unless the transaction is in its network form.
+func DecodeBlobTx(data []byte) (*BlobTx, *BlobSidecar, error) {
+	if len(data) == 0 || data[0] != BlobTxType {
+		return nil, nil, errors.New("the transaction isn't a blob transaction")
+	}
+	_, content, _, err := rlp.Split(data[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	kind, _, _, err := rlp.Split(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := new(BlobTx)
+	if kind != rlp.List {
+		if err = rlp.DecodeBytes(data[1:], tx); err != nil {
+			return nil, nil, err
+		}
+		return tx, nil, nil
+	}
+
+	var items []rlp.RawValue
+	if err = rlp.DecodeBytes(data[1:], &items); err != nil {
+		return nil, nil, err
+	}
+	sidecar := new(BlobSidecar)
+	switch len(items) {
+	case 4:
+	case 5:
+		if err = rlp.DecodeBytes(items[1], &sidecar.Version); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode the sidecar version: %w", err)
+		}
+		items = append(items[:1], items[2:]...)
+	default:
+		return nil, nil, fmt.Errorf("the blob transaction wrapper has %d items", len(items))
+	}
+	if err = rlp.DecodeBytes(items[0], tx); err != nil {
+		return nil, nil, err
+	}
+	for i, field := range []*[][]byte{&sidecar.Blobs, &sidecar.Commitments, &sidecar.Proofs} {
+		if err = rlp.DecodeBytes(items[i+1], field); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode the sidecar: %w", err)
+		}
+	}
+	return tx, sidecar, nil
+}
+
+func (tx *BlobTx) fields() []interface{} {
+	return []interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, tx.AccessList, tx.BlobFeeCap, tx.BlobHashes}
+}
+
+// SigningHash returns the hash the sender signs.
+func (tx *BlobTx) SigningHash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes(tx.fields())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{BlobTxType}, payload), nil
+}
+
+// Hash returns the hash of a signed transaction, which doesn't cover the
+// sidecar.
+func (tx *BlobTx) Hash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes(append(tx.fields(), tx.V, tx.R, tx.S))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{BlobTxType}, payload), nil
+}
+
+// Sender recovers the address that signed the transaction.
+func (tx *BlobTx) Sender() (common.Address, error) {
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return RecoverTxSigner(hash, tx.V, tx.R, tx.S)
+}
+
+// KZGToVersionedHash returns the versioned hash of a KZG commitment, which is
+// how blob transactions refer to their blobs.
+func KZGToVersionedHash(commitment []byte) common.Hash {
+	h := sha256.Sum256(commitment)
+	h[0] = BlobCommitmentVersionKZG
+	return h
+}
+
+// RecoverTxSigner validates the signature values of a typed transaction or
+// authorization, whose V is the 0 or 1 y parity, and recovers the address
+// that signed the hash.
+func RecoverTxSigner(hash common.Hash, v, r, s *big.Int) (common.Address, error) {
+	if v == nil || r == nil || s == nil {
+		return common.Address{}, errors.New("the signature is missing")
+	}
+	if !v.IsUint64() || v.Uint64() > 1 {
+		return common.Address{}, fmt.Errorf("the y parity %s isn't 0 or 1", v)
+	}
+	if !crypto.ValidateSignatureValues(byte(v.Uint64()), r, s, true) {
+		return common.Address{}, errors.New("the signature values are out of range")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[crypto.RecoveryIDOffset] = byte(v.Uint64())
+	return RecoverSigner(hash.Bytes(), sig)
+}
+
+// TxSender returns the hash and the sender of a raw transaction of any type.
+// Legacy transactions without replay protection are recovered without a
+// chain ID.
+func TxSender(data []byte) (common.Hash, common.Address, error) {
+	if len(data) == 0 {
+		return common.Hash{}, common.Address{}, errors.New("the transaction is empty")
+	}
+	switch data[0] {
+	case BlobTxType:
+		tx, _, err := DecodeBlobTx(data)
+		if err != nil {
+			return common.Hash{}, common.Address{}, err
+		}
+		hash, err := tx.Hash()
+		if err != nil {
+			return common.Hash{}, common.Address{}, err
+		}
+		from, err := tx.Sender()
+		return hash, from, err
+	case SetCodeTxType:
+		tx := new(SetCodeTx)
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return common.Hash{}, common.Address{}, err
+		}
+		hash, err := tx.Hash()
+		if err != nil {
+			return common.Hash{}, common.Address{}, err
+		}
+		from, err := tx.Sender()
+		return hash, from, err
+	}
+
+	tx := new(ethtypes.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, common.Address{}, err
+	}
+	var signer ethtypes.Signer = ethtypes.HomesteadSigner{}
+	if tx.Protected() {
+		signer = ethtypes.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err := ethtypes.Sender(signer, tx)
+	return tx.Hash(), from, err
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// The exit codes of polycli, so that scripts and CI can tell the kinds of
+// failures apart. Errors that aren't classified exit with ExitFailure.
+const (
+	ExitOK = 0
+	// ExitFailure is any error that isn't one of the kinds below.
+	ExitFailure = 1
+	// ExitConfig is an invalid flag, argument, or configuration file.
+	ExitConfig = 2
+	// ExitUnreachable is an RPC endpoint or node that couldn't be reached.
+	ExitUnreachable = 3
+	// ExitPartial is a command that ran, but failed for some of its
+	// requests or targets.
+	ExitPartial = 4
+	// ExitThreshold is a command that ran, but whose results breached a
+	// threshold that was set.
+	ExitThreshold = 5
+)
+
+// ExitError is an error with the exit code of the kind of failure it is.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError marks the error as an invalid flag, argument, or configuration.
+func ConfigError(err error) error {
+	return &ExitError{Code: ExitConfig, Err: err}
+}
+
+// UnreachableError marks the error as an endpoint that couldn't be reached.
+func UnreachableError(err error) error {
+	return &ExitError{Code: ExitUnreachable, Err: err}
+}
+
+// PartialError returns an error for a command that failed for some of its
+// requests or targets.
+func PartialError(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitPartial, Err: fmt.Errorf(format, args...)}
+}
+
+// ThresholdError returns an error for results that breached a threshold.
+func ThresholdError(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitThreshold, Err: fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the exit code of the error. Network errors that weren't
+// marked are unreachable endpoints, since that's what they are in practice.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return ExitUnreachable
+	}
+	return ExitFailure
+}
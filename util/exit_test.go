@@ -0,0 +1,90 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	type test struct {
+		name string
+		err  error
+		code int
+	}
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []test{
+		{
+			name: "no error",
+			err:  nil,
+			code: ExitOK,
+		},
+		{
+			name: "unclassified",
+			err:  errors.New("failed"),
+			code: ExitFailure,
+		},
+		{
+			name: "config",
+			err:  ConfigError(errors.New("invalid flag")),
+			code: ExitConfig,
+		},
+		{
+			name: "unreachable",
+			err:  UnreachableError(errors.New("no route")),
+			code: ExitUnreachable,
+		},
+		{
+			name: "partial",
+			err:  PartialError("%d of %d requests failed", 3, 10),
+			code: ExitPartial,
+		},
+		{
+			name: "wrapped partial",
+			err:  fmt.Errorf("load test: %w", PartialError("%d of %d requests failed", 3, 10)),
+			code: ExitPartial,
+		},
+		{
+			name: "threshold",
+			err:  ThresholdError("%d regressions", 2),
+			code: ExitThreshold,
+		},
+		{
+			name: "network error",
+			err:  dialErr,
+			code: ExitUnreachable,
+		},
+		{
+			name: "url error",
+			err:  &url.Error{Op: "Post", URL: "http://localhost:8545", Err: errors.New("EOF")},
+			code: ExitUnreachable,
+		},
+		{
+			name: "classified network error",
+			err:  PartialError("some peers failed: %w", dialErr),
+			code: ExitPartial,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := ExitCode(tc.err); code != tc.code {
+				t.Errorf("expected exit code %d, got %d", tc.code, code)
+			}
+		})
+	}
+}
+
+func TestPartialError(t *testing.T) {
+	err := PartialError("%d of %d requests failed", 3, 10)
+	if err.Error() != "3 of 10 requests failed" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitPartial {
+		t.Errorf("expected an exit error with code %d", ExitPartial)
+	}
+}
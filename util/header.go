@@ -0,0 +1,179 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// extraVanity is the number of bytes at the start of the extra data that
+	// bor and clique leave to the signer.
+	extraVanity = 32
+	// borValidatorLength is the length of a validator in the extra data of a
+	// bor sprint end header, which is the address followed by the power.
+	borValidatorLength = common.AddressLength + 20
+)
+
+// ParseHeader decodes the header of a block returned by eth_getBlockByNumber
+// and returns the hash that the RPC claims it has.
+func ParseHeader(raw json.RawMessage) (*types.Header, common.Hash, error) {
+	var claimed struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &claimed); err != nil {
+		return nil, common.Hash{}, fmt.Errorf("unable to decode block hash: %w", err)
+	}
+
+	header := new(types.Header)
+	if err := json.Unmarshal(raw, header); err != nil {
+		return nil, common.Hash{}, fmt.Errorf("unable to decode header %s: %w", claimed.Hash.Hex(), err)
+	}
+	return header, claimed.Hash, nil
+}
+
+// SealSigner recovers the signer from the clique seal at the end of the extra
+// data.
+func SealSigner(header *types.Header) (common.Address, error) {
+	return recoverSeal(header, clique.SealHash)
+}
+
+// BorSealSigner recovers the signer from the bor seal at the end of the extra
+// data. The seal only covers the base fee from the Jaipur block.
+func BorSealSigner(header *types.Header, jaipurBlock uint64) (common.Address, error) {
+	return recoverSeal(header, func(header *types.Header) common.Hash {
+		return BorSealHash(header, jaipurBlock)
+	})
+}
+
+func recoverSeal(header *types.Header, sealHash func(*types.Header) common.Hash) (common.Address, error) {
+	sigStart := len(header.Extra) - crypto.SignatureLength
+	if sigStart < 0 {
+		return common.Address{}, fmt.Errorf("extra data is too short to contain a seal")
+	}
+	pubkey, err := crypto.Ecrecover(sealHash(header).Bytes(), header.Extra[sigStart:])
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(crypto.Keccak256(pubkey[1:])[12:]), nil
+}
+
+// BorSealHash returns the hash that bor signs to seal the header, which is the
+// hash of the header without the seal. Unlike clique, the base fee is only
+// covered from the Jaipur block, even if the header has one before. The extra
+// data has to be long enough to contain a seal.
+func BorSealHash(header *types.Header, jaipurBlock uint64) common.Hash {
+	enc := []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-crypto.SignatureLength],
+		header.MixDigest,
+		header.Nonce,
+	}
+	if header.Number.Uint64() >= jaipurBlock && header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	data, err := rlp.EncodeToBytes(enc)
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+// CheckBorValidatorBytes checks that the extra data of the header has validator
+// bytes if and only if it's the last header of a sprint, like bor does.
+func CheckBorValidatorBytes(header *types.Header, sprint uint64) error {
+	extra, err := ParseBorExtra(header.Extra)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	sprintEnd := (number+1)%sprint == 0
+	if sprintEnd && len(extra.Validators) == 0 {
+		return fmt.Errorf("sprint end header %d has no validator bytes", number)
+	}
+	if !sprintEnd && len(extra.Validators) > 0 {
+		return fmt.Errorf("header %d has %d validators but isn't the end of a sprint", number, len(extra.Validators))
+	}
+	return nil
+}
+
+// BorValidators returns the validator set in the extra data of a bor sprint
+// end header, which is the set that signs the blocks of the next sprint. The
+// second value is false for headers that don't have a validator set.
+func BorValidators(header *types.Header) (map[common.Address]struct{}, bool) {
+	extra, err := ParseBorExtra(header.Extra)
+	if err != nil || len(extra.Validators) == 0 {
+		return nil, false
+	}
+	validators := make(map[common.Address]struct{}, len(extra.Validators))
+	for _, v := range extra.Validators {
+		validators[v.Address] = struct{}{}
+	}
+	return validators, true
+}
+
+// BorValidator is a validator in the extra data of a bor sprint end header.
+type BorValidator struct {
+	Address common.Address `json:"address"`
+	Power   *hexutil.Big   `json:"power"`
+}
+
+// BorExtra is the extra data of a bor header.
+type BorExtra struct {
+	Vanity       hexutil.Bytes  `json:"vanity"`
+	Validators   []BorValidator `json:"validators,omitempty"`
+	TxDependency [][]uint64     `json:"txDependency,omitempty"`
+	Seal         hexutil.Bytes  `json:"seal"`
+}
+
+// ParseBorExtra splits the extra data of a bor header into the vanity, the
+// validator set of sprint end headers, and the seal. Since Napoli, the part in
+// between is the RLP encoded validator bytes and transaction dependencies
+// rather than the validator bytes themselves.
+func ParseBorExtra(extra []byte) (*BorExtra, error) {
+	sigStart := len(extra) - crypto.SignatureLength
+	if sigStart < extraVanity {
+		return nil, fmt.Errorf("extra data is too short to contain a vanity and seal")
+	}
+	out := &BorExtra{Vanity: extra[:extraVanity], Seal: extra[sigStart:]}
+
+	validatorBytes := extra[extraVanity:sigStart]
+	var blockExtra struct {
+		ValidatorBytes []byte
+		TxDependency   [][]uint64
+		Rest           []rlp.RawValue `rlp:"tail"`
+	}
+	if len(validatorBytes) > 0 && rlp.DecodeBytes(validatorBytes, &blockExtra) == nil {
+		validatorBytes = blockExtra.ValidatorBytes
+		out.TxDependency = blockExtra.TxDependency
+	}
+
+	if len(validatorBytes)%borValidatorLength != 0 {
+		return nil, fmt.Errorf("the validator bytes are %d bytes long, which isn't a multiple of %d", len(validatorBytes), borValidatorLength)
+	}
+	for i := 0; i < len(validatorBytes); i += borValidatorLength {
+		out.Validators = append(out.Validators, BorValidator{
+			Address: common.BytesToAddress(validatorBytes[i : i+common.AddressLength]),
+			Power:   (*hexutil.Big)(new(big.Int).SetBytes(validatorBytes[i+common.AddressLength : i+borValidatorLength])),
+		})
+	}
+	return out, nil
+}
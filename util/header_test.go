@@ -0,0 +1,236 @@
+package util
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// testBorHeader returns a header with the extra data and an empty seal.
+func testBorHeader(number int64, baseFee *big.Int, validatorBytes []byte) *types.Header {
+	extra := append(make([]byte, extraVanity), validatorBytes...)
+	return &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Coinbase:   common.Address{},
+		Difficulty: big.NewInt(16),
+		Number:     big.NewInt(number),
+		GasLimit:   30000000,
+		GasUsed:    21000,
+		Time:       1700000000,
+		Extra:      append(extra, make([]byte, crypto.SignatureLength)...),
+		BaseFee:    baseFee,
+	}
+}
+
+func TestBorSealSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA("42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	tests := []struct {
+		name    string
+		header  *types.Header
+		jaipur  uint64
+		baseFee bool
+	}{
+		{
+			name:    "base fee after jaipur",
+			header:  testBorHeader(100, big.NewInt(30000000000), nil),
+			jaipur:  100,
+			baseFee: true,
+		},
+		{
+			name:   "base fee before jaipur",
+			header: testBorHeader(99, big.NewInt(30000000000), nil),
+			jaipur: 100,
+		},
+		{
+			name:   "no base fee after jaipur",
+			header: testBorHeader(100, nil, nil),
+			jaipur: 100,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := tc.header
+			hash := BorSealHash(header, tc.jaipur)
+			if covered := hash != BorSealHash(header, header.Number.Uint64()+1); covered != tc.baseFee {
+				t.Errorf("expected the seal hash to cover the base fee: %t", tc.baseFee)
+			}
+			// The seal hash has the clique layout, unless the header has a
+			// base fee that it doesn't cover.
+			wantClique := header.BaseFee == nil || tc.baseFee
+			if isClique := hash == clique.SealHash(header); isClique != wantClique {
+				t.Errorf("expected the seal hash to match the clique one: %t", wantClique)
+			}
+
+			sig, err := crypto.Sign(hash.Bytes(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sig)
+			got, err := BorSealSigner(header, tc.jaipur)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != signer {
+				t.Errorf("expected the signer %s, got %s", signer.Hex(), got.Hex())
+			}
+		})
+	}
+}
+
+func TestCheckBorValidatorBytes(t *testing.T) {
+	validators := append(common.HexToAddress("0x1000000000000000000000000000000000000001").Bytes(), make([]byte, 20)...)
+	validators[len(validators)-1] = 1
+	blockExtra := func(validatorBytes []byte) []byte {
+		b, err := rlp.EncodeToBytes([]interface{}{validatorBytes, [][]uint64{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name           string
+		number         int64
+		validatorBytes []byte
+		valid          bool
+	}{
+		{
+			name:           "sprint end with validators",
+			number:         15,
+			validatorBytes: validators,
+			valid:          true,
+		},
+		{
+			name:   "sprint end without validators",
+			number: 15,
+		},
+		{
+			name:           "sprint with validators",
+			number:         14,
+			validatorBytes: validators,
+		},
+		{
+			name:   "sprint without validators",
+			number: 16,
+			valid:  true,
+		},
+		{
+			name:           "sprint end with encoded validators",
+			number:         31,
+			validatorBytes: blockExtra(validators),
+			valid:          true,
+		},
+		{
+			name:           "sprint end without encoded validators",
+			number:         31,
+			validatorBytes: blockExtra(nil),
+		},
+		{
+			name:           "sprint without encoded validators",
+			number:         30,
+			validatorBytes: blockExtra(nil),
+			valid:          true,
+		},
+		{
+			name:           "truncated validator",
+			number:         15,
+			validatorBytes: validators[:len(validators)-1],
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := testBorHeader(tc.number, nil, bytes.Clone(tc.validatorBytes))
+			err := CheckBorValidatorBytes(header, 16)
+			if tc.valid && err != nil {
+				t.Errorf("expected the validator bytes to be valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected the validator bytes to be rejected")
+			}
+		})
+	}
+}
+
+func TestParseBorExtra(t *testing.T) {
+	v1 := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	v2 := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	validatorBytes := append(append(v1.Bytes(), common.LeftPadBytes([]byte{0x0a}, 20)...), append(v2.Bytes(), common.LeftPadBytes([]byte{0x01, 0x00}, 20)...)...)
+	napoli, err := rlp.EncodeToBytes([]interface{}{validatorBytes, [][]uint64{{}, {0}, {0, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		middle       []byte
+		validators   map[common.Address]int64
+		txDependency [][]uint64
+	}{
+		{
+			name: "no validators",
+		},
+		{
+			name:       "validator bytes",
+			middle:     validatorBytes,
+			validators: map[common.Address]int64{v1: 10, v2: 256},
+		},
+		{
+			name:         "encoded validator bytes and dependencies",
+			middle:       napoli,
+			validators:   map[common.Address]int64{v1: 10, v2: 256},
+			txDependency: [][]uint64{{}, {0}, {0, 1}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := testBorHeader(15, nil, tc.middle)
+			header.Extra[0] = 0xaa
+			header.Extra[len(header.Extra)-1] = 0xbb
+
+			extra, err := ParseBorExtra(header.Extra)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(extra.Vanity) != extraVanity || extra.Vanity[0] != 0xaa {
+				t.Errorf("expected the %d byte vanity, got %x", extraVanity, extra.Vanity)
+			}
+			if len(extra.Seal) != crypto.SignatureLength || extra.Seal[crypto.SignatureLength-1] != 0xbb {
+				t.Errorf("expected the %d byte seal, got %x", crypto.SignatureLength, extra.Seal)
+			}
+			if len(extra.Validators) != len(tc.validators) {
+				t.Fatalf("expected %d validators, got %d", len(tc.validators), len(extra.Validators))
+			}
+			for _, v := range extra.Validators {
+				if power, ok := tc.validators[v.Address]; !ok || v.Power.ToInt().Int64() != power {
+					t.Errorf("unexpected validator %s with the power %s", v.Address.Hex(), v.Power)
+				}
+			}
+			if len(extra.TxDependency) != len(tc.txDependency) {
+				t.Errorf("expected %d transaction dependencies, got %d", len(tc.txDependency), len(extra.TxDependency))
+			}
+
+			validators, ok := BorValidators(header)
+			if ok != (len(tc.validators) > 0) || len(validators) != len(tc.validators) {
+				t.Errorf("expected the validator set of %d validators, got %d", len(tc.validators), len(validators))
+			}
+		})
+	}
+
+	if _, err := ParseBorExtra(make([]byte, extraVanity+crypto.SignatureLength-1)); err == nil {
+		t.Error("expected an error for extra data without room for a seal")
+	}
+}
@@ -0,0 +1,378 @@
+package util
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// FieldElementsPerBlob is the number of 32 byte field elements of a blob.
+	FieldElementsPerBlob = 4096
+	// BlobSize is the size of a blob in bytes.
+	BlobSize = FieldElementsPerBlob * 32
+	// BlobDataPerFieldElement is the number of bytes EncodeBlobs stores in a
+	// field element. The first byte of each element is left zero, so that the
+	// element is always less than the modulus.
+	BlobDataPerFieldElement = 31
+	// KZGPointSize is the size of a compressed commitment or proof in bytes.
+	KZGPointSize = 48
+
+	// blobPaddingMarker follows the data in the last blob, so that the zeros
+	// that pad the blob aren't mistaken for data.
+	blobPaddingMarker = 0x80
+)
+
+// kzgFiatShamirDomain is the domain separator of the challenge of blob proofs.
+var kzgFiatShamirDomain = []byte("FSBLOBVERIFY_V1_")
+
+//go:embed kzg_trusted_setup.json
+var kzgTrustedSetupJSON []byte
+
+// kzgSetup holds the trusted setup of the KZG ceremony and the roots of
+// unity that the blob polynomials are evaluated over. It's parsed on first
+// use, since checking the points takes a moment.
+type kzgSetup struct {
+	g1Lagrange []bls12381.G1Affine
+	g2Tau      bls12381.G2Affine
+	roots      []fr.Element
+}
+
+var (
+	kzgOnce   sync.Once
+	kzgLoaded *kzgSetup
+	kzgErr    error
+)
+
+func loadKZGSetup() (*kzgSetup, error) {
+	kzgOnce.Do(func() {
+		var raw struct {
+			G1Lagrange []hexutil.Bytes `json:"g1_lagrange"`
+			G2Monomial []hexutil.Bytes `json:"g2_monomial"`
+		}
+		if kzgErr = json.Unmarshal(kzgTrustedSetupJSON, &raw); kzgErr != nil {
+			return
+		}
+		if len(raw.G1Lagrange) != FieldElementsPerBlob || len(raw.G2Monomial) < 2 {
+			kzgErr = errors.New("the trusted setup is incomplete")
+			return
+		}
+
+		// The setup has the Lagrange points in their natural order, and the
+		// blobs are in bit reversed order, so the points are reordered to
+		// match them, like the roots of unity below.
+		s := &kzgSetup{g1Lagrange: make([]bls12381.G1Affine, FieldElementsPerBlob)}
+		for i, p := range raw.G1Lagrange {
+			if _, kzgErr = s.g1Lagrange[reverseBits(i, 12)].SetBytes(p); kzgErr != nil {
+				return
+			}
+		}
+		if _, kzgErr = s.g2Tau.SetBytes(raw.G2Monomial[1]); kzgErr != nil {
+			return
+		}
+
+		// The roots of unity that the blobs are evaluated over.
+		var root fr.Element
+		root.SetUint64(7)
+		root.Exp(root, new(big.Int).Div(new(big.Int).Sub(fr.Modulus(), big.NewInt(1)), big.NewInt(FieldElementsPerBlob)))
+		powers := make([]fr.Element, FieldElementsPerBlob)
+		powers[0].SetOne()
+		for i := 1; i < FieldElementsPerBlob; i++ {
+			powers[i].Mul(&powers[i-1], &root)
+		}
+		s.roots = make([]fr.Element, FieldElementsPerBlob)
+		for i := range powers {
+			s.roots[reverseBits(i, 12)] = powers[i]
+		}
+		kzgLoaded = s
+	})
+	return kzgLoaded, kzgErr
+}
+
+func reverseBits(n, bits int) int {
+	var r int
+	for i := 0; i < bits; i++ {
+		r = r<<1 | n&1
+		n >>= 1
+	}
+	return r
+}
+
+// EncodeBlobs packs the data into as many blobs as it takes. Every field
+// element holds 31 bytes of the data after a zero byte, and the data is
+// followed by a 0x80 byte before the zero padding, which DecodeBlobs strips.
+func EncodeBlobs(data []byte) [][]byte {
+	padded := append(append([]byte{}, data...), blobPaddingMarker)
+	perBlob := FieldElementsPerBlob * BlobDataPerFieldElement
+	var blobs [][]byte
+	for start := 0; start < len(padded); start += perBlob {
+		end := start + perBlob
+		if end > len(padded) {
+			end = len(padded)
+		}
+		blob := make([]byte, BlobSize)
+		chunk := padded[start:end]
+		for i := 0; len(chunk) > 0; i++ {
+			n := copy(blob[i*32+1:(i+1)*32], chunk)
+			chunk = chunk[n:]
+		}
+		blobs = append(blobs, blob)
+	}
+	return blobs
+}
+
+// DecodeBlobs returns the data that EncodeBlobs packed into the blobs.
+func DecodeBlobs(blobs [][]byte) ([]byte, error) {
+	data := make([]byte, 0, len(blobs)*FieldElementsPerBlob*BlobDataPerFieldElement)
+	for i, blob := range blobs {
+		if len(blob) != BlobSize {
+			return nil, fmt.Errorf("blob %d is %d bytes rather than %d", i, len(blob), BlobSize)
+		}
+		for j := 0; j < FieldElementsPerBlob; j++ {
+			if blob[j*32] != 0 {
+				return nil, fmt.Errorf("field element %d of blob %d doesn't start with a zero byte, so it wasn't encoded by polycli", j, i)
+			}
+			data = append(data, blob[j*32+1:(j+1)*32]...)
+		}
+	}
+	end := len(data) - 1
+	for end >= 0 && data[end] == 0 {
+		end--
+	}
+	if end < 0 || data[end] != blobPaddingMarker {
+		return nil, errors.New("the blobs don't end with the padding marker, so they weren't encoded by polycli")
+	}
+	return data[:end], nil
+}
+
+// blobToPolynomial returns the field elements of the blob, which have to be
+// less than the modulus.
+func blobToPolynomial(blob []byte) ([]fr.Element, error) {
+	if len(blob) != BlobSize {
+		return nil, fmt.Errorf("the blob is %d bytes rather than %d", len(blob), BlobSize)
+	}
+	modulus := fr.Modulus()
+	poly := make([]fr.Element, FieldElementsPerBlob)
+	for i := range poly {
+		b := blob[i*32 : (i+1)*32]
+		if new(big.Int).SetBytes(b).Cmp(modulus) >= 0 {
+			return nil, fmt.Errorf("field element %d of the blob isn't less than the modulus", i)
+		}
+		poly[i].SetBytes(b)
+	}
+	return poly, nil
+}
+
+func (s *kzgSetup) commit(poly []fr.Element) (bls12381.G1Affine, error) {
+	var jac bls12381.G1Jac
+	if _, err := jac.MultiExp(s.g1Lagrange, poly, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
+		return bls12381.G1Affine{}, err
+	}
+	var p bls12381.G1Affine
+	p.FromJacobian(&jac)
+	return p, nil
+}
+
+// evaluate evaluates the polynomial in evaluation form at z with the
+// barycentric formula.
+func (s *kzgSetup) evaluate(poly []fr.Element, z fr.Element) fr.Element {
+	for i := range s.roots {
+		if s.roots[i].Equal(&z) {
+			return poly[i]
+		}
+	}
+	denominators := make([]fr.Element, FieldElementsPerBlob)
+	for i := range denominators {
+		denominators[i].Sub(&z, &s.roots[i])
+	}
+	inverses := fr.BatchInvert(denominators)
+
+	var sum, term fr.Element
+	for i := range poly {
+		term.Mul(&poly[i], &s.roots[i])
+		term.Mul(&term, &inverses[i])
+		sum.Add(&sum, &term)
+	}
+	var zn, one, n fr.Element
+	one.SetOne()
+	zn.Exp(z, big.NewInt(FieldElementsPerBlob))
+	zn.Sub(&zn, &one)
+	n.SetUint64(FieldElementsPerBlob)
+	n.Inverse(&n)
+	sum.Mul(&sum, &zn)
+	sum.Mul(&sum, &n)
+	return sum
+}
+
+// challenge derives the evaluation point of the blob proof from the blob and
+// its commitment.
+func blobChallenge(blob, commitment []byte) fr.Element {
+	h := sha256.New()
+	h.Write(kzgFiatShamirDomain)
+	degree := make([]byte, 16)
+	big.NewInt(FieldElementsPerBlob).FillBytes(degree)
+	h.Write(degree)
+	h.Write(blob)
+	h.Write(commitment)
+	var z fr.Element
+	z.SetBigInt(new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), fr.Modulus()))
+	return z
+}
+
+// parseKZGPoint parses a compressed commitment or proof. The point has to be
+// exactly KZGPointSize bytes, since SetBytes ignores any bytes that follow it.
+func parseKZGPoint(b []byte) (bls12381.G1Affine, error) {
+	var p bls12381.G1Affine
+	if len(b) != KZGPointSize {
+		return p, fmt.Errorf("the point is %d bytes rather than %d", len(b), KZGPointSize)
+	}
+	_, err := p.SetBytes(b)
+	return p, err
+}
+
+// BlobToCommitment returns the 48 byte KZG commitment of the blob.
+func BlobToCommitment(blob []byte) ([]byte, error) {
+	s, err := loadKZGSetup()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the trusted setup: %w", err)
+	}
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.commit(poly)
+	if err != nil {
+		return nil, err
+	}
+	commitment := p.Bytes()
+	return commitment[:], nil
+}
+
+// ComputeBlobProof returns the 48 byte KZG proof of the blob and its
+// commitment that blob transactions carry in their sidecar.
+func ComputeBlobProof(blob, commitment []byte) ([]byte, error) {
+	s, err := loadKZGSetup()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the trusted setup: %w", err)
+	}
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = parseKZGPoint(commitment); err != nil {
+		return nil, fmt.Errorf("the commitment isn't valid: %w", err)
+	}
+	z := blobChallenge(blob, commitment)
+	y := s.evaluate(poly, z)
+
+	// The quotient (p(x) - y) / (x - z) in evaluation form. Where x is z
+	// itself, the quotient is the derivative of the numerator instead.
+	quotient := make([]fr.Element, FieldElementsPerBlob)
+	denominators := make([]fr.Element, FieldElementsPerBlob)
+	for i := range denominators {
+		denominators[i].Sub(&s.roots[i], &z)
+	}
+	inverses := fr.BatchInvert(denominators)
+	for i := range quotient {
+		if denominators[i].IsZero() {
+			quotient[i] = s.quotientInDomain(poly, i, y)
+			continue
+		}
+		quotient[i].Sub(&poly[i], &y)
+		quotient[i].Mul(&quotient[i], &inverses[i])
+	}
+
+	p, err := s.commit(quotient)
+	if err != nil {
+		return nil, err
+	}
+	proof := p.Bytes()
+	return proof[:], nil
+}
+
+// quotientInDomain is the quotient of the proof at the root of unity m, when
+// the challenge is that root.
+func (s *kzgSetup) quotientInDomain(poly []fr.Element, m int, y fr.Element) fr.Element {
+	z := s.roots[m]
+	var sum, numerator, denominator fr.Element
+	for i := range poly {
+		if i == m {
+			continue
+		}
+		numerator.Sub(&poly[i], &y)
+		numerator.Mul(&numerator, &s.roots[i])
+		denominator.Sub(&z, &s.roots[i])
+		denominator.Mul(&denominator, &z)
+		denominator.Inverse(&denominator)
+		numerator.Mul(&numerator, &denominator)
+		sum.Add(&sum, &numerator)
+	}
+	return sum
+}
+
+// VerifyBlobProof checks the KZG proof of the blob against its commitment.
+func VerifyBlobProof(blob, commitment, proof []byte) error {
+	s, err := loadKZGSetup()
+	if err != nil {
+		return fmt.Errorf("unable to load the trusted setup: %w", err)
+	}
+	poly, err := blobToPolynomial(blob)
+	if err != nil {
+		return err
+	}
+	c, err := parseKZGPoint(commitment)
+	if err != nil {
+		return fmt.Errorf("the commitment isn't valid: %w", err)
+	}
+	pi, err := parseKZGPoint(proof)
+	if err != nil {
+		return fmt.Errorf("the proof isn't valid: %w", err)
+	}
+	z := blobChallenge(blob, commitment)
+	y := s.evaluate(poly, z)
+
+	// Check that e(C - [y]G1, -G2) * e(proof, [tau]G2 - [z]G2) is one.
+	g1, g2, _, _ := bls12381.Generators()
+	var zBig, yBig big.Int
+	z.ToBigIntRegular(&zBig)
+	y.ToBigIntRegular(&yBig)
+
+	var xMinusZ, tau bls12381.G2Jac
+	tau.FromAffine(&s.g2Tau)
+	xMinusZ.ScalarMultiplication(&g2, &zBig)
+	xMinusZ.Neg(&xMinusZ)
+	xMinusZ.AddAssign(&tau)
+
+	var pMinusY, cJac bls12381.G1Jac
+	cJac.FromAffine(&c)
+	pMinusY.ScalarMultiplication(&g1, &yBig)
+	pMinusY.Neg(&pMinusY)
+	pMinusY.AddAssign(&cJac)
+
+	var negG2 bls12381.G2Jac
+	negG2.Neg(&g2)
+
+	var p1, p2 bls12381.G1Affine
+	var q1, q2 bls12381.G2Affine
+	p1.FromJacobian(&pMinusY)
+	p2 = pi
+	q1.FromJacobian(&negG2)
+	q2.FromJacobian(&xMinusZ)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{p1, p2}, []bls12381.G2Affine{q1, q2})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the proof doesn't match the blob and commitment")
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The vectors are the blob_to_kzg_commitment, compute_blob_kzg_proof, and
+// verify_blob_kzg_proof reference tests of the consensus specs for mainnet,
+// as published in the tests of c-kzg-4844. Only the cases whose blobs have a
+// simple structure are included, so that the blobs can be built here rather
+// than stored.
+
+const (
+	kzgInfinity = "0xc00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+	// kzgG1 is the generator of G1, which is a valid point that isn't the
+	// commitment or proof of any of the blobs.
+	kzgG1 = "0x97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+)
+
+// kzgBlob returns a blob whose field elements are all the element.
+func kzgBlob(element string) []byte {
+	return bytes.Repeat(hexutil.MustDecode(element), FieldElementsPerBlob)
+}
+
+// kzgBlobWith returns a zero blob with the element at index.
+func kzgBlobWith(index int, element string) []byte {
+	blob := make([]byte, BlobSize)
+	copy(blob[index*32:], hexutil.MustDecode(element))
+	return blob
+}
+
+var (
+	zeroElement    = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	oneElement     = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	twoElement     = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	modulusElement = "0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"
+	maxElement     = "0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000"
+	ffElement      = "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+
+	// validKZGBlobs are the valid blobs with their commitment and proof.
+	validKZGBlobs = []struct {
+		name       string
+		blob       []byte
+		commitment string
+		proof      string
+	}{
+		{
+			name:       "zero",
+			blob:       kzgBlob(zeroElement),
+			commitment: kzgInfinity,
+			proof:      kzgInfinity,
+		},
+		{
+			name:       "single one",
+			blob:       kzgBlobWith(3211, oneElement),
+			commitment: "0x93efc82d2017e9c57834a1246463e64774e56183bb247c8fc9dd98c56817e878d97b05f5c8d900acf1fbbbca6f146556",
+			proof:      "0x9720099d507280aba6a9c9e8c31187336d10dc6a4b04646d1aa42c8d38f891de36f939313cb99e9e7953606555db269a",
+		},
+		{
+			name:       "twos",
+			blob:       kzgBlob(twoElement),
+			commitment: "0xa572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e",
+			proof:      kzgInfinity,
+		},
+		{
+			name:       "modulus minus one",
+			blob:       kzgBlob(maxElement),
+			commitment: "0xb7f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+			proof:      kzgInfinity,
+		},
+	}
+
+	// invalidKZGBlobs have a field element that isn't less than the modulus.
+	invalidKZGBlobs = []struct {
+		name string
+		blob []byte
+	}{
+		{
+			name: "modulus",
+			blob: kzgBlobWith(2111, modulusElement),
+		},
+		{
+			name: "all ones",
+			blob: kzgBlob(ffElement),
+		},
+	}
+)
+
+func TestBlobToCommitment(t *testing.T) {
+	for _, tc := range validKZGBlobs {
+		t.Run(tc.name, func(t *testing.T) {
+			commitment, err := BlobToCommitment(tc.blob)
+			if err != nil {
+				t.Fatalf("unable to commit to the blob: %v", err)
+			}
+			if got := hexutil.Encode(commitment); got != tc.commitment {
+				t.Errorf("expected the commitment %s, got %s", tc.commitment, got)
+			}
+		})
+	}
+	for _, tc := range invalidKZGBlobs {
+		t.Run("invalid "+tc.name, func(t *testing.T) {
+			if _, err := BlobToCommitment(tc.blob); err == nil {
+				t.Error("expected the blob to be rejected")
+			}
+		})
+	}
+}
+
+func TestComputeBlobProof(t *testing.T) {
+	for _, tc := range validKZGBlobs {
+		t.Run(tc.name, func(t *testing.T) {
+			proof, err := ComputeBlobProof(tc.blob, hexutil.MustDecode(tc.commitment))
+			if err != nil {
+				t.Fatalf("unable to compute the proof: %v", err)
+			}
+			if got := hexutil.Encode(proof); got != tc.proof {
+				t.Errorf("expected the proof %s, got %s", tc.proof, got)
+			}
+		})
+	}
+	for _, tc := range invalidKZGBlobs {
+		t.Run("invalid "+tc.name, func(t *testing.T) {
+			if _, err := ComputeBlobProof(tc.blob, hexutil.MustDecode(kzgG1)); err == nil {
+				t.Error("expected the blob to be rejected")
+			}
+		})
+	}
+}
+
+func TestVerifyBlobProof(t *testing.T) {
+	twos := kzgBlob(twoElement)
+	tests := []struct {
+		name       string
+		blob       []byte
+		commitment string
+		proof      string
+		valid      bool
+	}{
+		{
+			name:       "incorrect proof of zero",
+			blob:       kzgBlob(zeroElement),
+			commitment: kzgInfinity,
+			proof:      kzgG1,
+		},
+		{
+			name:       "incorrect proof of single one",
+			blob:       kzgBlobWith(3211, oneElement),
+			commitment: "0x93efc82d2017e9c57834a1246463e64774e56183bb247c8fc9dd98c56817e878d97b05f5c8d900acf1fbbbca6f146556",
+			proof:      "0x8e5995b8136efc6e4a6d915ecfbeef542a44c1749afef58cac423e24e8dc2d03387faea0adc29ad454cdeae0be44d139",
+		},
+		{
+			name:       "incorrect proof of twos",
+			blob:       twos,
+			commitment: "0xa572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e",
+			proof:      kzgG1,
+		},
+		{
+			name:       "incorrect proof of modulus minus one",
+			blob:       kzgBlob(maxElement),
+			commitment: "0xb7f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb",
+			proof:      kzgG1,
+		},
+		{
+			name:       "commitment not on the curve",
+			blob:       twos,
+			commitment: "0x8123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcde0",
+			proof:      kzgG1,
+		},
+		{
+			name:       "commitment not in the subgroup",
+			blob:       twos,
+			commitment: "0x8123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			proof:      kzgG1,
+		},
+		{
+			name:       "short commitment",
+			blob:       twos,
+			commitment: kzgG1[:len(kzgG1)-2],
+			proof:      kzgG1,
+		},
+		{
+			name:       "long commitment",
+			blob:       kzgBlobWith(3211, oneElement),
+			commitment: "0x93efc82d2017e9c57834a1246463e64774e56183bb247c8fc9dd98c56817e878d97b05f5c8d900acf1fbbbca6f14655600",
+			proof:      "0x9720099d507280aba6a9c9e8c31187336d10dc6a4b04646d1aa42c8d38f891de36f939313cb99e9e7953606555db269a",
+		},
+		{
+			name:       "proof not on the curve",
+			blob:       twos,
+			commitment: kzgG1,
+			proof:      "0x8123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcde0",
+		},
+		{
+			name:       "proof not in the subgroup",
+			blob:       twos,
+			commitment: kzgG1,
+			proof:      "0x8123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		},
+		{
+			name:       "short proof",
+			blob:       twos,
+			commitment: kzgG1,
+			proof:      kzgG1[:len(kzgG1)-2],
+		},
+		{
+			name:       "long proof",
+			blob:       kzgBlobWith(3211, oneElement),
+			commitment: "0x93efc82d2017e9c57834a1246463e64774e56183bb247c8fc9dd98c56817e878d97b05f5c8d900acf1fbbbca6f146556",
+			proof:      "0x9720099d507280aba6a9c9e8c31187336d10dc6a4b04646d1aa42c8d38f891de36f939313cb99e9e7953606555db269a00",
+		},
+	}
+	for _, tc := range validKZGBlobs {
+		tests = append(tests, struct {
+			name       string
+			blob       []byte
+			commitment string
+			proof      string
+			valid      bool
+		}{"correct proof of " + tc.name, tc.blob, tc.commitment, tc.proof, true})
+	}
+	for _, tc := range invalidKZGBlobs {
+		tests = append(tests, struct {
+			name       string
+			blob       []byte
+			commitment string
+			proof      string
+			valid      bool
+		}{"invalid blob " + tc.name, tc.blob, kzgG1, kzgG1, false})
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyBlobProof(tc.blob, hexutil.MustDecode(tc.commitment), hexutil.MustDecode(tc.proof))
+			if tc.valid && err != nil {
+				t.Errorf("expected the proof to be valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected the proof to be rejected")
+			}
+		})
+	}
+}
+
+func TestEncodeBlobs(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, BlobSize, 2 * BlobSize} {
+		data := bytes.Repeat([]byte{0xab}, size)
+		blobs := EncodeBlobs(data)
+		for i, blob := range blobs {
+			if _, err := BlobToCommitment(blob); err != nil {
+				t.Fatalf("blob %d of %d bytes isn't valid: %v", i, size, err)
+			}
+		}
+		decoded, err := DecodeBlobs(blobs)
+		if err != nil {
+			t.Fatalf("unable to decode %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("the %d bytes didn't survive the encoding", size)
+		}
+	}
+}
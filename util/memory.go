@@ -0,0 +1,91 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxMemoryHelp describes the --max-memory flags.
+const MaxMemoryHelp = "A hint of the memory to stay under, e.g. 4GB, which bounds the buffered data and sets the soft memory limit of the runtime (empty for no limit)"
+
+// heapObjectsMetric is the runtime metric of the memory used by live and
+// not yet collected heap objects.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// maxMemoryWait is how long MemoryLimiter.Wait waits for the heap to shrink.
+const maxMemoryWait = 30 * time.Second
+
+// MemoryLimiter holds back readers and fetchers while the heap is close to
+// the --max-memory hint, so that records are streamed with bounded buffers
+// rather than piling up in memory when the writer is slower than they are.
+// A nil limiter never waits.
+type MemoryLimiter struct {
+	limit uint64
+}
+
+// NewMemoryLimiter parses the --max-memory hint and sets the soft memory
+// limit of the runtime to it, so that the garbage collector runs more often
+// as the heap approaches it. It returns nil if the hint is empty.
+func NewMemoryLimiter(maxMemory string) (*MemoryLimiter, error) {
+	if maxMemory == "" {
+		return nil, nil
+	}
+	limit, err := humanize.ParseBytes(maxMemory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max memory %q: %w", maxMemory, err)
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	debug.SetMemoryLimit(int64(limit))
+	log.Debug().Str("limit", humanize.IBytes(limit)).Msg("Set the memory limit")
+	return &MemoryLimiter{limit: limit}, nil
+}
+
+// Limit returns the hint in bytes, or 0 if there's no limit.
+func (m *MemoryLimiter) Limit() uint64 {
+	if m == nil {
+		return 0
+	}
+	return m.limit
+}
+
+// Wait blocks while the heap uses more than three quarters of the limit,
+// which leaves room for the records that are being processed. It collects
+// garbage while waiting, since the heap only shrinks once it's collected.
+// Since the limit is a hint, it gives up waiting after maxMemoryWait rather
+// than blocking forever when the heap doesn't shrink.
+func (m *MemoryLimiter) Wait(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	start := time.Now()
+	for waited := false; ; waited = true {
+		metrics.Read(sample)
+		inUse := sample[0].Value.Uint64()
+		if inUse <= m.limit/4*3 {
+			return nil
+		}
+		if !waited {
+			log.Debug().Str("heap", humanize.IBytes(inUse)).Str("limit", humanize.IBytes(m.limit)).Msg("Waiting for memory to be freed")
+		}
+		if time.Since(start) > maxMemoryWait {
+			log.Warn().Str("heap", humanize.IBytes(inUse)).Str("limit", humanize.IBytes(m.limit)).Msg("The heap didn't shrink below the max memory, continuing anyway")
+			return nil
+		}
+		runtime.GC()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MessageDigest returns the digest that's signed for the message. The message
+// is prefixed like personal_sign does (EIP-191 version 0x45) unless raw is
+// set, in which case the message must be the 32 byte digest itself.
+func MessageDigest(message []byte, raw bool) ([]byte, error) {
+	if !raw {
+		return accounts.TextHash(message), nil
+	}
+	if len(message) != 32 {
+		return nil, fmt.Errorf("a raw digest should be 32 bytes, got %d", len(message))
+	}
+	return message, nil
+}
+
+// RecoverSigner returns the address that signed the digest. The recovery id
+// of the signature can either be 0 or 1, or 27 or 28 like personal_sign
+// returns.
+func RecoverSigner(digest, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("the signature should be %d bytes, got %d", crypto.SignatureLength, len(signature))
+	}
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("unable to recover the signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// ReadMessage returns the file if one is set, the arguments joined with
+// spaces if there are any, and otherwise reads stdin. Hex input is decoded.
+func ReadMessage(file string, isHex bool, args []string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch {
+	case file != "":
+		data, err = os.ReadFile(file)
+	case len(args) > 0:
+		data = []byte(strings.Join(args, " "))
+	default:
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the message: %w", err)
+	}
+
+	if !isHex {
+		return data, nil
+	}
+	data, err = hexutil.Decode(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode hex message: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,317 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+)
+
+// nameServiceABI has the functions of an ENS compatible registry and resolver
+// that are used to resolve names.
+const nameServiceABI = `[
+	{"type":"function","name":"resolver","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"addr","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"name","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"string"}]}
+]`
+
+const (
+	// nameCacheTTL is how long resolved names and addresses are cached,
+	// including the ones that couldn't be resolved.
+	nameCacheTTL = 10 * time.Minute
+	// nameQueueSize is how many reverse lookups of Label can wait to be
+	// resolved. The rest are dropped and queued again on the next Label.
+	nameQueueSize = 256
+)
+
+var (
+	// ensRegistry is the address of the ENS registry, which is the same on
+	// every chain ENS is deployed on.
+	ensRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	// ensChains are the chain IDs of mainnet, sepolia, and holesky.
+	ensChains = map[uint64]bool{1: true, 11155111: true, 17000: true}
+
+	parsedNameServiceABI, _ = gethabi.JSON(strings.NewReader(nameServiceABI))
+
+	nameOptions NameOptions
+)
+
+// NameOptions configures the name service. It's set by the --resolve-names
+// and --name-registry flags of the root command.
+type NameOptions struct {
+	// Resolve shows the names of the addresses that commands display.
+	Resolve bool
+	// Registry is the address of an ENS compatible registry, like the one of
+	// a Polygon name service. The ENS registry is used if it's empty.
+	Registry string
+}
+
+// SetNameOptions sets the options of the name services created from now on.
+func SetNameOptions(o NameOptions) error {
+	if o.Registry != "" && !common.IsHexAddress(o.Registry) {
+		return fmt.Errorf("the name registry %s isn't a valid address", o.Registry)
+	}
+	nameOptions = o
+	return nil
+}
+
+// ResolveNames reports whether commands should show the names of the
+// addresses they display.
+func ResolveNames() bool {
+	return nameOptions.Resolve
+}
+
+// IsName reports whether the value looks like a name, like vitalik.eth,
+// rather than an address.
+func IsName(s string) bool {
+	if common.IsHexAddress(s) || !strings.Contains(s, ".") {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Namehash returns the node of the name as described in ENSIP-1. Names are
+// only lower cased, rather than fully normalized.
+func Namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+type nameEntry struct {
+	address common.Address
+	name    string
+	err     error
+	expires time.Time
+}
+
+// Names resolves names to addresses and addresses to their primary names with
+// an ENS compatible registry, and caches the results.
+type Names struct {
+	rpc *ethrpc.Client
+
+	// registryMu is separate from mu, since the registry is looked up over
+	// RPC and Label shouldn't wait for it.
+	registryMu sync.Mutex
+	registry   *common.Address
+
+	mu      sync.Mutex
+	forward map[string]nameEntry
+	reverse map[common.Address]nameEntry
+	pending map[common.Address]bool
+	queue   chan common.Address
+}
+
+// NewNames returns a name service that uses the registry of the RPC endpoint.
+func NewNames(rpc *ethrpc.Client) *Names {
+	return &Names{
+		rpc:     rpc,
+		forward: make(map[string]nameEntry),
+		reverse: make(map[common.Address]nameEntry),
+		pending: make(map[common.Address]bool),
+		queue:   make(chan common.Address, nameQueueSize),
+	}
+}
+
+// Address returns the address the name resolves to.
+func (n *Names) Address(ctx context.Context, name string) (common.Address, error) {
+	name = strings.ToLower(name)
+	n.mu.Lock()
+	e, ok := n.forward[name]
+	n.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.address, e.err
+	}
+
+	address, err := n.resolveAddress(ctx, name)
+	if err == nil && address == (common.Address{}) {
+		err = fmt.Errorf("the name %s doesn't resolve to an address", name)
+	}
+	if ctx.Err() == nil {
+		n.mu.Lock()
+		n.forward[name] = nameEntry{address: address, err: err, expires: time.Now().Add(nameCacheTTL)}
+		n.mu.Unlock()
+	}
+	return address, err
+}
+
+// Name returns the primary name of the address, or an empty string if it
+// doesn't have one. The name is only returned if it resolves back to the
+// address, since anyone can set the reverse record of their address to any
+// name.
+func (n *Names) Name(ctx context.Context, address common.Address) (string, error) {
+	n.mu.Lock()
+	e, ok := n.reverse[address]
+	n.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.name, e.err
+	}
+
+	name, err := n.resolveName(ctx, address)
+	if ctx.Err() == nil {
+		n.mu.Lock()
+		n.reverse[address] = nameEntry{name: name, err: err, expires: time.Now().Add(nameCacheTTL)}
+		n.mu.Unlock()
+	}
+	return name, err
+}
+
+// Label returns the cached name of the address without blocking, which is
+// what user interfaces that redraw often need. Addresses that aren't cached
+// yet are resolved by Run and have a name from a later call on.
+func (n *Names) Label(address common.Address) string {
+	if address == (common.Address{}) {
+		return ""
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if e, ok := n.reverse[address]; ok && time.Now().Before(e.expires) {
+		return e.name
+	}
+	if !n.pending[address] {
+		select {
+		case n.queue <- address:
+			n.pending[address] = true
+		default:
+		}
+	}
+	return ""
+}
+
+// Run resolves the addresses queued by Label until the context is done.
+func (n *Names) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case address := <-n.queue:
+			if _, err := n.Name(ctx, address); err != nil {
+				log.Debug().Err(err).Str("address", address.Hex()).Msg("Unable to resolve the name of the address")
+			}
+			n.mu.Lock()
+			delete(n.pending, address)
+			n.mu.Unlock()
+		}
+	}
+}
+
+func (n *Names) resolveAddress(ctx context.Context, name string) (common.Address, error) {
+	resolver, err := n.resolver(ctx, Namehash(name))
+	if err != nil || resolver == (common.Address{}) {
+		return common.Address{}, err
+	}
+	out, err := n.call(ctx, resolver, "addr", Namehash(name))
+	if err != nil {
+		return common.Address{}, err
+	}
+	address, _ := out.(common.Address)
+	return address, nil
+}
+
+func (n *Names) resolveName(ctx context.Context, address common.Address) (string, error) {
+	node := Namehash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	resolver, err := n.resolver(ctx, node)
+	if err != nil || resolver == (common.Address{}) {
+		return "", err
+	}
+	out, err := n.call(ctx, resolver, "name", node)
+	if err != nil {
+		return "", err
+	}
+	name, _ := out.(string)
+	if name == "" {
+		return "", nil
+	}
+
+	forward, err := n.Address(ctx, name)
+	if err != nil || forward != address {
+		log.Debug().Str("address", address.Hex()).Str("name", name).Msg("The reverse record doesn't resolve back to the address")
+		return "", nil
+	}
+	return name, nil
+}
+
+// resolver returns the resolver of the node, or the zero address if the node
+// doesn't have one.
+func (n *Names) resolver(ctx context.Context, node common.Hash) (common.Address, error) {
+	registry, err := n.registryAddress(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	out, err := n.call(ctx, registry, "resolver", node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	resolver, _ := out.(common.Address)
+	return resolver, nil
+}
+
+// registryAddress returns the registry of --name-registry, or the ENS
+// registry if ENS is deployed on the chain of the RPC endpoint.
+func (n *Names) registryAddress(ctx context.Context) (common.Address, error) {
+	n.registryMu.Lock()
+	defer n.registryMu.Unlock()
+	if n.registry != nil {
+		return *n.registry, nil
+	}
+	if nameOptions.Registry != "" {
+		registry := common.HexToAddress(nameOptions.Registry)
+		n.registry = &registry
+		return registry, nil
+	}
+
+	var chainID hexutil.Uint64
+	if err := n.rpc.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return common.Address{}, fmt.Errorf("unable to get the chain ID to find the name registry: %w", err)
+	}
+	if !ensChains[uint64(chainID)] {
+		return common.Address{}, fmt.Errorf("ENS isn't deployed on chain %d, set the registry of the name service with --name-registry", uint64(chainID))
+	}
+	n.registry = &ensRegistry
+	return ensRegistry, nil
+}
+
+func (n *Names) call(ctx context.Context, to common.Address, method string, node common.Hash) (interface{}, error) {
+	data, err := parsedNameServiceABI.Pack(method, node)
+	if err != nil {
+		return nil, err
+	}
+	args := map[string]any{"to": to, "data": hexutil.Bytes(data)}
+	var out hexutil.Bytes
+	if err = n.rpc.CallContext(ctx, &out, "eth_call", args, "latest"); err != nil {
+		return nil, fmt.Errorf("unable to call %s of %s: %w", method, to.Hex(), err)
+	}
+	// Resolvers without the function, or without code at all, return
+	// nothing, which is the same as no record.
+	if len(out) == 0 {
+		return nil, nil
+	}
+	values, err := parsedNameServiceABI.Unpack(method, out)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, errors.New("unexpected number of return values")
+	}
+	return values[0], nil
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
+)
+
+// Network holds the parameters of a well known network, so they don't have to
+// be looked up and passed as flags. Networks that aren't reachable over devp2p
+// have no bootnodes, and the genesis hash is zero if it isn't checked. The
+// fork ID is the EIP-2124 fork ID that the nodes of the network advertise
+// after its latest fork, so it has to be updated with every hard fork that
+// changes it, along with the forks it's computed from. Those are the blocks
+// of the Ethereum forks that bor activated after the genesis, since its own
+// forks don't change the fork ID. The Jaipur block is the bor fork from which the seals of the
+// headers cover the base fee, and the base fee change denominator is the
+// EIP-1559 one of the latest fork.
+type Network struct {
+	Name                     string
+	ChainID                  uint64
+	Genesis                  common.Hash
+	ForkID                   forkid.ID
+	Forks                    []uint64
+	JaipurBlock              uint64
+	BaseFeeChangeDenominator uint64
+	Bootnodes                []string
+	RPCURL                   string
+}
+
+var networks = map[string]Network{
+	"mainnet": {
+		Name:    "mainnet",
+		ChainID: 137,
+		Genesis: common.HexToHash("0xa9c28ce2141b56c474f1dc504bee9b01eb1bd7d1a507580d5519d4437a97de1b"),
+		// The Bhilai hard fork at block 73440256.
+		ForkID: forkid.ID{Hash: [4]byte{0x22, 0xd5, 0x23, 0xb2}},
+		// Istanbul and Muir Glacier, Berlin, London, Shanghai, Cancun, and
+		// Prague.
+		Forks: []uint64{3395000, 14750000, 23850000, 50523000, 54876000, 73440256},
+		// The Jaipur hard fork, from which the seals cover the base fee.
+		JaipurBlock: 23850000,
+		// The Bhilai hard fork raised it from 16, which it was since the
+		// Delhi hard fork at block 38189056.
+		BaseFeeChangeDenominator: 64,
+		Bootnodes: []string{
+			"enode://0cb82b395094ee4a2915e9714894627de9ed8498fb881cec6db7c65e8b9a5bd7f2f25cc84e71e89d0947e51c76e85d0847de848c7782b13c0255247a6758178c@44.232.55.71:30303",
+			"enode://88116f4295f5a31538ae409e4d44ad40d22e44ee9342869e7d68bdec55b0f83c1530355ce8b41fbec0928a7d75a5745d528450d30aec92066ab6ba1ee351d710@159.203.9.164:30303",
+			"enode://4be7248c3a12c5f95d4ef5fff37f7c44ad1072fdb59701b2e5987c5f3846ef448ce7eabc941c5575b13db0fb016552c1fa5cca0dda1a8008cf6d63874c0f3eb7@3.93.224.197:30303",
+			"enode://32dd20eaf75513cf84ffc9940972ab17a62e88ea753b0780ea5eca9f40f9254064dacb99508337043d944c2a41b561a17deaad45c53ea0be02663e55e6a302b2@3.212.183.151:30303",
+		},
+		RPCURL: "https://polygon-rpc.com",
+	},
+	"amoy": {
+		Name:    "amoy",
+		ChainID: 80002,
+		Genesis: common.HexToHash("0x7202b2b53c5a0836e773e319d18922cc756dd67432f9a1f65352b61f4406c697"),
+		// The Bhilai hard fork at block 22765056.
+		ForkID: forkid.ID{Hash: [4]byte{0x8b, 0x7e, 0x41, 0x75}},
+		// London and Shanghai, Cancun, and Prague.
+		Forks: []uint64{73100, 5423600, 22765056},
+		// The Jaipur hard fork, from which the seals cover the base fee.
+		JaipurBlock: 73100,
+		// The Bhilai hard fork raised it from 16.
+		BaseFeeChangeDenominator: 64,
+		Bootnodes: []string{
+			"enode://bce861be777e91b0a5a49d58a51e14f32f201b4c6c2d1fbea6c7a1f14756cbb3f931f3188d6b65de8b07b53ff28d03b6e366d09e56360d2124a9fc5a15a0913d@54.217.171.196:30303",
+			"enode://4a3dc0081a346d26a73d79dd88216a9402d2292318e2db9947dbc97ea9c4afb2498dc519c0af04420dc13a238c279062da0320181e7c1461216ce4513bfd40bf@13.251.184.185:30303",
+		},
+		RPCURL: "https://rpc-amoy.polygon.technology",
+	},
+	"zkevm-mainnet": {
+		Name:    "zkevm-mainnet",
+		ChainID: 1101,
+		RPCURL:  "https://zkevm-rpc.com",
+	},
+	"cardona": {
+		Name:    "cardona",
+		ChainID: 2442,
+		RPCURL:  "https://rpc.cardona.zkevm-rpc.com",
+	},
+}
+
+// FormatForkID returns the fork ID in the hash:next format of the flags, or an
+// empty string if the network has none.
+func (n Network) FormatForkID() string {
+	if n.ForkID == (forkid.ID{}) {
+		return ""
+	}
+	return fmt.Sprintf("%#x:%d", n.ForkID.Hash, n.ForkID.Next)
+}
+
+// NetworkNames returns the names of the network presets in order.
+func NetworkNames() []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupNetwork returns the network preset with the name.
+func LookupNetwork(name string) (Network, error) {
+	n, ok := networks[strings.ToLower(name)]
+	if !ok {
+		return Network{}, fmt.Errorf("unknown network %q, expected one of %s", name, strings.Join(NetworkNames(), ", "))
+	}
+	return n, nil
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// TestNetworkForkIDs checks that the fork ID of every preset is the EIP-2124
+// checksum of its genesis hash and fork blocks.
+func TestNetworkForkIDs(t *testing.T) {
+	for _, name := range NetworkNames() {
+		n := networks[name]
+		if n.FormatForkID() == "" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			hash := crc32.ChecksumIEEE(n.Genesis[:])
+			var last uint64
+			for _, fork := range n.Forks {
+				if fork <= last {
+					t.Fatalf("the forks need to be in ascending order without duplicates, got %d after %d", fork, last)
+				}
+				last = fork
+				var blob [8]byte
+				binary.BigEndian.PutUint64(blob[:], fork)
+				hash = crc32.Update(hash, crc32.IEEETable, blob[:])
+			}
+
+			var want [4]byte
+			binary.BigEndian.PutUint32(want[:], hash)
+			if n.ForkID.Hash != want {
+				t.Errorf("expected the fork ID %#x, got %#x", want, n.ForkID.Hash)
+			}
+			if n.ForkID.Next != 0 {
+				t.Errorf("expected no next fork after the latest one, got %d", n.ForkID.Next)
+			}
+		})
+	}
+}
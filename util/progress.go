@@ -0,0 +1,284 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+	"golang.org/x/term"
+)
+
+// The modes of --progress.
+const (
+	ProgressAuto = "auto"
+	ProgressBar  = "bar"
+	ProgressLog  = "log"
+	ProgressJSON = "json"
+	ProgressNone = "none"
+)
+
+const (
+	// progressBarInterval is how often the bar is redrawn.
+	progressBarInterval = 250 * time.Millisecond
+	// progressReportInterval is how often progress is logged or emitted as
+	// JSON events.
+	progressReportInterval = 10 * time.Second
+	// progressRateWindow is the time constant of the moving average of the
+	// rate, so that the ETA follows changes of the rate within minutes
+	// without jumping around with every report.
+	progressRateWindow = 30 * time.Second
+	progressBarWidth   = 30
+)
+
+var (
+	progressMode = ProgressAuto
+
+	// The bar that's drawn on the last line of stderr, which is cleared
+	// before logs are written and redrawn after them.
+	barMu   sync.Mutex
+	barLine string
+)
+
+// ProgressModes returns the modes of --progress.
+func ProgressModes() []string {
+	return []string{ProgressAuto, ProgressBar, ProgressLog, ProgressJSON, ProgressNone}
+}
+
+// SetProgressMode sets how the progress of long running commands is
+// reported. The auto mode draws a bar when stderr is a terminal and logs the
+// progress otherwise.
+func SetProgressMode(mode string) error {
+	if !slices.Contains(ProgressModes(), mode) {
+		return fmt.Errorf("invalid progress mode %q, must be one of %s", mode, strings.Join(ProgressModes(), ", "))
+	}
+	if mode == ProgressAuto {
+		mode = ProgressLog
+		if term.IsTerminal(int(os.Stderr.Fd())) {
+			mode = ProgressBar
+		}
+	}
+	progressMode = mode
+	return nil
+}
+
+// ProgressWriter wraps the writer of the logs, so that log lines are written
+// above the progress bar rather than in the middle of it.
+func ProgressWriter(w io.Writer) io.Writer {
+	return &progressWriter{w: w}
+}
+
+type progressWriter struct {
+	w io.Writer
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	barMu.Lock()
+	defer barMu.Unlock()
+	if barLine == "" {
+		return p.w.Write(b)
+	}
+	fmt.Fprint(p.w, "\r\033[K")
+	n, err := p.w.Write(b)
+	fmt.Fprint(p.w, barLine)
+	return n, err
+}
+
+// Progress reports the progress of a long running command, with its rate and
+// the estimated time left if the total is known, as a bar, log lines, or JSON
+// events on stderr depending on --progress.
+type Progress struct {
+	name  string
+	unit  string
+	mode  string
+	start time.Time
+	total uint64
+	done  atomic.Uint64
+
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// The moving average of the rate, which is only used by the reporting
+	// goroutine.
+	lastDone uint64
+	lastTime time.Time
+	rate     float64
+}
+
+// progressEvent is the JSON event of --progress json.
+type progressEvent struct {
+	Event   string  `json:"event"`
+	Name    string  `json:"name"`
+	Unit    string  `json:"unit"`
+	Done    uint64  `json:"done"`
+	Total   uint64  `json:"total,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Rate    float64 `json:"rate"`
+	Elapsed float64 `json:"elapsedSeconds"`
+	ETA     float64 `json:"etaSeconds,omitempty"`
+}
+
+// NewProgress starts reporting the progress of the named job, which counts
+// the units up to the total, or without an ETA if the total is 0. Done must
+// be called when the job ends.
+func NewProgress(name, unit string, total uint64) *Progress {
+	now := time.Now()
+	p := &Progress{
+		name:     name,
+		total:    total,
+		unit:     unit,
+		mode:     progressMode,
+		start:    now,
+		lastTime: now,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	interval := progressReportInterval
+	switch p.mode {
+	case ProgressNone:
+		close(p.stopped)
+		return p
+	case ProgressBar:
+		interval = progressBarInterval
+	case ProgressAuto:
+		// SetProgressMode wasn't called, e.g. in tests.
+		p.mode = ProgressLog
+	}
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				p.report(true)
+				return
+			case <-ticker.C:
+				p.report(false)
+			}
+		}
+	}()
+	return p
+}
+
+// Add counts n more units as done.
+func (p *Progress) Add(n uint64) {
+	p.done.Add(n)
+}
+
+// Done reports the final progress and stops reporting.
+func (p *Progress) Done() {
+	p.once.Do(func() { close(p.stop) })
+	<-p.stopped
+}
+
+func (p *Progress) report(final bool) {
+	now := time.Now()
+	done, total := p.done.Load(), p.total
+	elapsed := now.Sub(p.start)
+
+	if dt := now.Sub(p.lastTime); dt > 0 && !final {
+		current := float64(done-p.lastDone) / dt.Seconds()
+		if p.lastDone == 0 && p.rate == 0 {
+			p.rate = current
+		} else {
+			alpha := 1 - math.Exp(-dt.Seconds()/progressRateWindow.Seconds())
+			p.rate += alpha * (current - p.rate)
+		}
+		p.lastDone, p.lastTime = done, now
+	}
+	rate := p.rate
+	if final && elapsed > 0 {
+		rate = float64(done) / elapsed.Seconds()
+	}
+
+	var percent float64
+	var eta time.Duration
+	if total > 0 {
+		percent = math.Min(float64(done)/float64(total)*100, 100)
+		if rate > 0 && done < total && !final {
+			eta = time.Duration(float64(total-done) / rate * float64(time.Second))
+		}
+	}
+
+	switch p.mode {
+	case ProgressBar:
+		p.drawBar(final, done, total, percent, rate, elapsed, eta)
+	case ProgressLog:
+		event := log.Info().Str("job", p.name).Uint64(p.unit, done)
+		if total > 0 {
+			event = event.Uint64("total", total).Str("percent", fmt.Sprintf("%.1f%%", percent))
+		}
+		event = event.Str("rate", fmt.Sprintf("%.1f/s", rate)).Str("elapsed", elapsed.Truncate(time.Second).String())
+		if eta > 0 {
+			event = event.Str("eta", eta.Truncate(time.Second).String())
+		}
+		if final {
+			event.Msg("Finished")
+		} else {
+			event.Msg("Progress")
+		}
+	case ProgressJSON:
+		e := progressEvent{
+			Event:   "progress",
+			Name:    p.name,
+			Unit:    p.unit,
+			Done:    done,
+			Total:   total,
+			Percent: percent,
+			Rate:    rate,
+			Elapsed: elapsed.Seconds(),
+			ETA:     eta.Seconds(),
+		}
+		if final {
+			e.Event = "done"
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		barMu.Lock()
+		fmt.Fprintln(os.Stderr, string(b))
+		barMu.Unlock()
+	}
+}
+
+func (p *Progress) drawBar(final bool, done, total uint64, percent, rate float64, elapsed, eta time.Duration) {
+	var sb strings.Builder
+	sb.WriteString(p.name)
+	if total > 0 {
+		filled := int(percent / 100 * progressBarWidth)
+		bar := strings.Repeat("=", filled)
+		if filled < progressBarWidth {
+			bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+		}
+		fmt.Fprintf(&sb, " [%s] %5.1f%% %s/%s %s", bar, percent, humanize.Comma(int64(done)), humanize.Comma(int64(total)), p.unit)
+	} else {
+		fmt.Fprintf(&sb, " %s %s", humanize.Comma(int64(done)), p.unit)
+	}
+	fmt.Fprintf(&sb, " %.1f/s %s", rate, elapsed.Truncate(time.Second))
+	if eta > 0 {
+		fmt.Fprintf(&sb, " ETA %s", eta.Truncate(time.Second))
+	}
+
+	barMu.Lock()
+	defer barMu.Unlock()
+	if final {
+		fmt.Fprintf(os.Stderr, "\r\033[K%s\n", sb.String())
+		barLine = ""
+		return
+	}
+	barLine = "\r\033[K" + sb.String()
+	fmt.Fprint(os.Stderr, barLine)
+}
@@ -0,0 +1,171 @@
+package util
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type (
+	// AccountProof is the response of eth_getProof.
+	AccountProof struct {
+		Address      common.Address  `json:"address"`
+		AccountProof []hexutil.Bytes `json:"accountProof"`
+		Balance      *hexutil.Big    `json:"balance"`
+		CodeHash     common.Hash     `json:"codeHash"`
+		Nonce        hexutil.Uint64  `json:"nonce"`
+		StorageHash  common.Hash     `json:"storageHash"`
+		StorageProof []struct {
+			Key   string          `json:"key"`
+			Value *hexutil.Big    `json:"value"`
+			Proof []hexutil.Bytes `json:"proof"`
+		} `json:"storageProof"`
+	}
+
+	// VerifiedAccount only has the values of an account and its storage that
+	// were proven against a state root.
+	VerifiedAccount struct {
+		Address     common.Address `json:"address"`
+		Exists      bool           `json:"exists"`
+		Nonce       uint64         `json:"nonce"`
+		Balance     *hexutil.Big   `json:"balance"`
+		StorageHash common.Hash    `json:"storageHash"`
+		CodeHash    common.Hash    `json:"codeHash"`
+		Storage     []VerifiedSlot `json:"storage,omitempty"`
+	}
+	VerifiedSlot struct {
+		Slot  common.Hash `json:"slot"`
+		Value common.Hash `json:"value"`
+	}
+)
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// ParseSlot parses a slot as a hex or decimal number and pads it to 32 bytes.
+func ParseSlot(s string) (common.Hash, error) {
+	if h, ok := strings.CutPrefix(s, "0x"); ok {
+		if len(h)%2 == 1 {
+			h = "0" + h
+		}
+		b, err := hex.DecodeString(h)
+		if err != nil || len(b) > 32 {
+			return common.Hash{}, fmt.Errorf("the slot %s isn't valid", s)
+		}
+		return common.BytesToHash(b), nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("the slot %s isn't valid", s)
+	}
+	return common.BigToHash(n), nil
+}
+
+// VerifyAccountProof checks the account proof against the state root and the
+// storage proofs of the slots against the proven storage root, and that the
+// values returned with the proofs are the proven ones.
+func VerifyAccountProof(stateRoot common.Hash, address common.Address, slots []common.Hash, p *AccountProof) (*VerifiedAccount, error) {
+	if p.Address != address {
+		return nil, fmt.Errorf("the proof is for %s rather than %s", p.Address.Hex(), address.Hex())
+	}
+
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(address.Bytes()), proofDB(p.AccountProof))
+	if err != nil {
+		return nil, fmt.Errorf("invalid account proof: %w", err)
+	}
+
+	out := &VerifiedAccount{
+		Address:     address,
+		Balance:     (*hexutil.Big)(new(big.Int)),
+		StorageHash: types.EmptyRootHash,
+		CodeHash:    emptyCodeHash,
+	}
+
+	// An empty value proves that the account doesn't exist.
+	if len(value) > 0 {
+		var account types.StateAccount
+		if err = rlp.DecodeBytes(value, &account); err != nil {
+			return nil, fmt.Errorf("unable to decode the proven account: %w", err)
+		}
+		out.Exists = true
+		out.Nonce = account.Nonce
+		out.Balance = (*hexutil.Big)(account.Balance)
+		out.StorageHash = account.Root
+		out.CodeHash = common.BytesToHash(account.CodeHash)
+	}
+
+	var mismatches []string
+	if uint64(p.Nonce) != out.Nonce {
+		mismatches = append(mismatches, fmt.Sprintf("nonce %d rather than %d", p.Nonce, out.Nonce))
+	}
+	if p.Balance == nil || p.Balance.ToInt().Cmp(out.Balance.ToInt()) != 0 {
+		mismatches = append(mismatches, fmt.Sprintf("balance %v rather than %v", p.Balance, out.Balance))
+	}
+	if p.StorageHash != out.StorageHash && out.Exists {
+		mismatches = append(mismatches, fmt.Sprintf("storage hash %s rather than %s", p.StorageHash.Hex(), out.StorageHash.Hex()))
+	}
+	// Clients disagree on the code hash of accounts that don't exist, some
+	// return zero rather than the hash of empty code.
+	if p.CodeHash != out.CodeHash && out.Exists {
+		mismatches = append(mismatches, fmt.Sprintf("code hash %s rather than %s", p.CodeHash.Hex(), out.CodeHash.Hex()))
+	}
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("the response has %s", strings.Join(mismatches, ", "))
+	}
+
+	if len(p.StorageProof) != len(slots) {
+		return nil, fmt.Errorf("expected %d storage proofs, got %d", len(slots), len(p.StorageProof))
+	}
+	for i, sp := range p.StorageProof {
+		slot := slots[i]
+		if key, err := ParseSlot(sp.Key); err != nil || key != slot {
+			return nil, fmt.Errorf("storage proof %d is for %s rather than %s", i, sp.Key, slot.Hex())
+		}
+
+		// Every slot of an empty storage trie is zero, so there's nothing to
+		// prove.
+		var value []byte
+		if out.StorageHash != types.EmptyRootHash {
+			if value, err = trie.VerifyProof(out.StorageHash, crypto.Keccak256(slot.Bytes()), proofDB(sp.Proof)); err != nil {
+				return nil, fmt.Errorf("invalid storage proof of slot %s: %w", slot.Hex(), err)
+			}
+		}
+
+		// The values are stored RLP encoded with the leading zeros trimmed,
+		// and an empty value proves that the slot is zero.
+		var proven []byte
+		if len(value) > 0 {
+			if err = rlp.DecodeBytes(value, &proven); err != nil {
+				return nil, fmt.Errorf("unable to decode the proven value of slot %s: %w", slot.Hex(), err)
+			}
+		}
+		returned := new(big.Int)
+		if sp.Value != nil {
+			returned = sp.Value.ToInt()
+		}
+		if !bytes.Equal(returned.Bytes(), new(big.Int).SetBytes(proven).Bytes()) {
+			return nil, fmt.Errorf("the response has %s for slot %s rather than 0x%x", returned.String(), slot.Hex(), proven)
+		}
+		out.Storage = append(out.Storage, VerifiedSlot{Slot: slot, Value: common.BytesToHash(proven)})
+	}
+	return out, nil
+}
+
+// proofDB returns the proof nodes keyed by their hash, which is how
+// trie.VerifyProof looks them up.
+func proofDB(nodes []hexutil.Bytes) *memorydb.Database {
+	db := memorydb.New()
+	for _, n := range nodes {
+		_ = db.Put(crypto.Keccak256(n), n)
+	}
+	return db
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestParseSlot(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    common.Hash
+		wantErr bool
+	}{
+		{value: "0", want: common.Hash{}},
+		{value: "0x0", want: common.Hash{}},
+		{value: "10", want: common.HexToHash("0x0a")},
+		{value: "0xa", want: common.HexToHash("0x0a")},
+		{value: "0x000a", want: common.HexToHash("0x0a")},
+		{value: "0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563", want: common.HexToHash("0x290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563")},
+		{value: "115792089237316195423570985008687907853269984665640564039457584007913129639935", want: common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+		{value: "115792089237316195423570985008687907853269984665640564039457584007913129639936", wantErr: true},
+		{value: "0x01290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "0xg", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		slot, err := ParseSlot(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("expected an error for %s", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unable to parse %s: %v", tc.value, err)
+			continue
+		}
+		if slot != tc.want {
+			t.Errorf("expected %s for %s, got %s", tc.want.Hex(), tc.value, slot.Hex())
+		}
+	}
+}
+
+// testAccountProof returns a state root and the eth_getProof response of
+// go-ethereum for the address and slots in it.
+func testAccountProof(t *testing.T, address common.Address, slots []common.Hash) (common.Hash, *AccountProof) {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	s, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	s.SetNonce(contract, 3)
+	s.SetBalance(contract, big.NewInt(1000))
+	s.SetCode(contract, []byte{0x60, 0x00, 0x54})
+	s.SetState(contract, common.HexToHash("0x00"), common.HexToHash("0x2a"))
+	s.SetState(contract, common.HexToHash("0x01"), common.HexToHash("0xff00000000000000000000000000000000000000000000000000000000000001"))
+	s.SetBalance(common.HexToAddress("0x2000000000000000000000000000000000000002"), big.NewInt(7))
+	root, err := s.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = state.New(root, db, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Like some clients, the code and storage hashes of accounts that don't
+	// exist are zero.
+	p := &AccountProof{
+		Address: address,
+		Balance: (*hexutil.Big)(s.GetBalance(address)),
+		Nonce:   hexutil.Uint64(s.GetNonce(address)),
+	}
+	if s.Exist(address) {
+		p.CodeHash = s.GetCodeHash(address)
+		p.StorageHash = s.StorageTrie(address).Hash()
+	}
+	nodes, err := s.GetProof(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		p.AccountProof = append(p.AccountProof, n)
+	}
+	for _, slot := range slots {
+		sp := struct {
+			Key   string          `json:"key"`
+			Value *hexutil.Big    `json:"value"`
+			Proof []hexutil.Bytes `json:"proof"`
+		}{Key: slot.Hex(), Value: (*hexutil.Big)(s.GetState(address, slot).Big())}
+		if s.Exist(address) {
+			nodes, err := s.GetStorageProof(address, slot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range nodes {
+				sp.Proof = append(sp.Proof, n)
+			}
+		}
+		p.StorageProof = append(p.StorageProof, sp)
+	}
+	return root, p
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	contract := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	missing := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	slots := []common.Hash{common.HexToHash("0x00"), common.HexToHash("0x01"), common.HexToHash("0x02")}
+
+	tests := []struct {
+		name    string
+		address common.Address
+		slots   []common.Hash
+		tamper  func(p *AccountProof)
+		wantErr string
+	}{
+		{name: "contract", address: contract, slots: slots},
+		{name: "missing account", address: missing, slots: slots[:1]},
+		{
+			name:    "wrong balance",
+			address: contract,
+			tamper:  func(p *AccountProof) { p.Balance = (*hexutil.Big)(big.NewInt(1001)) },
+			wantErr: "balance",
+		},
+		{
+			name:    "wrong slot value",
+			address: contract,
+			slots:   slots[:1],
+			tamper:  func(p *AccountProof) { p.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(43)) },
+			wantErr: "slot",
+		},
+		{
+			name:    "proof of another address",
+			address: contract,
+			tamper:  func(p *AccountProof) { p.Address = missing },
+			wantErr: "rather than",
+		},
+		{
+			name:    "tampered account proof",
+			address: contract,
+			tamper: func(p *AccountProof) {
+				last := p.AccountProof[len(p.AccountProof)-1]
+				last[len(last)-1] ^= 0xff
+			},
+			wantErr: "invalid account proof",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, p := testAccountProof(t, tc.address, tc.slots)
+			if tc.tamper != nil {
+				tc.tamper(p)
+			}
+			account, err := VerifyAccountProof(root, tc.address, tc.slots, p)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error about the %s, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if account.Exists != (tc.address == contract) {
+				t.Errorf("expected the account to exist: %t, got %t", tc.address == contract, account.Exists)
+			}
+			if account.Exists {
+				if account.Nonce != 3 || account.Balance.ToInt().Int64() != 1000 {
+					t.Errorf("expected the nonce 3 and balance 1000, got %d and %s", account.Nonce, account.Balance)
+				}
+				if want := crypto.Keccak256Hash([]byte{0x60, 0x00, 0x54}); account.CodeHash != want {
+					t.Errorf("expected the code hash %s, got %s", want.Hex(), account.CodeHash.Hex())
+				}
+			} else if account.CodeHash != emptyCodeHash {
+				t.Errorf("expected the empty code hash for a missing account, got %s", account.CodeHash.Hex())
+			}
+			for i, s := range account.Storage {
+				if want := p.StorageProof[i].Value.ToInt(); s.Value.Big().Cmp(want) != 0 {
+					t.Errorf("expected slot %s to be %s, got %s", s.Slot.Hex(), want, s.Value.Hex())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcIdleConnsPerHost is how many idle connections to a host are kept for
+// reuse when the connections per host aren't limited. The default of the Go
+// transport is 2, so any more concurrent requests open and close a connection
+// every time.
+const rpcIdleConnsPerHost = 256
+
+// RPCTransport configures the HTTP transport of the RPC clients. It's set by
+// the --rpc-* flags of the root command and applies to every command that
+// dials with DialRPC.
+type RPCTransport struct {
+	// MaxConnsPerHost limits the connections to a host, 0 doesn't limit them.
+	MaxConnsPerHost int
+	// HTTP2 negotiates HTTP/2 with https endpoints that support it, which
+	// multiplexes the requests over a single connection.
+	HTTP2 bool
+	// KeepAlive is how long idle connections are kept for reuse, 0 opens a
+	// new connection for every request.
+	KeepAlive time.Duration
+	// TLSSessionCache is how many TLS sessions are cached to resume them
+	// without a full handshake, 0 disables session resumption.
+	TLSSessionCache int
+	// Sticky keeps the cookies of the endpoint, so that load balancers with
+	// cookie based session affinity send all the requests to the same node.
+	Sticky bool
+}
+
+// RPCConnStats counts the HTTP requests of the RPC clients and the
+// connections they were sent on.
+type RPCConnStats struct {
+	Requests      uint64 `json:"requests"`
+	Failed        uint64 `json:"failed"`
+	HTTP2Requests uint64 `json:"http2Requests"`
+	NewConns      uint64 `json:"newConns"`
+	ReusedConns   uint64 `json:"reusedConns"`
+	TLSHandshakes uint64 `json:"tlsHandshakes"`
+	TLSResumed    uint64 `json:"tlsResumed"`
+}
+
+var (
+	rpcClientMu sync.Mutex
+	rpcClient   = newRPCHTTPClient(DefaultRPCTransport())
+
+	rpcStats struct {
+		requests, failed, http2, newConns, reusedConns, handshakes, resumed atomic.Uint64
+	}
+)
+
+// DefaultRPCTransport returns the transport settings of the --rpc-* flags.
+func DefaultRPCTransport() RPCTransport {
+	return RPCTransport{
+		HTTP2:           true,
+		KeepAlive:       90 * time.Second,
+		TLSSessionCache: 64,
+	}
+}
+
+// SetRPCTransport sets the transport of the RPC clients that are dialed from
+// now on.
+func SetRPCTransport(t RPCTransport) error {
+	if t.MaxConnsPerHost < 0 {
+		return errors.New("the maximum number of connections per host can't be negative")
+	}
+	if t.KeepAlive < 0 {
+		return errors.New("the keep-alive duration can't be negative")
+	}
+	if t.TLSSessionCache < 0 {
+		return errors.New("the TLS session cache size can't be negative")
+	}
+	rpcClientMu.Lock()
+	defer rpcClientMu.Unlock()
+	rpcClient = newRPCHTTPClient(t)
+	return nil
+}
+
+// DialRPC connects to the RPC endpoint. HTTP endpoints share the connections
+// of the transport set with SetRPCTransport, and other endpoints, like
+// websockets and IPC, are dialed as usual.
+func DialRPC(ctx context.Context, rawurl string) (*ethrpc.Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ethrpc.DialContext(ctx, rawurl)
+	}
+	rpcClientMu.Lock()
+	client := rpcClient
+	rpcClientMu.Unlock()
+	return ethrpc.DialHTTPWithClient(rawurl, client)
+}
+
+// RPCTransportStats returns the connection stats of the RPC clients so far.
+func RPCTransportStats() RPCConnStats {
+	return RPCConnStats{
+		Requests:      rpcStats.requests.Load(),
+		Failed:        rpcStats.failed.Load(),
+		HTTP2Requests: rpcStats.http2.Load(),
+		NewConns:      rpcStats.newConns.Load(),
+		ReusedConns:   rpcStats.reusedConns.Load(),
+		TLSHandshakes: rpcStats.handshakes.Load(),
+		TLSResumed:    rpcStats.resumed.Load(),
+	}
+}
+
+func newRPCHTTPClient(t RPCTransport) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: t.KeepAlive}
+	if t.KeepAlive == 0 {
+		dialer.KeepAlive = -1
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     t.HTTP2,
+		MaxConnsPerHost:       t.MaxConnsPerHost,
+		MaxIdleConnsPerHost:   rpcIdleConnsPerHost,
+		IdleConnTimeout:       t.KeepAlive,
+		DisableKeepAlives:     t.KeepAlive == 0,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	if t.MaxConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = t.MaxConnsPerHost
+	}
+	if !t.HTTP2 {
+		// A non-nil empty map disables the HTTP/2 upgrade of the transport.
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if t.TLSSessionCache > 0 {
+		transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(t.TLSSessionCache)
+	}
+
+	client := &http.Client{Transport: statsTransport{transport}}
+	if t.Sticky {
+		// The jar can't fail without options.
+		client.Jar, _ = cookiejar.New(nil)
+	}
+	return client
+}
+
+// statsTransport counts the requests and the connections they're sent on.
+type statsTransport struct {
+	base http.RoundTripper
+}
+
+func (s statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				rpcStats.reusedConns.Add(1)
+			} else {
+				rpcStats.newConns.Add(1)
+			}
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			rpcStats.handshakes.Add(1)
+			if state.DidResume {
+				rpcStats.resumed.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	rpcStats.requests.Add(1)
+	resp, err := s.base.RoundTrip(req)
+	if err != nil {
+		rpcStats.failed.Add(1)
+		return nil, err
+	}
+	if resp.ProtoMajor == 2 {
+		rpcStats.http2.Add(1)
+	}
+	return resp, nil
+}
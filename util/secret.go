@@ -0,0 +1,280 @@
+package util
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+)
+
+// The environment variables that unlock encrypted secrets. They're prompted
+// for on the terminal if they aren't set.
+const (
+	EnvAgeIdentity      = "POLYCLI_AGE_IDENTITY"
+	EnvAgePassphrase    = "POLYCLI_AGE_PASSPHRASE"
+	EnvKeystorePassword = "POLYCLI_KEYSTORE_PASSWORD"
+)
+
+// SecretHelp describes the secret references for flag usages.
+const SecretHelp = "Can be a env:NAME, file:PATH, age:PATH, keystore:PATH, or keychain:SERVICE/ACCOUNT reference, which should be preferred since plaintext values stay visible in the process list with only a warning"
+
+var (
+	secrets   []string
+	secretsMu sync.RWMutex
+)
+
+// ResolveSecret returns the secret the value refers to, so that private keys
+// and mnemonics don't have to be passed in plaintext:
+//
+//	env:NAME                   the environment variable
+//	file:PATH                  the contents of the file
+//	age:PATH                   the age encrypted file
+//	keystore:PATH              the hex private key of the geth keystore file
+//	keychain:SERVICE/ACCOUNT   the macOS keychain or Linux secret service item
+//
+// Other values are used as is, with a warning if they were passed on the
+// command line, since they stay visible in the process list. Every secret is
+// redacted from the logs.
+func ResolveSecret(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	scheme, ref, _ := strings.Cut(value, ":")
+	var secret string
+	var err error
+	switch scheme {
+	case "env":
+		var ok bool
+		if secret, ok = os.LookupEnv(ref); !ok {
+			err = fmt.Errorf("environment variable %s isn't set", ref)
+		}
+	case "file":
+		secret, err = readSecretFile(ref)
+	case "age":
+		secret, err = readAgeFile(ref)
+	case "keystore":
+		secret, err = readKeystore(ref)
+	case "keychain":
+		secret, err = readKeychain(ref)
+	default:
+		secret = value
+		if inArgs(value) {
+			log.Warn().Msg("A secret was passed in plaintext on the command line, where it's visible in the process list, consider using an env:, file:, or encrypted reference instead")
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the %s secret: %w", scheme, err)
+	}
+
+	registerSecret(secret)
+	return secret, nil
+}
+
+// ResolvePrivateKey resolves the value with ResolveSecret and parses it as a
+// hex encoded private key.
+func ResolvePrivateKey(value string) (*ecdsa.PrivateKey, error) {
+	secret, err := ResolveSecret(value)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(secret, "0x"))
+	if err != nil {
+		return nil, errors.New("the private key isn't valid hex")
+	}
+	return key, nil
+}
+
+func readSecretFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readAgeFile decrypts the file with the identities in the file set by
+// POLYCLI_AGE_IDENTITY, or the passphrase otherwise. Armored files are
+// supported.
+func readAgeFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	var identities []age.Identity
+	if identityFile := os.Getenv(EnvAgeIdentity); identityFile != "" {
+		f, err := os.Open(identityFile)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if identities, err = age.ParseIdentities(f); err != nil {
+			return "", err
+		}
+	} else {
+		passphrase, err := readPassword(EnvAgePassphrase, fmt.Sprintf("Passphrase for %s: ", file))
+		if err != nil {
+			return "", err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return "", err
+		}
+		identities = append(identities, identity)
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		r = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+	dr, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := io.ReadAll(dr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(plaintext), "\r\n"), nil
+}
+
+func readKeystore(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	password, err := readPassword(EnvKeystorePassword, fmt.Sprintf("Password for %s: ", file))
+	if err != nil {
+		return "", err
+	}
+	key, err := keystore.DecryptKey(data, password)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", crypto.FromECDSA(key.PrivateKey)), nil
+}
+
+// readKeychain looks up the item with the security tool on macOS and with
+// secret-tool, which uses the secret service, on Linux.
+func readKeychain(ref string) (string, error) {
+	service, account, _ := strings.Cut(ref, "/")
+	if service == "" {
+		return "", errors.New("a keychain service is required")
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		args := []string{"find-generic-password", "-s", service, "-w"}
+		if account != "" {
+			args = append(args, "-a", account)
+		}
+		cmd = exec.Command("security", args...)
+	case "linux":
+		args := []string{"lookup", "service", service}
+		if account != "" {
+			args = append(args, "account", account)
+		}
+		cmd = exec.Command("secret-tool", args...)
+	default:
+		return "", fmt.Errorf("keychains aren't supported on %s", runtime.GOOS)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", cmd.Args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s failed: %w", cmd.Args[0], err)
+	}
+	secret := strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("no keychain item for %s", ref)
+	}
+	return secret, nil
+}
+
+// readPassword reads the password from the environment variable, or prompts
+// for it if stdin is a terminal.
+func readPassword(env, prompt string) (string, error) {
+	if password, ok := os.LookupEnv(env); ok {
+		return password, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("set %s or run in a terminal to enter it", env)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// inArgs reports whether the secret is the value of a process argument.
+func inArgs(secret string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == secret || strings.HasSuffix(arg, "="+secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func registerSecret(secret string) {
+	if len(secret) < 8 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, secret)
+}
+
+// Redact replaces the resolved secrets in the string.
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "[redacted]")
+	}
+	return s
+}
+
+type redactWriter struct {
+	w io.Writer
+}
+
+// RedactWriter returns a writer that redacts the resolved secrets, which is
+// used for the logs. Every write has to be complete, like a log line, so that
+// secrets aren't split across writes.
+func RedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	secretsMu.RLock()
+	n := len(secrets)
+	secretsMu.RUnlock()
+	if n == 0 {
+		return r.w.Write(p)
+	}
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
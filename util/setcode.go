@@ -0,0 +1,182 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// SetCodeTxType is the EIP-7702 transaction type.
+	SetCodeTxType = 0x04
+
+	// setCodeAuthorizationMagic prefixes the signed authorization payload.
+	setCodeAuthorizationMagic = 0x05
+)
+
+// SetCodeAuthorization allows the signing account to have its code delegated
+// to Address, as defined by EIP-7702. A chain ID of zero is valid on every
+// chain.
+type SetCodeAuthorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	V       uint8
+	R       *big.Int
+	S       *big.Int
+}
+
+// SignSetCodeAuthorization signs an authorization delegating the code of the
+// key's account to the address. The nonce is the account's nonce at the time
+// the authorization is processed.
+func SignSetCodeAuthorization(key *ecdsa.PrivateKey, chainID *big.Int, address common.Address, nonce uint64) (SetCodeAuthorization, error) {
+	auth := SetCodeAuthorization{ChainID: chainID, Address: address, Nonce: nonce}
+	hash, err := auth.SigHash()
+	if err != nil {
+		return auth, err
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return auth, err
+	}
+
+	auth.R = new(big.Int).SetBytes(sig[:32])
+	auth.S = new(big.Int).SetBytes(sig[32:64])
+	auth.V = sig[64]
+	return auth, nil
+}
+
+// SigHash returns the hash that the authority signs, which is
+// keccak256(0x05 || rlp([chain_id, address, nonce])).
+func (auth SetCodeAuthorization) SigHash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes([]interface{}{auth.ChainID, auth.Address, auth.Nonce})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{setCodeAuthorizationMagic}, payload), nil
+}
+
+// Authority recovers the address of the account that signed the
+// authorization.
+func (auth SetCodeAuthorization) Authority() (common.Address, error) {
+	hash, err := auth.SigHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return RecoverTxSigner(hash, new(big.Int).SetUint64(uint64(auth.V)), auth.R, auth.S)
+}
+
+// SetCodeTx is an EIP-7702 transaction. The go-ethereum version used by polycli
+// doesn't know about this type, so it's encoded and signed here and has to be
+// sent using eth_sendRawTransaction.
+type SetCodeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList ethtypes.AccessList
+	AuthList   []SetCodeAuthorization
+
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+func (tx *SetCodeTx) fields() []interface{} {
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	accessList := tx.AccessList
+	if accessList == nil {
+		accessList = ethtypes.AccessList{}
+	}
+	authList := tx.AuthList
+	if authList == nil {
+		authList = []SetCodeAuthorization{}
+	}
+	return []interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, value, tx.Data, accessList, authList}
+}
+
+func (tx *SetCodeTx) encode(fields []interface{}) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{SetCodeTxType}, payload...), nil
+}
+
+// Sign signs the transaction with the key of the sender.
+func (tx *SetCodeTx) Sign(key *ecdsa.PrivateKey) error {
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return err
+	}
+	tx.SetSignature(sig)
+	return nil
+}
+
+// SigningHash returns the hash the sender signs.
+func (tx *SetCodeTx) SigningHash() (common.Hash, error) {
+	unsigned, err := tx.encode(tx.fields())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(unsigned), nil
+}
+
+// SetSignature sets the 65 byte [R || S || V] signature of the sender.
+func (tx *SetCodeTx) SetSignature(sig []byte) {
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = new(big.Int).SetUint64(uint64(sig[64]))
+}
+
+// UnmarshalBinary decodes the typed transaction envelope of a signed
+// transaction.
+func (tx *SetCodeTx) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != SetCodeTxType {
+		return errors.New("the transaction isn't a set code transaction")
+	}
+	return rlp.DecodeBytes(data[1:], tx)
+}
+
+// Sender recovers the address that signed the transaction.
+func (tx *SetCodeTx) Sender() (common.Address, error) {
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return RecoverTxSigner(hash, tx.V, tx.R, tx.S)
+}
+
+// MarshalBinary returns the typed transaction envelope of a signed transaction.
+func (tx *SetCodeTx) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, fmt.Errorf("the set code transaction isn't signed")
+	}
+	return tx.encode(append(tx.fields(), tx.V, tx.R, tx.S))
+}
+
+// Hash returns the hash of a signed transaction.
+func (tx *SetCodeTx) Hash() (common.Hash, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(raw), nil
+}
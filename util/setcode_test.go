@@ -0,0 +1,97 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The vectors were signed by go-ethereum's types.SignSetCode, whose signatures
+// recover from the hashes to the address of the key.
+func TestSetCodeAuthorizationSigHash(t *testing.T) {
+	key, err := crypto.HexToECDSA("42b6e34dc21598a807dc19d7784c71b2a7a01f6480dc6f58258f78e539f1a1fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authority := common.HexToAddress("0x85dA99c8a7C2C95964c8EfD687E95E632Fc533D6")
+
+	tests := []struct {
+		name    string
+		chainID int64
+		address string
+		nonce   uint64
+		hash    string
+		v       uint8
+		r       string
+		s       string
+	}{
+		{
+			name:    "any chain",
+			chainID: 0,
+			address: "0x0000000000000000000000000000000000000000",
+			nonce:   0,
+			hash:    "0x47a11722d19dd800b7c679d90322b58f77db7465a9e9d3442aadfcade4121b7d",
+			v:       1,
+			r:       "0x8906628377223e5e90cbad0d4012a14c5ad6db2a18e68f5dddc4cea4c95262fa",
+			s:       "0x638169aaf3970f9b9330e8987ec9240f568fe74a5d5be4d5f6587a25e9e875cf",
+		},
+		{
+			name:    "ethereum",
+			chainID: 1,
+			address: "0x000000000000000000000000000000000000aaaa",
+			nonce:   1,
+			hash:    "0xec4af342bb9197e727111d6a9e0a68881bd368a85a04053fe2606f4c6610dfc2",
+			v:       0,
+			r:       "0x1494708e83f2586be8cb9bbe3d4bdc5591b946ae19ffbf41390db42fb31034e2",
+			s:       "0x707e0913010636c28e1ec7bedc0256966ca90dc0fd6cfeaddfcf46b16982a87a",
+		},
+		{
+			name:    "polygon",
+			chainID: 137,
+			address: "0x1000000000000000000000000000000000000001",
+			nonce:   42,
+			hash:    "0x1432851400162c116d9600dec3273169540f32770a3f2d928f13fb20e9650fd1",
+			v:       0,
+			r:       "0x2e5203cafa179cd34337e0fa87f24cb93343e01fa28557145942ca552001a3c7",
+			s:       "0x66ca3ce7d9b26897b147a3ae34da626caaa487dc1821cae4f903d33df5a518f4",
+		},
+		{
+			name:    "large nonce",
+			chainID: 80002,
+			address: "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			nonce:   1 << 40,
+			hash:    "0xe4a44369eee40ccb850df9d8604f7a32db6c275c69bee314f1cc53e851318402",
+			v:       1,
+			r:       "0x741499f21ff1fe1a8b401630adead89154ef1ab1648b2be5fb26215064b84c0a",
+			s:       "0x43035e729fb8fca089bbeb259429ae4559df2936f52e329050770213f7e3f459",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth, err := SignSetCodeAuthorization(key, big.NewInt(tc.chainID), common.HexToAddress(tc.address), tc.nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hash, err := auth.SigHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hash.Hex() != tc.hash {
+				t.Errorf("expected the hash %s, got %s", tc.hash, hash.Hex())
+			}
+			if auth.V != tc.v || common.BigToHash(auth.R).Hex() != tc.r || common.BigToHash(auth.S).Hex() != tc.s {
+				t.Errorf("expected the signature %d %s %s, got %d %s %s", tc.v, tc.r, tc.s, auth.V, common.BigToHash(auth.R).Hex(), common.BigToHash(auth.S).Hex())
+			}
+			got, err := auth.Authority()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != authority {
+				t.Errorf("expected the authority %s, got %s", authority.Hex(), got.Hex())
+			}
+		})
+	}
+}
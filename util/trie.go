@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// TrieEntry is a key and value of a Merkle-Patricia trie.
+type TrieEntry struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
+
+// rawList is a list of consensus encoded items, to compute the trie root of
+// transactions and receipts.
+type rawList [][]byte
+
+func (l rawList) Len() int { return len(l) }
+
+func (l rawList) EncodeIndex(i int, w *bytes.Buffer) {
+	w.Write(l[i])
+}
+
+// DeriveListRoot returns the root of the trie of the consensus encoded items
+// keyed by their RLP encoded index, which is how the transactions and
+// receipts roots of headers are computed. Typed items are the type followed
+// by the payload, not wrapped in an RLP string.
+func DeriveListRoot(items [][]byte) common.Hash {
+	return types.DeriveSha(rawList(items), trie.NewStackTrie(nil))
+}
+
+// TrieRoot returns the root of the trie of the entries. The keys are hashed
+// with keccak256 first if secure is set, like they are in the state and
+// storage tries. Entries with empty values are left out.
+func TrieRoot(entries []TrieEntry, secure bool) (common.Hash, error) {
+	t := trie.NewEmpty(trie.NewDatabase(memorydb.New()))
+	for _, e := range entries {
+		key := []byte(e.Key)
+		if secure {
+			key = crypto.Keccak256(key)
+		}
+		if err := t.TryUpdate(key, e.Value); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return t.Hash(), nil
+}
+
+// StateRoot returns the root of the state trie of the accounts, for example
+// the alloc of a genesis file.
+func StateRoot(alloc core.GenesisAlloc) (common.Hash, error) {
+	entries := make([]TrieEntry, 0, len(alloc))
+	for address, account := range alloc {
+		storageRoot, err := StorageRoot(account.Storage)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("unable to compute the storage root of %s: %w", address.Hex(), err)
+		}
+		balance := account.Balance
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		value, err := rlp.EncodeToBytes(&types.StateAccount{
+			Nonce:    account.Nonce,
+			Balance:  balance,
+			Root:     storageRoot,
+			CodeHash: crypto.Keccak256(account.Code),
+		})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		entries = append(entries, TrieEntry{Key: address.Bytes(), Value: value})
+	}
+	return TrieRoot(entries, true)
+}
+
+// StorageRoot returns the root of the storage trie of the slots. The values
+// are stored RLP encoded with the leading zeros trimmed, and zero values
+// aren't stored at all.
+func StorageRoot(storage map[common.Hash]common.Hash) (common.Hash, error) {
+	entries := make([]TrieEntry, 0, len(storage))
+	for slot, value := range storage {
+		trimmed := common.TrimLeftZeroes(value.Bytes())
+		if len(trimmed) == 0 {
+			continue
+		}
+		encoded, err := rlp.EncodeToBytes(trimmed)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		entries = append(entries, TrieEntry{Key: slot.Bytes(), Value: encoded})
+	}
+	return TrieRoot(entries, true)
+}
+
+// VerifyProof checks the proof of the key against the root and returns the
+// proven value, which is empty if the proof shows that the key isn't in the
+// trie. The key is hashed with keccak256 first if secure is set.
+func VerifyProof(root common.Hash, key []byte, nodes []hexutil.Bytes, secure bool) ([]byte, error) {
+	if secure {
+		key = crypto.Keccak256(key)
+	}
+	return trie.VerifyProof(root, key, proofDB(nodes))
+}
@@ -0,0 +1,170 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func stringEntries(kv ...string) []TrieEntry {
+	var entries []TrieEntry
+	for i := 0; i < len(kv); i += 2 {
+		entries = append(entries, TrieEntry{Key: []byte(kv[i]), Value: []byte(kv[i+1])})
+	}
+	return entries
+}
+
+func TestTrieRoot(t *testing.T) {
+	// The roots are the ones of the trie tests of go-ethereum.
+	tests := []struct {
+		name    string
+		entries []TrieEntry
+		want    common.Hash
+	}{
+		{
+			name: "empty",
+			want: types.EmptyRootHash,
+		},
+		{
+			name:    "doe dog dogglesworth",
+			entries: stringEntries("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat"),
+			want:    common.HexToHash("0x8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3"),
+		},
+		{
+			name:    "empty value left out",
+			entries: stringEntries("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat", "horse", ""),
+			want:    common.HexToHash("0x8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3"),
+		},
+		{
+			name:    "single long value",
+			entries: stringEntries("A", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+			want:    common.HexToHash("0xd23786fb4a010da3ce639d66d5e904a11dbc02746d1ce25029e53290cabf28ab"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := TrieRoot(tc.entries, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root != tc.want {
+				t.Errorf("expected the root %s, got %s", tc.want.Hex(), root.Hex())
+			}
+		})
+	}
+}
+
+func TestStateRoot(t *testing.T) {
+	mainnet, err := StateRoot(core.DefaultGenesisBlock().Alloc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The state root of the Ethereum mainnet genesis block.
+	if want := common.HexToHash("0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544"); mainnet != want {
+		t.Errorf("expected the mainnet genesis state root %s, got %s", want.Hex(), mainnet.Hex())
+	}
+
+	// An alloc with code and storage, including a zero slot that isn't
+	// stored, against the state root that go-ethereum computes for it.
+	alloc := core.GenesisAlloc{
+		common.HexToAddress("0x1000000000000000000000000000000000000001"): {
+			Balance: big.NewInt(1),
+			Nonce:   1,
+			Code:    []byte{0x60, 0x00, 0x54},
+			Storage: map[common.Hash]common.Hash{
+				common.HexToHash("0x00"): common.HexToHash("0x2a"),
+				common.HexToHash("0x01"): common.HexToHash("0xff00000000000000000000000000000000000000000000000000000000000001"),
+				common.HexToHash("0x02"): {},
+			},
+		},
+		common.HexToAddress("0x2000000000000000000000000000000000000002"): {
+			Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+		},
+	}
+	root, err := StateRoot(alloc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&core.Genesis{Alloc: alloc}).ToBlock().Root(); root != want {
+		t.Errorf("expected the state root %s, got %s", want.Hex(), root.Hex())
+	}
+}
+
+// proveEntries builds the trie of the entries and returns the proof of the
+// key with its root.
+func proveEntries(t *testing.T, entries []TrieEntry, key []byte) (common.Hash, []hexutil.Bytes) {
+	tr := trie.NewEmpty(trie.NewDatabase(memorydb.New()))
+	for _, e := range entries {
+		if err := tr.TryUpdate(e.Key, e.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db := memorydb.New()
+	if err := tr.Prove(key, 0, db); err != nil {
+		t.Fatal(err)
+	}
+	var nodes []hexutil.Bytes
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		nodes = append(nodes, common.CopyBytes(it.Value()))
+	}
+	return tr.Hash(), nodes
+}
+
+func TestVerifyProof(t *testing.T) {
+	entries := stringEntries("doe", "reindeer", "dog", "puppy", "dogglesworth", "cat")
+	var secureEntries []TrieEntry
+	for _, e := range entries {
+		secureEntries = append(secureEntries, TrieEntry{Key: crypto.Keccak256(e.Key), Value: e.Value})
+	}
+
+	tests := []struct {
+		name    string
+		entries []TrieEntry
+		prove   []byte
+		key     []byte
+		secure  bool
+		tamper  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "value", entries: entries, prove: []byte("dog"), key: []byte("dog"), want: "puppy"},
+		{name: "value below a branch", entries: entries, prove: []byte("dogglesworth"), key: []byte("dogglesworth"), want: "cat"},
+		{name: "absent key", entries: entries, prove: []byte("dogs"), key: []byte("dogs")},
+		{name: "secure", entries: secureEntries, prove: crypto.Keccak256([]byte("doe")), key: []byte("doe"), secure: true, want: "reindeer"},
+		{name: "proof of another key", entries: entries, prove: []byte("doe"), key: []byte("dogglesworth"), wantErr: true},
+		{name: "tampered node", entries: entries, prove: []byte("dog"), key: []byte("dog"), tamper: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, nodes := proveEntries(t, tc.entries, tc.prove)
+			if tc.tamper {
+				last := nodes[len(nodes)-1]
+				last[len(last)-1] ^= 0xff
+			}
+			value, err := VerifyProof(root, tc.key, nodes, tc.secure)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got the value %q", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(value) != tc.want {
+				t.Errorf("expected the value %q, got %q", tc.want, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
+	"golang.org/x/term"
+)
+
+// InteractiveHelp describes the --interactive flags.
+const InteractiveHelp = "Walk through the main parameters interactively and print the equivalent command line before running it"
+
+// shellSafe matches the values that don't need quoting in a shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// WizardStep is a value the wizard asks for, which is either a flag of the
+// command or, if Flag is empty, its next positional argument.
+type WizardStep struct {
+	Flag     string
+	Prompt   string
+	Choices  []string
+	Required bool
+	// Secret values are read without echo and left out of the printed
+	// command line, unless they're a reference like env:NAME.
+	Secret   bool
+	Validate func(string) error
+	// Skip skips the step if it returns true, e.g. for values that an
+	// earlier answer already provides.
+	Skip func() bool
+}
+
+// RunWizard asks for the values of the steps on stderr, sets the flags of the
+// command, prints the equivalent command line, and asks whether to run it.
+// It returns the positional arguments, with the ones that were asked for
+// appended to args.
+func RunWizard(cmd *cobra.Command, args []string, steps []WizardStep) ([]string, error) {
+	in := bufio.NewReader(os.Stdin)
+	out := os.Stderr
+	fmt.Fprintf(out, "Answer the questions below, or press enter to keep the value in brackets.\n\n")
+
+	var secrets []string
+	for _, step := range steps {
+		if step.Skip != nil && step.Skip() {
+			continue
+		}
+		var f *pflag.Flag
+		def := ""
+		if step.Flag != "" {
+			if f = cmd.Flags().Lookup(step.Flag); f == nil {
+				return nil, fmt.Errorf("unknown flag --%s", step.Flag)
+			}
+			if def = f.Value.String(); step.Secret && def != "" {
+				def = "unchanged"
+			}
+		} else if len(args) > 0 {
+			// The argument was already given.
+			continue
+		}
+
+		for {
+			prompt := step.Prompt
+			if len(step.Choices) > 0 {
+				prompt += " (" + strings.Join(step.Choices, "|") + ")"
+			}
+			if def != "" {
+				prompt += " [" + def + "]"
+			}
+			fmt.Fprint(out, prompt+": ")
+
+			value, err := readWizardLine(in, step.Secret)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" && (def != "" || !step.Required) {
+				break
+			}
+
+			if err = validateWizardValue(step, value); err == nil && f != nil {
+				if err = cmd.Flags().Set(step.Flag, value); err == nil && step.Secret {
+					secrets = append(secrets, step.Flag)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(out, "  %v\n", err)
+				continue
+			}
+			if f == nil {
+				args = append(args, value)
+			}
+			break
+		}
+	}
+
+	fmt.Fprintf(out, "\nThe equivalent command line is:\n\n  %s\n\n", commandLine(cmd, args, secrets))
+	fmt.Fprint(out, "Run it now? [Y/n]: ")
+	answer, err := readWizardLine(in, false)
+	if err != nil {
+		return nil, err
+	}
+	if answer != "" && !strings.HasPrefix(strings.ToLower(answer), "y") {
+		return nil, errors.New("the command wasn't run")
+	}
+	return args, nil
+}
+
+func validateWizardValue(step WizardStep, value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+	if len(step.Choices) > 0 && !slices.Contains(step.Choices, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(step.Choices, ", "))
+	}
+	if step.Validate != nil {
+		return step.Validate(value)
+	}
+	return nil
+}
+
+func readWizardLine(in *bufio.Reader, secret bool) (string, error) {
+	if fd := int(os.Stdin.Fd()); secret && term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(b)), err
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("interactive input ended: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// commandLine returns the command line with the flags that were set, except
+// --interactive and secrets that were typed in.
+func commandLine(cmd *cobra.Command, args []string, secrets []string) string {
+	parts := []string{cmd.CommandPath()}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed || f.Name == "interactive" {
+			return
+		}
+		value := f.Value.String()
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			value = strings.Join(sv.GetSlice(), ",")
+		}
+		if slices.Contains(secrets, f.Name) && !strings.Contains(value, ":") {
+			value = "<" + f.Name + ">"
+		}
+		parts = append(parts, "--"+f.Name+"="+shellQuote(value))
+	})
+	for _, a := range args {
+		parts = append(parts, shellQuote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}